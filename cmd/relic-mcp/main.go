@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/sha1n/mcp-relic-server/internal/app"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -16,6 +22,9 @@ var (
 	Build = "unknown"
 	// ProgramName is injected at build time
 	ProgramName = "relic-mcp"
+	// ReleasePublicKey is injected at build time: the base64 ed25519 key
+	// self-update verifies release checksums with
+	ReleasePublicKey = ""
 )
 
 func main() {
@@ -36,19 +45,340 @@ func Execute(version, build, programName string, args []string) error {
 		Long:    "Repository Exploration and Lookup for Indexed Code (RELIC) MCP Server",
 		Version: version,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runWithFlags(cmd.Flags(), version)
+			return runWithFlags(cmd.Flags(), app.BuildInfo{Version: version, Build: build})
 		},
 	}
 
 	rootCmd.SetVersionTemplate(`{{.Version}}
 `)
+	cobra.AddTemplateFunc("groupedFlagUsages", app.GroupedFlagUsages)
+	rootCmd.SetUsageTemplate(usageTemplate)
 
 	app.RegisterFlags(rootCmd.Flags())
+	rootCmd.AddCommand(newServeCommand(app.BuildInfo{Version: version, Build: build}))
+	rootCmd.AddCommand(newStopCommand())
+	rootCmd.AddCommand(newStatusCommand())
+	rootCmd.AddCommand(newSyncCommand())
+	rootCmd.AddCommand(newSmokeCommand(app.BuildInfo{Version: version, Build: build}))
+	rootCmd.AddCommand(newEnvCommand())
+	rootCmd.AddCommand(newTelemetryCommand())
+	rootCmd.AddCommand(newIndexChecksumCommand())
+	rootCmd.AddCommand(newMigrateBaseDirCommand())
+	rootCmd.AddCommand(newClientConfigCommand(programName))
+	rootCmd.AddCommand(newSelfUpdateCommand(app.BuildInfo{Version: version, Build: build}, programName))
 	rootCmd.SetArgs(args)
 
 	return rootCmd.Execute()
 }
 
-func runWithFlags(flags *pflag.FlagSet, version string) error {
-	return app.RunWithDeps(context.Background(), app.DefaultRunParams(), flags, version)
+func newServeCommand(info app.BuildInfo) *cobra.Command {
+	var daemon, daemonChild bool
+	var logMaxSizeMB int64
+	var opts app.DaemonOptions
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the server, optionally in the background",
+		Long: `Run the server, like running without a command.
+
+With --daemon, the SSE server is started in the background: its PID is
+written to --pid-file, its logs to --log-file, which is rotated once it
+reaches --log-max-size, and the command returns once the server has started.
+Use the stop and status commands, with the same --pid-file or base directory,
+to manage it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.LogMaxSize = logMaxSizeMB << 20
+			if daemon {
+				return app.StartDaemon(cmd.OutOrStdout(), cmd.Flags(), os.Args[1:], opts)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if daemonChild {
+				return app.RunDaemon(ctx, app.DefaultRunParams(), cmd.Flags(), info, opts)
+			}
+			return app.RunWithDeps(ctx, app.DefaultRunParams(), cmd.Flags(), info)
+		},
+	}
+	app.RegisterFlags(serveCmd.Flags())
+	serveCmd.Flags().BoolVar(&daemon, "daemon", false, "Run the SSE server in the background")
+	serveCmd.Flags().BoolVar(&daemonChild, app.DaemonChildFlag, false, "Run as the background process started by --daemon")
+	_ = serveCmd.Flags().MarkHidden(app.DaemonChildFlag)
+	addPIDFileFlag(serveCmd.Flags(), &opts)
+	serveCmd.Flags().StringVar(&opts.LogFile, "log-file", "", "Log file of the background server (default <base-dir>/"+app.DefaultLogFilename+")")
+	serveCmd.Flags().Int64Var(&logMaxSizeMB, "log-max-size", 10, "Size in MB at which the log file is rotated (0 disables rotation)")
+	serveCmd.Flags().IntVar(&opts.LogMaxBackups, "log-max-backups", 3, "Number of rotated log files to keep")
+	return serveCmd
+}
+
+func newStopCommand() *cobra.Command {
+	var opts app.DaemonOptions
+	var timeout time.Duration
+	stopCmd := &cobra.Command{
+		Use:          "stop",
+		Short:        "Stop the server started with serve --daemon",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.StopDaemon(cmd.OutOrStdout(), cmd.Flags(), opts, timeout)
+		},
+	}
+	app.RegisterFlags(stopCmd.Flags())
+	addPIDFileFlag(stopCmd.Flags(), &opts)
+	stopCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for the server to stop")
+	return stopCmd
+}
+
+func newStatusCommand() *cobra.Command {
+	var opts app.DaemonOptions
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report whether the server started with serve --daemon is running",
+		Long: `Report whether the server started with serve --daemon is running.
+
+Exits with a non-zero status if it is not.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.PrintDaemonStatus(cmd.OutOrStdout(), cmd.Flags(), opts)
+		},
+	}
+	app.RegisterFlags(statusCmd.Flags())
+	addPIDFileFlag(statusCmd.Flags(), &opts)
+	return statusCmd
+}
+
+// addPIDFileFlag registers the --pid-file flag shared by serve, stop and status.
+func addPIDFileFlag(flags *pflag.FlagSet, opts *app.DaemonOptions) {
+	flags.StringVar(&opts.PIDFile, "pid-file", "", "PID file of the background server (default <base-dir>/"+app.DefaultPIDFilename+")")
+}
+
+func newSyncCommand() *cobra.Command {
+	var opts app.SyncOptions
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Clone and index repositories without serving MCP requests",
+		Long: `Clone and index the configured repositories into the base directory.
+
+Designed for Kubernetes init containers (--once) and sidecars that share the
+base directory with servers running with --git-repos-read-only.
+
+Use --reindex to delete and rebuild the index of a repository regardless of
+its recorded sync state, e.g. after changing file filters.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return app.RunSync(ctx, app.DefaultSyncParams(), cmd.Flags(), opts)
+		},
+	}
+	app.RegisterFlags(syncCmd.Flags())
+	syncCmd.Flags().BoolVar(&opts.Once, "once", false, "Run a single sync pass and exit")
+	syncCmd.Flags().StringSliceVar(&opts.Reindex, "reindex", nil, "Rebuild the index of these repositories before syncing (e.g. github.com/org/repo)")
+	return syncCmd
+}
+
+func newSmokeCommand(info app.BuildInfo) *cobra.Command {
+	var opts app.SmokeOptions
+	smokeCmd := &cobra.Command{
+		Use:   "smoke",
+		Short: "Start the server in-process and check that search and read work",
+		Long: `Start the configured server in-process, search the first configured
+repository and read the first file found, through an MCP client as an agent
+would. Each step is reported with its timing.
+
+Exits with a non-zero status if a step fails, so it can be used as a
+container healthcheck or to verify a deployment. The search looks for the
+name of the repository unless --query is set. Like the server, it syncs the
+repositories first; with --git-repos-read-only it checks the indexes already
+in the base directory instead.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return app.RunSmoke(ctx, cmd.OutOrStdout(), cmd.Flags(), info, opts)
+		},
+	}
+	app.RegisterFlags(smokeCmd.Flags())
+	smokeCmd.Flags().StringVar(&opts.Query, "query", "", "Search query (default: the name of the first repository)")
+	return smokeCmd
+}
+
+func newEnvCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "env",
+		Short: "Print the supported environment variables and their defaults",
+		Long: `Print every environment variable read by the server, with its default
+value and the description of the matching flag, in .env format.
+
+The output can be saved as a starting point for a .env file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := pflag.NewFlagSet("env", pflag.ContinueOnError)
+			app.RegisterFlags(flags)
+			return app.PrintEnv(cmd.OutOrStdout(), flags)
+		},
+	}
+}
+
+func newTelemetryCommand() *cobra.Command {
+	var query string
+	telemetryCmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Report on the search telemetry recorded in the base directory",
+		Long: `Aggregate the search telemetry recorded with --git-repos-search-telemetry:
+searches without results, how often a search is followed by a read of one of
+its hits, and the rank of the hits read.
+
+Queries are only recorded as hashes. Use --hash to print the hash of a query
+and find it in the report.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if query != "" {
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), gitrepos.QueryHash(query))
+				return err
+			}
+			return app.PrintTelemetryReport(cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	app.RegisterFlags(telemetryCmd.Flags())
+	telemetryCmd.Flags().StringVar(&query, "hash", "", "Print the hash of a query instead of the report")
+	return telemetryCmd
+}
+
+func newIndexChecksumCommand() *cobra.Command {
+	var opts app.IndexChecksumOptions
+	checksumCmd := &cobra.Command{
+		Use:   "index-checksum",
+		Short: "Print the checksum of the indexes in the base directory",
+		Long: `Print the checksum recorded by the last sync in the base directory. It covers
+the index schema version, the snapshot refs and the indexed commit of every
+repository, so equal checksums mean equal indexes.
+
+Use --remote to print the checksum a sync would produce now, from the HEAD
+commits of the remote repositories. A CI pipeline that bakes indexes into an
+image can compare it with the checksum of the previous image and skip the
+rebuild when they match.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.PrintIndexChecksum(cmd.Context(), cmd.OutOrStdout(), cmd.Flags(), opts)
+		},
+	}
+	app.RegisterFlags(checksumCmd.Flags())
+	checksumCmd.Flags().BoolVar(&opts.Remote, "remote", false, "Compute the checksum from the current remote HEADs instead of the built indexes")
+	checksumCmd.Flags().BoolVar(&opts.JSON, "json", false, "Print the repositories and commits the checksum covers, as JSON")
+	return checksumCmd
+}
+
+func newMigrateBaseDirCommand() *cobra.Command {
+	var opts app.MigrateOptions
+	migrateCmd := &cobra.Command{
+		Use:   "migrate-basedir",
+		Short: "Move the base directory with its clones, indexes and manifest",
+		Long: `Move the base directory, given by --git-repos-base-dir or its environment
+variable, to --to, which must not exist or be empty.
+
+The command refuses to run while a daemon started with serve --daemon or a
+sync uses the base directory, and holds the sync lock while moving. Stop
+foreground servers using the base directory first. Within a file system the
+directory is renamed; otherwise it is copied, verified by checksum, and only
+then removed. The sync lock and PID files are not carried over.
+
+Clones and indexes kept elsewhere with --git-repos-repos-dir and
+--git-repos-indexes-dir are left in place.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.MigrateBaseDir(cmd.OutOrStdout(), cmd.Flags(), opts)
+		},
+	}
+	app.RegisterFlags(migrateCmd.Flags())
+	addPIDFileFlag(migrateCmd.Flags(), &opts.Daemon)
+	migrateCmd.Flags().StringVar(&opts.To, "to", "", "New base directory")
+	return migrateCmd
 }
+
+func newClientConfigCommand(programName string) *cobra.Command {
+	var opts app.ClientConfigOptions
+	clientConfigCmd := &cobra.Command{
+		Use:   "client-config",
+		Short: "Print the configuration that adds this server to an MCP client",
+		Long: `Print the JSON snippet that adds the server, with the settings given by
+flags, environment variables and the config files in the current directory,
+to the configuration of an MCP client.
+
+Stdio servers are started with the path of this executable and every setting
+that differs from its default as an environment variable, so that the client
+may start them from any directory. SSE servers are connected to by URL, with
+placeholders for credentials.
+
+Formats:
+  claude   Claude Desktop (claude_desktop_config.json) and Claude Code (.mcp.json)
+  vscode   VS Code (.vscode/mcp.json)
+  cursor   Cursor (.cursor/mcp.json)`,
+		Example: "  " + programName + " client-config --format vscode --git-repos-urls git@github.com:org/repo.git",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.PrintClientConfig(cmd.OutOrStdout(), cmd.Flags(), opts)
+		},
+	}
+	app.RegisterFlags(clientConfigCmd.Flags())
+	clientConfigCmd.Flags().StringVar(&opts.Format, "format", app.ClientFormatClaude, "Client configuration format: "+strings.Join(app.ClientFormats, ", "))
+	clientConfigCmd.Flags().StringVar(&opts.Name, "name", "relic", "Name of the server in the client configuration")
+	return clientConfigCmd
+}
+
+func newSelfUpdateCommand(info app.BuildInfo, programName string) *cobra.Command {
+	var opts app.SelfUpdateOptions
+	selfUpdateCmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update this binary to the latest GitHub release",
+		Long: `Check the GitHub releases of ` + app.ReleaseRepository + ` for a newer version and
+replace this binary with the release binary of the current platform.
+
+The binary is only installed if its SHA-256 checksum matches the ` + app.ReleaseChecksumsAsset + `
+asset of the release. That checks the integrity of the download, not who
+published it, since both come from the same release. With a public key, set
+with --public-key or at build time, the checksums must also be signed with it
+(` + app.ReleaseSignatureAsset + `), which checks their authenticity.
+
+Set GITHUB_TOKEN to avoid the rate limits of anonymous GitHub API requests.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return app.RunSelfUpdate(ctx, cmd.OutOrStdout(), info, programName, opts)
+		},
+	}
+	selfUpdateCmd.Flags().BoolVar(&opts.CheckOnly, "check-only", false, "Only report whether a newer release is available")
+	selfUpdateCmd.Flags().StringVar(&opts.PublicKey, "public-key", ReleasePublicKey, "Base64 ed25519 public key the release checksums must be signed with")
+	return selfUpdateCmd
+}
+
+func runWithFlags(flags *pflag.FlagSet, info app.BuildInfo) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return app.RunWithDeps(ctx, app.DefaultRunParams(), flags, info)
+}
+
+// usageTemplate is cobra's default usage template, with flags listed by
+// flag group.
+const usageTemplate = `Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}
+
+Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+{{groupedFlagUsages .LocalFlags | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`
@@ -19,6 +19,13 @@ func TestExecute_Help(t *testing.T) {
 	}
 }
 
+func TestExecute_SyncHelp(t *testing.T) {
+	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"sync", "--help"})
+	if err != nil {
+		t.Errorf("Expected no error for sync --help, got: %v", err)
+	}
+}
+
 func TestExecute_InvalidFlag(t *testing.T) {
 	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"--invalid-flag"})
 	if err == nil {
@@ -62,3 +69,60 @@ func TestRunMain_Failure(t *testing.T) {
 		t.Errorf("Expected exit code 1 for invalid flag, got: %d", exitCode)
 	}
 }
+
+func TestExecute_Env(t *testing.T) {
+	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"env"})
+	if err != nil {
+		t.Errorf("Expected no error for env, got: %v", err)
+	}
+}
+
+func TestExecute_TelemetryHash(t *testing.T) {
+	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"telemetry", "--hash", "parse config"})
+	if err != nil {
+		t.Errorf("Expected no error for telemetry --hash, got: %v", err)
+	}
+}
+
+func TestExecute_IndexChecksum(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", t.TempDir())
+	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"index-checksum"})
+	if err == nil || !strings.Contains(err.Error(), "run a sync first") {
+		t.Errorf("Expected an error without a sync, got: %v", err)
+	}
+}
+
+func TestExecute_ClientConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"client-config", "--format", "vscode", "--repo", "git@github.com:org/repo.git"})
+	if err != nil {
+		t.Errorf("Expected no error for client-config, got: %v", err)
+	}
+
+	err = Execute("1.0.0", "abc123", "relic-mcp", []string{"client-config", "--format", "zed"})
+	if err == nil || !strings.Contains(err.Error(), "unknown client format") {
+		t.Errorf("Expected an unknown format error, got: %v", err)
+	}
+}
+
+func TestExecute_SelfUpdateInvalidPublicKey(t *testing.T) {
+	err := Execute("v1.0.0", "abc123", "relic-mcp", []string{"self-update", "--check-only", "--public-key", "not-a-key"})
+	if err == nil || !strings.Contains(err.Error(), "invalid public key") {
+		t.Errorf("Expected an invalid public key error, got: %v", err)
+	}
+}
+
+func TestExecute_DaemonCommands(t *testing.T) {
+	baseDir := t.TempDir()
+	for _, command := range []string{"status", "stop"} {
+		err := Execute("1.0.0", "abc123", "relic-mcp", []string{command, "--git-repos-base-dir", baseDir})
+		if err == nil || !strings.Contains(err.Error(), "not running") {
+			t.Errorf("Expected %s to report the server is not running, got: %v", command, err)
+		}
+	}
+
+	err := Execute("1.0.0", "abc123", "relic-mcp", []string{"serve", "--daemon", "-t", "stdio", "--git-repos-base-dir", baseDir, "--git-repos-urls", "git@github.com:org/repo.git"})
+	if err == nil || !strings.Contains(err.Error(), "daemon mode requires transport 'sse'") {
+		t.Errorf("Expected serve --daemon to require sse, got: %v", err)
+	}
+}
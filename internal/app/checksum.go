@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+// IndexChecksumOptions controls what PrintIndexChecksum reports
+type IndexChecksumOptions struct {
+	Remote bool // checksum of the indexes a sync would build now, from the remote HEADs
+	JSON   bool // print the checksum document rather than the checksum alone
+	// RemoteHead resolves the HEAD commit of a repository URL; nil uses git
+	// with the configured command timeout
+	RemoteHead func(ctx context.Context, url string) (string, error)
+}
+
+// PrintIndexChecksum writes the checksum of the indexes in the base directory
+// of the settings described by flags, as recorded by the last sync.
+func PrintIndexChecksum(ctx context.Context, w io.Writer, flags *pflag.FlagSet, opts IndexChecksumOptions) error {
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	var checksum *gitrepos.IndexChecksum
+	if opts.Remote {
+		checksum, err = remoteIndexChecksum(ctx, &settings.GitRepos, opts.RemoteHead)
+		if err != nil {
+			return err
+		}
+	} else {
+		path := filepath.Join(settings.GitRepos.BaseDir, gitrepos.IndexChecksumFilename)
+		checksum, err = gitrepos.LoadIndexChecksum(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no index checksum at %s (run a sync first)", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read index checksum: %w", err)
+		}
+	}
+
+	if !opts.JSON {
+		_, err = fmt.Fprintln(w, checksum.Checksum)
+		return err
+	}
+	data, err := json.MarshalIndent(checksum, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// remoteIndexChecksum returns the checksum of the indexes a sync of the
+// configured repositories would build at their current remote HEADs.
+func remoteIndexChecksum(ctx context.Context, settings *config.GitReposSettings, remoteHead func(context.Context, string) (string, error)) (*gitrepos.IndexChecksum, error) {
+	if settings.LocalDir != "" {
+		return nil, errors.New("remote checksums are not available with --cwd")
+	}
+	if remoteHead == nil {
+		git := gitrepos.NewGitClientWithExecutor(gitrepos.NewExecutor(settings))
+		git.SetURLLogging(settings.LogURLs)
+		tokens, err := settings.HTTPSCredentials()
+		if err != nil {
+			return nil, err
+		}
+		git.SetHTTPSTokens(tokens)
+		remoteHead = git.RemoteHead
+	}
+
+	commits := make(map[string]string, len(settings.URLs))
+	for _, url := range settings.URLs {
+		repository := gitrepos.RepoIDToDisplay(gitrepos.URLToRepoID(url))
+		commit, err := remoteHead(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve HEAD of %s: %w", repository, err)
+		}
+		commits[repository] = commit
+	}
+	return gitrepos.NewIndexChecksum(commits, settings.Refs), nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+func TestPrintIndexChecksum(t *testing.T) {
+	baseDir := t.TempDir()
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", baseDir)
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	var buf bytes.Buffer
+	if err := PrintIndexChecksum(context.Background(), &buf, flags, IndexChecksumOptions{}); err == nil || !strings.Contains(err.Error(), "run a sync first") {
+		t.Errorf("Expected an error without a checksum, got: %v", err)
+	}
+
+	checksum := gitrepos.NewIndexChecksum(map[string]string{"github.com/org/repo": "abc123"}, nil)
+	if err := checksum.Save(filepath.Join(baseDir, gitrepos.IndexChecksumFilename)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := PrintIndexChecksum(context.Background(), &buf, flags, IndexChecksumOptions{}); err != nil {
+		t.Fatalf("PrintIndexChecksum failed: %v", err)
+	}
+	if buf.String() != checksum.Checksum+"\n" {
+		t.Errorf("Expected the checksum, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := PrintIndexChecksum(context.Background(), &buf, flags, IndexChecksumOptions{JSON: true}); err != nil {
+		t.Fatalf("PrintIndexChecksum failed: %v", err)
+	}
+	var printed gitrepos.IndexChecksum
+	if err := json.Unmarshal(buf.Bytes(), &printed); err != nil || printed.Checksum != checksum.Checksum || printed.Repos[0].Commit != "abc123" {
+		t.Errorf("Expected the checksum document, got %s (%v)", buf.String(), err)
+	}
+}
+
+func TestPrintIndexChecksum_Remote(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", t.TempDir())
+	t.Setenv("RELIC_MCP_GIT_REPOS_URLS", "git@github.com:org/api.git,git@github.com:org/web.git")
+	t.Setenv("RELIC_MCP_GIT_REPOS_REFS", "v1.0.0")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	heads := map[string]string{"git@github.com:org/api.git": "abc123", "git@github.com:org/web.git": "def456"}
+	opts := IndexChecksumOptions{Remote: true, RemoteHead: func(_ context.Context, url string) (string, error) {
+		if commit, ok := heads[url]; ok {
+			return commit, nil
+		}
+		return "", errors.New("repository not found")
+	}}
+
+	var buf bytes.Buffer
+	if err := PrintIndexChecksum(context.Background(), &buf, flags, opts); err != nil {
+		t.Fatalf("PrintIndexChecksum failed: %v", err)
+	}
+	expected := gitrepos.NewIndexChecksum(map[string]string{"github.com/org/api": "abc123", "github.com/org/web": "def456"}, []string{"v1.0.0"})
+	if buf.String() != expected.Checksum+"\n" {
+		t.Errorf("Expected %s, got %q", expected.Checksum, buf.String())
+	}
+
+	delete(heads, "git@github.com:org/web.git")
+	if err := PrintIndexChecksum(context.Background(), &buf, flags, opts); err == nil || !strings.Contains(err.Error(), "github.com/org/web") {
+		t.Errorf("Expected an error naming the repository, got: %v", err)
+	}
+}
@@ -1,29 +1,167 @@
 package app
 
 import (
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 )
 
+// Flag groups, in the order they are listed in help output
+const (
+	FlagGroupServer   = "Server"
+	FlagGroupAuth     = "Authentication"
+	FlagGroupGitRepos = "Git Repositories"
+	FlagGroupIndexing = "Indexing"
+)
+
+// flagGroupAnnotation is the flag annotation holding the group of a flag
+const flagGroupAnnotation = "relic_flag_group"
+
+var flagGroups = []string{FlagGroupServer, FlagGroupAuth, FlagGroupGitRepos, FlagGroupIndexing}
+
 // RegisterFlags registers all CLI flags on the given FlagSet
 func RegisterFlags(flags *pflag.FlagSet) {
 	// Transport and server flags
-	flags.StringP("transport", "t", "", "Transport type: stdio or sse")
-	flags.StringP("host", "H", "", "Host for SSE transport")
-	flags.IntP("port", "p", 0, "Port for SSE transport")
+	flags.StringP("transport", "t", "", "Transport type: stdio, sse or http")
+	flags.StringP("host", "H", "", "Host for SSE and HTTP transports")
+	flags.IntP("port", "p", 0, "Port for SSE and HTTP transports")
+	flags.Bool("pprof", false, "Serve profiling endpoints under /debug to admin API keys (SSE and HTTP only)")
+	flags.Bool("ui", false, "Serve a web UI under /ui showing sync status and running searches (SSE and HTTP only)")
+	flags.String("client-log-level", "info", "Minimum level of index events sent to MCP clients: debug, info, warn, error, or off")
+	flags.String("index-update-notify", "off", "How MCP clients are told that a sync changed the index: resources, log, or off")
+	flags.Bool("request-id-footer", false, "Append the request ID of each tool call to its result, for matching reported results with server logs")
+	flags.String("debug-stdio", "", "Append the JSON-RPC frames exchanged over stdio, redacted, to this file for debugging client issues")
+	setFlagGroup(flags, FlagGroupServer)
 
 	// Auth flags
-	flags.StringP("auth-type", "a", "", "Authentication type: none, basic, or apikey")
+	flags.StringP("auth-type", "a", "", "Authentication type: none, basic, apikey, or mtls")
 	flags.StringP("auth-basic-username", "u", "", "Basic auth username")
 	flags.StringP("auth-basic-password", "P", "", "Basic auth password")
 	flags.StringSliceP("auth-api-keys", "k", nil, "API keys (comma-separated)")
+	flags.StringSlice("auth-admin-api-keys", nil, "API keys for administrative endpoints (comma-separated)")
+	flags.StringSlice("auth-mtls-identities", nil, "Client certificate identities (CN, or first SAN) allowed with mtls auth (comma-separated; default: any verified certificate)")
+	flags.StringSlice("auth-excluded-paths", nil, "Paths that bypass auth, exact or ending with * to match a prefix (comma-separated; default: /health)")
+	flags.String("tls-cert-file", "", "Certificate file for serving SSE and HTTP over HTTPS")
+	flags.String("tls-key-file", "", "Private key file for serving SSE and HTTP over HTTPS")
+	flags.String("tls-client-ca-file", "", "CA certificates that client certificates are verified against (mtls auth)")
+	setFlagGroup(flags, FlagGroupAuth)
+
+	// Quick mode
+	flags.String("repo", "", "Serve a single repository over stdio with per-repository defaults")
+	flags.Bool("cwd", false, "Serve the git checkout in the current directory over stdio, reindexing when HEAD changes")
+	flags.String("profile", "", "Configuration profile: load config.<profile>.yaml and .env.<profile> over the base config files")
+	setFlagGroup(flags, FlagGroupServer)
 
 	// Git repos flags
 	flags.StringSlice("git-repos-urls", nil, "Git repository SSH URLs (comma-separated)")
 	flags.String("git-repos-base-dir", "", "Base directory for git data (default: ~/.relic-mcp)")
+	flags.String("git-repos-repos-dir", "", "Directory for repository clones (default: <base-dir>/repos)")
+	flags.String("git-repos-indexes-dir", "", "Directory for search indexes (default: <base-dir>/indexes)")
 	flags.Duration("git-repos-sync-interval", 15*time.Minute, "Minimum interval between syncs")
 	flags.Duration("git-repos-sync-timeout", 60*time.Second, "Maximum time to wait for sync lock")
-	flags.Int64("git-repos-max-file-size", 256*1024, "Skip files larger than this (bytes)")
+	flags.Duration("git-repos-git-command-timeout", 10*time.Minute, "Maximum run time of each git command (0 = no limit)")
+	flags.Int64("git-repos-git-max-output", 16*1024*1024, "Maximum output kept from each git command, in bytes (0 = unlimited)")
+	flags.Bool("git-repos-git-sandbox", false, "Run git with a clean environment, without the system and global git config, credential helpers or hooks")
+	flags.Int64("git-repos-git-max-memory", 0, "Maximum virtual memory of each git command, in bytes (0 = no limit; not on Windows)")
+	flags.Duration("git-repos-git-max-cpu", 0, "Maximum CPU time of each git command (0 = no limit; not on Windows)")
+	flags.StringSlice("git-repos-sync-windows", nil, "Daily windows in which periodic syncs of the sync command may start, in local time (comma-separated, e.g. '02:00-05:00')")
+	flags.Int64("git-repos-fetch-rate-limit", 0, "Maximum bandwidth of clones and fetches over HTTP(S), in bytes per second (0 = no limit)")
 	flags.Int("git-repos-max-results", 20, "Maximum search results")
+	flags.Bool("git-repos-read-only", false, "Serve indexes built by a separate 'sync' process instead of syncing")
+	flags.String("git-repos-snapshot-url", "", "Object storage URL for distributing index snapshots (file://, s3://, gs://)")
+	flags.Bool("git-repos-watch", true, "Reindex files as they change (with --cwd)")
+	flags.Bool("git-repos-log-urls", true, "Include repository URLs in logs and errors (embedded credentials are always removed)")
+	flags.Duration("git-repos-removed-retention", 24*time.Hour, "How long to keep the index and clone of a repository removed from the URL list (0 = delete on the next sync)")
+	flags.Int("git-repos-sync-failure-threshold", 0, "Number of repositories failing their initial sync that makes startup fail (0 = never, start without them)")
+	flags.Bool("git-repos-startup-checks", true, "Check that git is installed and every repository is reachable (git ls-remote) before the initial sync")
+	flags.Bool("git-repos-search-telemetry", false, "Record query hashes, result counts and reads of search hits in telemetry.jsonl in the base directory, for relevance tuning (see the telemetry command)")
+	flags.StringSlice("git-repos-refs", nil, "Tags or branches indexed as snapshots next to the default branch (comma-separated, e.g. v1.0.0,release/2.0)")
+	flags.StringSlice("git-repos-priority", nil, "Repositories synced first, in this order, by name or URL (comma-separated); the others follow in the order of --git-repos-urls")
+	flags.StringSlice("git-repos-allowed-urls", nil, "Only sync repositories matching these hosts or URL patterns (comma-separated, e.g. 'git@github.com:myorg/*,github.com/myorg/**')")
+	flags.StringSlice("git-repos-denied-urls", nil, "Never sync repositories matching these hosts or URL patterns (comma-separated)")
+	flags.Int("git-repos-clone-depth", 1, "Commits of history kept by clones and fetches (0 = full history)")
+	flags.StringSlice("git-repos-clone-depth-overrides", nil, "Clone depths of some repositories, by name or URL (comma-separated, e.g. 'github.com/org/repo=0')")
+	flags.StringSlice("git-repos-branches", nil, "Branches to sync instead of the remote default, by repository name or URL (comma-separated, e.g. 'github.com/org/repo=trunk')")
+	flags.StringSlice("git-repos-https-tokens", nil, "Access tokens of HTTPS remotes by host (comma-separated, e.g. 'github.com=ghp_...' or 'gitlab.com=user:token'); prefer RELIC_MCP_GIT_HTTPS_TOKENS(_FILE)")
+	flags.Int("git-repos-history-commits", 0, "Index the previous versions of files changed or deleted by this many recent commits, for search_history (0 = off)")
+	flags.StringSlice("git-repos-read-deny-patterns", nil, "Path patterns the read tools refuse and search results omit (comma-separated, e.g. '**/secrets/**,*.pem')")
+	flags.StringArray("git-repos-read-redact-patterns", nil, "Regular expression masked in read output and search snippets; only the first capture group if it has one (repeatable)")
+	flags.Bool("git-repos-read-indexed-only", false, "Only serve indexed files from the read tool")
+	flags.Bool("git-repos-highlight", true, "Mark matched terms in search result fragments")
+	flags.String("git-repos-highlight-pre", "**", "Text inserted before each matched term")
+	flags.String("git-repos-highlight-post", "**", "Text inserted after each matched term")
+	flags.Int("git-repos-max-concurrent-searches", 8, "Maximum searches running at once (0 = unlimited)")
+	flags.Int("git-repos-search-queue-size", 16, "Maximum searches waiting for a slot before new ones are rejected")
+	flags.Int("git-repos-max-concurrent-reads", 16, "Maximum file reads (read, search_in_file, get_readme) running at once (0 = unlimited)")
+	flags.Int("git-repos-read-queue-size", 32, "Maximum file reads waiting for a slot before new ones are rejected")
+	flags.Duration("git-repos-search-timeout", 10*time.Second, "Maximum run time of a search (0 = no limit)")
+	flags.Duration("git-repos-read-timeout", 30*time.Second, "Maximum run time of a file read (0 = no limit)")
+	flags.StringSlice("git-repos-extension-aliases", nil, "Extension groups for the search extension filter, as name=ext+ext (comma-separated, e.g. web=html+css+js); added to the built-in groups")
+	flags.String("git-repos-ranking-profile", "default", "Ranking profile of searches that select none themselves or through their API key (default, definitions-first, docs-first or recent-first)")
+	flags.String("git-repos-query-experiment", "", "Also run every search with an alternate query strategy (exact or symbols) and log how its top hits compare (experimental)")
+	setFlagGroup(flags, FlagGroupGitRepos)
+
+	// Indexing flags
+	flags.Int64("git-repos-max-file-size", 256*1024, "Skip files larger than this (bytes)")
+	flags.Bool("git-repos-follow-symlinks", false, "Follow symlinks that resolve inside the repository when indexing and reading")
+	flags.Int("git-repos-max-repo-files", 0, "Stop indexing a repository after this many files (0 = unlimited)")
+	flags.Int64("git-repos-max-repo-bytes", 0, "Stop indexing a repository after this many content bytes (0 = unlimited)")
+	flags.Bool("git-repos-verify-index", false, "Verify each full index after it is built and record the outcome in repo_stats")
+	flags.Bool("git-repos-index-declarations", false, "Index each top-level declaration of source files as its own document, so search hits point at functions and types")
+	flags.Int("git-repos-index-batch-size", 100, "Max documents written to an index in one batch")
+	flags.Int64("git-repos-index-batch-bytes", 10*1024*1024, "Max content bytes written to an index in one batch")
+	flags.StringSlice("git-repos-max-file-size-overrides", nil, "Max file size per extension, as ext=bytes (comma-separated, e.g. md=1048576,proto=1048576)")
+	setFlagGroup(flags, FlagGroupIndexing)
+}
+
+// setFlagGroup assigns group to the flags registered so far that have none.
+func setFlagGroup(flags *pflag.FlagSet, group string) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[flagGroupAnnotation]; !ok {
+			_ = flags.SetAnnotation(f.Name, flagGroupAnnotation, []string{group})
+		}
+	})
+}
+
+// FlagGroup returns the group of a flag registered by RegisterFlags, or an
+// empty string for other flags.
+func FlagGroup(f *pflag.Flag) string {
+	if group := f.Annotations[flagGroupAnnotation]; len(group) > 0 {
+		return group[0]
+	}
+	return ""
+}
+
+// GroupedFlagUsages formats the usage of flags in sections by flag group.
+// Flags without a group, such as those of a subcommand, are listed last.
+func GroupedFlagUsages(flags *pflag.FlagSet) string {
+	sets := make(map[string]*pflag.FlagSet)
+	flags.VisitAll(func(f *pflag.Flag) {
+		group := FlagGroup(f)
+		if sets[group] == nil {
+			sets[group] = pflag.NewFlagSet(group, pflag.ContinueOnError)
+		}
+		sets[group].AddFlag(f)
+	})
+
+	var b strings.Builder
+	for _, group := range append(flagGroups, "") {
+		set := sets[group]
+		if set == nil {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		title := "Flags:"
+		if group != "" {
+			title = group + " Flags:"
+		}
+		if group == "" && len(sets) > 1 {
+			title = "Other Flags:"
+		}
+		b.WriteString(title + "\n" + set.FlagUsages())
+	}
+	return b.String()
 }
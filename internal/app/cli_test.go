@@ -1,6 +1,7 @@
 package app
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/spf13/pflag"
@@ -88,3 +89,52 @@ func TestRegisterFlags_SetValues(t *testing.T) {
 		t.Errorf("Expected auth-type 'basic', got '%s'", authType)
 	}
 }
+
+func TestRegisterFlags_Groups(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		if FlagGroup(f) == "" {
+			t.Errorf("Flag %q has no group", f.Name)
+		}
+	})
+
+	groups := map[string]string{
+		"transport":               FlagGroupServer,
+		"auth-api-keys":           FlagGroupAuth,
+		"git-repos-urls":          FlagGroupGitRepos,
+		"git-repos-max-file-size": FlagGroupIndexing,
+	}
+	for name, group := range groups {
+		if got := FlagGroup(flags.Lookup(name)); got != group {
+			t.Errorf("Flag %q expected group %q, got %q", name, group, got)
+		}
+	}
+}
+
+func TestGroupedFlagUsages(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	flags.Bool("once", false, "Run once")
+
+	usage := GroupedFlagUsages(flags)
+	sections := []string{"Server Flags:", "Authentication Flags:", "Git Repositories Flags:", "Indexing Flags:", "Other Flags:"}
+	last := -1
+	for _, section := range sections {
+		i := strings.Index(usage, section)
+		if i <= last {
+			t.Fatalf("Expected section %q after the previous one in:\n%s", section, usage)
+		}
+		last = i
+	}
+	if !strings.Contains(usage[last:], "--once") {
+		t.Errorf("Expected ungrouped flags last, got:\n%s", usage)
+	}
+
+	ungrouped := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	ungrouped.Bool("once", false, "Run once")
+	if usage := GroupedFlagUsages(ungrouped); !strings.HasPrefix(usage, "Flags:\n") {
+		t.Errorf("Expected a plain flags section, got:\n%s", usage)
+	}
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// Client configuration formats
+const (
+	ClientFormatClaude = "claude" // mcpServers, as in Claude Desktop and Claude Code configuration files
+	ClientFormatVSCode = "vscode" // servers, as in VS Code .vscode/mcp.json
+	ClientFormatCursor = "cursor" // mcpServers, as in Cursor .cursor/mcp.json
+)
+
+// ClientFormats lists the supported client configuration formats
+var ClientFormats = []string{ClientFormatClaude, ClientFormatVSCode, ClientFormatCursor}
+
+// stdioIgnoredEnv are the prefixes of settings left out of stdio client
+// configurations: SSE-only settings, and the profile, whose config files are
+// already resolved into the other settings
+var stdioIgnoredEnv = []string{"RELIC_MCP_AUTH_", "RELIC_MCP_TLS_", "RELIC_MCP_HOST", "RELIC_MCP_PORT", "RELIC_MCP_PPROF", "RELIC_MCP_PROFILE"}
+
+// ClientConfigOptions controls what PrintClientConfig prints
+type ClientConfigOptions struct {
+	Format string // one of ClientFormats
+	Name   string // name of the server entry
+	// Executable is the command clients start the server with; empty uses
+	// the path of the running executable
+	Executable string
+}
+
+// clientServer is a server entry of an MCP client configuration
+type clientServer struct {
+	Type    string            `json:"type,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// PrintClientConfig writes the configuration snippet that adds the server
+// described by flags, the environment and the config files in the current
+// directory to an MCP client. Stdio servers are started with every setting
+// that differs from its default in env, so that the client may start them
+// from any directory. SSE servers are connected to by URL; credentials are
+// left as placeholders.
+func PrintClientConfig(w io.Writer, flags *pflag.FlagSet, opts ClientConfigOptions) error {
+	key := "mcpServers"
+	switch opts.Format {
+	case ClientFormatClaude, ClientFormatCursor:
+	case ClientFormatVSCode:
+		key = "servers"
+	default:
+		return fmt.Errorf("unknown client format %q (use %s)", opts.Format, strings.Join(ClientFormats, ", "))
+	}
+
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if err := config.ValidateSettings(settings); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	var server clientServer
+	if settings.ServesHTTP() {
+		server = sseClientServer(settings, opts.Format)
+	} else {
+		server, err = stdioClientServer(flags, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(map[string]any{key: map[string]clientServer{opts.Name: server}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// stdioClientServer returns the entry of a server the client starts.
+func stdioClientServer(flags *pflag.FlagSet, opts ClientConfigOptions) (clientServer, error) {
+	command := opts.Executable
+	if command == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return clientServer{}, fmt.Errorf("failed to locate the executable: %w", err)
+		}
+		command = executable
+	}
+
+	env, err := config.SettingsEnv(flags)
+	if err != nil {
+		return clientServer{}, fmt.Errorf("failed to load settings: %w", err)
+	}
+	for name := range env {
+		for _, prefix := range stdioIgnoredEnv {
+			if strings.HasPrefix(name, prefix) {
+				delete(env, name)
+			}
+		}
+	}
+
+	// The quick modes are flags rather than settings
+	var args []string
+	if f := flags.Lookup("repo"); f != nil && f.Changed {
+		args = append(args, "--repo", f.Value.String())
+	}
+	if f := flags.Lookup("cwd"); f != nil && f.Changed && f.Value.String() == "true" {
+		args = append(args, "--cwd")
+	}
+
+	server := clientServer{Command: command, Args: args, Env: env}
+	if opts.Format == ClientFormatVSCode {
+		server.Type = "stdio"
+	}
+	return server, nil
+}
+
+// sseClientServer returns the entry of a server the client connects to, over
+// SSE or streamable HTTP.
+func sseClientServer(settings *config.Settings, format string) clientServer {
+	host := settings.Host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	scheme := "http"
+	if settings.TLS.Enabled() {
+		scheme = "https"
+	}
+
+	server := clientServer{URL: fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, strconv.Itoa(settings.Port)), settings.MCPPath())}
+	if format != ClientFormatCursor {
+		server.Type = settings.Transport
+	}
+	switch settings.Auth.Type {
+	case config.AuthTypeAPIKey:
+		server.Headers = map[string]string{"X-API-Key": "<api-key>"}
+	case config.AuthTypeBasic:
+		server.Headers = map[string]string{"Authorization": "Basic <base64 of " + settings.Auth.Basic.Username + ":password>"}
+	}
+	return server
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// clientConfigFlags returns the server flags parsed from args.
+func clientConfigFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("client-config", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return flags
+}
+
+// printClientConfig prints the client configuration and decodes it.
+func printClientConfig(t *testing.T, flags *pflag.FlagSet, format string) map[string]map[string]clientServer {
+	t.Helper()
+	var buf bytes.Buffer
+	err := PrintClientConfig(&buf, flags, ClientConfigOptions{Format: format, Name: "relic", Executable: "/opt/bin/relic-mcp"})
+	if err != nil {
+		t.Fatalf("PrintClientConfig failed: %v", err)
+	}
+	var config map[string]map[string]clientServer
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	return config
+}
+
+func TestPrintClientConfig_Stdio(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("git_repos:\n  refs: [v1, v2]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	urlsFile := filepath.Join(dir, "urls")
+	if err := os.WriteFile(urlsFile, []byte("git@github.com:org/repo.git\n"), 0600); err != nil {
+		t.Fatalf("Failed to write URLs: %v", err)
+	}
+	t.Setenv("RELIC_MCP_GIT_REPOS_URLS_FILE", "urls")
+	t.Setenv("RELIC_MCP_PORT", "9090") // SSE only
+	flags := clientConfigFlags(t, "--git-repos-max-results", "30", "--git-repos-highlight=false")
+
+	tests := []struct {
+		format   string
+		key      string
+		wantType string
+	}{
+		{ClientFormatClaude, "mcpServers", ""},
+		{ClientFormatCursor, "mcpServers", ""},
+		{ClientFormatVSCode, "servers", "stdio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			server, ok := printClientConfig(t, flags, tt.format)[tt.key]["relic"]
+			if !ok {
+				t.Fatalf("Expected a relic entry under %q", tt.key)
+			}
+			if server.Type != tt.wantType || server.Command != "/opt/bin/relic-mcp" || len(server.Args) != 0 || server.URL != "" {
+				t.Errorf("Unexpected server entry: %+v", server)
+			}
+			want := map[string]string{
+				"RELIC_MCP_GIT_REPOS_MAX_RESULTS": "30",
+				"RELIC_MCP_GIT_REPOS_HIGHLIGHT":   "false",
+				"RELIC_MCP_GIT_REPOS_REFS":        "v1,v2",
+				"RELIC_MCP_GIT_REPOS_URLS_FILE":   urlsFile,
+			}
+			if len(server.Env) != len(want) {
+				t.Errorf("Expected env %v, got %v", want, server.Env)
+			}
+			for name, value := range want {
+				if server.Env[name] != value {
+					t.Errorf("Expected %s=%q, got %q", name, value, server.Env[name])
+				}
+			}
+		})
+	}
+}
+
+func TestPrintClientConfig_QuickMode(t *testing.T) {
+	t.Chdir(t.TempDir())
+	flags := clientConfigFlags(t, "--repo", "git@github.com:org/repo.git")
+
+	server := printClientConfig(t, flags, ClientFormatClaude)["mcpServers"]["relic"]
+	if strings.Join(server.Args, " ") != "--repo git@github.com:org/repo.git" || len(server.Env) != 0 {
+		t.Errorf("Expected the --repo shortcut as arguments only, got: %+v", server)
+	}
+}
+
+func TestPrintClientConfig_SSE(t *testing.T) {
+	t.Chdir(t.TempDir())
+	flags := clientConfigFlags(t, "--git-repos-urls", "git@github.com:org/repo.git", "--transport", "sse", "--port", "9090", "--auth-type", "apikey", "--auth-api-keys", "secret-key")
+
+	var buf bytes.Buffer
+	if err := PrintClientConfig(&buf, flags, ClientConfigOptions{Format: ClientFormatCursor, Name: "relic"}); err != nil {
+		t.Fatalf("PrintClientConfig failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "secret-key") {
+		t.Errorf("Expected the API key to be left out, got:\n%s", buf.String())
+	}
+
+	cursor := printClientConfig(t, flags, ClientFormatCursor)["mcpServers"]["relic"]
+	if cursor.URL != "http://localhost:9090/sse" || cursor.Type != "" || cursor.Command != "" || cursor.Headers["X-API-Key"] != "<api-key>" {
+		t.Errorf("Unexpected cursor entry: %+v", cursor)
+	}
+	vscode := printClientConfig(t, flags, ClientFormatVSCode)["servers"]["relic"]
+	if vscode.Type != "sse" || vscode.URL != cursor.URL {
+		t.Errorf("Unexpected vscode entry: %+v", vscode)
+	}
+}
+
+func TestPrintClientConfig_HTTP(t *testing.T) {
+	t.Chdir(t.TempDir())
+	flags := clientConfigFlags(t, "--git-repos-urls", "git@github.com:org/repo.git", "--transport", "http", "--port", "9090")
+
+	claude := printClientConfig(t, flags, ClientFormatClaude)["mcpServers"]["relic"]
+	if claude.URL != "http://localhost:9090/mcp" || claude.Type != "http" || claude.Command != "" {
+		t.Errorf("Unexpected claude entry: %+v", claude)
+	}
+}
+
+func TestPrintClientConfig_Errors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	err := PrintClientConfig(&bytes.Buffer{}, clientConfigFlags(t), ClientConfigOptions{Format: "zed", Name: "relic"})
+	if err == nil || !strings.Contains(err.Error(), `unknown client format "zed"`) {
+		t.Errorf("Expected unknown format error, got: %v", err)
+	}
+
+	err = PrintClientConfig(&bytes.Buffer{}, clientConfigFlags(t, "--transport", "sse", "--auth-type", "apikey"), ClientConfigOptions{Format: ClientFormatClaude, Name: "relic"})
+	if err == nil || !strings.Contains(err.Error(), "invalid configuration") {
+		t.Errorf("Expected invalid configuration error, got: %v", err)
+	}
+}
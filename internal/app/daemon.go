@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// DefaultPIDFilename is the PID file of a daemon, in the base directory
+	DefaultPIDFilename = "relic-mcp.pid"
+	// DefaultLogFilename is the log file of a daemon, in the base directory
+	DefaultLogFilename = "relic-mcp.log"
+
+	// DaemonChildFlag marks the background process started by StartDaemon
+	DaemonChildFlag = "daemon-child"
+
+	// daemonStartTimeout is how long StartDaemon waits for the daemon to
+	// write its PID file
+	daemonStartTimeout = 10 * time.Second
+	// daemonPollInterval is how often the PID file or process is checked
+	daemonPollInterval = 100 * time.Millisecond
+)
+
+// DaemonOptions controls where a daemon keeps its PID and logs
+type DaemonOptions struct {
+	PIDFile       string // empty uses DefaultPIDFilename in the base directory
+	LogFile       string // empty uses DefaultLogFilename in the base directory
+	LogMaxSize    int64  // size at which the log file is rotated (0 = never)
+	LogMaxBackups int    // rotated log files kept
+}
+
+// paths returns the PID and log files of the daemon with settings.
+func (o DaemonOptions) paths(settings *config.Settings) (pidFile, logFile string) {
+	pidFile, logFile = o.PIDFile, o.LogFile
+	if pidFile == "" {
+		pidFile = filepath.Join(settings.GitRepos.BaseDir, DefaultPIDFilename)
+	}
+	if logFile == "" {
+		logFile = filepath.Join(settings.GitRepos.BaseDir, DefaultLogFilename)
+	}
+	return pidFile, logFile
+}
+
+// StartDaemon starts the SSE or HTTP server described by flags in the
+// background by running the executable again with args, where the --daemon
+// flag is replaced by the --daemon-child flag. It returns once the daemon has
+// written its PID file, or fails if it exits first.
+func StartDaemon(w io.Writer, flags *pflag.FlagSet, args []string, opts DaemonOptions) error {
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if err := config.ValidateSettings(settings); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if !settings.ServesHTTP() {
+		return errors.New("daemon mode requires transport 'sse' or 'http'")
+	}
+
+	pidFile, logFile := opts.paths(settings)
+	if pid, err := readPIDFile(pidFile); err == nil && processRunning(pid) {
+		return fmt.Errorf("already running (pid %d)", pid)
+	}
+	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	// Output the daemon writes before its logger is set up, such as a panic
+	output, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer func() { _ = output.Close() }()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the executable: %w", err)
+	}
+	cmd := exec.Command(executable, daemonChildArgs(args)...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.SysProcAttr = detachedProcess()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the daemon: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	deadline := time.After(daemonStartTimeout)
+	for {
+		if pid, err := readPIDFile(pidFile); err == nil && pid == cmd.Process.Pid {
+			break
+		}
+		select {
+		case err := <-exited:
+			return fmt.Errorf("the daemon exited during startup (%v), see %s", err, logFile)
+		case <-deadline:
+			return fmt.Errorf("the daemon did not start within %s, see %s", daemonStartTimeout, logFile)
+		case <-time.After(daemonPollInterval):
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "Started in the background (pid %d)\nLogs: %s\nStop with: %s stop\n", cmd.Process.Pid, logFile, filepath.Base(executable))
+	return err
+}
+
+// daemonChildArgs returns args with the --daemon flag replaced by the
+// --daemon-child flag.
+func daemonChildArgs(args []string) []string {
+	child := make([]string, 0, len(args)+1)
+	for _, arg := range args {
+		if arg == "--daemon" || strings.HasPrefix(arg, "--daemon=") {
+			continue
+		}
+		child = append(child, arg)
+	}
+	return append(child, "--"+DaemonChildFlag)
+}
+
+// RunDaemon runs the server as the background process started by
+// StartDaemon: it records its PID, writes its logs to the rotating log file
+// and removes the PID file when it stops.
+func RunDaemon(ctx context.Context, params RunParams, flags *pflag.FlagSet, info BuildInfo, opts DaemonOptions) error {
+	settings, err := params.LoadSettings(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	pidFile, logFile := opts.paths(settings)
+
+	if err := writePIDFile(pidFile); err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(pidFile) }()
+
+	logs, err := OpenRotatingFile(logFile, opts.LogMaxSize, opts.LogMaxBackups)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = logs.Close() }()
+
+	params.LogWriter = logs
+	return RunWithDeps(ctx, params, flags, info)
+}
+
+// StopDaemon stops the daemon whose PID file is given by flags and opts, and
+// waits up to timeout for it to exit.
+func StopDaemon(w io.Writer, flags *pflag.FlagSet, opts DaemonOptions, timeout time.Duration) error {
+	pidFile, pid, err := runningDaemon(flags, opts)
+	if err != nil {
+		return err
+	}
+	if err := terminateProcess(pid); err != nil {
+		return fmt.Errorf("failed to stop pid %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pid %d did not stop within %s", pid, timeout)
+		}
+		time.Sleep(daemonPollInterval)
+	}
+	// The daemon removes its PID file, unless it was killed
+	_ = os.Remove(pidFile)
+	_, err = fmt.Fprintf(w, "Stopped (pid %d)\n", pid)
+	return err
+}
+
+// PrintDaemonStatus reports whether the daemon whose PID file is given by
+// flags and opts is running. It returns an error if it is not.
+func PrintDaemonStatus(w io.Writer, flags *pflag.FlagSet, opts DaemonOptions) error {
+	_, pid, err := runningDaemon(flags, opts)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	pidFile, logFile := opts.paths(settings)
+	if _, err := fmt.Fprintf(w, "Running (pid %d)\n", pid); err != nil {
+		return err
+	}
+	// The daemon writes its PID file when it starts
+	if info, err := os.Stat(pidFile); err == nil {
+		if _, err := fmt.Fprintf(w, "Started: %s\n", gitrepos.FormatTime(info.ModTime(), time.Now())); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "PID file: %s\nLogs: %s\n", pidFile, logFile)
+	return err
+}
+
+// runningDaemon returns the PID file and PID of the running daemon. A stale
+// PID file, left by a daemon that did not stop cleanly, is removed.
+func runningDaemon(flags *pflag.FlagSet, opts DaemonOptions) (string, int, error) {
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to load settings: %w", err)
+	}
+	pidFile, _ := opts.paths(settings)
+
+	pid, err := readPIDFile(pidFile)
+	if os.IsNotExist(err) {
+		return "", 0, fmt.Errorf("not running (no PID file at %s)", pidFile)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if !processRunning(pid) {
+		_ = os.Remove(pidFile)
+		return "", 0, fmt.Errorf("not running (removed the stale PID file of pid %d)", pid)
+	}
+	return pidFile, pid, nil
+}
+
+// readPIDFile returns the PID recorded in path.
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file %s", path)
+	}
+	return pid, nil
+}
+
+// writePIDFile records the PID of this process in path, unless it records
+// another process that is still running.
+func writePIDFile(path string) error {
+	if pid, err := readPIDFile(path); err == nil && pid != os.Getpid() && processRunning(pid) {
+		return fmt.Errorf("already running (pid %d)", pid)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create PID file directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// daemonFlags returns the server flags parsed from args, with the base
+// directory in a temporary directory.
+func daemonFlags(t *testing.T, args ...string) (*pflag.FlagSet, string) {
+	t.Helper()
+	baseDir := t.TempDir()
+	flags := pflag.NewFlagSet("serve", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	if err := flags.Parse(append([]string{"--git-repos-base-dir", baseDir, "--git-repos-urls", "https://github.com/org/repo.git"}, args...)); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return flags, baseDir
+}
+
+// startProcess starts a process that stands in for a running daemon.
+func startProcess(t *testing.T) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a sleep command")
+	}
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start process: %v", err)
+	}
+	// Reap the process once it exits, so that it is not reported as running
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		<-exited
+	})
+	return cmd
+}
+
+func writeTestPIDFile(t *testing.T, path string, pid int) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write PID file: %v", err)
+	}
+}
+
+func TestDaemonChildArgs(t *testing.T) {
+	got := daemonChildArgs([]string{"serve", "--daemon", "-t", "sse", "--daemon=true", "--port", "9090"})
+	want := "serve -t sse --port 9090 --daemon-child"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestStartDaemon_RequiresSSE(t *testing.T) {
+	flags, _ := daemonFlags(t, "--transport", "stdio")
+	err := StartDaemon(&bytes.Buffer{}, flags, nil, DaemonOptions{})
+	if err == nil || !strings.Contains(err.Error(), "daemon mode requires transport 'sse'") {
+		t.Errorf("Expected the stdio transport to be refused, got: %v", err)
+	}
+}
+
+func TestStartDaemon_AlreadyRunning(t *testing.T) {
+	flags, baseDir := daemonFlags(t, "--transport", "sse")
+	process := startProcess(t)
+	writeTestPIDFile(t, filepath.Join(baseDir, DefaultPIDFilename), process.Process.Pid)
+
+	err := StartDaemon(&bytes.Buffer{}, flags, nil, DaemonOptions{})
+	if err == nil || !strings.Contains(err.Error(), "already running (pid "+strconv.Itoa(process.Process.Pid)+")") {
+		t.Errorf("Expected an already running error, got: %v", err)
+	}
+}
+
+func TestRunDaemon_ManagesPIDAndLogFiles(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	flags, _ := daemonFlags(t)
+	dir := t.TempDir()
+	opts := DaemonOptions{
+		PIDFile: filepath.Join(dir, "run", "relic.pid"),
+		LogFile: filepath.Join(dir, "relic.log"),
+	}
+
+	var pidDuringRun int
+	params := RunParams{
+		LoadSettings: func(*pflag.FlagSet) (*config.Settings, error) {
+			return &config.Settings{Transport: "sse"}, nil
+		},
+		ValidSettings: noopValidate,
+		CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+			return mcp.NewServer(&mcp.Implementation{Name: "test"}, nil), nil, nil
+		},
+		StartSSEServer: func(context.Context, *mcp.Server, *config.Settings) error {
+			pidDuringRun, _ = readPIDFile(opts.PIDFile)
+			slog.Info("serving")
+			return nil
+		},
+	}
+	if err := RunDaemon(context.Background(), params, flags, BuildInfo{Version: "test"}, opts); err != nil {
+		t.Fatalf("RunDaemon failed: %v", err)
+	}
+
+	if pidDuringRun != os.Getpid() {
+		t.Errorf("Expected the PID file to record %d while running, got %d", os.Getpid(), pidDuringRun)
+	}
+	if _, err := os.Stat(opts.PIDFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the PID file to be removed, got: %v", err)
+	}
+	if data, _ := os.ReadFile(opts.LogFile); !strings.Contains(string(data), "msg=serving") {
+		t.Errorf("Expected the logs in the log file, got %q", data)
+	}
+}
+
+func TestRunDaemon_AlreadyRunning(t *testing.T) {
+	flags, _ := daemonFlags(t)
+	process := startProcess(t)
+	pidFile := filepath.Join(t.TempDir(), "relic.pid")
+	writeTestPIDFile(t, pidFile, process.Process.Pid)
+
+	params := DefaultRunParams()
+	params.LoadSettings = func(*pflag.FlagSet) (*config.Settings, error) {
+		return &config.Settings{Transport: "sse"}, nil
+	}
+	err := RunDaemon(context.Background(), params, flags, BuildInfo{}, DaemonOptions{PIDFile: pidFile})
+	if err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("Expected an already running error, got: %v", err)
+	}
+	if pid, _ := readPIDFile(pidFile); pid != process.Process.Pid {
+		t.Errorf("Expected the PID file to be left alone, got pid %d", pid)
+	}
+}
+
+func TestStopDaemon(t *testing.T) {
+	flags, baseDir := daemonFlags(t)
+	process := startProcess(t)
+	pidFile := filepath.Join(baseDir, DefaultPIDFilename)
+	writeTestPIDFile(t, pidFile, process.Process.Pid)
+
+	var out bytes.Buffer
+	if err := PrintDaemonStatus(&out, flags, DaemonOptions{}); err != nil {
+		t.Fatalf("PrintDaemonStatus failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Running (pid "+strconv.Itoa(process.Process.Pid)+")") {
+		t.Errorf("Unexpected status: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "Z (just now)\n") {
+		t.Errorf("Expected the start time in UTC with its age, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := StopDaemon(&out, flags, DaemonOptions{}, 5*time.Second); err != nil {
+		t.Fatalf("StopDaemon failed: %v", err)
+	}
+	if out.String() != "Stopped (pid "+strconv.Itoa(process.Process.Pid)+")\n" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the PID file to be removed, got: %v", err)
+	}
+}
+
+func TestPrintDaemonStatus_NotRunning(t *testing.T) {
+	flags, baseDir := daemonFlags(t)
+	err := PrintDaemonStatus(&bytes.Buffer{}, flags, DaemonOptions{})
+	if err == nil || !strings.Contains(err.Error(), "not running (no PID file") {
+		t.Errorf("Expected a not running error, got: %v", err)
+	}
+
+	// A PID file left by a daemon that was killed is removed
+	process := startProcess(t)
+	pidFile := filepath.Join(baseDir, DefaultPIDFilename)
+	writeTestPIDFile(t, pidFile, process.Process.Pid)
+	_ = process.Process.Kill()
+	deadline := time.Now().Add(5 * time.Second)
+	for processRunning(process.Process.Pid) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = StopDaemon(&bytes.Buffer{}, flags, DaemonOptions{}, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "removed the stale PID file") {
+		t.Errorf("Expected a stale PID file error, got: %v", err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale PID file to be removed, got: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package app
+
+import (
+	"os"
+	"syscall"
+)
+
+// Process creation flags of a daemon without a console
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcessFlag   = 0x00000008
+)
+
+// detachedProcess starts the daemon without a console, in a process group of
+// its own, so that it is not stopped with the console it was started from.
+func detachedProcess() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcessFlag}
+}
+
+// processRunning reports whether a process with the given PID exists.
+func processRunning(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer func() { _ = syscall.CloseHandle(handle) }()
+	var code uint32
+	const stillActive = 259
+	return syscall.GetExitCodeProcess(handle, &code) == nil && code == stillActive
+}
+
+// terminateProcess stops the process. Windows has no SIGTERM for processes
+// without a console, so it is killed.
+func terminateProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
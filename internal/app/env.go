@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// flagNameReplacer maps a settings key to the name of its flag, e.g.
+// git_repos.max_results to git-repos-max-results
+var flagNameReplacer = strings.NewReplacer(".", "-", "_", "-")
+
+// PrintEnv writes every supported environment variable with its default, in
+// .env format. The usage of the matching flag in flags, if any, is written
+// as a comment above each variable.
+func PrintEnv(w io.Writer, flags *pflag.FlagSet) error {
+	for n, env := range config.EnvVars() {
+		if n > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if env.File {
+			if _, err := fmt.Fprintf(w, "# File holding the value of %s, e.g. a mounted secret\n", strings.TrimSuffix(env.Name, "_FILE")); err != nil {
+				return err
+			}
+		} else if f := flags.Lookup(flagNameReplacer.Replace(env.Key)); f != nil {
+			if _, err := fmt.Fprintf(w, "# %s (--%s)\n", f.Usage, f.Name); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", env.Name, env.Default); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestPrintEnv(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	var buf bytes.Buffer
+	if err := PrintEnv(&buf, flags); err != nil {
+		t.Fatalf("PrintEnv failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"# Maximum search results (--git-repos-max-results)\nRELIC_MCP_GIT_REPOS_MAX_RESULTS=20\n",
+		"RELIC_MCP_TRANSPORT=stdio\n",
+		"RELIC_MCP_GIT_REPOS_URLS=\n",
+		"RELIC_MCP_GIT_REPOS_SYNC_INTERVAL=15m0s\n",
+	}
+	for _, want := range expected {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// Every flag backed by a setting is documented
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "repo" || f.Name == "cwd" {
+			return
+		}
+		if !strings.Contains(out, "(--"+f.Name+")\n") {
+			t.Errorf("Expected flag %q to be described", f.Name)
+		}
+	})
+}
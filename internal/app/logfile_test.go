@@ -0,0 +1,108 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relic-mcp.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	logs, err := OpenRotatingFile(path, 20, 2)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := logs.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := logs.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Each write past 20 bytes starts a new file; only two backups are kept
+	want := map[string]string{
+		path:        "fourth line\n",
+		path + ".1": "third line\n",
+		path + ".2": "second line\n",
+	}
+	for file, content := range want {
+		data, err := os.ReadFile(file)
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q (err: %v)", filepath.Base(file), content, data, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected no third backup, got: %v", err)
+	}
+}
+
+func TestRotatingFile_NoRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relic-mcp.log")
+	logs, err := OpenRotatingFile(path, 0, 3)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		_, _ = logs.Write([]byte("line\n"))
+	}
+	_ = logs.Close()
+
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), "line\n") != 100 {
+		t.Errorf("Expected all lines in one file, got %d bytes", len(data))
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup, got: %v", err)
+	}
+}
+
+func TestRotatingFile_NoBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relic-mcp.log")
+	logs, err := OpenRotatingFile(path, 10, 0)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	_, _ = logs.Write([]byte("first line\n"))
+	_, _ = logs.Write([]byte("second line\n"))
+	_ = logs.Close()
+
+	if data, _ := os.ReadFile(path); string(data) != "second line\n" {
+		t.Errorf("Expected the log to restart, got %q", data)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup, got: %v", err)
+	}
+}
+
+func TestRotatingFile_KeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relic-mcp.log")
+	// A non-empty directory in place of the first backup makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	logs, err := OpenRotatingFile(path, 20, 1)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	for _, line := range []string{"first line\n", "second line\n", "third line\n"} {
+		if _, err := logs.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := logs.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "first line\nsecond line\nthird line\n" {
+		t.Errorf("Expected every line in the log file, got %q (err: %v)", data, err)
+	}
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+// MigrateOptions controls MigrateBaseDir
+type MigrateOptions struct {
+	To     string        // new base directory
+	Daemon DaemonOptions // PID file of a daemon that must not be running
+	// Rename moves a directory within a file system; nil uses os.Rename. When
+	// it fails, e.g. across file systems, the base directory is copied instead.
+	Rename func(oldpath, newpath string) error
+}
+
+// migratedFile is what the verification of a migration compares.
+type migratedFile struct {
+	size int64
+	hash string // SHA-256 of regular files, only computed when copying
+	link string // target of symlinks
+}
+
+// MigrateBaseDir moves the base directory of the settings described by flags,
+// with its clones, indexes and manifest, to opts.To. It refuses to run while
+// a daemon or a sync uses the base directory, and holds the sync lock while
+// moving. The directory is renamed if possible, and copied otherwise; a copy
+// is verified file by file before the old directory is removed. The sync lock
+// and PID files are not carried over, so that the new base directory starts
+// without stale ones.
+func MigrateBaseDir(w io.Writer, flags *pflag.FlagSet, opts MigrateOptions) error {
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if opts.To == "" {
+		return errors.New("--to is required")
+	}
+	src, err := filepath.Abs(settings.GitRepos.BaseDir)
+	if err != nil {
+		return err
+	}
+	dst, err := filepath.Abs(opts.To)
+	if err != nil {
+		return err
+	}
+	if err := checkMigrationPaths(src, dst); err != nil {
+		return err
+	}
+
+	pidFile, _ := opts.Daemon.paths(settings)
+	if pid, err := readPIDFile(pidFile); err == nil && processRunning(pid) {
+		return fmt.Errorf("a server is running on %s (pid %d); stop it first", src, pid)
+	}
+
+	lock := gitrepos.NewFileLock(filepath.Join(src, gitrepos.LockFilename))
+	locked, err := lock.TryLock()
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", src, err)
+	}
+	if !locked {
+		return fmt.Errorf("a sync is in progress on %s; try again once it finishes", src)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	// Not carried over: the lock is released once the move is done, and the
+	// PID file belongs to a daemon that is not running
+	skip := map[string]bool{gitrepos.LockFilename: true}
+	if rel, err := filepath.Rel(src, pidFile); err == nil && !strings.HasPrefix(rel, "..") {
+		skip[rel] = true
+	}
+
+	rename := opts.Rename
+	if rename == nil {
+		rename = os.Rename
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dst), err)
+	}
+	// An empty target directory, which checkMigrationPaths allows, would make
+	// the rename fail
+	_ = os.Remove(dst)
+
+	before, err := inventory(src, skip, false)
+	if err != nil {
+		return err
+	}
+	files := before
+	method := "Moved"
+	if err := rename(src, dst); err == nil {
+		after, err := inventory(dst, skip, false)
+		if err != nil {
+			return err
+		}
+		if err := compareInventories(before, after); err != nil {
+			return fmt.Errorf("verification of %s failed: %w", dst, err)
+		}
+		for rel := range skip {
+			_ = os.Remove(filepath.Join(dst, rel))
+		}
+	} else {
+		method = "Copied"
+		if files, err = copyBaseDir(src, dst, skip); err != nil {
+			_ = os.RemoveAll(dst)
+			return err
+		}
+		if err := os.RemoveAll(src); err != nil {
+			return fmt.Errorf("copied and verified %s, but failed to remove %s: %w", dst, src, err)
+		}
+	}
+
+	if _, err := gitrepos.LoadManifest(filepath.Join(dst, gitrepos.ManifestFilename)); err != nil {
+		return fmt.Errorf("migrated, but the manifest in %s cannot be read: %w", dst, err)
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	_, err = fmt.Fprintf(w, "%s %s to %s (%d entries, %.1f MB)\n", method, src, dst, len(files), float64(total)/(1024*1024))
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "Start the server with --git-repos-base-dir %s or RELIC_MCP_GIT_REPOS_BASE_DIR=%s\n", dst, dst)
+	printMovedDirSetting(w, "git-repos-repos-dir", settings.GitRepos.ReposDir, src, dst)
+	printMovedDirSetting(w, "git-repos-indexes-dir", settings.GitRepos.IndexesDir, src, dst)
+	return nil
+}
+
+// checkMigrationPaths rejects targets that cannot receive the base directory
+// src: src itself, paths inside it or containing it, and non-empty
+// directories.
+func checkMigrationPaths(src, dst string) error {
+	if _, err := os.Stat(filepath.Join(src, gitrepos.ManifestFilename)); err != nil {
+		return fmt.Errorf("no base directory to migrate at %s (no %s)", src, gitrepos.ManifestFilename)
+	}
+	if src == dst {
+		return errors.New("the new base directory is the current one")
+	}
+	if isWithin(dst, src) || isWithin(src, dst) {
+		return fmt.Errorf("the new base directory %s cannot contain or be inside %s", dst, src)
+	}
+	entries, err := os.ReadDir(dst)
+	if err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s exists and is not empty", dst)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot use %s: %w", dst, err)
+	}
+	return nil
+}
+
+// isWithin reports whether path is inside dir.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// printMovedDirSetting tells the user to update a clones or indexes
+// directory setting that pointed inside the old base directory.
+func printMovedDirSetting(w io.Writer, flag, dir, src, dst string) {
+	if dir == "" {
+		return
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil || !isWithin(abs, src) {
+		_, _ = fmt.Fprintf(w, "--%s %s is outside the base directory and was left in place\n", flag, dir)
+		return
+	}
+	rel, _ := filepath.Rel(src, abs)
+	_, _ = fmt.Fprintf(w, "--%s pointed inside the old base directory; set it to %s\n", flag, filepath.Join(dst, rel))
+}
+
+// inventory lists the files, directories and symlinks under root by relative
+// path, except those in skip, with the SHA-256 of regular files if hash.
+func inventory(root string, skip map[string]bool, hash bool) (map[string]migratedFile, error) {
+	files := make(map[string]migratedFile)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if rel == "." || skip[rel] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f := migratedFile{}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			if f.link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			f.size = info.Size()
+			if hash {
+				if f.hash, err = fileSHA256(path); err != nil {
+					return err
+				}
+			}
+		}
+		files[rel] = f
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// compareInventories reports the first difference between two inventories.
+func compareInventories(want, got map[string]migratedFile) error {
+	for rel, f := range want {
+		g, ok := got[rel]
+		if !ok {
+			return fmt.Errorf("%s is missing", rel)
+		}
+		if g != f {
+			return fmt.Errorf("%s differs", rel)
+		}
+	}
+	for rel := range got {
+		if _, ok := want[rel]; !ok {
+			return fmt.Errorf("unexpected %s", rel)
+		}
+	}
+	return nil
+}
+
+// copyBaseDir copies src to dst, except the paths in skip, keeping file modes
+// and symlinks, and verifies the copy by content. It returns the inventory
+// of the copy.
+func copyBaseDir(src, dst string, skip map[string]bool) (map[string]migratedFile, error) {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if skip[rel] {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+
+	want, err := inventory(src, skip, true)
+	if err != nil {
+		return nil, err
+	}
+	got, err := inventory(dst, skip, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := compareInventories(want, got); err != nil {
+		return nil, fmt.Errorf("verification of the copy in %s failed: %w", dst, err)
+	}
+	return got, nil
+}
+
+// copyFile copies the regular file src to dst with mode, syncing it to disk.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
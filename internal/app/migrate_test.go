@@ -0,0 +1,176 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+// setupBaseDir creates a base directory with a manifest, a clone, an index,
+// a lock and a stale PID file, and returns flags pointing at it.
+func setupBaseDir(t *testing.T) (string, *pflag.FlagSet) {
+	t.Helper()
+	baseDir := filepath.Join(t.TempDir(), "old")
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", baseDir)
+
+	manifest := gitrepos.NewManifest()
+	manifest.SetRepoState("github.com_org_repo", gitrepos.RepoState{URL: "https://github.com/org/repo.git", LastIndexed: "abc123"})
+	if err := manifest.Save(filepath.Join(baseDir, gitrepos.ManifestFilename)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	writeTestFile(t, filepath.Join(baseDir, "repos", "github.com_org_repo", "main.go"), "package main\n")
+	writeTestFile(t, filepath.Join(baseDir, "indexes", "github.com_org_repo", "index_meta.json"), "{}")
+	writeTestFile(t, filepath.Join(baseDir, gitrepos.LockFilename), "")
+	writeTestFile(t, filepath.Join(baseDir, DefaultPIDFilename), "999999\n")
+	if err := os.Symlink("main.go", filepath.Join(baseDir, "repos", "github.com_org_repo", "link.go")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	return baseDir, flags
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func assertMigrated(t *testing.T, oldDir, newDir string) {
+	t.Helper()
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got: %v", oldDir, err)
+	}
+	for _, rel := range []string{gitrepos.ManifestFilename, "repos/github.com_org_repo/main.go", "indexes/github.com_org_repo/index_meta.json"} {
+		if _, err := os.Stat(filepath.Join(newDir, rel)); err != nil {
+			t.Errorf("Expected %s in the new base directory: %v", rel, err)
+		}
+	}
+	if link, err := os.Readlink(filepath.Join(newDir, "repos/github.com_org_repo/link.go")); err != nil || link != "main.go" {
+		t.Errorf("Expected the symlink to be kept, got %q (%v)", link, err)
+	}
+	for _, rel := range []string{gitrepos.LockFilename, DefaultPIDFilename} {
+		if _, err := os.Stat(filepath.Join(newDir, rel)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be carried over, got: %v", rel, err)
+		}
+	}
+	manifest, err := gitrepos.LoadManifest(filepath.Join(newDir, gitrepos.ManifestFilename))
+	if err != nil || manifest.GetRepoState("github.com_org_repo").LastIndexed != "abc123" {
+		t.Errorf("Expected the manifest to be kept, got %v", err)
+	}
+}
+
+func TestMigrateBaseDir_Rename(t *testing.T) {
+	oldDir, flags := setupBaseDir(t)
+	newDir := filepath.Join(t.TempDir(), "nested", "new")
+
+	var buf bytes.Buffer
+	if err := MigrateBaseDir(&buf, flags, MigrateOptions{To: newDir}); err != nil {
+		t.Fatalf("MigrateBaseDir failed: %v", err)
+	}
+	assertMigrated(t, oldDir, newDir)
+	if out := buf.String(); !strings.Contains(out, "Moved "+oldDir+" to "+newDir) || !strings.Contains(out, "--git-repos-base-dir "+newDir) {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestMigrateBaseDir_CopyWhenRenameFails(t *testing.T) {
+	oldDir, flags := setupBaseDir(t)
+	newDir := t.TempDir() // empty directories are accepted
+
+	rename := func(_, _ string) error { return errors.New("invalid cross-device link") }
+	var buf bytes.Buffer
+	if err := MigrateBaseDir(&buf, flags, MigrateOptions{To: newDir, Rename: rename}); err != nil {
+		t.Fatalf("MigrateBaseDir failed: %v", err)
+	}
+	assertMigrated(t, oldDir, newDir)
+	if !strings.HasPrefix(buf.String(), "Copied ") {
+		t.Errorf("Expected a copy, got: %s", buf.String())
+	}
+}
+
+func TestMigrateBaseDir_Refuses(t *testing.T) {
+	oldDir, flags := setupBaseDir(t)
+	nonEmpty := t.TempDir()
+	writeTestFile(t, filepath.Join(nonEmpty, "file"), "x")
+
+	tests := []struct {
+		name string
+		to   string
+		want string
+	}{
+		{"no target", "", "--to is required"},
+		{"same directory", oldDir, "is the current one"},
+		{"inside", filepath.Join(oldDir, "sub"), "cannot contain or be inside"},
+		{"containing", filepath.Dir(oldDir), "cannot contain or be inside"},
+		{"not empty", nonEmpty, "is not empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MigrateBaseDir(&bytes.Buffer{}, flags, MigrateOptions{To: tt.to})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(oldDir, gitrepos.ManifestFilename)); err != nil {
+		t.Errorf("Expected the base directory to be left alone: %v", err)
+	}
+}
+
+func TestMigrateBaseDir_RefusesWhileInUse(t *testing.T) {
+	oldDir, flags := setupBaseDir(t)
+	newDir := filepath.Join(t.TempDir(), "new")
+
+	writeTestFile(t, filepath.Join(oldDir, DefaultPIDFilename), strconv.Itoa(os.Getpid()))
+	err := MigrateBaseDir(&bytes.Buffer{}, flags, MigrateOptions{To: newDir})
+	if err == nil || !strings.Contains(err.Error(), "a server is running") {
+		t.Errorf("Expected a running server error, got: %v", err)
+	}
+	_ = os.Remove(filepath.Join(oldDir, DefaultPIDFilename))
+
+	lock := gitrepos.NewFileLock(filepath.Join(oldDir, gitrepos.LockFilename))
+	if ok, err := lock.TryLock(); !ok || err != nil {
+		t.Fatalf("TryLock failed: %v", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+	err = MigrateBaseDir(&bytes.Buffer{}, flags, MigrateOptions{To: newDir})
+	if err == nil || !strings.Contains(err.Error(), "a sync is in progress") {
+		t.Errorf("Expected a sync in progress error, got: %v", err)
+	}
+	if _, err := os.Stat(newDir); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be moved, got: %v", err)
+	}
+}
+
+func TestMigrateBaseDir_DirSettings(t *testing.T) {
+	oldDir, flags := setupBaseDir(t)
+	outside := t.TempDir()
+	t.Setenv("RELIC_MCP_GIT_REPOS_REPOS_DIR", filepath.Join(oldDir, "repos"))
+	t.Setenv("RELIC_MCP_GIT_REPOS_INDEXES_DIR", outside)
+	newDir := filepath.Join(t.TempDir(), "new")
+
+	var buf bytes.Buffer
+	if err := MigrateBaseDir(&buf, flags, MigrateOptions{To: newDir}); err != nil {
+		t.Fatalf("MigrateBaseDir failed: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "--git-repos-repos-dir pointed inside the old base directory; set it to "+filepath.Join(newDir, "repos")) {
+		t.Errorf("Expected a note on the clones directory, got: %s", out)
+	}
+	if !strings.Contains(out, "--git-repos-indexes-dir "+outside+" is outside the base directory") {
+		t.Errorf("Expected a note on the indexes directory, got: %s", out)
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// SettingsLoader re-reads and validates settings from their sources.
+type SettingsLoader func() (*config.Settings, error)
+
+// reloadable is implemented by services that can apply new settings at runtime.
+type reloadable interface {
+	Reload(ctx context.Context, settings *config.GitReposSettings) error
+}
+
+// watchReload reloads settings whenever the process receives SIGHUP and applies
+// them to the given service. Returns a function that stops watching.
+func watchReload(svc reloadable, current *config.Settings, load SettingsLoader) func() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				if next := reloadSettings(context.Background(), svc, current, load); next != nil {
+					current = next
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
+
+// reloadSettings loads fresh settings and applies the git repos part to the
+// service. Returns the applied settings, or nil if the reload failed.
+func reloadSettings(ctx context.Context, svc reloadable, current *config.Settings, load SettingsLoader) *config.Settings {
+	slog.Info("Reloading configuration")
+	next, err := load()
+	if err != nil {
+		slog.Error("Configuration reload failed, keeping current settings", "error", err)
+		return nil
+	}
+
+	if !serverSettingsEqual(next, current) {
+		slog.Warn("Transport, auth, TLS, web UI and profiling settings changes require a restart and were not applied")
+	}
+
+	if err := svc.Reload(ctx, &next.GitRepos); err != nil {
+		slog.Error("Failed to apply reloaded git repos settings", "error", err)
+		return nil
+	}
+
+	slog.Info("Configuration reloaded")
+	return next
+}
+
+// serverSettingsEqual reports whether two configurations have identical
+// settings of the HTTP server, which are only applied when it starts.
+func serverSettingsEqual(a, b *config.Settings) bool {
+	return a.Transport == b.Transport && a.Host == b.Host && a.Port == b.Port &&
+		reflect.DeepEqual(a.Auth, b.Auth) && reflect.DeepEqual(a.TLS, b.TLS) &&
+		a.UI == b.UI && a.Pprof == b.Pprof
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// mockReloadable records the settings passed to Reload.
+type mockReloadable struct {
+	mu       sync.Mutex
+	calls    []*config.GitReposSettings
+	err      error
+	reloaded chan struct{}
+}
+
+func (m *mockReloadable) Reload(_ context.Context, settings *config.GitReposSettings) error {
+	m.mu.Lock()
+	m.calls = append(m.calls, settings)
+	m.mu.Unlock()
+	if m.reloaded != nil {
+		m.reloaded <- struct{}{}
+	}
+	return m.err
+}
+
+func TestReloadSettings_AppliesGitRepos(t *testing.T) {
+	svc := &mockReloadable{}
+	current := &config.Settings{Transport: "stdio"}
+	next := &config.Settings{
+		Transport: "stdio",
+		GitRepos:  config.GitReposSettings{URLs: []string{"git@github.com:org/new.git"}},
+	}
+
+	got := reloadSettings(context.Background(), svc, current, func() (*config.Settings, error) {
+		return next, nil
+	})
+
+	if got != next {
+		t.Error("Expected reloaded settings to be returned")
+	}
+	if len(svc.calls) != 1 {
+		t.Fatalf("Expected 1 Reload call, got %d", len(svc.calls))
+	}
+	if svc.calls[0].URLs[0] != "git@github.com:org/new.git" {
+		t.Errorf("Unexpected URLs passed to Reload: %v", svc.calls[0].URLs)
+	}
+}
+
+func TestReloadSettings_LoadError(t *testing.T) {
+	svc := &mockReloadable{}
+
+	got := reloadSettings(context.Background(), svc, &config.Settings{}, func() (*config.Settings, error) {
+		return nil, errors.New("invalid configuration")
+	})
+
+	if got != nil {
+		t.Error("Expected nil settings on load error")
+	}
+	if len(svc.calls) != 0 {
+		t.Error("Reload should not be called when loading fails")
+	}
+}
+
+func TestReloadSettings_ReloadError(t *testing.T) {
+	svc := &mockReloadable{err: errors.New("lock timeout")}
+
+	got := reloadSettings(context.Background(), svc, &config.Settings{}, func() (*config.Settings, error) {
+		return &config.Settings{}, nil
+	})
+
+	if got != nil {
+		t.Error("Expected nil settings when Reload fails")
+	}
+}
+
+func TestWatchReload_SIGHUP(t *testing.T) {
+	svc := &mockReloadable{reloaded: make(chan struct{}, 1)}
+	stop := watchReload(svc, &config.Settings{}, func() (*config.Settings, error) {
+		return &config.Settings{}, nil
+	})
+	defer stop()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+
+	select {
+	case <-svc.reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Reload to be called after SIGHUP")
+	}
+}
+
+func TestReloadSettings_WarnsAboutExcludedPathsChange(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	var logs bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	current := &config.Settings{Transport: "sse", Auth: config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"key"}}}
+	next := &config.Settings{Transport: "sse", Auth: config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"key"}, ExcludedPaths: []string{"/metrics"}}}
+
+	if got := reloadSettings(context.Background(), &mockReloadable{}, current, func() (*config.Settings, error) {
+		return next, nil
+	}); got != next {
+		t.Fatal("Expected reloaded settings to be returned")
+	}
+	if !strings.Contains(logs.String(), "require a restart") {
+		t.Errorf("Expected a restart warning, got:\n%s", logs.String())
+	}
+}
+
+func TestServerSettingsEqual(t *testing.T) {
+	base := func() *config.Settings {
+		return &config.Settings{
+			Transport: "sse",
+			Auth:      config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"a", "b"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*config.Settings)
+		want   bool
+	}{
+		{"identical", func(*config.Settings) {}, true},
+		{"git repos", func(s *config.Settings) { s.GitRepos.URLs = []string{"git@github.com:org/new.git"} }, true},
+		{"port", func(s *config.Settings) { s.Port = 9090 }, false},
+		{"auth type", func(s *config.Settings) { s.Auth.Type = config.AuthTypeNone }, false},
+		{"api keys", func(s *config.Settings) { s.Auth.APIKeys = []string{"a", "c"} }, false},
+		{"excluded paths", func(s *config.Settings) { s.Auth.ExcludedPaths = []string{"/metrics"} }, false},
+		{"admin api keys", func(s *config.Settings) { s.Auth.AdminAPIKeys = []string{"admin"} }, false},
+		{"mtls identities", func(s *config.Settings) { s.Auth.MTLSIdentities = []string{"agent"} }, false},
+		{"tls", func(s *config.Settings) { s.TLS.CertFile = "server.pem" }, false},
+		{"ui", func(s *config.Settings) { s.UI = true }, false},
+		{"pprof", func(s *config.Settings) { s.Pprof = true }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base()
+			tt.change(other)
+			if got := serverSettingsEqual(base(), other); got != tt.want {
+				t.Errorf("serverSettingsEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// gitVersionTimeout bounds the git --version call made for the self-report
+const gitVersionTimeout = 5 * time.Second
+
+// BuildInfo identifies the running binary.
+type BuildInfo struct {
+	Version string
+	Build   string
+}
+
+// SelfReport describes the running server and its environment. It is logged
+// on startup and returned by the version tool so that users can include it
+// when reporting issues.
+type SelfReport struct {
+	Version    string
+	Build      string
+	Transport  string
+	AuthType   string
+	Repos      int
+	BaseDir    string
+	IndexBytes int64  // -1 if unknown
+	GitVersion string // empty if git is unavailable
+	Platform   gitrepos.Platform
+}
+
+// NewSelfReport collects a self-report for settings.
+func NewSelfReport(info BuildInfo, settings *config.Settings) SelfReport {
+	repos := len(settings.GitRepos.URLs)
+	if settings.GitRepos.LocalDir != "" {
+		repos = 1
+	}
+	authType := settings.Auth.Type
+	if authType == "" {
+		authType = config.AuthTypeNone
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitVersionTimeout)
+	defer cancel()
+	gitVersion, _ := gitrepos.NewGitClient().Version(ctx)
+
+	return SelfReport{
+		Version:    info.Version,
+		Build:      info.Build,
+		Transport:  settings.Transport,
+		AuthType:   authType,
+		Repos:      repos,
+		BaseDir:    settings.GitRepos.BaseDir,
+		IndexBytes: diskUsage(settings.GitRepos.IndexesPath()),
+		GitVersion: gitVersion,
+		Platform:   gitrepos.CurrentPlatform(),
+	}
+}
+
+// LogAttrs returns the report as slog key-value pairs.
+func (r SelfReport) LogAttrs() []any {
+	return []any{
+		"version", r.Version,
+		"build", r.Build,
+		"transport", r.Transport,
+		"auth_type", r.AuthType,
+		"repos", r.Repos,
+		"base_dir", r.BaseDir,
+		"index_bytes", r.IndexBytes,
+		"git_version", r.GitVersion,
+		"os", r.Platform.OS,
+		"arch", r.Platform.Arch,
+		"go_version", r.Platform.GoVersion,
+		"cgo_enabled", r.Platform.CGOEnabled,
+		"index_engine", r.Platform.IndexEngine,
+	}
+}
+
+// String formats the report as a markdown list.
+func (r SelfReport) String() string {
+	gitVersion := r.GitVersion
+	if gitVersion == "" {
+		gitVersion = "not found"
+	}
+	indexSize := "unknown"
+	if r.IndexBytes >= 0 {
+		indexSize = fmt.Sprintf("%.1f MB", float64(r.IndexBytes)/(1024*1024))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- Version: %s (build %s)\n", r.Version, r.Build))
+	sb.WriteString(fmt.Sprintf("- Platform: %s\n", r.Platform))
+	sb.WriteString(fmt.Sprintf("- Transport: %s\n", r.Transport))
+	sb.WriteString(fmt.Sprintf("- Auth type: %s\n", r.AuthType))
+	sb.WriteString(fmt.Sprintf("- Repositories: %d\n", r.Repos))
+	sb.WriteString(fmt.Sprintf("- Base directory: %s\n", r.BaseDir))
+	sb.WriteString(fmt.Sprintf("- Index disk usage: %s\n", indexSize))
+	sb.WriteString(fmt.Sprintf("- Index engine: %s\n", r.Platform.IndexEngine))
+	sb.WriteString(fmt.Sprintf("- Git: %s\n", gitVersion))
+	return sb.String()
+}
+
+// diskUsage returns the total size of the regular files under dir, or -1 if
+// it cannot be read.
+func diskUsage(dir string) int64 {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return -1
+	}
+	return total
+}
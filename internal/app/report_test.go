@@ -0,0 +1,61 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestNewSelfReport(t *testing.T) {
+	baseDir := t.TempDir()
+	indexDir := filepath.Join(baseDir, "indexes", "repo.bleve")
+	if err := os.MkdirAll(indexDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(indexDir, "store"), make([]byte, 1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &config.Settings{
+		Transport: "sse",
+		GitRepos: config.GitReposSettings{
+			BaseDir: baseDir,
+			URLs:    []string{"git@github.com:org/a.git", "git@github.com:org/b.git"},
+		},
+	}
+
+	report := NewSelfReport(BuildInfo{Version: "1.2.3", Build: "abc123"}, settings)
+	if report.Repos != 2 || report.AuthType != config.AuthTypeNone || report.IndexBytes != 1024 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	if report.Platform.OS != runtime.GOOS || report.Platform.Arch != runtime.GOARCH {
+		t.Errorf("Expected the running platform, got %+v", report.Platform)
+	}
+
+	text := report.String()
+	for _, want := range []string{"Version: 1.2.3 (build abc123)", "Platform: " + runtime.GOOS + "/" + runtime.GOARCH + ", go", "Index engine: bleve", "Transport: sse", "Repositories: 2", "Base directory: " + baseDir, "Index disk usage: 0.0 MB"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in report:\n%s", want, text)
+		}
+	}
+}
+
+func TestNewSelfReport_LocalDirMissingIndexes(t *testing.T) {
+	settings := &config.Settings{
+		Transport: "stdio",
+		GitRepos:  config.GitReposSettings{BaseDir: filepath.Join(t.TempDir(), "missing"), LocalDir: "/src/project"},
+	}
+
+	report := NewSelfReport(BuildInfo{Version: "dev"}, settings)
+	if report.Repos != 1 {
+		t.Errorf("Expected the working directory to count as one repository, got %d", report.Repos)
+	}
+	if report.IndexBytes != -1 || !strings.Contains(report.String(), "Index disk usage: unknown") {
+		t.Errorf("Expected unknown index size, got %d", report.IndexBytes)
+	}
+}
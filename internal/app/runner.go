@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
@@ -17,9 +18,10 @@ import (
 type RunParams struct {
 	LoadSettings      func(*pflag.FlagSet) (*config.Settings, error)
 	ValidSettings     func(*config.Settings) error
-	StartSSEServer    func(*mcp.Server, *config.Settings) error
-	CreateServer      func(*config.Settings) (*mcp.Server, func(), error)
+	StartSSEServer    func(context.Context, *mcp.Server, *config.Settings) error
+	CreateServer      func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error)
 	CustomIOTransport mcp.Transport // Optional: for testing with custom IO
+	LogWriter         io.Writer     // Optional: where logs are written instead of stderr
 }
 
 // DefaultRunParams returns production dependencies
@@ -33,7 +35,7 @@ func DefaultRunParams() RunParams {
 }
 
 // RunWithDeps executes the server with the provided dependencies
-func RunWithDeps(ctx context.Context, params RunParams, flags *pflag.FlagSet, version string) error {
+func RunWithDeps(ctx context.Context, params RunParams, flags *pflag.FlagSet, info BuildInfo) error {
 	// Load settings
 	settings, err := params.LoadSettings(flags)
 	if err != nil {
@@ -45,14 +47,23 @@ func RunWithDeps(ctx context.Context, params RunParams, flags *pflag.FlagSet, ve
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Configure logging - always use stderr to avoid buffering issues
-	handler := slog.NewTextHandler(os.Stderr, nil)
-	slog.SetDefault(slog.New(handler))
+	configureLogging(params.LogWriter)
 
-	slog.Info("Starting MCP RELIC server", "version", version)
+	slog.Info("Starting MCP RELIC server", NewSelfReport(info, settings).LogAttrs()...)
 	config.Log(settings)
 
-	mcpServer, cleanup, err := params.CreateServer(settings)
+	reload := func() (*config.Settings, error) {
+		next, err := params.LoadSettings(flags)
+		if err != nil {
+			return nil, err
+		}
+		if err := params.ValidSettings(next); err != nil {
+			return nil, err
+		}
+		return next, nil
+	}
+
+	mcpServer, cleanup, err := params.CreateServer(settings, reload, info)
 	if err != nil {
 		return err
 	}
@@ -60,23 +71,51 @@ func RunWithDeps(ctx context.Context, params RunParams, flags *pflag.FlagSet, ve
 		defer cleanup()
 	}
 
+	// Forward index activity to connected clients
+	slog.SetDefault(slog.New(mcputil.NewClientLogHandler(slog.Default().Handler(), mcpServer, settings.ClientLogLevel)))
+	// Tag what is logged while serving a tool call with its request ID
+	slog.SetDefault(slog.New(mcputil.NewRequestIDLogHandler(slog.Default().Handler())))
+
 	// Start server
 	if settings.Transport == "stdio" {
 		// Use custom transport if provided (for testing), otherwise use stdio
 		transport := params.CustomIOTransport
 		if transport == nil {
-			transport = &mcp.StdioTransport{}
+			transport = mcputil.NewGuardedIOTransport(os.Stdin, os.Stdout)
+		}
+		if settings.DebugStdio != "" {
+			frameLog, err := os.OpenFile(settings.DebugStdio, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				return fmt.Errorf("failed to open stdio debug log: %w", err)
+			}
+			defer func() { _ = frameLog.Close() }()
+			transport = mcputil.NewFrameLogTransport(transport, frameLog)
+		}
+		if err := mcpServer.Run(ctx, transport); err != nil && ctx.Err() == nil {
+			return err
 		}
-		return mcpServer.Run(ctx, transport)
+		return nil
 	} else {
-		slog.Info("Starting SSE server", "host", settings.Host, "port", settings.Port)
-		return params.StartSSEServer(mcpServer, settings)
+		slog.Info("Starting HTTP server", "transport", settings.Transport, "host", settings.Host, "port", settings.Port, "path", settings.MCPPath())
+		return params.StartSSEServer(ctx, mcpServer, settings)
 	}
 }
 
-// CreateMCPServer creates the MCP server with registered tools
-func CreateMCPServer(settings *config.Settings) (*mcp.Server, func(), error) {
+// configureLogging sets up the default logger, writing to w.
+// Defaults to stderr to avoid buffering issues and to keep stdout free for stdio transport.
+func configureLogging(w io.Writer) {
+	if w == nil {
+		w = os.Stderr
+	}
+	handler := slog.NewTextHandler(w, nil)
+	slog.SetDefault(slog.New(handler))
+}
+
+// CreateMCPServer creates the MCP server with registered tools.
+// If reload is non-nil, settings are re-read and applied on SIGHUP.
+func CreateMCPServer(settings *config.Settings, reload SettingsLoader, info BuildInfo) (*mcp.Server, func(), error) {
 	var gitReposSvc mcputil.GitReposToolService
+	var gitReposError string
 	var cleanup func()
 
 	svc, err := gitrepos.NewService(&settings.GitRepos)
@@ -87,14 +126,20 @@ func CreateMCPServer(settings *config.Settings) (*mcp.Server, func(), error) {
 	// Initialize in background context (not tied to request context)
 	if err := svc.Initialize(context.Background()); err != nil {
 		slog.Error("Git repos initialization failed", "error", err)
+		gitReposError = err.Error()
 		// Close service on initialization failure and continue without it
 		if closeErr := svc.Close(); closeErr != nil {
 			slog.Error("Failed to close git repos service", "error", closeErr)
 		}
 	} else {
 		gitReposSvc = svc
+		stopReload := func() {}
+		if reload != nil {
+			stopReload = watchReload(svc, settings, reload)
+		}
 		// Set up cleanup function
 		cleanup = func() {
+			stopReload()
 			if err := svc.Close(); err != nil {
 				slog.Error("Failed to close git repos service", "error", err)
 			}
@@ -102,10 +147,28 @@ func CreateMCPServer(settings *config.Settings) (*mcp.Server, func(), error) {
 	}
 
 	server := mcputil.CreateServer(mcputil.ServerConfig{
-		Name:        "relic-mcp",
-		Version:     "1.0.0",
-		GitReposSvc: gitReposSvc,
+		Name:          "relic-mcp",
+		Version:       info.Version,
+		Build:         info.Build,
+		GitReposSvc:   gitReposSvc,
+		GitReposError: gitReposError,
+		Report: func() string {
+			return NewSelfReport(info, settings).String()
+		},
+		IndexUpdateNotify: settings.IndexUpdateNotify,
+		RequestIDFooter:   settings.RequestIDFooter,
 	})
 
+	if gitReposSvc != nil {
+		stopNotify := mcputil.WatchIndexUpdates(server, gitReposSvc, settings.IndexUpdateNotify)
+		stopReadiness := mcputil.WatchToolReadiness(server, gitReposSvc)
+		closeService := cleanup
+		cleanup = func() {
+			stopReadiness()
+			stopNotify()
+			closeService()
+		}
+	}
+
 	return server, cleanup, nil
 }
@@ -3,6 +3,8 @@ package app
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -52,7 +54,7 @@ func TestRunWithDeps_ErrorCases(t *testing.T) {
 					return &config.Settings{Transport: "sse"}, nil
 				},
 				ValidSettings: noopValidate,
-				CreateServer: func(*config.Settings) (*mcp.Server, func(), error) {
+				CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
 					return nil, nil, errors.New("create server error")
 				},
 			},
@@ -65,10 +67,10 @@ func TestRunWithDeps_ErrorCases(t *testing.T) {
 					return &config.Settings{Transport: "sse"}, nil
 				},
 				ValidSettings: noopValidate,
-				CreateServer: func(*config.Settings) (*mcp.Server, func(), error) {
-					return nil, nil, nil
+				CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+					return mcp.NewServer(&mcp.Implementation{Name: "test"}, nil), nil, nil
 				},
-				StartSSEServer: func(*mcp.Server, *config.Settings) error {
+				StartSSEServer: func(context.Context, *mcp.Server, *config.Settings) error {
 					return errors.New("sse start error")
 				},
 			},
@@ -78,7 +80,7 @@ func TestRunWithDeps_ErrorCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := RunWithDeps(context.Background(), tt.params, nil, "test")
+			err := RunWithDeps(context.Background(), tt.params, nil, BuildInfo{Version: "test"})
 			if err == nil {
 				t.Fatalf("Expected error containing %q, got nil", tt.wantErrContain)
 			}
@@ -96,15 +98,15 @@ func TestRunWithDeps_Cleanup(t *testing.T) {
 			return &config.Settings{Transport: "sse"}, nil
 		},
 		ValidSettings: noopValidate,
-		CreateServer: func(*config.Settings) (*mcp.Server, func(), error) {
-			return nil, func() { cleanupCalled = true }, nil
+		CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+			return mcp.NewServer(&mcp.Implementation{Name: "test"}, nil), func() { cleanupCalled = true }, nil
 		},
-		StartSSEServer: func(*mcp.Server, *config.Settings) error {
+		StartSSEServer: func(context.Context, *mcp.Server, *config.Settings) error {
 			return errors.New("intentional error to trigger cleanup")
 		},
 	}
 
-	_ = RunWithDeps(context.Background(), params, nil, "test")
+	_ = RunWithDeps(context.Background(), params, nil, BuildInfo{Version: "test"})
 
 	if !cleanupCalled {
 		t.Error("Cleanup was not called")
@@ -134,7 +136,7 @@ func TestRunWithDeps_StdioWithDefaultTransport(t *testing.T) {
 			return &config.Settings{Transport: "stdio"}, nil
 		},
 		ValidSettings: noopValidate,
-		CreateServer: func(*config.Settings) (*mcp.Server, func(), error) {
+		CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
 			impl := &mcp.Implementation{Name: "test", Version: "1.0"}
 			server := mcp.NewServer(impl, nil)
 			return server, nil, nil
@@ -146,7 +148,7 @@ func TestRunWithDeps_StdioWithDefaultTransport(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := RunWithDeps(ctx, params, nil, "test")
+	err := RunWithDeps(ctx, params, nil, BuildInfo{Version: "test"})
 
 	// We expect an error because the context is cancelled
 	if err == nil {
@@ -165,7 +167,7 @@ func TestRunWithDeps_StdioWithCustomTransport(t *testing.T) {
 			return &config.Settings{Transport: "stdio"}, nil
 		},
 		ValidSettings: noopValidate,
-		CreateServer: func(*config.Settings) (*mcp.Server, func(), error) {
+		CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
 			impl := &mcp.Implementation{Name: "test", Version: "1.0"}
 			server := mcp.NewServer(impl, nil)
 			return server, nil, nil
@@ -177,13 +179,43 @@ func TestRunWithDeps_StdioWithCustomTransport(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	_ = RunWithDeps(ctx, params, nil, "test")
+	_ = RunWithDeps(ctx, params, nil, BuildInfo{Version: "test"})
 
 	if !transportUsed {
 		t.Error("Custom transport Connect was not called")
 	}
 }
 
+func TestRunWithDeps_DebugStdio(t *testing.T) {
+	dir := t.TempDir()
+	newParams := func(path string) RunParams {
+		return RunParams{
+			LoadSettings: func(*pflag.FlagSet) (*config.Settings, error) {
+				return &config.Settings{Transport: "stdio", DebugStdio: path}, nil
+			},
+			ValidSettings: noopValidate,
+			CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+				return mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil), nil, nil
+			},
+			CustomIOTransport: &mockTransport{},
+		}
+	}
+
+	path := filepath.Join(dir, "frames.log")
+	err := RunWithDeps(context.Background(), newParams(path), nil, BuildInfo{Version: "test"})
+	if err == nil || !strings.Contains(err.Error(), "mock transport") {
+		t.Errorf("Expected the wrapped transport to be connected, got: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a private frame log file, got %v (err: %v)", info, err)
+	}
+
+	err = RunWithDeps(context.Background(), newParams(filepath.Join(dir, "missing", "frames.log")), nil, BuildInfo{Version: "test"})
+	if err == nil || !strings.Contains(err.Error(), "failed to open stdio debug log") {
+		t.Errorf("Expected an error opening the frame log, got: %v", err)
+	}
+}
+
 func TestCreateMCPServer_WithGitRepos(t *testing.T) {
 	dir := t.TempDir()
 
@@ -198,7 +230,7 @@ func TestCreateMCPServer_WithGitRepos(t *testing.T) {
 		},
 	}
 
-	server, cleanup, err := CreateMCPServer(settings)
+	server, cleanup, err := CreateMCPServer(settings, nil, BuildInfo{Version: "test"})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -221,7 +253,7 @@ func TestCreateMCPServer_WithGitReposInvalidDir(t *testing.T) {
 		},
 	}
 
-	_, _, err := CreateMCPServer(settings)
+	_, _, err := CreateMCPServer(settings, nil, BuildInfo{Version: "test"})
 	// This should fail because the base directory can't be created
 	if err == nil {
 		t.Error("Expected error for invalid base directory")
@@ -244,7 +276,7 @@ func TestCreateMCPServer_WithGitReposInitFailure(t *testing.T) {
 
 	// CreateMCPServer should succeed even when git repos init has issues
 	// (it logs errors but continues)
-	server, cleanup, err := CreateMCPServer(settings)
+	server, cleanup, err := CreateMCPServer(settings, nil, BuildInfo{Version: "test"})
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -262,16 +294,16 @@ func TestRunWithDeps_SSEWithNilCleanup(t *testing.T) {
 			return &config.Settings{Transport: "sse"}, nil
 		},
 		ValidSettings: noopValidate,
-		CreateServer: func(*config.Settings) (*mcp.Server, func(), error) {
+		CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
 			// Return nil cleanup (no git repos)
-			return nil, nil, nil
+			return mcp.NewServer(&mcp.Implementation{Name: "test"}, nil), nil, nil
 		},
-		StartSSEServer: func(*mcp.Server, *config.Settings) error {
+		StartSSEServer: func(context.Context, *mcp.Server, *config.Settings) error {
 			return errors.New("intentional error")
 		},
 	}
 
-	err := RunWithDeps(context.Background(), params, nil, "test")
+	err := RunWithDeps(context.Background(), params, nil, BuildInfo{Version: "test"})
 	if err == nil {
 		t.Error("Expected error")
 	}
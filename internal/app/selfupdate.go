@@ -0,0 +1,353 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ReleaseRepository is the GitHub repository releases are published to
+	ReleaseRepository = "sha1n/mcp-relic-server"
+
+	// ReleaseChecksumsAsset lists the SHA-256 checksum of every release
+	// binary, in sha256sum format
+	ReleaseChecksumsAsset = "checksums.txt"
+	// ReleaseSignatureAsset is the ed25519 signature of the checksums asset,
+	// raw or base64 encoded
+	ReleaseSignatureAsset = ReleaseChecksumsAsset + ".sig"
+
+	// defaultGitHubAPIURL is the GitHub API self-update queries
+	defaultGitHubAPIURL = "https://api.github.com"
+	// selfUpdateTimeout bounds each request of a self-update, downloads included
+	selfUpdateTimeout = 5 * time.Minute
+	// maxReleaseAsset is the size of the largest release asset downloaded
+	maxReleaseAsset = 256 << 20
+)
+
+// SelfUpdateOptions controls what RunSelfUpdate does
+type SelfUpdateOptions struct {
+	CheckOnly bool // report whether an update is available without installing it
+	// PublicKey is the base64 ed25519 key the checksums of a release must be
+	// signed with. Empty only checks the binary against the checksums of the
+	// same release, which detects corrupted downloads but does not prove who
+	// published it
+	PublicKey string
+
+	APIURL     string       // GitHub API URL; empty uses api.github.com
+	Executable string       // binary to replace; empty uses the running executable
+	Client     *http.Client // empty uses a client with selfUpdateTimeout
+}
+
+// githubRelease is the part of a GitHub release self-update reads
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of the named asset, or an empty string.
+func (r *githubRelease) assetURL(name string) string {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL
+		}
+	}
+	return ""
+}
+
+// RunSelfUpdate replaces the running binary with the one of the latest
+// GitHub release, if it is newer than info.Version. The binary is only
+// installed if its SHA-256 checksum matches the checksums asset of the
+// release, and, with a public key, if the checksums are signed with it.
+// Without a public key, only the integrity of the download is checked, and
+// the output says so.
+func RunSelfUpdate(ctx context.Context, w io.Writer, info BuildInfo, programName string, opts SelfUpdateOptions) error {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: selfUpdateTimeout}
+	}
+	var publicKey ed25519.PublicKey
+	if opts.PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(opts.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return errors.New("invalid public key: expected a base64 ed25519 public key")
+		}
+		publicKey = key
+	}
+
+	release, err := latestRelease(ctx, client, opts.APIURL, info.Version)
+	if err != nil {
+		return err
+	}
+	newer, ok := compareVersions(release.TagName, info.Version)
+	if !ok {
+		if _, err := fmt.Fprintf(w, "The latest release is %s (%s)\n", release.TagName, release.HTMLURL); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s is not a release build and cannot be updated", info.Version)
+	}
+	if newer <= 0 {
+		_, err := fmt.Fprintf(w, "%s %s is up to date\n", programName, info.Version)
+		return err
+	}
+	if opts.CheckOnly {
+		_, err := fmt.Fprintf(w, "Update available: %s -> %s (%s)\n", info.Version, release.TagName, release.HTMLURL)
+		return err
+	}
+
+	asset := fmt.Sprintf("%s-%s-%s", programName, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	binaryURL := release.assetURL(asset)
+	if binaryURL == "" {
+		return fmt.Errorf("release %s has no %s binary", release.TagName, asset)
+	}
+	checksumsURL := release.assetURL(ReleaseChecksumsAsset)
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no %s; refusing to install an unverified binary", release.TagName, ReleaseChecksumsAsset)
+	}
+
+	checksums, err := download(ctx, client, checksumsURL, info.Version)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", ReleaseChecksumsAsset, err)
+	}
+	if publicKey != nil {
+		if err := verifyChecksumsSignature(ctx, client, release, checksums, publicKey, info.Version); err != nil {
+			return err
+		}
+	} else if _, err := fmt.Fprintf(w, "No public key set: checking %s against %s for integrity only, not for authenticity\n", asset, ReleaseChecksumsAsset); err != nil {
+		return err
+	}
+	want, err := assetChecksum(checksums, asset)
+	if err != nil {
+		return err
+	}
+
+	binary, err := download(ctx, client, binaryURL, info.Version)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	if got := sha256.Sum256(binary); hex.EncodeToString(got[:]) != want {
+		return fmt.Errorf("checksum mismatch for %s: refusing to install it", asset)
+	}
+
+	executable := opts.Executable
+	if executable == "" {
+		if executable, err = os.Executable(); err != nil {
+			return fmt.Errorf("failed to locate the executable: %w", err)
+		}
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Updated %s %s -> %s\n", programName, info.Version, release.TagName)
+	return err
+}
+
+// latestRelease fetches the latest release of ReleaseRepository. A
+// GITHUB_TOKEN in the environment is used to avoid anonymous rate limits.
+func latestRelease(ctx context.Context, client *http.Client, apiURL, version string) (*githubRelease, error) {
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(apiURL, "/")+"/repos/"+ReleaseRepository+"/releases/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "relic-mcp/"+version)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the latest release: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query the latest release: %s", resp.Status)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReleaseAsset)).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to read the latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// download returns the content at url, up to maxReleaseAsset bytes.
+func download(ctx context.Context, client *http.Client, url, version string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "relic-mcp/"+version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReleaseAsset+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxReleaseAsset {
+		return nil, fmt.Errorf("larger than %d bytes", maxReleaseAsset)
+	}
+	return data, nil
+}
+
+// verifyChecksumsSignature checks that the checksums of release are signed
+// with publicKey.
+func verifyChecksumsSignature(ctx context.Context, client *http.Client, release *githubRelease, checksums []byte, publicKey ed25519.PublicKey, version string) error {
+	url := release.assetURL(ReleaseSignatureAsset)
+	if url == "" {
+		return fmt.Errorf("release %s has no %s; refusing to install an unsigned binary", release.TagName, ReleaseSignatureAsset)
+	}
+	signature, err := download(ctx, client, url, version)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", ReleaseSignatureAsset, err)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+			signature = decoded
+		}
+	}
+	if !ed25519.Verify(publicKey, checksums, signature) {
+		return fmt.Errorf("invalid signature of the %s checksums: refusing to install it", release.TagName)
+	}
+	return nil
+}
+
+// assetChecksum returns the hex SHA-256 checksum of asset in checksums, in
+// sha256sum format.
+func assetChecksum(checksums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", ReleaseChecksumsAsset, asset)
+}
+
+// replaceExecutable atomically replaces the file at path, following
+// symlinks, with binary. A running Windows executable can't be replaced, but
+// it can be renamed, so it is moved aside first.
+func replaceExecutable(path string, binary []byte) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to locate the executable: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to locate the executable: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the update: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0100); err != nil {
+		return fmt.Errorf("failed to write the update: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// compareVersions compares two vMAJOR.MINOR.PATCH versions, with an optional
+// pre-release suffix that sorts before the release. ok is false if either is
+// not such a version, e.g. a development build.
+func compareVersions(a, b string) (cmp int, ok bool) {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for n := range 3 {
+		if va.parts[n] != vb.parts[n] {
+			if va.parts[n] < vb.parts[n] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	switch {
+	case va.pre == vb.pre:
+		return 0, true
+	case va.pre == "":
+		return 1, true
+	case vb.pre == "":
+		return -1, true
+	default:
+		return strings.Compare(va.pre, vb.pre), true
+	}
+}
+
+// releaseVersion is a parsed vMAJOR.MINOR.PATCH[-PRE] version
+type releaseVersion struct {
+	parts [3]int
+	pre   string
+}
+
+func parseVersion(s string) (releaseVersion, bool) {
+	var v releaseVersion
+	s = strings.TrimPrefix(s, "v")
+	s, v.pre, _ = strings.Cut(s, "-")
+	fields := strings.Split(s, ".")
+	if len(fields) != 3 {
+		return v, false
+	}
+	for n, field := range fields {
+		part, err := strconv.Atoi(field)
+		if err != nil || part < 0 {
+			return v, false
+		}
+		v.parts[n] = part
+	}
+	return v, true
+}
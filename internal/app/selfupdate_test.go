@@ -0,0 +1,229 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeRelease serves a GitHub release API and the assets of a release.
+type fakeRelease struct {
+	tag    string
+	assets map[string][]byte
+}
+
+func (f *fakeRelease) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/"+ReleaseRepository+"/releases/latest" {
+			release := map[string]any{"tag_name": f.tag, "html_url": "https://example.com/" + f.tag}
+			var assets []map[string]string
+			for name := range f.assets {
+				assets = append(assets, map[string]string{"name": name, "browser_download_url": server.URL + "/download/" + name})
+			}
+			release["assets"] = assets
+			_ = json.NewEncoder(w).Encode(release)
+			return
+		}
+		content, ok := f.assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// releaseAsset is the name of the release binary of the test platform
+func releaseAsset() string {
+	name := fmt.Sprintf("relic-mcp-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// newFakeRelease returns a release of binary with its checksums.
+func newFakeRelease(tag string, binary []byte) *fakeRelease {
+	sum := sha256.Sum256(binary)
+	return &fakeRelease{tag: tag, assets: map[string][]byte{
+		releaseAsset():        binary,
+		ReleaseChecksumsAsset: []byte(fmt.Sprintf("0000  relic-mcp-plan9-386\n%s  %s\n", hex.EncodeToString(sum[:]), releaseAsset())),
+	}}
+}
+
+// writeExecutable writes the binary self-update replaces.
+func writeExecutable(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "relic-mcp")
+	if err := os.WriteFile(path, []byte("old binary"), 0755); err != nil {
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+	return path
+}
+
+func runSelfUpdate(t *testing.T, release *fakeRelease, version string, opts SelfUpdateOptions) (string, error) {
+	t.Helper()
+	opts.APIURL = release.serve(t).URL
+	var out bytes.Buffer
+	err := RunSelfUpdate(context.Background(), &out, BuildInfo{Version: version}, "relic-mcp", opts)
+	return out.String(), err
+}
+
+func TestRunSelfUpdate_ReplacesBinary(t *testing.T) {
+	executable := writeExecutable(t)
+	link := filepath.Join(t.TempDir(), "relic-mcp")
+	if err := os.Symlink(executable, link); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	out, err := runSelfUpdate(t, newFakeRelease("v1.3.0", []byte("new binary")), "v1.2.9", SelfUpdateOptions{Executable: link})
+	if err != nil {
+		t.Fatalf("RunSelfUpdate failed: %v", err)
+	}
+	// Without a public key, only the integrity of the binary is checked
+	want := "No public key set: checking " + releaseAsset() + " against checksums.txt for integrity only, not for authenticity\n" +
+		"Updated relic-mcp v1.2.9 -> v1.3.0\n"
+	if out != want {
+		t.Errorf("Unexpected output: %q", out)
+	}
+	data, _ := os.ReadFile(executable)
+	info, _ := os.Stat(executable)
+	if string(data) != "new binary" || info.Mode().Perm() != 0755 {
+		t.Errorf("Expected the symlinked executable to be replaced, got %q (%v)", data, info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(executable)); len(entries) != 1 {
+		t.Errorf("Expected no leftover files, got %d entries", len(entries))
+	}
+}
+
+func TestRunSelfUpdate_CheckOnlyAndUpToDate(t *testing.T) {
+	executable := writeExecutable(t)
+	release := newFakeRelease("v1.3.0", []byte("new binary"))
+
+	tests := []struct {
+		version string
+		opts    SelfUpdateOptions
+		want    string
+	}{
+		{"v1.2.9", SelfUpdateOptions{CheckOnly: true}, "Update available: v1.2.9 -> v1.3.0 (https://example.com/v1.3.0)\n"},
+		{"v1.3.0", SelfUpdateOptions{}, "relic-mcp v1.3.0 is up to date\n"},
+		{"v1.4.0-rc1", SelfUpdateOptions{}, "relic-mcp v1.4.0-rc1 is up to date\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			tt.opts.Executable = executable
+			out, err := runSelfUpdate(t, release, tt.version, tt.opts)
+			if err != nil || out != tt.want {
+				t.Errorf("Expected %q, got %q (err: %v)", tt.want, out, err)
+			}
+			if data, _ := os.ReadFile(executable); string(data) != "old binary" {
+				t.Errorf("Expected the executable to be left alone, got %q", data)
+			}
+		})
+	}
+}
+
+func TestRunSelfUpdate_Signature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(publicKey)
+
+	release := newFakeRelease("v1.3.0", []byte("new binary"))
+	executable := writeExecutable(t)
+	_, err = runSelfUpdate(t, release, "v1.2.9", SelfUpdateOptions{Executable: executable, PublicKey: encodedKey})
+	if err == nil || !strings.Contains(err.Error(), "refusing to install an unsigned binary") {
+		t.Errorf("Expected an unsigned release to be refused, got: %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	release.assets[ReleaseSignatureAsset] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, release.assets[ReleaseChecksumsAsset])) + "\n")
+	_, err = runSelfUpdate(t, release, "v1.2.9", SelfUpdateOptions{Executable: executable, PublicKey: base64.StdEncoding.EncodeToString(otherKey)})
+	if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("Expected a signature of another key to be refused, got: %v", err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "old binary" {
+		t.Errorf("Expected the executable to be left alone, got %q", data)
+	}
+
+	if _, err := runSelfUpdate(t, release, "v1.2.9", SelfUpdateOptions{Executable: executable, PublicKey: encodedKey}); err != nil {
+		t.Fatalf("RunSelfUpdate failed: %v", err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "new binary" {
+		t.Errorf("Expected the executable to be replaced, got %q", data)
+	}
+}
+
+func TestRunSelfUpdate_Errors(t *testing.T) {
+	tampered := newFakeRelease("v1.3.0", []byte("new binary"))
+	tampered.assets[releaseAsset()] = []byte("tampered binary")
+	unverified := newFakeRelease("v1.3.0", []byte("new binary"))
+	delete(unverified.assets, ReleaseChecksumsAsset)
+	missing := newFakeRelease("v1.3.0", []byte("new binary"))
+	delete(missing.assets, releaseAsset())
+
+	tests := []struct {
+		name    string
+		release *fakeRelease
+		version string
+		opts    SelfUpdateOptions
+		wantErr string
+	}{
+		{"checksum mismatch", tampered, "v1.2.9", SelfUpdateOptions{}, "checksum mismatch"},
+		{"no checksums", unverified, "v1.2.9", SelfUpdateOptions{}, "refusing to install an unverified binary"},
+		{"no binary", missing, "v1.2.9", SelfUpdateOptions{}, "has no " + releaseAsset() + " binary"},
+		{"development build", tampered, "dev", SelfUpdateOptions{}, "dev is not a release build"},
+		{"invalid key", tampered, "v1.2.9", SelfUpdateOptions{PublicKey: "not-a-key"}, "invalid public key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Executable = writeExecutable(t)
+			_, err := runSelfUpdate(t, tt.release, tt.version, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			if data, _ := os.ReadFile(tt.opts.Executable); string(data) != "old binary" {
+				t.Errorf("Expected the executable to be left alone, got %q", data)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{"v1.2.3", "v1.2.3", 0, true},
+		{"v1.10.0", "v1.9.9", 1, true},
+		{"1.2.3", "v2.0.0", -1, true},
+		{"v1.2.3-rc1", "v1.2.3", -1, true},
+		{"v1.2.3-rc2", "v1.2.3-rc1", 1, true},
+		{"v1.2.3", "dev", 0, false},
+		{"v1.2", "v1.2.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareVersions(tt.a, tt.b)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("compareVersions(%q, %q) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
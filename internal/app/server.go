@@ -1,32 +1,77 @@
 package app
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/sha1n/mcp-relic-server/internal/auth"
 	"github.com/sha1n/mcp-relic-server/internal/config"
+	mcputil "github.com/sha1n/mcp-relic-server/internal/mcp"
 )
 
-// StartSSEServer starts the SSE server with authentication
-func StartSSEServer(s *mcp.Server, settings *config.Settings) error {
+// sseShutdownTimeout is how long a stopping SSE or HTTP server waits for
+// requests in flight; event streams still open after it are closed
+const sseShutdownTimeout = 5 * time.Second
+
+// sessionIdleTimeout closes the streamable HTTP sessions that receive no
+// request for that long, e.g. because their client went away without
+// deleting them
+const sessionIdleTimeout = time.Hour
+
+// StartSSEServer starts the SSE or streamable HTTP server, depending on the
+// transport, with authentication. It returns once ctx is done and the server
+// has shut down.
+func StartSSEServer(ctx context.Context, s *mcp.Server, settings *config.Settings) error {
 	srv, err := NewSSEServer(s, settings)
 	if err != nil {
 		return err
 	}
 
-	slog.Info("Server listening (HTTP)", "addr", srv.Addr, "auth_type", settings.Auth.Type)
-	return srv.ListenAndServe()
+	shutdown := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(shutdown)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), sseShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			_ = srv.Close()
+		}
+	})
+	defer stop()
+
+	if settings.TLS.Enabled() {
+		slog.Info("Server listening (HTTPS)", "addr", srv.Addr, "auth_type", settings.Auth.Type)
+		err = srv.ListenAndServeTLS(settings.TLS.CertFile, settings.TLS.KeyFile)
+	} else {
+		slog.Info("Server listening (HTTP)", "addr", srv.Addr, "auth_type", settings.Auth.Type)
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		// Serving stops as soon as shutdown starts; wait for it to finish
+		<-shutdown
+		slog.Info("Server stopped")
+		return nil
+	}
+	return err
 }
 
-// NewSSEServer creates a new SSE server with authentication middleware
+// NewSSEServer creates a new SSE server with authentication middleware. With
+// the http transport, the MCP endpoint is served with the streamable HTTP
+// transport at /mcp instead of /sse.
 func NewSSEServer(s *mcp.Server, settings *config.Settings) (*http.Server, error) {
 	// Factory function returns the server instance for each request
-	sseHandler := mcp.NewSSEHandler(func(r *http.Request) *mcp.Server {
+	getServer := func(r *http.Request) *mcp.Server {
 		return s
-	}, nil)
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -34,7 +79,19 @@ func NewSSEServer(s *mcp.Server, settings *config.Settings) (*http.Server, error
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.Handle("/sse", sseHandler)
+	if settings.Transport == "http" {
+		// Batches were dropped from the protocol version that introduced
+		// streamable HTTP, so messages are posted one by one
+		mux.Handle("/mcp", auth.NewSessionBinding(mcputil.NewRequestIDHandler(mcp.NewStreamableHTTPHandler(getServer, &mcp.StreamableHTTPOptions{SessionTimeout: sessionIdleTimeout})), sessionIdleTimeout))
+	} else {
+		mux.Handle("/sse", auth.NewSessionBinding(mcputil.NewSSEBatchHandler(mcputil.NewRequestIDHandler(mcp.NewSSEHandler(getServer, nil))), sessionIdleTimeout))
+	}
+
+	var ui *uiAPI
+	if settings.UI {
+		ui = newUIAPI(s)
+		mux.Handle(config.UIAPIPath, ui)
+	}
 
 	authMiddleware, err := auth.NewMiddleware(settings.Auth)
 	if err != nil {
@@ -42,10 +99,74 @@ func NewSSEServer(s *mcp.Server, settings *config.Settings) (*http.Server, error
 	}
 
 	handler := authMiddleware(mux)
+	if settings.Pprof {
+		if handler, err = withDebugEndpoints(handler, settings.Auth); err != nil {
+			return nil, err
+		}
+		slog.Warn("Profiling endpoints enabled", "path", config.DebugPath)
+	}
+	if ui != nil {
+		handler = withUIPage(handler)
+		slog.Info("Web UI enabled", "path", "/ui/")
+	}
 	addr := fmt.Sprintf("%s:%d", settings.Host, settings.Port)
 
-	return &http.Server{
-		Addr:    addr,
-		Handler: handler,
-	}, nil
+	tlsConfig, err := newTLSConfig(settings.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	return srv, nil
+}
+
+// newTLSConfig returns the TLS configuration of the SSE server, or nil when
+// it serves plain HTTP. Client certificates are verified against the client
+// CAs if given, but only required by the mtls auth middleware, so that
+// excluded paths such as /health stay reachable without one.
+func newTLSConfig(settings config.TLSSettings) (*tls.Config, error) {
+	if !settings.Enabled() {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if settings.ClientCAFile != "" {
+		pem, err := os.ReadFile(settings.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", settings.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// withDebugEndpoints serves net/http/pprof and expvar (including a
+// runtime.MemStats snapshot) under /debug/, guarded by the admin API keys
+// instead of the client auth. All other paths go to next.
+func withDebugEndpoints(next http.Handler, settings config.AuthSettings) (http.Handler, error) {
+	adminMiddleware, err := auth.NewAdminMiddleware(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin middleware: %w", err)
+	}
+
+	debug := http.NewServeMux()
+	debug.HandleFunc("/debug/pprof/", pprof.Index)
+	debug.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	debug.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	debug.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	debug.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	debug.Handle("/debug/vars", expvar.Handler())
+
+	mux := http.NewServeMux()
+	mux.Handle(config.DebugPath, adminMiddleware(debug))
+	mux.Handle("/", next)
+	return mux, nil
 }
@@ -1,11 +1,27 @@
 package app
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
 	"github.com/sha1n/mcp-relic-server/internal/config"
 )
 
@@ -125,6 +141,50 @@ func TestNewSSEServer_HealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestStartSSEServer_ShutsDownWithContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	settings := &config.Settings{
+		Host: "127.0.0.1",
+		Port: port,
+		Auth: config.AuthSettings{Type: config.AuthTypeNone},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- StartSSEServer(ctx, mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil), settings)
+	}()
+
+	healthURL := fmt.Sprintf("http://127.0.0.1:%d/health", port)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(healthURL)
+		if err == nil {
+			_ = resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got: %v", err)
+		}
+	case <-time.After(sseShutdownTimeout + time.Second):
+		t.Fatal("Server did not stop after its context was cancelled")
+	}
+}
+
 func TestNewSSEServer_HealthEndpointBypassesAuth(t *testing.T) {
 	impl := &mcp.Implementation{Name: "test", Version: "1.0"}
 	server := mcp.NewServer(impl, nil)
@@ -186,3 +246,458 @@ func TestNewSSEServer_SSEEndpointRequiresAuth(t *testing.T) {
 		t.Errorf("Expected status 401 for /sse without auth, got %d", rec.Code)
 	}
 }
+
+func TestNewSSEServer_DebugEndpoints(t *testing.T) {
+	impl := &mcp.Implementation{Name: "test", Version: "1.0"}
+	server := mcp.NewServer(impl, nil)
+
+	settings := &config.Settings{
+		Host:  "localhost",
+		Port:  8080,
+		Pprof: true,
+		Auth: config.AuthSettings{
+			Type:         config.AuthTypeAPIKey,
+			APIKeys:      []string{"client-key"},
+			AdminAPIKeys: []string{"admin-key"},
+		},
+	}
+
+	srv, err := NewSSEServer(server, settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		key  string
+		want int
+	}{
+		{"/debug/vars", "", http.StatusUnauthorized},
+		{"/debug/vars", "client-key", http.StatusUnauthorized},
+		{"/debug/vars", "admin-key", http.StatusOK},
+		{"/debug/pprof/", "admin-key", http.StatusOK},
+		{"/health", "", http.StatusOK},
+		{"/sse", "admin-key", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s with key %q: expected status %d, got %d", tt.path, tt.key, tt.want, rec.Code)
+		}
+		if tt.path == "/debug/vars" && rec.Code == http.StatusOK && !strings.Contains(rec.Body.String(), "memstats") {
+			t.Error("Expected memstats in /debug/vars")
+		}
+	}
+}
+
+func TestNewSSEServer_DebugEndpointsDisabled(t *testing.T) {
+	impl := &mcp.Implementation{Name: "test", Version: "1.0"}
+	server := mcp.NewServer(impl, nil)
+
+	settings := &config.Settings{
+		Host: "localhost",
+		Port: 8080,
+		Auth: config.AuthSettings{Type: config.AuthTypeNone, AdminAPIKeys: []string{"admin-key"}},
+	}
+
+	srv, err := NewSSEServer(server, settings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/debug/vars", nil)
+	req.Header.Set("X-API-Key", "admin-key")
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 when pprof is disabled, got %d", rec.Code)
+	}
+}
+
+// apiKeyTransport sets the X-API-Key header of every request
+type apiKeyTransport struct {
+	key string
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-API-Key", t.key)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewSSEServer_APIKeyScopesReachTools(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "can_read"}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprint(auth.HasScope(ctx, config.ScopeRead))}}}, nil, nil
+	})
+
+	srv, err := NewSSEServer(server, &config.Settings{
+		Auth: config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"full-key", "search-key:search"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	for key, want := range map[string]string{"full-key": "true", "search-key": "false"} {
+		client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "1.0"}, nil)
+		session, err := client.Connect(context.Background(), &mcp.SSEClientTransport{
+			Endpoint:   ts.URL + "/sse",
+			HTTPClient: &http.Client{Transport: apiKeyTransport{key}},
+		}, nil)
+		if err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+
+		result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "can_read"})
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if got := result.Content[0].(*mcp.TextContent).Text; got != want {
+			t.Errorf("Key %q: read scope = %s, want %s", key, got, want)
+		}
+		_ = session.Close()
+	}
+}
+
+func TestNewSSEServer_SessionBoundToAPIKey(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	srv, err := NewSSEServer(server, &config.Settings{
+		Auth: config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"admin-key:search+admin", "search-key:search"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	// Open a session with the admin key and read its endpoint
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set("X-API-Key", "admin-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /sse failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	buf := make([]byte, 512)
+	n, err := resp.Body.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read the endpoint event: %v", err)
+	}
+	var endpoint string
+	for _, line := range strings.Split(string(buf[:n]), "\n") {
+		if rest, ok := strings.CutPrefix(line, "data: "); ok {
+			endpoint = rest
+		}
+	}
+	if endpoint == "" {
+		t.Fatalf("No endpoint event in %q", buf[:n])
+	}
+
+	// Tool calls run with the scopes of the session, so messages posted with
+	// a weaker key are refused
+	body := `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	for key, want := range map[string]int{"search-key": http.StatusForbidden, "admin-key": http.StatusAccepted} {
+		post, _ := http.NewRequest(http.MethodPost, ts.URL+endpoint, strings.NewReader(body))
+		post.Header.Set("X-API-Key", key)
+		post.Header.Set("Content-Type", "application/json")
+		postResp, err := http.DefaultClient.Do(post)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		_ = postResp.Body.Close()
+		if postResp.StatusCode != want {
+			t.Errorf("Key %q: expected status %d, got %d", key, want, postResp.StatusCode)
+		}
+	}
+}
+
+func TestNewSSEServer_StreamableHTTP(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "can_read"}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprint(auth.HasScope(ctx, config.ScopeRead))}}}, nil, nil
+	})
+
+	srv, err := NewSSEServer(server, &config.Settings{
+		Transport: "http",
+		Auth:      config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"search-key:search"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	// The MCP endpoint moves to /mcp and requires auth
+	for _, path := range []string{"/mcp", "/sse"} {
+		resp, err := http.Post(ts.URL+path, "application/json", strings.NewReader(`{}`))
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for %s without auth, got %d", path, resp.StatusCode)
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "1.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   ts.URL + "/mcp",
+		HTTPClient: &http.Client{Transport: apiKeyTransport{"search-key"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "can_read"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if got := result.Content[0].(*mcp.TextContent).Text; got != "false" {
+		t.Errorf("Expected the key scopes to reach the tool, got read scope = %s", got)
+	}
+
+	// /sse is not served with the http transport
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set("X-API-Key", "search-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /sse failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for /sse, got %d", resp.StatusCode)
+	}
+}
+
+// writeTestCert writes a PEM certificate signed by parent (self-signed if
+// nil) and returns it with its key.
+func writeTestCert(t *testing.T, path string, template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestNewSSEServer_MTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := writeTestCert(t, caFile, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+	serverCert := writeTestCert(t, filepath.Join(dir, "server.pem"), &x509.Certificate{
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	clientCert := writeTestCert(t, filepath.Join(dir, "client.pem"), &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "agent-1"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "whoami"}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: auth.IdentityFromContext(ctx)}}}, nil, nil
+	})
+
+	srv, err := NewSSEServer(server, &config.Settings{
+		Auth: config.AuthSettings{Type: config.AuthTypeMTLS},
+		// The key files are not read by NewSSEServer
+		TLS: config.TLSSettings{CertFile: "server.pem", KeyFile: "server.key", ClientCAFile: caFile},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.TLS = srv.TLSConfig
+	ts.TLS.Certificates = []tls.Certificate{serverCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+
+	// Health checks do not need a client certificate, MCP sessions do
+	resp, err := newClient().Get(ts.URL + "/health")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected /health without a certificate, got %v (%v)", resp, err)
+	}
+	_ = resp.Body.Close()
+	resp, err = newClient().Get(ts.URL + "/sse")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected /sse to require a certificate, got %v (%v)", resp, err)
+	}
+	_ = resp.Body.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "1.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.SSEClientTransport{
+		Endpoint:   ts.URL + "/sse",
+		HTTPClient: newClient(clientCert),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "whoami"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if got := result.Content[0].(*mcp.TextContent).Text; got != "agent-1" {
+		t.Errorf("Expected identity agent-1, got %q", got)
+	}
+}
+
+func TestNewSSEServer_InvalidClientCA(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	_ = os.WriteFile(caFile, []byte("not a certificate"), 0600)
+
+	_, err := NewSSEServer(mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil), &config.Settings{
+		Auth: config.AuthSettings{Type: config.AuthTypeMTLS},
+		TLS:  config.TLSSettings{CertFile: "server.pem", KeyFile: "server.key", ClientCAFile: caFile},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no certificates found") {
+		t.Errorf("Expected a client CA error, got: %v", err)
+	}
+}
+
+func TestNewSSEServer_UI(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "repo_stats"}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "**github.com/org/repo**\n"}}}, nil, nil
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "search"}, func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprint(args)}}}, nil, nil
+	})
+
+	srv, err := NewSSEServer(server, &config.Settings{
+		UI:   true,
+		Auth: config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"full-key", "read-key:read"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The page holds no data and is served without auth
+	if rec := get("/ui", ""); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/ui/" {
+		t.Errorf("Expected /ui to redirect to /ui/, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get("/ui/", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<title>RELIC</title>") {
+		t.Errorf("Expected the UI page, got %d", rec.Code)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		key      string
+		wantCode int
+		wantBody string
+	}{
+		{"status without key", "/ui/api/status", "", http.StatusUnauthorized, ""},
+		{"status", "/ui/api/status", "full-key", http.StatusOK, `{"text":"**github.com/org/repo**\n"}`},
+		{"search", "/ui/api/search?query=retry&extension=go&whole_word=true&case_sensitive=false", "full-key", http.StatusOK, `{"text":"map[extension:go query:retry whole_word:true]"}`},
+		{"search without query", "/ui/api/search?query=+", "full-key", http.StatusBadRequest, "query is required"},
+		{"key without search scope", "/ui/api/status", "read-key", http.StatusForbidden, "requires an API key with the search scope"},
+		{"unknown endpoint", "/ui/api/read", "full-key", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.path, tt.key)
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body containing %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewSSEServer_UICarriesCredentials(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "search"}, func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		text := fmt.Sprintf("%s|%t|%t", auth.RankingProfileFromContext(ctx), auth.HasScope(ctx, config.ScopeSearch), auth.HasScope(ctx, config.ScopeRead))
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil, nil
+	})
+
+	srv, err := NewSSEServer(server, &config.Settings{
+		UI:   true,
+		Auth: config.AuthSettings{Type: config.AuthTypeAPIKey, APIKeys: []string{"full-key", "docs-key:search@docs-first"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	// Tool calls run with the scopes and ranking profile of each request's key
+	for key, want := range map[string]string{"full-key": `{"text":"|true|true"}`, "docs-key": `{"text":"docs-first|true|false"}`} {
+		req := httptest.NewRequest("GET", "/ui/api/search?query=retry", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %s for %s, got %d %s", want, key, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestNewSSEServer_UIDisabled(t *testing.T) {
+	srv, err := NewSSEServer(mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil), &config.Settings{
+		Auth: config.AuthSettings{Type: config.AuthTypeNone},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, path := range []string{"/ui/", "/ui/api/status"} {
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+}
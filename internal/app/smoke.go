@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+// ErrSmokeFailed is returned by RunSmoke when a step of the smoke test fails.
+var ErrSmokeFailed = errors.New("smoke test failed")
+
+var (
+	// smokeHitPattern matches the header of a search hit: repository and path
+	smokeHitPattern = regexp.MustCompile("(?m)^\\*\\*\\d+\\. (\\S+)\\*\\* `([^`]+)`")
+	// smokeTotalPattern matches the result count of a search
+	smokeTotalPattern = regexp.MustCompile(`^Found (\d+) results`)
+)
+
+// SmokeOptions controls RunSmoke
+type SmokeOptions struct {
+	Query string // search query; defaults to the name of the repository
+	// CreateServer creates the server under test; nil uses CreateMCPServer
+	CreateServer func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error)
+}
+
+// smokeStep is the outcome of one step of the smoke test.
+type smokeStep struct {
+	name    string
+	elapsed time.Duration
+	detail  string
+	err     error
+}
+
+// RunSmoke starts the server configured by flags in-process, searches the
+// first configured repository and reads the first file found, through an
+// MCP client as an agent would. Each step is reported to w with its timing;
+// it returns ErrSmokeFailed if one fails, so it can serve as a container
+// healthcheck or post-deploy check. The tool calls are bounded by the
+// configured search and read timeouts.
+func RunSmoke(ctx context.Context, w io.Writer, flags *pflag.FlagSet, info BuildInfo, opts SmokeOptions) error {
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	if err := config.ValidateSettings(settings); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	repoIDs := gitrepos.ConfiguredRepoIDs(&settings.GitRepos)
+	if len(repoIDs) == 0 {
+		return errors.New("no repositories configured")
+	}
+	repository := gitrepos.RepoIDToDisplay(repoIDs[0])
+	query := opts.Query
+	if query == "" {
+		query = path.Base(repository)
+	}
+	createServer := opts.CreateServer
+	if createServer == nil {
+		createServer = CreateMCPServer
+	}
+
+	_, _ = fmt.Fprintf(w, "Smoke test of %s\n", repository)
+	steps := runSmokeSteps(ctx, settings, info, createServer, repository, query)
+	failed := false
+	for _, step := range steps {
+		status, detail := "PASS", step.detail
+		if step.err != nil {
+			status, detail, failed = "FAIL", step.err.Error(), true
+		}
+		_, _ = fmt.Fprintf(w, "  %s  %-6s %9s  %s\n", status, step.name, step.elapsed.Round(time.Millisecond), detail)
+	}
+	if failed {
+		_, _ = fmt.Fprintln(w, "FAIL")
+		return ErrSmokeFailed
+	}
+	_, _ = fmt.Fprintln(w, "PASS")
+	return nil
+}
+
+// runSmokeSteps runs the steps of the smoke test until one fails, and
+// returns those that ran.
+func runSmokeSteps(ctx context.Context, settings *config.Settings, info BuildInfo, createServer func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error), repository, query string) []smokeStep {
+	// Start: the same initialization as the server, without a transport
+	start := time.Now()
+	server, cleanup, err := createServer(settings, nil, info)
+	if err != nil {
+		return []smokeStep{{name: "start", elapsed: time.Since(start), err: err}}
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		return []smokeStep{{name: "start", elapsed: time.Since(start), err: err}}
+	}
+	defer func() { _ = serverSession.Close() }()
+	client := mcp.NewClient(&mcp.Implementation{Name: "relic-mcp-smoke", Version: info.Version}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		return []smokeStep{{name: "start", elapsed: time.Since(start), err: err}}
+	}
+	defer func() { _ = session.Close() }()
+	steps := []smokeStep{{name: "start", elapsed: time.Since(start), detail: "server initialized"}}
+
+	// Search the repository
+	start = time.Now()
+	text, err := callSmokeTool(ctx, session, "search", map[string]any{"query": query, "repository": repository})
+	step := smokeStep{name: "search", elapsed: time.Since(start), err: err}
+	var filePath string
+	if err == nil {
+		filePath = firstSmokeHit(text, repository)
+		total := "0"
+		if m := smokeTotalPattern.FindStringSubmatch(text); m != nil {
+			total = m[1]
+		}
+		if filePath == "" {
+			step.err = fmt.Errorf("no results in %s for %q (set --query to a word the repository contains)", repository, query)
+		} else {
+			step.detail = fmt.Sprintf("%s results for %q", total, query)
+		}
+	}
+	steps = append(steps, step)
+	if step.err != nil {
+		return steps
+	}
+
+	// Read the first file found
+	start = time.Now()
+	text, err = callSmokeTool(ctx, session, "read", map[string]any{"repository": repository, "path": filePath})
+	step = smokeStep{name: "read", elapsed: time.Since(start), err: err}
+	if err == nil {
+		step.detail = fmt.Sprintf("%s (%d bytes of output)", filePath, len(text))
+	}
+	return append(steps, step)
+}
+
+// callSmokeTool calls a tool and returns the text of its result, or an
+// error if the call or the tool failed.
+func callSmokeTool(ctx context.Context, session *mcp.ClientSession, name string, args map[string]any) (string, error) {
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			texts = append(texts, strings.TrimSpace(text.Text))
+		}
+	}
+	if result.IsError {
+		return "", errors.New(strings.Join(texts, " "))
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// firstSmokeHit returns the path of the first search hit in repository, as
+// the repository filter also matches repositories whose name contains it.
+func firstSmokeHit(text, repository string) string {
+	for _, m := range smokeHitPattern.FindAllStringSubmatch(text, -1) {
+		if m[1] == repository {
+			return m[2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// smokeServer returns a CreateServer function for a server whose search and
+// read tools return the given texts, and records the arguments they get.
+func smokeServer(searchText, readText string, readErr bool, calls map[string]map[string]any) func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+	return func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+		server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+		tool := func(name, text string, isErr bool) {
+			server.AddTool(&mcp.Tool{Name: name, InputSchema: map[string]any{"type": "object"}}, func(_ context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				var args map[string]any
+				_ = json.Unmarshal(req.Params.Arguments, &args)
+				calls[name] = args
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}, IsError: isErr}, nil
+			})
+		}
+		tool("search", searchText, false)
+		tool("read", readText, readErr)
+		return server, nil, nil
+	}
+}
+
+func smokeFlags(t *testing.T) *pflag.FlagSet {
+	t.Helper()
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", t.TempDir())
+	t.Setenv("RELIC_MCP_GIT_REPOS_URLS", "git@github.com:org/api.git,git@github.com:org/web.git")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	return flags
+}
+
+func TestRunSmoke(t *testing.T) {
+	flags := smokeFlags(t)
+	search := "Found 2 results for 'api':\n\n" +
+		"**1. github.com/org/api-gateway** `gateway.go` @ `abc123`\n```go\napi\n```\n\n" +
+		"**2. github.com/org/api** `README.md` @ `def456`\n```markdown\napi\n```\n"
+	calls := map[string]map[string]any{}
+
+	var buf bytes.Buffer
+	err := RunSmoke(context.Background(), &buf, flags, BuildInfo{Version: "1.0.0"}, SmokeOptions{
+		CreateServer: smokeServer(search, "**github.com/org/api** `README.md`\n\n# API\n", false, calls),
+	})
+	if err != nil {
+		t.Fatalf("RunSmoke failed: %v\n%s", err, buf.String())
+	}
+
+	if calls["search"]["query"] != "api" || calls["search"]["repository"] != "github.com/org/api" {
+		t.Errorf("Expected a search for the name of the first repository, got %v", calls["search"])
+	}
+	if calls["read"]["path"] != "README.md" {
+		t.Errorf("Expected a read of the first hit in the repository, got %v", calls["read"])
+	}
+	out := buf.String()
+	for _, want := range []string{"Smoke test of github.com/org/api", "PASS  start", "PASS  search", `2 results for "api"`, "PASS  read", "README.md", "\nPASS\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the report, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunSmoke_Failures(t *testing.T) {
+	tests := []struct {
+		name     string
+		search   string
+		readErr  bool
+		query    string
+		wantFail string
+	}{
+		{"no results", "No results found for query: api", false, "", "FAIL  search"},
+		{"read error", "Found 1 results for 'x':\n\n**1. github.com/org/api** `main.go`\n", true, "x", "FAIL  read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := smokeFlags(t)
+			calls := map[string]map[string]any{}
+
+			var buf bytes.Buffer
+			err := RunSmoke(context.Background(), &buf, flags, BuildInfo{}, SmokeOptions{
+				Query:        tt.query,
+				CreateServer: smokeServer(tt.search, "Read did not finish within 30s", tt.readErr, calls),
+			})
+			if !errors.Is(err, ErrSmokeFailed) {
+				t.Fatalf("Expected ErrSmokeFailed, got: %v", err)
+			}
+			if out := buf.String(); !strings.Contains(out, tt.wantFail) || !strings.HasSuffix(out, "\nFAIL\n") {
+				t.Errorf("Expected %q in the report, got:\n%s", tt.wantFail, out)
+			}
+			if tt.query != "" && calls["search"]["query"] != tt.query {
+				t.Errorf("Expected the query option to be searched, got %v", calls["search"])
+			}
+		})
+	}
+}
+
+func TestRunSmoke_StartFails(t *testing.T) {
+	flags := smokeFlags(t)
+
+	var buf bytes.Buffer
+	err := RunSmoke(context.Background(), &buf, flags, BuildInfo{}, SmokeOptions{
+		CreateServer: func(*config.Settings, SettingsLoader, BuildInfo) (*mcp.Server, func(), error) {
+			return nil, nil, errors.New("no indexes")
+		},
+	})
+	if !errors.Is(err, ErrSmokeFailed) || !strings.Contains(buf.String(), "FAIL  start") || strings.Contains(buf.String(), "search") {
+		t.Errorf("Expected the smoke test to stop at start, got %v:\n%s", err, buf.String())
+	}
+}
+
+func TestRunSmoke_NoRepositories(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", t.TempDir())
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	if err := RunSmoke(context.Background(), &bytes.Buffer{}, flags, BuildInfo{}, SmokeOptions{}); err == nil {
+		t.Error("Expected an error without repositories")
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+// Syncer performs repository sync passes for the sync command.
+type Syncer interface {
+	Sync(ctx context.Context) error
+	Reindex(ctx context.Context, repository string) error
+	Close() error
+}
+
+// SyncOptions controls what RunSync does
+type SyncOptions struct {
+	Once    bool     // run a single sync pass and exit
+	Reindex []string // repositories whose indexes are rebuilt before syncing
+}
+
+// SyncParams contains dependencies for the sync command
+type SyncParams struct {
+	LoadSettings  func(*pflag.FlagSet) (*config.Settings, error)
+	ValidSettings func(*config.Settings) error
+	NewSyncer     func(*config.GitReposSettings) (Syncer, error)
+	Now           func() time.Time // the clock of the sync schedule; time.Now if nil
+}
+
+// DefaultSyncParams returns production dependencies
+func DefaultSyncParams() SyncParams {
+	return SyncParams{
+		LoadSettings:  config.LoadSettingsWithFlags,
+		ValidSettings: config.ValidateSettings,
+		NewSyncer: func(settings *config.GitReposSettings) (Syncer, error) {
+			return gitrepos.NewService(settings)
+		},
+		Now: time.Now,
+	}
+}
+
+// RunSync clones and indexes the configured repositories without serving MCP
+// requests. It is meant to run as an init container (once=true) or sidecar
+// next to servers started with --git-repos-read-only on a shared base directory.
+// The first pass runs right away, so that servers have indexes to serve; later
+// passes wait for the git-repos-sync-windows schedule.
+func RunSync(ctx context.Context, params SyncParams, flags *pflag.FlagSet, opts SyncOptions) error {
+	settings, err := params.LoadSettings(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if err := params.ValidSettings(settings); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if settings.GitRepos.ReadOnly {
+		return errors.New("sync cannot run with git-repos-read-only enabled")
+	}
+
+	configureLogging(nil)
+
+	syncer, err := params.NewSyncer(&settings.GitRepos)
+	if err != nil {
+		return fmt.Errorf("failed to create git repos service: %w", err)
+	}
+	defer func() {
+		if err := syncer.Close(); err != nil {
+			slog.Error("Failed to close git repos service", "error", err)
+		}
+	}()
+
+	schedule, err := settings.GitRepos.SyncSchedule()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	now := params.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	for _, repository := range opts.Reindex {
+		if err := syncer.Reindex(ctx, repository); err != nil {
+			return fmt.Errorf("failed to reindex %s: %w", repository, err)
+		}
+	}
+
+	for {
+		slog.Info("Starting repository sync", "repos", len(settings.GitRepos.URLs))
+		err := syncer.Sync(ctx)
+		if opts.Once {
+			return err
+		}
+		if err != nil {
+			slog.Error("Sync failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(settings.GitRepos.SyncInterval):
+		}
+
+		if wait := schedule.Wait(now()); wait > 0 {
+			slog.Info("Waiting for the next sync window", "windows", schedule.String(), "wait", wait.Round(time.Second))
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+		}
+	}
+}
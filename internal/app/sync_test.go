@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/spf13/pflag"
+)
+
+// mockSyncer counts sync passes for RunSync tests.
+type mockSyncer struct {
+	syncs      int
+	syncErr    error
+	reindexed  []string
+	reindexErr error
+	closed     bool
+	onSync     func()
+}
+
+func (m *mockSyncer) Sync(_ context.Context) error {
+	m.syncs++
+	if m.onSync != nil {
+		m.onSync()
+	}
+	return m.syncErr
+}
+
+func (m *mockSyncer) Reindex(_ context.Context, repository string) error {
+	m.reindexed = append(m.reindexed, repository)
+	return m.reindexErr
+}
+
+func (m *mockSyncer) Close() error {
+	m.closed = true
+	return nil
+}
+
+func syncParamsWith(settings *config.Settings, syncer *mockSyncer) SyncParams {
+	return SyncParams{
+		LoadSettings: func(*pflag.FlagSet) (*config.Settings, error) {
+			return settings, nil
+		},
+		ValidSettings: noopValidate,
+		NewSyncer: func(*config.GitReposSettings) (Syncer, error) {
+			return syncer, nil
+		},
+	}
+}
+
+func TestRunSync_ErrorCases(t *testing.T) {
+	tests := []struct {
+		name           string
+		params         SyncParams
+		wantErrContain string
+	}{
+		{
+			name: "LoadSettings error",
+			params: SyncParams{
+				LoadSettings: func(*pflag.FlagSet) (*config.Settings, error) {
+					return nil, errors.New("settings error")
+				},
+				ValidSettings: noopValidate,
+			},
+			wantErrContain: "failed to load settings",
+		},
+		{
+			name: "ValidSettings error",
+			params: SyncParams{
+				LoadSettings: func(*pflag.FlagSet) (*config.Settings, error) {
+					return &config.Settings{}, nil
+				},
+				ValidSettings: func(*config.Settings) error {
+					return errors.New("validation error")
+				},
+			},
+			wantErrContain: "invalid configuration",
+		},
+		{
+			name:           "Read-only mode",
+			params:         syncParamsWith(&config.Settings{GitRepos: config.GitReposSettings{ReadOnly: true}}, &mockSyncer{}),
+			wantErrContain: "read-only",
+		},
+		{
+			name: "NewSyncer error",
+			params: SyncParams{
+				LoadSettings: func(*pflag.FlagSet) (*config.Settings, error) {
+					return &config.Settings{}, nil
+				},
+				ValidSettings: noopValidate,
+				NewSyncer: func(*config.GitReposSettings) (Syncer, error) {
+					return nil, errors.New("base dir error")
+				},
+			},
+			wantErrContain: "failed to create git repos service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RunSync(context.Background(), tt.params, nil, SyncOptions{Once: true})
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.wantErrContain)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrContain) {
+				t.Errorf("Expected error containing %q, got %q", tt.wantErrContain, err.Error())
+			}
+		})
+	}
+}
+
+func TestRunSync_Once(t *testing.T) {
+	syncer := &mockSyncer{syncErr: errors.New("1 repository sync(s) failed")}
+
+	err := RunSync(context.Background(), syncParamsWith(&config.Settings{}, syncer), nil, SyncOptions{Once: true})
+
+	if err == nil {
+		t.Error("Expected sync error to be returned in once mode")
+	}
+	if syncer.syncs != 1 {
+		t.Errorf("Expected 1 sync pass, got %d", syncer.syncs)
+	}
+	if !syncer.closed {
+		t.Error("Expected syncer to be closed")
+	}
+}
+
+func TestRunSync_Reindex(t *testing.T) {
+	syncer := &mockSyncer{}
+
+	err := RunSync(context.Background(), syncParamsWith(&config.Settings{}, syncer), nil, SyncOptions{
+		Once:    true,
+		Reindex: []string{"github.com/org/a", "github.com/org/b"},
+	})
+	if err != nil {
+		t.Fatalf("RunSync failed: %v", err)
+	}
+	if len(syncer.reindexed) != 2 || syncer.reindexed[1] != "github.com/org/b" {
+		t.Errorf("Unexpected reindexed repositories: %v", syncer.reindexed)
+	}
+	if syncer.syncs != 1 {
+		t.Errorf("Expected a sync pass after reindexing, got %d", syncer.syncs)
+	}
+}
+
+func TestRunSync_ReindexError(t *testing.T) {
+	syncer := &mockSyncer{reindexErr: errors.New("repository not configured")}
+
+	err := RunSync(context.Background(), syncParamsWith(&config.Settings{}, syncer), nil, SyncOptions{
+		Once:    true,
+		Reindex: []string{"github.com/org/missing"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to reindex github.com/org/missing") {
+		t.Errorf("Expected reindex error, got: %v", err)
+	}
+	if syncer.syncs != 0 {
+		t.Errorf("Expected no sync pass after a failed reindex, got %d", syncer.syncs)
+	}
+}
+
+func TestRunSync_RepeatsUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	syncer := &mockSyncer{}
+	syncer.onSync = func() {
+		if syncer.syncs == 3 {
+			cancel()
+		}
+	}
+	settings := &config.Settings{GitRepos: config.GitReposSettings{SyncInterval: time.Millisecond}}
+
+	if err := RunSync(ctx, syncParamsWith(settings, syncer), nil, SyncOptions{}); err != nil {
+		t.Fatalf("Expected nil error on cancellation, got: %v", err)
+	}
+	if syncer.syncs != 3 {
+		t.Errorf("Expected 3 sync passes, got %d", syncer.syncs)
+	}
+}
+
+func TestRunSync_WaitsForSyncWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var passes []time.Time
+	syncer := &mockSyncer{}
+	syncer.onSync = func() {
+		passes = append(passes, time.Now())
+		if syncer.syncs == 2 {
+			cancel()
+		}
+	}
+	settings := &config.Settings{GitRepos: config.GitReposSettings{
+		SyncInterval: time.Millisecond,
+		SyncWindows:  []string{"02:00-05:00"},
+	}}
+	params := syncParamsWith(settings, syncer)
+	// 100ms before the window opens
+	params.Now = func() time.Time {
+		return time.Date(2026, 3, 1, 1, 59, 59, int(900*time.Millisecond), time.Local)
+	}
+
+	if err := RunSync(ctx, params, nil, SyncOptions{}); err != nil {
+		t.Fatalf("Expected nil error on cancellation, got: %v", err)
+	}
+	if len(passes) != 2 {
+		t.Fatalf("Expected 2 sync passes, got %d", len(passes))
+	}
+	if gap := passes[1].Sub(passes[0]); gap < 100*time.Millisecond {
+		t.Errorf("Expected the second pass to wait for the window, ran after %v", gap)
+	}
+}
+
+func TestRunSync_InvalidSyncWindows(t *testing.T) {
+	syncer := &mockSyncer{}
+	settings := &config.Settings{GitRepos: config.GitReposSettings{SyncWindows: []string{"2am-5am"}}}
+
+	err := RunSync(context.Background(), syncParamsWith(settings, syncer), nil, SyncOptions{Once: true})
+	if err == nil || !strings.Contains(err.Error(), "git-repos-sync-windows") {
+		t.Errorf("Expected invalid sync window error, got: %v", err)
+	}
+	if syncer.syncs != 0 {
+		t.Errorf("Expected no sync pass, got %d", syncer.syncs)
+	}
+}
+
+func TestDefaultSyncParams(t *testing.T) {
+	params := DefaultSyncParams()
+	if params.LoadSettings == nil {
+		t.Error("LoadSettings is nil")
+	}
+	if params.ValidSettings == nil {
+		t.Error("ValidSettings is nil")
+	}
+	if params.NewSyncer == nil {
+		t.Error("NewSyncer is nil")
+	}
+	if params.Now == nil {
+		t.Error("Now is nil")
+	}
+}
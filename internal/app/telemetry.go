@@ -0,0 +1,33 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+// PrintTelemetryReport writes the report of the search telemetry recorded in
+// the base directory of the settings described by flags.
+func PrintTelemetryReport(w io.Writer, flags *pflag.FlagSet) error {
+	settings, err := config.LoadSettingsWithFlags(flags)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	path := filepath.Join(settings.GitRepos.BaseDir, gitrepos.TelemetryFilename)
+	report, err := gitrepos.LoadTelemetryReport(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no search telemetry at %s (enable it with --git-repos-search-telemetry)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read search telemetry: %w", err)
+	}
+
+	_, err = io.WriteString(w, report.String())
+	return err
+}
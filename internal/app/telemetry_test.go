@@ -0,0 +1,37 @@
+package app
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+	"github.com/spf13/pflag"
+)
+
+func TestPrintTelemetryReport(t *testing.T) {
+	baseDir := t.TempDir()
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", baseDir)
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	var buf bytes.Buffer
+	if err := PrintTelemetryReport(&buf, flags); err == nil || !strings.Contains(err.Error(), "--git-repos-search-telemetry") {
+		t.Errorf("Expected an error without telemetry, got: %v", err)
+	}
+
+	telemetry, err := gitrepos.OpenSearchTelemetry(filepath.Join(baseDir, gitrepos.TelemetryFilename))
+	if err != nil {
+		t.Fatalf("OpenSearchTelemetry failed: %v", err)
+	}
+	telemetry.RecordSearch("", "query", 0, nil)
+	_ = telemetry.Close()
+
+	if err := PrintTelemetryReport(&buf, flags); err != nil {
+		t.Fatalf("PrintTelemetryReport failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "- Searches: 1") {
+		t.Errorf("Expected the report, got:\n%s", buf.String())
+	}
+}
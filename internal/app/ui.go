@@ -0,0 +1,140 @@
+package app
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// uiPage is the single page of the web UI. It holds no data: it is served
+// without auth so that it can ask for an API key, and fetches everything
+// from the authenticated endpoints under config.UIAPIPath.
+//
+//go:embed ui/index.html
+var uiPage []byte
+
+// uiResult is the response of the web UI endpoints: the text of a tool result
+type uiResult struct {
+	Text    string `json:"text"`
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// uiAPI serves the web UI endpoints by calling the tools of the MCP server
+// through an in-memory client session, so that the UI sees exactly what MCP
+// clients see. Each request opens its own session with its context, so that
+// the tool calls run with the identity, scopes and ranking profile of the
+// credentials of the request, as the calls of an MCP session do.
+type uiAPI struct {
+	server *mcp.Server
+	client *mcp.Client
+}
+
+// newUIAPI creates the web UI endpoints of s.
+func newUIAPI(s *mcp.Server) *uiAPI {
+	return &uiAPI{
+		server: s,
+		client: mcp.NewClient(&mcp.Implementation{Name: "relic-ui", Version: "1.0"}, nil),
+	}
+}
+
+// connect opens an in-memory client session to the server with the context
+// of r.
+func (u *uiAPI) connect(r *http.Request) (*mcp.ClientSession, error) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := u.server.Connect(r.Context(), serverTransport, nil)
+	if err != nil {
+		return nil, err
+	}
+	session, err := u.client.Connect(r.Context(), clientTransport, nil)
+	if err != nil {
+		_ = serverSession.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+// ServeHTTP serves status (repo_stats) and search (search) requests.
+func (u *uiAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !auth.HasScope(r.Context(), config.ScopeSearch) {
+		http.Error(w, "the web UI requires an API key with the search scope", http.StatusForbidden)
+		return
+	}
+
+	var params mcp.CallToolParams
+	switch strings.TrimPrefix(r.URL.Path, config.UIAPIPath) {
+	case "status":
+		params = mcp.CallToolParams{Name: "repo_stats", Arguments: map[string]any{}}
+	case "search":
+		query := r.URL.Query()
+		if strings.TrimSpace(query.Get("query")) == "" {
+			http.Error(w, "query is required", http.StatusBadRequest)
+			return
+		}
+		args := map[string]any{"query": query.Get("query")}
+		for _, name := range []string{"repository", "extension", "ref"} {
+			if value := query.Get(name); value != "" {
+				args[name] = value
+			}
+		}
+		for _, name := range []string{"case_sensitive", "whole_word", "include_generated", "directories"} {
+			if query.Get(name) == "true" {
+				args[name] = true
+			}
+		}
+		params = mcp.CallToolParams{Name: "search", Arguments: args}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	session, err := u.connect(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to connect the web UI: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = session.Close() }()
+	result, err := session.CallTool(r.Context(), &params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s failed: %v", params.Name, err), http.StatusBadGateway)
+		return
+	}
+	var text strings.Builder
+	for _, content := range result.Content {
+		if t, ok := content.(*mcp.TextContent); ok {
+			text.WriteString(t.Text)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(uiResult{Text: text.String(), IsError: result.IsError})
+}
+
+// serveUIPage serves the web UI page.
+func serveUIPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	w.Header().Set("X-Frame-Options", "DENY")
+	_, _ = w.Write(uiPage)
+}
+
+// withUIPage serves the web UI page at /ui without auth. All other paths go
+// to next.
+func withUIPage(next http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	// The page fetches relative paths, which only resolve under /ui/
+	mux.Handle("GET /ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
+	mux.HandleFunc("GET /ui/{$}", serveUIPage)
+	mux.Handle("/", next)
+	return mux
+}
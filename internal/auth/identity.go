@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+// identityKey is the context key of the authenticated client identity
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the identity of the authenticated
+// client.
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity of the authenticated client, or
+// an empty string if the auth type does not identify clients.
+func IdentityFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(string)
+	return identity
+}
+
+// CertificateIdentity returns the identity of a client certificate: its
+// common name, or without one its first DNS name, email address or URI.
+func CertificateIdentity(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	}
+	return ""
+}
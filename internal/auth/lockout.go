@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"expvar"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxAuthFailures is the number of failed attempts from one IP address,
+	// within authFailureWindow, that locks the address out
+	maxAuthFailures   = 10
+	authFailureWindow = 10 * time.Minute
+	// authLockout is how long a locked out address is refused
+	authLockout = 15 * time.Minute
+	// maxTrackedAddrs bounds the addresses tracked at once, so that failures
+	// from many addresses cannot exhaust memory
+	maxTrackedAddrs = 10000
+)
+
+// authMetrics are published with the other expvars under /debug/vars
+var authMetrics = expvar.NewMap("relic_auth")
+
+// Auth metric names
+const (
+	metricBasicFailures = "basic_failures" // failed basic auth attempts
+	metricBasicLockouts = "basic_lockouts" // addresses locked out
+	metricBasicRefused  = "basic_refused"  // requests refused during a lockout
+)
+
+// addrFailures are the recent failed attempts of one address.
+type addrFailures struct {
+	count       int
+	first       time.Time
+	lockedUntil time.Time
+}
+
+// failureTracker counts failed auth attempts per client address and locks out
+// addresses with too many of them.
+type failureTracker struct {
+	mu    sync.Mutex
+	addrs map[string]*addrFailures
+	now   func() time.Time
+}
+
+func newFailureTracker() *failureTracker {
+	return &failureTracker{
+		addrs: make(map[string]*addrFailures),
+		now:   time.Now,
+	}
+}
+
+// lockedOut returns how long addr is still locked out, or 0 if it is not.
+func (t *failureTracker) lockedOut(addr string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if f, ok := t.addrs[addr]; ok {
+		if remaining := f.lockedUntil.Sub(t.now()); remaining > 0 {
+			return remaining
+		}
+	}
+	return 0
+}
+
+// fail records a failed attempt from addr and reports whether it locked the
+// address out.
+func (t *failureTracker) fail(addr string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	f, ok := t.addrs[addr]
+	if !ok || now.Sub(f.first) > authFailureWindow {
+		if !ok && len(t.addrs) >= maxTrackedAddrs {
+			t.prune(now)
+			if len(t.addrs) >= maxTrackedAddrs {
+				return false
+			}
+		}
+		f = &addrFailures{first: now}
+		t.addrs[addr] = f
+	}
+
+	f.count++
+	if f.count < maxAuthFailures {
+		return false
+	}
+	f.count = 0
+	f.first = now
+	f.lockedUntil = now.Add(authLockout)
+	return true
+}
+
+// succeed forgets the failed attempts of addr.
+func (t *failureTracker) succeed(addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.addrs, addr)
+}
+
+// prune drops addresses that are neither locked out nor within their failure
+// window. The caller holds mu.
+func (t *failureTracker) prune(now time.Time) {
+	for addr, f := range t.addrs {
+		if now.After(f.lockedUntil) && now.Sub(f.first) > authFailureWindow {
+			delete(t.addrs, addr)
+		}
+	}
+}
+
+// clientAddr returns the IP address of the client of r. Forwarding headers
+// are not trusted.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// refuseLockedOut writes the response to a request from a locked out
+// address.
+func refuseLockedOut(w http.ResponseWriter, remaining time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Round(time.Second).Seconds())))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
@@ -3,21 +3,13 @@ package auth
 import (
 	"crypto/subtle"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/sha1n/mcp-relic-server/internal/config"
 )
 
-// excludedPaths are paths that bypass authentication (e.g., health checks)
-var excludedPaths = map[string]bool{
-	"/health": true,
-}
-
-// isExcludedPath checks if the request path should bypass authentication
-func isExcludedPath(path string) bool {
-	return excludedPaths[path]
-}
-
 // NewMiddleware creates a new authentication middleware based on settings
 func NewMiddleware(settings config.AuthSettings) (func(http.Handler) http.Handler, error) {
 	switch settings.Type {
@@ -29,23 +21,43 @@ func NewMiddleware(settings config.AuthSettings) (func(http.Handler) http.Handle
 		if settings.Basic.Username == "" || settings.Basic.Password == "" {
 			return nil, fmt.Errorf("basic auth requires non-empty username and password")
 		}
-		return withExclusions(basicAuthMiddleware(settings.Basic)), nil
+		return withExclusions(basicAuthMiddleware(settings.Basic), settings), nil
 	case config.AuthTypeAPIKey:
 		if len(settings.APIKeys) == 0 {
 			return nil, fmt.Errorf("apikey auth requires at least one API key")
 		}
-		return withExclusions(apiKeyMiddleware(settings.APIKeys)), nil
+		return withExclusions(apiKeyMiddleware(settings.APIKeys), settings), nil
+	case config.AuthTypeMTLS:
+		return withExclusions(mtlsMiddleware(settings.MTLSIdentities), settings), nil
 	default:
 		return nil, fmt.Errorf("unknown auth type: %s", settings.Type)
 	}
 }
 
-// withExclusions wraps an auth middleware to skip auth for excluded paths
-func withExclusions(authMiddleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+// NewAdminMiddleware creates the middleware guarding administrative endpoints.
+// Admin access always requires one of the admin API keys, or an API key with
+// the admin scope, in the X-API-Key header, whatever auth type is configured
+// for MCP clients.
+func NewAdminMiddleware(settings config.AuthSettings) (func(http.Handler) http.Handler, error) {
+	keys := slices.Clone(settings.AdminAPIKeys)
+	for _, entry := range settings.APIKeys {
+		if key, scopes, _, err := config.ParseAPIKey(entry); err == nil && slices.Contains(scopes, config.ScopeAdmin) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("admin endpoints require at least one admin API key")
+	}
+	return apiKeyMiddleware(keys), nil
+}
+
+// withExclusions wraps an auth middleware to skip auth for the excluded paths
+// of settings
+func withExclusions(authMiddleware func(http.Handler) http.Handler, settings config.AuthSettings) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		authedHandler := authMiddleware(next)
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if isExcludedPath(r.URL.Path) {
+			if settings.IsExcludedPath(r.URL.Path) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -54,23 +66,58 @@ func withExclusions(authMiddleware func(http.Handler) http.Handler) func(http.Ha
 	}
 }
 
+// basicAuthMiddleware checks basic auth credentials in constant time. Client
+// addresses with too many failed attempts are locked out for a while, and
+// refused without checking their credentials.
 func basicAuthMiddleware(settings config.BasicAuthSettings) func(http.Handler) http.Handler {
+	return basicAuthMiddlewareWithTracker(settings, newFailureTracker())
+}
+
+func basicAuthMiddlewareWithTracker(settings config.BasicAuthSettings, failures *failureTracker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr := clientAddr(r)
+			if remaining := failures.lockedOut(addr); remaining > 0 {
+				authMetrics.Add(metricBasicRefused, 1)
+				refuseLockedOut(w, remaining)
+				return
+			}
+
 			user, pass, ok := r.BasicAuth()
 			userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(settings.Username)) == 1
 			passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(settings.Password)) == 1
 			if !ok || !userMatch || !passMatch {
+				authMetrics.Add(metricBasicFailures, 1)
+				if failures.fail(addr) {
+					authMetrics.Add(metricBasicLockouts, 1)
+					slog.Warn("Too many failed basic auth attempts, locking out client", "remote_addr", addr, "duration", authLockout)
+				}
 				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+			failures.succeed(addr)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func apiKeyMiddleware(apiKeys []string) func(http.Handler) http.Handler {
+// apiKeyMiddleware accepts requests with one of the API keys in the X-API-Key
+// header. The scopes of a scoped key, and the ranking profile of a key with
+// one, are added to the request context.
+func apiKeyMiddleware(entries []string) func(http.Handler) http.Handler {
+	type apiKey struct {
+		key     string
+		scopes  []string
+		profile string
+	}
+	var apiKeys []apiKey
+	for _, entry := range entries {
+		// Entries are validated with the settings
+		key, scopes, profile, _ := config.ParseAPIKey(entry)
+		apiKeys = append(apiKeys, apiKey{key, scopes, profile})
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			key := r.Header.Get("X-API-Key")
@@ -79,19 +126,51 @@ func apiKeyMiddleware(apiKeys []string) func(http.Handler) http.Handler {
 				return
 			}
 
-			valid := false
-			for _, validKey := range apiKeys {
-				if subtle.ConstantTimeCompare([]byte(key), []byte(validKey)) == 1 {
-					valid = true
+			var match *apiKey
+			for i := range apiKeys {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(apiKeys[i].key)) == 1 {
+					match = &apiKeys[i]
 					break
 				}
 			}
 
-			if !valid {
+			if match == nil {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
+			if match.scopes != nil {
+				r = r.WithContext(WithScopes(r.Context(), match.scopes))
+			}
+			if match.profile != "" {
+				r = r.WithContext(WithRankingProfile(r.Context(), match.profile))
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// mtlsMiddleware accepts requests with a client certificate verified during
+// the TLS handshake, and if identities is not empty, whose identity is one of
+// them. The identity is added to the request context and logged when a
+// client opens a session.
+func mtlsMiddleware(identities []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			identity := CertificateIdentity(r.TLS.VerifiedChains[0][0])
+			if len(identities) > 0 && !slices.Contains(identities, identity) {
+				slog.Warn("Client certificate identity not allowed", "identity", identity, "remote_addr", r.RemoteAddr)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			if r.Method == http.MethodGet {
+				slog.Info("Client connected", "identity", identity, "remote_addr", r.RemoteAddr, "path", r.URL.Path)
+			}
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package auth
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/sha1n/mcp-relic-server/internal/config"
 )
@@ -256,6 +262,108 @@ func TestNewMiddleware_UnknownType(t *testing.T) {
 	}
 }
 
+func TestNewAdminMiddleware(t *testing.T) {
+	middleware, err := NewAdminMiddleware(config.AuthSettings{
+		APIKeys:      []string{"client-key", "ops-key:search+admin"},
+		AdminAPIKeys: []string{"admin-key"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for key, want := range map[string]int{"": http.StatusUnauthorized, "client-key": http.StatusUnauthorized, "admin-key": http.StatusOK, "ops-key": http.StatusOK} {
+		req := httptest.NewRequest("GET", "/debug/vars", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("Key %q: expected status %d, got %d", key, want, rec.Code)
+		}
+	}
+}
+
+func TestNewAdminMiddleware_NoKeys(t *testing.T) {
+	if _, err := NewAdminMiddleware(config.AuthSettings{APIKeys: []string{"client-key:search+read"}}); err == nil {
+		t.Error("Expected error for no admin API keys")
+	}
+}
+
+func TestAPIKeyMiddleware_Scopes(t *testing.T) {
+	middleware, err := NewMiddleware(config.AuthSettings{
+		Type:    config.AuthTypeAPIKey,
+		APIKeys: []string{"full-key", "search-key:search"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var canSearch, canRead bool
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canSearch = HasScope(r.Context(), config.ScopeSearch)
+		canRead = HasScope(r.Context(), config.ScopeRead)
+	}))
+
+	tests := []struct {
+		key        string
+		wantSearch bool
+		wantRead   bool
+	}{
+		{"full-key", true, true},
+		{"search-key", true, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/sse", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if canSearch != tt.wantSearch || canRead != tt.wantRead {
+			t.Errorf("Key %q: search=%v read=%v, want search=%v read=%v", tt.key, canSearch, canRead, tt.wantSearch, tt.wantRead)
+		}
+	}
+
+	// The scope suffix is not part of the key
+	req := httptest.NewRequest("GET", "/sse", nil)
+	req.Header.Set("X-API-Key", "search-key:search")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the entry with its scopes to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestAPIKeyMiddleware_RankingProfile(t *testing.T) {
+	middleware, err := NewMiddleware(config.AuthSettings{
+		Type:    config.AuthTypeAPIKey,
+		APIKeys: []string{"plain-key", "docs-key:search@docs-first"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var profile string
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		profile = RankingProfileFromContext(r.Context())
+	}))
+
+	for key, want := range map[string]string{"plain-key": "", "docs-key": config.RankingProfileDocs} {
+		req := httptest.NewRequest("GET", "/sse", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || profile != want {
+			t.Errorf("Key %q: status %d, profile %q, want profile %q", key, rec.Code, profile, want)
+		}
+	}
+}
+
 func TestExcludedPath_Health(t *testing.T) {
 	settings := config.AuthSettings{
 		Type: config.AuthTypeBasic,
@@ -283,22 +391,277 @@ func TestExcludedPath_Health(t *testing.T) {
 	}
 }
 
-func TestIsExcludedPath(t *testing.T) {
+func TestExcludedPath_Configured(t *testing.T) {
+	middleware, err := NewMiddleware(config.AuthSettings{
+		Type:          config.AuthTypeAPIKey,
+		APIKeys:       []string{"key"},
+		ExcludedPaths: []string{"/readyz", "/probes/*"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
 	tests := []struct {
 		path     string
-		expected bool
+		expected int
 	}{
-		{"/health", true},
-		{"/test", false},
-		{"/api/health", false},
-		{"/", false},
+		{"/readyz", http.StatusOK},
+		{"/probes/live", http.StatusOK},
+		{"/health", http.StatusUnauthorized}, // replaced by the configured paths
+		{"/readyz/full", http.StatusUnauthorized},
+		{"/sse", http.StatusUnauthorized},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
-			if got := isExcludedPath(tt.path); got != tt.expected {
-				t.Errorf("isExcludedPath(%q) = %v, want %v", tt.path, got, tt.expected)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d for %s, got %d", tt.expected, tt.path, rec.Code)
 			}
 		})
 	}
 }
+
+func TestMTLSMiddleware(t *testing.T) {
+	middleware, err := NewMiddleware(config.AuthSettings{
+		Type:           config.AuthTypeMTLS,
+		MTLSIdentities: []string{"agent-1", "ci.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var identity string
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	withCert := func(cert *x509.Certificate) *http.Request {
+		req := httptest.NewRequest("GET", "/sse", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		return req
+	}
+
+	tests := []struct {
+		name         string
+		req          *http.Request
+		wantCode     int
+		wantIdentity string
+	}{
+		{"no TLS", httptest.NewRequest("GET", "/sse", nil), http.StatusUnauthorized, ""},
+		{"unverified", func() *http.Request {
+			req := httptest.NewRequest("GET", "/sse", nil)
+			req.TLS = &tls.ConnectionState{}
+			return req
+		}(), http.StatusUnauthorized, ""},
+		{"common name", withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "agent-1"}}), http.StatusOK, "agent-1"},
+		{"DNS SAN", withCert(&x509.Certificate{DNSNames: []string{"ci.example.com"}}), http.StatusOK, "ci.example.com"},
+		{"not allowed", withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "intruder"}}), http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity = ""
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if identity != tt.wantIdentity {
+				t.Errorf("Expected identity %q, got %q", tt.wantIdentity, identity)
+			}
+		})
+	}
+
+	// Excluded paths don't need a certificate
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /health to bypass mtls, got %d", rec.Code)
+	}
+}
+
+func TestCertificateIdentity(t *testing.T) {
+	uri, _ := url.Parse("spiffe://example.org/agent")
+	tests := []struct {
+		cert *x509.Certificate
+		want string
+	}{
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "cn"}, DNSNames: []string{"dns"}}, "cn"},
+		{&x509.Certificate{DNSNames: []string{"dns"}}, "dns"},
+		{&x509.Certificate{EmailAddresses: []string{"agent@example.org"}}, "agent@example.org"},
+		{&x509.Certificate{URIs: []*url.URL{uri}}, "spiffe://example.org/agent"},
+		{&x509.Certificate{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := CertificateIdentity(tt.cert); got != tt.want {
+			t.Errorf("CertificateIdentity() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestBasicAuthMiddleware_Lockout(t *testing.T) {
+	settings := config.BasicAuthSettings{Username: "admin", Password: "secret"}
+	failures := newFailureTracker()
+	now := time.Now()
+	failures.now = func() time.Time { return now }
+
+	handler := basicAuthMiddlewareWithTracker(settings, failures)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(remoteAddr, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = remoteAddr
+		req.SetBasicAuth("admin", password)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for range maxAuthFailures {
+		if rec := serve("192.0.2.1:1234", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401, got %d", rec.Code)
+		}
+	}
+
+	// Locked out, even with the right password and from another port
+	rec := serve("192.0.2.1:5678", "secret")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "900" {
+		t.Errorf("Expected Retry-After 900, got %q", got)
+	}
+
+	// Other addresses are not affected
+	if rec := serve("192.0.2.2:1234", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for another address, got %d", rec.Code)
+	}
+
+	now = now.Add(authLockout + time.Second)
+	if rec := serve("192.0.2.1:1234", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after the lockout, got %d", rec.Code)
+	}
+}
+
+func TestFailureTracker(t *testing.T) {
+	failures := newFailureTracker()
+	now := time.Now()
+	failures.now = func() time.Time { return now }
+
+	for range maxAuthFailures - 1 {
+		if failures.fail("192.0.2.1") {
+			t.Fatal("Expected no lockout before the limit")
+		}
+	}
+
+	// A success forgets earlier failures
+	failures.succeed("192.0.2.1")
+	if failures.fail("192.0.2.1") {
+		t.Error("Expected no lockout after a success")
+	}
+
+	// Failures outside the window start a new count
+	for range maxAuthFailures - 2 {
+		failures.fail("192.0.2.1")
+	}
+	now = now.Add(authFailureWindow + time.Second)
+	if failures.fail("192.0.2.1") || failures.lockedOut("192.0.2.1") > 0 {
+		t.Error("Expected old failures to expire")
+	}
+
+	// Stale addresses are pruned when the tracker is full
+	for n := range maxTrackedAddrs {
+		failures.addrs[fmt.Sprintf("addr%d", n)] = &addrFailures{count: 1, first: now.Add(-2 * authFailureWindow)}
+	}
+	failures.fail("192.0.2.3")
+	if _, ok := failures.addrs["192.0.2.3"]; !ok || len(failures.addrs) > 2 {
+		t.Errorf("Expected stale addresses to be pruned, %d tracked", len(failures.addrs))
+	}
+}
+
+func TestSessionBinding_StreamableHTTP(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Mcp-Session-Id") == "" {
+			w.Header().Set("Mcp-Session-Id", "s1")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := apiKeyMiddleware([]string{"admin-key:admin", "search-key:search"})(NewSessionBinding(next, time.Hour))
+
+	send := func(method, key, session string) int {
+		req := httptest.NewRequest(method, "/mcp", nil)
+		req.Header.Set("X-API-Key", key)
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send(http.MethodPost, "admin-key", ""); code != http.StatusOK {
+		t.Fatalf("Expected the session to open, got %d", code)
+	}
+	if code := send(http.MethodPost, "search-key", "s1"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another key, got %d", code)
+	}
+	if code := send(http.MethodPost, "admin-key", "s1"); code != http.StatusOK {
+		t.Errorf("Expected 200 for the opening key, got %d", code)
+	}
+	if code := send(http.MethodDelete, "admin-key", "s1"); code != http.StatusOK {
+		t.Errorf("Expected 200 for closing the session, got %d", code)
+	}
+	// Closed sessions are left to the SDK
+	if code := send(http.MethodPost, "search-key", "s1"); code != http.StatusOK {
+		t.Errorf("Expected a closed session to be forgotten, got %d", code)
+	}
+}
+
+func TestSessionBinding_ForgetsIdleSessions(t *testing.T) {
+	sessions := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Mcp-Session-Id") == "" {
+			sessions++
+			w.Header().Set("Mcp-Session-Id", fmt.Sprintf("s%d", sessions))
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	binding := NewSessionBinding(next, time.Hour).(*sessionBinding)
+	now := time.Now()
+	binding.now = func() time.Time { return now }
+	handler := apiKeyMiddleware([]string{"admin-key:admin", "search-key:search"})(binding)
+
+	send := func(key, session string) int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("X-API-Key", key)
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Sessions abandoned without a DELETE stay bound while they may be open
+	send("admin-key", "")
+	now = now.Add(59 * time.Minute)
+	send("admin-key", "")
+	if code := send("search-key", "s1"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another key, got %d", code)
+	}
+
+	now = now.Add(time.Hour)
+	send("admin-key", "")
+	if _, ok := binding.sessions["s1"]; ok || len(binding.sessions) != 1 {
+		t.Errorf("Expected idle sessions to be forgotten, %d bound", len(binding.sessions))
+	}
+}
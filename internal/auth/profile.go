@@ -0,0 +1,19 @@
+package auth
+
+import "context"
+
+// profileKey is the context key of the ranking profile of an API key
+type profileKey struct{}
+
+// WithRankingProfile returns a context carrying the ranking profile of the
+// API key that authenticated the request.
+func WithRankingProfile(ctx context.Context, profile string) context.Context {
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// RankingProfileFromContext returns the ranking profile of the API key that
+// authenticated the request, or an empty string if it has none.
+func RankingProfileFromContext(ctx context.Context) string {
+	profile, _ := ctx.Value(profileKey{}).(string)
+	return profile
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// scopesKey is the context key of the scopes of an authenticated API key
+type scopesKey struct{}
+
+// WithScopes returns a context carrying the scopes of the API key that
+// authenticated the request.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// HasScope reports whether the request of ctx may use scope. Requests that
+// were not authenticated with a scoped API key, including all stdio and
+// basic auth requests, are not restricted.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(scopesKey{}).([]string)
+	return !ok || slices.Contains(scopes, scope)
+}
+
+// HasExplicitScope reports whether the request of ctx was authenticated with
+// an API key granted scope. Unlike HasScope, it fails closed: requests without
+// scopes, including unscoped API keys, stdio, basic auth and mTLS, never have
+// one.
+func HasExplicitScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(scopesKey{}).([]string)
+	return ok && slices.Contains(scopes, scope)
+}
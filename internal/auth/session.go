@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionIDHeader is the header of the streamable HTTP session ID
+const sessionIDHeader = "Mcp-Session-Id"
+
+// NewSessionBinding binds the MCP sessions of an SSE or streamable HTTP
+// handler to the credentials that opened them. The SDK runs every call of a
+// session with the context of the request that opened it, so the scopes,
+// ranking profile and identity of that request apply to all of its calls.
+// Messages posted to a session with other credentials are refused with 403,
+// so that a weaker API key cannot use a session opened with a stronger one.
+// Bindings are forgotten when a session is deleted, when its SSE stream ends,
+// or when it has had no request for idle (0 = never), which must not be
+// shorter than the idle timeout of the sessions of next.
+func NewSessionBinding(next http.Handler, idle time.Duration) http.Handler {
+	return &sessionBinding{next: next, idle: idle, now: time.Now, sessions: make(map[string]*boundSession)}
+}
+
+// sessionBinding maps the ID of each open session to the principal of the
+// request that opened it.
+type sessionBinding struct {
+	next     http.Handler
+	idle     time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	sessions map[string]*boundSession
+}
+
+// boundSession is the binding of an open session.
+type boundSession struct {
+	principal string
+	active    int       // requests in flight
+	seen      time.Time // end of the last request
+}
+
+// ServeHTTP checks the credentials of requests to known sessions, and records
+// the sessions that requests open.
+func (b *sessionBinding) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := principal(r.Context())
+	id := r.URL.Query().Get("sessionid")
+	if id == "" {
+		id = r.Header.Get(sessionIDHeader)
+	}
+	if id != "" {
+		b.mu.Lock()
+		session, ok := b.sessions[id]
+		if ok && session.principal != p {
+			b.mu.Unlock()
+			http.Error(w, "Forbidden: session was opened with other credentials", http.StatusForbidden)
+			return
+		}
+		if ok {
+			session.active++
+		}
+		b.mu.Unlock()
+		if ok {
+			if r.Method == http.MethodDelete {
+				defer b.forget(id)
+			} else {
+				defer b.done(id)
+			}
+		}
+		b.next.ServeHTTP(w, r)
+		return
+	}
+
+	rec := &sessionRecorder{ResponseWriter: w, bind: func(id string) { b.bind(id, p) }}
+	b.next.ServeHTTP(rec, r)
+	if rec.id == "" {
+		return
+	}
+	if r.Method == http.MethodGet {
+		// An SSE session ends with its event stream
+		b.forget(rec.id)
+	} else {
+		b.done(rec.id)
+	}
+}
+
+// bind records a session opened by a request in flight, and forgets the
+// sessions idle for longer than b.idle.
+func (b *sessionBinding) bind(id, principal string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.idle > 0 {
+		now := b.now()
+		for other, session := range b.sessions {
+			if session.active == 0 && now.Sub(session.seen) >= b.idle {
+				delete(b.sessions, other)
+			}
+		}
+	}
+	b.sessions[id] = &boundSession{principal: principal, active: 1}
+}
+
+// done records the end of a request to a session.
+func (b *sessionBinding) done(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if session, ok := b.sessions[id]; ok {
+		session.active--
+		session.seen = b.now()
+	}
+}
+
+func (b *sessionBinding) forget(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.sessions, id)
+}
+
+// sessionRecorder picks up the ID of a session opened by a request: from the
+// session ID header of a streamable HTTP response, or from the endpoint event
+// that starts an SSE stream.
+type sessionRecorder struct {
+	http.ResponseWriter
+	bind        func(id string)
+	id          string
+	wroteHeader bool
+}
+
+func (r *sessionRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		if id := r.Header().Get(sessionIDHeader); id != "" {
+			r.record(id)
+		}
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *sessionRecorder) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.id == "" && strings.HasPrefix(r.Header().Get("Content-Type"), "text/event-stream") {
+		if id := endpointSessionID(data); id != "" {
+			r.record(id)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush passes flushes on, since event streams rely on them.
+func (r *sessionRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the wrapped writer.
+func (r *sessionRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *sessionRecorder) record(id string) {
+	r.id = id
+	r.bind(id)
+}
+
+// endpointSessionID returns the session ID of the SSE endpoint event in data,
+// or an empty string if data does not hold one.
+func endpointSessionID(data []byte) string {
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	endpoint := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "event: endpoint":
+			endpoint = true
+		case endpoint && strings.HasPrefix(line, "data: "):
+			u, err := url.Parse(strings.TrimPrefix(line, "data: "))
+			if err != nil {
+				return ""
+			}
+			return u.Query().Get("sessionid")
+		}
+	}
+	return ""
+}
+
+// principal describes the credentials of the request of ctx, as far as tool
+// calls can tell them apart.
+func principal(ctx context.Context) string {
+	scopes, ok := ctx.Value(scopesKey{}).([]string)
+	scoped := "*"
+	if ok {
+		scoped = strings.Join(scopes, "+")
+	}
+	return strings.Join([]string{IdentityFromContext(ctx), RankingProfileFromContext(ctx), scoped}, "\x00")
+}
@@ -13,8 +13,17 @@ func Log(s *Settings) {
 // LogWithLogger logs the resolved settings using the provided logger
 func LogWithLogger(s *Settings, logger *slog.Logger) {
 	ctx := context.Background()
+	if s.Profile != "" {
+		logger.InfoContext(ctx, "Config: profile", "value", s.Profile)
+	}
 	logger.InfoContext(ctx, "Config: transport", "value", s.Transport)
-	if s.Transport == "sse" {
+	if s.DebugStdio != "" {
+		logger.InfoContext(ctx, "Config: debug_stdio", "value", s.DebugStdio)
+	}
+	if s.IndexUpdateNotify != "" && s.IndexUpdateNotify != IndexUpdateNotifyOff {
+		logger.InfoContext(ctx, "Config: index_update_notify", "value", s.IndexUpdateNotify)
+	}
+	if s.ServesHTTP() {
 		logger.InfoContext(ctx, "Config: host", "value", s.Host)
 		logger.InfoContext(ctx, "Config: port", "value", s.Port)
 	}
@@ -26,6 +35,14 @@ func LogWithLogger(s *Settings, logger *slog.Logger) {
 		logger.InfoContext(ctx, "Config: auth.basic.password", "value", "****")
 	case AuthTypeAPIKey:
 		logger.InfoContext(ctx, "Config: auth.api_keys", "count", len(s.Auth.APIKeys))
+	case AuthTypeMTLS:
+		logger.InfoContext(ctx, "Config: auth.mtls_identities", "value", s.Auth.MTLSIdentities)
+	}
+	if s.Auth.Type != AuthTypeNone && s.Auth.Type != "" {
+		logger.InfoContext(ctx, "Config: auth.excluded_paths", "value", s.Auth.ExcludedPaths)
+	}
+	if s.ServesHTTP() && s.TLS.Enabled() {
+		logger.InfoContext(ctx, "Config: tls.cert_file", "value", s.TLS.CertFile)
 	}
 }
 
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncWindow is a daily time window, as offsets from midnight in local time.
+// A window whose end is before its start spans midnight.
+type SyncWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// SyncSchedule is the set of daily windows in which periodic syncs may
+// start. An empty schedule allows syncs at any time.
+type SyncSchedule []SyncWindow
+
+// ParseSyncWindow parses a window written as "HH:MM-HH:MM", e.g.
+// "02:00-05:00" or "22:00-04:00". The end may be "24:00".
+func ParseSyncWindow(s string) (SyncWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return SyncWindow{}, fmt.Errorf("invalid sync window %q (expected HH:MM-HH:MM)", s)
+	}
+	start, err := parseClock(strings.TrimSpace(from), false)
+	if err != nil {
+		return SyncWindow{}, fmt.Errorf("invalid sync window %q: %w", s, err)
+	}
+	end, err := parseClock(strings.TrimSpace(to), true)
+	if err != nil {
+		return SyncWindow{}, fmt.Errorf("invalid sync window %q: %w", s, err)
+	}
+	if start == end {
+		return SyncWindow{}, fmt.Errorf("invalid sync window %q: start and end are the same", s)
+	}
+	return SyncWindow{Start: start, End: end}, nil
+}
+
+// parseClock parses a time of day written as "HH:MM". "24:00" is accepted
+// when allowMidnight is set.
+func parseClock(s string, allowMidnight bool) (time.Duration, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok || len(h) != 2 || len(m) != 2 {
+		return 0, fmt.Errorf("%q is not a time (HH:MM)", s)
+	}
+	hours, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time (HH:MM)", s)
+	}
+	minutes, err := strconv.Atoi(m)
+	if err != nil || minutes < 0 || minutes > 59 || hours < 0 || hours > 24 || (hours == 24 && (minutes != 0 || !allowMidnight)) {
+		return 0, fmt.Errorf("%q is not a time (HH:MM)", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// contains reports whether offset, the time since midnight, is in the window.
+func (w SyncWindow) contains(offset time.Duration) bool {
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Wait returns how long to wait from t until a sync may start: 0 within a
+// window, or until the start of the next one.
+func (s SyncSchedule) Wait(t time.Time) time.Duration {
+	if len(s) == 0 {
+		return 0
+	}
+	y, mo, d := t.Date()
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+
+	var wait time.Duration
+	for i, w := range s {
+		if w.contains(offset) {
+			return 0
+		}
+		// Built with time.Date, so that days with a DST change are handled
+		start := time.Date(y, mo, d, 0, 0, 0, 0, t.Location()).Add(w.Start)
+		if !start.After(t) {
+			start = time.Date(y, mo, d+1, 0, 0, 0, 0, t.Location()).Add(w.Start)
+		}
+		if next := start.Sub(t); i == 0 || next < wait {
+			wait = next
+		}
+	}
+	return wait
+}
+
+// String formats the schedule as its windows, e.g. "02:00-05:00,22:00-23:30".
+func (s SyncSchedule) String() string {
+	windows := make([]string, len(s))
+	for i, w := range s {
+		windows[i] = formatClock(w.Start) + "-" + formatClock(w.End)
+	}
+	return strings.Join(windows, ",")
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
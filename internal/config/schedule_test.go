@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSyncWindow(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SyncWindow
+		wantErr bool
+	}{
+		{"02:00-05:00", SyncWindow{Start: 2 * time.Hour, End: 5 * time.Hour}, false},
+		{"22:30 - 04:15", SyncWindow{Start: 22*time.Hour + 30*time.Minute, End: 4*time.Hour + 15*time.Minute}, false},
+		{"00:00-24:00", SyncWindow{Start: 0, End: 24 * time.Hour}, false},
+		{"02:00", SyncWindow{}, true},
+		{"2:00-5:00", SyncWindow{}, true},
+		{"02:00-02:00", SyncWindow{}, true},
+		{"24:00-02:00", SyncWindow{}, true},
+		{"02:60-05:00", SyncWindow{}, true},
+		{"25:00-05:00", SyncWindow{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSyncWindow(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSyncWindow(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSyncWindow(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncSchedule_Wait(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+	schedule := SyncSchedule{
+		{Start: 2 * time.Hour, End: 5 * time.Hour},
+		{Start: 22 * time.Hour, End: 1 * time.Hour},
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Duration
+	}{
+		{"inside", at(3, 0), 0},
+		{"at start", at(2, 0), 0},
+		{"at end", at(5, 0), 17 * time.Hour},
+		{"before start", at(1, 30), 30 * time.Minute},
+		{"inside across midnight", at(0, 30), 0},
+		{"after midnight window", at(23, 0), 0},
+		{"afternoon", at(15, 0), 7 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.Wait(tt.t); got != tt.want {
+				t.Errorf("Wait(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+
+	if got := (SyncSchedule{}).Wait(at(15, 0)); got != 0 {
+		t.Errorf("Expected an empty schedule to allow syncs at any time, got %v", got)
+	}
+}
+
+func TestSyncSchedule_String(t *testing.T) {
+	schedule, err := (&GitReposSettings{SyncWindows: []string{"02:00-05:00", "22:30-24:00"}}).SyncSchedule()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := schedule.String(); got != "02:00-05:00,22:30-24:00" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestValidateSettings_SyncWindowsAndRateLimit(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.SyncWindows = []string{"02:00-05:00", "night"}
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-sync-windows") {
+		t.Errorf("Expected invalid sync window error, got: %v", err)
+	}
+
+	s = &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.FetchRateLimit = -1
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-fetch-rate-limit") {
+		t.Errorf("Expected negative rate limit error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_SyncWindowsFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_SYNC_WINDOWS", "02:00-05:00, 22:00-23:00")
+	t.Setenv("RELIC_MCP_GIT_REPOS_FETCH_RATE_LIMIT", "1048576")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.GitRepos.SyncWindows) != 2 || settings.GitRepos.SyncWindows[1] != "22:00-23:00" {
+		t.Errorf("Unexpected sync windows: %q", settings.GitRepos.SyncWindows)
+	}
+	if settings.GitRepos.FetchRateLimit != 1048576 {
+		t.Errorf("Expected fetch rate limit 1048576, got %d", settings.GitRepos.FetchRateLimit)
+	}
+}
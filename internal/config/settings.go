@@ -2,8 +2,14 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,18 +17,200 @@ import (
 	"github.com/spf13/viper"
 )
 
+// envPrefix prefixes the environment variable of every setting
+const envPrefix = "RELIC_MCP"
+
 // Auth type constants
 const (
 	AuthTypeNone   = "none"
 	AuthTypeBasic  = "basic"
 	AuthTypeAPIKey = "apikey"
+	AuthTypeMTLS   = "mtls"
+)
+
+// API key scope constants. A key with scopes, written "key:search+read", is
+// limited to them; a key without scopes has full client access, but not
+// admin access.
+const (
+	ScopeSearch = "search" // search and repository metadata
+	ScopeRead   = "read"   // full file contents
+	ScopeAdmin  = "admin"  // reindexing and administrative endpoints
+)
+
+// apiKeyScopeSeparator separates an API key from its scopes
+const apiKeyScopeSeparator = ":"
+
+// apiKeyProfileSeparator separates an API key, and its scopes, from its
+// ranking profile, e.g. "key:search@docs-first"
+const apiKeyProfileSeparator = "@"
+
+// Query strategies that git-repos-query-experiment compares searches with
+const (
+	QueryStrategyExact   = "exact"   // no typo tolerance in content terms
+	QueryStrategySymbols = "symbols" // twice the boost of symbol name matches
+)
+
+// Ranking profiles order search results for different kinds of questions.
+// They are selected per search, per API key, or for the whole server.
+const (
+	RankingProfileDefault     = "default"           // declared symbols first, then content matches
+	RankingProfileDefinitions = "definitions-first" // declarations weigh more; documentation is left out
+	RankingProfileDocs        = "docs-first"        // documentation first; symbols weigh like content
+	RankingProfileRecent      = "recent-first"      // files changed by the recent commits of the history index first
+)
+
+// RankingProfiles lists the ranking profiles, the default first
+var RankingProfiles = []string{RankingProfileDefault, RankingProfileDefinitions, RankingProfileDocs, RankingProfileRecent}
+
+// MaxHistoryCommits caps git-repos-history-commits, since every commit adds
+// files to the history index and deepens the shallow clones
+const MaxHistoryCommits = 1000
+
+// Client log level constants
+const (
+	ClientLogLevelDebug = "debug"
+	ClientLogLevelInfo  = "info"
+	ClientLogLevelWarn  = "warn"
+	ClientLogLevelError = "error"
+	ClientLogLevelOff   = "off"
+)
+
+// Index update notification constants
+const (
+	IndexUpdateNotifyOff       = "off"       // clients are not told about index updates
+	IndexUpdateNotifyResources = "resources" // MCP resource list-changed and resource-updated notifications
+	IndexUpdateNotifyLog       = "log"       // MCP log notifications with the index_updated event
 )
 
 // AuthSettings configuration for authentication
 type AuthSettings struct {
-	Type    string            `mapstructure:"type"` // AuthTypeNone, AuthTypeBasic, or AuthTypeAPIKey
+	Type    string            `mapstructure:"type"` // AuthTypeNone, AuthTypeBasic, AuthTypeAPIKey, or AuthTypeMTLS
 	Basic   BasicAuthSettings `mapstructure:"basic"`
 	APIKeys []string          `mapstructure:"api_keys"`
+
+	// MTLSIdentities, if set, limit mtls auth to clients whose certificate
+	// identity (CN, or the first SAN without one) is listed
+	MTLSIdentities []string `mapstructure:"mtls_identities"`
+
+	// AdminAPIKeys grant access to administrative endpoints such as /debug,
+	// independently of the auth type used for MCP clients
+	AdminAPIKeys []string `mapstructure:"admin_api_keys"`
+
+	// ExcludedPaths bypass client auth, e.g. for health probes. An entry
+	// matches a path exactly, or every path it prefixes if it ends with *.
+	// Defaults to DefaultAuthExcludedPaths if empty.
+	ExcludedPaths []string `mapstructure:"excluded_paths"`
+}
+
+// ErrURLNotAllowed indicates a repository URL rejected by AllowedURLs or
+// DeniedURLs
+var ErrURLNotAllowed = errors.New("repository URL not allowed")
+
+// DefaultAuthExcludedPaths are the paths that bypass client auth unless
+// configured otherwise
+var DefaultAuthExcludedPaths = []string{"/health"}
+
+// authExcludedPathWildcard ends an excluded path that matches by prefix
+const authExcludedPathWildcard = "*"
+
+// IsExcludedPath reports whether path bypasses client auth.
+func (a AuthSettings) IsExcludedPath(path string) bool {
+	excluded := a.ExcludedPaths
+	if len(excluded) == 0 {
+		excluded = DefaultAuthExcludedPaths
+	}
+	for _, entry := range excluded {
+		if prefix, ok := strings.CutSuffix(entry, authExcludedPathWildcard); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// excludesUnder reports whether any path under prefix bypasses client auth.
+func (a AuthSettings) excludesUnder(prefix string) bool {
+	if a.IsExcludedPath(prefix) {
+		return true
+	}
+	for _, entry := range a.ExcludedPaths {
+		if strings.HasPrefix(strings.TrimSuffix(entry, authExcludedPathWildcard), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExcludedPath checks an auth-excluded-paths entry.
+func validateExcludedPath(entry string) error {
+	switch {
+	case !strings.HasPrefix(entry, "/"):
+		return fmt.Errorf("%q must start with /", entry)
+	case strings.Contains(strings.TrimSuffix(entry, authExcludedPathWildcard), authExcludedPathWildcard):
+		return fmt.Errorf("%q can only end with %s", entry, authExcludedPathWildcard)
+	case strings.ContainsAny(entry, " \t?#"):
+		return fmt.Errorf("%q must be a plain URL path", entry)
+	}
+	return nil
+}
+
+// ParseAPIKey splits an API key entry into the key, its scopes and its
+// ranking profile, if any.
+func ParseAPIKey(entry string) (key string, scopes []string, profile string, err error) {
+	if i := strings.LastIndex(entry, apiKeyProfileSeparator); i >= 0 {
+		entry, profile = entry[:i], entry[i+1:]
+		if !slices.Contains(RankingProfiles, profile) {
+			return "", nil, "", fmt.Errorf("unknown ranking profile %q (use %s)", profile, strings.Join(RankingProfiles, ", "))
+		}
+	}
+	key, list, found := strings.Cut(entry, apiKeyScopeSeparator)
+	if key == "" {
+		return "", nil, "", errors.New("API key cannot be empty")
+	}
+	if !found {
+		return key, nil, profile, nil
+	}
+	for _, scope := range strings.Split(list, "+") {
+		switch scope {
+		case ScopeSearch, ScopeRead, ScopeAdmin:
+			scopes = append(scopes, scope)
+		default:
+			return "", nil, "", fmt.Errorf("unknown API key scope %q (use search, read, or admin)", scope)
+		}
+	}
+	return key, scopes, profile, nil
+}
+
+// HasAdminKeys reports whether any key grants access to administrative
+// endpoints: an admin API key, or an API key with the admin scope.
+func (a AuthSettings) HasAdminKeys() bool {
+	if len(a.AdminAPIKeys) > 0 {
+		return true
+	}
+	for _, entry := range a.APIKeys {
+		if _, scopes, _, err := ParseAPIKey(entry); err == nil && slices.Contains(scopes, ScopeAdmin) {
+			return true
+		}
+	}
+	return false
+}
+
+// TLSSettings configure HTTPS for the SSE and HTTP transports. The listener
+// serves HTTPS when both a certificate and a key are set.
+type TLSSettings struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientCAFile holds the CAs client certificates are verified against
+	// (mtls auth)
+	ClientCAFile string `mapstructure:"client_ca_file"`
+}
+
+// Enabled reports whether the listener serves HTTPS.
+func (t TLSSettings) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
 }
 
 // BasicAuthSettings configuration for basic auth
@@ -35,10 +223,166 @@ type BasicAuthSettings struct {
 type GitReposSettings struct {
 	URLs         []string      `mapstructure:"urls"`
 	BaseDir      string        `mapstructure:"base_dir"`
+	ReposDir     string        `mapstructure:"repos_dir"`   // clones; defaults to <base_dir>/repos
+	IndexesDir   string        `mapstructure:"indexes_dir"` // indexes; defaults to <base_dir>/indexes
 	SyncInterval time.Duration `mapstructure:"sync_interval"`
 	SyncTimeout  time.Duration `mapstructure:"sync_timeout"`
 	MaxFileSize  int64         `mapstructure:"max_file_size"`
 	MaxResults   int           `mapstructure:"max_results"`
+	ReadOnly     bool          `mapstructure:"read_only"`    // serve indexes built by an external `sync` process
+	SnapshotURL  string        `mapstructure:"snapshot_url"` // object storage for index snapshots (file://, s3://, gs://)
+	LocalDir     string        `mapstructure:"local_dir"`    // index this working directory instead of cloning (--cwd)
+	Watch        bool          `mapstructure:"watch"`        // reindex changed files as they are saved (--cwd only)
+
+	// GitCommandTimeout bounds each git command, independently of the whole
+	// sync (0 = no limit)
+	GitCommandTimeout time.Duration `mapstructure:"git_command_timeout"`
+	// GitMaxOutput caps the output kept from each git command, in bytes
+	// (0 = unlimited)
+	GitMaxOutput int64 `mapstructure:"git_max_output"`
+	// GitSandbox runs git with a clean environment, without the system and
+	// global git config, credential helpers or hooks
+	GitSandbox bool `mapstructure:"git_sandbox"`
+	// GitMaxMemory and GitMaxCPU limit the virtual memory, in bytes, and the
+	// CPU time of each git command (0 = no limit; not supported on Windows)
+	GitMaxMemory int64         `mapstructure:"git_max_memory"`
+	GitMaxCPU    time.Duration `mapstructure:"git_max_cpu"`
+
+	// SyncWindows are the daily "HH:MM-HH:MM" windows, in local time, in
+	// which periodic syncs of the sync command may start (empty = any time)
+	SyncWindows []string `mapstructure:"sync_windows"`
+	// FetchRateLimit caps the bandwidth of clones and fetches over HTTP(S),
+	// in bytes per second, shared by all repositories (0 = no limit)
+	FetchRateLimit int64 `mapstructure:"fetch_rate_limit"`
+
+	LogURLs        bool  `mapstructure:"log_urls"`        // include repository URLs, without credentials, in logs and errors
+	FollowSymlinks bool  `mapstructure:"follow_symlinks"` // follow symlinks that resolve inside the repository
+	MaxRepoFiles   int   `mapstructure:"max_repo_files"`  // stop indexing a repository after this many files (0 = unlimited)
+	MaxRepoBytes   int64 `mapstructure:"max_repo_bytes"`  // stop indexing a repository after this many content bytes (0 = unlimited)
+
+	// VerifyIndex checks each full index after it is built, recording the
+	// outcome in the manifest
+	VerifyIndex bool `mapstructure:"verify_index"`
+
+	// IndexDeclarations indexes each top-level declaration of a source file
+	// as a document of its own, so that search hits point at the function or
+	// type that matched rather than the whole file
+	IndexDeclarations bool `mapstructure:"index_declarations"`
+
+	// RemovedRetention is how long the clone and index of a repository
+	// removed from URLs are kept, hidden from search, before being deleted
+	// (0 = delete on the next sync)
+	RemovedRetention time.Duration `mapstructure:"removed_retention"`
+
+	// SyncFailureThreshold is the number of repositories failing their
+	// initial sync that makes startup fail; fewer failures start degraded,
+	// without them (0 = never fail)
+	SyncFailureThreshold int `mapstructure:"sync_failure_threshold"`
+
+	// StartupChecks check that git is installed and every repository is
+	// reachable before the initial sync, logging what to fix for failures
+	StartupChecks bool `mapstructure:"startup_checks"`
+
+	// SearchTelemetry records query hashes, result counts and the reads that
+	// follow searches in a local file, for relevance tuning
+	SearchTelemetry bool `mapstructure:"search_telemetry"`
+
+	// IndexBatchSize and IndexBatchBytes bound the documents and content
+	// bytes written to an index in one batch (0 = built-in default)
+	IndexBatchSize  int   `mapstructure:"index_batch_size"`
+	IndexBatchBytes int64 `mapstructure:"index_batch_bytes"`
+
+	// Refs are tags or branches indexed as snapshots next to the default
+	// branch, for searching and reading code as of that ref
+	Refs []string `mapstructure:"refs"`
+
+	// Priority lists repositories, by name (github.com/org/repo) or URL, that
+	// are synced first, in this order; the others follow in the order of URLs
+	Priority []string `mapstructure:"priority"`
+
+	// AllowedURLs, when set, are the only repository URLs the server syncs:
+	// host names (github.com) or URL patterns (git@github.com:myorg/*,
+	// github.com/myorg/**). DeniedURLs are never synced, even if allowed.
+	// Both are checked at startup and again before every clone or fetch.
+	AllowedURLs []string `mapstructure:"allowed_urls"`
+	DeniedURLs  []string `mapstructure:"denied_urls"`
+
+	// CloneDepth is how many commits of history clones and fetches keep
+	// (0 = full history); CloneDepthOverrides set it for some repositories,
+	// as "name=depth" entries where name is a repository name or URL
+	CloneDepth          int      `mapstructure:"clone_depth"`
+	CloneDepthOverrides []string `mapstructure:"clone_depth_overrides"`
+
+	// Branches sets the branch synced for some repositories, as
+	// "name=branch" entries where name is a repository name or URL. Other
+	// repositories sync the branch their remote HEAD points to.
+	Branches []string `mapstructure:"branches"`
+
+	// HTTPSTokens are the access tokens of HTTPS remotes, as "host=token"
+	// or "host=user:token" entries (e.g. "github.com=ghp_..."). They are read
+	// from RELIC_MCP_GIT_HTTPS_TOKENS.
+	HTTPSTokens []string `mapstructure:"https_tokens"`
+
+	// HistoryCommits is how many recent commits of each repository have the
+	// previous versions of the files they changed or deleted indexed in a
+	// separate history index (0 = off)
+	HistoryCommits int `mapstructure:"history_commits"`
+
+	// MaxFileSizeOverrides replace MaxFileSize for some file extensions, as
+	// "ext=bytes" entries (e.g. "md=1048576")
+	MaxFileSizeOverrides []string `mapstructure:"max_file_size_overrides"`
+
+	// ReadDenyPatterns are path patterns (e.g. "**/secrets/**", "*.pem") the
+	// read tool refuses, whether or not the files are indexed
+	ReadDenyPatterns []string `mapstructure:"read_deny_patterns"`
+	// ReadRedactPatterns are regular expressions whose matches are masked in
+	// read output; with a capture group, only the first group is masked
+	ReadRedactPatterns []string `mapstructure:"read_redact_patterns"`
+	// ReadIndexedOnly limits the read tool to files present in the index, so
+	// that excluded files such as lock files are not served either
+	ReadIndexedOnly bool `mapstructure:"read_indexed_only"`
+
+	Highlight     bool   `mapstructure:"highlight"`      // mark matched terms in search fragments
+	HighlightPre  string `mapstructure:"highlight_pre"`  // inserted before each matched term
+	HighlightPost string `mapstructure:"highlight_post"` // inserted after each matched term
+
+	MaxConcurrentSearches int `mapstructure:"max_concurrent_searches"` // searches running at once (0 = unlimited)
+	SearchQueueSize       int `mapstructure:"search_queue_size"`       // searches waiting for a slot before new ones are rejected
+	MaxConcurrentReads    int `mapstructure:"max_concurrent_reads"`    // file reads running at once (0 = unlimited)
+	ReadQueueSize         int `mapstructure:"read_queue_size"`         // file reads waiting for a slot before new ones are rejected
+
+	// SearchTimeout and ReadTimeout bound a single call of the search tools
+	// (search, search_history) and of the tools returning file contents
+	// (read, search_in_file, get_readme); 0 = no limit
+	SearchTimeout time.Duration `mapstructure:"search_timeout"`
+	ReadTimeout   time.Duration `mapstructure:"read_timeout"`
+
+	// QueryExperiment names an alternate query strategy that every search is
+	// also run with, logging how its top hits compare (empty = off)
+	QueryExperiment string `mapstructure:"query_experiment"`
+
+	// RankingProfile orders the results of searches that select no profile
+	// themselves, nor through their API key (one of RankingProfiles)
+	RankingProfile string `mapstructure:"ranking_profile"`
+
+	// ExtensionAliases name groups of file extensions for the search
+	// extension filter, as "name=ext+ext" entries (e.g. "web=html+css+js").
+	// They add to DefaultExtensionAliases, replacing groups of the same name.
+	ExtensionAliases []string `mapstructure:"extension_aliases"`
+}
+
+// DefaultExtensionAliases are the built-in extension groups of the search
+// extension filter, so that e.g. "js" also finds TypeScript and JSX files.
+var DefaultExtensionAliases = []string{
+	"js=js+jsx+mjs+cjs+ts+tsx",
+	"ts=ts+tsx+mts+cts",
+	"c=c+h",
+	"c++=cc+cpp+cxx+h+hh+hpp+hxx",
+	"cpp=cc+cpp+cxx+h+hh+hpp+hxx",
+	"py=py+pyi",
+	"yaml=yaml+yml",
+	"md=md+markdown",
+	"sh=sh+bash+zsh",
 }
 
 // Settings application settings
@@ -48,6 +392,20 @@ type Settings struct {
 	Port      int              `mapstructure:"port"`
 	Auth      AuthSettings     `mapstructure:"auth"`
 	GitRepos  GitReposSettings `mapstructure:"git_repos"`
+	Pprof     bool             `mapstructure:"pprof"` // serve /debug/pprof and /debug/vars to admin API keys (SSE and HTTP only)
+	UI        bool             `mapstructure:"ui"`    // serve the web UI under /ui (SSE and HTTP only)
+
+	ClientLogLevel string `mapstructure:"client_log_level"` // minimum level of index events sent to MCP clients, or "off"
+	DebugStdio     string `mapstructure:"debug_stdio"`      // file the redacted JSON-RPC frames of the stdio transport are appended to
+
+	IndexUpdateNotify string `mapstructure:"index_update_notify"` // how clients are told that a sync changed the index, or "off"
+	RequestIDFooter   bool   `mapstructure:"request_id_footer"`   // append the request ID of each tool call to its result
+
+	TLS TLSSettings `mapstructure:"tls"`
+
+	// Profile is the configuration profile whose files were loaded over the
+	// base configuration files, if any
+	Profile string `mapstructure:"profile"`
 }
 
 // LoadSettings loads settings from environment variables and optional .env file
@@ -56,26 +414,230 @@ func LoadSettings() (*Settings, error) {
 }
 
 // LoadSettingsWithFlags loads settings with optional CLI flag overrides.
-// Priority: CLI flags > environment variables > .env file > defaults.
-// If flags is nil, only env vars and defaults are used.
+// Priority: CLI flags > environment variables > config files > defaults,
+// where the files of the selected profile take priority over the base files
+// (see readConfigFiles). If flags is nil, only env vars and defaults are
+// used.
 func LoadSettingsWithFlags(flags *pflag.FlagSet) (*Settings, error) {
+	v, err := loadViper(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings Settings
+	if err := v.Unmarshal(&settings); err != nil {
+		return nil, err
+	}
+
+	// List settings given by environment variable are comma-separated
+	settings.Auth.APIKeys = envList(settings.Auth.APIKeys, "RELIC_MCP_AUTH_API_KEYS")
+	settings.Auth.AdminAPIKeys = envList(settings.Auth.AdminAPIKeys, "RELIC_MCP_AUTH_ADMIN_API_KEYS")
+	settings.Auth.MTLSIdentities = envList(settings.Auth.MTLSIdentities, "RELIC_MCP_AUTH_MTLS_IDENTITIES")
+	settings.Auth.ExcludedPaths = envList(settings.Auth.ExcludedPaths, "RELIC_MCP_AUTH_EXCLUDED_PATHS")
+	// Normalize the URLs so that a repository listed twice is cloned and
+	// indexed once
+	settings.GitRepos.URLs = dedupeRepoURLs(envList(settings.GitRepos.URLs, "RELIC_MCP_GIT_REPOS_URLS"))
+	settings.GitRepos.MaxFileSizeOverrides = envList(settings.GitRepos.MaxFileSizeOverrides, "RELIC_MCP_GIT_REPOS_MAX_FILE_SIZE_OVERRIDES")
+	settings.GitRepos.CloneDepthOverrides = envList(settings.GitRepos.CloneDepthOverrides, "RELIC_MCP_GIT_REPOS_CLONE_DEPTH_OVERRIDES")
+	settings.GitRepos.SyncWindows = envList(settings.GitRepos.SyncWindows, "RELIC_MCP_GIT_REPOS_SYNC_WINDOWS")
+	settings.GitRepos.Branches = envList(settings.GitRepos.Branches, "RELIC_MCP_GIT_REPOS_BRANCHES")
+	settings.GitRepos.HTTPSTokens = envList(settings.GitRepos.HTTPSTokens, envNames["git_repos.https_tokens"])
+	settings.GitRepos.ReadDenyPatterns = envList(settings.GitRepos.ReadDenyPatterns, "RELIC_MCP_GIT_REPOS_READ_DENY_PATTERNS")
+	settings.GitRepos.ReadRedactPatterns = filterEmptyStrings(settings.GitRepos.ReadRedactPatterns)
+	settings.GitRepos.Refs = envList(settings.GitRepos.Refs, "RELIC_MCP_GIT_REPOS_REFS")
+	settings.GitRepos.Priority = envList(settings.GitRepos.Priority, "RELIC_MCP_GIT_REPOS_PRIORITY")
+	settings.GitRepos.AllowedURLs = envList(settings.GitRepos.AllowedURLs, "RELIC_MCP_GIT_REPOS_ALLOWED_URLS")
+	settings.GitRepos.DeniedURLs = envList(settings.GitRepos.DeniedURLs, "RELIC_MCP_GIT_REPOS_DENIED_URLS")
+	settings.GitRepos.ExtensionAliases = envList(settings.GitRepos.ExtensionAliases, "RELIC_MCP_GIT_REPOS_EXTENSION_ALIASES")
+
+	// Expand home directory in base_dir
+	settings.GitRepos.BaseDir = expandHomeDir(settings.GitRepos.BaseDir)
+	settings.GitRepos.ReposDir = expandHomeDir(settings.GitRepos.ReposDir)
+	settings.GitRepos.IndexesDir = expandHomeDir(settings.GitRepos.IndexesDir)
+
+	if err := applyQuickRepoMode(&settings, flags); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// loadViper resolves the settings keys from the defaults, the environment,
+// the config files and flags, in that order of increasing priority.
+func loadViper(flags *pflag.FlagSet) (*viper.Viper, error) {
 	v := viper.New()
+	setDefaults(v)
+	bindEnv(v)
 
-	// Default values
+	// Bind CLI flags if provided (highest priority)
+	if flags != nil {
+		_ = v.BindPFlag("transport", flags.Lookup("transport"))
+		_ = v.BindPFlag("host", flags.Lookup("host"))
+		_ = v.BindPFlag("port", flags.Lookup("port"))
+		_ = v.BindPFlag("auth.type", flags.Lookup("auth-type"))
+		_ = v.BindPFlag("auth.basic.username", flags.Lookup("auth-basic-username"))
+		_ = v.BindPFlag("auth.basic.password", flags.Lookup("auth-basic-password"))
+		_ = v.BindPFlag("auth.api_keys", flags.Lookup("auth-api-keys"))
+		_ = v.BindPFlag("auth.admin_api_keys", flags.Lookup("auth-admin-api-keys"))
+		_ = v.BindPFlag("auth.mtls_identities", flags.Lookup("auth-mtls-identities"))
+		_ = v.BindPFlag("auth.excluded_paths", flags.Lookup("auth-excluded-paths"))
+		_ = v.BindPFlag("tls.cert_file", flags.Lookup("tls-cert-file"))
+		_ = v.BindPFlag("tls.key_file", flags.Lookup("tls-key-file"))
+		_ = v.BindPFlag("tls.client_ca_file", flags.Lookup("tls-client-ca-file"))
+		_ = v.BindPFlag("pprof", flags.Lookup("pprof"))
+		_ = v.BindPFlag("ui", flags.Lookup("ui"))
+		_ = v.BindPFlag("client_log_level", flags.Lookup("client-log-level"))
+		_ = v.BindPFlag("debug_stdio", flags.Lookup("debug-stdio"))
+		_ = v.BindPFlag("index_update_notify", flags.Lookup("index-update-notify"))
+		_ = v.BindPFlag("request_id_footer", flags.Lookup("request-id-footer"))
+		_ = v.BindPFlag("profile", flags.Lookup("profile"))
+
+		// Git repos CLI flags
+		_ = v.BindPFlag("git_repos.urls", flags.Lookup("git-repos-urls"))
+		_ = v.BindPFlag("git_repos.base_dir", flags.Lookup("git-repos-base-dir"))
+		_ = v.BindPFlag("git_repos.repos_dir", flags.Lookup("git-repos-repos-dir"))
+		_ = v.BindPFlag("git_repos.indexes_dir", flags.Lookup("git-repos-indexes-dir"))
+		_ = v.BindPFlag("git_repos.sync_interval", flags.Lookup("git-repos-sync-interval"))
+		_ = v.BindPFlag("git_repos.sync_timeout", flags.Lookup("git-repos-sync-timeout"))
+		_ = v.BindPFlag("git_repos.git_command_timeout", flags.Lookup("git-repos-git-command-timeout"))
+		_ = v.BindPFlag("git_repos.git_max_output", flags.Lookup("git-repos-git-max-output"))
+		_ = v.BindPFlag("git_repos.git_sandbox", flags.Lookup("git-repos-git-sandbox"))
+		_ = v.BindPFlag("git_repos.git_max_memory", flags.Lookup("git-repos-git-max-memory"))
+		_ = v.BindPFlag("git_repos.git_max_cpu", flags.Lookup("git-repos-git-max-cpu"))
+		_ = v.BindPFlag("git_repos.sync_windows", flags.Lookup("git-repos-sync-windows"))
+		_ = v.BindPFlag("git_repos.fetch_rate_limit", flags.Lookup("git-repos-fetch-rate-limit"))
+		_ = v.BindPFlag("git_repos.max_file_size", flags.Lookup("git-repos-max-file-size"))
+		_ = v.BindPFlag("git_repos.max_results", flags.Lookup("git-repos-max-results"))
+		_ = v.BindPFlag("git_repos.read_only", flags.Lookup("git-repos-read-only"))
+		_ = v.BindPFlag("git_repos.snapshot_url", flags.Lookup("git-repos-snapshot-url"))
+		_ = v.BindPFlag("git_repos.watch", flags.Lookup("git-repos-watch"))
+		_ = v.BindPFlag("git_repos.follow_symlinks", flags.Lookup("git-repos-follow-symlinks"))
+		_ = v.BindPFlag("git_repos.log_urls", flags.Lookup("git-repos-log-urls"))
+		_ = v.BindPFlag("git_repos.max_repo_files", flags.Lookup("git-repos-max-repo-files"))
+		_ = v.BindPFlag("git_repos.max_repo_bytes", flags.Lookup("git-repos-max-repo-bytes"))
+		_ = v.BindPFlag("git_repos.removed_retention", flags.Lookup("git-repos-removed-retention"))
+		_ = v.BindPFlag("git_repos.verify_index", flags.Lookup("git-repos-verify-index"))
+		_ = v.BindPFlag("git_repos.index_declarations", flags.Lookup("git-repos-index-declarations"))
+		_ = v.BindPFlag("git_repos.sync_failure_threshold", flags.Lookup("git-repos-sync-failure-threshold"))
+		_ = v.BindPFlag("git_repos.startup_checks", flags.Lookup("git-repos-startup-checks"))
+		_ = v.BindPFlag("git_repos.search_telemetry", flags.Lookup("git-repos-search-telemetry"))
+		_ = v.BindPFlag("git_repos.index_batch_size", flags.Lookup("git-repos-index-batch-size"))
+		_ = v.BindPFlag("git_repos.index_batch_bytes", flags.Lookup("git-repos-index-batch-bytes"))
+		_ = v.BindPFlag("git_repos.max_file_size_overrides", flags.Lookup("git-repos-max-file-size-overrides"))
+		_ = v.BindPFlag("git_repos.read_deny_patterns", flags.Lookup("git-repos-read-deny-patterns"))
+		_ = v.BindPFlag("git_repos.refs", flags.Lookup("git-repos-refs"))
+		_ = v.BindPFlag("git_repos.priority", flags.Lookup("git-repos-priority"))
+		_ = v.BindPFlag("git_repos.allowed_urls", flags.Lookup("git-repos-allowed-urls"))
+		_ = v.BindPFlag("git_repos.denied_urls", flags.Lookup("git-repos-denied-urls"))
+		_ = v.BindPFlag("git_repos.history_commits", flags.Lookup("git-repos-history-commits"))
+		_ = v.BindPFlag("git_repos.clone_depth", flags.Lookup("git-repos-clone-depth"))
+		_ = v.BindPFlag("git_repos.clone_depth_overrides", flags.Lookup("git-repos-clone-depth-overrides"))
+		_ = v.BindPFlag("git_repos.branches", flags.Lookup("git-repos-branches"))
+		_ = v.BindPFlag("git_repos.https_tokens", flags.Lookup("git-repos-https-tokens"))
+		_ = v.BindPFlag("git_repos.read_redact_patterns", flags.Lookup("git-repos-read-redact-patterns"))
+		_ = v.BindPFlag("git_repos.read_indexed_only", flags.Lookup("git-repos-read-indexed-only"))
+		_ = v.BindPFlag("git_repos.highlight", flags.Lookup("git-repos-highlight"))
+		_ = v.BindPFlag("git_repos.highlight_pre", flags.Lookup("git-repos-highlight-pre"))
+		_ = v.BindPFlag("git_repos.highlight_post", flags.Lookup("git-repos-highlight-post"))
+		_ = v.BindPFlag("git_repos.max_concurrent_searches", flags.Lookup("git-repos-max-concurrent-searches"))
+		_ = v.BindPFlag("git_repos.search_queue_size", flags.Lookup("git-repos-search-queue-size"))
+		_ = v.BindPFlag("git_repos.max_concurrent_reads", flags.Lookup("git-repos-max-concurrent-reads"))
+		_ = v.BindPFlag("git_repos.read_queue_size", flags.Lookup("git-repos-read-queue-size"))
+		_ = v.BindPFlag("git_repos.search_timeout", flags.Lookup("git-repos-search-timeout"))
+		_ = v.BindPFlag("git_repos.read_timeout", flags.Lookup("git-repos-read-timeout"))
+		_ = v.BindPFlag("git_repos.query_experiment", flags.Lookup("git-repos-query-experiment"))
+		_ = v.BindPFlag("git_repos.ranking_profile", flags.Lookup("git-repos-ranking-profile"))
+		_ = v.BindPFlag("git_repos.extension_aliases", flags.Lookup("git-repos-extension-aliases"))
+	}
+
+	if err := readSecretFiles(v, flags); err != nil {
+		return nil, err
+	}
+
+	// The profile selects config files, so it can only come from a flag or
+	// the environment
+	profile := v.GetString("profile")
+	if !validProfile.MatchString(profile) {
+		return nil, fmt.Errorf("invalid profile %q: use letters, digits, '-' and '_'", profile)
+	}
+	if err := readConfigFiles(v, ".", profile); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// setDefaults sets the default value of every setting.
+func setDefaults(v *viper.Viper) {
 	v.SetDefault("transport", "stdio")
 	v.SetDefault("host", "0.0.0.0")
 	v.SetDefault("port", 8080)
 	v.SetDefault("auth.type", AuthTypeNone)
+	v.SetDefault("auth.excluded_paths", DefaultAuthExcludedPaths)
+	v.SetDefault("pprof", false)
+	v.SetDefault("ui", false)
+	v.SetDefault("client_log_level", ClientLogLevelInfo)
+	v.SetDefault("debug_stdio", "")
+	v.SetDefault("index_update_notify", IndexUpdateNotifyOff)
+	v.SetDefault("request_id_footer", false)
+	v.SetDefault("profile", "")
 
 	// Git repos defaults
 	v.SetDefault("git_repos.base_dir", defaultGitReposBaseDir())
+	v.SetDefault("git_repos.repos_dir", "")
+	v.SetDefault("git_repos.indexes_dir", "")
 	v.SetDefault("git_repos.sync_interval", 15*time.Minute)
 	v.SetDefault("git_repos.sync_timeout", 60*time.Second)
-	v.SetDefault("git_repos.max_file_size", int64(256*1024)) // 256KB
+	v.SetDefault("git_repos.git_command_timeout", 10*time.Minute)
+	v.SetDefault("git_repos.git_max_output", int64(16*1024*1024)) // 16MB
+	v.SetDefault("git_repos.max_file_size", int64(256*1024))      // 256KB
+	v.SetDefault("git_repos.git_sandbox", false)
+	v.SetDefault("git_repos.git_max_memory", int64(0))
+	v.SetDefault("git_repos.git_max_cpu", time.Duration(0))
+	v.SetDefault("git_repos.sync_windows", []string{})
+	v.SetDefault("git_repos.fetch_rate_limit", int64(0))
 	v.SetDefault("git_repos.max_results", 20)
+	v.SetDefault("git_repos.read_only", false)
+	v.SetDefault("git_repos.watch", true)
+	v.SetDefault("git_repos.follow_symlinks", false)
+	v.SetDefault("git_repos.log_urls", true)
+	v.SetDefault("git_repos.max_repo_files", 0)
+	v.SetDefault("git_repos.max_repo_bytes", int64(0))
+	v.SetDefault("git_repos.removed_retention", 24*time.Hour)
+	v.SetDefault("git_repos.verify_index", false)
+	v.SetDefault("git_repos.index_declarations", false)
+	v.SetDefault("git_repos.sync_failure_threshold", 0)
+	v.SetDefault("git_repos.startup_checks", true)
+	v.SetDefault("git_repos.search_telemetry", false)
+	v.SetDefault("git_repos.index_batch_size", 100)
+	v.SetDefault("git_repos.index_batch_bytes", int64(10*1024*1024)) // 10MB
+	v.SetDefault("git_repos.read_indexed_only", false)
+	v.SetDefault("git_repos.max_file_size_overrides", []string{})
+	v.SetDefault("git_repos.refs", []string{})
+	v.SetDefault("git_repos.priority", []string{})
+	v.SetDefault("git_repos.allowed_urls", []string{})
+	v.SetDefault("git_repos.denied_urls", []string{})
+	v.SetDefault("git_repos.history_commits", 0)
+	v.SetDefault("git_repos.clone_depth", 1)
+	v.SetDefault("git_repos.clone_depth_overrides", []string{})
+	v.SetDefault("git_repos.branches", []string{})
+	v.SetDefault("git_repos.https_tokens", []string{})
+	v.SetDefault("git_repos.highlight", true)
+	v.SetDefault("git_repos.highlight_pre", "**")
+	v.SetDefault("git_repos.highlight_post", "**")
+	v.SetDefault("git_repos.max_concurrent_searches", 8)
+	v.SetDefault("git_repos.search_queue_size", 16)
+	v.SetDefault("git_repos.max_concurrent_reads", 16)
+	v.SetDefault("git_repos.read_queue_size", 32)
+	v.SetDefault("git_repos.search_timeout", 10*time.Second)
+	v.SetDefault("git_repos.read_timeout", 30*time.Second)
+	v.SetDefault("git_repos.query_experiment", "")
+	v.SetDefault("git_repos.ranking_profile", RankingProfileDefault)
+	v.SetDefault("git_repos.extension_aliases", []string{})
+}
 
-	// Environment variables
-	v.SetEnvPrefix("RELIC_MCP")
+// bindEnv binds settings to their RELIC_MCP_ environment variables.
+func bindEnv(v *viper.Viper) {
+	v.SetEnvPrefix(envPrefix)
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
@@ -84,78 +646,354 @@ func LoadSettingsWithFlags(flags *pflag.FlagSet) (*Settings, error) {
 	_ = v.BindEnv("auth.basic.username", "RELIC_MCP_AUTH_BASIC_USERNAME")
 	_ = v.BindEnv("auth.basic.password", "RELIC_MCP_AUTH_BASIC_PASSWORD")
 	_ = v.BindEnv("auth.api_keys", "RELIC_MCP_AUTH_API_KEYS")
+	_ = v.BindEnv("auth.admin_api_keys", "RELIC_MCP_AUTH_ADMIN_API_KEYS")
+	_ = v.BindEnv("auth.mtls_identities", "RELIC_MCP_AUTH_MTLS_IDENTITIES")
+	_ = v.BindEnv("auth.excluded_paths", "RELIC_MCP_AUTH_EXCLUDED_PATHS")
+	_ = v.BindEnv("tls.cert_file", "RELIC_MCP_TLS_CERT_FILE")
+	_ = v.BindEnv("tls.key_file", "RELIC_MCP_TLS_KEY_FILE")
+	_ = v.BindEnv("tls.client_ca_file", "RELIC_MCP_TLS_CLIENT_CA_FILE")
 
 	// Git repos env var bindings
 	_ = v.BindEnv("git_repos.urls", "RELIC_MCP_GIT_REPOS_URLS")
 	_ = v.BindEnv("git_repos.base_dir", "RELIC_MCP_GIT_REPOS_BASE_DIR")
+	_ = v.BindEnv("git_repos.repos_dir", "RELIC_MCP_GIT_REPOS_REPOS_DIR")
+	_ = v.BindEnv("git_repos.indexes_dir", "RELIC_MCP_GIT_REPOS_INDEXES_DIR")
 	_ = v.BindEnv("git_repos.sync_interval", "RELIC_MCP_GIT_REPOS_SYNC_INTERVAL")
 	_ = v.BindEnv("git_repos.sync_timeout", "RELIC_MCP_GIT_REPOS_SYNC_TIMEOUT")
+	_ = v.BindEnv("git_repos.git_command_timeout", "RELIC_MCP_GIT_REPOS_GIT_COMMAND_TIMEOUT")
+	_ = v.BindEnv("git_repos.git_max_output", "RELIC_MCP_GIT_REPOS_GIT_MAX_OUTPUT")
+	_ = v.BindEnv("git_repos.git_sandbox", "RELIC_MCP_GIT_REPOS_GIT_SANDBOX")
+	_ = v.BindEnv("git_repos.git_max_memory", "RELIC_MCP_GIT_REPOS_GIT_MAX_MEMORY")
+	_ = v.BindEnv("git_repos.git_max_cpu", "RELIC_MCP_GIT_REPOS_GIT_MAX_CPU")
+	_ = v.BindEnv("git_repos.sync_windows", "RELIC_MCP_GIT_REPOS_SYNC_WINDOWS")
+	_ = v.BindEnv("git_repos.fetch_rate_limit", "RELIC_MCP_GIT_REPOS_FETCH_RATE_LIMIT")
 	_ = v.BindEnv("git_repos.max_file_size", "RELIC_MCP_GIT_REPOS_MAX_FILE_SIZE")
 	_ = v.BindEnv("git_repos.max_results", "RELIC_MCP_GIT_REPOS_MAX_RESULTS")
+	_ = v.BindEnv("git_repos.read_only", "RELIC_MCP_GIT_REPOS_READ_ONLY")
+	_ = v.BindEnv("git_repos.snapshot_url", "RELIC_MCP_GIT_REPOS_SNAPSHOT_URL")
+	_ = v.BindEnv("git_repos.watch", "RELIC_MCP_GIT_REPOS_WATCH")
+	_ = v.BindEnv("git_repos.follow_symlinks", "RELIC_MCP_GIT_REPOS_FOLLOW_SYMLINKS")
+	_ = v.BindEnv("git_repos.log_urls", "RELIC_MCP_GIT_REPOS_LOG_URLS")
+	_ = v.BindEnv("git_repos.max_repo_files", "RELIC_MCP_GIT_REPOS_MAX_REPO_FILES")
+	_ = v.BindEnv("git_repos.max_repo_bytes", "RELIC_MCP_GIT_REPOS_MAX_REPO_BYTES")
+	_ = v.BindEnv("git_repos.removed_retention", "RELIC_MCP_GIT_REPOS_REMOVED_RETENTION")
+	_ = v.BindEnv("git_repos.verify_index", "RELIC_MCP_GIT_REPOS_VERIFY_INDEX")
+	_ = v.BindEnv("git_repos.index_declarations", "RELIC_MCP_GIT_REPOS_INDEX_DECLARATIONS")
+	_ = v.BindEnv("git_repos.sync_failure_threshold", "RELIC_MCP_GIT_REPOS_SYNC_FAILURE_THRESHOLD")
+	_ = v.BindEnv("git_repos.startup_checks", "RELIC_MCP_GIT_REPOS_STARTUP_CHECKS")
+	_ = v.BindEnv("git_repos.search_telemetry", "RELIC_MCP_GIT_REPOS_SEARCH_TELEMETRY")
+	_ = v.BindEnv("git_repos.index_batch_size", "RELIC_MCP_GIT_REPOS_INDEX_BATCH_SIZE")
+	_ = v.BindEnv("git_repos.index_batch_bytes", "RELIC_MCP_GIT_REPOS_INDEX_BATCH_BYTES")
+	_ = v.BindEnv("git_repos.max_file_size_overrides", "RELIC_MCP_GIT_REPOS_MAX_FILE_SIZE_OVERRIDES")
+	_ = v.BindEnv("git_repos.read_deny_patterns", "RELIC_MCP_GIT_REPOS_READ_DENY_PATTERNS")
+	_ = v.BindEnv("git_repos.refs", "RELIC_MCP_GIT_REPOS_REFS")
+	_ = v.BindEnv("git_repos.priority", "RELIC_MCP_GIT_REPOS_PRIORITY")
+	_ = v.BindEnv("git_repos.allowed_urls", "RELIC_MCP_GIT_REPOS_ALLOWED_URLS")
+	_ = v.BindEnv("git_repos.denied_urls", "RELIC_MCP_GIT_REPOS_DENIED_URLS")
+	_ = v.BindEnv("git_repos.history_commits", "RELIC_MCP_GIT_REPOS_HISTORY_COMMITS")
+	_ = v.BindEnv("git_repos.clone_depth", "RELIC_MCP_GIT_REPOS_CLONE_DEPTH")
+	_ = v.BindEnv("git_repos.clone_depth_overrides", "RELIC_MCP_GIT_REPOS_CLONE_DEPTH_OVERRIDES")
+	_ = v.BindEnv("git_repos.branches", "RELIC_MCP_GIT_REPOS_BRANCHES")
+	_ = v.BindEnv("git_repos.https_tokens", envNames["git_repos.https_tokens"])
+	_ = v.BindEnv("git_repos.read_redact_patterns", "RELIC_MCP_GIT_REPOS_READ_REDACT_PATTERNS")
+	_ = v.BindEnv("git_repos.read_indexed_only", "RELIC_MCP_GIT_REPOS_READ_INDEXED_ONLY")
+	_ = v.BindEnv("git_repos.highlight", "RELIC_MCP_GIT_REPOS_HIGHLIGHT")
+	_ = v.BindEnv("git_repos.highlight_pre", "RELIC_MCP_GIT_REPOS_HIGHLIGHT_PRE")
+	_ = v.BindEnv("git_repos.highlight_post", "RELIC_MCP_GIT_REPOS_HIGHLIGHT_POST")
+	_ = v.BindEnv("git_repos.max_concurrent_searches", "RELIC_MCP_GIT_REPOS_MAX_CONCURRENT_SEARCHES")
+	_ = v.BindEnv("git_repos.search_queue_size", "RELIC_MCP_GIT_REPOS_SEARCH_QUEUE_SIZE")
+	_ = v.BindEnv("git_repos.max_concurrent_reads", "RELIC_MCP_GIT_REPOS_MAX_CONCURRENT_READS")
+	_ = v.BindEnv("git_repos.read_queue_size", "RELIC_MCP_GIT_REPOS_READ_QUEUE_SIZE")
+	_ = v.BindEnv("git_repos.search_timeout", "RELIC_MCP_GIT_REPOS_SEARCH_TIMEOUT")
+	_ = v.BindEnv("git_repos.read_timeout", "RELIC_MCP_GIT_REPOS_READ_TIMEOUT")
+	_ = v.BindEnv("git_repos.query_experiment", "RELIC_MCP_GIT_REPOS_QUERY_EXPERIMENT")
+	_ = v.BindEnv("git_repos.ranking_profile", "RELIC_MCP_GIT_REPOS_RANKING_PROFILE")
+	_ = v.BindEnv("git_repos.extension_aliases", "RELIC_MCP_GIT_REPOS_EXTENSION_ALIASES")
+}
 
-	// Bind CLI flags if provided (highest priority)
-	if flags != nil {
-		_ = v.BindPFlag("transport", flags.Lookup("transport"))
-		_ = v.BindPFlag("host", flags.Lookup("host"))
-		_ = v.BindPFlag("port", flags.Lookup("port"))
-		_ = v.BindPFlag("auth.type", flags.Lookup("auth-type"))
-		_ = v.BindPFlag("auth.basic.username", flags.Lookup("auth-basic-username"))
-		_ = v.BindPFlag("auth.basic.password", flags.Lookup("auth-basic-password"))
-		_ = v.BindPFlag("auth.api_keys", flags.Lookup("auth-api-keys"))
+// secretFileSuffix is appended to the environment variable of a secret
+// setting to name a file holding its value instead
+const secretFileSuffix = "_FILE"
 
-		// Git repos CLI flags
-		_ = v.BindPFlag("git_repos.urls", flags.Lookup("git-repos-urls"))
-		_ = v.BindPFlag("git_repos.base_dir", flags.Lookup("git-repos-base-dir"))
-		_ = v.BindPFlag("git_repos.sync_interval", flags.Lookup("git-repos-sync-interval"))
-		_ = v.BindPFlag("git_repos.sync_timeout", flags.Lookup("git-repos-sync-timeout"))
-		_ = v.BindPFlag("git_repos.max_file_size", flags.Lookup("git-repos-max-file-size"))
-		_ = v.BindPFlag("git_repos.max_results", flags.Lookup("git-repos-max-results"))
+// secretSettings can be read from a file named by <env var>_FILE, such as a
+// mounted Docker or Kubernetes secret. Lists can be one entry per line.
+var secretSettings = []struct {
+	key  string
+	env  string
+	list bool
+}{
+	{"auth.basic.password", "RELIC_MCP_AUTH_BASIC_PASSWORD", false},
+	{"auth.api_keys", "RELIC_MCP_AUTH_API_KEYS", true},
+	{"auth.admin_api_keys", "RELIC_MCP_AUTH_ADMIN_API_KEYS", true},
+	{"git_repos.urls", "RELIC_MCP_GIT_REPOS_URLS", true}, // may embed access tokens
+	{"git_repos.https_tokens", "RELIC_MCP_GIT_HTTPS_TOKENS", true},
+}
+
+// envNames are the environment variables of the settings whose variable is
+// not derived from their key.
+var envNames = map[string]string{
+	"git_repos.https_tokens": "RELIC_MCP_GIT_HTTPS_TOKENS",
+}
+
+// readSecretFiles sets the secret settings whose <env var>_FILE variable is
+// set to the content of that file. A flag still takes priority; setting both
+// the variable and its _FILE variant is an error.
+func readSecretFiles(v *viper.Viper, flags *pflag.FlagSet) error {
+	for _, secret := range secretSettings {
+		path := os.Getenv(secret.env + secretFileSuffix)
+		if path == "" {
+			continue
+		}
+		if os.Getenv(secret.env) != "" {
+			return fmt.Errorf("%s and %s are mutually exclusive", secret.env, secret.env+secretFileSuffix)
+		}
+		if flags != nil {
+			if f := flags.Lookup(strings.NewReplacer(".", "-", "_", "-").Replace(secret.key)); f != nil && f.Changed {
+				continue
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", secret.env+secretFileSuffix, err)
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+		if secret.list {
+			value = strings.Join(strings.Fields(strings.ReplaceAll(value, ",", " ")), ",")
+		}
+		v.Set(secret.key, value)
 	}
+	return nil
+}
 
-	// Helper to look for .env file
-	v.SetConfigName(".env")
-	v.SetConfigType("env")
-	v.AddConfigPath(".")
-	_ = v.ReadInConfig() // Ignore error if .env doesn't exist
+// validProfile matches the profile names that can be part of a file name
+var validProfile = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
 
-	var settings Settings
-	if err := v.Unmarshal(&settings); err != nil {
-		return nil, err
+// readConfigFiles merges the optional config files in dir into the config
+// layer of v. Later files take priority: config.yaml, .env, and with a
+// profile, config.<profile>.yaml and .env.<profile>. At least one file of a
+// selected profile must exist, so that a misspelled profile is not silently
+// ignored.
+//
+// Variables in .env files are named as in the environment
+// (RELIC_MCP_GIT_REPOS_URLS); settings keys (git_repos.urls) are accepted
+// too.
+func readConfigFiles(v *viper.Viper, dir, profile string) error {
+	type configFile struct {
+		name, format string
+	}
+	files := []configFile{{"config.yaml", "yaml"}, {".env", "env"}}
+	if profile != "" {
+		files = append(files, configFile{"config." + profile + ".yaml", "yaml"}, configFile{".env." + profile, "env"})
+	}
+
+	keys := make(map[string]string)
+	for _, env := range EnvVars() {
+		if !env.File {
+			keys[strings.ToLower(env.Name)] = env.Key
+		}
 	}
 
-	// Handle explicit parsing of API keys if provided via env var as comma-separated string
-	apiKeysEnv := os.Getenv("RELIC_MCP_AUTH_API_KEYS")
-	if apiKeysEnv != "" {
-		if len(settings.Auth.APIKeys) == 0 || (len(settings.Auth.APIKeys) == 1 && strings.Contains(settings.Auth.APIKeys[0], ",")) {
-			settings.Auth.APIKeys = strings.Split(apiKeysEnv, ",")
+	profileFiles := 0
+	for n, file := range files {
+		path := filepath.Join(dir, file.name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		f := viper.New()
+		f.SetConfigFile(path)
+		f.SetConfigType(file.format)
+		if err := f.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		// Rebuilt through Set, so that flat keys end up nested like the
+		// settings
+		layer := viper.New()
+		for _, key := range f.AllKeys() {
+			name := key
+			if k, ok := keys[key]; ok {
+				name = k
+			}
+			layer.Set(name, f.Get(key))
+		}
+		if err := v.MergeConfigMap(layer.AllSettings()); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", path, err)
+		}
+		if n >= 2 {
+			profileFiles++
 		}
 	}
 
-	// Trim spaces from API keys
-	for i := range settings.Auth.APIKeys {
-		settings.Auth.APIKeys[i] = strings.TrimSpace(settings.Auth.APIKeys[i])
+	if profile != "" && profileFiles == 0 {
+		return fmt.Errorf("no config files found for profile %q (expected config.%s.yaml or .env.%s)", profile, profile, profile)
 	}
+	return nil
+}
+
+// EnvVar is an environment variable read by LoadSettings.
+type EnvVar struct {
+	Name    string // e.g. RELIC_MCP_GIT_REPOS_URLS
+	Key     string // settings key, e.g. git_repos.urls
+	Default string // empty if the setting has no default
+	File    bool   // names a file holding the value of the setting
+}
+
+// EnvVars returns the environment variables read by LoadSettings, sorted by
+// name, with their defaults. The _FILE variants of secret settings are
+// included.
+func EnvVars() []EnvVar {
+	bound := viper.New()
+	setDefaults(bound)
+	bindEnv(bound)
 
-	// Handle explicit parsing of git repos URLs if provided via env var as comma-separated string
-	gitReposURLsEnv := os.Getenv("RELIC_MCP_GIT_REPOS_URLS")
-	if gitReposURLsEnv != "" {
-		if len(settings.GitRepos.URLs) == 0 || (len(settings.GitRepos.URLs) == 1 && strings.Contains(settings.GitRepos.URLs[0], ",")) {
-			settings.GitRepos.URLs = strings.Split(gitReposURLsEnv, ",")
+	// A separate instance, so that defaults are not masked by the environment
+	defaults := viper.New()
+	setDefaults(defaults)
+
+	replacer := strings.NewReplacer(".", "_")
+	var vars []EnvVar
+	for _, key := range bound.AllKeys() {
+		name, ok := envNames[key]
+		if !ok {
+			name = envPrefix + "_" + strings.ToUpper(replacer.Replace(key))
 		}
+		vars = append(vars, EnvVar{
+			Name:    name,
+			Key:     key,
+			Default: formatEnvValue(defaults.Get(key)),
+		})
+	}
+	for _, secret := range secretSettings {
+		vars = append(vars, EnvVar{Name: secret.env + secretFileSuffix, Key: secret.key, File: true})
 	}
+	slices.SortFunc(vars, func(a, b EnvVar) int { return strings.Compare(a.Name, b.Name) })
+	return vars
+}
 
-	// Trim spaces from git repos URLs
-	for i := range settings.GitRepos.URLs {
-		settings.GitRepos.URLs[i] = strings.TrimSpace(settings.GitRepos.URLs[i])
+// SettingsEnv returns the environment variables that reproduce the settings
+// loaded with flags, for the settings that differ from their defaults, e.g.
+// to start the server from another directory with the same settings. Secrets
+// read from files are returned as their _FILE variables.
+func SettingsEnv(flags *pflag.FlagSet) (map[string]string, error) {
+	v, err := loadViper(flags)
+	if err != nil {
+		return nil, err
 	}
 
-	// Filter out empty URLs
-	settings.GitRepos.URLs = filterEmptyStrings(settings.GitRepos.URLs)
+	env := make(map[string]string)
+	for _, secret := range secretSettings {
+		path := os.Getenv(secret.env + secretFileSuffix)
+		if path == "" {
+			continue
+		}
+		if flags != nil {
+			if f := flags.Lookup(strings.NewReplacer(".", "-", "_", "-").Replace(secret.key)); f != nil && f.Changed {
+				continue
+			}
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		env[secret.env+secretFileSuffix] = path
+	}
+	for _, e := range EnvVars() {
+		if e.File || env[e.Name+secretFileSuffix] != "" {
+			continue
+		}
+		if value := formatEnvValue(v.Get(e.Key)); value != e.Default {
+			env[e.Name] = value
+		}
+	}
+	return env, nil
+}
 
-	// Expand home directory in base_dir
-	settings.GitRepos.BaseDir = expandHomeDir(settings.GitRepos.BaseDir)
+// formatEnvValue formats a setting value the way it is written in the
+// environment.
+func formatEnvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(v, ",")
+	case []any:
+		items := make([]string, len(v))
+		for n, item := range v {
+			items[n] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
 
-	return &settings, nil
+// applyQuickRepoMode applies the --repo and --cwd shortcuts: a single
+// repository served over stdio, with its clone and index in their own
+// directory under the base directory so that separate editor sessions don't
+// interfere. Explicit --transport and --git-repos-base-dir flags are respected.
+func applyQuickRepoMode(settings *Settings, flags *pflag.FlagSet) error {
+	if flags == nil {
+		return nil
+	}
+
+	var repo string
+	if flags.Lookup("repo") != nil {
+		repo, _ = flags.GetString("repo")
+		repo = strings.TrimSpace(repo)
+	}
+	var cwd bool
+	if flags.Lookup("cwd") != nil {
+		cwd, _ = flags.GetBool("cwd")
+	}
+
+	var dirName string
+	switch {
+	case repo != "" && cwd:
+		return errors.New("--repo and --cwd are mutually exclusive")
+	case repo != "":
+		settings.GitRepos.URLs = []string{repo}
+		dirName = quickRepoDirName(repo)
+	case cwd:
+		dir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		settings.GitRepos.URLs = nil
+		settings.GitRepos.LocalDir = dir
+		dirName = "cwd_" + quickRepoDirName(dir)
+	default:
+		return nil
+	}
+
+	if !flags.Changed("transport") {
+		settings.Transport = "stdio"
+	}
+	if !flags.Changed("git-repos-base-dir") {
+		settings.GitRepos.BaseDir = filepath.Join(settings.GitRepos.BaseDir, "quick", dirName)
+	}
+	if settings.GitRepos.ReposDir != "" && !flags.Changed("git-repos-repos-dir") {
+		settings.GitRepos.ReposDir = filepath.Join(settings.GitRepos.ReposDir, "quick", dirName)
+	}
+	if settings.GitRepos.IndexesDir != "" && !flags.Changed("git-repos-indexes-dir") {
+		settings.GitRepos.IndexesDir = filepath.Join(settings.GitRepos.IndexesDir, "quick", dirName)
+	}
+	return nil
+}
+
+// quickRepoDirName converts a repository URL or path into a filesystem-safe directory name.
+func quickRepoDirName(repo string) string {
+	repo = strings.TrimSuffix(repo, ".git")
+	if i := strings.LastIndex(repo, "@"); i >= 0 {
+		repo = repo[i+1:]
+	}
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, repo)
+	return strings.Trim(name, "_")
 }
 
 // defaultGitReposBaseDir returns the default base directory for git repos
@@ -186,6 +1024,22 @@ func expandHomeDir(path string) string {
 	return path
 }
 
+// envList returns the list setting loaded into list, trimmed and without
+// empty entries. Viper does not split the value of an environment variable,
+// so the comma-separated value of env replaces a list that was loaded from it
+// as a single entry.
+func envList(list []string, env string) []string {
+	if value := os.Getenv(env); value != "" {
+		if len(list) == 0 || (len(list) == 1 && strings.Contains(list[0], ",")) {
+			list = strings.Split(value, ",")
+		}
+	}
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return filterEmptyStrings(list)
+}
+
 // filterEmptyStrings removes empty strings from a slice
 func filterEmptyStrings(s []string) []string {
 	var result []string
@@ -197,15 +1051,36 @@ func filterEmptyStrings(s []string) []string {
 	return result
 }
 
+// ServesHTTP reports whether the transport is served by the HTTP listener,
+// which is the case for sse and http.
+func (s *Settings) ServesHTTP() bool {
+	return s.Transport == "sse" || s.Transport == "http"
+}
+
+// UIAPIPath prefixes the endpoints the web UI fetches its data from
+const UIAPIPath = "/ui/api/"
+
+// DebugPath prefixes the profiling endpoints served with Pprof
+const DebugPath = "/debug/"
+
+// MCPPath returns the path of the MCP endpoint of the HTTP listener: /mcp for
+// the streamable http transport and /sse otherwise.
+func (s *Settings) MCPPath() string {
+	if s.Transport == "http" {
+		return "/mcp"
+	}
+	return "/sse"
+}
+
 // ValidateSettings checks for conflicting configurations.
 // Returns an error if the settings contain mutually exclusive or incomplete auth config.
 func ValidateSettings(s *Settings) error {
 	// Validate transport type
 	switch s.Transport {
-	case "stdio", "sse":
+	case "stdio", "sse", "http":
 		// valid
 	default:
-		return errors.New("transport must be 'stdio' or 'sse', got: " + s.Transport)
+		return errors.New("transport must be 'stdio', 'sse' or 'http', got: " + s.Transport)
 	}
 
 	hasBasicCreds := s.Auth.Basic.Username != "" || s.Auth.Basic.Password != ""
@@ -223,6 +1098,16 @@ func ValidateSettings(s *Settings) error {
 		if s.Auth.Basic.Username == "" || s.Auth.Basic.Password == "" {
 			return errors.New("auth-type 'basic' requires both username and password")
 		}
+	case AuthTypeMTLS:
+		if hasBasicCreds || hasAPIKeys {
+			return errors.New("auth-type 'mtls' is incompatible with basic auth credentials and auth-api-keys")
+		}
+		if !s.ServesHTTP() {
+			return errors.New("auth-type 'mtls' requires transport 'sse' or 'http'")
+		}
+		if !s.TLS.Enabled() || s.TLS.ClientCAFile == "" {
+			return errors.New("auth-type 'mtls' requires tls-cert-file, tls-key-file and tls-client-ca-file")
+		}
 	case AuthTypeAPIKey:
 		if hasBasicCreds {
 			return errors.New("auth-type 'apikey' is mutually exclusive with basic auth credentials")
@@ -230,10 +1115,72 @@ func ValidateSettings(s *Settings) error {
 		if !hasAPIKeys {
 			return errors.New("auth-type 'apikey' requires at least one API key")
 		}
+		for _, entry := range s.Auth.APIKeys {
+			if _, _, _, err := ParseAPIKey(entry); err != nil {
+				return fmt.Errorf("invalid auth-api-keys entry: %w", err)
+			}
+		}
 	default:
 		return errors.New("unknown auth-type: " + s.Auth.Type)
 	}
 
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		return errors.New("tls-cert-file and tls-key-file must be set together")
+	}
+	if s.TLS.ClientCAFile != "" && s.Auth.Type != AuthTypeMTLS {
+		return errors.New("tls-client-ca-file requires auth-type 'mtls'")
+	}
+	if len(s.Auth.MTLSIdentities) > 0 && s.Auth.Type != AuthTypeMTLS {
+		return errors.New("auth-mtls-identities requires auth-type 'mtls'")
+	}
+	for _, entry := range s.Auth.ExcludedPaths {
+		if err := validateExcludedPath(entry); err != nil {
+			return fmt.Errorf("invalid auth-excluded-paths entry: %w", err)
+		}
+	}
+	// Excluding the MCP endpoint would disable auth altogether
+	if s.Auth.IsExcludedPath(s.MCPPath()) {
+		return errors.New("auth-excluded-paths cannot include the MCP endpoint " + s.MCPPath())
+	}
+	// Nor can it include the web UI endpoints, which run the tools for any
+	// request without scopes, or the admin-only profiling endpoints
+	if s.UI && s.Auth.excludesUnder(UIAPIPath) {
+		return errors.New("auth-excluded-paths cannot include the web UI endpoints " + UIAPIPath)
+	}
+	if s.Pprof && s.Auth.excludesUnder(DebugPath) {
+		return errors.New("auth-excluded-paths cannot include the profiling endpoints " + DebugPath)
+	}
+
+	// Profiling endpoints are never served without admin credentials
+	if s.Pprof {
+		if !s.ServesHTTP() {
+			return errors.New("pprof requires transport 'sse' or 'http'")
+		}
+		if !s.Auth.HasAdminKeys() {
+			return errors.New("pprof requires at least one admin API key (auth-admin-api-keys, or an auth-api-keys entry with the admin scope)")
+		}
+	}
+
+	if s.UI && !s.ServesHTTP() {
+		return errors.New("ui requires transport 'sse' or 'http'")
+	}
+
+	switch s.ClientLogLevel {
+	case "", ClientLogLevelDebug, ClientLogLevelInfo, ClientLogLevelWarn, ClientLogLevelError, ClientLogLevelOff:
+	default:
+		return errors.New("unknown client-log-level: " + s.ClientLogLevel)
+	}
+
+	switch s.IndexUpdateNotify {
+	case "", IndexUpdateNotifyOff, IndexUpdateNotifyResources, IndexUpdateNotifyLog:
+	default:
+		return errors.New("unknown index-update-notify: " + s.IndexUpdateNotify)
+	}
+
+	if s.DebugStdio != "" && s.Transport != "stdio" {
+		return errors.New("debug-stdio requires transport 'stdio'")
+	}
+
 	// Validate git repos settings
 	if err := validateGitReposSettings(&s.GitRepos); err != nil {
 		return err
@@ -244,7 +1191,14 @@ func ValidateSettings(s *Settings) error {
 
 // validateGitReposSettings validates the git repos configuration
 func validateGitReposSettings(g *GitReposSettings) error {
-	if len(g.URLs) == 0 {
+	if g.LocalDir != "" {
+		if len(g.URLs) > 0 {
+			return errors.New("--cwd cannot be combined with repository URLs")
+		}
+		if g.ReadOnly {
+			return errors.New("--cwd cannot be combined with git-repos-read-only")
+		}
+	} else if len(g.URLs) == 0 {
 		return errors.New("at least one repository URL is required (git-repos-urls)")
 	}
 
@@ -256,6 +1210,24 @@ func validateGitReposSettings(g *GitReposSettings) error {
 		return errors.New("git-repos-sync-timeout must be positive")
 	}
 
+	if _, err := g.SyncSchedule(); err != nil {
+		return err
+	}
+	if g.FetchRateLimit < 0 {
+		return errors.New("git-repos-fetch-rate-limit cannot be negative")
+	}
+
+	if g.GitCommandTimeout < 0 || g.GitMaxOutput < 0 {
+		return errors.New("git-repos-git-command-timeout and git-repos-git-max-output cannot be negative")
+	}
+
+	if g.GitMaxMemory < 0 || g.GitMaxCPU < 0 {
+		return errors.New("git-repos-git-max-memory and git-repos-git-max-cpu cannot be negative")
+	}
+	if runtime.GOOS == "windows" && (g.GitMaxMemory > 0 || g.GitMaxCPU > 0) {
+		return errors.New("git-repos-git-max-memory and git-repos-git-max-cpu are not supported on Windows")
+	}
+
 	if g.MaxFileSize <= 0 {
 		return errors.New("git-repos-max-file-size must be positive")
 	}
@@ -264,9 +1236,322 @@ func validateGitReposSettings(g *GitReposSettings) error {
 		return errors.New("git-repos-max-results must be positive")
 	}
 
+	if g.MaxConcurrentSearches < 0 || g.SearchQueueSize < 0 {
+		return errors.New("git-repos-max-concurrent-searches and git-repos-search-queue-size cannot be negative")
+	}
+	if g.MaxConcurrentReads < 0 || g.ReadQueueSize < 0 {
+		return errors.New("git-repos-max-concurrent-reads and git-repos-read-queue-size cannot be negative")
+	}
+	if g.SearchTimeout < 0 || g.ReadTimeout < 0 {
+		return errors.New("git-repos-search-timeout and git-repos-read-timeout cannot be negative")
+	}
+
+	switch g.QueryExperiment {
+	case "", QueryStrategyExact, QueryStrategySymbols:
+	default:
+		return fmt.Errorf("unknown git-repos-query-experiment %q (use %s or %s)", g.QueryExperiment, QueryStrategyExact, QueryStrategySymbols)
+	}
+
+	if g.RankingProfile != "" && !slices.Contains(RankingProfiles, g.RankingProfile) {
+		return fmt.Errorf("unknown git-repos-ranking-profile %q (use %s)", g.RankingProfile, strings.Join(RankingProfiles, ", "))
+	}
+
+	if g.MaxRepoFiles < 0 || g.MaxRepoBytes < 0 {
+		return errors.New("git-repos-max-repo-files and git-repos-max-repo-bytes cannot be negative")
+	}
+
+	if g.HistoryCommits < 0 || g.HistoryCommits > MaxHistoryCommits {
+		return fmt.Errorf("git-repos-history-commits must be between 0 and %d", MaxHistoryCommits)
+	}
+
+	if g.CloneDepth < 0 {
+		return errors.New("git-repos-clone-depth cannot be negative")
+	}
+	if _, err := g.CloneDepths(); err != nil {
+		return err
+	}
+	if _, err := g.BranchOverrides(); err != nil {
+		return err
+	}
+
+	if _, err := g.HTTPSCredentials(); err != nil {
+		return err
+	}
+
+	if g.RemovedRetention < 0 {
+		return errors.New("git-repos-removed-retention cannot be negative")
+	}
+
+	if g.SyncFailureThreshold < 0 {
+		return errors.New("git-repos-sync-failure-threshold cannot be negative")
+	}
+
+	if g.IndexBatchSize < 0 || g.IndexBatchBytes < 0 {
+		return errors.New("git-repos-index-batch-size and git-repos-index-batch-bytes cannot be negative")
+	}
+
+	if filepath.Clean(g.ReposPath()) == filepath.Clean(g.IndexesPath()) {
+		return errors.New("git-repos-repos-dir and git-repos-indexes-dir must be different directories")
+	}
+
+	if _, err := g.ReadRedactions(); err != nil {
+		return err
+	}
+
+	for _, ref := range g.Refs {
+		if strings.HasPrefix(ref, "-") || strings.Contains(ref, "..") || strings.ContainsAny(ref, " \t~^:?*[\\") {
+			return fmt.Errorf("invalid git-repos-refs entry %q: not a valid tag or branch name", ref)
+		}
+	}
+
+	if _, err := g.FileSizeOverrides(); err != nil {
+		return err
+	}
+
+	if _, err := g.ExtensionGroups(); err != nil {
+		return err
+	}
+
 	if g.BaseDir == "" {
 		return errors.New("git-repos-base-dir cannot be empty")
 	}
 
+	for _, pattern := range append(slices.Clone(g.AllowedURLs), g.DeniedURLs...) {
+		if err := validURLPattern(pattern); err != nil {
+			return fmt.Errorf("invalid git-repos-allowed-urls or git-repos-denied-urls entry: %w", err)
+		}
+	}
+	for _, u := range g.URLs {
+		if err := g.CheckRepoURL(u); err != nil {
+			return err
+		}
+	}
+
+	if g.SnapshotURL != "" {
+		u, err := url.Parse(g.SnapshotURL)
+		if err != nil {
+			return fmt.Errorf("invalid git-repos-snapshot-url: %w", err)
+		}
+		switch u.Scheme {
+		case "file", "s3", "gs":
+		default:
+			return fmt.Errorf("unsupported git-repos-snapshot-url scheme: %q (must be file, s3 or gs)", u.Scheme)
+		}
+	}
+
 	return nil
 }
+
+// FileSizeOverrides parses MaxFileSizeOverrides into a map from lowercase
+// file extension, without the leading dot, to the maximum size in bytes.
+func (g *GitReposSettings) FileSizeOverrides() (map[string]int64, error) {
+	overrides := make(map[string]int64, len(g.MaxFileSizeOverrides))
+	for _, entry := range g.MaxFileSizeOverrides {
+		ext, size, ok := strings.Cut(entry, "=")
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if !ok || ext == "" {
+			return nil, fmt.Errorf("invalid git-repos-max-file-size-overrides entry %q (expected ext=bytes)", entry)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid git-repos-max-file-size-overrides entry %q: size must be a positive number of bytes", entry)
+		}
+		overrides[ext] = n
+	}
+	return overrides, nil
+}
+
+// CloneDepths parses CloneDepthOverrides into a map from repository name
+// (e.g. github.com/org/repo) to clone depth.
+func (g *GitReposSettings) CloneDepths() (map[string]int, error) {
+	depths := make(map[string]int, len(g.CloneDepthOverrides))
+	for _, entry := range g.CloneDepthOverrides {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid git-repos-clone-depth-overrides entry %q (expected repo=depth)", entry)
+		}
+		depth, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil || depth < 0 {
+			return nil, fmt.Errorf("invalid git-repos-clone-depth-overrides entry %q: depth must be 0 (full history) or more", entry)
+		}
+		depths[RepoURLName(strings.TrimSpace(entry[:i]))] = depth
+	}
+	return depths, nil
+}
+
+// CloneDepthFor returns the clone depth of the repository at rawURL,
+// applying any override for it. Invalid overrides are ignored; they are
+// reported by ValidateSettings.
+func (g *GitReposSettings) CloneDepthFor(rawURL string) int {
+	depths, _ := g.CloneDepths()
+	if depth, ok := depths[RepoURLName(rawURL)]; ok {
+		return depth
+	}
+	return g.CloneDepth
+}
+
+// SyncSchedule parses SyncWindows.
+func (g *GitReposSettings) SyncSchedule() (SyncSchedule, error) {
+	schedule := make(SyncSchedule, 0, len(g.SyncWindows))
+	for _, entry := range g.SyncWindows {
+		window, err := ParseSyncWindow(entry)
+		if err != nil {
+			return nil, fmt.Errorf("git-repos-sync-windows: %w", err)
+		}
+		schedule = append(schedule, window)
+	}
+	return schedule, nil
+}
+
+// BranchOverrides parses Branches into a map from repository name (e.g.
+// github.com/org/repo) to branch.
+func (g *GitReposSettings) BranchOverrides() (map[string]string, error) {
+	branches := make(map[string]string, len(g.Branches))
+	for _, entry := range g.Branches {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid git-repos-branches entry %q (expected repo=branch)", entry)
+		}
+		branch := strings.TrimSpace(entry[i+1:])
+		if !validBranchName(branch) {
+			return nil, fmt.Errorf("invalid git-repos-branches entry %q: %q is not a valid branch name", entry, branch)
+		}
+		branches[RepoURLName(strings.TrimSpace(entry[:i]))] = branch
+	}
+	return branches, nil
+}
+
+// BranchFor returns the branch to sync for the repository at rawURL, or ""
+// for the branch its remote HEAD points to. Invalid entries are ignored;
+// they are reported by ValidateSettings.
+func (g *GitReposSettings) BranchFor(rawURL string) string {
+	branches, _ := g.BranchOverrides()
+	return branches[RepoURLName(rawURL)]
+}
+
+// HTTPSCredentials parses HTTPSTokens into a map from host name (e.g.
+// github.com) to token, or to "user:token". Errors do not include tokens.
+func (g *GitReposSettings) HTTPSCredentials() (map[string]string, error) {
+	credentials := make(map[string]string, len(g.HTTPSTokens))
+	for n, entry := range g.HTTPSTokens {
+		host, token, ok := strings.Cut(entry, "=")
+		host, token = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(token)
+		if !ok || host == "" || token == "" {
+			return nil, fmt.Errorf("invalid git-repos-https-tokens entry #%d (expected host=token)", n+1)
+		}
+		if strings.ContainsAny(host, "/@ \t") {
+			return nil, fmt.Errorf("invalid git-repos-https-tokens entry #%d: %q is not a host name", n+1, host)
+		}
+		credentials[host] = token
+	}
+	return credentials, nil
+}
+
+// validBranchName reports whether name is a branch name git accepts, per
+// git check-ref-format. A leading '-' is refused as well, since the name
+// is passed on git command lines.
+func validBranchName(name string) bool {
+	if name == "" || name == "@" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") ||
+		strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") ||
+		strings.Contains(name, "..") || strings.Contains(name, "@{") || strings.Contains(name, "//") {
+		return false
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// ExtensionGroups parses DefaultExtensionAliases and ExtensionAliases into a
+// map from lowercase group name to the extensions it stands for, without
+// leading dots.
+func (g *GitReposSettings) ExtensionGroups() (map[string][]string, error) {
+	groups := make(map[string][]string, len(DefaultExtensionAliases)+len(g.ExtensionAliases))
+	for _, entry := range append(slices.Clone(DefaultExtensionAliases), g.ExtensionAliases...) {
+		name, list, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "."))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid git-repos-extension-aliases entry %q (expected name=ext+ext)", entry)
+		}
+		var exts []string
+		for _, ext := range strings.Split(list, "+") {
+			if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+				exts = append(exts, ext)
+			}
+		}
+		if len(exts) == 0 {
+			return nil, fmt.Errorf("invalid git-repos-extension-aliases entry %q: no extensions", entry)
+		}
+		groups[name] = exts
+	}
+	return groups, nil
+}
+
+// ReposPath returns the directory holding repository clones.
+func (g *GitReposSettings) ReposPath() string {
+	if g.ReposDir != "" {
+		return g.ReposDir
+	}
+	return filepath.Join(g.BaseDir, "repos")
+}
+
+// IndexesPath returns the directory holding the search indexes.
+func (g *GitReposSettings) IndexesPath() string {
+	if g.IndexesDir != "" {
+		return g.IndexesDir
+	}
+	return filepath.Join(g.BaseDir, "indexes")
+}
+
+// ReadRedactions compiles ReadRedactPatterns.
+func (g *GitReposSettings) ReadRedactions() ([]*regexp.Regexp, error) {
+	redactions := make([]*regexp.Regexp, 0, len(g.ReadRedactPatterns))
+	for _, pattern := range g.ReadRedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid git-repos-read-redact-patterns entry %q: %w", pattern, err)
+		}
+		redactions = append(redactions, re)
+	}
+	return redactions, nil
+}
+
+// CheckRepoURL returns an ErrURLNotAllowed error if AllowedURLs is set and
+// rawURL matches none of its patterns, or if rawURL matches a pattern of
+// DeniedURLs.
+func (g *GitReposSettings) CheckRepoURL(rawURL string) error {
+	name := RepoURLName(rawURL)
+	for _, pattern := range g.DeniedURLs {
+		if matchURLPattern(pattern, rawURL) {
+			return fmt.Errorf("%w: %s matches git-repos-denied-urls entry %q", ErrURLNotAllowed, name, pattern)
+		}
+	}
+	if len(g.AllowedURLs) == 0 {
+		return nil
+	}
+	for _, pattern := range g.AllowedURLs {
+		if matchURLPattern(pattern, rawURL) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s matches no git-repos-allowed-urls entry", ErrURLNotAllowed, name)
+}
+
+// MaxFileSizeFor returns the maximum size of the file at path, applying any
+// override for its extension. Invalid overrides are ignored; they are
+// reported by ValidateSettings.
+func (g *GitReposSettings) MaxFileSizeFor(path string) int64 {
+	overrides, _ := g.FileSizeOverrides()
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if size, ok := overrides[ext]; ok {
+		return size
+	}
+	return g.MaxFileSize
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -433,13 +435,29 @@ func TestValidateSettings_ValidTransportSSE(t *testing.T) {
 	}
 }
 
+func TestValidateSettings_ValidTransportHTTP(t *testing.T) {
+	s := &Settings{Transport: "http", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos(), UI: true}
+	if err := ValidateSettings(s); err != nil {
+		t.Errorf("Expected no error for valid http transport, got: %v", err)
+	}
+
+	// The streamable HTTP endpoint cannot bypass auth, unlike the unused /sse
+	s.Auth = AuthSettings{Type: AuthTypeBasic, Basic: BasicAuthSettings{Username: "admin", Password: "secret"}, ExcludedPaths: []string{"/sse"}}
+	if err := ValidateSettings(s); err != nil {
+		t.Errorf("Expected /sse to be excludable with the http transport, got: %v", err)
+	}
+	s.Auth.ExcludedPaths = []string{"/mcp"}
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "cannot include the MCP endpoint /mcp") {
+		t.Errorf("Expected /mcp exclusion to be refused, got: %v", err)
+	}
+}
+
 func TestValidateSettings_InvalidTransport(t *testing.T) {
 	tests := []struct {
 		name      string
 		transport string
 	}{
 		{"empty transport", ""},
-		{"http transport", "http"},
 		{"websocket transport", "websocket"},
 		{"unknown transport", "foobar"},
 	}
@@ -652,6 +670,141 @@ func TestLoadSettingsWithFlags_GitReposFlags(t *testing.T) {
 	}
 }
 
+func TestLoadSettings_GitReposReadOnly(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_READ_ONLY", "true")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+
+	if !settings.GitRepos.ReadOnly {
+		t.Error("Expected read-only mode to be enabled from env var")
+	}
+}
+
+func TestLoadSettings_GitReposWatch(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !settings.GitRepos.Watch {
+		t.Error("Expected file watching to be enabled by default")
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_WATCH", "false")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.Watch {
+		t.Error("Expected file watching to be disabled from env var")
+	}
+}
+
+func TestLoadSettings_GitReposFollowSymlinks(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.FollowSymlinks {
+		t.Error("Expected symlinks to be skipped by default")
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_FOLLOW_SYMLINKS", "true")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !settings.GitRepos.FollowSymlinks {
+		t.Error("Expected symlinks to be followed from env var")
+	}
+}
+
+func TestLoadSettings_GitReposHighlight(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !settings.GitRepos.Highlight || settings.GitRepos.HighlightPre != "**" || settings.GitRepos.HighlightPost != "**" {
+		t.Errorf("Unexpected highlight defaults: %v %q %q",
+			settings.GitRepos.Highlight, settings.GitRepos.HighlightPre, settings.GitRepos.HighlightPost)
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_HIGHLIGHT", "false")
+	t.Setenv("RELIC_MCP_GIT_REPOS_HIGHLIGHT_PRE", "<<")
+	t.Setenv("RELIC_MCP_GIT_REPOS_HIGHLIGHT_POST", ">>")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.Highlight {
+		t.Error("Expected highlighting to be disabled from env var")
+	}
+	if settings.GitRepos.HighlightPre != "<<" || settings.GitRepos.HighlightPost != ">>" {
+		t.Errorf("Unexpected highlight tags: %q %q", settings.GitRepos.HighlightPre, settings.GitRepos.HighlightPost)
+	}
+}
+
+func TestLoadSettings_GitReposSearchConcurrency(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.MaxConcurrentSearches != 8 || settings.GitRepos.SearchQueueSize != 16 {
+		t.Errorf("Unexpected search concurrency defaults: %d %d",
+			settings.GitRepos.MaxConcurrentSearches, settings.GitRepos.SearchQueueSize)
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_MAX_CONCURRENT_SEARCHES", "2")
+	t.Setenv("RELIC_MCP_GIT_REPOS_SEARCH_QUEUE_SIZE", "0")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.MaxConcurrentSearches != 2 || settings.GitRepos.SearchQueueSize != 0 {
+		t.Errorf("Unexpected search concurrency: %d %d",
+			settings.GitRepos.MaxConcurrentSearches, settings.GitRepos.SearchQueueSize)
+	}
+}
+
+func TestLoadSettings_GitReposToolLimits(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	g := settings.GitRepos
+	if g.MaxConcurrentReads != 16 || g.ReadQueueSize != 32 || g.SearchTimeout != 10*time.Second || g.ReadTimeout != 30*time.Second {
+		t.Errorf("Unexpected tool limit defaults: %d %d %v %v", g.MaxConcurrentReads, g.ReadQueueSize, g.SearchTimeout, g.ReadTimeout)
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_MAX_CONCURRENT_READS", "4")
+	t.Setenv("RELIC_MCP_GIT_REPOS_READ_QUEUE_SIZE", "8")
+	t.Setenv("RELIC_MCP_GIT_REPOS_SEARCH_TIMEOUT", "5s")
+	t.Setenv("RELIC_MCP_GIT_REPOS_READ_TIMEOUT", "0")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	g = settings.GitRepos
+	if g.MaxConcurrentReads != 4 || g.ReadQueueSize != 8 || g.SearchTimeout != 5*time.Second || g.ReadTimeout != 0 {
+		t.Errorf("Unexpected tool limits: %d %d %v %v", g.MaxConcurrentReads, g.ReadQueueSize, g.SearchTimeout, g.ReadTimeout)
+	}
+}
+
+func TestLoadSettings_GitReposSnapshotURL(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_SNAPSHOT_URL", "s3://bucket/prefix")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+
+	if settings.GitRepos.SnapshotURL != "s3://bucket/prefix" {
+		t.Errorf("Expected snapshot URL from env var, got %q", settings.GitRepos.SnapshotURL)
+	}
+}
+
 func TestLoadSettingsWithFlags_GitReposFlagsOverrideEnv(t *testing.T) {
 	t.Setenv("RELIC_MCP_GIT_REPOS_MAX_RESULTS", "100")
 
@@ -670,6 +823,98 @@ func TestLoadSettingsWithFlags_GitReposFlagsOverrideEnv(t *testing.T) {
 	}
 }
 
+func TestLoadSettingsWithFlags_QuickRepoMode(t *testing.T) {
+	t.Setenv("RELIC_MCP_TRANSPORT", "sse")
+	t.Setenv("RELIC_MCP_GIT_REPOS_URLS", "git@github.com:org/other.git")
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", "/data/relic")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("repo", "", "")
+	flags.String("transport", "", "")
+	flags.String("git-repos-base-dir", "", "")
+	_ = flags.Set("repo", "git@github.com:org/x.git")
+
+	settings, err := LoadSettingsWithFlags(flags)
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+
+	if len(settings.GitRepos.URLs) != 1 || settings.GitRepos.URLs[0] != "git@github.com:org/x.git" {
+		t.Errorf("Expected --repo to replace URLs, got %v", settings.GitRepos.URLs)
+	}
+	if settings.Transport != "stdio" {
+		t.Errorf("Expected stdio transport in quick mode, got %q", settings.Transport)
+	}
+	if want := filepath.Join("/data/relic", "quick", "github.com_org_x"); settings.GitRepos.BaseDir != want {
+		t.Errorf("Expected base dir %q, got %q", want, settings.GitRepos.BaseDir)
+	}
+}
+
+func TestLoadSettingsWithFlags_QuickRepoModeExplicitFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("repo", "", "")
+	flags.String("transport", "", "")
+	flags.String("git-repos-base-dir", "", "")
+	_ = flags.Set("repo", "git@github.com:org/x.git")
+	_ = flags.Set("transport", "sse")
+	_ = flags.Set("git-repos-base-dir", "/srv/relic")
+
+	settings, err := LoadSettingsWithFlags(flags)
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+
+	if settings.Transport != "sse" {
+		t.Errorf("Expected explicit transport to be kept, got %q", settings.Transport)
+	}
+	if settings.GitRepos.BaseDir != "/srv/relic" {
+		t.Errorf("Expected explicit base dir to be kept, got %q", settings.GitRepos.BaseDir)
+	}
+}
+
+func TestLoadSettingsWithFlags_CwdMode(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_URLS", "git@github.com:org/other.git")
+	t.Setenv("RELIC_MCP_GIT_REPOS_BASE_DIR", "/data/relic")
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("cwd", false, "")
+	flags.String("git-repos-base-dir", "", "")
+	_ = flags.Set("cwd", "true")
+
+	settings, err := LoadSettingsWithFlags(flags)
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+
+	cwd, _ := os.Getwd()
+	if settings.GitRepos.LocalDir != cwd {
+		t.Errorf("Expected local dir %q, got %q", cwd, settings.GitRepos.LocalDir)
+	}
+	if len(settings.GitRepos.URLs) != 0 {
+		t.Errorf("Expected URLs to be cleared, got %v", settings.GitRepos.URLs)
+	}
+	if !strings.HasPrefix(settings.GitRepos.BaseDir, filepath.Join("/data/relic", "quick", "cwd_")) {
+		t.Errorf("Expected per-directory base dir, got %q", settings.GitRepos.BaseDir)
+	}
+	if err := validateGitReposSettings(&settings.GitRepos); err != nil {
+		t.Errorf("Expected cwd settings to be valid, got: %v", err)
+	}
+}
+
+func TestLoadSettingsWithFlags_RepoAndCwdExclusive(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("repo", "", "")
+	flags.Bool("cwd", false, "")
+	_ = flags.Set("repo", "git@github.com:org/x.git")
+	_ = flags.Set("cwd", "true")
+
+	if _, err := LoadSettingsWithFlags(flags); err == nil {
+		t.Fatal("Expected error when combining --repo and --cwd")
+	}
+}
+
 // --- GitRepos Validation Tests ---
 
 func TestValidateSettings_GitReposNoURLs(t *testing.T) {
@@ -822,6 +1067,100 @@ func TestValidateSettings_GitReposInvalidMaxResults(t *testing.T) {
 	}
 }
 
+func TestValidateSettings_Pprof(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport string
+		adminKeys []string
+		wantErr   string
+	}{
+		{"sse with admin keys", "sse", []string{"admin-key"}, ""},
+		{"stdio", "stdio", []string{"admin-key"}, "requires transport 'sse'"},
+		{"no admin keys", "sse", nil, "requires at least one admin API key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Settings{
+				Transport: tt.transport,
+				Pprof:     true,
+				Auth:      AuthSettings{Type: AuthTypeNone, AdminAPIKeys: tt.adminKeys},
+				GitRepos:  validGitRepos(),
+			}
+			err := ValidateSettings(s)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSettings_UI(t *testing.T) {
+	for transport, wantErr := range map[string]bool{"sse": false, "stdio": true} {
+		s := &Settings{Transport: transport, UI: true, Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+		err := ValidateSettings(s)
+		if wantErr && (err == nil || !strings.Contains(err.Error(), "ui requires transport 'sse'")) {
+			t.Errorf("Expected the %s transport to be refused, got: %v", transport, err)
+		}
+		if !wantErr && err != nil {
+			t.Errorf("Unexpected error for %s: %v", transport, err)
+		}
+	}
+}
+
+func TestLoadSettings_AdminAPIKeysFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_AUTH_ADMIN_API_KEYS", "one, two")
+	t.Setenv("RELIC_MCP_PPROF", "true")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.Auth.AdminAPIKeys) != 2 || settings.Auth.AdminAPIKeys[1] != "two" {
+		t.Errorf("Unexpected admin API keys: %q", settings.Auth.AdminAPIKeys)
+	}
+	if !settings.Pprof {
+		t.Error("Expected pprof to be enabled from env var")
+	}
+}
+
+func TestValidateSettings_GitReposNegativeSearchConcurrency(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.SearchQueueSize = -1
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "cannot be negative") {
+		t.Errorf("Expected negative search queue size error, got: %v", err)
+	}
+}
+
+func TestValidateSettings_GitReposNegativeToolLimits(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.MaxConcurrentReads = -1
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-max-concurrent-reads") {
+		t.Errorf("Expected negative read concurrency error, got: %v", err)
+	}
+
+	s = &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.ReadTimeout = -time.Second
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-read-timeout") {
+		t.Errorf("Expected negative read timeout error, got: %v", err)
+	}
+}
+
+func TestValidateSettings_GitReposNegativeRepoBudget(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.MaxRepoFiles = -1
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "cannot be negative") {
+		t.Errorf("Expected negative budget error, got: %v", err)
+	}
+}
+
 func TestValidateSettings_GitReposEmptyBaseDir(t *testing.T) {
 	s := &Settings{
 		Transport: "stdio",
@@ -844,6 +1183,57 @@ func TestValidateSettings_GitReposEmptyBaseDir(t *testing.T) {
 	}
 }
 
+func TestValidateSettings_GitReposInvalidSnapshotURL(t *testing.T) {
+	s := &Settings{
+		Transport: "stdio",
+		Auth:      AuthSettings{Type: AuthTypeNone},
+		GitRepos: GitReposSettings{
+			URLs:         []string{"git@github.com:org/repo.git"},
+			BaseDir:      "/tmp/test",
+			SyncInterval: 15 * time.Minute,
+			SyncTimeout:  60 * time.Second,
+			MaxFileSize:  256 * 1024,
+			MaxResults:   20,
+			SnapshotURL:  "ftp://host/indexes",
+		},
+	}
+	err := ValidateSettings(s)
+	if err == nil {
+		t.Fatal("Expected error for unsupported snapshot URL scheme")
+	}
+	if !strings.Contains(err.Error(), "snapshot-url scheme") {
+		t.Errorf("Expected 'snapshot-url scheme' in error, got: %v", err)
+	}
+
+	s.GitRepos.SnapshotURL = "gs://bucket/indexes"
+	if err := ValidateSettings(s); err != nil {
+		t.Errorf("Expected gs:// snapshot URL to be valid, got: %v", err)
+	}
+}
+
+func TestValidateSettings_GitReposLocalDirConflicts(t *testing.T) {
+	base := GitReposSettings{
+		BaseDir:      "/tmp/test",
+		SyncInterval: 15 * time.Minute,
+		SyncTimeout:  60 * time.Second,
+		MaxFileSize:  256 * 1024,
+		MaxResults:   20,
+		LocalDir:     "/src/project",
+	}
+
+	withURLs := base
+	withURLs.URLs = []string{"git@github.com:org/repo.git"}
+	if err := validateGitReposSettings(&withURLs); err == nil {
+		t.Error("Expected error for local dir combined with URLs")
+	}
+
+	readOnly := base
+	readOnly.ReadOnly = true
+	if err := validateGitReposSettings(&readOnly); err == nil {
+		t.Error("Expected error for local dir combined with read-only mode")
+	}
+}
+
 // --- Helper Function Tests ---
 
 func TestExpandHomeDir(t *testing.T) {
@@ -900,3 +1290,1070 @@ func TestFilterEmptyStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvList(t *testing.T) {
+	const env = "RELIC_MCP_TEST_ENV_LIST"
+	tests := []struct {
+		name     string
+		list     []string
+		value    string
+		expected []string
+	}{
+		{"no env", []string{" a ", "", "b"}, "", []string{"a", "b"}},
+		{"empty list", nil, "a, b,,c ", []string{"a", "b", "c"}},
+		{"single joined entry", []string{"a, b,,c "}, "a, b,,c ", []string{"a", "b", "c"}},
+		{"list from a flag", []string{"x", "y"}, "a,b", []string{"x", "y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(env, tt.value)
+			if result := envList(tt.list, env); !slices.Equal(result, tt.expected) {
+				t.Errorf("envList(%v) with %q = %v, want %v", tt.list, tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_ClientLogLevel(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.ClientLogLevel != ClientLogLevelInfo {
+		t.Errorf("Expected default client log level %q, got %q", ClientLogLevelInfo, settings.ClientLogLevel)
+	}
+
+	t.Setenv("RELIC_MCP_CLIENT_LOG_LEVEL", "off")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.ClientLogLevel != ClientLogLevelOff {
+		t.Errorf("Expected client log level %q from env var, got %q", ClientLogLevelOff, settings.ClientLogLevel)
+	}
+}
+
+func TestValidateSettings_UnknownClientLogLevel(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.ClientLogLevel = "verbose"
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "unknown client-log-level") {
+		t.Errorf("Expected unknown client-log-level error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_IndexUpdateNotify(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.IndexUpdateNotify != IndexUpdateNotifyOff {
+		t.Errorf("Expected default index update notify %q, got %q", IndexUpdateNotifyOff, settings.IndexUpdateNotify)
+	}
+
+	t.Setenv("RELIC_MCP_INDEX_UPDATE_NOTIFY", "resources")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.IndexUpdateNotify != IndexUpdateNotifyResources {
+		t.Errorf("Expected index update notify %q from env var, got %q", IndexUpdateNotifyResources, settings.IndexUpdateNotify)
+	}
+}
+
+func TestValidateSettings_UnknownIndexUpdateNotify(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.IndexUpdateNotify = "webhook"
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "unknown index-update-notify") {
+		t.Errorf("Expected unknown index-update-notify error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_RequestIDFooter(t *testing.T) {
+	t.Setenv("RELIC_MCP_REQUEST_ID_FOOTER", "true")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !settings.RequestIDFooter {
+		t.Error("Expected request ID footer from env var")
+	}
+}
+
+func TestLoadSettings_DebugStdio(t *testing.T) {
+	t.Setenv("RELIC_MCP_DEBUG_STDIO", "/tmp/frames.log")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.DebugStdio != "/tmp/frames.log" {
+		t.Errorf("Expected debug stdio file from env var, got %q", settings.DebugStdio)
+	}
+}
+
+func TestValidateSettings_DebugStdioRequiresStdio(t *testing.T) {
+	s := &Settings{Transport: "sse", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos(), DebugStdio: "frames.log"}
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "debug-stdio requires transport 'stdio'") {
+		t.Errorf("Expected debug-stdio transport error, got: %v", err)
+	}
+
+	s.Transport = "stdio"
+	if err := ValidateSettings(s); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestGitReposSettings_FileSizeOverrides(t *testing.T) {
+	g := GitReposSettings{MaxFileSize: 100, MaxFileSizeOverrides: []string{"md=1048576", ".Proto = 2048"}}
+
+	overrides, err := g.FileSizeOverrides()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if overrides["md"] != 1048576 || overrides["proto"] != 2048 {
+		t.Errorf("Unexpected overrides: %v", overrides)
+	}
+	if got := g.MaxFileSizeFor("docs/API.PROTO"); got != 2048 {
+		t.Errorf("MaxFileSizeFor(proto) = %d, want 2048", got)
+	}
+	if got := g.MaxFileSizeFor("main.go"); got != 100 {
+		t.Errorf("MaxFileSizeFor(go) = %d, want 100", got)
+	}
+}
+
+func TestValidateSettings_InvalidFileSizeOverrides(t *testing.T) {
+	for _, entry := range []string{"md", "=100", "md=0", "md=big"} {
+		t.Run(entry, func(t *testing.T) {
+			s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+			s.GitRepos.MaxFileSizeOverrides = []string{entry}
+
+			err := ValidateSettings(s)
+			if err == nil || !strings.Contains(err.Error(), "git-repos-max-file-size-overrides") {
+				t.Errorf("Expected invalid override error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_FileSizeOverridesFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_MAX_FILE_SIZE_OVERRIDES", "md=1048576, proto=1048576")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.GitRepos.MaxFileSizeOverrides) != 2 || settings.GitRepos.MaxFileSizeOverrides[1] != "proto=1048576" {
+		t.Errorf("Unexpected overrides: %q", settings.GitRepos.MaxFileSizeOverrides)
+	}
+}
+
+func TestGitReposSettings_CloneDepths(t *testing.T) {
+	g := GitReposSettings{CloneDepth: 1, CloneDepthOverrides: []string{"github.com/org/tools=0", "git@gitlab.com:org/big.git = 50"}}
+
+	depths, err := g.CloneDepths()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if depths["github.com/org/tools"] != 0 || depths["gitlab.com/org/big"] != 50 {
+		t.Errorf("Unexpected depths: %v", depths)
+	}
+	tests := map[string]int{
+		"https://github.com/org/tools.git": 0,
+		"https://gitlab.com/org/big":       50,
+		"git@github.com:org/other.git":     1,
+	}
+	for url, want := range tests {
+		if got := g.CloneDepthFor(url); got != want {
+			t.Errorf("CloneDepthFor(%q) = %d, want %d", url, got, want)
+		}
+	}
+}
+
+func TestValidateSettings_CloneDepth(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.CloneDepth = -1
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-clone-depth") {
+		t.Errorf("Expected negative depth error, got: %v", err)
+	}
+
+	for _, entry := range []string{"github.com/org/repo", "=1", "github.com/org/repo=-1", "github.com/org/repo=all"} {
+		t.Run(entry, func(t *testing.T) {
+			s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+			s.GitRepos.CloneDepthOverrides = []string{entry}
+
+			err := ValidateSettings(s)
+			if err == nil || !strings.Contains(err.Error(), "git-repos-clone-depth-overrides") {
+				t.Errorf("Expected invalid override error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_CloneDepth(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.CloneDepth != 1 {
+		t.Errorf("Expected default clone depth 1, got %d", settings.GitRepos.CloneDepth)
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_CLONE_DEPTH", "0")
+	t.Setenv("RELIC_MCP_GIT_REPOS_CLONE_DEPTH_OVERRIDES", "github.com/org/a=10, github.com/org/b=20")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.CloneDepth != 0 {
+		t.Errorf("Expected clone depth 0, got %d", settings.GitRepos.CloneDepth)
+	}
+	if len(settings.GitRepos.CloneDepthOverrides) != 2 || settings.GitRepos.CloneDepthOverrides[1] != "github.com/org/b=20" {
+		t.Errorf("Unexpected overrides: %q", settings.GitRepos.CloneDepthOverrides)
+	}
+}
+
+func TestGitReposSettings_BranchOverrides(t *testing.T) {
+	g := GitReposSettings{Branches: []string{"github.com/org/api=trunk", "git@gitlab.com:org/web.git = release/2.x"}}
+
+	tests := map[string]string{
+		"https://github.com/org/api.git": "trunk",
+		"https://gitlab.com/org/web":     "release/2.x",
+		"git@github.com:org/other.git":   "",
+	}
+	for url, want := range tests {
+		if got := g.BranchFor(url); got != want {
+			t.Errorf("BranchFor(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestValidateSettings_InvalidBranches(t *testing.T) {
+	for _, entry := range []string{"github.com/org/repo", "=main", "github.com/org/repo=", "github.com/org/repo=-x", "github.com/org/repo=a..b", "github.com/org/repo=a b", "github.com/org/repo=x.lock", "github.com/org/repo=feature/"} {
+		t.Run(entry, func(t *testing.T) {
+			s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+			s.GitRepos.Branches = []string{entry}
+
+			err := ValidateSettings(s)
+			if err == nil || !strings.Contains(err.Error(), "git-repos-branches") {
+				t.Errorf("Expected invalid branch error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_BranchesFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_BRANCHES", "github.com/org/a=trunk, github.com/org/b=develop")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.GitRepos.Branches) != 2 || settings.GitRepos.Branches[1] != "github.com/org/b=develop" {
+		t.Errorf("Unexpected branches: %q", settings.GitRepos.Branches)
+	}
+}
+
+func TestGitReposSettings_HTTPSCredentials(t *testing.T) {
+	g := GitReposSettings{HTTPSTokens: []string{"GitHub.com=ghp_abc", "gitlab.com = oauth2:glpat=x"}}
+
+	got, err := g.HTTPSCredentials()
+	if err != nil {
+		t.Fatalf("HTTPSCredentials failed: %v", err)
+	}
+	want := map[string]string{"github.com": "ghp_abc", "gitlab.com": "oauth2:glpat=x"}
+	if !maps.Equal(got, want) {
+		t.Errorf("HTTPSCredentials() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateSettings_InvalidHTTPSTokens(t *testing.T) {
+	for _, entry := range []string{"s3cret", "=s3cret", "github.com=", "github.com/org=s3cret", "bot@github.com=s3cret"} {
+		t.Run(entry, func(t *testing.T) {
+			s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+			s.GitRepos.HTTPSTokens = []string{entry}
+
+			err := ValidateSettings(s)
+			if err == nil || !strings.Contains(err.Error(), "git-repos-https-tokens") {
+				t.Errorf("Expected invalid token error, got: %v", err)
+			}
+			if err != nil && strings.Contains(err.Error(), "s3cret") {
+				t.Errorf("Expected the token to be left out of the error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_HTTPSTokens(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_HTTPS_TOKENS", "github.com=ghp_abc, gitlab.com=oauth2:glpat-x")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !slices.Equal(settings.GitRepos.HTTPSTokens, []string{"github.com=ghp_abc", "gitlab.com=oauth2:glpat-x"}) {
+		t.Errorf("Unexpected HTTPS tokens: %q", settings.GitRepos.HTTPSTokens)
+	}
+
+	vars := make(map[string]string)
+	for _, env := range EnvVars() {
+		vars[env.Name] = env.Key
+	}
+	if vars["RELIC_MCP_GIT_HTTPS_TOKENS"] != "git_repos.https_tokens" || vars["RELIC_MCP_GIT_HTTPS_TOKENS_FILE"] != "git_repos.https_tokens" {
+		t.Errorf("Expected the HTTPS token variables to be listed, got %v", vars)
+	}
+}
+
+func TestLoadSettings_HTTPSTokensFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte("github.com=ghp_abc\ngitlab.com=glpat-x\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RELIC_MCP_GIT_HTTPS_TOKENS_FILE", path)
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !slices.Equal(settings.GitRepos.HTTPSTokens, []string{"github.com=ghp_abc", "gitlab.com=glpat-x"}) {
+		t.Errorf("Unexpected HTTPS tokens: %q", settings.GitRepos.HTTPSTokens)
+	}
+}
+
+func TestGitReposSettings_ExtensionGroups(t *testing.T) {
+	g := GitReposSettings{ExtensionAliases: []string{"web=html+.CSS+js", "JS=js+jsx"}}
+
+	groups, err := g.ExtensionGroups()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(groups["web"], ","); got != "html,css,js" {
+		t.Errorf("web = %q, want html,css,js", got)
+	}
+	if got := strings.Join(groups["js"], ","); got != "js,jsx" {
+		t.Errorf("Expected the configured js group to replace the built-in one, got %q", got)
+	}
+	if got := strings.Join(groups["c++"], ","); !strings.Contains(got, "cpp") || !strings.Contains(got, "hpp") {
+		t.Errorf("Expected the built-in c++ group, got %q", got)
+	}
+}
+
+func TestValidateSettings_InvalidExtensionAliases(t *testing.T) {
+	for _, entry := range []string{"web", "=html", "web=", "web=+"} {
+		t.Run(entry, func(t *testing.T) {
+			s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+			s.GitRepos.ExtensionAliases = []string{entry}
+
+			err := ValidateSettings(s)
+			if err == nil || !strings.Contains(err.Error(), "git-repos-extension-aliases") {
+				t.Errorf("Expected invalid alias error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_ExtensionAliasesFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_EXTENSION_ALIASES", "web=html+css, jvm=java+kt+scala")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.GitRepos.ExtensionAliases) != 2 || settings.GitRepos.ExtensionAliases[1] != "jvm=java+kt+scala" {
+		t.Errorf("Unexpected aliases: %q", settings.GitRepos.ExtensionAliases)
+	}
+}
+
+func TestValidateSettings_InvalidReadRedactPattern(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.ReadRedactPatterns = []string{`password=(\S+`}
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "git-repos-read-redact-patterns") {
+		t.Errorf("Expected invalid redact pattern error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_ReadDenyPatternsFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_READ_DENY_PATTERNS", "**/secrets/**, *.pem")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.GitRepos.ReadDenyPatterns) != 2 || settings.GitRepos.ReadDenyPatterns[1] != "*.pem" {
+		t.Errorf("Unexpected deny patterns: %q", settings.GitRepos.ReadDenyPatterns)
+	}
+}
+
+func TestGitReposSettings_DataPaths(t *testing.T) {
+	g := GitReposSettings{BaseDir: "/data"}
+	if g.ReposPath() != filepath.Join("/data", "repos") || g.IndexesPath() != filepath.Join("/data", "indexes") {
+		t.Errorf("Unexpected default paths: %q, %q", g.ReposPath(), g.IndexesPath())
+	}
+
+	g.ReposDir = "/bulk/repos"
+	g.IndexesDir = "/nvme/indexes"
+	if g.ReposPath() != "/bulk/repos" || g.IndexesPath() != "/nvme/indexes" {
+		t.Errorf("Unexpected configured paths: %q, %q", g.ReposPath(), g.IndexesPath())
+	}
+}
+
+func TestValidateSettings_GitReposSameDataDirs(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.ReposDir = "/data/shared"
+	s.GitRepos.IndexesDir = "/data/shared/"
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "must be different") {
+		t.Errorf("Expected same directory error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_DataDirsFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_REPOS_DIR", "/bulk/repos")
+	t.Setenv("RELIC_MCP_GIT_REPOS_INDEXES_DIR", "/nvme/indexes")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.ReposDir != "/bulk/repos" || settings.GitRepos.IndexesDir != "/nvme/indexes" {
+		t.Errorf("Unexpected data dirs: %q, %q", settings.GitRepos.ReposDir, settings.GitRepos.IndexesDir)
+	}
+}
+
+func TestValidateSettings_GitReposNegativeCommandLimits(t *testing.T) {
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.GitMaxOutput = -1
+
+	err := ValidateSettings(s)
+	if err == nil || !strings.Contains(err.Error(), "cannot be negative") {
+		t.Errorf("Expected negative command limit error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_GitCommandLimitDefaults(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.GitCommandTimeout != 10*time.Minute || settings.GitRepos.GitMaxOutput != 16*1024*1024 {
+		t.Errorf("Unexpected defaults: %s, %d", settings.GitRepos.GitCommandTimeout, settings.GitRepos.GitMaxOutput)
+	}
+}
+
+func TestLoadSettings_IndexBatchLimits(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_INDEX_BATCH_SIZE", "500")
+	t.Setenv("RELIC_MCP_GIT_REPOS_INDEX_BATCH_BYTES", "1048576")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.IndexBatchSize != 500 || settings.GitRepos.IndexBatchBytes != 1048576 {
+		t.Errorf("Unexpected batch limits: %d, %d", settings.GitRepos.IndexBatchSize, settings.GitRepos.IndexBatchBytes)
+	}
+
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.IndexBatchSize = -1
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-index-batch-size") {
+		t.Errorf("Expected negative batch size error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_RefsFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_REFS", "v1.0.0, release/2.0,")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if len(settings.GitRepos.Refs) != 2 || settings.GitRepos.Refs[1] != "release/2.0" {
+		t.Errorf("Unexpected refs: %v", settings.GitRepos.Refs)
+	}
+}
+
+func TestLoadSettings_PriorityFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_PRIORITY", "github.com/org/api, git@github.com:org/core.git,")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if want := []string{"github.com/org/api", "git@github.com:org/core.git"}; !slices.Equal(settings.GitRepos.Priority, want) {
+		t.Errorf("Expected priority %v, got %v", want, settings.GitRepos.Priority)
+	}
+}
+
+func TestLoadSettings_URLPatternsFromEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_URLS", "git@github.com:myorg/repo.git")
+	t.Setenv("RELIC_MCP_GIT_REPOS_ALLOWED_URLS", "git@github.com:myorg/*, github.com/shared/**,")
+	t.Setenv("RELIC_MCP_GIT_REPOS_DENIED_URLS", "github.com/myorg/secret")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if want := []string{"git@github.com:myorg/*", "github.com/shared/**"}; !slices.Equal(settings.GitRepos.AllowedURLs, want) {
+		t.Errorf("Expected allowed URLs %v, got %v", want, settings.GitRepos.AllowedURLs)
+	}
+	if want := []string{"github.com/myorg/secret"}; !slices.Equal(settings.GitRepos.DeniedURLs, want) {
+		t.Errorf("Expected denied URLs %v, got %v", want, settings.GitRepos.DeniedURLs)
+	}
+}
+
+func TestValidateSettings_GitReposURLPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		want    string
+	}{
+		{"allowed", []string{"git@github.com:org/*"}, nil, ""},
+		{"allowed host", []string{"github.com"}, nil, ""},
+		{"not allowed", []string{"git@github.com:myorg/*"}, nil, "matches no git-repos-allowed-urls entry"},
+		{"denied", nil, []string{"github.com/org/repo"}, `matches git-repos-denied-urls entry "github.com/org/repo"`},
+		{"denied wins", []string{"github.com"}, []string{"github.com/org/*"}, "git-repos-denied-urls"},
+		{"invalid pattern", []string{"github.com/[org/*"}, nil, "invalid URL pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+			s.GitRepos.AllowedURLs = tt.allowed
+			s.GitRepos.DeniedURLs = tt.denied
+			err := ValidateSettings(s)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateSettings_GitReposInvalidRefs(t *testing.T) {
+	for _, ref := range []string{"--upload-pack=evil", "v1..v2", "has space", "refs:heads"} {
+		s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+		s.GitRepos.Refs = []string{ref}
+		if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-refs") {
+			t.Errorf("Expected %q to be rejected, got: %v", ref, err)
+		}
+	}
+}
+
+func TestLoadSettings_SyncFailureThreshold(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_SYNC_FAILURE_THRESHOLD", "3")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.SyncFailureThreshold != 3 {
+		t.Errorf("Expected threshold 3, got %d", settings.GitRepos.SyncFailureThreshold)
+	}
+
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.SyncFailureThreshold = -1
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-sync-failure-threshold") {
+		t.Errorf("Expected negative threshold error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_StartupChecks(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !settings.GitRepos.StartupChecks {
+		t.Error("Expected startup checks to be enabled by default")
+	}
+
+	t.Setenv("RELIC_MCP_GIT_REPOS_STARTUP_CHECKS", "false")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.StartupChecks {
+		t.Error("Expected startup checks to be disabled")
+	}
+}
+
+func TestLoadSettings_RemovedRetention(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.RemovedRetention != 24*time.Hour {
+		t.Errorf("Expected a 24h default, got %s", settings.GitRepos.RemovedRetention)
+	}
+
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.RemovedRetention = -time.Hour
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-removed-retention") {
+		t.Errorf("Expected negative retention error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_HistoryCommits(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_HISTORY_COMMITS", "50")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.HistoryCommits != 50 {
+		t.Errorf("Expected 50 history commits, got %d", settings.GitRepos.HistoryCommits)
+	}
+
+	for _, commits := range []int{-1, MaxHistoryCommits + 1} {
+		s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+		s.GitRepos.HistoryCommits = commits
+		if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-history-commits") {
+			t.Errorf("Expected an error for %d history commits, got: %v", commits, err)
+		}
+	}
+}
+
+func TestLoadSettings_GitSandbox(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_GIT_SANDBOX", "true")
+	t.Setenv("RELIC_MCP_GIT_REPOS_GIT_MAX_MEMORY", "1073741824")
+	t.Setenv("RELIC_MCP_GIT_REPOS_GIT_MAX_CPU", "5m")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	g := settings.GitRepos
+	if !g.GitSandbox || g.GitMaxMemory != 1<<30 || g.GitMaxCPU != 5*time.Minute {
+		t.Errorf("Unexpected sandbox settings: %v, %d, %s", g.GitSandbox, g.GitMaxMemory, g.GitMaxCPU)
+	}
+
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.GitMaxMemory = -1
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "git-repos-git-max-memory") {
+		t.Errorf("Expected an error for a negative memory limit, got: %v", err)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_MAX_RESULTS", "50")
+
+	vars := make(map[string]EnvVar)
+	for _, env := range EnvVars() {
+		vars[env.Name] = env
+	}
+
+	// Defaults are not masked by the environment
+	if env := vars["RELIC_MCP_GIT_REPOS_MAX_RESULTS"]; env.Key != "git_repos.max_results" || env.Default != "20" {
+		t.Errorf("Unexpected max results variable: %+v", env)
+	}
+	if env, ok := vars["RELIC_MCP_GIT_REPOS_URLS"]; !ok || env.Default != "" {
+		t.Errorf("Expected URLs without a default, got %+v", env)
+	}
+	if env := vars["RELIC_MCP_GIT_REPOS_REMOVED_RETENTION"]; env.Default != "24h0m0s" {
+		t.Errorf("Expected a duration default, got %+v", env)
+	}
+}
+
+func TestSettingsEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("git_repos:\n  refs: [v1, v2]\n  max_results: 20\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "keys"), []byte("k1\n"), 0600); err != nil {
+		t.Fatalf("Failed to write keys: %v", err)
+	}
+	t.Setenv("RELIC_MCP_GIT_REPOS_SYNC_INTERVAL", "5m")
+	t.Setenv("RELIC_MCP_AUTH_API_KEYS_FILE", "keys")
+
+	env, err := SettingsEnv(nil)
+	if err != nil {
+		t.Fatalf("SettingsEnv failed: %v", err)
+	}
+	want := map[string]string{
+		"RELIC_MCP_GIT_REPOS_REFS":          "v1,v2",
+		"RELIC_MCP_GIT_REPOS_SYNC_INTERVAL": "5m",
+		"RELIC_MCP_AUTH_API_KEYS_FILE":      filepath.Join(dir, "keys"),
+	}
+	if len(env) != len(want) {
+		t.Errorf("Expected only the settings that differ from their defaults, got %v", env)
+	}
+	for name, value := range want {
+		if env[name] != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, env[name])
+		}
+	}
+}
+
+func TestLoadSettings_Profile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	files := map[string]string{
+		"config.yaml":     "git_repos:\n  max_results: 30\n  sync_interval: 5m\n",
+		".env":            "RELIC_MCP_PORT=7000\nRELIC_MCP_GIT_REPOS_URLS=git@github.com:org/base.git\n",
+		"config.dev.yaml": "git_repos:\n  max_results: 40\n",
+		".env.dev":        "RELIC_MCP_GIT_REPOS_URLS=git@github.com:org/dev.git,git@github.com:org/tools.git\nhost=127.0.0.3\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.Port != 7000 || settings.GitRepos.MaxResults != 30 || settings.GitRepos.SyncInterval != 5*time.Minute {
+		t.Errorf("Expected the base files to apply, got port %d, max results %d, sync interval %s", settings.Port, settings.GitRepos.MaxResults, settings.GitRepos.SyncInterval)
+	}
+	if len(settings.GitRepos.URLs) != 1 || settings.GitRepos.URLs[0] != "git@github.com:org/base.git" {
+		t.Errorf("Expected the base URL, got %v", settings.GitRepos.URLs)
+	}
+
+	// Profile files override the base files, the environment overrides both
+	t.Setenv("RELIC_MCP_PROFILE", "dev")
+	t.Setenv("RELIC_MCP_PORT", "9000")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.Profile != "dev" {
+		t.Errorf("Expected profile dev, got %q", settings.Profile)
+	}
+	if settings.GitRepos.MaxResults != 40 || settings.Host != "127.0.0.3" || settings.Port != 9000 {
+		t.Errorf("Expected the dev profile to apply, got max results %d, host %s, port %d", settings.GitRepos.MaxResults, settings.Host, settings.Port)
+	}
+	if len(settings.GitRepos.URLs) != 2 || settings.GitRepos.URLs[0] != "git@github.com:org/dev.git" {
+		t.Errorf("Expected the dev URLs, got %v", settings.GitRepos.URLs)
+	}
+
+	// A flag overrides the environment
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("profile", "", "")
+	_ = flags.Set("profile", "prod")
+	if _, err := LoadSettingsWithFlags(flags); err == nil || !strings.Contains(err.Error(), `profile "prod"`) {
+		t.Errorf("Expected an error for a profile without files, got: %v", err)
+	}
+
+	t.Setenv("RELIC_MCP_PROFILE", "../dev")
+	if _, err := LoadSettings(); err == nil || !strings.Contains(err.Error(), "invalid profile") {
+		t.Errorf("Expected an invalid profile error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	keysFile := filepath.Join(dir, "api_keys")
+	_ = os.WriteFile(passwordFile, []byte("s3cret\n"), 0600)
+	_ = os.WriteFile(keysFile, []byte("key1\nkey2, key3\n"), 0600)
+
+	t.Setenv("RELIC_MCP_AUTH_BASIC_PASSWORD_FILE", passwordFile)
+	t.Setenv("RELIC_MCP_AUTH_API_KEYS_FILE", keysFile)
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.Auth.Basic.Password != "s3cret" {
+		t.Errorf("Expected the password from the file, got %q", settings.Auth.Basic.Password)
+	}
+	if want := []string{"key1", "key2", "key3"}; !slices.Equal(settings.Auth.APIKeys, want) {
+		t.Errorf("Expected API keys %v, got %v", want, settings.Auth.APIKeys)
+	}
+
+	// A flag takes priority over the file
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("auth-basic-password", "", "")
+	_ = flags.Set("auth-basic-password", "from-flag")
+	settings, err = LoadSettingsWithFlags(flags)
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.Auth.Basic.Password != "from-flag" {
+		t.Errorf("Expected the flag to take priority, got %q", settings.Auth.Basic.Password)
+	}
+
+	t.Setenv("RELIC_MCP_AUTH_BASIC_PASSWORD", "from-env")
+	if _, err := LoadSettings(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Expected an error for both variables, got: %v", err)
+	}
+
+	t.Setenv("RELIC_MCP_AUTH_BASIC_PASSWORD", "")
+	t.Setenv("RELIC_MCP_AUTH_BASIC_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	if _, err := LoadSettings(); err == nil || !strings.Contains(err.Error(), "RELIC_MCP_AUTH_BASIC_PASSWORD_FILE") {
+		t.Errorf("Expected a read error naming the variable, got: %v", err)
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		entry   string
+		key     string
+		scopes  []string
+		profile string
+		wantErr bool
+	}{
+		{"abc123", "abc123", nil, "", false},
+		{"abc123:search", "abc123", []string{"search"}, "", false},
+		{"abc123:search+read+admin", "abc123", []string{"search", "read", "admin"}, "", false},
+		{"abc123@docs-first", "abc123", nil, "docs-first", false},
+		{"abc123:search@recent-first", "abc123", []string{"search"}, "recent-first", false},
+		{"abc123:serch", "", nil, "", true},
+		{"abc123:", "", nil, "", true},
+		{":search", "", nil, "", true},
+		{"abc123@nope", "", nil, "", true},
+		{"@docs-first", "", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		key, scopes, profile, err := ParseAPIKey(tt.entry)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAPIKey(%q) error = %v, wantErr %v", tt.entry, err, tt.wantErr)
+			continue
+		}
+		if key != tt.key || !slices.Equal(scopes, tt.scopes) || profile != tt.profile {
+			t.Errorf("ParseAPIKey(%q) = %q, %v, %q, want %q, %v, %q", tt.entry, key, scopes, profile, tt.key, tt.scopes, tt.profile)
+		}
+	}
+}
+
+func TestValidateSettings_RankingProfile(t *testing.T) {
+	for _, profile := range append([]string{""}, RankingProfiles...) {
+		s := &Settings{Transport: "stdio", GitRepos: validGitRepos()}
+		s.GitRepos.RankingProfile = profile
+		if err := ValidateSettings(s); err != nil {
+			t.Errorf("Expected ranking profile %q to be valid, got: %v", profile, err)
+		}
+	}
+
+	s := &Settings{Transport: "stdio", GitRepos: validGitRepos()}
+	s.GitRepos.RankingProfile = "newest"
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "unknown git-repos-ranking-profile") {
+		t.Errorf("Expected an unknown ranking profile error, got: %v", err)
+	}
+}
+
+func TestLoadSettings_RankingProfile(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_RANKING_PROFILE", "docs-first")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	if settings.GitRepos.RankingProfile != RankingProfileDocs {
+		t.Errorf("Expected ranking profile docs-first, got %q", settings.GitRepos.RankingProfile)
+	}
+}
+
+func TestLoadSettings_IndexDeclarations(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_INDEX_DECLARATIONS", "true")
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	if !settings.GitRepos.IndexDeclarations {
+		t.Error("Expected declarations to be indexed")
+	}
+}
+
+func TestValidateSettings_APIKeyScopes(t *testing.T) {
+	s := &Settings{Transport: "sse", Auth: AuthSettings{Type: AuthTypeAPIKey, APIKeys: []string{"key:write"}}, GitRepos: validGitRepos()}
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "unknown API key scope") {
+		t.Errorf("Expected an unknown scope error, got: %v", err)
+	}
+
+	// An admin scope is enough for the profiling endpoints
+	s.Auth.APIKeys = []string{"key:search+admin"}
+	s.Pprof = true
+	if err := ValidateSettings(s); err != nil {
+		t.Errorf("Expected a scoped admin key to enable pprof, got: %v", err)
+	}
+}
+
+func TestValidateSettings_MTLS(t *testing.T) {
+	tlsSettings := TLSSettings{CertFile: "server.pem", KeyFile: "server.key", ClientCAFile: "ca.pem"}
+	tests := []struct {
+		name    string
+		mutate  func(s *Settings)
+		wantErr string
+	}{
+		{"valid", func(s *Settings) {}, ""},
+		{"stdio", func(s *Settings) { s.Transport = "stdio" }, "requires transport 'sse'"},
+		{"no client CA", func(s *Settings) { s.TLS.ClientCAFile = "" }, "requires tls-cert-file"},
+		{"no TLS", func(s *Settings) { s.TLS = TLSSettings{} }, "requires tls-cert-file"},
+		{"with API keys", func(s *Settings) { s.Auth.APIKeys = []string{"key"} }, "incompatible"},
+		{"client CA without mtls", func(s *Settings) { s.Auth.Type = AuthTypeNone }, "tls-client-ca-file requires auth-type 'mtls'"},
+		{"identities without mtls", func(s *Settings) {
+			s.Auth.Type = AuthTypeNone
+			s.TLS.ClientCAFile = ""
+		}, "auth-mtls-identities requires auth-type 'mtls'"},
+		{"key without certificate", func(s *Settings) {
+			s.Auth = AuthSettings{Type: AuthTypeNone}
+			s.TLS = TLSSettings{KeyFile: "server.key"}
+		}, "must be set together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Settings{
+				Transport: "sse",
+				Auth:      AuthSettings{Type: AuthTypeMTLS, MTLSIdentities: []string{"agent-1"}},
+				TLS:       tlsSettings,
+				GitRepos:  validGitRepos(),
+			}
+			tt.mutate(s)
+			err := ValidateSettings(s)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings_MTLSIdentitiesEnv(t *testing.T) {
+	t.Setenv("RELIC_MCP_AUTH_MTLS_IDENTITIES", "agent-1, ci.example.com")
+	t.Setenv("RELIC_MCP_TLS_CLIENT_CA_FILE", "/etc/relic/ca.pem")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if want := []string{"agent-1", "ci.example.com"}; !slices.Equal(settings.Auth.MTLSIdentities, want) {
+		t.Errorf("Expected identities %v, got %v", want, settings.Auth.MTLSIdentities)
+	}
+	if settings.TLS.ClientCAFile != "/etc/relic/ca.pem" {
+		t.Errorf("Expected the client CA file, got %q", settings.TLS.ClientCAFile)
+	}
+}
+
+func TestAuthSettings_IsExcludedPath(t *testing.T) {
+	tests := []struct {
+		excluded []string
+		path     string
+		expected bool
+	}{
+		{nil, "/health", true},
+		{nil, "/healthz", false},
+		{[]string{"/readyz", "/metrics"}, "/metrics", true},
+		{[]string{"/readyz", "/metrics"}, "/health", false},
+		{[]string{"/probes/*"}, "/probes/live", true},
+		{[]string{"/probes/*"}, "/probes", false},
+		{[]string{"/probes*"}, "/probes", true},
+	}
+
+	for _, tt := range tests {
+		settings := AuthSettings{ExcludedPaths: tt.excluded}
+		if got := settings.IsExcludedPath(tt.path); got != tt.expected {
+			t.Errorf("IsExcludedPath(%q) with %v = %v, want %v", tt.path, tt.excluded, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateSettings_AuthExcludedPaths(t *testing.T) {
+	tests := []struct {
+		excluded []string
+		wantErr  string
+	}{
+		{[]string{"/health", "/readyz", "/probes/*"}, ""},
+		{[]string{"health"}, "must start with /"},
+		{[]string{"/probes/*/live"}, "can only end with *"},
+		{[]string{"/health?full=1"}, "plain URL path"},
+		{[]string{"/sse"}, "cannot include the MCP endpoint"},
+		{[]string{"/*"}, "cannot include the MCP endpoint"},
+		{[]string{"/ui/*"}, "cannot include the web UI endpoints"},
+		{[]string{"/ui/api/search"}, "cannot include the web UI endpoints"},
+		{[]string{"/debug/*"}, "cannot include the profiling endpoints"},
+		{[]string{"/debug/vars"}, "cannot include the profiling endpoints"},
+		{[]string{"/ui", "/debug"}, ""},
+	}
+
+	for _, tt := range tests {
+		s := &Settings{
+			Transport: "sse",
+			UI:        true,
+			Pprof:     true,
+			Auth: AuthSettings{
+				Type:          AuthTypeBasic,
+				Basic:         BasicAuthSettings{Username: "admin", Password: "secret"},
+				AdminAPIKeys:  []string{"admin-key"},
+				ExcludedPaths: tt.excluded,
+			},
+			GitRepos: validGitRepos(),
+		}
+		err := ValidateSettings(s)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", tt.excluded, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%v: expected error containing %q, got: %v", tt.excluded, tt.wantErr, err)
+		}
+	}
+}
+
+func TestValidateSettings_AuthExcludedPathsWithoutUIOrPprof(t *testing.T) {
+	s := &Settings{
+		Transport: "sse",
+		Auth: AuthSettings{
+			Type:          AuthTypeBasic,
+			Basic:         BasicAuthSettings{Username: "admin", Password: "secret"},
+			ExcludedPaths: []string{"/ui/*", "/debug/*"},
+		},
+		GitRepos: validGitRepos(),
+	}
+	if err := ValidateSettings(s); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLoadSettings_AuthExcludedPaths(t *testing.T) {
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !slices.Equal(settings.Auth.ExcludedPaths, DefaultAuthExcludedPaths) {
+		t.Errorf("Expected default excluded paths %v, got %v", DefaultAuthExcludedPaths, settings.Auth.ExcludedPaths)
+	}
+
+	t.Setenv("RELIC_MCP_AUTH_EXCLUDED_PATHS", "/health, /readyz,/probes/*")
+	settings, err = LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if want := []string{"/health", "/readyz", "/probes/*"}; !slices.Equal(settings.Auth.ExcludedPaths, want) {
+		t.Errorf("Expected excluded paths %v, got %v", want, settings.Auth.ExcludedPaths)
+	}
+}
+
+func TestLoadSettings_QueryExperiment(t *testing.T) {
+	t.Setenv("RELIC_MCP_GIT_REPOS_QUERY_EXPERIMENT", QueryStrategySymbols)
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.GitRepos.QueryExperiment != QueryStrategySymbols {
+		t.Errorf("Expected the symbols strategy, got %q", settings.GitRepos.QueryExperiment)
+	}
+
+	s := &Settings{Transport: "stdio", Auth: AuthSettings{Type: AuthTypeNone}, GitRepos: validGitRepos()}
+	s.GitRepos.QueryExperiment = "bm25"
+	if err := ValidateSettings(s); err == nil || !strings.Contains(err.Error(), "unknown git-repos-query-experiment") {
+		t.Errorf("Expected an unknown strategy error, got: %v", err)
+	}
+}
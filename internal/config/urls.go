@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// scpURLPattern matches SCP-style repository URLs, e.g. git@github.com:org/repo.git
+var scpURLPattern = regexp.MustCompile(`^([\w.-]+@)?([\w.-]+):([^/].*)$`)
+
+// NormalizeRepoURL returns the canonical form of a repository URL: host names
+// are lower case, ssh:// URLs without a port are written in SCP style
+// (git@host:path), and trailing slashes are removed. A trailing .git is kept
+// as configured, since some servers require it.
+func NormalizeRepoURL(raw string) string {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+
+	if m := scpURLPattern.FindStringSubmatch(raw); m != nil && !strings.Contains(raw, "://") {
+		return m[1] + strings.ToLower(m[2]) + ":" + m[3]
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if u.Scheme == "ssh" && u.Port() == "" && u.User != nil {
+		return u.User.String() + "@" + u.Host + ":" + strings.TrimPrefix(u.Path, "/")
+	}
+	return u.String()
+}
+
+// repoURLKey identifies the repository of a normalized URL, ignoring the
+// trailing .git and any credentials, so that URLs of the same repository
+// share a key.
+func repoURLKey(normalized string) string {
+	key := strings.TrimSuffix(normalized, ".git")
+	if u, err := url.Parse(key); err == nil && u.Host != "" && u.Scheme != "ssh" {
+		u.User = nil
+		key = u.String()
+	}
+	return key
+}
+
+// dedupeRepoURLs normalizes urls and drops the ones naming a repository
+// already listed, keeping the first. SSH and HTTPS URLs of a repository name
+// the same repository, as they share its ID.
+func dedupeRepoURLs(urls []string) []string {
+	var result []string
+	seen := make(map[string]bool, len(urls))
+	for _, raw := range urls {
+		normalized := NormalizeRepoURL(raw)
+		key := RepoURLName(normalized)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, normalized)
+	}
+	return result
+}
+
+// RepoURLName returns the host and path of a repository URL without scheme,
+// user, port or trailing .git, e.g. github.com/org/repo for both
+// git@github.com:org/repo.git and https://github.com/org/repo.
+func RepoURLName(raw string) string {
+	normalized := NormalizeRepoURL(raw)
+	if m := scpURLPattern.FindStringSubmatch(normalized); m != nil && !strings.Contains(normalized, "://") {
+		return m[2] + "/" + strings.TrimSuffix(m[3], ".git")
+	}
+	u, err := url.Parse(normalized)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(normalized, ".git")
+	}
+	return u.Hostname() + "/" + strings.TrimSuffix(strings.TrimPrefix(u.Path, "/"), ".git")
+}
+
+// matchURLPattern reports whether a repository URL matches an allowed or
+// denied URL pattern. A pattern without '/' or ':' is a host name and matches
+// every repository on that host. Other patterns are globs, where '*' does not
+// cross a '/' and a trailing "/**" matches any depth, compared both with the
+// URL, without credentials and with or without .git, and with its name, e.g.
+// git@github.com:myorg/* or github.com/myorg/**.
+func matchURLPattern(pattern, raw string) bool {
+	name := RepoURLName(raw)
+	if !strings.ContainsAny(pattern, "/:") {
+		host, _, _ := strings.Cut(name, "/")
+		return strings.EqualFold(pattern, host)
+	}
+
+	normalized := repoURLKey(NormalizeRepoURL(raw))
+	for _, candidate := range []string{normalized, normalized + ".git", name} {
+		if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+			if strings.HasPrefix(candidate, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validURLPattern returns an error if pattern is not a valid URL pattern.
+func validURLPattern(pattern string) error {
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+		return fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+	}
+	return nil
+}
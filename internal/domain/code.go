@@ -24,8 +24,100 @@ type CodeDocument struct {
 
 	// Symbols is a list of extracted code symbols (functions, classes, etc.) for boosting search results.
 	Symbols []string `json:"symbols"`
+
+	// Keys is the list of flattened key paths of a YAML or JSON file, e.g.
+	// "server.timeout", for precise lookups of configuration settings.
+	Keys []string `json:"keys,omitempty"`
+
+	// Generated reports whether the file header marks it as generated code,
+	// e.g. "Code generated by protoc-gen-go. DO NOT EDIT."
+	Generated bool `json:"generated,omitempty"`
+
+	// Declared reports whether the file is also indexed as
+	// DeclarationDocuments, which are searched in its place.
+	Declared bool `json:"declared,omitempty"`
+}
+
+// DeclarationDocument is a top-level declaration of an indexed source file,
+// such as a function or type, or the lines ahead of the first declaration.
+// It is stored in the same index as the CodeDocuments, marked by Kind.
+type DeclarationDocument struct {
+	// ID combines the file ID and the line range of the declaration.
+	// Format: "github.com_org_repo/path/to/file.go#L12-40"
+	ID string `json:"id"`
+
+	// File is the ID of the CodeDocument of the file.
+	File string `json:"file"`
+
+	// Repository, FilePath, Extension and Generated are those of the file.
+	Repository string `json:"repository"`
+	FilePath   string `json:"file_path"`
+	Extension  string `json:"extension"`
+	Generated  bool   `json:"generated,omitempty"`
+
+	// Kind is always DocumentKindDeclaration.
+	Kind string `json:"kind"`
+
+	// Declaration is the declared name, e.g. "NewServer"; empty for the
+	// lines ahead of the first declaration.
+	Declaration string `json:"declaration,omitempty"`
+
+	// StartLine and EndLine are the 1-based, inclusive line range of the
+	// declaration in the file, including its doc comment.
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+
+	// Content holds the lines of the declaration.
+	Content string `json:"content"`
+
+	// Symbols are the symbols declared within the lines.
+	Symbols []string `json:"symbols"`
 }
 
+// DirectoryDocument summarizes a directory of an indexed repository, so that
+// questions about directories can be answered without visiting their files.
+// It is stored in the same index as the CodeDocuments, marked by Kind.
+type DirectoryDocument struct {
+	// ID combines repo ID and directory path with a trailing slash, which no
+	// file ID has.
+	// Format: "github.com_org_repo/src/main/"
+	ID string `json:"id"`
+
+	// Repository is the human-readable repository identifier.
+	Repository string `json:"repository"`
+
+	// FilePath is the directory path relative to the repository root.
+	// Example: "src/main"
+	FilePath string `json:"file_path"`
+
+	// Kind is always DocumentKindDirectory.
+	Kind string `json:"kind"`
+
+	// Content is the directory path followed by the names of its entries,
+	// one per line and subdirectories with a trailing slash, for search.
+	Content string `json:"content"`
+
+	// Symbols are the entry names without file extensions, so that words of
+	// names like "kafka_consumer.go" match as they do for code symbols.
+	Symbols []string `json:"symbols"`
+
+	// FileCount is the number of indexed files in the directory and its
+	// subdirectories.
+	FileCount int `json:"file_count"`
+
+	// SubdirCount is the number of direct subdirectories with indexed files.
+	SubdirCount int `json:"subdir_count"`
+
+	// Languages are the languages of the indexed files, most common first.
+	Languages []string `json:"languages"`
+}
+
+// Kinds of the documents stored alongside CodeDocuments, which have no kind.
+const (
+	DocumentKindDirectory   = "directory"   // DirectoryDocuments
+	DocumentKindDeclaration = "declaration" // DeclarationDocuments
+)
+
 // Bleve field name constants for consistent field references in queries and mappings.
 const (
 	CodeFieldID         = "id"
@@ -34,4 +126,28 @@ const (
 	CodeFieldExtension  = "extension"
 	CodeFieldContent    = "content"
 	CodeFieldSymbols    = "symbols"
+	CodeFieldKeys       = "keys"
+	CodeFieldGenerated  = "generated"
+	CodeFieldDeclared   = "declared"
+
+	// Fields of DirectoryDocuments
+	CodeFieldKind        = "kind"
+	CodeFieldFileCount   = "file_count"
+	CodeFieldSubdirCount = "subdir_count"
+	CodeFieldLanguages   = "languages"
+
+	// Fields of DeclarationDocuments
+	CodeFieldFile        = "file"
+	CodeFieldDeclaration = "declaration"
+	CodeFieldStartLine   = "start_line"
+	CodeFieldEndLine     = "end_line"
+
+	// CodeFieldContentExact indexes Content with its original letter case
+	// for case-sensitive search. It is derived from Content, not stored.
+	CodeFieldContentExact = "content_exact"
+
+	// CodeFieldSymbolParts indexes the words of each symbol name, e.g.
+	// http, server and config for HTTPServerConfig. It is derived from
+	// Symbols, not stored.
+	CodeFieldSymbolParts = "symbol_parts"
 )
@@ -0,0 +1,194 @@
+package gitrepos
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxArchiveEntries caps the number of members listed for an archive
+const maxArchiveEntries = 1000
+
+// ErrDecompressedTooLarge is returned when a compressed file expands beyond
+// the read size limit.
+var ErrDecompressedTooLarge = errors.New("decompressed content is too large")
+
+// archiveKind identifies how the read tool presents a compressed file.
+type archiveKind int
+
+const (
+	archiveNone archiveKind = iota
+	archiveGzip             // single compressed file, e.g. fixture.sql.gz
+	archiveZip
+	archiveTar
+	archiveTarGzip
+)
+
+// detectArchive classifies path by its file name.
+func detectArchive(path string) archiveKind {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGzip
+	case strings.HasSuffix(lower, ".gz"):
+		return archiveGzip
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	default:
+		return archiveNone
+	}
+}
+
+// archiveEntry is one member of an archive.
+type archiveEntry struct {
+	Name string
+	Size int64
+	Dir  bool
+}
+
+// readGzip decompresses a single-file gzip archive, failing with
+// ErrDecompressedTooLarge if the content exceeds limit bytes.
+func readGzip(ctx context.Context, path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	zr, err := gzip.NewReader(&contextReader{ctx: ctx, r: f})
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip file: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	content, err := io.ReadAll(io.LimitReader(zr, limit+1))
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip file: %w", err)
+	}
+	if int64(len(content)) > limit {
+		return nil, ErrDecompressedTooLarge
+	}
+	return content, nil
+}
+
+// listArchive lists the members of a zip or (gzipped) tar archive, up to
+// maxArchiveEntries. truncated reports whether more members were present.
+func listArchive(ctx context.Context, path string, kind archiveKind) (entries []archiveEntry, truncated bool, err error) {
+	if kind == archiveZip {
+		return listZip(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = &contextReader{ctx: ctx, r: f}
+	if kind == archiveTarGzip {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid gzip file: %w", err)
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, false, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, false, ctxErr
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if len(entries) == maxArchiveEntries {
+			return entries, true, nil
+		}
+		entries = append(entries, archiveEntry{Name: hdr.Name, Size: hdr.Size, Dir: hdr.Typeflag == tar.TypeDir})
+	}
+}
+
+func listZip(path string) ([]archiveEntry, bool, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid zip archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	var entries []archiveEntry
+	for _, f := range zr.File {
+		if len(entries) == maxArchiveEntries {
+			return entries, true, nil
+		}
+		entries = append(entries, archiveEntry{Name: f.Name, Size: int64(f.UncompressedSize64), Dir: f.FileInfo().IsDir()})
+	}
+	return entries, false, nil
+}
+
+// contextReader fails reads once ctx is done, so that reading a large file
+// stops when the call it serves is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// readFile reads a file like os.ReadFile, stopping when ctx is done.
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(&contextReader{ctx: ctx, r: f})
+}
+
+// readFileHead reads up to limit bytes from the start of the file at path,
+// so that large files are never loaded whole.
+func readFileHead(ctx context.Context, path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(io.LimitReader(&contextReader{ctx: ctx, r: f}, limit))
+}
+
+// formatArchiveListing renders archive members as a markdown list.
+func formatArchiveListing(entries []archiveEntry, truncated bool, format FormatOptions) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Archive with %d members:\n\n", len(entries)))
+	for _, e := range entries {
+		if e.Dir {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", e.Name))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", e.Name, format.size(e.Size, 2)))
+	}
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n_Listing truncated to the first %d members_\n", maxArchiveEntries))
+	}
+	return sb.String()
+}
@@ -0,0 +1,217 @@
+package gitrepos
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// CatalogFilename is the name of the file catalog database in the base
+// directory.
+const CatalogFilename = "catalog.db"
+
+const catalogSchema = `
+CREATE TABLE IF NOT EXISTS files (
+	repo_id     TEXT NOT NULL,
+	path        TEXT NOT NULL,
+	size        INTEGER NOT NULL,
+	hash        TEXT NOT NULL,
+	language    TEXT NOT NULL,
+	last_commit TEXT NOT NULL,
+	PRIMARY KEY (repo_id, path)
+) WITHOUT ROWID;`
+
+// CatalogFile describes an indexed file.
+type CatalogFile struct {
+	Path     string
+	Size     int64
+	Hash     string // git blob hash of the indexed content
+	Language string
+	Commit   string // commit at which the file was last indexed
+}
+
+// CatalogChanges are the catalog updates recorded by one indexing run.
+type CatalogChanges struct {
+	Full    bool // replaces every file of the repository
+	Files   []CatalogFile
+	Deleted []string
+}
+
+// CatalogSummary aggregates the catalog entries of a repository.
+type CatalogSummary struct {
+	Files     int
+	Bytes     int64
+	Languages map[string]int // file count by language
+}
+
+// Catalog is a SQLite database of indexed files, kept next to the Bleve
+// indexes so that listing and stat operations need neither the index nor the
+// repository checkout.
+type Catalog struct {
+	db *sql.DB
+}
+
+// OpenCatalog opens the catalog at path. A read-only catalog must already
+// exist and is never written, so it can be shared with the process that
+// maintains it.
+func OpenCatalog(path string, readOnly bool) (*Catalog, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)"
+	if readOnly {
+		dsn += "&mode=ro"
+	} else {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog: %w", err)
+	}
+	if readOnly {
+		err = db.Ping()
+	} else {
+		_, err = db.Exec(catalogSchema)
+	}
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open catalog: %w", err)
+	}
+	return &Catalog{db: db}, nil
+}
+
+// Apply records the changes of an indexing run of a repository at commit.
+func (c *Catalog) Apply(repoID, commit string, changes *CatalogChanges) (err error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if changes.Full {
+		if _, err = tx.Exec(`DELETE FROM files WHERE repo_id = ?`, repoID); err != nil {
+			return err
+		}
+	}
+	for _, path := range changes.Deleted {
+		if _, err = tx.Exec(`DELETE FROM files WHERE repo_id = ? AND path = ?`, repoID, path); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO files (repo_id, path, size, hash, language, last_commit) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, file := range changes.Files {
+		if _, err = stmt.Exec(repoID, file.Path, file.Size, file.Hash, file.Language, commit); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRepo removes every file of a repository.
+func (c *Catalog) DeleteRepo(repoID string) error {
+	_, err := c.db.Exec(`DELETE FROM files WHERE repo_id = ?`, repoID)
+	return err
+}
+
+// ListFiles returns the files of a repository under prefix, ordered by path.
+// A limit of 0 returns all of them.
+func (c *Catalog) ListFiles(repoID, prefix string, limit int) ([]CatalogFile, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := c.db.Query(
+		`SELECT path, size, hash, language, last_commit FROM files
+		 WHERE repo_id = ? AND instr(path, ?) = 1
+		 ORDER BY path LIMIT ?`,
+		repoID, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var files []CatalogFile
+	for rows.Next() {
+		var file CatalogFile
+		if err := rows.Scan(&file.Path, &file.Size, &file.Hash, &file.Language, &file.Commit); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// Stat returns a single file of a repository, or nil if it is not cataloged.
+func (c *Catalog) Stat(repoID, path string) (*CatalogFile, error) {
+	file := CatalogFile{Path: path}
+	err := c.db.QueryRow(
+		`SELECT size, hash, language, last_commit FROM files WHERE repo_id = ? AND path = ?`,
+		repoID, path).Scan(&file.Size, &file.Hash, &file.Language, &file.Commit)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Summary aggregates the files of a repository.
+func (c *Catalog) Summary(repoID string) (*CatalogSummary, error) {
+	rows, err := c.db.Query(
+		`SELECT language, COUNT(*), SUM(size) FROM files WHERE repo_id = ? GROUP BY language`,
+		repoID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	summary := &CatalogSummary{Languages: make(map[string]int)}
+	for rows.Next() {
+		var language string
+		var count int
+		var bytes int64
+		if err := rows.Scan(&language, &count, &bytes); err != nil {
+			return nil, err
+		}
+		summary.Files += count
+		summary.Bytes += bytes
+		summary.Languages[language] = count
+	}
+	return summary, rows.Err()
+}
+
+// Close closes the catalog database.
+func (c *Catalog) Close() error {
+	return c.db.Close()
+}
+
+// newCatalogFile describes indexed content at relPath.
+func newCatalogFile(relPath string, content []byte) CatalogFile {
+	return CatalogFile{
+		Path:     filepath.ToSlash(relPath),
+		Size:     int64(len(content)),
+		Hash:     blobHash(content),
+		Language: extensionToLanguage(GetFileExtension(relPath)),
+	}
+}
+
+// blobHash returns the git blob hash of content, as printed by
+// git hash-object, so that entries can be compared with the repository.
+func blobHash(content []byte) string {
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
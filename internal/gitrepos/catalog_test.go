@@ -0,0 +1,149 @@
+package gitrepos
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func openTestCatalog(t *testing.T) (*Catalog, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), CatalogFilename)
+	catalog, err := OpenCatalog(path, false)
+	if err != nil {
+		t.Fatalf("OpenCatalog failed: %v", err)
+	}
+	t.Cleanup(func() { _ = catalog.Close() })
+	return catalog, path
+}
+
+func TestCatalog_ApplyFullAndIncremental(t *testing.T) {
+	catalog, _ := openTestCatalog(t)
+
+	err := catalog.Apply("repo", "commit1", &CatalogChanges{Full: true, Files: []CatalogFile{
+		newCatalogFile("main.go", []byte("package main")),
+		newCatalogFile("docs/guide.md", []byte("# Guide")),
+		newCatalogFile("docs/old.md", []byte("# Old")),
+	}})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	err = catalog.Apply("repo", "commit2", &CatalogChanges{
+		Files:   []CatalogFile{newCatalogFile("main.go", []byte("package main\n"))},
+		Deleted: []string{"docs/old.md"},
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	files, err := catalog.ListFiles("repo", "", 0)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "docs/guide.md" || files[1].Path != "main.go" {
+		t.Fatalf("Expected guide.md and main.go, got %+v", files)
+	}
+	if files[0].Commit != "commit1" || files[1].Commit != "commit2" {
+		t.Errorf("Expected per-file commits, got %+v", files)
+	}
+
+	// A full run replaces every file of the repository
+	err = catalog.Apply("repo", "commit3", &CatalogChanges{Full: true, Files: []CatalogFile{
+		newCatalogFile("lib.go", []byte("package lib")),
+	}})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if files, _ := catalog.ListFiles("repo", "", 0); len(files) != 1 || files[0].Path != "lib.go" {
+		t.Errorf("Expected only lib.go after a full run, got %+v", files)
+	}
+}
+
+func TestCatalog_ListFiles_PrefixAndLimit(t *testing.T) {
+	catalog, _ := openTestCatalog(t)
+	_ = catalog.Apply("repo", "c", &CatalogChanges{Full: true, Files: []CatalogFile{
+		newCatalogFile("a.go", nil),
+		newCatalogFile("pkg/b.go", nil),
+		newCatalogFile("pkg/c.go", nil),
+		newCatalogFile("pkgs/d.go", nil),
+	}})
+	_ = catalog.Apply("other", "c", &CatalogChanges{Full: true, Files: []CatalogFile{newCatalogFile("pkg/x.go", nil)}})
+
+	files, err := catalog.ListFiles("repo", "pkg/", 0)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "pkg/b.go" || files[1].Path != "pkg/c.go" {
+		t.Errorf("Expected files under pkg/, got %+v", files)
+	}
+
+	if files, _ := catalog.ListFiles("repo", "", 3); len(files) != 3 {
+		t.Errorf("Expected 3 files with limit, got %d", len(files))
+	}
+}
+
+func TestCatalog_Stat(t *testing.T) {
+	catalog, _ := openTestCatalog(t)
+	_ = catalog.Apply("repo", "commit1", &CatalogChanges{Full: true, Files: []CatalogFile{
+		newCatalogFile("main.go", []byte("hello\n")),
+	}})
+
+	file, err := catalog.Stat("repo", "main.go")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	// git hash-object of "hello\n"
+	if file == nil || file.Size != 6 || file.Language != "go" || file.Hash != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("Unexpected file: %+v", file)
+	}
+
+	if file, err := catalog.Stat("repo", "missing.go"); err != nil || file != nil {
+		t.Errorf("Expected nil for missing file, got %+v, %v", file, err)
+	}
+}
+
+func TestCatalog_SummaryAndDeleteRepo(t *testing.T) {
+	catalog, _ := openTestCatalog(t)
+	_ = catalog.Apply("repo", "c", &CatalogChanges{Full: true, Files: []CatalogFile{
+		newCatalogFile("a.go", []byte("1234")),
+		newCatalogFile("b.go", []byte("12")),
+		newCatalogFile("README.md", []byte("1")),
+	}})
+
+	summary, err := catalog.Summary("repo")
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+	if summary.Files != 3 || summary.Bytes != 7 || summary.Languages["go"] != 2 || summary.Languages["markdown"] != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	if err := catalog.DeleteRepo("repo"); err != nil {
+		t.Fatalf("DeleteRepo failed: %v", err)
+	}
+	if summary, _ := catalog.Summary("repo"); summary.Files != 0 {
+		t.Errorf("Expected empty summary after delete, got %+v", summary)
+	}
+}
+
+func TestCatalog_ReadOnly(t *testing.T) {
+	if _, err := OpenCatalog(filepath.Join(t.TempDir(), CatalogFilename), true); err == nil {
+		t.Error("Expected error opening a missing catalog read-only")
+	}
+
+	writer, path := openTestCatalog(t)
+	_ = writer.Apply("repo", "c", &CatalogChanges{Full: true, Files: []CatalogFile{newCatalogFile("a.go", nil)}})
+
+	reader, err := OpenCatalog(path, true)
+	if err != nil {
+		t.Fatalf("OpenCatalog read-only failed: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	if files, err := reader.ListFiles("repo", "", 0); err != nil || len(files) != 1 {
+		t.Errorf("Expected the writer's files, got %+v, %v", files, err)
+	}
+	if err := reader.DeleteRepo("repo"); err == nil {
+		t.Error("Expected writes to fail on a read-only catalog")
+	}
+}
@@ -0,0 +1,102 @@
+package gitrepos
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// IndexChecksumFilename is the file in the base directory that identifies
+// the indexes built by the last sync.
+const IndexChecksumFilename = "index-checksum.json"
+
+// IndexChecksum identifies a set of built indexes by what determines their
+// content: the index schema version, the snapshot refs and the indexed
+// commit of every repository. Equal checksums mean equal indexes, so a
+// prebuilt index can be reused instead of rebuilt.
+type IndexChecksum struct {
+	Checksum      string          `json:"checksum"` // hex SHA-256 of the fields below
+	SchemaVersion int             `json:"schema_version"`
+	Refs          []string        `json:"refs,omitempty"`
+	Repos         []IndexedCommit `json:"repos"`
+}
+
+// IndexedCommit is the commit a repository is indexed at; empty if it is not
+// indexed.
+type IndexedCommit struct {
+	Repository string `json:"repository"`
+	Commit     string `json:"commit"`
+}
+
+// NewIndexChecksum returns the checksum of indexes built with the current
+// schema version from commits, by repository display name, and refs. The
+// order of commits and refs does not matter.
+func NewIndexChecksum(commits map[string]string, refs []string) *IndexChecksum {
+	c := &IndexChecksum{
+		SchemaVersion: IndexMappingVersion,
+		Refs:          slices.Compact(slices.Sorted(slices.Values(refs))),
+		Repos:         make([]IndexedCommit, 0, len(commits)),
+	}
+	for repository, commit := range commits {
+		c.Repos = append(c.Repos, IndexedCommit{Repository: repository, Commit: commit})
+	}
+	slices.SortFunc(c.Repos, func(a, b IndexedCommit) int {
+		return cmp.Compare(a.Repository, b.Repository)
+	})
+
+	h := sha256.New()
+	writeLine(h, "schema %d", c.SchemaVersion)
+	for _, ref := range c.Refs {
+		writeLine(h, "ref %s", ref)
+	}
+	for _, repo := range c.Repos {
+		writeLine(h, "repo %s %s", repo.Repository, repo.Commit)
+	}
+	c.Checksum = hex.EncodeToString(h.Sum(nil))
+	return c
+}
+
+// writeLine writes a formatted line to h.
+func writeLine(h hash.Hash, format string, args ...any) {
+	_, _ = fmt.Fprintf(h, format+"\n", args...)
+}
+
+// LoadIndexChecksum reads an index checksum file.
+func LoadIndexChecksum(path string) (*IndexChecksum, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c IndexChecksum
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse index checksum: %w", err)
+	}
+	return &c, nil
+}
+
+// Save writes the checksum to path atomically.
+func (c *IndexChecksum) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal index checksum: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create index checksum directory: %w", err)
+	}
+
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write index checksum temp file: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to rename index checksum file: %w", err)
+	}
+	return nil
+}
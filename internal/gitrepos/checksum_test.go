@@ -0,0 +1,54 @@
+package gitrepos
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewIndexChecksum(t *testing.T) {
+	commits := map[string]string{"github.com/org/api": "abc123", "github.com/org/web": "def456"}
+	checksum := NewIndexChecksum(commits, []string{"v2.0.0", "v1.0.0", "v2.0.0"})
+
+	if checksum.SchemaVersion != IndexMappingVersion || len(checksum.Checksum) != 64 {
+		t.Errorf("Unexpected checksum: %+v", checksum)
+	}
+	if len(checksum.Refs) != 2 || checksum.Refs[0] != "v1.0.0" {
+		t.Errorf("Expected sorted unique refs, got %q", checksum.Refs)
+	}
+	if len(checksum.Repos) != 2 || checksum.Repos[0].Repository != "github.com/org/api" {
+		t.Errorf("Expected repositories by name, got %+v", checksum.Repos)
+	}
+
+	// Deterministic for the same input, whatever the order of refs
+	if again := NewIndexChecksum(commits, []string{"v1.0.0", "v2.0.0"}); again.Checksum != checksum.Checksum {
+		t.Errorf("Expected the same checksum, got %s and %s", checksum.Checksum, again.Checksum)
+	}
+
+	changed := []*IndexChecksum{
+		NewIndexChecksum(map[string]string{"github.com/org/api": "abc124", "github.com/org/web": "def456"}, []string{"v1.0.0", "v2.0.0"}),
+		NewIndexChecksum(map[string]string{"github.com/org/api": "abc123"}, []string{"v1.0.0", "v2.0.0"}),
+		NewIndexChecksum(map[string]string{"github.com/org/api": "abc123", "github.com/org/web": ""}, []string{"v1.0.0", "v2.0.0"}),
+		NewIndexChecksum(commits, []string{"v1.0.0"}),
+	}
+	for _, other := range changed {
+		if other.Checksum == checksum.Checksum {
+			t.Errorf("Expected a different checksum for %+v", other)
+		}
+	}
+}
+
+func TestIndexChecksum_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", IndexChecksumFilename)
+	checksum := NewIndexChecksum(map[string]string{"github.com/org/api": "abc123"}, nil)
+
+	if err := checksum.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadIndexChecksum(path)
+	if err != nil {
+		t.Fatalf("LoadIndexChecksum failed: %v", err)
+	}
+	if loaded.Checksum != checksum.Checksum || len(loaded.Repos) != 1 || loaded.Repos[0].Commit != "abc123" {
+		t.Errorf("Expected %+v, got %+v", checksum, loaded)
+	}
+}
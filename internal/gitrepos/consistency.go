@@ -0,0 +1,85 @@
+package gitrepos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// MetaGeneration is the tool result _meta key holding the sync generation
+	// of the indexes that served the call
+	MetaGeneration = "relic/generation"
+
+	// MetaCommits is the tool result _meta key holding the indexed commit of
+	// each repository, by display name
+	MetaCommits = "relic/commits"
+)
+
+// ConsistencyArgument is embedded in tool arguments so that agents running a
+// multi-step plan can detect that the indexes changed between calls.
+type ConsistencyArgument struct {
+	IfGeneration uint64 `json:"if_generation,omitempty" jsonschema_description:"Fail unless the index is still at this sync generation, as reported by a previous response"`
+}
+
+// ConsistencyMiddleware stamps every tool result with the sync generation and
+// indexed commits it was served from, and rejects calls whose if_generation
+// no longer matches the current generation.
+func ConsistencyMiddleware(service ConsistencyService) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			// Read before the call so a sync completing mid-call stamps the
+			// older generation and the next conditional call fails safe
+			generation := service.Generation()
+
+			// Malformed arguments are left for the tool handler to report
+			var args ConsistencyArgument
+			if call.Params != nil && len(call.Params.Arguments) > 0 {
+				_ = json.Unmarshal(call.Params.Arguments, &args)
+			}
+
+			var result *mcp.CallToolResult
+			if args.IfGeneration != 0 && args.IfGeneration != generation {
+				result = &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("Index changed: expected generation %d, current generation is %d. Results from earlier calls may be stale.", args.IfGeneration, generation)},
+					},
+					IsError: true,
+				}
+			} else {
+				res, err := next(ctx, method, req)
+				if err != nil {
+					return res, err
+				}
+				result, ok = res.(*mcp.CallToolResult)
+				if !ok || result == nil {
+					return res, nil
+				}
+			}
+
+			stampConsistency(result, generation, service.IndexedCommits())
+			return result, nil
+		}
+	}
+}
+
+// stampConsistency records the generation and commits in the result _meta and
+// appends the generation as text for clients that do not surface _meta.
+func stampConsistency(result *mcp.CallToolResult, generation uint64, commits map[string]string) {
+	if result.Meta == nil {
+		result.Meta = mcp.Meta{}
+	}
+	result.Meta[MetaGeneration] = generation
+	result.Meta[MetaCommits] = commits
+
+	result.Content = append(result.Content, &mcp.TextContent{
+		Text: fmt.Sprintf("_Index generation: %d_", generation),
+	})
+}
@@ -0,0 +1,140 @@
+package gitrepos
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// mockConsistencyService implements ConsistencyService for middleware tests.
+type mockConsistencyService struct {
+	generation uint64
+	commits    map[string]string
+}
+
+func (m *mockConsistencyService) Generation() uint64                { return m.generation }
+func (m *mockConsistencyService) IndexedCommits() map[string]string { return m.commits }
+
+func callTool(t *testing.T, service ConsistencyService, arguments string) (*mcp.CallToolResult, bool) {
+	t.Helper()
+	called := false
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		called = true
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "tool output"}}}, nil
+	}
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "search", Arguments: json.RawMessage(arguments)}}
+	res, err := ConsistencyMiddleware(service)(next)(context.Background(), "tools/call", req)
+	if err != nil {
+		t.Fatalf("Middleware returned error: %v", err)
+	}
+	return res.(*mcp.CallToolResult), called
+}
+
+func TestConsistencyMiddleware_StampsResult(t *testing.T) {
+	service := &mockConsistencyService{generation: 7, commits: map[string]string{"github.com/org/api": "abc123"}}
+
+	result, called := callTool(t, service, `{"query":"auth"}`)
+	if !called || result.IsError {
+		t.Fatalf("Expected the tool to run, got: %s", ExtractTextContent(result))
+	}
+	if result.Meta[MetaGeneration] != uint64(7) {
+		t.Errorf("Expected generation 7 in _meta, got %v", result.Meta[MetaGeneration])
+	}
+	if commits, _ := result.Meta[MetaCommits].(map[string]string); commits["github.com/org/api"] != "abc123" {
+		t.Errorf("Expected commits in _meta, got %v", result.Meta[MetaCommits])
+	}
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "tool output") || !strings.Contains(text, "Index generation: 7") {
+		t.Errorf("Expected tool output and generation, got: %s", text)
+	}
+}
+
+func TestConsistencyMiddleware_IfGeneration(t *testing.T) {
+	service := &mockConsistencyService{generation: 7}
+
+	result, called := callTool(t, service, `{"query":"auth","if_generation":7}`)
+	if !called || result.IsError {
+		t.Errorf("Expected matching generation to run the tool, got: %s", ExtractTextContent(result))
+	}
+
+	result, called = callTool(t, service, `{"query":"auth","if_generation":6}`)
+	if called {
+		t.Error("Expected stale generation to fail before running the tool")
+	}
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "expected generation 6, current generation is 7") {
+		t.Errorf("Expected index changed error, got: %s", ExtractTextContent(result))
+	}
+	if result.Meta[MetaGeneration] != uint64(7) {
+		t.Errorf("Expected the current generation in _meta, got %v", result.Meta[MetaGeneration])
+	}
+}
+
+func TestConsistencyMiddleware_PassesOtherMethods(t *testing.T) {
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.ListToolsResult{}, nil
+	}
+
+	res, err := ConsistencyMiddleware(&mockConsistencyService{})(next)(context.Background(), "tools/list", &mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("Middleware returned error: %v", err)
+	}
+	if _, ok := res.(*mcp.ListToolsResult); !ok {
+		t.Errorf("Expected the result to pass through, got %T", res)
+	}
+}
+
+func TestService_GenerationAndCommits(t *testing.T) {
+	manifest := newMockManifestOps()
+	manifest.repos["github.com_test_repo"] = RepoState{LastIndexed: "abc123"}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			URLs: []string{"git@github.com:test/repo.git", "git@github.com:test/new.git"},
+		},
+		ServiceDeps{Manifest: manifest},
+	)
+
+	manifest.UpdateLastSync(true)
+	if svc.Generation() != 1 {
+		t.Errorf("Expected generation 1, got %d", svc.Generation())
+	}
+
+	commits := svc.IndexedCommits()
+	if len(commits) != 1 || commits["github.com/test/repo"] != "abc123" {
+		t.Errorf("Expected only indexed repositories, got %v", commits)
+	}
+}
+
+func TestService_IndexedCommit(t *testing.T) {
+	manifest := newMockManifestOps()
+	manifest.repos["github.com_test_repo"] = RepoState{
+		LastIndexed: "abc123",
+		Snapshots:   map[string]RefSnapshot{"v1.0": {Commit: "def456"}},
+	}
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{URLs: []string{"git@github.com:test/repo.git"}},
+		ServiceDeps{Manifest: manifest},
+	)
+
+	tests := []struct {
+		repoID, ref, want string
+	}{
+		{"github.com_test_repo", "", "abc123"},
+		{"github.com_test_repo", "v1.0", "def456"},
+		{"github.com_test_repo", "v2.0", ""},
+		{"github.com_test_other", "", ""},
+	}
+	for _, tt := range tests {
+		if got := svc.IndexedCommit(tt.repoID, tt.ref); got != tt.want {
+			t.Errorf("IndexedCommit(%q, %q) = %q, want %q", tt.repoID, tt.ref, got, tt.want)
+		}
+	}
+	if manifest.HasRepo("github.com_test_other") {
+		t.Error("Expected the lookup not to add repositories to the manifest")
+	}
+}
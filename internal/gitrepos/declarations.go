@@ -0,0 +1,228 @@
+package gitrepos
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+// MaxDeclarations is the number of declarations above which a file is not
+// split, since its declarations are too small to be worth a document each,
+// e.g. a file of constants.
+const MaxDeclarations = 200
+
+// minDeclarations is the number of declarations below which a file is not
+// split, since the declaration would span about the whole file.
+const minDeclarations = 2
+
+// declarationPatterns match the first line of the top-level declarations of
+// a language, by symbolLanguage. Top-level declarations start in the first
+// column; the first non-empty group is the declared name.
+var declarationPatterns = map[string]*regexp.Regexp{
+	"go":     regexp.MustCompile(`(?m)^(?:func\s+(?:\([^)]*\)\s*)?(\w+)|type\s+(\w+)|var\s+(\w+)|const\s+(\w+)|(?:var|const|type)\s*\()`),
+	"py":     pyDeclarationPattern,
+	"python": pyDeclarationPattern,
+	"java": regexp.MustCompile(`(?m)^(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp)\s+)*` +
+		`(?:class|interface|enum|record|@interface)\s+(\w+)`),
+	"js": jsDeclarationPattern,
+	"ts": jsDeclarationPattern,
+	"rs": regexp.MustCompile(`(?m)^(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|const|unsafe|extern(?:\s+"[^"]*")?)\s+)*` +
+		`(?:fn\s+(\w+)|struct\s+(\w+)|enum\s+(\w+)|trait\s+(\w+)|mod\s+(\w+)|type\s+(\w+)|union\s+(\w+)|static\s+(?:mut\s+)?(\w+)|const\s+(\w+)|macro_rules!\s*(\w+)|` +
+		`impl(?:<[^>\n]*>)?\s+(?:[\w:]+(?:<[^>\n]*>)?\s+for\s+)?([\w:]+))`),
+	"c":     cDeclarationPattern,
+	"cpp":   cDeclarationPattern,
+	"proto": regexp.MustCompile(`(?m)^(?:message|service|enum|extend)\s+([\w.]+)`),
+}
+
+// pyDeclarationPattern matches the functions and classes of Python.
+var pyDeclarationPattern = regexp.MustCompile(`(?m)^(?:async\s+)?(?:def|class)\s+(\w+)`)
+
+// jsDeclarationPattern matches the top-level declarations of JavaScript and
+// TypeScript, exported or not.
+var jsDeclarationPattern = regexp.MustCompile(`(?m)^(?:export\s+(?:default\s+)?)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?` +
+	`(?:function\s*\*?\s*(\w+)|class\s+(\w+)|interface\s+(\w+)|(?:const\s+)?enum\s+(\w+)|type\s+(\w+)\s*[=<]|namespace\s+(\w+)|(?:const|let|var)\s+(\w+))`)
+
+// cDeclarationPattern matches the definitions of types, namespaces and
+// functions of C and C++ that start in the first column. Lines ending a
+// prototype or forward declaration with ";" do not match.
+var cDeclarationPattern = regexp.MustCompile(`(?m)^(?:template\s*<[^>\n]*>\s*)?` +
+	`(?:(?:typedef\s+)?(?:struct|class|union|enum(?:\s+class)?|namespace)\s+(\w+)[^;\n]*$|` +
+	`(?:[\w:*&<>,]+[ \t*&]+)+\**(\w+(?:::~?\w+)*)\s*\([^;\n]*$)`)
+
+// declarationCommentPrefixes start the lines of doc comments, attributes and
+// decorators, which belong to the declaration that follows them.
+var declarationCommentPrefixes = []string{"//", "/*", "*", "#", "@", "--"}
+
+// Declaration is a line range of a source file: a top-level declaration
+// with its doc comment, or the lines ahead of the first declaration.
+type Declaration struct {
+	Name      string // declared name; empty for the lines ahead of the first declaration
+	StartLine int    // 1-based, inclusive
+	EndLine   int    // 1-based, inclusive
+}
+
+// ExtractDeclarations splits content into its top-level declarations, based
+// on file extension. Each declaration runs until the next one starts, so
+// together they cover every non-blank line of the file. It returns nil for
+// languages without declaration patterns, and for files with fewer than two
+// or more than MaxDeclarations declarations.
+func ExtractDeclarations(ext, content string) []Declaration {
+	pattern, ok := declarationPatterns[symbolLanguage(ext)]
+	if !ok {
+		return nil
+	}
+	matches := pattern.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) < minDeclarations || len(matches) > MaxDeclarations {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var decls []Declaration
+	line, offset := 1, 0
+	for _, match := range matches {
+		line += strings.Count(content[offset:match[0]], "\n")
+		offset = match[0]
+		if len(decls) > 0 && decls[len(decls)-1].StartLine >= line {
+			continue // a second match within a line
+		}
+		decls = append(decls, Declaration{Name: declarationName(content, match), StartLine: line})
+	}
+	if len(decls) < minDeclarations {
+		return nil
+	}
+
+	// Doc comments and attributes right above a declaration belong to it
+	floor := 1
+	for n := range decls {
+		start := decls[n].StartLine
+		for start > floor && isDeclarationComment(lines[start-2]) {
+			start--
+		}
+		decls[n].StartLine = start
+		floor = decls[n].StartLine + 1
+	}
+
+	// Each declaration ends ahead of the next one, without trailing blank lines
+	for n := range decls {
+		end := len(lines)
+		if n+1 < len(decls) {
+			end = decls[n+1].StartLine - 1
+		}
+		decls[n].EndLine = lastContentLine(lines, decls[n].StartLine, end)
+	}
+
+	// The package clause, imports and the like
+	if header := lastContentLine(lines, 1, decls[0].StartLine-1); header > 0 {
+		decls = append([]Declaration{{StartLine: 1, EndLine: header}}, decls...)
+	}
+	return decls
+}
+
+// declarationName returns the first non-empty group of a declaration match,
+// or its first word for declarations without a name, like Go's "var (".
+func declarationName(content string, match []int) string {
+	for g := 2; g+1 < len(match); g += 2 {
+		if match[g] >= 0 {
+			return content[match[g]:match[g+1]]
+		}
+	}
+	fields := strings.FieldsFunc(content[match[0]:match[1]], func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '('
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// isDeclarationComment reports whether line is part of a doc comment,
+// attribute or decorator.
+func isDeclarationComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range declarationCommentPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastContentLine returns the last non-blank line from start to end, or
+// start-1 if they are all blank.
+func lastContentLine(lines []string, start, end int) int {
+	for end >= start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// declarationDocuments returns the documents of the declarations of a file,
+// or nil if it is not split into declarations (see ExtractDeclarations).
+func declarationDocuments(file *domain.CodeDocument) []domain.DeclarationDocument {
+	decls := ExtractDeclarations(file.Extension, file.Content)
+	if len(decls) == 0 {
+		return nil
+	}
+	lines := strings.Split(file.Content, "\n")
+	docs := make([]domain.DeclarationDocument, len(decls))
+	for n, decl := range decls {
+		content := strings.Join(lines[decl.StartLine-1:decl.EndLine], "\n")
+		docs[n] = domain.DeclarationDocument{
+			ID:          fmt.Sprintf("%s#L%d-%d", file.ID, decl.StartLine, decl.EndLine),
+			File:        file.ID,
+			Repository:  file.Repository,
+			FilePath:    file.FilePath,
+			Extension:   file.Extension,
+			Generated:   file.Generated,
+			Kind:        domain.DocumentKindDeclaration,
+			Declaration: decl.Name,
+			StartLine:   decl.StartLine,
+			EndLine:     decl.EndLine,
+			Content:     content,
+			Symbols:     ExtractSymbols(file.Extension, content),
+		}
+	}
+	return docs
+}
+
+// declarationIDs returns the IDs of the declaration documents of a file in
+// index.
+func declarationIDs(index bleve.Index, fileID string) ([]string, error) {
+	req := bleve.NewSearchRequest(fileDeclarationsQuery(fileID))
+	req.Size = MaxDeclarations + 1 // and the lines ahead of them
+	result, err := index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(result.Hits))
+	for n, hit := range result.Hits {
+		ids[n] = hit.ID
+	}
+	return ids, nil
+}
+
+// fileDeclarationsQuery matches the declaration documents of a file.
+func fileDeclarationsQuery(fileID string) *query.TermQuery {
+	q := bleve.NewTermQuery(fileID)
+	q.SetField(domain.CodeFieldFile)
+	return q
+}
+
+// declaredQuery matches the files indexed with their declarations, which
+// are searched in their place.
+func declaredQuery() query.Query {
+	q := bleve.NewBoolFieldQuery(true)
+	q.SetField(domain.CodeFieldDeclared)
+	return q
+}
+
+// declarationKindQuery matches declaration documents.
+func declarationKindQuery() query.Query {
+	q := bleve.NewTermQuery(domain.DocumentKindDeclaration)
+	q.SetField(domain.CodeFieldKind)
+	return q
+}
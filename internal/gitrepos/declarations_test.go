@@ -0,0 +1,203 @@
+package gitrepos
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestExtractDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		ext      string
+		content  string
+		expected []Declaration
+	}{
+		{
+			name: "Go with header and doc comments",
+			ext:  "go",
+			content: `package main
+
+import "fmt"
+
+// Hello greets.
+func Hello() {
+	fmt.Println("hello")
+}
+
+type Server struct{}
+
+func (s *Server) Start() {}
+`,
+			expected: []Declaration{
+				{Name: "", StartLine: 1, EndLine: 3},
+				{Name: "Hello", StartLine: 5, EndLine: 8},
+				{Name: "Server", StartLine: 10, EndLine: 10},
+				{Name: "Start", StartLine: 12, EndLine: 12},
+			},
+		},
+		{
+			name: "Go grouped declarations",
+			ext:  "go",
+			content: `const (
+	A = 1
+)
+
+var x = 2
+`,
+			expected: []Declaration{
+				{Name: "const", StartLine: 1, EndLine: 3},
+				{Name: "x", StartLine: 5, EndLine: 5},
+			},
+		},
+		{
+			name: "Python with decorators",
+			ext:  "py",
+			content: `@cached
+def load():
+    pass
+
+class Store:
+    def get(self):
+        pass
+`,
+			expected: []Declaration{
+				{Name: "load", StartLine: 1, EndLine: 3},
+				{Name: "Store", StartLine: 5, EndLine: 7},
+			},
+		},
+		{
+			name: "TypeScript exports",
+			ext:  "ts",
+			content: `export interface Options {}
+
+export async function run() {}
+`,
+			expected: []Declaration{
+				{Name: "Options", StartLine: 1, EndLine: 1},
+				{Name: "run", StartLine: 3, EndLine: 3},
+			},
+		},
+		{
+			name: "Rust impl blocks",
+			ext:  "rs",
+			content: `pub struct Point;
+
+impl Display for Point {
+}
+`,
+			expected: []Declaration{
+				{Name: "Point", StartLine: 1, EndLine: 1},
+				{Name: "Point", StartLine: 3, EndLine: 4},
+			},
+		},
+		{
+			name:    "single declaration",
+			ext:     "go",
+			content: "package main\n\nfunc main() {}\n",
+		},
+		{
+			name:    "unsupported language",
+			ext:     "md",
+			content: "# Title\n\n## Section\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractDeclarations(tt.ext, tt.content)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ExtractDeclarations() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractDeclarations_TooMany(t *testing.T) {
+	var sb strings.Builder
+	for n := range MaxDeclarations + 1 {
+		sb.WriteString(fmt.Sprintf("func F%d() {}\n", n))
+	}
+	if got := ExtractDeclarations("go", sb.String()); got != nil {
+		t.Errorf("Expected no declarations above MaxDeclarations, got %d", len(got))
+	}
+}
+
+func TestIndexer_Declarations(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+	indexer.SetDeclarations(true)
+
+	createTestFile(t, repoDir, "main.go", "package main\n\nfunc Alpha() {}\n\nfunc Beta() {}\n")
+	createTestFile(t, repoDir, "single.go", "package main\n\nfunc Gamma() {}\n")
+	if _, err := indexer.FullIndex("testrepo", repoDir); err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+	assertDeclarationIDs(t, indexer, "main.go", []string{"main.go#L1-1", "main.go#L3-3", "main.go#L5-5"})
+	assertDeclarationIDs(t, indexer, "single.go", nil)
+
+	// Changed declarations replace the previous ones
+	createTestFile(t, repoDir, "main.go", "package main\n\nfunc Alpha() {\n}\n\nfunc Delta() {}\n")
+	if _, err := indexer.IncrementalIndex("testrepo", repoDir, []string{"main.go"}); err != nil {
+		t.Fatalf("IncrementalIndex failed: %v", err)
+	}
+	assertDeclarationIDs(t, indexer, "main.go", []string{"main.go#L1-1", "main.go#L3-4", "main.go#L6-6"})
+
+	// Deleted files take their declarations along, also with the setting off
+	if err := os.Remove(filepath.Join(repoDir, "main.go")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	indexer.SetDeclarations(false)
+	if _, err := indexer.IncrementalIndex("testrepo", repoDir, []string{"main.go"}); err != nil {
+		t.Fatalf("IncrementalIndex failed: %v", err)
+	}
+	assertDeclarationIDs(t, indexer, "main.go", nil)
+}
+
+// assertDeclarationIDs checks the declaration documents indexed for a file,
+// in any order.
+func assertDeclarationIDs(t *testing.T, indexer *Indexer, path string, expected []string) {
+	t.Helper()
+	index, err := indexer.OpenForRead("testrepo")
+	if err != nil {
+		t.Fatalf("OpenForRead failed: %v", err)
+	}
+	defer closeIndex(t, index)
+
+	fileID := "testrepo/" + path
+	ids, err := declarationIDs(index, fileID)
+	if err != nil {
+		t.Fatalf("declarationIDs failed: %v", err)
+	}
+	want := make(map[string]bool)
+	for _, id := range expected {
+		want["testrepo/"+id] = true
+	}
+	got := make(map[string]bool)
+	for _, id := range ids {
+		got[id] = true
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Declarations of %s = %v, want %v", path, ids, expected)
+	}
+
+	// Files split into declarations are marked declared
+	doc, err := index.Document(fileID)
+	if err != nil || doc == nil {
+		return
+	}
+	declared := bleve.NewSearchRequest(bleve.NewConjunctionQuery(bleve.NewDocIDQuery([]string{fileID}), declaredQuery()))
+	result, err := index.Search(declared)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if (result.Total > 0) != (len(expected) > 0) {
+		t.Errorf("Expected %s declared=%v", path, len(expected) > 0)
+	}
+}
@@ -0,0 +1,162 @@
+package gitrepos
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+// maxDirectoryEntries caps the entry names a directory document lists, so
+// that directories with thousands of files stay lightweight
+const maxDirectoryEntries = 500
+
+// directoryDocuments summarizes the directories of files, the complete list
+// of indexed files of a repository, ordered by path. The repository root is
+// not included.
+func directoryDocuments(repoID string, files []CatalogFile) []domain.DirectoryDocument {
+	type dirStats struct {
+		files     int
+		entries   map[string]bool // subdirectories with a trailing slash
+		languages map[string]int
+	}
+	dirs := make(map[string]*dirStats)
+
+	for _, file := range files {
+		name := path.Base(file.Path)
+		for dir := path.Dir(file.Path); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			stats, ok := dirs[dir]
+			if !ok {
+				stats = &dirStats{entries: make(map[string]bool), languages: make(map[string]int)}
+				dirs[dir] = stats
+			}
+			stats.files++
+			stats.entries[name] = true
+			if file.Language != "" {
+				stats.languages[file.Language]++
+			}
+			name = path.Base(dir) + "/"
+		}
+	}
+
+	displayName := RepoIDToDisplay(baseRepoID(repoID))
+	docs := make([]domain.DirectoryDocument, 0, len(dirs))
+	for dir, stats := range dirs {
+		entries := make([]string, 0, len(stats.entries))
+		subdirs := 0
+		for entry := range stats.entries {
+			entries = append(entries, entry)
+			if strings.HasSuffix(entry, "/") {
+				subdirs++
+			}
+		}
+		slices.Sort(entries)
+		if len(entries) > maxDirectoryEntries {
+			entries = entries[:maxDirectoryEntries]
+		}
+		names := make([]string, len(entries))
+		for n, entry := range entries {
+			entry = strings.TrimSuffix(entry, "/")
+			names[n] = strings.TrimSuffix(entry, path.Ext(entry))
+		}
+
+		docs = append(docs, domain.DirectoryDocument{
+			ID:          repoID + "/" + dir + "/",
+			Repository:  displayName,
+			FilePath:    dir,
+			Kind:        domain.DocumentKindDirectory,
+			Content:     dir + "\n" + strings.Join(entries, "\n"),
+			Symbols:     names,
+			FileCount:   stats.files,
+			SubdirCount: subdirs,
+			Languages:   sortedByCount(stats.languages),
+		})
+	}
+	slices.SortFunc(docs, func(a, b domain.DirectoryDocument) int {
+		return cmp.Compare(a.FilePath, b.FilePath)
+	})
+	return docs
+}
+
+// sortedByCount returns the keys of counts, most common first.
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if n := cmp.Compare(counts[b], counts[a]); n != 0 {
+			return n
+		}
+		return cmp.Compare(a, b)
+	})
+	return keys
+}
+
+// IndexDirectories replaces the directory documents of a repository's index
+// with summaries of files, the complete list of its indexed files.
+func (i *Indexer) IndexDirectories(repoID string, files []CatalogFile) (err error) {
+	index, err := i.OpenForWrite(repoID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := index.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	stale, err := directoryIDs(index)
+	if err != nil {
+		return err
+	}
+
+	batch := index.NewBatch()
+	for _, doc := range directoryDocuments(repoID, files) {
+		delete(stale, doc.ID)
+		if err := batch.Index(doc.ID, doc); err != nil {
+			return err
+		}
+		if batch.Size() >= i.batchSize {
+			if err := index.Batch(batch); err != nil {
+				return err
+			}
+			batch = index.NewBatch()
+		}
+	}
+	for id := range stale {
+		batch.Delete(id)
+	}
+	return index.Batch(batch)
+}
+
+// directoryIDs returns the IDs of the directory documents in index.
+func directoryIDs(index bleve.Index) (map[string]bool, error) {
+	req := bleve.NewSearchRequest(directoryKindQuery())
+	req.Size = 0
+	result, err := index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Size = int(result.Total)
+	if result, err = index.Search(req); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids[hit.ID] = true
+	}
+	return ids, nil
+}
+
+// directoryKindQuery matches directory documents.
+func directoryKindQuery() query.Query {
+	q := bleve.NewTermQuery(domain.DocumentKindDirectory)
+	q.SetField(domain.CodeFieldKind)
+	return q
+}
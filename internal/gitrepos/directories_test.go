@@ -0,0 +1,109 @@
+package gitrepos
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+func TestDirectoryDocuments(t *testing.T) {
+	files := []CatalogFile{
+		{Path: "README.md", Language: "markdown"},
+		{Path: "services/kafka/consumer.go", Language: "go"},
+		{Path: "services/kafka/producer.go", Language: "go"},
+		{Path: "services/kafka/topics.yaml", Language: "yaml"},
+		{Path: "services/http/server.go", Language: "go"},
+		{Path: "services/Makefile"},
+	}
+
+	docs := directoryDocuments("github.com_org_repo", files)
+	var paths []string
+	for _, doc := range docs {
+		paths = append(paths, doc.FilePath)
+	}
+	if !slices.Equal(paths, []string{"services", "services/http", "services/kafka"}) {
+		t.Fatalf("Unexpected directories: %q", paths)
+	}
+
+	services := docs[0]
+	if services.ID != "github.com_org_repo/services/" || services.Repository != "github.com/org/repo" || services.Kind != domain.DocumentKindDirectory {
+		t.Errorf("Unexpected identity: %+v", services)
+	}
+	if services.FileCount != 5 || services.SubdirCount != 2 {
+		t.Errorf("Expected 5 files in 2 subdirectories, got %d in %d", services.FileCount, services.SubdirCount)
+	}
+	if !slices.Equal(services.Languages, []string{"go", "yaml"}) {
+		t.Errorf("Expected languages by frequency, got %q", services.Languages)
+	}
+	if services.Content != "services\nMakefile\nhttp/\nkafka/" {
+		t.Errorf("Unexpected content: %q", services.Content)
+	}
+
+	kafka := docs[2]
+	if kafka.FileCount != 3 || kafka.SubdirCount != 0 || !slices.Equal(kafka.Symbols, []string{"consumer", "producer", "topics"}) {
+		t.Errorf("Unexpected kafka directory: %+v", kafka)
+	}
+}
+
+func TestIndexer_IndexDirectories(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+
+	createTestFile(t, repoDir, "main.go", "package main")
+	createTestFile(t, repoDir, "lib/utils.go", "package lib")
+	createTestFile(t, repoDir, "old/legacy.go", "package old")
+	if _, err := indexer.FullIndex("testrepo", repoDir); err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+	files := []CatalogFile{{Path: "main.go"}, {Path: "lib/utils.go"}, {Path: "old/legacy.go"}}
+	if err := indexer.IndexDirectories("testrepo", files); err != nil {
+		t.Fatalf("IndexDirectories failed: %v", err)
+	}
+
+	// Directories that no longer hold files are removed
+	var kept []CatalogFile
+	for _, file := range files {
+		if file.Path != "old/legacy.go" {
+			kept = append(kept, file)
+		}
+	}
+	if err := indexer.IndexDirectories("testrepo", kept); err != nil {
+		t.Fatalf("IndexDirectories failed: %v", err)
+	}
+
+	// Verification counts files only
+	if err := indexer.VerifyIndex("testrepo", 3); err != nil {
+		t.Errorf("VerifyIndex failed: %v", err)
+	}
+
+	index, err := indexer.OpenForRead("testrepo")
+	if err != nil {
+		t.Fatalf("OpenForRead failed: %v", err)
+	}
+	defer closeIndex(t, index)
+
+	ids, err := directoryIDs(index)
+	if err != nil {
+		t.Fatalf("directoryIDs failed: %v", err)
+	}
+	if len(ids) != 1 || !ids["testrepo/lib/"] {
+		t.Errorf("Expected only the lib directory, got %v", ids)
+	}
+	if count, _ := index.DocCount(); count != 4 {
+		t.Errorf("Expected 3 files and 1 directory, got %d documents", count)
+	}
+
+	req := bleve.NewSearchRequest(directoryKindQuery())
+	req.Fields = []string{domain.CodeFieldFileCount, domain.CodeFieldLanguages}
+	result, err := index.Search(req)
+	if err != nil || len(result.Hits) != 1 {
+		t.Fatalf("Expected the directory document, got %v (%v)", result, err)
+	}
+	if files, _ := result.Hits[0].Fields[domain.CodeFieldFileCount].(float64); files != 1 {
+		t.Errorf("Expected a stored file count of 1, got %v", result.Hits[0].Fields)
+	}
+}
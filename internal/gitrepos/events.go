@@ -0,0 +1,15 @@
+package gitrepos
+
+// LogEventKey is the slog attribute that marks index activity worth showing
+// to MCP clients. Records carrying it are forwarded as MCP log notifications.
+const LogEventKey = "event"
+
+// Index activity events, used as values of LogEventKey
+const (
+	EventSyncStarted  = "sync_started"
+	EventSyncFinished = "sync_finished"
+	EventRepoError    = "repo_error"
+	// EventIndexUpdated is sent by the index update notifier rather than
+	// logged, so that it does not depend on client-log-level
+	EventIndexUpdated = "index_updated"
+)
@@ -0,0 +1,88 @@
+package gitrepos
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// queryStrategy holds the tuning of the text part of a search query.
+type queryStrategy struct {
+	contentFuzziness int
+	symbolsBoost     float64
+	symbolPartsBoost float64
+}
+
+// defaultQueryStrategy is the tuning searches are answered with
+var defaultQueryStrategy = queryStrategy{contentFuzziness: contentFuzziness, symbolsBoost: symbolsBoost, symbolPartsBoost: symbolPartsBoost}
+
+// queryStrategies are the alternates a query experiment can compare against
+// the default, by config.QueryStrategy name
+var queryStrategies = map[string]queryStrategy{
+	config.QueryStrategyExact:   {contentFuzziness: 0, symbolsBoost: symbolsBoost, symbolPartsBoost: symbolPartsBoost},
+	config.QueryStrategySymbols: {contentFuzziness: contentFuzziness, symbolsBoost: 2 * symbolsBoost, symbolPartsBoost: symbolPartsBoost},
+}
+
+// runQueryExperiment runs the search of args with the alternate strategy
+// named experiment and logs how its hits compare to the hits of the default
+// strategy. Only the query hash is logged, not the query.
+func (h *SearchHandler) runQueryExperiment(ctx context.Context, alias bleve.IndexAlias, args SearchArgument, primary *bleve.SearchResult, experiment string) {
+	strategy, ok := queryStrategies[experiment]
+	if !ok {
+		return
+	}
+
+	req := bleve.NewSearchRequest(h.buildQuery(args, rankingProfile{strategy: strategy}))
+	req.Size = h.service.MaxResults()
+	experimentCtx, cancel := withTimeout(ctx, h.service.SearchTimeout())
+	defer cancel()
+	alternate, err := alias.SearchInContext(experimentCtx, req)
+	if err != nil {
+		slog.Warn("Query experiment failed", "strategy", experiment, "error", err)
+		return
+	}
+
+	primaryIDs, alternateIDs := hitIDs(primary), hitIDs(alternate)
+	slog.Info("Query experiment",
+		"strategy", experiment,
+		"query_hash", QueryHash(args.Query),
+		"k", max(len(primaryIDs), len(alternateIDs)),
+		"overlap", topKOverlap(primaryIDs, alternateIDs),
+		"same_top", len(primaryIDs) > 0 && len(alternateIDs) > 0 && primaryIDs[0] == alternateIDs[0],
+		"total", primary.Total,
+		"alternate_total", alternate.Total,
+	)
+	slog.Debug("Query experiment hits", "strategy", experiment, "hits", primaryIDs, "alternate_hits", alternateIDs)
+}
+
+// hitIDs returns the document IDs of the hits of result, in rank order.
+func hitIDs(result *bleve.SearchResult) []string {
+	ids := make([]string, len(result.Hits))
+	for n, hit := range result.Hits {
+		ids[n] = hit.ID
+	}
+	return ids
+}
+
+// topKOverlap returns the share of the top k hits found in both lists, where
+// k is the length of the longer list: 1 when both hold the same documents in
+// any order, 0 when they share none. Two empty lists overlap fully.
+func topKOverlap(a, b []string) float64 {
+	k := max(len(a), len(b))
+	if k == 0 {
+		return 1
+	}
+	inA := make(map[string]bool, len(a))
+	for _, id := range a {
+		inA[id] = true
+	}
+	shared := 0
+	for _, id := range b {
+		if inA[id] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(k)
+}
@@ -0,0 +1,65 @@
+package gitrepos
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestTopKOverlap(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want float64
+	}{
+		{nil, nil, 1},
+		{[]string{"x", "y"}, []string{"y", "x"}, 1},
+		{[]string{"x", "y"}, []string{"x", "z"}, 0.5},
+		{[]string{"x", "y", "z", "w"}, []string{"x"}, 0.25},
+		{[]string{"x"}, nil, 0},
+	}
+
+	for _, tt := range tests {
+		if got := topKOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("topKOverlap(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSearchHandler_QueryExperiment(t *testing.T) {
+	files := map[string]string{
+		"greeting.go": "package main\n\nfunc greet() { println(\"hello\") }",
+		"typo.go":     "package main\n\nfunc typo() { println(\"helo\") }",
+	}
+	svc := setupSearchService(t, t.TempDir(), files)
+	defer func() { _ = svc.Close() }()
+
+	settings := *svc.currentSettings()
+	settings.QueryExperiment = config.QueryStrategyExact
+	svc.settings = &settings
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	result, _, _ := NewSearchHandler(svc).Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "hello"})
+	if text := ExtractTextContent(result); result.IsError || !strings.Contains(text, "typo.go") {
+		t.Fatalf("Expected the default strategy to answer, with the fuzzy match: %s", text)
+	}
+
+	// Without typo tolerance, only one of the two hits is found
+	out := logs.String()
+	for _, want := range []string{"msg=\"Query experiment\"", "strategy=exact", "overlap=0.5", "total=2", "alternate_total=1", "query_hash=" + QueryHash("hello")} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in logs:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "query=hello") {
+		t.Errorf("Expected the query not to be logged:\n%s", out)
+	}
+}
@@ -45,8 +45,12 @@ var DefaultExcludePatterns = []string{
 
 // FileFilter determines which files should be included in indexing.
 type FileFilter struct {
-	patterns    []string
-	maxFileSize int64
+	patterns       []string
+	maxFileSize    int64
+	sizeOverrides  map[string]int64 // by lowercase extension, without the dot
+	followSymlinks bool
+	maxRepoFiles   int
+	maxRepoBytes   int64
 }
 
 // NewFileFilter creates a new FileFilter with default exclusion patterns.
@@ -68,11 +72,35 @@ func NewFileFilterWithPatterns(patterns []string, maxFileSize int64) *FileFilter
 // ShouldExclude returns true if the given path matches any exclusion pattern.
 // The path should be relative to the repository root.
 func (f *FileFilter) ShouldExclude(relPath string) bool {
+	return f.ExcludingPattern(relPath) != ""
+}
+
+// ExcludingPattern returns the first exclusion pattern matching the given
+// path, or an empty string if the path is not excluded.
+func (f *FileFilter) ExcludingPattern(relPath string) string {
 	// Normalize path separators
 	relPath = filepath.ToSlash(relPath)
 
 	for _, pattern := range f.patterns {
 		if matchPattern(pattern, relPath) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// Patterns returns the exclusion patterns, in the order they are tried.
+func (f *FileFilter) Patterns() []string {
+	return f.patterns
+}
+
+// ShouldExcludeDir returns true if the directory matches a directory
+// exclusion pattern (e.g. "node_modules/**"), so none of its contents are indexed.
+func (f *FileFilter) ShouldExcludeDir(relDir string) bool {
+	relDir = filepath.ToSlash(relDir)
+
+	for _, pattern := range f.patterns {
+		if strings.HasSuffix(pattern, "/**") && matchPattern(pattern, relDir+"/") {
 			return true
 		}
 	}
@@ -84,21 +112,57 @@ func (f *FileFilter) MaxFileSize() int64 {
 	return f.maxFileSize
 }
 
+// SetSizeOverrides sets per-extension maximum file sizes that replace
+// MaxFileSize, keyed by lowercase extension without the leading dot.
+func (f *FileFilter) SetSizeOverrides(overrides map[string]int64) {
+	f.sizeOverrides = overrides
+}
+
+// SizeOverride returns the maximum size configured for the extension of
+// relPath, if any.
+func (f *FileFilter) SizeOverride(relPath string) (int64, bool) {
+	size, ok := f.sizeOverrides[strings.ToLower(GetFileExtension(relPath))]
+	return size, ok
+}
+
+// SetFollowSymlinks sets whether symlinked files that resolve inside the
+// repository are indexed. Symlinks are skipped by default.
+func (f *FileFilter) SetFollowSymlinks(follow bool) {
+	f.followSymlinks = follow
+}
+
+// FollowSymlinks reports whether symlinked files are indexed.
+func (f *FileFilter) FollowSymlinks() bool {
+	return f.followSymlinks
+}
+
+// SetRepoBudget limits the number of files and content bytes indexed per
+// repository. Zero means unlimited.
+func (f *FileFilter) SetRepoBudget(maxFiles int, maxBytes int64) {
+	f.maxRepoFiles = maxFiles
+	f.maxRepoBytes = maxBytes
+}
+
+// RepoBudget returns the per-repository file and byte limits.
+func (f *FileFilter) RepoBudget() (maxFiles int, maxBytes int64) {
+	return f.maxRepoFiles, f.maxRepoBytes
+}
+
 // matchPattern matches a file path against a glob pattern.
 // Supports ** for directory matching and * for filename matching.
 func matchPattern(pattern, path string) bool {
 	// Handle **/ prefix (match any directory depth)
 	if strings.HasPrefix(pattern, "**/") {
-		// Match at root or any subdirectory
+		// Match at root or any subdirectory; the rest may itself end in /**
 		rest := pattern[3:]
-		if matchSimplePattern(rest, path) {
+		if matchPattern(rest, path) {
 			return true
 		}
 		// Try matching at any subdirectory level
 		parts := strings.Split(path, "/")
 		for i := range parts {
 			subPath := strings.Join(parts[i:], "/")
-			if matchSimplePattern(rest, subPath) {
+			if matchPattern(rest, subPath) {
 				return true
 			}
 		}
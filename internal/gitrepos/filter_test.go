@@ -462,6 +462,11 @@ func TestMatchPattern(t *testing.T) {
 		{"complex_glob_fail", "test_?.go", "test_10.go", false},
 		{"bad_pattern", "[", "file.go", false},
 		{"bad_pattern_simple", "a[", "file.go", false},
+
+		// Any-depth directory
+		{"any depth directory", "**/secrets/**", "deploy/secrets/prod/db.yaml", true},
+		{"any depth directory at root", "**/secrets/**", "secrets/db.yaml", true},
+		{"any depth directory no match", "**/secrets/**", "deploy/secrets.yaml", false},
 	}
 
 	for _, tt := range tests {
@@ -564,3 +569,46 @@ func TestDefaultExcludePatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestFileFilter_ExcludingPattern(t *testing.T) {
+	filter := NewFileFilterWithPatterns([]string{"vendor/**", "*.min.js", "*.js"}, 1024)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"vendor/lib/a.go", "vendor/**"},
+		{"web/app.min.js", "*.min.js"}, // the first matching pattern
+		{"web/app.js", "*.js"},
+		{"main.go", ""},
+	}
+	for _, tt := range tests {
+		if got := filter.ExcludingPattern(tt.path); got != tt.want {
+			t.Errorf("ExcludingPattern(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFileFilter_ShouldExcludeDir(t *testing.T) {
+	filter := NewFileFilter(256 * 1024)
+
+	tests := []struct {
+		dir     string
+		exclude bool
+	}{
+		{".git", true},
+		{"node_modules", true},
+		{"web/node_modules", true},
+		{"src", false},
+		{"src/build.d", false},
+		{"assets", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := filter.ShouldExcludeDir(tt.dir); got != tt.exclude {
+				t.Errorf("ShouldExcludeDir(%q) = %v, want %v", tt.dir, got, tt.exclude)
+			}
+		})
+	}
+}
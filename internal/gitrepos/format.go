@@ -0,0 +1,189 @@
+package gitrepos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Size units of FormatOptions
+const (
+	UnitsBinary = "binary" // KB of 1024 bytes, the default
+	UnitsSI     = "si"     // kB of 1000 bytes
+	UnitsBytes  = "bytes"  // exact byte counts
+)
+
+// FormatOptions controls how tool results are rendered, for clients that
+// embed results in their own UI. The zero value is the default rendering.
+type FormatOptions struct {
+	Units           string `json:"units,omitempty" jsonschema_description:"Unit of file sizes: 'binary' (KB of 1024 bytes, default), 'si' (kB of 1000 bytes) or 'bytes'"`
+	MaxSnippetWidth int    `json:"max_snippet_width,omitempty" jsonschema_description:"Cut code snippet and matching lines longer than this many characters (default: no limit beyond the tool's own)"`
+	Plain           bool   `json:"plain,omitempty" jsonschema_description:"Return plain text instead of markdown: no emphasis, inline code or code fences"`
+}
+
+// FormatArgument is embedded in tool arguments to let clients choose how
+// results are rendered.
+type FormatArgument struct {
+	Format *FormatOptions `json:"format,omitempty" jsonschema_description:"Formatting options for clients that render results in their own UI"`
+}
+
+// formatKey is the context key of the FormatOptions of a tool call
+type formatKey struct{}
+
+// formatFrom returns the FormatOptions of the tool call of ctx.
+func formatFrom(ctx context.Context) FormatOptions {
+	opts, _ := ctx.Value(formatKey{}).(FormatOptions)
+	return opts
+}
+
+// FormatMiddleware passes the format argument of tool calls to the tool
+// handlers through the context, and converts the results of calls that ask
+// for plain text.
+func FormatMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			// Malformed arguments are left for the tool handler to report
+			var args FormatArgument
+			if call.Params != nil && len(call.Params.Arguments) > 0 {
+				_ = json.Unmarshal(call.Params.Arguments, &args)
+			}
+			if args.Format == nil {
+				return next(ctx, method, req)
+			}
+			if err := args.Format.validate(); err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("Invalid format: %s", err)},
+					},
+					IsError: true,
+				}, nil
+			}
+
+			res, err := next(context.WithValue(ctx, formatKey{}, *args.Format), method, req)
+			if result, ok := res.(*mcp.CallToolResult); ok && result != nil && args.Format.Plain {
+				for _, content := range result.Content {
+					if text, ok := content.(*mcp.TextContent); ok {
+						text.Text = plainText(text.Text)
+					}
+				}
+			}
+			return res, err
+		}
+	}
+}
+
+// validate checks the options of a tool call.
+func (o FormatOptions) validate() error {
+	switch o.Units {
+	case "", UnitsBinary, UnitsSI, UnitsBytes:
+	default:
+		return fmt.Errorf("units must be one of %s, %s or %s, got %q", UnitsBinary, UnitsSI, UnitsBytes, o.Units)
+	}
+	if o.MaxSnippetWidth < 0 {
+		return fmt.Errorf("max_snippet_width cannot be negative, got %d", o.MaxSnippetWidth)
+	}
+	return nil
+}
+
+// size renders a file size in the requested units, with the given number of
+// decimals.
+func (o FormatOptions) size(bytes int64, decimals int) string {
+	switch o.Units {
+	case UnitsSI:
+		return fmt.Sprintf("%.*f kB", decimals, float64(bytes)/1000)
+	case UnitsBytes:
+		return fmt.Sprintf("%d bytes", bytes)
+	default:
+		return fmt.Sprintf("%.*f KB", decimals, float64(bytes)/1024)
+	}
+}
+
+// snippetWidth returns the width at which lines of code are cut: the
+// requested width, within limit if the tool has one (0 = no limit).
+func (o FormatOptions) snippetWidth(limit int) int {
+	if o.MaxSnippetWidth > 0 && (limit == 0 || o.MaxSnippetWidth < limit) {
+		return o.MaxSnippetWidth
+	}
+	return limit
+}
+
+// FormatTime renders t in UTC as RFC 3339, followed by how long before now
+// it was, e.g. "2026-01-02T03:04:05Z (3 hours ago)".
+func FormatTime(t, now time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.UTC().Format(time.RFC3339), FormatAge(now.Sub(t)))
+}
+
+// FormatAge renders how long ago something happened in its largest whole
+// unit, e.g. "just now", "5 minutes ago" or "2 days ago". Negative durations,
+// from clocks that disagree, render as "just now".
+func FormatAge(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if n := int(d / unit.size); n >= 1 {
+			if n == 1 {
+				return fmt.Sprintf("1 %s ago", unit.name)
+			}
+			return fmt.Sprintf("%d %ss ago", n, unit.name)
+		}
+	}
+	return "just now"
+}
+
+// truncateLine cuts a line to width characters, marking the cut with an
+// ellipsis. A width of 0 leaves the line as is.
+func truncateLine(line string, width int) string {
+	if width <= 0 || len(line) <= width {
+		return line
+	}
+	chars := 0
+	for i := range line {
+		if chars == width {
+			return line[:i] + "…"
+		}
+		chars++
+	}
+	return line
+}
+
+// plainText converts the markdown of a tool result to plain text: code
+// fences are dropped, and emphasis, inline code and headings are unwrapped
+// outside of them. Code is left as is.
+func plainText(markdown string) string {
+	unwrap := strings.NewReplacer("**", "", "`", "")
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			line = unwrap.Replace(line)
+			if strings.HasPrefix(line, "#") {
+				line = strings.TrimLeft(line, "# ")
+			}
+			if len(line) > 1 && strings.HasPrefix(line, "_") && strings.HasSuffix(line, "_") {
+				line = line[1 : len(line)-1]
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
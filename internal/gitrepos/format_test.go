@@ -0,0 +1,180 @@
+package gitrepos
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func callFormattedTool(t *testing.T, arguments, output string) (*mcp.CallToolResult, FormatOptions, bool) {
+	t.Helper()
+	var seen FormatOptions
+	called := false
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		called = true
+		seen = formatFrom(ctx)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: output}}}, nil
+	}
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "read", Arguments: json.RawMessage(arguments)}}
+	res, err := FormatMiddleware()(next)(context.Background(), "tools/call", req)
+	if err != nil {
+		t.Fatalf("Middleware returned error: %v", err)
+	}
+	return res.(*mcp.CallToolResult), seen, called
+}
+
+func TestFormatMiddleware_PassesOptions(t *testing.T) {
+	_, seen, called := callFormattedTool(t, `{"path":"main.go","format":{"units":"si","max_snippet_width":80}}`, "output")
+	if !called {
+		t.Fatal("Expected the tool to run")
+	}
+	if seen.Units != UnitsSI || seen.MaxSnippetWidth != 80 {
+		t.Errorf("Expected the options in the context, got %+v", seen)
+	}
+
+	_, seen, _ = callFormattedTool(t, `{"path":"main.go"}`, "output")
+	if seen != (FormatOptions{}) {
+		t.Errorf("Expected default options without format, got %+v", seen)
+	}
+}
+
+func TestFormatMiddleware_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		arguments string
+		want      string
+	}{
+		{`{"format":{"units":"furlongs"}}`, `units must be one of binary, si or bytes, got "furlongs"`},
+		{`{"format":{"max_snippet_width":-1}}`, "max_snippet_width cannot be negative"},
+	}
+	for _, tt := range tests {
+		result, _, called := callFormattedTool(t, tt.arguments, "output")
+		if called {
+			t.Errorf("Expected %s to fail before running the tool", tt.arguments)
+		}
+		if !result.IsError || !strings.Contains(ExtractTextContent(result), tt.want) {
+			t.Errorf("Expected error %q, got: %s", tt.want, ExtractTextContent(result))
+		}
+	}
+}
+
+func TestFormatMiddleware_Plain(t *testing.T) {
+	markdown := "_Path `Main.go` resolved to `main.go`_\n\n**github.com/org/repo** `main.go`\n\n```go\nx := `**raw**`\n```\n"
+	result, _, _ := callFormattedTool(t, `{"format":{"plain":true}}`, markdown)
+
+	want := "Path Main.go resolved to main.go\n\ngithub.com/org/repo main.go\n\nx := `**raw**`\n"
+	if got := ExtractTextContent(result); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFormatOptions_Size(t *testing.T) {
+	tests := []struct {
+		units string
+		want  string
+	}{
+		{"", "1.50 KB"},
+		{UnitsBinary, "1.50 KB"},
+		{UnitsSI, "1.54 kB"},
+		{UnitsBytes, "1536 bytes"},
+	}
+	for _, tt := range tests {
+		if got := (FormatOptions{Units: tt.units}).size(1536, 2); got != tt.want {
+			t.Errorf("size with units %q = %q, want %q", tt.units, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOptions_SnippetWidth(t *testing.T) {
+	tests := []struct {
+		requested, limit, want int
+	}{
+		{0, 0, 0},
+		{0, 500, 500},
+		{80, 0, 80},
+		{80, 500, 80},
+		{800, 500, 500},
+	}
+	for _, tt := range tests {
+		if got := (FormatOptions{MaxSnippetWidth: tt.requested}).snippetWidth(tt.limit); got != tt.want {
+			t.Errorf("snippetWidth(%d) with %d requested = %d, want %d", tt.limit, tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc…"},
+		{"héllo wörld", 5, "héllo…"},
+		{"unlimited", 0, "unlimited"},
+	}
+	for _, tt := range tests {
+		if got := truncateLine(tt.line, tt.width); got != tt.want {
+			t.Errorf("truncateLine(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateFragment_ClosesHighlight(t *testing.T) {
+	fragment := "func " + highlightStart + "Authenticate" + highlightEnd + "(ctx)\nreturn nil"
+	got := truncateFragment(fragment, 8)
+	want := "func " + highlightStart + "Au…" + highlightEnd + "\nreturn n…"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestReadHandler_FormatUnits(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "large.txt", strings.Repeat("x", 2000))
+
+	handler := NewReadHandler(&mockReadService{ready: true, repoDir: repoDir, maxFileSize: 1000})
+	ctx := context.WithValue(context.Background(), formatKey{}, FormatOptions{Units: UnitsBytes})
+	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, ReadArgument{
+		Repository: "github.com/test/repo",
+		Path:       "large.txt",
+	})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if text := ExtractTextContent(result); !strings.Contains(text, "File too large (2000 bytes). Maximum allowed size is 1000 bytes") {
+		t.Errorf("Expected sizes in bytes, got: %s", text)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{-time.Minute, "just now"},
+		{30 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{59 * time.Minute, "59 minutes ago"},
+		{90 * time.Minute, "1 hour ago"},
+		{5 * time.Hour, "5 hours ago"},
+		{49 * time.Hour, "2 days ago"},
+	}
+	for _, tt := range tests {
+		if got := FormatAge(tt.age); got != tt.want {
+			t.Errorf("FormatAge(%s) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	at := time.Date(2026, 1, 2, 5, 4, 5, 0, time.FixedZone("EET", 2*60*60))
+	if got := FormatTime(at, at.Add(3*time.Hour)); got != "2026-01-02T03:04:05Z (3 hours ago)" {
+		t.Errorf("Unexpected time: %q", got)
+	}
+}
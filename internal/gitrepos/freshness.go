@@ -0,0 +1,82 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// freshnessTimeout bounds the remote HEAD checks of a search with
+// require_fresh, so that an unreachable remote delays the search only briefly.
+const freshnessTimeout = 3 * time.Second
+
+// RepoFreshness compares the indexed commit of a repository with the HEAD of
+// its remote.
+type RepoFreshness struct {
+	RepoID  string
+	Indexed string // commit of the index, "" if not indexed
+	Remote  string // HEAD commit of the remote, "" if the check failed
+	Err     error  // why the remote could not be checked
+}
+
+// Stale reports whether the remote has commits the index lacks.
+func (f RepoFreshness) Stale() bool {
+	return f.Err == nil && f.Remote != f.Indexed
+}
+
+// CheckFreshness checks the remotes of the configured repositories whose name
+// contains repository, as the search filter matches them, in parallel and
+// within freshnessTimeout. Nothing is fetched or indexed. The working
+// directory of local (--cwd) mode has no remote and is never reported.
+func (s *Service) CheckFreshness(ctx context.Context, repository string) []RepoFreshness {
+	settings := s.currentSettings()
+	if settings.LocalDir != "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, freshnessTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var reports []RepoFreshness
+	sem := make(chan struct{}, MaxParallelSyncs)
+	for _, url := range settings.URLs {
+		repoID := URLToRepoID(url)
+		if !strings.Contains(RepoIDToDisplay(repoID), repository) {
+			continue
+		}
+		wg.Add(1)
+		go func(url, repoID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			report := RepoFreshness{RepoID: repoID, Indexed: s.manifest.GetRepoState(repoID).LastIndexed}
+			report.Remote, report.Err = s.git.RemoteHead(ctx, url)
+			if report.Err != nil && ctx.Err() != nil {
+				report.Err = fmt.Errorf("no answer within %s", freshnessTimeout)
+			}
+			mu.Lock()
+			reports = append(reports, report)
+			mu.Unlock()
+		}(url, repoID)
+	}
+	wg.Wait()
+
+	slices.SortFunc(reports, func(a, b RepoFreshness) int {
+		return strings.Compare(a.RepoID, b.RepoID)
+	})
+	return reports
+}
+
+// shortCommit abbreviates a commit SHA for display.
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,122 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestService_CheckFreshness(t *testing.T) {
+	manifest := newMockManifestOps()
+	manifest.SetRepoState("github.com_org_api", RepoState{LastIndexed: "aaa111"})
+	manifest.SetRepoState("github.com_org_web", RepoState{LastIndexed: "bbb222"})
+	git := &mockGitOps{
+		remoteHeads: map[string]string{
+			"git@github.com:org/api.git": "aaa111",
+			"git@github.com:org/web.git": "ccc333",
+		},
+		lsRemoteErrs: map[string]error{"git@github.com:org/apps.git": errors.New("permission denied")},
+	}
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:org/web.git", "git@github.com:org/api.git", "git@github.com:org/apps.git", "git@github.com:other/lib.git"},
+		},
+		ServiceDeps{Git: git, Indexer: &mockIndexOps{}, Manifest: manifest, Lock: &mockSyncLock{}},
+	)
+
+	reports := svc.CheckFreshness(context.Background(), "github.com/org/")
+	if len(reports) != 3 {
+		t.Fatalf("Expected the repositories matching the filter, got %+v", reports)
+	}
+	api, apps, web := reports[0], reports[1], reports[2]
+	if api.RepoID != "github.com_org_api" || api.Stale() {
+		t.Errorf("Expected api to be fresh, got %+v", api)
+	}
+	if apps.Err == nil || apps.Stale() {
+		t.Errorf("Expected the failed check of apps to be reported, got %+v", apps)
+	}
+	if !web.Stale() || web.Indexed != "bbb222" || web.Remote != "ccc333" {
+		t.Errorf("Expected web to be stale, got %+v", web)
+	}
+}
+
+func TestService_CheckFreshness_LocalDir(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{BaseDir: t.TempDir(), LocalDir: t.TempDir()},
+		ServiceDeps{Git: &mockGitOps{}, Indexer: &mockIndexOps{}, Manifest: newMockManifestOps(), Lock: &mockSyncLock{}},
+	)
+	if reports := svc.CheckFreshness(context.Background(), "local/"); len(reports) != 0 {
+		t.Errorf("Expected no remote checks in local mode, got %+v", reports)
+	}
+}
+
+func TestSearchHandler_RequireFresh(t *testing.T) {
+	svc := setupSearchService(t, t.TempDir(), map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(&freshnessSearchService{Service: svc, reports: []RepoFreshness{
+		{RepoID: "github.com_test_repo", Indexed: "0123456789abcdef", Remote: "fedcba9876543210"},
+	}})
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "main", Repository: "test/repo", RequireFresh: true})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+	if !strings.HasPrefix(text, "_Warning: the index of github.com/test/repo is stale: indexed 0123456789ab, remote HEAD is fedcba987654.") {
+		t.Errorf("Expected a staleness warning, got: %s", text)
+	}
+	if !strings.Contains(text, "main.go") {
+		t.Errorf("Expected the search results after the warning, got: %s", text)
+	}
+}
+
+func TestSearchHandler_RequireFreshNeedsRepository(t *testing.T) {
+	handler := NewSearchHandler(&mockSearchService{ready: true})
+	for _, args := range []SearchArgument{
+		{Query: "main", RequireFresh: true},
+		{Query: "main", Repository: "test/repo", Ref: "v1.0.0", RequireFresh: true},
+	} {
+		result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, args)
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		if !result.IsError || !strings.Contains(ExtractTextContent(result), "require_fresh needs a repository filter") {
+			t.Errorf("Expected an argument error for %+v, got: %s", args, ExtractTextContent(result))
+		}
+	}
+}
+
+func TestFreshnessNotice(t *testing.T) {
+	tests := []struct {
+		name   string
+		report RepoFreshness
+		want   string
+	}{
+		{"fresh", RepoFreshness{RepoID: "github.com_org_api", Indexed: "abc", Remote: "abc"}, "_github.com/org/api is up to date with its remote (abc)_"},
+		{"not indexed", RepoFreshness{RepoID: "github.com_org_api", Remote: "abc"}, "_Warning: github.com/org/api is not indexed yet; remote HEAD is abc_"},
+		{"failed", RepoFreshness{RepoID: "github.com_org_api", Err: errors.New("no answer within 3s")}, "_Could not check whether github.com/org/api is up to date: no answer within 3s_"},
+	}
+	for _, tt := range tests {
+		if got := freshnessNotice([]RepoFreshness{tt.report}); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+	if got := freshnessNotice(nil); !strings.Contains(got, "freshness not checked") {
+		t.Errorf("Expected a notice when nothing was checked, got %q", got)
+	}
+}
+
+// freshnessSearchService reports fixed freshness checks over a real service.
+type freshnessSearchService struct {
+	*Service
+	reports []RepoFreshness
+}
+
+func (s *freshnessSearchService) CheckFreshness(_ context.Context, _ string) []RepoFreshness {
+	return s.reports
+}
@@ -0,0 +1,41 @@
+package gitrepos
+
+import (
+	"bytes"
+	"strings"
+)
+
+// generatedHeaderBytes is how far into a file generated-code markers are
+// looked for; generators put them in the leading comment.
+const generatedHeaderBytes = 1024
+
+// generatedMarkers are header comments written by code generators, e.g. the
+// Go convention "Code generated by stringer; DO NOT EDIT." or "@generated".
+var generatedMarkers = [][]byte{
+	[]byte("Code generated"),
+	[]byte("DO NOT EDIT"),
+	[]byte("@generated"),
+	[]byte("<auto-generated"),
+	[]byte("This file was automatically generated"),
+}
+
+// IsGenerated reports whether content carries a generated-code marker near
+// its start.
+func IsGenerated(content []byte) bool {
+	head := content[:min(len(content), generatedHeaderBytes)]
+	for _, marker := range generatedMarkers {
+		if bytes.Contains(head, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedMarkerList formats the markers for documentation.
+func generatedMarkerList() string {
+	quoted := make([]string, len(generatedMarkers))
+	for i, marker := range generatedMarkers {
+		quoted[i] = "`" + string(marker) + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,28 @@
+package gitrepos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"go generator header", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pb\n", true},
+		{"generated annotation", "/**\n * @generated\n */\nexport const x = 1;\n", true},
+		{"dotnet header", "// <auto-generated>\n// This code was generated by a tool.\n", true},
+		{"hand written", "package main\n\nfunc main() {}\n", false},
+		{"marker beyond header", strings.Repeat("x", generatedHeaderBytes) + "\n// Code generated\n", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsGenerated([]byte(tt.content)); got != tt.want {
+				t.Errorf("IsGenerated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
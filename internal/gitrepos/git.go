@@ -3,11 +3,42 @@ package gitrepos
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
+var (
+	// ErrCommandTimeout indicates a command ran longer than its timeout.
+	ErrCommandTimeout = errors.New("command timed out")
+
+	// ErrOutputTooLarge indicates a command wrote more output than allowed.
+	ErrOutputTooLarge = errors.New("command output too large")
+
+	// ErrCommitUnavailable indicates a commit to diff against is no longer in
+	// the clone, e.g. after a force push and a shallow fetch.
+	ErrCommitUnavailable = errors.New("commit not available")
+
+	// ErrNoDefaultBranch indicates the remote HEAD does not point to a
+	// branch: the remote is empty, or its HEAD names a missing branch.
+	ErrNoDefaultBranch = errors.New("the remote HEAD does not point to a branch")
+)
+
+// httpsTokenUser is the user name sent with HTTPS tokens configured without
+// one. GitHub expects it; GitLab, Bitbucket and Azure DevOps accept any name.
+const httpsTokenUser = "x-access-token"
+
+// commandWaitDelay bounds how long a killed command may keep its output
+// pipes open, e.g. through an ssh child process.
+const commandWaitDelay = 5 * time.Second
+
 // CommandExecutor abstracts command execution for testing.
 type CommandExecutor interface {
 	// Run executes a command and returns its combined output.
@@ -15,34 +46,185 @@ type CommandExecutor interface {
 }
 
 // DefaultExecutor executes commands using os/exec.
-type DefaultExecutor struct{}
+type DefaultExecutor struct {
+	Timeout   time.Duration // per command, on top of the caller's deadline (0 = none)
+	MaxOutput int64         // bytes kept from each of stdout and stderr (0 = unlimited)
+	Sandbox   Sandbox       // environment and resource restrictions (zero = none)
+	HTTPProxy string        // proxy of git's HTTP(S) transfers, e.g. a FetchThrottle (empty = git's own)
+}
 
-// Run executes a command and returns its combined output.
+// Run executes a command and returns its standard output. Output beyond
+// MaxOutput is discarded; for stdout this fails the command.
 func (e *DefaultExecutor) Run(ctx context.Context, dir string, name string, args ...string) ([]byte, error) {
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	var gitConfig [][2]string
+	if name == "git" {
+		gitConfig = gitConfigFrom(ctx)
+	}
+	if name == "git" && e.HTTPProxy != "" {
+		args = append([]string{"-c", "http.proxy=" + e.HTTPProxy}, args...)
+	}
+	name, args = e.Sandbox.command(name, args)
 	cmd := exec.CommandContext(ctx, name, args...)
 	if dir != "" {
 		cmd.Dir = dir
 	}
+	cmd.Env = e.Sandbox.env()
+	if e.HTTPProxy != "" {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		// Hosts in NO_PROXY would bypass the proxy; it applies the
+		// environment's proxy settings itself
+		cmd.Env = append(cmd.Env, "NO_PROXY=", "no_proxy=")
+	}
+	if len(gitConfig) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = appendGitConfig(cmd.Env, gitConfig)
+	}
+	cmd.WaitDelay = commandWaitDelay
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout := &limitedBuffer{limit: e.MaxOutput}
+	stderr := &limitedBuffer{limit: e.MaxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	err := cmd.Run()
 	if err != nil {
+		if e.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %s: %w", ErrCommandTimeout, e.Timeout, err)
+		}
 		// Include stderr in error message for debugging
-		if stderr.Len() > 0 {
-			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		if stderr.buf.Len() > 0 {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.buf.String()))
 		}
 		return nil, err
 	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrOutputTooLarge, e.MaxOutput)
+	}
+
+	return stdout.buf.Bytes(), nil
+}
+
+// gitConfigKey is the context key of the git config of the commands run with
+// the context.
+type gitConfigKey struct{}
 
-	return stdout.Bytes(), nil
+// withGitConfig returns a context whose git commands get config through
+// their environment. Unlike arguments, the environment of a process cannot
+// be read by other local users.
+func withGitConfig(ctx context.Context, config [][2]string) context.Context {
+	return context.WithValue(ctx, gitConfigKey{}, config)
+}
+
+// gitConfigFrom returns the git config set by withGitConfig, if any.
+func gitConfigFrom(ctx context.Context) [][2]string {
+	config, _ := ctx.Value(gitConfigKey{}).([][2]string)
+	return config
+}
+
+// appendGitConfig appends config to env as GIT_CONFIG_KEY_n and
+// GIT_CONFIG_VALUE_n variables, after the entries env already declares.
+func appendGitConfig(env []string, config [][2]string) []string {
+	count := 0
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "GIT_CONFIG_COUNT="); ok {
+			count, _ = strconv.Atoi(value)
+		}
+	}
+	for i, kv := range config {
+		n := strconv.Itoa(count + i)
+		env = append(env, "GIT_CONFIG_KEY_"+n+"="+kv[0], "GIT_CONFIG_VALUE_"+n+"="+kv[1])
+	}
+	return append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(count+len(config)))
+}
+
+// limitedBuffer keeps up to limit bytes written to it and silently drops the
+// rest, so that a command is never blocked on a full pipe. The buffer is a
+// named field so that io.Copy cannot bypass Write through ReadFrom.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int64 // 0 = unlimited
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit <= 0 {
+		return b.buf.Write(p)
+	}
+	if room := b.limit - int64(b.buf.Len()); int64(len(p)) > room {
+		b.truncated = true
+		b.buf.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
 }
 
 // GitClient executes git commands.
 type GitClient struct {
-	executor CommandExecutor
+	executor   CommandExecutor
+	hideURLs   bool
+	authConfig [][2]string // git config sending the HTTPS tokens, passed to remote commands
+}
+
+// gitError is a git command failure whose message has been sanitized. The
+// original error remains available to errors.Is and errors.As.
+type gitError struct {
+	msg string
+	err error
+}
+
+func (e *gitError) Error() string { return e.msg }
+func (e *gitError) Unwrap() error { return e.err }
+
+// SetURLLogging controls whether repository URLs may appear in errors.
+// Credentials embedded in URLs are always removed.
+func (g *GitClient) SetURLLogging(enabled bool) {
+	g.hideURLs = !enabled
+}
+
+// SetHTTPSTokens sets the credentials of HTTPS remotes by host name (e.g.
+// github.com), as "user:token" or as a token alone, sent with the user
+// x-access-token. They are passed to the commands that contact a remote as
+// an Authorization header in their environment, and never appear in their
+// arguments or in the clones.
+func (g *GitClient) SetHTTPSTokens(tokens map[string]string) {
+	g.authConfig = nil
+	for _, host := range slices.Sorted(maps.Keys(tokens)) {
+		credentials := tokens[host]
+		if !strings.Contains(credentials, ":") {
+			credentials = httpsTokenUser + ":" + credentials
+		}
+		header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+		g.authConfig = append(g.authConfig, [2]string{"http.https://" + host + "/.extraHeader", header})
+	}
+}
+
+// remote returns the context of a git command that contacts a remote, with
+// the HTTPS credentials.
+func (g *GitClient) remote(ctx context.Context) context.Context {
+	if len(g.authConfig) == 0 {
+		return ctx
+	}
+	return withGitConfig(ctx, g.authConfig)
+}
+
+// wrapError prefixes a command failure with what failed, removing credentials
+// (and, if URL logging is disabled, URLs) that git echoes in its output.
+func (g *GitClient) wrapError(what string, err error) error {
+	msg := RedactURLCredentials(fmt.Sprintf("%s: %v", what, err))
+	if g.hideURLs {
+		msg = HideURLs(msg)
+	}
+	return &gitError{msg: msg, err: err}
 }
 
 // NewGitClient creates a new GitClient with the default command executor.
@@ -59,37 +241,77 @@ func NewGitClientWithExecutor(executor CommandExecutor) *GitClient {
 	}
 }
 
-// Clone performs a shallow clone of the repository.
-// Uses --depth 1 and --single-branch for efficiency.
-func (g *GitClient) Clone(ctx context.Context, url, destDir string) error {
-	_, err := g.executor.Run(ctx, "", "git", "clone",
+// Clone clones a single branch of the repository, keeping the last depth
+// commits of its history, or all of it when depth is 0. An empty branch
+// clones the branch the remote HEAD points to.
+func (g *GitClient) Clone(ctx context.Context, url, destDir, branch string, depth int) error {
+	args := []string{"clone"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	args = append(args, "--single-branch")
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, url, destDir)
+	_, err := g.executor.Run(g.remote(ctx), "", "git", args...)
+	if err != nil {
+		return g.wrapError("git clone failed", err)
+	}
+	return nil
+}
+
+// CloneRef performs a shallow clone of a single tag or branch.
+func (g *GitClient) CloneRef(ctx context.Context, url, ref, destDir string) error {
+	_, err := g.executor.Run(g.remote(ctx), "", "git", "clone",
 		"--depth", "1",
 		"--single-branch",
+		"--branch", ref,
 		url,
 		destDir,
 	)
 	if err != nil {
-		return fmt.Errorf("git clone failed: %w", err)
+		return g.wrapError("git clone failed", err)
 	}
 	return nil
 }
 
-// Fetch fetches the latest changes from the remote.
-// Uses --depth 1 to maintain shallow clone.
-func (g *GitClient) Fetch(ctx context.Context, repoDir string) error {
-	_, err := g.executor.Run(ctx, repoDir, "git", "fetch", "--depth", "1")
+// Fetch fetches the latest changes of the cloned branch, or of branch when
+// set, keeping the last depth commits of history. When depth is 0, a shallow
+// clone is unshallowed to the full history.
+func (g *GitClient) Fetch(ctx context.Context, repoDir, branch string, depth int) error {
+	args := []string{"fetch"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	} else {
+		output, err := g.executor.Run(ctx, repoDir, "git", "rev-parse", "--is-shallow-repository")
+		if err != nil {
+			return g.wrapError("git rev-parse failed", err)
+		}
+		if strings.TrimSpace(string(output)) == "true" {
+			args = append(args, "--unshallow")
+		}
+	}
+	if branch != "" {
+		args = append(args, "origin", "+refs/heads/"+branch+":refs/remotes/origin/"+branch)
+	}
+	_, err := g.executor.Run(g.remote(ctx), repoDir, "git", args...)
 	if err != nil {
-		return fmt.Errorf("git fetch failed: %w", err)
+		return g.wrapError("git fetch failed", err)
 	}
 	return nil
 }
 
-// Reset performs a hard reset to origin/HEAD.
-// This updates the working directory to match the remote.
-func (g *GitClient) Reset(ctx context.Context, repoDir string) error {
-	_, err := g.executor.Run(ctx, repoDir, "git", "reset", "--hard", "origin/HEAD")
+// Reset performs a hard reset to the fetched branch, or to origin/HEAD when
+// branch is empty. This updates the working directory to match the remote.
+func (g *GitClient) Reset(ctx context.Context, repoDir, branch string) error {
+	target := "origin/HEAD"
+	if branch != "" {
+		target = "origin/" + branch
+	}
+	_, err := g.executor.Run(ctx, repoDir, "git", "reset", "--hard", target)
 	if err != nil {
-		return fmt.Errorf("git reset failed: %w", err)
+		return g.wrapError("git reset failed", err)
 	}
 	return nil
 }
@@ -98,60 +320,204 @@ func (g *GitClient) Reset(ctx context.Context, repoDir string) error {
 func (g *GitClient) GetHeadCommit(ctx context.Context, repoDir string) (string, error) {
 	output, err := g.executor.Run(ctx, repoDir, "git", "rev-parse", "HEAD")
 	if err != nil {
-		return "", fmt.Errorf("git rev-parse failed: %w", err)
+		return "", g.wrapError("git rev-parse failed", err)
 	}
 	return strings.TrimSpace(string(output)), nil
 }
 
+// Version returns the installed git version, e.g. "2.43.0".
+func (g *GitClient) Version(ctx context.Context) (string, error) {
+	output, err := g.executor.Run(ctx, "", "git", "--version")
+	if err != nil {
+		return "", g.wrapError("git --version failed", err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "git version "), nil
+}
+
+// LsRemote lists the HEAD of a remote repository, which checks that it is
+// reachable with the configured credentials without fetching anything.
+func (g *GitClient) LsRemote(ctx context.Context, url string) error {
+	_, err := g.executor.Run(g.remote(ctx), "", "git", "ls-remote", "--quiet", url, "HEAD")
+	if err != nil {
+		return g.wrapError("git ls-remote failed", err)
+	}
+	return nil
+}
+
+// RemoteHead returns the commit at the HEAD of a remote repository, without
+// fetching anything.
+func (g *GitClient) RemoteHead(ctx context.Context, url string) (string, error) {
+	output, err := g.executor.Run(g.remote(ctx), "", "git", "ls-remote", "--quiet", url, "HEAD")
+	if err != nil {
+		return "", g.wrapError("git ls-remote failed", err)
+	}
+	commit, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\t")
+	if commit == "" {
+		return "", errors.New("git ls-remote returned no HEAD")
+	}
+	return commit, nil
+}
+
+// CommitChanges lists the files a commit modified or deleted.
+type CommitChanges struct {
+	Commit   string
+	Time     time.Time
+	Subject  string
+	Modified []string
+	Deleted  []string
+}
+
+// RecentChanges returns the files modified or deleted by each of the last
+// count commits, newest first. Renames count as a deletion and an addition.
+// Paths are returned unquoted; names with tabs or newlines are still quoted.
+func (g *GitClient) RecentChanges(ctx context.Context, repoDir string, count int) ([]CommitChanges, error) {
+	output, err := g.executor.Run(ctx, repoDir, "git", "-c", "core.quotePath=false", "log",
+		"-n", strconv.Itoa(count),
+		"--no-renames",
+		"--diff-filter=DM",
+		"--name-status",
+		"--format=%x00%H%x09%ct%x09%s",
+	)
+	if err != nil {
+		return nil, g.wrapError("git log failed", err)
+	}
+	return parseRecentChanges(string(output)), nil
+}
+
+// parseRecentChanges parses the output of RecentChanges' git log: a NUL
+// before each commit line "sha<TAB>unix time<TAB>subject", followed by
+// "status<TAB>path" lines.
+func parseRecentChanges(output string) []CommitChanges {
+	var changes []CommitChanges
+	for _, record := range strings.Split(output, "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		header := strings.SplitN(lines[0], "\t", 3)
+		if len(header) < 2 {
+			continue
+		}
+		change := CommitChanges{Commit: header[0]}
+		if seconds, err := strconv.ParseInt(header[1], 10, 64); err == nil {
+			change.Time = time.Unix(seconds, 0).UTC()
+		}
+		if len(header) == 3 {
+			change.Subject = header[2]
+		}
+		for _, line := range lines[1:] {
+			status, path, ok := strings.Cut(line, "\t")
+			if !ok {
+				continue
+			}
+			switch status {
+			case "M":
+				change.Modified = append(change.Modified, path)
+			case "D":
+				change.Deleted = append(change.Deleted, path)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// ShowFile returns the content of a file at a revision, e.g. "abc123^".
+func (g *GitClient) ShowFile(ctx context.Context, repoDir, rev, path string) ([]byte, error) {
+	output, err := g.executor.Run(ctx, repoDir, "git", "show", rev+":"+path)
+	if err != nil {
+		return nil, g.wrapError("git show failed", err)
+	}
+	return output, nil
+}
+
 // GetChangedFiles returns the list of files changed between two commits.
 // Returns file paths relative to the repository root.
+//
+// The trees of the two commits are compared directly, rather than from their
+// merge base: after a rebase or force push, files changed only by the
+// dropped commits differ too, and no history between the commits is needed,
+// which shallow clones lack. Renamed files are reported under both their old
+// and new paths, so that the old path is removed from the index. If
+// fromCommit is no longer in the clone, an ErrCommitUnavailable error is
+// returned and the caller should reindex fully.
 func (g *GitClient) GetChangedFiles(ctx context.Context, repoDir, fromCommit, toCommit string) ([]string, error) {
+	if _, err := g.executor.Run(ctx, repoDir, "git", "rev-parse", "--verify", "--quiet", fromCommit+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCommitUnavailable, fromCommit)
+	}
+
 	output, err := g.executor.Run(ctx, repoDir, "git", "diff",
-		"--name-only",
-		fromCommit+".."+toCommit,
+		"--name-status",
+		"--find-renames",
+		"--no-ext-diff",
+		"-z",
+		fromCommit,
+		toCommit,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("git diff failed: %w", err)
+		return nil, g.wrapError("git diff failed", err)
 	}
+	return parseNameStatus(output), nil
+}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-
-	// Filter empty lines
+// parseNameStatus returns the paths of git diff --name-status -z output,
+// without duplicates: both paths of renames, and the new path of copies.
+func parseNameStatus(output []byte) []string {
+	fields := strings.Split(string(output), "\x00")
 	var files []string
-	for _, line := range lines {
-		if line != "" {
-			files = append(files, line)
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
 		}
 	}
-
-	return files, nil
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+		switch status[0] {
+		case 'R':
+			if i+2 < len(fields) {
+				add(fields[i+1])
+				add(fields[i+2])
+			}
+			i += 2
+		case 'C':
+			if i+2 < len(fields) {
+				add(fields[i+2])
+			}
+			i += 2
+		default:
+			if i+1 < len(fields) {
+				add(fields[i+1])
+			}
+			i++
+		}
+	}
+	return files
 }
 
-// GetDefaultBranch returns the default branch name (e.g., "main" or "master").
+// GetDefaultBranch returns the branch the remote HEAD points to, e.g.
+// "main" or "trunk", or the checked out branch when the remote HEAD is not
+// known, as in clones of a given branch. It fails when neither is a branch.
 func (g *GitClient) GetDefaultBranch(ctx context.Context, repoDir string) (string, error) {
-	// Try to get the default branch from remote HEAD
-	output, err := g.executor.Run(ctx, repoDir, "git", "symbolic-ref", "refs/remotes/origin/HEAD")
+	output, err := g.executor.Run(ctx, repoDir, "git", "symbolic-ref", "--quiet", "refs/remotes/origin/HEAD")
 	if err == nil {
-		// Output is like "refs/remotes/origin/main"
-		ref := strings.TrimSpace(string(output))
-		parts := strings.Split(ref, "/")
-		if len(parts) > 0 {
-			return parts[len(parts)-1], nil
+		// Output is like "refs/remotes/origin/main"; branch names may
+		// contain slashes
+		if branch, ok := strings.CutPrefix(strings.TrimSpace(string(output)), "refs/remotes/origin/"); ok && branch != "" {
+			return branch, nil
 		}
 	}
 
-	// Fallback: check if main exists, then master
-	_, err = g.executor.Run(ctx, repoDir, "git", "rev-parse", "--verify", "origin/main")
-	if err == nil {
-		return "main", nil
-	}
-
-	_, err = g.executor.Run(ctx, repoDir, "git", "rev-parse", "--verify", "origin/master")
+	// Fails when nothing is checked out, and prints HEAD when detached
+	output, err = g.executor.Run(ctx, repoDir, "git", "rev-parse", "--abbrev-ref", "HEAD")
 	if err == nil {
-		return "master", nil
+		if branch := strings.TrimSpace(string(output)); branch != "" && branch != "HEAD" {
+			return branch, nil
+		}
 	}
 
-	return "", fmt.Errorf("could not determine default branch")
+	return "", ErrNoDefaultBranch
 }
 
 // IsGitRepository checks if the given directory is a git repository.
@@ -164,7 +530,7 @@ func (g *GitClient) IsGitRepository(ctx context.Context, dir string) bool {
 func (g *GitClient) Clean(ctx context.Context, repoDir string) error {
 	_, err := g.executor.Run(ctx, repoDir, "git", "clean", "-fdx")
 	if err != nil {
-		return fmt.Errorf("git clean failed: %w", err)
+		return g.wrapError("git clean failed", err)
 	}
 	return nil
 }
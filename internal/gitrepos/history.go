@@ -0,0 +1,222 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// historyRef is the ref part of the ID of history indexes. Git ref names
+// cannot contain "~", so it never names a ref snapshot.
+const historyRef = "~history"
+
+// historyMaxFiles caps the file versions written to the history index of a
+// repository, newest commits first.
+const historyMaxFiles = 5000
+
+// HistorySnapshot records the history index of a repository: the previous
+// versions of the files changed or deleted by its recent commits.
+type HistorySnapshot struct {
+	Head      string          `json:"head"`    // commit the history was taken at
+	Depth     int             `json:"depth"`   // commits requested
+	Commits   []HistoryCommit `json:"commits"` // commits with indexed files, newest first
+	IndexedAt time.Time       `json:"indexed_at"`
+	FileCount int             `json:"file_count"`
+	// IndexVersion is the IndexMappingVersion the index was built with
+	IndexVersion int `json:"index_version,omitempty"`
+}
+
+// HistoryCommit describes a commit whose changes are in a history index.
+type HistoryCommit struct {
+	SHA     string    `json:"sha"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	// Modified are the files the commit modified that pass the file filter,
+	// which the recent-first ranking profile prefers
+	Modified []string `json:"modified,omitempty"`
+}
+
+// HistoryID returns the ID under which the history of a repository is
+// written and indexed, e.g. github.com_org_repo@~history. Files are stored
+// under the abbreviated SHA of the commit that changed or deleted them.
+func HistoryID(repoID string) string {
+	return repoID + refSnapshotSeparator + historyRef
+}
+
+// syncHistory rebuilds the history index of a repository when its HEAD,
+// the configured depth or the index mapping changed, and removes it when
+// history is disabled. Failures are logged; the history is best effort.
+func (s *Service) syncHistory(ctx context.Context, repoID string) {
+	depth := s.currentSettings().HistoryCommits
+	state := s.manifest.GetRepoState(repoID)
+	id := HistoryID(repoID)
+
+	if depth == 0 {
+		if state.History != nil {
+			slog.Info("Removing history index", "repo_id", repoID)
+			s.removeRefSnapshot(id)
+			state.History = nil
+			s.manifest.SetRepoState(repoID, *state)
+		}
+		return
+	}
+	if h := state.History; h != nil && h.Head == state.LastCommit && h.Depth == depth && h.IndexVersion == IndexMappingVersion && s.indexer.IndexExists(id) {
+		return
+	}
+
+	history, err := s.takeHistory(ctx, repoID, depth)
+	if err != nil {
+		slog.Warn("Failed to index history", "repo_id", repoID, "error", err)
+		return
+	}
+	slog.Info("Indexed history", "repo_id", repoID, "commits", len(history.Commits), "files", history.FileCount)
+
+	state = s.manifest.GetRepoState(repoID)
+	state.History = history
+	s.manifest.SetRepoState(repoID, *state)
+}
+
+// takeHistory writes the versions of the files each commit changed or
+// deleted, as they were before the commit, and rebuilds the history index
+// over them.
+func (s *Service) takeHistory(ctx context.Context, repoID string, depth int) (*HistorySnapshot, error) {
+	repoDir := s.GetRepoDir(repoID)
+	head, err := s.git.GetHeadCommit(ctx, repoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	changes, err := s.git.RecentChanges(ctx, repoDir, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	id := HistoryID(repoID)
+	dir := s.GetRepoDir(id)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove previous history: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	filter := newFileFilter(s.currentSettings())
+	history := &HistorySnapshot{Head: head, Depth: depth, IndexVersion: IndexMappingVersion}
+	written := 0
+	for _, change := range changes {
+		if written == historyMaxFiles {
+			slog.Warn("History file limit reached, older commits are left out", "repo_id", repoID, "limit", historyMaxFiles)
+			break
+		}
+		files := 0
+		for _, path := range append(change.Deleted, change.Modified...) {
+			if written == historyMaxFiles {
+				break
+			}
+			if filter.ShouldExclude(path) || filter.ShouldExcludeDir(filepath.Dir(path)) {
+				continue
+			}
+			content, err := s.git.ShowFile(ctx, repoDir, change.Commit+"^", path)
+			if err != nil {
+				slog.Debug("Failed to read previous file version", "repo_id", repoID, "commit", change.Commit, "path", path, "error", err)
+				continue
+			}
+			dest := filepath.Join(dir, shortCommit(change.Commit), filepath.FromSlash(path))
+			if !strings.HasPrefix(dest, dir+string(filepath.Separator)) {
+				continue // git never reports such paths
+			}
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return nil, fmt.Errorf("failed to write history: %w", err)
+			}
+			if err := os.WriteFile(dest, content, 0644); err != nil {
+				return nil, fmt.Errorf("failed to write history: %w", err)
+			}
+			files++
+			written++
+		}
+		if files > 0 {
+			var modified []string
+			for _, path := range change.Modified {
+				if !filter.ShouldExclude(path) && !filter.ShouldExcludeDir(filepath.Dir(path)) {
+					modified = append(modified, path)
+				}
+			}
+			history.Commits = append(history.Commits, HistoryCommit{SHA: change.Commit, Time: change.Time, Subject: change.Subject, Modified: modified})
+		}
+	}
+
+	if err := s.indexer.DeleteIndex(id); err != nil {
+		return nil, fmt.Errorf("failed to delete index: %w", err)
+	}
+	count, err := s.indexer.FullIndex(id, dir)
+	if err != nil && !errors.Is(err, ErrIndexBudgetExceeded) {
+		return nil, fmt.Errorf("index failed: %w", err)
+	}
+	history.FileCount = count
+	history.IndexedAt = time.Now().UTC()
+	return history, nil
+}
+
+// HistoryAlias returns an alias over the history indexes of all repositories
+// that have one. The caller must close it.
+func (s *Service) HistoryAlias() (bleve.IndexAlias, error) {
+	var ids []string
+	for _, repoID := range ConfiguredRepoIDs(s.currentSettings()) {
+		if s.manifest.GetRepoState(repoID).History != nil && s.indexer.IndexExists(HistoryID(repoID)) {
+			ids = append(ids, HistoryID(repoID))
+		}
+	}
+	if len(ids) == 0 {
+		if s.currentSettings().HistoryCommits == 0 {
+			return nil, errors.New("history is not indexed on this server (git-repos-history-commits is 0)")
+		}
+		return nil, errors.New("history is not indexed yet")
+	}
+	return s.indexer.CreateAlias(ids)
+}
+
+// RecentFiles returns the document IDs of the files modified by the commits
+// in the history indexes of the configured repositories.
+func (s *Service) RecentFiles() []string {
+	var ids []string
+	for _, repoID := range ConfiguredRepoIDs(s.currentSettings()) {
+		if !s.manifest.HasRepo(repoID) {
+			continue
+		}
+		history := s.manifest.GetRepoState(repoID).History
+		if history == nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, commit := range history.Commits {
+			for _, path := range commit.Modified {
+				if !seen[path] {
+					seen[path] = true
+					ids = append(ids, repoID+"/"+path)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// HistoryCommit returns the commit of a repository's history index whose
+// SHA starts with prefix.
+func (s *Service) HistoryCommit(repoID, prefix string) (HistoryCommit, bool) {
+	history := s.manifest.GetRepoState(repoID).History
+	if history == nil || prefix == "" {
+		return HistoryCommit{}, false
+	}
+	for _, commit := range history.Commits {
+		if strings.HasPrefix(commit.SHA, prefix) {
+			return commit, true
+		}
+	}
+	return HistoryCommit{}, false
+}
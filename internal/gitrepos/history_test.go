@@ -0,0 +1,150 @@
+package gitrepos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+const (
+	historyDeleteCommit = "1111111111111111111111111111111111111111"
+	historyChangeCommit = "2222222222222222222222222222222222222222"
+)
+
+// setupHistoryService initializes a service whose repository had old.go
+// and secrets/keys.go deleted and main.go changed by its last two commits.
+func setupHistoryService(t *testing.T, commits int) *Service {
+	t.Helper()
+	return setupHistoryServiceWith(t, commits, func(*config.GitReposSettings) {})
+}
+
+func setupHistoryServiceWith(t *testing.T, commits int, configure func(*config.GitReposSettings)) *Service {
+	t.Helper()
+	baseDir := t.TempDir()
+	settings := &config.GitReposSettings{
+		URLs:           []string{"git@github.com:test/repo.git"},
+		BaseDir:        baseDir,
+		SyncTimeout:    5 * time.Second,
+		MaxFileSize:    256 * 1024,
+		MaxResults:     20,
+		HistoryCommits: commits,
+	}
+	configure(settings)
+	svc, err := NewService(settings)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	svc.git = &mockGitOps{
+		headCommit: "abc123",
+		changes: []CommitChanges{
+			{Commit: historyDeleteCommit, Time: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), Subject: "Remove legacy retry", Deleted: []string{"old.go", "secrets/keys.go", "node_modules/lib/index.js"}},
+			{Commit: historyChangeCommit, Time: time.Date(2026, 9, 30, 12, 0, 0, 0, time.UTC), Subject: "Simplify main", Modified: []string{"main.go"}},
+		},
+		files: map[string]string{
+			historyDeleteCommit + "^:old.go":                    "package main\n\n// retryToken = token=s3cr3tvalue\nfunc LegacyRetry() {}\n",
+			historyDeleteCommit + "^:secrets/keys.go":           "package secrets\n\nconst retryToken = \"token=def456\"\n",
+			historyDeleteCommit + "^:node_modules/lib/index.js": "function LegacyRetry() {}\n",
+			historyChangeCommit + "^:main.go":                   "package main\n\nfunc main() { VerboseStartup() }\n",
+		},
+	}
+
+	repoDir := filepath.Join(baseDir, "repos", "github.com_test_repo")
+	writeTestFile(t, repoDir, "main.go", "package main\n\nfunc main() {}\n")
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	return svc
+}
+
+func searchHistory(t *testing.T, svc *Service, query string) *mcp.CallToolResult {
+	t.Helper()
+	result, _, err := NewSearchHistoryHandler(svc).Handle(context.Background(), &mcp.CallToolRequest{}, SearchHistoryArgument{Query: query})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	return result
+}
+
+func TestSearchHistory_DeletedFile(t *testing.T) {
+	svc := setupHistoryService(t, 10)
+
+	result := searchHistory(t, svc, "LegacyRetry")
+	text := ExtractTextContent(result)
+	if result.IsError {
+		t.Fatalf("Expected results, got error: %s", text)
+	}
+	if !strings.Contains(text, "**1. github.com/test/repo** `old.go` (deleted by 111111111111 on 2026-10-01: Remove legacy retry)") {
+		t.Errorf("Expected the deleting commit, got: %s", text)
+	}
+	if !strings.Contains(text, "`git show 111111111111^:old.go`") {
+		t.Errorf("Expected how to recover the file, got: %s", text)
+	}
+	if strings.Contains(text, "node_modules") {
+		t.Errorf("Expected excluded paths to be left out of the history, got: %s", text)
+	}
+}
+
+func TestSearchHistory_ChangedFile(t *testing.T) {
+	svc := setupHistoryService(t, 10)
+
+	text := ExtractTextContent(searchHistory(t, svc, "VerboseStartup"))
+	if !strings.Contains(text, "`main.go` (changed by 222222222222 on 2026-09-30: Simplify main)") {
+		t.Errorf("Expected the changing commit, got: %s", text)
+	}
+}
+
+func TestSearchHistory_AppliesReadPolicy(t *testing.T) {
+	svc := setupHistoryServiceWith(t, 10, func(settings *config.GitReposSettings) {
+		settings.ReadDenyPatterns = []string{"secrets/**"}
+		settings.ReadRedactPatterns = []string{`token=\w+`}
+	})
+
+	result := searchHistory(t, svc, "retryToken")
+	text := ExtractTextContent(result)
+	if result.IsError {
+		t.Fatalf("Expected results, got error: %s", text)
+	}
+	if !strings.Contains(text, "Found 1 results") || !strings.Contains(text, "`old.go`") || strings.Contains(text, "secrets/keys.go") {
+		t.Errorf("Expected only the allowed file, got: %s", text)
+	}
+	if strings.Contains(text, "s3cr3tvalue") || strings.Contains(text, "def456") {
+		t.Errorf("Expected redacted fragments, got: %s", text)
+	}
+}
+
+func TestSearchHistory_Disabled(t *testing.T) {
+	svc := setupHistoryService(t, 0)
+
+	result := searchHistory(t, svc, "LegacyRetry")
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "git-repos-history-commits is 0") {
+		t.Errorf("Expected a disabled error, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestService_SyncHistory_RecordsAndRemoves(t *testing.T) {
+	svc := setupHistoryService(t, 10)
+
+	history := svc.manifest.GetRepoState("github.com_test_repo").History
+	if history == nil || history.Depth != 10 || history.Head != "abc123" || len(history.Commits) != 2 {
+		t.Fatalf("Expected the history in the manifest, got %+v", history)
+	}
+	if commit, ok := svc.HistoryCommit("github.com_test_repo", "222222222222"); !ok || commit.Subject != "Simplify main" {
+		t.Errorf("Expected to find the commit by its abbreviated SHA, got %+v", commit)
+	}
+
+	svc.settings.HistoryCommits = 0
+	svc.syncHistory(context.Background(), "github.com_test_repo")
+	if svc.manifest.GetRepoState("github.com_test_repo").History != nil {
+		t.Error("Expected the history to be removed when disabled")
+	}
+	if _, err := os.Stat(svc.GetRepoDir(HistoryID("github.com_test_repo"))); !os.IsNotExist(err) {
+		t.Errorf("Expected the history files to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package gitrepos
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// indexPool shares open index handles between the search alias, writers and
+// one-off readers. An index can only be opened once per process (a second
+// open waits for the first to be closed), so each repository index is opened
+// at most once and closed when its last reference is released, which lets
+// sync processes and deletes take over the files.
+type indexPool struct {
+	mu      sync.Mutex
+	handles map[string]*pooledIndex // by repo ID
+}
+
+// pooledIndex is a shared index handle and the number of its references.
+type pooledIndex struct {
+	index bleve.Index
+	refs  int
+}
+
+func newIndexPool() *indexPool {
+	return &indexPool{handles: make(map[string]*pooledIndex)}
+}
+
+// acquire returns a reference to the index of repoID, opening it with open if
+// it is not open yet. Closing the returned index releases the reference.
+func (p *indexPool) acquire(repoID string, open func() (bleve.Index, error)) (bleve.Index, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handle, ok := p.handles[repoID]
+	if !ok {
+		index, err := open()
+		if err != nil {
+			return nil, err
+		}
+		handle = &pooledIndex{index: index}
+		p.handles[repoID] = handle
+	}
+	handle.refs++
+
+	return &indexRef{sharedIndex: handle.index, release: func() error { return p.release(repoID) }}, nil
+}
+
+// acquireOpen returns a reference to the index of repoID if it is open,
+// without opening it otherwise.
+func (p *indexPool) acquireOpen(repoID string) (*indexRef, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handle, ok := p.handles[repoID]
+	if !ok {
+		return nil, false
+	}
+	handle.refs++
+
+	return &indexRef{sharedIndex: handle.index, release: func() error { return p.release(repoID) }}, true
+}
+
+// release drops a reference to the index of repoID and closes it when no
+// references remain.
+func (p *indexPool) release(repoID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handle, ok := p.handles[repoID]
+	if !ok {
+		return nil
+	}
+	handle.refs--
+	if handle.refs > 0 {
+		return nil
+	}
+	delete(p.handles, repoID)
+	return handle.index.Close()
+}
+
+// inUse reports whether the index of repoID is open.
+func (p *indexPool) inUse(repoID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.handles[repoID]
+	return ok
+}
+
+// openCount returns the number of open indexes.
+func (p *indexPool) openCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.handles)
+}
+
+// sharedIndex names the embedded index of an indexRef; bleve.Index cannot be
+// embedded directly since it has an Index method.
+type sharedIndex = bleve.Index
+
+// indexRef is a reference to a pooled index. Close releases the reference
+// instead of closing the shared handle, and is safe to call more than once.
+type indexRef struct {
+	sharedIndex
+	once    sync.Once
+	release func() error
+}
+
+func (r *indexRef) Close() error {
+	var err error
+	r.once.Do(func() { err = r.release() })
+	return err
+}
+
+// pooledAlias is an alias over pooled index references. Closing an alias
+// leaves its indexes open, so Close also releases the references.
+type pooledAlias struct {
+	bleve.IndexAlias
+	indexes []bleve.Index
+}
+
+func (a *pooledAlias) Close() error {
+	err := a.IndexAlias.Close()
+	for _, index := range a.indexes {
+		if cerr := index.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close index %s: %w", index.Name(), cerr)
+		}
+	}
+	return err
+}
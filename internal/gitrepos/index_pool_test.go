@@ -0,0 +1,80 @@
+package gitrepos
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexPool_SharesHandles(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+
+	createTestFile(t, repoDir, "main.go", "package main")
+	if _, err := indexer.FullIndex("testrepo", repoDir); err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+	if n := indexer.handles.openCount(); n != 0 {
+		t.Fatalf("Expected no open indexes after indexing, got %d", n)
+	}
+
+	alias, err := indexer.CreateAlias([]string{"testrepo"})
+	if err != nil {
+		t.Fatalf("CreateAlias failed: %v", err)
+	}
+
+	// Readers and writers reuse the handle held by the alias instead of
+	// waiting for it to be closed
+	count, err := indexer.GetDocumentCount("testrepo")
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 document, got %d (%v)", count, err)
+	}
+	if _, err := indexer.IncrementalIndex("testrepo", repoDir, []string{"main.go"}); err != nil {
+		t.Errorf("IncrementalIndex failed: %v", err)
+	}
+	if n := indexer.handles.openCount(); n != 1 {
+		t.Errorf("Expected 1 open index, got %d", n)
+	}
+
+	if err := indexer.DeleteIndex("testrepo"); err == nil {
+		t.Error("Expected an open index not to be deleted")
+	}
+
+	if err := alias.Close(); err != nil {
+		t.Fatalf("Failed to close alias: %v", err)
+	}
+	if n := indexer.handles.openCount(); n != 0 {
+		t.Errorf("Expected closing the alias to close its indexes, got %d open", n)
+	}
+	if err := indexer.DeleteIndex("testrepo"); err != nil {
+		t.Errorf("DeleteIndex failed: %v", err)
+	}
+}
+
+func TestIndexRef_CloseTwice(t *testing.T) {
+	indexer := NewIndexer(t.TempDir(), NewFileFilter(256*1024), 256*1024)
+
+	first, err := indexer.OpenForWrite("testrepo")
+	if err != nil {
+		t.Fatalf("OpenForWrite failed: %v", err)
+	}
+	second, err := indexer.OpenForRead("testrepo")
+	if err != nil {
+		t.Fatalf("OpenForRead failed: %v", err)
+	}
+
+	// A repeated Close must not release the other reference
+	_ = first.Close()
+	_ = first.Close()
+	if n := indexer.handles.openCount(); n != 1 {
+		t.Errorf("Expected the index to stay open, got %d open", n)
+	}
+	if _, err := second.DocCount(); err != nil {
+		t.Errorf("Expected the remaining reference to be usable: %v", err)
+	}
+
+	_ = second.Close()
+	if n := indexer.handles.openCount(); n != 0 {
+		t.Errorf("Expected the index to be closed, got %d open", n)
+	}
+}
@@ -1,15 +1,23 @@
 package gitrepos
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/analysis/char/regexp"
+	"github.com/blevesearch/bleve/v2/analysis/token/camelcase"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/sha1n/mcp-relic-server/internal/domain"
 )
@@ -18,32 +26,122 @@ const (
 	// IndexSuffix is the suffix for index directories
 	IndexSuffix = ".bleve"
 
-	// MaxBatchSize is the maximum number of documents per batch
+	// MaxBatchSize is the default maximum number of documents per batch
 	MaxBatchSize = 100
 
-	// MaxBatchBytes is the maximum bytes per batch (10MB)
+	// MaxBatchBytes is the default maximum bytes per batch (10MB)
 	MaxBatchBytes = 10 * 1024 * 1024
+
+	// MaxParallelReads is the maximum number of files read concurrently
+	// during an incremental index
+	MaxParallelReads = 8
+
+	// IndexMappingVersion identifies the current index mapping. Repositories
+	// indexed with a different version are rebuilt on the next sync.
+	IndexMappingVersion = 8
+
+	// caseSensitiveAnalyzer tokenizes like the standard analyzer but keeps
+	// letter case and stop words
+	caseSensitiveAnalyzer = "case_sensitive"
+
+	// configKeyAnalyzer indexes each configuration key path as a single
+	// lowercase term
+	configKeyAnalyzer = "config_key"
+
+	// identifierPartsAnalyzer splits identifiers into lowercase words at
+	// case changes and underscores
+	identifierPartsAnalyzer = "identifier_parts"
+
+	// underscoreCharFilter replaces underscores with spaces ahead of
+	// tokenization
+	underscoreCharFilter = "underscore_to_space"
 )
 
+// ErrIndexBudgetExceeded is returned by FullIndex when a repository has more
+// content than its budget allows. The files indexed up to that point are
+// kept and searchable.
+var ErrIndexBudgetExceeded = errors.New("index budget exceeded")
+
 // Indexer manages Bleve indexes for repositories.
 type Indexer struct {
-	baseDir     string
+	indexesDir  string
 	filter      *FileFilter
 	maxFileSize int64
+	batchSize   int   // documents per batch
+	batchBytes  int64 // content bytes per batch
+	// declarations indexes the top-level declarations of source files as
+	// documents of their own
+	declarations bool
+
+	handles *indexPool
+
+	runMu   sync.Mutex
+	skips   map[string]*SkipStats      // by repo ID, from the last full index
+	catalog map[string]*CatalogChanges // by repo ID, from the last run
 }
 
-// NewIndexer creates a new indexer.
+// NewIndexer creates a new indexer keeping indexes under baseDir/indexes.
 func NewIndexer(baseDir string, filter *FileFilter, maxFileSize int64) *Indexer {
+	return NewIndexerWithIndexesDir(filepath.Join(baseDir, "indexes"), filter, maxFileSize)
+}
+
+// NewIndexerWithIndexesDir creates a new indexer keeping indexes in
+// indexesDir.
+func NewIndexerWithIndexesDir(indexesDir string, filter *FileFilter, maxFileSize int64) *Indexer {
 	return &Indexer{
-		baseDir:     baseDir,
+		indexesDir:  indexesDir,
 		filter:      filter,
 		maxFileSize: maxFileSize,
+		batchSize:   MaxBatchSize,
+		batchBytes:  MaxBatchBytes,
+		handles:     newIndexPool(),
+		skips:       make(map[string]*SkipStats),
+		catalog:     make(map[string]*CatalogChanges),
 	}
 }
 
+// SetFilter replaces the file filter used for subsequent indexing runs.
+func (i *Indexer) SetFilter(filter *FileFilter) {
+	i.filter = filter
+	i.maxFileSize = filter.MaxFileSize()
+}
+
+// SetBatchLimits sets the number of documents and content bytes after which
+// a batch is flushed, for subsequent indexing runs. Non-positive values keep
+// the current limit.
+func (i *Indexer) SetBatchLimits(size int, bytes int64) {
+	if size > 0 {
+		i.batchSize = size
+	}
+	if bytes > 0 {
+		i.batchBytes = bytes
+	}
+}
+
+// SetDeclarations sets whether subsequent indexing runs index the top-level
+// declarations of source files as documents of their own. History indexes
+// never do.
+func (i *Indexer) SetDeclarations(enabled bool) {
+	i.declarations = enabled
+}
+
+// indexesDeclarations reports whether the index of repoID gets declaration
+// documents.
+func (i *Indexer) indexesDeclarations(repoID string) bool {
+	return i.declarations && repoID != HistoryID(baseRepoID(repoID))
+}
+
+// maxFileSizeFor returns the size limit for the file at relPath.
+func (i *Indexer) maxFileSizeFor(relPath string) int64 {
+	if size, ok := i.filter.SizeOverride(relPath); ok {
+		return size
+	}
+	return i.maxFileSize
+}
+
 // indexPath returns the path to an index for a given repo ID.
 func (i *Indexer) indexPath(repoID string) string {
-	return filepath.Join(i.baseDir, "indexes", repoID+IndexSuffix)
+	return filepath.Join(i.indexesDir, repoID+IndexSuffix)
 }
 
 // CreateIndexMapping creates the Bleve index mapping for code documents.
@@ -56,7 +154,14 @@ func CreateIndexMapping() mapping.IndexMapping {
 	contentField.Analyzer = standard.Name
 	contentField.Store = true
 	contentField.IncludeTermVectors = true
-	docMapping.AddFieldMappingsAt(domain.CodeFieldContent, contentField)
+
+	// Content again, case preserved, for case-sensitive search
+	exactField := bleve.NewTextFieldMapping()
+	exactField.Name = domain.CodeFieldContentExact
+	exactField.Analyzer = caseSensitiveAnalyzer
+	exactField.Store = false
+	exactField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldContent, contentField, exactField)
 
 	// Repository - keyword (not analyzed), stored for retrieval
 	repoField := bleve.NewTextFieldMapping()
@@ -80,7 +185,71 @@ func CreateIndexMapping() mapping.IndexMapping {
 	symbolsField := bleve.NewTextFieldMapping()
 	symbolsField.Analyzer = standard.Name
 	symbolsField.Store = false
-	docMapping.AddFieldMappingsAt(domain.CodeFieldSymbols, symbolsField)
+
+	// Symbols again, split into words, for partial identifier matches
+	symbolPartsField := bleve.NewTextFieldMapping()
+	symbolPartsField.Name = domain.CodeFieldSymbolParts
+	symbolPartsField.Analyzer = identifierPartsAnalyzer
+	symbolPartsField.Store = false
+	symbolPartsField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldSymbols, symbolsField, symbolPartsField)
+
+	// Keys - whole key paths of YAML/JSON files, not stored
+	keysField := bleve.NewTextFieldMapping()
+	keysField.Analyzer = configKeyAnalyzer
+	keysField.Store = false
+	keysField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldKeys, keysField)
+
+	// Generated - marks generated code for filtering, not stored
+	generatedField := bleve.NewBooleanFieldMapping()
+	generatedField.Store = false
+	generatedField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldGenerated, generatedField)
+
+	// Declared - marks files searched through their declarations, not stored
+	declaredField := bleve.NewBooleanFieldMapping()
+	declaredField.Store = false
+	declaredField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldDeclared, declaredField)
+
+	// Kind - marks directory and declaration documents, keyword, not stored
+	kindField := bleve.NewTextFieldMapping()
+	kindField.Analyzer = keyword.Name
+	kindField.Store = false
+	kindField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldKind, kindField)
+
+	// Directory summaries - stored for display, not searched
+	for _, name := range []string{domain.CodeFieldFileCount, domain.CodeFieldSubdirCount} {
+		countField := bleve.NewNumericFieldMapping()
+		countField.Index = false
+		countField.Store = true
+		docMapping.AddFieldMappingsAt(name, countField)
+	}
+	languagesField := bleve.NewTextFieldMapping()
+	languagesField.Analyzer = keyword.Name
+	languagesField.Store = true
+	languagesField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldLanguages, languagesField)
+
+	// Declarations - the file they belong to, keyword, not stored; their
+	// name and lines, stored for display, not searched
+	fileField := bleve.NewTextFieldMapping()
+	fileField.Analyzer = keyword.Name
+	fileField.Store = false
+	fileField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(domain.CodeFieldFile, fileField)
+	declarationField := bleve.NewTextFieldMapping()
+	declarationField.Index = false
+	declarationField.Store = true
+	docMapping.AddFieldMappingsAt(domain.CodeFieldDeclaration, declarationField)
+	for _, name := range []string{domain.CodeFieldStartLine, domain.CodeFieldEndLine} {
+		lineField := bleve.NewNumericFieldMapping()
+		lineField.Index = false
+		lineField.Store = true
+		docMapping.AddFieldMappingsAt(name, lineField)
+	}
 
 	// ID - stored but not indexed (we use the document ID)
 	idField := bleve.NewTextFieldMapping()
@@ -90,42 +259,75 @@ func CreateIndexMapping() mapping.IndexMapping {
 
 	// Create the index mapping
 	indexMapping := bleve.NewIndexMapping()
+	if err := indexMapping.AddCustomAnalyzer(caseSensitiveAnalyzer, map[string]any{
+		"type":      custom.Name,
+		"tokenizer": unicode.Name,
+	}); err != nil {
+		panic(fmt.Sprintf("invalid analyzer definition: %v", err)) // static definition, cannot fail
+	}
+	if err := indexMapping.AddCustomAnalyzer(configKeyAnalyzer, map[string]any{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		panic(fmt.Sprintf("invalid analyzer definition: %v", err)) // static definition, cannot fail
+	}
+	if err := indexMapping.AddCustomCharFilter(underscoreCharFilter, map[string]any{
+		"type":    regexp.Name,
+		"regexp":  "_",
+		"replace": " ",
+	}); err != nil {
+		panic(fmt.Sprintf("invalid char filter definition: %v", err)) // static definition, cannot fail
+	}
+	if err := indexMapping.AddCustomAnalyzer(identifierPartsAnalyzer, map[string]any{
+		"type":          custom.Name,
+		"char_filters":  []string{underscoreCharFilter},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{camelcase.Name, lowercase.Name},
+	}); err != nil {
+		panic(fmt.Sprintf("invalid analyzer definition: %v", err)) // static definition, cannot fail
+	}
 	indexMapping.DefaultMapping = docMapping
 	indexMapping.DefaultAnalyzer = standard.Name
 
 	return indexMapping
 }
 
-// OpenForWrite opens or creates an index for writing.
+// OpenForWrite opens or creates an index for writing. An index that is
+// already open, e.g. by the search alias, is shared rather than reopened.
 func (i *Indexer) OpenForWrite(repoID string) (bleve.Index, error) {
 	indexPath := i.indexPath(repoID)
 
-	// Try to open existing index
-	index, err := bleve.Open(indexPath)
-	if err == nil {
-		return index, nil
-	}
+	return i.handles.acquire(repoID, func() (bleve.Index, error) {
+		// Try to open existing index
+		index, err := bleve.Open(indexPath)
+		if err == nil {
+			return index, nil
+		}
 
-	// Create new index
-	indexMapping := CreateIndexMapping()
-	index, err = bleve.New(indexPath, indexMapping)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create index: %w", err)
-	}
+		// Create new index
+		indexMapping := CreateIndexMapping()
+		index, err = bleve.New(indexPath, indexMapping)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create index: %w", err)
+		}
 
-	return index, nil
+		return index, nil
+	})
 }
 
-// OpenForRead opens an existing index for reading.
+// OpenForRead opens an existing index for reading, sharing the handle if the
+// index is already open.
 func (i *Indexer) OpenForRead(repoID string) (bleve.Index, error) {
 	indexPath := i.indexPath(repoID)
 
-	index, err := bleve.Open(indexPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open index: %w", err)
-	}
-
-	return index, nil
+	return i.handles.acquire(repoID, func() (bleve.Index, error) {
+		index, err := bleve.Open(indexPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open index: %w", err)
+		}
+		return index, nil
+	})
 }
 
 // IndexExists checks if an index exists for the given repo ID.
@@ -135,7 +337,8 @@ func (i *Indexer) IndexExists(repoID string) bool {
 	return err == nil
 }
 
-// CreateAlias creates an IndexAlias combining multiple indexes.
+// CreateAlias creates an IndexAlias combining multiple indexes. Closing the
+// alias releases its indexes.
 func (i *Indexer) CreateAlias(repoIDs []string) (bleve.IndexAlias, error) {
 	indexes := make([]bleve.Index, 0, len(repoIDs))
 
@@ -155,11 +358,13 @@ func (i *Indexer) CreateAlias(repoIDs []string) (bleve.IndexAlias, error) {
 		return nil, fmt.Errorf("no indexes to combine")
 	}
 
-	return bleve.NewIndexAlias(indexes...), nil
+	return &pooledAlias{IndexAlias: bleve.NewIndexAlias(indexes...), indexes: indexes}, nil
 }
 
 // FullIndex performs a full index of a repository.
-// Returns the number of files indexed.
+// Returns the number of files indexed. If the repository exceeds the filter's
+// budget, the files indexed so far are kept and ErrIndexBudgetExceeded is
+// returned alongside the count.
 func (i *Indexer) FullIndex(repoID, repoDir string) (count int, err error) {
 	index, err := i.OpenForWrite(repoID)
 	if err != nil {
@@ -173,9 +378,22 @@ func (i *Indexer) FullIndex(repoID, repoDir string) (count int, err error) {
 
 	batch := index.NewBatch()
 	batchSize := 0
-	batchBytes := 0
+	batchBytes := int64(0)
+	maxBatchSize, maxBatchBytes := i.batchSize, i.batchBytes
 	totalIndexed := 0
-	displayName := RepoIDToDisplay(repoID)
+	totalBytes := int64(0)
+	displayName := RepoIDToDisplay(baseRepoID(repoID))
+	declarations := i.indexesDeclarations(repoID)
+	maxFiles, maxBytes := i.filter.RepoBudget()
+	var budgetErr error
+	skipped := &SkipStats{}
+	changes := &CatalogChanges{Full: true}
+	defer func() {
+		i.runMu.Lock()
+		i.skips[repoID] = skipped
+		i.catalog[repoID] = changes
+		i.runMu.Unlock()
+	}()
 
 	err = filepath.WalkDir(repoDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -197,31 +415,63 @@ func (i *Indexer) FullIndex(repoID, repoDir string) (count int, err error) {
 			return nil
 		}
 
-		// Check exclusion patterns
-		if i.filter.ShouldExclude(relPath) {
-			return nil
-		}
-
 		// Check file size
 		info, err := d.Info()
 		if err != nil {
+			skipped.add(relPath, -1, SkipReasonUnreadable)
 			return nil
 		}
-		if info.Size() > i.maxFileSize {
+
+		// Check exclusion patterns
+		if pattern := i.filter.ExcludingPattern(relPath); pattern != "" {
+			skipped.addExcluded(relPath, info.Size(), pattern)
+			return nil
+		}
+
+		// WalkDir reports symlinks without following them. Symlinked files
+		// are indexed only when allowed and inside the repository; symlinked
+		// directories are skipped since their targets are indexed in place.
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := resolveRepoPath(repoDir, relPath, i.filter.FollowSymlinks())
+			if err != nil {
+				skipped.add(relPath, -1, SkipReasonSymlink)
+				return nil
+			}
+			if info, err = os.Stat(target); err != nil || info.IsDir() {
+				skipped.add(relPath, -1, SkipReasonSymlink)
+				return nil
+			}
+			path = target
+		}
+
+		if info.Size() > i.maxFileSizeFor(relPath) {
+			skipped.add(relPath, info.Size(), SkipReasonTooLarge)
 			return nil
 		}
 
 		// Read file content
 		content, err := os.ReadFile(path)
 		if err != nil {
+			skipped.add(relPath, info.Size(), SkipReasonUnreadable)
 			return nil
 		}
 
 		// Skip binary files
 		if IsBinary(content) {
+			skipped.add(relPath, info.Size(), SkipReasonBinary)
 			return nil
 		}
 
+		// Stop once the repository budget is used up
+		if maxFiles > 0 && totalIndexed+batchSize >= maxFiles {
+			budgetErr = fmt.Errorf("%w: stopped at %d files (limit %d)", ErrIndexBudgetExceeded, totalIndexed+batchSize, maxFiles)
+			return filepath.SkipAll
+		}
+		if maxBytes > 0 && totalBytes+int64(len(content)) > maxBytes {
+			budgetErr = fmt.Errorf("%w: stopped at %d bytes in %d files (limit %d bytes)", ErrIndexBudgetExceeded, totalBytes, totalIndexed+batchSize, maxBytes)
+			return filepath.SkipAll
+		}
+
 		// Create document
 		doc := domain.CodeDocument{
 			ID:         repoID + "/" + relPath,
@@ -230,17 +480,33 @@ func (i *Indexer) FullIndex(repoID, repoDir string) (count int, err error) {
 			Extension:  GetFileExtension(relPath),
 			Content:    string(content),
 			Symbols:    ExtractSymbols(GetFileExtension(relPath), string(content)),
+			Keys:       ExtractKeys(GetFileExtension(relPath), content),
+			Generated:  IsGenerated(content),
+		}
+
+		var decls []domain.DeclarationDocument
+		if declarations {
+			decls = declarationDocuments(&doc)
+			doc.Declared = len(decls) > 0
 		}
 
 		// Add to batch
 		if err := batch.Index(doc.ID, doc); err != nil {
 			return nil // Skip on indexing error
 		}
+		for _, decl := range decls {
+			if err := batch.Index(decl.ID, decl); err != nil {
+				return fmt.Errorf("declaration index failed: %w", err)
+			}
+			batchBytes += int64(len(decl.Content))
+		}
+		changes.Files = append(changes.Files, newCatalogFile(relPath, content))
 		batchSize++
-		batchBytes += len(content)
+		batchBytes += int64(len(content))
+		totalBytes += int64(len(content))
 
 		// Flush batch if needed
-		if batchSize >= MaxBatchSize || batchBytes >= MaxBatchBytes {
+		if batchSize >= maxBatchSize || batchBytes >= maxBatchBytes {
 			if err := index.Batch(batch); err != nil {
 				return fmt.Errorf("batch index failed: %w", err)
 			}
@@ -265,10 +531,30 @@ func (i *Indexer) FullIndex(repoID, repoDir string) (count int, err error) {
 		totalIndexed += batchSize
 	}
 
-	return totalIndexed, nil
+	return totalIndexed, budgetErr
+}
+
+// SkipStats returns the files skipped by the last full index of a
+// repository, or nil if it has not been fully indexed by this indexer.
+func (i *Indexer) SkipStats(repoID string) *SkipStats {
+	i.runMu.Lock()
+	defer i.runMu.Unlock()
+	return i.skips[repoID]
+}
+
+// CatalogChanges returns and clears the catalog updates recorded by the last
+// indexing run of a repository, or nil if there are none.
+func (i *Indexer) CatalogChanges(repoID string) *CatalogChanges {
+	i.runMu.Lock()
+	defer i.runMu.Unlock()
+	changes := i.catalog[repoID]
+	delete(i.catalog, repoID)
+	return changes
 }
 
-// IncrementalIndex updates the index for changed files only.
+// IncrementalIndex updates the index for changed files only. Files are read
+// in parallel, a chunk at a time, and flushed with the same batch limits as
+// FullIndex.
 func (i *Indexer) IncrementalIndex(repoID, repoDir string, changedFiles []string) (indexed int, err error) {
 	index, err := i.OpenForWrite(repoID)
 	if err != nil {
@@ -281,78 +567,212 @@ func (i *Indexer) IncrementalIndex(repoID, repoDir string, changedFiles []string
 	}()
 
 	batch := index.NewBatch()
-	displayName := RepoIDToDisplay(repoID)
-
-	for _, relPath := range changedFiles {
-		fullPath := filepath.Join(repoDir, relPath)
-		docID := repoID + "/" + relPath
-
-		// Check if file exists
-		info, err := os.Stat(fullPath)
-		if os.IsNotExist(err) {
-			// File was deleted, remove from index
-			batch.Delete(docID)
-			continue
-		}
-		if err != nil {
-			continue // Skip on error
-		}
+	batchSize := 0
+	batchBytes := int64(0)
+	maxBatchSize, maxBatchBytes := i.batchSize, i.batchBytes
+	displayName := RepoIDToDisplay(baseRepoID(repoID))
+	changes := &CatalogChanges{}
+
+	for start := 0; start < len(changedFiles); start += maxBatchSize {
+		chunk := changedFiles[start:min(start+maxBatchSize, len(changedFiles))]
+
+		// Results keep the order of the changed files
+		for _, file := range i.readChangedFiles(repoID, repoDir, displayName, chunk) {
+			// Declarations of the previous version are replaced, also when
+			// declarations are no longer indexed
+			if file.remove || file.doc != nil {
+				stale, err := declarationIDs(index, repoID+"/"+file.relPath)
+				if err != nil {
+					return indexed, fmt.Errorf("declaration lookup failed: %w", err)
+				}
+				for _, id := range stale {
+					batch.Delete(id)
+				}
+			}
 
-		// Skip directories
-		if info.IsDir() {
-			continue
-		}
+			switch {
+			case file.remove:
+				batch.Delete(repoID + "/" + file.relPath)
+				changes.Deleted = append(changes.Deleted, filepath.ToSlash(file.relPath))
+				batchSize++
+			case file.doc != nil:
+				if err := batch.Index(file.doc.ID, file.doc); err != nil {
+					continue
+				}
+				for _, decl := range file.decls {
+					if err := batch.Index(decl.ID, decl); err != nil {
+						return indexed, fmt.Errorf("declaration index failed: %w", err)
+					}
+					batchBytes += int64(len(decl.Content))
+				}
+				changes.Files = append(changes.Files, newCatalogFile(file.relPath, file.content))
+				indexed++
+				batchSize++
+				batchBytes += int64(len(file.content))
+			}
 
-		// Check exclusion patterns
-		if i.filter.ShouldExclude(relPath) {
-			// Remove from index in case it was previously indexed
-			batch.Delete(docID)
-			continue
+			// Flush batch if needed
+			if batchSize >= maxBatchSize || batchBytes >= maxBatchBytes {
+				if err := index.Batch(batch); err != nil {
+					return indexed, fmt.Errorf("batch index failed: %w", err)
+				}
+				batch = index.NewBatch()
+				batchSize = 0
+				batchBytes = 0
+			}
 		}
+	}
 
-		// Check file size
-		if info.Size() > i.maxFileSize {
-			batch.Delete(docID)
-			continue
+	// Flush remaining batch
+	if batchSize > 0 {
+		if err := index.Batch(batch); err != nil {
+			return indexed, fmt.Errorf("final batch index failed: %w", err)
 		}
+	}
 
-		// Read file content
-		content, err := os.ReadFile(fullPath)
-		if err != nil {
-			continue // Skip on error
-		}
+	i.runMu.Lock()
+	i.catalog[repoID] = changes
+	i.runMu.Unlock()
 
-		// Skip binary files
-		if IsBinary(content) {
-			batch.Delete(docID)
-			continue
-		}
+	return indexed, nil
+}
 
-		// Create document
-		doc := domain.CodeDocument{
-			ID:         docID,
-			Repository: displayName,
-			FilePath:   relPath,
-			Extension:  GetFileExtension(relPath),
-			Content:    string(content),
-			Symbols:    ExtractSymbols(GetFileExtension(relPath), string(content)),
-		}
+// changedFile is the outcome of reading a changed file: a document to index,
+// with its declarations, a path to remove from the index, or neither when
+// the file is skipped.
+type changedFile struct {
+	relPath string
+	doc     *domain.CodeDocument
+	decls   []domain.DeclarationDocument
+	content []byte
+	remove  bool
+}
 
-		if err := batch.Index(doc.ID, doc); err != nil {
-			continue
+// readChangedFiles reads the given files with up to MaxParallelReads workers
+// and returns their outcomes in the same order.
+func (i *Indexer) readChangedFiles(repoID, repoDir, displayName string, relPaths []string) []changedFile {
+	results := make([]changedFile, len(relPaths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for range min(MaxParallelReads, len(relPaths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				results[n] = i.readChangedFile(repoID, repoDir, displayName, relPaths[n])
+			}
+		}()
+	}
+	for n := range relPaths {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// readChangedFile applies the indexing rules of a full index to a single
+// changed file.
+func (i *Indexer) readChangedFile(repoID, repoDir, displayName, relPath string) changedFile {
+	fullPath := filepath.Join(repoDir, relPath)
+	remove := changedFile{relPath: relPath, remove: true}
+
+	// Check if file exists
+	info, err := os.Lstat(fullPath)
+	if os.IsNotExist(err) {
+		// File was deleted, remove from index
+		return remove
+	}
+	if err != nil {
+		return changedFile{relPath: relPath} // Skip on error
+	}
+
+	// Apply the same symlink policy as a full index
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := resolveRepoPath(repoDir, relPath, i.filter.FollowSymlinks())
+		if err == nil {
+			info, err = os.Stat(target)
+		}
+		if err != nil || info.IsDir() {
+			return remove
 		}
-		indexed++
+		fullPath = target
 	}
 
-	if err := index.Batch(batch); err != nil {
-		return indexed, fmt.Errorf("batch index failed: %w", err)
+	// Skip directories
+	if info.IsDir() {
+		return changedFile{relPath: relPath}
 	}
 
-	return indexed, nil
+	// Check exclusion patterns; remove in case it was previously indexed
+	if i.filter.ShouldExclude(relPath) {
+		return remove
+	}
+
+	// Check file size
+	if info.Size() > i.maxFileSizeFor(relPath) {
+		return remove
+	}
+
+	// Read file content
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return changedFile{relPath: relPath} // Skip on error
+	}
+
+	// Skip binary files
+	if IsBinary(content) {
+		return remove
+	}
+
+	ext := GetFileExtension(relPath)
+	doc := &domain.CodeDocument{
+		ID:         repoID + "/" + relPath,
+		Repository: displayName,
+		FilePath:   relPath,
+		Extension:  ext,
+		Content:    string(content),
+		Symbols:    ExtractSymbols(ext, string(content)),
+		Keys:       ExtractKeys(ext, content),
+		Generated:  IsGenerated(content),
+	}
+	var decls []domain.DeclarationDocument
+	if i.indexesDeclarations(repoID) {
+		decls = declarationDocuments(doc)
+		doc.Declared = len(decls) > 0
+	}
+	return changedFile{relPath: relPath, content: content, doc: doc, decls: decls}
+}
+
+// CopyOpenIndex copies an index that is open in this process, such as one
+// served by the search alias, to dir. The copy is consistent even while the
+// index merges segments in the background. It reports false without copying
+// when the index is not open, in which case its files can be read directly.
+func (i *Indexer) CopyOpenIndex(repoID, dir string) (bool, error) {
+	index, ok := i.handles.acquireOpen(repoID)
+	if !ok {
+		return false, nil
+	}
+	defer func() { _ = index.Close() }()
+
+	copyable, ok := index.sharedIndex.(bleve.IndexCopyable)
+	if !ok {
+		return false, fmt.Errorf("index %s cannot be copied", repoID)
+	}
+	if err := copyable.CopyTo(bleve.FileSystemDirectory(dir)); err != nil {
+		return false, fmt.Errorf("failed to copy index %s: %w", repoID, err)
+	}
+	return true, nil
 }
 
-// DeleteIndex removes an index from disk.
+// DeleteIndex removes an index from disk. Indexes that are still open cannot
+// be deleted.
 func (i *Indexer) DeleteIndex(repoID string) error {
+	if i.handles.inUse(repoID) {
+		return fmt.Errorf("index %s is still open", repoID)
+	}
 	indexPath := i.indexPath(repoID)
 	return os.RemoveAll(indexPath)
 }
@@ -1,10 +1,12 @@
 package gitrepos
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/blevesearch/bleve/v2"
@@ -23,8 +25,8 @@ func TestNewIndexer(t *testing.T) {
 	filter := NewFileFilter(256 * 1024)
 	indexer := NewIndexer("/tmp/test", filter, 256*1024)
 
-	if indexer.baseDir != "/tmp/test" {
-		t.Errorf("baseDir = %q, want '/tmp/test'", indexer.baseDir)
+	if indexer.indexesDir != filepath.Join("/tmp/test", "indexes") {
+		t.Errorf("indexesDir = %q, want '/tmp/test/indexes'", indexer.indexesDir)
 	}
 	if indexer.maxFileSize != 256*1024 {
 		t.Errorf("maxFileSize = %d", indexer.maxFileSize)
@@ -372,6 +374,136 @@ func TestIndexer_FullIndex_SkipsGitDir(t *testing.T) {
 	}
 }
 
+func TestIndexer_FullIndex_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	outside := filepath.Join(dir, "secret.txt")
+
+	createTestFile(t, repoDir, "main.go", "package main")
+	createTestFile(t, repoDir, "lib/util.go", "package lib")
+	createTestFile(t, dir, "secret.txt", "password")
+	createTestSymlink(t, "main.go", filepath.Join(repoDir, "alias.go"))
+	createTestSymlink(t, outside, filepath.Join(repoDir, "escape.txt"))
+	createTestSymlink(t, "lib", filepath.Join(repoDir, "libalias"))
+
+	tests := []struct {
+		name   string
+		follow bool
+		want   int
+	}{
+		{"skipped by default", false, 2},
+		{"followed inside repo", true, 3}, // alias.go only
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFileFilter(256 * 1024)
+			filter.SetFollowSymlinks(tt.follow)
+			indexer := NewIndexer(t.TempDir(), filter, 256*1024)
+
+			count, err := indexer.FullIndex("testrepo", repoDir)
+			if err != nil {
+				t.Fatalf("FullIndex failed: %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("Expected %d files indexed, got %d", tt.want, count)
+			}
+		})
+	}
+}
+
+func TestIndexer_IncrementalIndex_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	filter := NewFileFilter(256 * 1024)
+	indexer := NewIndexer(dir, filter, 256*1024)
+
+	createTestFile(t, repoDir, "main.go", "package main")
+	createTestFile(t, dir, "secret.txt", "password")
+	createTestSymlink(t, filepath.Join(dir, "secret.txt"), filepath.Join(repoDir, "escape.txt"))
+
+	count, err := indexer.IncrementalIndex("testrepo", repoDir, []string{"main.go", "escape.txt"})
+	if err != nil {
+		t.Fatalf("IncrementalIndex failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 file indexed, got %d", count)
+	}
+
+	filter.SetFollowSymlinks(true)
+	count, err = indexer.IncrementalIndex("testrepo", repoDir, []string{"escape.txt"})
+	if err != nil {
+		t.Fatalf("IncrementalIndex failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected symlink outside the repository to be skipped, got %d", count)
+	}
+}
+
+func TestIndexer_IncrementalIndex_ManyFiles(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+
+	// Spans several batches, with deletions mixed in
+	var changed []string
+	for n := range MaxBatchSize*2 + 7 {
+		relPath := fmt.Sprintf("pkg/file%03d.go", n)
+		if n%10 != 0 {
+			createTestFile(t, repoDir, relPath, fmt.Sprintf("package pkg // file %d", n))
+		}
+		changed = append(changed, relPath)
+	}
+
+	count, err := indexer.IncrementalIndex("testrepo", repoDir, changed)
+	if err != nil {
+		t.Fatalf("IncrementalIndex failed: %v", err)
+	}
+	deleted := (len(changed) + 9) / 10
+	if want := len(changed) - deleted; count != want {
+		t.Errorf("Expected %d files indexed, got %d", want, count)
+	}
+	if docs, err := indexer.GetDocumentCount("testrepo"); err != nil || docs != uint64(count) {
+		t.Errorf("Expected %d documents, got %d (%v)", count, docs, err)
+	}
+
+	changes := indexer.CatalogChanges("testrepo")
+	if changes == nil || len(changes.Deleted) != deleted || changes.Deleted[0] != "pkg/file000.go" {
+		t.Errorf("Expected %d ordered deletions, got %+v", deleted, changes)
+	}
+}
+
+func TestIndexer_SetBatchLimits(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+
+	indexer.SetBatchLimits(0, -1)
+	if indexer.batchSize != MaxBatchSize || indexer.batchBytes != MaxBatchBytes {
+		t.Errorf("Expected non-positive limits to keep defaults, got %d, %d", indexer.batchSize, indexer.batchBytes)
+	}
+
+	indexer.SetBatchLimits(3, 16)
+	var changed []string
+	for n := range 10 {
+		relPath := fmt.Sprintf("file%d.go", n)
+		createTestFile(t, repoDir, relPath, "package main // small batches")
+		changed = append(changed, relPath)
+	}
+
+	count, err := indexer.FullIndex("testrepo", repoDir)
+	if err != nil || count != 10 {
+		t.Fatalf("Expected 10 files fully indexed, got %d (%v)", count, err)
+	}
+	count, err = indexer.IncrementalIndex("testrepo", repoDir, changed)
+	if err != nil || count != 10 {
+		t.Fatalf("Expected 10 files incrementally indexed, got %d (%v)", count, err)
+	}
+	if docs, err := indexer.GetDocumentCount("testrepo"); err != nil || docs != 10 {
+		t.Errorf("Expected 10 documents, got %d (%v)", docs, err)
+	}
+}
+
 func TestIndexer_FullIndex_ReadError(t *testing.T) {
 	if os.Getuid() == 0 {
 		t.Skip("Skipping permission test as root")
@@ -537,6 +669,40 @@ func TestIndexer_IncrementalIndex_Delete(t *testing.T) {
 	}
 }
 
+func TestIndexer_CopyOpenIndex(t *testing.T) {
+	dir := t.TempDir()
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+	repoDir := filepath.Join(dir, "repo")
+	createTestFile(t, repoDir, "main.go", "package main")
+	if _, err := indexer.FullIndex("repo", repoDir); err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+
+	// Indexes that are not open are left to be read from disk
+	if copied, err := indexer.CopyOpenIndex("repo", filepath.Join(dir, "closed")); err != nil || copied {
+		t.Errorf("Expected no copy of a closed index, got %v, %v", copied, err)
+	}
+
+	alias, err := indexer.CreateAlias([]string{"repo"})
+	if err != nil {
+		t.Fatalf("CreateAlias failed: %v", err)
+	}
+	defer closeIndex(t, alias)
+
+	copyDir := filepath.Join(dir, "copy")
+	if copied, err := indexer.CopyOpenIndex("repo", copyDir); err != nil || !copied {
+		t.Fatalf("Expected the open index to be copied, got %v, %v", copied, err)
+	}
+	copyIndex, err := bleve.Open(copyDir)
+	if err != nil {
+		t.Fatalf("Failed to open copy: %v", err)
+	}
+	defer closeIndex(t, copyIndex)
+	if count, err := copyIndex.DocCount(); err != nil || count == 0 {
+		t.Errorf("Expected the copy to hold the indexed documents, got %d, %v", count, err)
+	}
+}
+
 func TestIndexer_DeleteIndex(t *testing.T) {
 	dir := t.TempDir()
 	filter := NewFileFilter(256 * 1024)
@@ -589,6 +755,164 @@ func TestIndexer_GetDocumentCount(t *testing.T) {
 	}
 }
 
+func TestIndexer_FullIndex_Budget(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	for i := range 5 {
+		createTestFile(t, repoDir, fmt.Sprintf("file%d.go", i), "package main // 0123456789")
+	}
+
+	tests := []struct {
+		name     string
+		maxFiles int
+		maxBytes int64
+		want     int
+		exceeded bool
+	}{
+		{"unlimited", 0, 0, 5, false},
+		{"file limit", 3, 0, 3, true},
+		{"file limit equals count", 5, 0, 5, false},
+		{"byte limit", 0, 60, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFileFilter(256 * 1024)
+			filter.SetRepoBudget(tt.maxFiles, tt.maxBytes)
+			indexer := NewIndexer(t.TempDir(), filter, 256*1024)
+
+			count, err := indexer.FullIndex("testrepo", repoDir)
+			if errors.Is(err, ErrIndexBudgetExceeded) != tt.exceeded {
+				t.Fatalf("FullIndex error = %v, exceeded %v", err, tt.exceeded)
+			}
+			if !tt.exceeded && err != nil {
+				t.Fatalf("FullIndex failed: %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("Expected %d files indexed, got %d", tt.want, count)
+			}
+			if docs, _ := indexer.GetDocumentCount("testrepo"); docs != uint64(tt.want) {
+				t.Errorf("Expected %d documents in the index, got %d", tt.want, docs)
+			}
+		})
+	}
+}
+
+func TestIndexer_FullIndex_SkipStats(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	filter := NewFileFilter(64)
+	indexer := NewIndexer(dir, filter, 64)
+
+	createTestFile(t, repoDir, "main.go", "package main")
+	createTestFile(t, repoDir, "big.go", strings.Repeat("x", 200))
+	createTestFile(t, repoDir, "bigger.txt", strings.Repeat("x", 300))
+	createTestFile(t, repoDir, "node_modules/lib/index.js", "module.exports = {}")
+	createBinaryFile(t, repoDir, "image.bin")
+	createTestSymlink(t, "main.go", filepath.Join(repoDir, "alias.go"))
+
+	if indexer.SkipStats("testrepo") != nil {
+		t.Error("Expected no stats before indexing")
+	}
+
+	if _, err := indexer.FullIndex("testrepo", repoDir); err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+
+	stats := indexer.SkipStats("testrepo")
+	if stats == nil {
+		t.Fatal("Expected skip stats after indexing")
+	}
+	want := map[string]int{
+		SkipReasonTooLarge: 2,
+		SkipReasonExcluded: 1,
+		SkipReasonBinary:   1,
+		SkipReasonSymlink:  1,
+	}
+	for reason, n := range want {
+		if stats.Counts[reason] != n {
+			t.Errorf("Counts[%s] = %d, want %d", reason, stats.Counts[reason], n)
+		}
+	}
+	if stats.Total() != 5 {
+		t.Errorf("Total() = %d, want 5", stats.Total())
+	}
+	if len(stats.Largest) == 0 || stats.Largest[0].Path != "bigger.txt" || stats.Largest[1].Path != "big.go" {
+		t.Errorf("Unexpected largest skipped files: %+v", stats.Largest)
+	}
+	if got := stats.ExcludedBy["node_modules/**"]; got.Files != 1 || got.Bytes != int64(len("module.exports = {}")) {
+		t.Errorf("Unexpected files excluded by node_modules/**: %+v", stats.ExcludedBy)
+	}
+	if stats.ExcludedTypes["js"] != 1 {
+		t.Errorf("Unexpected excluded file types: %v", stats.ExcludedTypes)
+	}
+}
+
+func TestIndexer_SizeOverrides(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	filter := NewFileFilter(64)
+	filter.SetSizeOverrides(map[string]int64{"md": 1024})
+	indexer := NewIndexer(dir, filter, 64)
+
+	createTestFile(t, repoDir, "GUIDE.MD", strings.Repeat("doc ", 100))
+	createTestFile(t, repoDir, "big.go", strings.Repeat("x", 200))
+
+	count, err := indexer.FullIndex("testrepo", repoDir)
+	if err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected only the markdown file to be indexed, got %d files", count)
+	}
+	if stats := indexer.SkipStats("testrepo"); stats.Counts[SkipReasonTooLarge] != 1 {
+		t.Errorf("Expected big.go to be skipped as too large, got %+v", stats.Counts)
+	}
+
+	// Incremental indexing applies the same limits
+	createTestFile(t, repoDir, "notes.md", strings.Repeat("note ", 100))
+	if count, err := indexer.IncrementalIndex("testrepo", repoDir, []string{"notes.md", "big.go"}); err != nil || count != 1 {
+		t.Errorf("Expected 1 file indexed incrementally, got %d (err: %v)", count, err)
+	}
+}
+
+func TestIndexer_CatalogChanges(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	filter := NewFileFilter(64)
+	indexer := NewIndexer(dir, filter, 64)
+
+	createTestFile(t, repoDir, "main.go", "package main")
+	createTestFile(t, repoDir, "old.go", "package old")
+	createTestFile(t, repoDir, "big.go", strings.Repeat("x", 200))
+
+	if _, err := indexer.FullIndex("testrepo", repoDir); err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+	changes := indexer.CatalogChanges("testrepo")
+	if changes == nil || !changes.Full || len(changes.Files) != 2 {
+		t.Fatalf("Expected a full run with the 2 indexed files, got %+v", changes)
+	}
+	if indexer.CatalogChanges("testrepo") != nil {
+		t.Error("Expected changes to be cleared once taken")
+	}
+
+	createTestFile(t, repoDir, "new.go", "package new")
+	if err := os.Remove(filepath.Join(repoDir, "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := indexer.IncrementalIndex("testrepo", repoDir, []string{"new.go", "old.go"}); err != nil {
+		t.Fatalf("IncrementalIndex failed: %v", err)
+	}
+	changes = indexer.CatalogChanges("testrepo")
+	if changes == nil || changes.Full {
+		t.Fatalf("Expected an incremental run, got %+v", changes)
+	}
+	if len(changes.Files) != 1 || changes.Files[0].Path != "new.go" || len(changes.Deleted) != 1 || changes.Deleted[0] != "old.go" {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+}
+
 func TestIndexer_FullIndex_BatchFlush(t *testing.T) {
 	dir := t.TempDir()
 	repoDir := filepath.Join(dir, "repos", "testrepo")
@@ -792,6 +1116,13 @@ func createTestFile(t *testing.T, baseDir, relPath, content string) {
 	}
 }
 
+func createTestSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+}
+
 func createBinaryFile(t *testing.T, baseDir, relPath string) {
 	t.Helper()
 	fullPath := filepath.Join(baseDir, relPath)
@@ -813,3 +1144,18 @@ func makeLargeContent(size int) string {
 	}
 	return string(content)
 }
+
+func TestCreateIndexMapping_IdentifierParts(t *testing.T) {
+	analyzer := CreateIndexMapping().AnalyzerNamed(identifierPartsAnalyzer)
+	if analyzer == nil {
+		t.Fatal("Expected the identifier parts analyzer to be registered")
+	}
+
+	var terms []string
+	for _, token := range analyzer.Analyze([]byte("HTTPServerConfig load_user_profile")) {
+		terms = append(terms, string(token.Term))
+	}
+	if got := strings.Join(terms, " "); got != "http server config load user profile" {
+		t.Errorf("Expected identifier words, got %q", got)
+	}
+}
@@ -10,24 +10,108 @@ import (
 // SearchService defines what the search handler needs from the service layer.
 type SearchService interface {
 	IsReady() bool
-	GetIndexAlias() (bleve.IndexAlias, error)
+	AcquireIndexAlias() (alias bleve.IndexAlias, release func(), err error)
+	RefAlias(ref string) (bleve.IndexAlias, error)
 	MaxResults() int
+	HighlightTags() (pre, post string)
+	AcquireSearch(ctx context.Context) (release func(), err error)
+	SearchTimeout() time.Duration
+	Telemetry() *SearchTelemetry
+	QueryExperiment() string
+	RankingProfile() string
+	RecentFiles() []string
+	ExtensionGroup(ext string) []string
+	PendingRepos() []string
+	CheckFreshness(ctx context.Context, repository string) []RepoFreshness
+	IndexedCommit(repoID, ref string) string
+	ReadDenied(relPath string) bool
+	Redact(content []byte) ([]byte, int)
+}
+
+// HistoryService defines what the search_history handler needs from the
+// service layer.
+type HistoryService interface {
+	SearchService
+	HistoryAlias() (bleve.IndexAlias, error)
+	HistoryCommit(repoID, prefix string) (HistoryCommit, bool)
+	GetRepoDir(repoID string) string
 }
 
 // ReadService defines what the read handler needs from the service layer.
 type ReadService interface {
 	IsReady() bool
+	IsRepoReady(repoID string) bool
 	GetRepoDir(repoID string) string
 	MaxFileSize() int64
+	MaxFileSizeFor(relPath string) int64
+	FollowSymlinks() bool
+	ReadDenied(relPath string) bool
+	Redact(content []byte) ([]byte, int)
+	ReadIndexedOnly() bool
+	IsIndexed(repoID, relPath string) bool
+	IndexedCommit(repoID, ref string) string
+	Telemetry() *SearchTelemetry
+	AcquireRead(ctx context.Context) (release func(), err error)
+	ReadTimeout() time.Duration
+}
+
+// StatsService defines what the repo_stats handler needs from the service layer.
+type StatsService interface {
+	RepoStates() map[string]RepoState
+	CatalogSummary(repoID string) *CatalogSummary
+}
+
+// MapService defines what the repo_map handler needs from the service layer.
+type MapService interface {
+	RepoStates() map[string]RepoState
+	CatalogFiles(repoID, prefix string) []CatalogFile
+}
+
+// FilterReportService defines what the filter_report handler needs from the
+// service layer.
+type FilterReportService interface {
+	RepoStates() map[string]RepoState
+	ExcludePatterns() []string
+}
+
+// ReindexService defines what the reindex handler needs from the service layer.
+type ReindexService interface {
+	Reindex(ctx context.Context, repository string) error
+}
+
+// RepositoryService defines what the update_repositories handler needs from
+// the service layer.
+type RepositoryService interface {
+	UpdateRepositories(ctx context.Context, add, remove []string) (*RepositoryUpdate, error)
+}
+
+// ProgressService defines what the progress middleware needs from the service
+// layer.
+type ProgressService interface {
+	IsReady() bool
+	IndexProgress() IndexProgress
+}
+
+// ConsistencyService defines what the consistency middleware needs from the
+// service layer.
+type ConsistencyService interface {
+	Generation() uint64
+	IndexedCommits() map[string]string
 }
 
 // GitOperations abstracts git client operations for testing.
 type GitOperations interface {
-	Clone(ctx context.Context, url, destDir string) error
-	Fetch(ctx context.Context, repoDir string) error
-	Reset(ctx context.Context, repoDir string) error
+	Clone(ctx context.Context, url, destDir, branch string, depth int) error
+	CloneRef(ctx context.Context, url, ref, destDir string) error
+	Fetch(ctx context.Context, repoDir, branch string, depth int) error
+	Reset(ctx context.Context, repoDir, branch string) error
 	GetHeadCommit(ctx context.Context, repoDir string) (string, error)
 	GetChangedFiles(ctx context.Context, repoDir, fromCommit, toCommit string) ([]string, error)
+	Version(ctx context.Context) (string, error)
+	LsRemote(ctx context.Context, url string) error
+	RemoteHead(ctx context.Context, url string) (string, error)
+	RecentChanges(ctx context.Context, repoDir string, count int) ([]CommitChanges, error)
+	ShowFile(ctx context.Context, repoDir, rev, path string) ([]byte, error)
 }
 
 // IndexOperations abstracts indexing operations for testing.
@@ -37,6 +121,14 @@ type IndexOperations interface {
 	DeleteIndex(repoID string) error
 	IndexExists(repoID string) bool
 	CreateAlias(repoIDs []string) (bleve.IndexAlias, error)
+	SetFilter(filter *FileFilter)
+	SetBatchLimits(size int, bytes int64)
+	SetDeclarations(enabled bool)
+	SkipStats(repoID string) *SkipStats
+	VerifyIndex(repoID string, fileCount int) error
+	CatalogChanges(repoID string) *CatalogChanges
+	IndexDirectories(repoID string, files []CatalogFile) error
+	CopyOpenIndex(repoID, dir string) (bool, error)
 }
 
 // ManifestOperations abstracts manifest operations for testing.
@@ -44,8 +136,13 @@ type ManifestOperations interface {
 	GetRepoState(repoID string) *RepoState
 	SetRepoState(repoID string, state RepoState)
 	HasRepo(repoID string) bool
-	RemoveStaleRepos(urls []string) []string
-	UpdateLastSync()
+	GetRepoIDs() []string
+	RemoveRepo(repoID string)
+	ExpireStaleRepos(urls []string, retention time.Duration) (marked, removed []string)
+	UpdateLastSync(changed bool)
+	GetGeneration() uint64
+	MarkRewriting(repoID string)
+	StateDigest() string
 	ClearRepoError(repoID string)
 	SetRepoError(repoID string, err string)
 	Save(path string) error
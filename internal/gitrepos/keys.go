@@ -0,0 +1,96 @@
+package gitrepos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// maxKeysPerFile caps the key paths indexed for one configuration file
+const maxKeysPerFile = 10000
+
+// ExtractKeys returns the flattened key paths of a YAML or JSON document,
+// e.g. "server.timeout" for {"server": {"timeout": 5}}. Keys of objects in
+// arrays are joined to the array's path without an index. Files of other
+// types, and files that fail to parse, have no keys.
+func ExtractKeys(ext string, content []byte) []string {
+	docs := decodeDocuments(ext, content)
+	if len(docs) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		collectKeys(doc, "", seen)
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// decodeDocuments parses a JSON file, or each document of a YAML file. It
+// returns nil for other file types and for content that fails to parse.
+func decodeDocuments(ext string, content []byte) []any {
+	var docs []any
+	switch strings.ToLower(ext) {
+	case "json":
+		var doc any
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil
+		}
+		docs = append(docs, doc)
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		for {
+			var doc any
+			err := dec.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// collectKeys adds the key paths under value, prefixed with path, to seen.
+func collectKeys(value any, path string, seen map[string]bool) {
+	visit := func(key string, child any) {
+		if len(seen) >= maxKeysPerFile {
+			return
+		}
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		seen[childPath] = true
+		collectKeys(child, childPath, seen)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			visit(key, child)
+		}
+	case map[any]any: // YAML mappings with non-string keys
+		for key, child := range v {
+			visit(fmt.Sprint(key), child)
+		}
+	case []any:
+		for _, child := range v {
+			collectKeys(child, path, seen)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package gitrepos
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractKeys_JSON(t *testing.T) {
+	content := []byte(`{"server": {"timeout": 30, "Port": 8080}, "tags": [{"name": "a"}]}`)
+
+	got := ExtractKeys("json", content)
+	want := []string{"server", "server.Port", "server.timeout", "tags", "tags.name"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractKeys_YAMLMultiDocument(t *testing.T) {
+	content := []byte("server:\n  timeout: 30s\n---\ndatabase:\n  hosts:\n    - name: primary\n")
+
+	got := ExtractKeys("yaml", content)
+	want := []string{"database", "database.hosts", "database.hosts.name", "server", "server.timeout"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractKeys_Unsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		content string
+	}{
+		{"other extension", "go", "package main"},
+		{"invalid json", "json", "{not json"},
+		{"invalid yaml", "yml", "a: [unclosed"},
+		{"scalar document", "json", "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractKeys(tt.ext, []byte(tt.content)); len(got) != 0 {
+				t.Errorf("ExtractKeys() = %v, want none", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package gitrepos
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LicenseUnrecognized is recorded for a license file whose text does not
+// match a known license.
+const LicenseUnrecognized = "unrecognized"
+
+// licenseHeadBytes is how much of a license file is read for detection
+const licenseHeadBytes = 16 * 1024
+
+// licenseFileNames are the base names, without extension, of files holding
+// a repository's license.
+var licenseFileNames = []string{"license", "licence", "copying", "unlicense"}
+
+// licenseSignatures map distinctive phrases to SPDX identifiers. More
+// specific licenses come first; all phrases of an entry must be present.
+var licenseSignatures = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE"}},
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "2.0"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "Neither the name"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+	{"ISC", []string{"ISC License"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"Unlicense", []string{"This is free and unencumbered software released into the public domain"}},
+}
+
+// DetectLicense identifies the license in the root of a repository. It
+// returns an SPDX identifier, LicenseUnrecognized for an unknown license
+// text, or "" if the repository has no license file.
+func DetectLicense(repoDir string) string {
+	entries, err := os.ReadDir(repoDir)
+	if err != nil {
+		return ""
+	}
+
+	found := false
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isLicenseFile(entry.Name()) {
+			continue
+		}
+		found = true
+		if id := identifyLicense(filepath.Join(repoDir, entry.Name())); id != "" {
+			return id
+		}
+	}
+	if found {
+		return LicenseUnrecognized
+	}
+	return ""
+}
+
+// isLicenseFile reports whether name is a license file, e.g. LICENSE,
+// LICENSE.md or COPYING.txt.
+func isLicenseFile(name string) bool {
+	base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	for _, candidate := range licenseFileNames {
+		if base == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// identifyLicense matches the head of a license file against known licenses.
+func identifyLicense(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	head, err := io.ReadAll(io.LimitReader(f, licenseHeadBytes))
+	if err != nil {
+		return ""
+	}
+	// Normalize line wrapping so phrases match across line breaks
+	text := string(bytes.Join(bytes.Fields(head), []byte(" ")))
+
+	for _, signature := range licenseSignatures {
+		matched := true
+		for _, phrase := range signature.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return signature.id
+		}
+	}
+	return ""
+}
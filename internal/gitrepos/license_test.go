@@ -0,0 +1,78 @@
+package gitrepos
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectLicense(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "MIT",
+			files: map[string]string{"LICENSE": "MIT License\n\nPermission is hereby granted, free\nof charge, to any person"},
+			want:  "MIT",
+		},
+		{
+			name:  "Apache with extension",
+			files: map[string]string{"LICENSE.txt": "Apache License\nVersion 2.0, January 2004"},
+			want:  "Apache-2.0",
+		},
+		{
+			name:  "GPL-3.0 in COPYING",
+			files: map[string]string{"COPYING": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007"},
+			want:  "GPL-3.0",
+		},
+		{
+			name: "BSD-3-Clause",
+			files: map[string]string{"licence.md": "Redistribution and use in source and binary forms, with or without\n" +
+				"modification, are permitted.\n3. Neither the name of the copyright holder"},
+			want: "BSD-3-Clause",
+		},
+		{
+			name:  "unrecognized text",
+			files: map[string]string{"LICENSE": "All rights reserved."},
+			want:  LicenseUnrecognized,
+		},
+		{
+			name:  "no license file",
+			files: map[string]string{"README.md": "MIT License"},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := DetectLicense(dir); got != tt.want {
+				t.Errorf("DetectLicense() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLicense_MissingDir(t *testing.T) {
+	if got := DetectLicense(filepath.Join(t.TempDir(), "missing")); got != "" {
+		t.Errorf("DetectLicense() = %q, want empty", got)
+	}
+}
+
+func TestService_RecordsLicense(t *testing.T) {
+	svc := setupSearchService(t, t.TempDir(), map[string]string{
+		"LICENSE": "ISC License\n\nCopyright (c) 2026",
+		"main.go": "package main",
+	})
+	defer func() { _ = svc.Close() }()
+
+	if got := svc.manifest.GetRepoState("github.com_test_repo").License; got != "ISC" {
+		t.Errorf("License = %q, want ISC", got)
+	}
+}
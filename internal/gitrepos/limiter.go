@@ -0,0 +1,80 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrServerBusy is returned when a search or file read cannot run or queue
+// because the concurrency limit and the queue are both used up.
+var ErrServerBusy = errors.New("server busy")
+
+// toolLimiter caps the number of concurrent calls of a kind of tool, such as
+// searches. Calls over the cap wait in a bounded queue; once that is full
+// they are rejected immediately.
+type toolLimiter struct {
+	kind          string // plural noun for the calls, e.g. "searches"
+	maxConcurrent int
+	queueSize     int
+	slots         chan struct{} // nil when unlimited
+	queue         chan struct{}
+}
+
+// newToolLimiter creates a limiter allowing maxConcurrent calls with up to
+// queueSize waiting. A maxConcurrent of 0 disables the limit.
+func newToolLimiter(kind string, maxConcurrent, queueSize int) *toolLimiter {
+	l := &toolLimiter{
+		kind:          kind,
+		maxConcurrent: maxConcurrent,
+		queueSize:     queueSize,
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+		l.queue = make(chan struct{}, queueSize)
+	}
+	return l
+}
+
+// matches reports whether the limiter was created with the given limits.
+func (l *toolLimiter) matches(maxConcurrent, queueSize int) bool {
+	return l.maxConcurrent == maxConcurrent && l.queueSize == queueSize
+}
+
+// acquire takes a slot, waiting in the queue if needed, and returns the
+// function that releases it.
+func (l *toolLimiter) acquire(ctx context.Context) (func(), error) {
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return nil, fmt.Errorf("%w: %d %s running and %d queued, retry shortly", ErrServerBusy, l.maxConcurrent, l.kind, l.queueSize)
+	}
+	defer func() { <-l.queue }()
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// withTimeout bounds ctx by timeout; a timeout of 0 leaves it unbounded.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
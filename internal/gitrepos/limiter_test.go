@@ -0,0 +1,74 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToolLimiter_Unlimited(t *testing.T) {
+	limiter := newToolLimiter("searches", 0, 0)
+	for range 100 {
+		if _, err := limiter.acquire(context.Background()); err != nil {
+			t.Fatalf("Expected no limit, got: %v", err)
+		}
+	}
+}
+
+func TestToolLimiter_QueuesThenRejects(t *testing.T) {
+	limiter := newToolLimiter("searches", 1, 1)
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	// The second search waits in the queue until the first releases its slot
+	queued := make(chan error, 1)
+	go func() {
+		release, err := limiter.acquire(context.Background())
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	waitFor(t, func() bool { return len(limiter.queue) == 1 })
+
+	// The queue is full, so the third is rejected immediately
+	if _, err := limiter.acquire(context.Background()); !errors.Is(err, ErrServerBusy) {
+		t.Errorf("Expected ErrServerBusy, got: %v", err)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Errorf("Expected queued search to run, got: %v", err)
+	}
+}
+
+func TestToolLimiter_QueuedSearchCancelled(t *testing.T) {
+	limiter := newToolLimiter("searches", 1, 1)
+	if _, err := limiter.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+	if len(limiter.queue) != 0 {
+		t.Error("Expected the cancelled search to leave the queue")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
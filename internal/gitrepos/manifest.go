@@ -1,10 +1,16 @@
 package gitrepos
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,12 +23,24 @@ const (
 	ManifestFilename = "manifest.json"
 )
 
-// Manifest stores the sync state for all repositories.
+// Manifest stores the sync state for all repositories. Times are stored in
+// UTC.
 type Manifest struct {
-	Version  int                  `json:"version"`
-	LastSync time.Time            `json:"last_sync"`
-	Repos    map[string]RepoState `json:"repos"`
-	mu       sync.RWMutex         `json:"-"`
+	Version  int       `json:"version"`
+	LastSync time.Time `json:"last_sync"`
+	// Generation is incremented by every sync that changed the indexes.
+	// Read-only servers watch it to know when to reopen indexes published by
+	// an external sync.
+	Generation uint64 `json:"generation"`
+	// Rewriting lists the repositories whose indexes an external sync process
+	// is rewriting, signalling read-only servers to release those index
+	// handles while they keep serving the others.
+	Rewriting []string `json:"rewriting,omitempty"`
+	// Syncing is set along with Rewriting for read-only servers of earlier
+	// versions, which release all their index handles while it is set.
+	Syncing bool                 `json:"syncing,omitempty"`
+	Repos   map[string]RepoState `json:"repos"`
+	mu      sync.RWMutex         `json:"-"`
 }
 
 // RepoState stores the sync state for a single repository.
@@ -33,7 +51,110 @@ type RepoState struct {
 	LastCommit  string    `json:"last_commit"`
 	LastIndexed string    `json:"last_indexed"`
 	FileCount   int       `json:"file_count"`
-	Error       string    `json:"error,omitempty"`
+	// Branch is the branch the clone was made of, when set with
+	// git-repos-branches; empty for the branch the remote HEAD points to
+	Branch string `json:"branch,omitempty"`
+	// License is the SPDX identifier of the license file in the repository
+	// root, LicenseUnrecognized, or empty if there is none
+	License string `json:"license,omitempty"`
+	// IndexVersion is the IndexMappingVersion the index was built with
+	IndexVersion int `json:"index_version,omitempty"`
+	// Warning describes a sync that succeeded with reduced coverage, such as
+	// an index stopped at its size budget
+	Warning string `json:"warning,omitempty"`
+	// Verification is the outcome of verifying the last full index, when
+	// enabled: VerificationOK or the reason it failed
+	Verification string `json:"verification,omitempty"`
+	// Skipped summarizes files left out of the last full index
+	Skipped *SkipStats `json:"skipped,omitempty"`
+	// RemovedAt is when the repository was removed from the configuration;
+	// its clone and index are kept until the retention period ends
+	RemovedAt time.Time `json:"removed_at,omitzero"`
+	// Snapshots are the indexed snapshots of configured refs, by ref
+	Snapshots map[string]RefSnapshot `json:"snapshots,omitempty"`
+	// History is the history index of recently changed or deleted files, when
+	// enabled
+	History *HistorySnapshot `json:"history,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// Skip reasons recorded in SkipStats.
+const (
+	SkipReasonExcluded   = "excluded"
+	SkipReasonTooLarge   = "too_large"
+	SkipReasonBinary     = "binary"
+	SkipReasonSymlink    = "symlink"
+	SkipReasonUnreadable = "unreadable"
+)
+
+// MaxLargestSkipped is the number of largest skipped files kept in SkipStats.
+const MaxLargestSkipped = 10
+
+// SkipStats counts files that were not indexed, by reason.
+type SkipStats struct {
+	Counts  map[string]int `json:"counts,omitempty"`
+	Largest []SkippedFile  `json:"largest,omitempty"` // largest skipped files, biggest first
+	// ExcludedBy counts the excluded files by the first exclusion pattern
+	// that matched them, and ExcludedTypes by file extension ("" for none)
+	ExcludedBy    map[string]ExcludedCount `json:"excluded_by,omitempty"`
+	ExcludedTypes map[string]int           `json:"excluded_types,omitempty"`
+}
+
+// ExcludedCount is the number and total size of files excluded by a pattern.
+type ExcludedCount struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// SkippedFile is a file that was not indexed.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+// Total returns the number of skipped files.
+func (s *SkipStats) Total() int {
+	total := 0
+	for _, n := range s.Counts {
+		total += n
+	}
+	return total
+}
+
+// add records a skipped file. Files of unknown size (-1) are counted but
+// not ranked.
+func (s *SkipStats) add(path string, size int64, reason string) {
+	if s.Counts == nil {
+		s.Counts = make(map[string]int)
+	}
+	s.Counts[reason]++
+
+	if size < 0 {
+		return
+	}
+	if len(s.Largest) == MaxLargestSkipped && size <= s.Largest[len(s.Largest)-1].Size {
+		return
+	}
+	pos := sort.Search(len(s.Largest), func(i int) bool { return s.Largest[i].Size < size })
+	s.Largest = slices.Insert(s.Largest, pos, SkippedFile{Path: path, Size: size, Reason: reason})
+	if len(s.Largest) > MaxLargestSkipped {
+		s.Largest = s.Largest[:MaxLargestSkipped]
+	}
+}
+
+// addExcluded records a file excluded by pattern.
+func (s *SkipStats) addExcluded(path string, size int64, pattern string) {
+	s.add(path, size, SkipReasonExcluded)
+	if s.ExcludedBy == nil {
+		s.ExcludedBy = make(map[string]ExcludedCount)
+		s.ExcludedTypes = make(map[string]int)
+	}
+	count := s.ExcludedBy[pattern]
+	count.Files++
+	count.Bytes += max(size, 0)
+	s.ExcludedBy[pattern] = count
+	s.ExcludedTypes[strings.ToLower(GetFileExtension(path))]++
 }
 
 // NewManifest creates a new empty manifest.
@@ -64,6 +185,12 @@ func LoadManifest(path string) (*Manifest, error) {
 		manifest.Repos = make(map[string]RepoState)
 	}
 
+	// Manifests written by older versions hold local times with an offset
+	manifest.LastSync = manifest.LastSync.UTC()
+	for repoID, state := range manifest.Repos {
+		manifest.Repos[repoID] = state.inUTC()
+	}
+
 	return &manifest, nil
 }
 
@@ -116,7 +243,28 @@ func (m *Manifest) GetRepoState(repoID string) *RepoState {
 func (m *Manifest) SetRepoState(repoID string, state RepoState) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.Repos[repoID] = state
+	m.Repos[repoID] = state.inUTC()
+}
+
+// inUTC returns the state with its times in UTC.
+func (s RepoState) inUTC() RepoState {
+	s.ClonedAt = s.ClonedAt.UTC()
+	s.LastPull = s.LastPull.UTC()
+	s.RemovedAt = s.RemovedAt.UTC()
+	if len(s.Snapshots) > 0 {
+		snapshots := make(map[string]RefSnapshot, len(s.Snapshots))
+		for ref, snapshot := range s.Snapshots {
+			snapshot.IndexedAt = snapshot.IndexedAt.UTC()
+			snapshots[ref] = snapshot
+		}
+		s.Snapshots = snapshots
+	}
+	if s.History != nil {
+		history := *s.History
+		history.IndexedAt = history.IndexedAt.UTC()
+		s.History = &history
+	}
+	return s
 }
 
 // HasRepo returns true if the repository exists in the manifest.
@@ -148,6 +296,15 @@ func (m *Manifest) GetRepoIDs() []string {
 // RemoveStaleRepos removes repositories not in the given URL list.
 // Returns the list of removed repository IDs.
 func (m *Manifest) RemoveStaleRepos(urls []string) []string {
+	_, removed := m.ExpireStaleRepos(urls, 0)
+	return removed
+}
+
+// ExpireStaleRepos marks repositories not in the given URL list as removed
+// and drops the ones that have been marked for at least retention.
+// Repositories back in the list are unmarked. Returns the IDs of newly
+// marked repositories and of dropped ones.
+func (m *Manifest) ExpireStaleRepos(urls []string, retention time.Duration) (marked, removed []string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -158,27 +315,87 @@ func (m *Manifest) RemoveStaleRepos(urls []string) []string {
 		expected[repoID] = true
 	}
 
-	// Find repos to remove
-	var removed []string
-	for repoID := range m.Repos {
-		if !expected[repoID] {
+	now := time.Now().UTC()
+	for repoID, state := range m.Repos {
+		if expected[repoID] {
+			if !state.RemovedAt.IsZero() {
+				state.RemovedAt = time.Time{}
+				m.Repos[repoID] = state
+			}
+			continue
+		}
+
+		newlyMarked := state.RemovedAt.IsZero()
+		if newlyMarked {
+			state.RemovedAt = now
+			m.Repos[repoID] = state
+		}
+		if now.Sub(state.RemovedAt) >= retention {
 			removed = append(removed, repoID)
+		} else if newlyMarked {
+			marked = append(marked, repoID)
 		}
 	}
 
-	// Remove stale repos
+	// Remove expired repos
 	for _, repoID := range removed {
 		delete(m.Repos, repoID)
 	}
 
-	return removed
+	return marked, removed
+}
+
+// UpdateLastSync updates the last sync timestamp. The generation advances
+// when changed is set or indexes were marked as being rewritten, whose marks
+// are cleared.
+func (m *Manifest) UpdateLastSync(changed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.LastSync = time.Now().UTC()
+	if changed || len(m.Rewriting) > 0 {
+		m.Generation++
+	}
+	m.Rewriting = nil
+	m.Syncing = false
 }
 
-// UpdateLastSync updates the last sync timestamp.
-func (m *Manifest) UpdateLastSync() {
+// MarkRewriting marks the index of a repository as being rewritten by an
+// external sync, until the next UpdateLastSync.
+func (m *Manifest) MarkRewriting(repoID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.LastSync = time.Now()
+	if !slices.Contains(m.Rewriting, repoID) {
+		m.Rewriting = append(m.Rewriting, repoID)
+	}
+	m.Syncing = true
+}
+
+// StateDigest returns a digest of the repository states, to tell whether a
+// sync changed any of them. Pull times are left out, since they advance on
+// every sync.
+func (m *Manifest) StateDigest() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	hash := sha256.New()
+	for _, repoID := range slices.Sorted(maps.Keys(m.Repos)) {
+		fmt.Fprintf(hash, "%s=%s\n", repoID, repoStateKey(m.Repos[repoID]))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// repoStateKey returns the state without its pull time, encoded for
+// comparison.
+func repoStateKey(state RepoState) string {
+	state.LastPull = time.Time{}
+	data, _ := json.Marshal(state)
+	return string(data)
+}
+
+// GetGeneration returns the current sync generation.
+func (m *Manifest) GetGeneration() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Generation
 }
 
 // NeedsSyncCheck returns true if enough time has passed since the last sync.
@@ -204,6 +421,19 @@ func (m *Manifest) GetReposWithErrors() map[string]string {
 	return result
 }
 
+// GetReposWithWarnings returns a list of repositories that have warnings.
+func (m *Manifest) GetReposWithWarnings() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[string]string)
+	for repoID, state := range m.Repos {
+		if state.Warning != "" {
+			result[repoID] = state.Warning
+		}
+	}
+	return result
+}
+
 // ClearRepoError clears the error for a repository.
 func (m *Manifest) ClearRepoError(repoID string) {
 	m.mu.Lock()
@@ -2,6 +2,7 @@ package gitrepos
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
@@ -127,6 +128,58 @@ func TestLoadManifest_NilRepos(t *testing.T) {
 	}
 }
 
+func TestLoadManifest_LocalTimesAsUTC(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+
+	// Older versions stored local times with their offset
+	data := `{"version": 1, "last_sync": "2026-01-02T05:04:05+02:00", "repos": {"github.com_org_repo": {
+		"cloned_at": "2025-12-31T19:00:00-05:00",
+		"last_pull": "2026-01-02T05:04:05+02:00",
+		"snapshots": {"v1.0": {"commit": "abc", "indexed_at": "2026-01-01T12:30:00+05:30"}}
+	}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	state := m.Repos["github.com_org_repo"]
+	for name, got := range map[string]time.Time{
+		"last_sync":  m.LastSync,
+		"cloned_at":  state.ClonedAt,
+		"last_pull":  state.LastPull,
+		"indexed_at": state.Snapshots["v1.0"].IndexedAt,
+	} {
+		if got.Location() != time.UTC {
+			t.Errorf("Expected %s in UTC, got %s", name, got)
+		}
+	}
+	if want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC); !state.LastPull.Equal(want) || state.LastPull.String() != want.String() {
+		t.Errorf("LastPull = %s, want %s", state.LastPull, want)
+	}
+
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), `"last_pull": "2026-01-02T03:04:05Z"`) || !strings.Contains(string(saved), `"cloned_at": "2026-01-01T00:00:00Z"`) {
+		t.Errorf("Expected UTC times in the saved manifest, got %s", saved)
+	}
+}
+
+func TestManifest_SetRepoState_UTC(t *testing.T) {
+	m := NewManifest()
+	local := time.Date(2026, 1, 2, 5, 4, 5, 0, time.FixedZone("EET", 2*60*60))
+	m.SetRepoState("repo", RepoState{LastPull: local})
+
+	if got := m.GetRepoState("repo").LastPull; got.Location() != time.UTC || !got.Equal(local) {
+		t.Errorf("Expected the same instant in UTC, got %s", got)
+	}
+}
+
 func TestManifest_Save(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "state", "manifest.json")
@@ -391,16 +444,110 @@ func TestManifest_RemoveStaleRepos_NoneRemoved(t *testing.T) {
 	}
 }
 
+func TestManifest_ExpireStaleRepos(t *testing.T) {
+	m := NewManifest()
+	m.Repos["github.com_org_kept"] = RepoState{}
+	m.Repos["github.com_org_new"] = RepoState{}
+	m.Repos["github.com_org_old"] = RepoState{RemovedAt: time.Now().Add(-2 * time.Hour)}
+	m.Repos["github.com_org_back"] = RepoState{RemovedAt: time.Now().Add(-time.Minute)}
+
+	urls := []string{"git@github.com:org/kept.git", "git@github.com:org/back.git"}
+	marked, removed := m.ExpireStaleRepos(urls, time.Hour)
+
+	if len(marked) != 1 || marked[0] != "github.com_org_new" {
+		t.Errorf("Expected only the new removal to be marked, got %v", marked)
+	}
+	if len(removed) != 1 || removed[0] != "github.com_org_old" {
+		t.Errorf("Expected only the expired repo to be removed, got %v", removed)
+	}
+	if state := m.GetRepoState("github.com_org_new"); state.RemovedAt.IsZero() {
+		t.Error("Expected the new removal to be recorded")
+	}
+	if state := m.GetRepoState("github.com_org_back"); !state.RemovedAt.IsZero() {
+		t.Error("Expected a repository added back to be restored")
+	}
+
+	// Marked repositories are not reported again
+	if marked, removed := m.ExpireStaleRepos(urls, time.Hour); len(marked) != 0 || len(removed) != 0 {
+		t.Errorf("Expected no changes, got marked %v, removed %v", marked, removed)
+	}
+}
+
 func TestManifest_UpdateLastSync(t *testing.T) {
 	m := NewManifest()
 
 	before := time.Now()
-	m.UpdateLastSync()
+	m.UpdateLastSync(true)
 	after := time.Now()
 
 	if m.LastSync.Before(before) || m.LastSync.After(after) {
 		t.Error("LastSync should be between before and after")
 	}
+	if m.LastSync.Location() != time.UTC {
+		t.Errorf("LastSync should be in UTC, got %s", m.LastSync.Location())
+	}
+	if m.GetGeneration() != 1 {
+		t.Errorf("Generation = %d, want 1", m.GetGeneration())
+	}
+
+	m.UpdateLastSync(true)
+	if m.GetGeneration() != 2 {
+		t.Errorf("Generation = %d, want 2", m.GetGeneration())
+	}
+
+	// A sync that changed nothing keeps the generation
+	m.UpdateLastSync(false)
+	if m.GetGeneration() != 2 {
+		t.Errorf("Generation = %d, want 2", m.GetGeneration())
+	}
+}
+
+func TestManifest_MarkRewriting_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	m := NewManifest()
+	m.MarkRewriting("github.com_org_a")
+	m.MarkRewriting("github.com_org_b")
+	m.MarkRewriting("github.com_org_a")
+
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if !slices.Equal(loaded.Rewriting, []string{"github.com_org_a", "github.com_org_b"}) {
+		t.Errorf("Rewriting = %v, want both repositories once", loaded.Rewriting)
+	}
+	if !loaded.Syncing {
+		t.Error("Expected Syncing to be set for readers of earlier versions")
+	}
+
+	// Marked indexes are republished even if no state changed
+	m.UpdateLastSync(false)
+	if m.GetGeneration() != 1 {
+		t.Errorf("Generation = %d, want 1", m.GetGeneration())
+	}
+	if len(m.Rewriting) != 0 || m.Syncing {
+		t.Errorf("Expected the marks to be cleared, got %v, syncing %v", m.Rewriting, m.Syncing)
+	}
+}
+
+func TestManifest_StateDigest(t *testing.T) {
+	m := NewManifest()
+	m.SetRepoState("github.com_org_a", RepoState{LastCommit: "abc123", LastPull: time.Now().UTC()})
+	digest := m.StateDigest()
+
+	m.SetRepoState("github.com_org_a", RepoState{LastCommit: "abc123", LastPull: time.Now().UTC().Add(time.Minute)})
+	if m.StateDigest() != digest {
+		t.Error("Expected pull times to be left out of the digest")
+	}
+
+	m.SetRepoState("github.com_org_a", RepoState{LastCommit: "def456"})
+	if m.StateDigest() == digest {
+		t.Error("Expected a new commit to change the digest")
+	}
 }
 
 func TestManifest_NeedsSyncCheck(t *testing.T) {
@@ -447,6 +594,38 @@ func TestManifest_GetReposWithErrors(t *testing.T) {
 	}
 }
 
+func TestManifest_GetReposWithWarnings(t *testing.T) {
+	m := NewManifest()
+	m.Repos["repo1"] = RepoState{Warning: "index budget exceeded"}
+	m.Repos["repo2"] = RepoState{Error: "clone failed"}
+
+	warnings := m.GetReposWithWarnings()
+
+	if len(warnings) != 1 || warnings["repo1"] != "index budget exceeded" {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+}
+
+func TestSkipStats_KeepsLargest(t *testing.T) {
+	stats := &SkipStats{}
+	for i := range MaxLargestSkipped + 5 {
+		stats.add(fmt.Sprintf("file%d", i), int64(i), SkipReasonTooLarge)
+	}
+	stats.add("unknown", -1, SkipReasonUnreadable)
+
+	if stats.Total() != MaxLargestSkipped+6 {
+		t.Errorf("Total() = %d, want %d", stats.Total(), MaxLargestSkipped+6)
+	}
+	if len(stats.Largest) != MaxLargestSkipped {
+		t.Fatalf("Expected %d largest files, got %d", MaxLargestSkipped, len(stats.Largest))
+	}
+	for i, file := range stats.Largest {
+		if want := int64(MaxLargestSkipped + 4 - i); file.Size != want {
+			t.Errorf("Largest[%d].Size = %d, want %d", i, file.Size, want)
+		}
+	}
+}
+
 func TestManifest_ClearRepoError(t *testing.T) {
 	m := NewManifest()
 	m.Repos["repo1"] = RepoState{Error: "some error", FileCount: 10}
@@ -2,6 +2,10 @@ package gitrepos
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
@@ -13,22 +17,111 @@ type mockSearchService struct {
 	alias      bleve.IndexAlias
 	aliasErr   error
 	maxResults int
+	pre, post  string
+	acquireErr error
+	timeout    time.Duration
+	telemetry  *SearchTelemetry
+	experiment string
+	profile    string
+	recent     []string // document IDs of recently modified files
+	pending    []string
+	freshness  []RepoFreshness
+	commits    map[string]string // indexed commits by repository ID
+	deny       []string
+	redactions []*regexp.Regexp
 }
 
-func (m *mockSearchService) IsReady() bool                            { return m.ready }
-func (m *mockSearchService) GetIndexAlias() (bleve.IndexAlias, error) { return m.alias, m.aliasErr }
-func (m *mockSearchService) MaxResults() int                          { return m.maxResults }
+func (m *mockSearchService) IsReady() bool { return m.ready }
+func (m *mockSearchService) ReadDenied(relPath string) bool {
+	return NewFileFilterWithPatterns(m.deny, 0).ShouldExclude(relPath)
+}
+func (m *mockSearchService) Redact(content []byte) ([]byte, int) {
+	return redactContent(content, m.redactions)
+}
+func (m *mockSearchService) AcquireIndexAlias() (bleve.IndexAlias, func(), error) {
+	return m.alias, func() {}, m.aliasErr
+}
+func (m *mockSearchService) RefAlias(_ string) (bleve.IndexAlias, error) {
+	return m.alias, m.aliasErr
+}
+func (m *mockSearchService) MaxResults() int                 { return m.maxResults }
+func (m *mockSearchService) HighlightTags() (string, string) { return m.pre, m.post }
+func (m *mockSearchService) AcquireSearch(_ context.Context) (func(), error) {
+	if m.acquireErr != nil {
+		return nil, m.acquireErr
+	}
+	return func() {}, nil
+}
+func (m *mockSearchService) SearchTimeout() time.Duration { return m.timeout }
+func (m *mockSearchService) Telemetry() *SearchTelemetry  { return m.telemetry }
+func (m *mockSearchService) QueryExperiment() string      { return m.experiment }
+func (m *mockSearchService) RankingProfile() string       { return m.profile }
+func (m *mockSearchService) RecentFiles() []string        { return m.recent }
+func (m *mockSearchService) ExtensionGroup(ext string) []string {
+	return []string{ext}
+}
+func (m *mockSearchService) PendingRepos() []string { return m.pending }
+func (m *mockSearchService) CheckFreshness(_ context.Context, _ string) []RepoFreshness {
+	return m.freshness
+}
+func (m *mockSearchService) IndexedCommit(repoID, _ string) string { return m.commits[repoID] }
 
 // mockReadService implements ReadService for handler tests.
 type mockReadService struct {
 	ready       bool
 	repoDir     string
 	maxFileSize int64
+	symlinks    bool
+	overrides   map[string]int64 // by extension
+	deny        []string
+	redactions  []*regexp.Regexp
+	indexedOnly bool
+	indexed     map[string]bool // by relative path
+	telemetry   *SearchTelemetry
+	acquireErr  error
+	limiter     *toolLimiter // slots of AcquireRead, unlimited if nil
+	timeout     time.Duration
+	pending     []string          // repository IDs still syncing
+	commits     map[string]string // indexed commits by repository ID
 }
 
-func (m *mockReadService) IsReady() bool              { return m.ready }
+func (m *mockReadService) IsReady() bool { return m.ready }
+func (m *mockReadService) IsRepoReady(repoID string) bool {
+	return m.ready && !slices.Contains(m.pending, repoID)
+}
 func (m *mockReadService) GetRepoDir(_ string) string { return m.repoDir }
 func (m *mockReadService) MaxFileSize() int64         { return m.maxFileSize }
+func (m *mockReadService) MaxFileSizeFor(relPath string) int64 {
+	if size, ok := m.overrides[GetFileExtension(relPath)]; ok {
+		return size
+	}
+	return m.maxFileSize
+}
+func (m *mockReadService) FollowSymlinks() bool { return m.symlinks }
+func (m *mockReadService) ReadDenied(relPath string) bool {
+	return NewFileFilterWithPatterns(m.deny, 0).ShouldExclude(relPath)
+}
+func (m *mockReadService) ReadIndexedOnly() bool { return m.indexedOnly }
+func (m *mockReadService) IsIndexed(_, relPath string) bool {
+	return m.indexed[relPath]
+}
+func (m *mockReadService) Telemetry() *SearchTelemetry { return m.telemetry }
+func (m *mockReadService) IndexedCommit(repoID, _ string) string {
+	return m.commits[repoID]
+}
+func (m *mockReadService) AcquireRead(ctx context.Context) (func(), error) {
+	if m.acquireErr != nil {
+		return nil, m.acquireErr
+	}
+	if m.limiter != nil {
+		return m.limiter.acquire(ctx)
+	}
+	return func() {}, nil
+}
+func (m *mockReadService) ReadTimeout() time.Duration { return m.timeout }
+func (m *mockReadService) Redact(content []byte) ([]byte, int) {
+	return redactContent(content, m.redactions)
+}
 
 // mockGitOps implements GitOperations for service tests.
 type mockGitOps struct {
@@ -39,17 +132,46 @@ type mockGitOps struct {
 	headCommitErr   error
 	changedFiles    []string
 	changedFilesErr error
+	version         string
+	versionErr      error
+	lsRemoteErrs    map[string]error  // by URL
+	remoteHeads     map[string]string // by URL
+	changes         []CommitChanges
+	files           map[string]string // by "rev:path"
 }
 
-func (m *mockGitOps) Clone(_ context.Context, _, _ string) error { return m.cloneErr }
-func (m *mockGitOps) Fetch(_ context.Context, _ string) error    { return m.fetchErr }
-func (m *mockGitOps) Reset(_ context.Context, _ string) error    { return m.resetErr }
+func (m *mockGitOps) Clone(_ context.Context, _, _, _ string, _ int) error { return m.cloneErr }
+func (m *mockGitOps) CloneRef(_ context.Context, _, _, _ string) error {
+	return m.cloneErr
+}
+func (m *mockGitOps) Fetch(_ context.Context, _, _ string, _ int) error { return m.fetchErr }
+func (m *mockGitOps) Reset(_ context.Context, _, _ string) error        { return m.resetErr }
 func (m *mockGitOps) GetHeadCommit(_ context.Context, _ string) (string, error) {
 	return m.headCommit, m.headCommitErr
 }
 func (m *mockGitOps) GetChangedFiles(_ context.Context, _, _, _ string) ([]string, error) {
 	return m.changedFiles, m.changedFilesErr
 }
+func (m *mockGitOps) Version(_ context.Context) (string, error) { return m.version, m.versionErr }
+func (m *mockGitOps) LsRemote(_ context.Context, url string) error {
+	return m.lsRemoteErrs[url]
+}
+func (m *mockGitOps) RecentChanges(_ context.Context, _ string, _ int) ([]CommitChanges, error) {
+	return m.changes, nil
+}
+func (m *mockGitOps) ShowFile(_ context.Context, _, rev, path string) ([]byte, error) {
+	content, ok := m.files[rev+":"+path]
+	if !ok {
+		return nil, fmt.Errorf("path %s does not exist in %s", path, rev)
+	}
+	return []byte(content), nil
+}
+func (m *mockGitOps) RemoteHead(_ context.Context, url string) (string, error) {
+	if err := m.lsRemoteErrs[url]; err != nil {
+		return "", err
+	}
+	return m.remoteHeads[url], nil
+}
 
 // mockIndexOps implements IndexOperations for service tests.
 type mockIndexOps struct {
@@ -61,6 +183,11 @@ type mockIndexOps struct {
 	existsMap      map[string]bool
 	alias          bleve.IndexAlias
 	aliasErr       error
+	filter         *FileFilter
+	skipStats      *SkipStats
+	verifyErr      error
+	catalog        *CatalogChanges
+	directories    map[string][]CatalogFile // files passed to IndexDirectories, by repo ID
 }
 
 func (m *mockIndexOps) FullIndex(_, _ string) (int, error) {
@@ -77,14 +204,40 @@ func (m *mockIndexOps) IndexExists(repoID string) bool {
 	return m.existsMap[repoID]
 }
 func (m *mockIndexOps) CreateAlias(_ []string) (bleve.IndexAlias, error) {
+	if m.alias == nil && m.aliasErr == nil {
+		return bleve.NewIndexAlias(), nil
+	}
 	return m.alias, m.aliasErr
 }
+func (m *mockIndexOps) SetFilter(filter *FileFilter)      { m.filter = filter }
+func (m *mockIndexOps) SetBatchLimits(_ int, _ int64)     {}
+func (m *mockIndexOps) SetDeclarations(_ bool)            {}
+func (m *mockIndexOps) SkipStats(_ string) *SkipStats     { return m.skipStats }
+func (m *mockIndexOps) VerifyIndex(_ string, _ int) error { return m.verifyErr }
+func (m *mockIndexOps) CatalogChanges(_ string) *CatalogChanges {
+	changes := m.catalog
+	m.catalog = nil
+	return changes
+}
+func (m *mockIndexOps) IndexDirectories(repoID string, files []CatalogFile) error {
+	if m.directories == nil {
+		m.directories = make(map[string][]CatalogFile)
+	}
+	m.directories[repoID] = files
+	return nil
+}
+
+func (m *mockIndexOps) CopyOpenIndex(_, _ string) (bool, error) { return false, nil }
 
 // mockManifestOps implements ManifestOperations for service tests.
 type mockManifestOps struct {
 	repos       map[string]RepoState
 	staleResult []string
 	saveErr     error
+	rewriting   []string // repositories marked since the last UpdateLastSync
+	marked      []string // every repository marked as being rewritten
+	saves       int
+	generation  uint64
 }
 
 func newMockManifestOps() *mockManifestOps {
@@ -104,8 +257,25 @@ func (m *mockManifestOps) HasRepo(repoID string) bool {
 	_, ok := m.repos[repoID]
 	return ok
 }
-func (m *mockManifestOps) RemoveStaleRepos(_ []string) []string { return m.staleResult }
-func (m *mockManifestOps) UpdateLastSync()                      {}
+func (m *mockManifestOps) GetRepoIDs() []string {
+	return slices.Collect(maps.Keys(m.repos))
+}
+func (m *mockManifestOps) RemoveRepo(repoID string) { delete(m.repos, repoID) }
+func (m *mockManifestOps) ExpireStaleRepos(_ []string, _ time.Duration) ([]string, []string) {
+	return nil, m.staleResult
+}
+func (m *mockManifestOps) UpdateLastSync(changed bool) {
+	if changed || len(m.rewriting) > 0 {
+		m.generation++
+	}
+	m.rewriting = nil
+}
+func (m *mockManifestOps) GetGeneration() uint64 { return m.generation }
+func (m *mockManifestOps) MarkRewriting(repoID string) {
+	m.rewriting = append(m.rewriting, repoID)
+	m.marked = append(m.marked, repoID)
+}
+func (m *mockManifestOps) StateDigest() string { return fmt.Sprint(m.repos) }
 func (m *mockManifestOps) ClearRepoError(repoID string) {
 	if state, ok := m.repos[repoID]; ok {
 		state.Error = ""
@@ -120,7 +290,10 @@ func (m *mockManifestOps) SetRepoError(repoID string, err string) {
 		m.repos[repoID] = RepoState{Error: err}
 	}
 }
-func (m *mockManifestOps) Save(_ string) error { return m.saveErr }
+func (m *mockManifestOps) Save(_ string) error {
+	m.saves++
+	return m.saveErr
+}
 
 // mockSyncLock implements SyncLock for service tests.
 type mockSyncLock struct {
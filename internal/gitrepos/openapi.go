@@ -0,0 +1,47 @@
+package gitrepos
+
+import (
+	"slices"
+	"strings"
+)
+
+// openAPIMethods are the path item keys that hold operations
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// extractOpenAPISymbols returns the paths and operation IDs declared by an
+// OpenAPI or Swagger spec. Other JSON and YAML files have no symbols.
+func extractOpenAPISymbols(ext, content string) []string {
+	// Skip parsing files that cannot be specs
+	if !strings.Contains(content, "openapi") && !strings.Contains(content, "swagger") {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, doc := range decodeDocuments(ext, []byte(content)) {
+		spec, ok := doc.(map[string]any)
+		if !ok || (spec["openapi"] == nil && spec["swagger"] == nil) {
+			continue
+		}
+		paths, _ := spec["paths"].(map[string]any)
+		for path, item := range paths {
+			seen[path] = true
+			operations, _ := item.(map[string]any)
+			for _, method := range openAPIMethods {
+				operation, _ := operations[method].(map[string]any)
+				if id, ok := operation["operationId"].(string); ok && id != "" {
+					seen[id] = true
+				}
+			}
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	symbols := make([]string, 0, len(seen))
+	for symbol := range seen {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+	return symbols
+}
@@ -0,0 +1,113 @@
+package gitrepos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// RepoOverlayFilename is the file in the base directory that records the
+// repositories added and removed with the update_repositories tool.
+const RepoOverlayFilename = "repositories.json"
+
+// RepoOverlay holds the repository changes made at runtime, on top of the
+// configured URLs. It is kept in the base directory, so that the changes
+// survive restarts and configuration reloads.
+type RepoOverlay struct {
+	// Added are the URLs of repositories added to the configured ones
+	Added []string `json:"added,omitempty"`
+	// Removed are the IDs of configured repositories that were removed
+	Removed []string `json:"removed,omitempty"`
+}
+
+// LoadRepoOverlay reads a repository overlay file. A missing file is an empty
+// overlay.
+func LoadRepoOverlay(path string) (*RepoOverlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RepoOverlay{}, nil
+		}
+		return nil, fmt.Errorf("failed to read repository overlay: %w", err)
+	}
+	var overlay RepoOverlay
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse repository overlay %s: %w", path, err)
+	}
+	return &overlay, nil
+}
+
+// Save writes the overlay to path atomically.
+func (o *RepoOverlay) Save(path string) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository overlay: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write repository overlay: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to rename repository overlay: %w", err)
+	}
+	return nil
+}
+
+// Apply returns configured without the removed repositories, followed by the
+// added ones that are not configured.
+func (o *RepoOverlay) Apply(configured []string) []string {
+	urls := make([]string, 0, len(configured)+len(o.Added))
+	seen := make(map[string]bool, len(configured)+len(o.Added))
+	for _, url := range append(slices.Clone(configured), o.Added...) {
+		repoID := URLToRepoID(url)
+		if seen[repoID] || slices.Contains(o.Removed, repoID) {
+			continue
+		}
+		seen[repoID] = true
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// Add records url as added. A configured repository that was removed is
+// restored instead.
+func (o *RepoOverlay) Add(url string, configured []string) {
+	repoID := URLToRepoID(url)
+	o.Removed = slices.DeleteFunc(o.Removed, func(id string) bool { return id == repoID })
+	if slices.ContainsFunc(append(slices.Clone(configured), o.Added...), func(u string) bool { return URLToRepoID(u) == repoID }) {
+		return
+	}
+	o.Added = append(o.Added, url)
+}
+
+// Remove records the repository with repoID as removed.
+func (o *RepoOverlay) Remove(repoID string, configured []string) {
+	o.Added = slices.DeleteFunc(o.Added, func(url string) bool { return URLToRepoID(url) == repoID })
+	if slices.ContainsFunc(configured, func(u string) bool { return URLToRepoID(u) == repoID }) && !slices.Contains(o.Removed, repoID) {
+		o.Removed = append(o.Removed, repoID)
+	}
+}
+
+// withRepoOverlay returns settings with the repository overlay of their base
+// directory applied to the URLs. Settings of a local working directory, and
+// settings without overlay changes, are returned as they are.
+func withRepoOverlay(settings *config.GitReposSettings) (*config.GitReposSettings, error) {
+	if settings.LocalDir != "" {
+		return settings, nil
+	}
+	overlay, err := LoadRepoOverlay(filepath.Join(settings.BaseDir, RepoOverlayFilename))
+	if err != nil {
+		return nil, err
+	}
+	if len(overlay.Added) == 0 && len(overlay.Removed) == 0 {
+		return settings, nil
+	}
+	applied := *settings
+	applied.URLs = overlay.Apply(settings.URLs)
+	return &applied, nil
+}
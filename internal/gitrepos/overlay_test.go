@@ -0,0 +1,57 @@
+package gitrepos
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepoOverlay_Apply(t *testing.T) {
+	configured := []string{"git@github.com:org/a.git", "git@github.com:org/b.git"}
+	overlay := &RepoOverlay{}
+
+	overlay.Add("https://github.com/org/c", configured)
+	overlay.Add("https://github.com/org/a", configured) // already configured
+	overlay.Remove("github.com_org_b", configured)
+	expected := []string{"git@github.com:org/a.git", "https://github.com/org/c"}
+	if got := overlay.Apply(configured); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Apply() = %v, want %v", got, expected)
+	}
+
+	// Undoing the changes empties the overlay
+	overlay.Remove("github.com_org_c", configured)
+	overlay.Add("git@github.com:org/b.git", configured)
+	if len(overlay.Added) != 0 || len(overlay.Removed) != 0 {
+		t.Errorf("Expected an empty overlay, got %+v", overlay)
+	}
+	if got := overlay.Apply(configured); !reflect.DeepEqual(got, configured) {
+		t.Errorf("Apply() = %v, want %v", got, configured)
+	}
+}
+
+func TestLoadRepoOverlay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, RepoOverlayFilename)
+
+	overlay, err := LoadRepoOverlay(path)
+	if err != nil || len(overlay.Added) != 0 || len(overlay.Removed) != 0 {
+		t.Fatalf("Expected an empty overlay for a missing file, got %+v, %v", overlay, err)
+	}
+
+	overlay.Added = []string{"git@github.com:org/c.git"}
+	if err := overlay.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadRepoOverlay(path)
+	if err != nil || !reflect.DeepEqual(loaded, overlay) {
+		t.Errorf("LoadRepoOverlay() = %+v, %v, want %+v", loaded, err, overlay)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRepoOverlay(path); err == nil {
+		t.Error("Expected an error for a corrupt overlay")
+	}
+}
@@ -0,0 +1,58 @@
+package gitrepos
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// bleveModule is the module path of the search library, whose version is
+// read from the build info.
+const bleveModule = "github.com/blevesearch/bleve/v2"
+
+// Platform describes the platform the server was built for and runs on.
+// Locking, file system and index behavior differ between platforms (e.g.
+// Windows), so bug reports should include it.
+type Platform struct {
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+	GoVersion   string `json:"go_version"`
+	CGOEnabled  bool   `json:"cgo_enabled"`
+	IndexEngine string `json:"index_engine"` // e.g. "bleve v2.5.7 (scorch)"
+}
+
+// CurrentPlatform returns the platform of the running binary.
+func CurrentPlatform() Platform {
+	platform := Platform{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+	}
+	bleveVersion := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "CGO_ENABLED" {
+				platform.CGOEnabled = setting.Value == "1"
+			}
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == bleveModule {
+				bleveVersion = " " + dep.Version
+			}
+		}
+	}
+	platform.IndexEngine = fmt.Sprintf("bleve%s (%s)", bleveVersion, bleve.Config.DefaultIndexType)
+	return platform
+}
+
+// String formats the platform on one line, e.g.
+// "linux/amd64, go1.25.0, cgo disabled".
+func (p Platform) String() string {
+	cgo := "disabled"
+	if p.CGOEnabled {
+		cgo = "enabled"
+	}
+	return fmt.Sprintf("%s/%s, %s, cgo %s", p.OS, p.Arch, p.GoVersion, cgo)
+}
@@ -0,0 +1,34 @@
+package gitrepos
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestCurrentPlatform(t *testing.T) {
+	platform := CurrentPlatform()
+	if platform.OS != runtime.GOOS || platform.Arch != runtime.GOARCH || platform.GoVersion != runtime.Version() {
+		t.Errorf("Expected the running platform, got %+v", platform)
+	}
+	if !strings.HasPrefix(platform.IndexEngine, "bleve v2.") || !strings.HasSuffix(platform.IndexEngine, "("+bleve.Config.DefaultIndexType+")") {
+		t.Errorf("Expected the bleve version and index type, got %q", platform.IndexEngine)
+	}
+}
+
+func TestPlatform_String(t *testing.T) {
+	tests := []struct {
+		platform Platform
+		want     string
+	}{
+		{Platform{OS: "linux", Arch: "amd64", GoVersion: "go1.25.0"}, "linux/amd64, go1.25.0, cgo disabled"},
+		{Platform{OS: "windows", Arch: "arm64", GoVersion: "go1.25.0", CGOEnabled: true}, "windows/arm64, go1.25.0, cgo enabled"},
+	}
+	for _, tt := range tests {
+		if got := tt.platform.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// preflightTimeout bounds each startup check, so that an unreachable host
+// delays startup by at most this long
+const preflightTimeout = 20 * time.Second
+
+// Kinds of remote access failures
+const (
+	RemoteErrorAuth        = "auth_denied"
+	RemoteErrorHostKey     = "host_key"
+	RemoteErrorUnreachable = "host_unreachable"
+	RemoteErrorUnknown     = "unknown"
+)
+
+// remoteErrorPatterns map git and ssh messages to the kind of failure they
+// report. Hosts such as GitHub answer "not found" for private repositories
+// the credentials cannot see, so it counts as denied access.
+var remoteErrorPatterns = []struct {
+	kind     string
+	patterns []string
+}{
+	{RemoteErrorHostKey, []string{"Host key verification failed", "REMOTE HOST IDENTIFICATION HAS CHANGED"}},
+	{RemoteErrorAuth, []string{
+		"Permission denied", "Authentication failed", "could not read Username", "could not read Password",
+		"Repository not found", "repository not found", "does not appear to be a git repository",
+		"The requested URL returned error: 401", "The requested URL returned error: 403",
+	}},
+	{RemoteErrorUnreachable, []string{
+		"Could not resolve host", "Could not resolve hostname", "Connection refused", "Connection timed out",
+		"Network is unreachable", "No route to host", "Operation timed out", "Failed to connect",
+	}},
+}
+
+// remoteErrorHints tell the operator what to check for each kind of failure
+var remoteErrorHints = map[string]string{
+	RemoteErrorAuth:        "check that the SSH key or token of the server has read access to the repository",
+	RemoteErrorHostKey:     "add the host key to known_hosts (e.g. ssh-keyscan <host> >> ~/.ssh/known_hosts)",
+	RemoteErrorUnreachable: "check DNS, firewall and proxy settings for the host",
+	RemoteErrorUnknown:     "run git ls-remote with the same URL and user to investigate",
+}
+
+// RemoteCheckError is a startup check of a repository that failed.
+type RemoteCheckError struct {
+	RepoID string
+	Kind   string // one of the RemoteError constants
+	Err    error
+}
+
+func (e *RemoteCheckError) Error() string {
+	return fmt.Sprintf("check %s: %s: %v", e.RepoID, e.Kind, e.Err)
+}
+
+func (e *RemoteCheckError) Unwrap() error { return e.Err }
+
+// Hint returns what to check to resolve the failure.
+func (e *RemoteCheckError) Hint() string {
+	return remoteErrorHints[e.Kind]
+}
+
+// classifyRemoteError returns the kind of a failed remote access.
+func classifyRemoteError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCommandTimeout) {
+		return RemoteErrorUnreachable
+	}
+	msg := err.Error()
+	for _, p := range remoteErrorPatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(msg, pattern) {
+				return p.kind
+			}
+		}
+	}
+	return RemoteErrorUnknown
+}
+
+// Preflight checks that git is installed and that every configured
+// repository can be reached with the credentials of the server, using git
+// ls-remote. Repositories are checked in parallel, each within
+// preflightTimeout. Failures are logged with what to check; the returned
+// error joins them, with a *RemoteCheckError per repository.
+func (s *Service) Preflight(ctx context.Context) error {
+	settings := s.currentSettings()
+
+	versionCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	version, err := s.git.Version(versionCtx)
+	cancel()
+	if err != nil {
+		slog.Error("git is not available; install git and make sure it is on the PATH", "error", err)
+		return fmt.Errorf("git is not available: %w", err)
+	}
+	slog.Info("Checking repository access", "git_version", version, "repos", len(settings.URLs))
+
+	sem := make(chan struct{}, MaxParallelSyncs)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for _, url := range settings.URLs {
+		wg.Add(1)
+		go func(url, repoID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			checkCtx, cancel := context.WithTimeout(ctx, preflightTimeout)
+			defer cancel()
+			if err := s.git.LsRemote(checkCtx, url); err != nil {
+				if checkCtx.Err() != nil {
+					err = fmt.Errorf("%w: no answer within %s", err, preflightTimeout)
+				}
+				checkErr := &RemoteCheckError{RepoID: repoID, Kind: classifyRemoteError(err), Err: err}
+				slog.Error("Repository is not accessible", "repo_id", repoID, "reason", checkErr.Kind, "hint", checkErr.Hint(), "error", err)
+				mu.Lock()
+				errs = append(errs, checkErr)
+				mu.Unlock()
+			}
+		}(url, URLToRepoID(url))
+	}
+	wg.Wait()
+
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.(*RemoteCheckError).RepoID, b.(*RemoteCheckError).RepoID)
+	})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestClassifyRemoteError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("git@github.com: Permission denied (publickey)."), RemoteErrorAuth},
+		{errors.New("ERROR: Repository not found."), RemoteErrorAuth},
+		{errors.New("fatal: could not read Username for 'https://github.com': terminal prompts disabled"), RemoteErrorAuth},
+		{errors.New("Host key verification failed."), RemoteErrorHostKey},
+		{errors.New("ssh: Could not resolve hostname git.example.com: Name or service not known"), RemoteErrorUnreachable},
+		{errors.New("ssh: connect to host git.example.com port 22: Connection refused"), RemoteErrorUnreachable},
+		{fmt.Errorf("git ls-remote failed: %w", context.DeadlineExceeded), RemoteErrorUnreachable},
+		{errors.New("fatal: protocol error: bad line length"), RemoteErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := classifyRemoteError(tt.err); got != tt.want {
+			t.Errorf("classifyRemoteError(%q) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestService_Preflight(t *testing.T) {
+	git := &mockGitOps{
+		version: "2.43.0",
+		lsRemoteErrs: map[string]error{
+			"git@github.com:test/b.git":  errors.New("git@github.com: Permission denied (publickey)."),
+			"git@example.com:test/c.git": errors.New("ssh: connect to host example.com port 22: No route to host"),
+		},
+	}
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir:     t.TempDir(),
+			URLs:        []string{"git@github.com:test/a.git", "git@github.com:test/b.git", "git@example.com:test/c.git"},
+			SyncTimeout: 5 * time.Second,
+		},
+		ServiceDeps{Git: git, Indexer: &mockIndexOps{}, Manifest: newMockManifestOps(), Lock: &mockSyncLock{}},
+	)
+
+	err := svc.Preflight(context.Background())
+	var checkErrs []*RemoteCheckError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var checkErr *RemoteCheckError
+		if !errors.As(e, &checkErr) {
+			t.Fatalf("Expected a RemoteCheckError, got %v", e)
+		}
+		checkErrs = append(checkErrs, checkErr)
+	}
+	if len(checkErrs) != 2 {
+		t.Fatalf("Expected 2 failed checks, got %v", err)
+	}
+	if checkErrs[0].RepoID != "example.com_test_c" || checkErrs[0].Kind != RemoteErrorUnreachable {
+		t.Errorf("Expected the unreachable host first, got %+v", checkErrs[0])
+	}
+	if checkErrs[1].RepoID != "github.com_test_b" || checkErrs[1].Kind != RemoteErrorAuth || checkErrs[1].Hint() == "" {
+		t.Errorf("Expected denied access with a hint, got %+v", checkErrs[1])
+	}
+
+	git.lsRemoteErrs = nil
+	if err := svc.Preflight(context.Background()); err != nil {
+		t.Errorf("Expected all checks to pass, got %v", err)
+	}
+
+	git.versionErr = errors.New(`exec: "git": executable file not found in $PATH`)
+	if err := svc.Preflight(context.Background()); err == nil {
+		t.Error("Expected an error without git")
+	}
+}
@@ -0,0 +1,153 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressPollInterval is how often a waiting tool call checks indexing progress
+const progressPollInterval = 250 * time.Millisecond
+
+// progressTools are the tools that need open indexes and therefore wait for
+// indexing to finish when the client asked for progress notifications.
+var progressTools = map[string]bool{
+	"search":         true,
+	"read":           true,
+	"get_readme":     true,
+	"search_in_file": true,
+}
+
+// IndexProgress reports how far an in-process indexing run has got.
+type IndexProgress struct {
+	Active  bool // indexes are being written and are unavailable
+	Indexed int  // repositories processed so far
+	Total   int  // repositories in this run
+}
+
+// indexProgress tracks the current indexing run. The zero value is inactive.
+type indexProgress struct {
+	mu    sync.Mutex
+	state IndexProgress
+}
+
+// begin starts a run over total repositories.
+func (p *indexProgress) begin(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = IndexProgress{Active: true, Total: total}
+}
+
+// advance records that one more repository was processed.
+func (p *indexProgress) advance() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state.Active && p.state.Indexed < p.state.Total {
+		p.state.Indexed++
+	}
+}
+
+// end finishes the current run.
+func (p *indexProgress) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state = IndexProgress{}
+}
+
+// get returns a snapshot of the current run.
+func (p *indexProgress) get() IndexProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// ProgressMiddleware holds calls to tools that need the indexes while they are
+// being rebuilt, sending MCP progress notifications ("N of M repositories
+// indexed") until they are ready, then runs the call. Only calls carrying a
+// progress token wait; others fail immediately with the usual not-ready error.
+func ProgressMiddleware(service ProgressService) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok || call.Params == nil || !progressTools[call.Params.Name] || service.IsReady() {
+				return next(ctx, method, req)
+			}
+			if token := call.Params.GetProgressToken(); token != nil {
+				waitForIndexes(ctx, call.Session, token, service)
+			}
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// ToolListMiddleware marks the tools that need the indexes as unavailable in
+// tools/list results while the indexes are not ready, so that clients listing
+// tools during a rebuild learn why their calls fail. Registered tools are
+// left unchanged.
+func ToolListMiddleware(service ProgressService) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			result, err := next(ctx, method, req)
+			list, ok := result.(*mcp.ListToolsResult)
+			if err != nil || !ok || service.IsReady() {
+				return result, err
+			}
+			notice := unavailableNotice(service.IndexProgress())
+			tools := make([]*mcp.Tool, len(list.Tools))
+			for n, tool := range list.Tools {
+				tools[n] = tool
+				if progressTools[tool.Name] {
+					unavailable := *tool
+					unavailable.Description = notice + "\n\n" + tool.Description
+					tools[n] = &unavailable
+				}
+			}
+			marked := *list
+			marked.Tools = tools
+			return &marked, nil
+		}
+	}
+}
+
+// unavailableNotice describes why the tools that need the indexes are
+// unavailable.
+func unavailableNotice(progress IndexProgress) string {
+	state := "the indexes are not ready yet"
+	if progress.Active {
+		state = fmt.Sprintf("the indexes are being rebuilt (%d of %d repositories indexed)", progress.Indexed, progress.Total)
+	}
+	return fmt.Sprintf("UNAVAILABLE: %s. Calls fail until indexing finishes, or wait for it when they carry a progress token.", state)
+}
+
+// waitForIndexes blocks until the indexes are ready, no indexing run is in
+// progress, or ctx is done, notifying the client whenever progress changes.
+func waitForIndexes(ctx context.Context, session *mcp.ServerSession, token any, service ProgressService) {
+	ticker := time.NewTicker(progressPollInterval)
+	defer ticker.Stop()
+
+	var last IndexProgress
+	for {
+		progress := service.IndexProgress()
+		if service.IsReady() || !progress.Active {
+			return
+		}
+		if progress != last && session != nil {
+			_ = session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+				ProgressToken: token,
+				Message:       fmt.Sprintf("Indexing in progress: %d of %d repositories indexed", progress.Indexed, progress.Total),
+				Progress:      float64(progress.Indexed),
+				Total:         float64(progress.Total),
+			})
+			last = progress
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package gitrepos
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// mockProgressService implements ProgressService for middleware tests.
+type mockProgressService struct {
+	mu       sync.Mutex
+	ready    bool
+	progress IndexProgress
+}
+
+func (m *mockProgressService) IsReady() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ready
+}
+
+func (m *mockProgressService) IndexProgress() IndexProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.progress
+}
+
+func (m *mockProgressService) set(ready bool, progress IndexProgress) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ready = ready
+	m.progress = progress
+}
+
+func TestIndexProgress(t *testing.T) {
+	var p indexProgress
+	p.advance()
+	if got := p.get(); got != (IndexProgress{}) {
+		t.Errorf("Expected advance to be ignored when inactive, got %+v", got)
+	}
+
+	p.begin(2)
+	p.advance()
+	p.advance()
+	p.advance()
+	if got := p.get(); got != (IndexProgress{Active: true, Indexed: 2, Total: 2}) {
+		t.Errorf("Unexpected progress: %+v", got)
+	}
+
+	p.end()
+	if got := p.get(); got.Active {
+		t.Errorf("Expected inactive progress after end, got %+v", got)
+	}
+}
+
+func TestProgressMiddleware_WithoutTokenDoesNotWait(t *testing.T) {
+	service := &mockProgressService{progress: IndexProgress{Active: true, Total: 3}}
+	called := false
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		called = true
+		return &mcp.CallToolResult{}, nil
+	}
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "search"}}
+	if _, err := ProgressMiddleware(service)(next)(context.Background(), "tools/call", req); err != nil {
+		t.Fatalf("Middleware returned error: %v", err)
+	}
+	if !called {
+		t.Error("Expected the tool to run immediately")
+	}
+}
+
+func TestProgressMiddleware_WaitsAndNotifies(t *testing.T) {
+	ctx := context.Background()
+	service := &mockProgressService{progress: IndexProgress{Active: true, Indexed: 1, Total: 2}}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "search"}, func(ctx context.Context, req *mcp.CallToolRequest, args SearchArgument) (*mcp.CallToolResult, any, error) {
+		if !service.IsReady() {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "not ready"}}, IsError: true}, nil, nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "results"}}}, nil, nil
+	})
+	server.AddReceivingMiddleware(ProgressMiddleware(service))
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Server connect failed: %v", err)
+	}
+	defer func() { _ = serverSession.Close() }()
+
+	notified := make(chan *mcp.ProgressNotificationParams, 10)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			notified <- req.Params
+			// Finish indexing once the client has seen the first update
+			service.set(true, IndexProgress{})
+		},
+	})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Client connect failed: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	params := &mcp.CallToolParams{Meta: mcp.Meta{}, Name: "search", Arguments: map[string]any{"query": "auth"}}
+	params.SetProgressToken("tok")
+	result, err := session.CallTool(ctx, params)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError || ExtractTextContent(result) != "results" {
+		t.Errorf("Expected the call to run once indexes were ready, got: %s", ExtractTextContent(result))
+	}
+
+	select {
+	case p := <-notified:
+		if p.ProgressToken != "tok" || p.Progress != 1 || p.Total != 2 {
+			t.Errorf("Unexpected progress notification: %+v", p)
+		}
+	default:
+		t.Error("Expected a progress notification")
+	}
+}
+
+func TestToolListMiddleware(t *testing.T) {
+	service := &mockProgressService{progress: IndexProgress{Active: true, Indexed: 1, Total: 3}}
+	search := &mcp.Tool{Name: "search", Description: "Search code."}
+	stats := &mcp.Tool{Name: "repo_stats", Description: "Show stats."}
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.ListToolsResult{Tools: []*mcp.Tool{search, stats}}, nil
+	}
+	list := func() []*mcp.Tool {
+		result, err := ToolListMiddleware(service)(next)(context.Background(), "tools/list", &mcp.ListToolsRequest{})
+		if err != nil {
+			t.Fatalf("Middleware returned error: %v", err)
+		}
+		return result.(*mcp.ListToolsResult).Tools
+	}
+
+	tools := list()
+	expected := "UNAVAILABLE: the indexes are being rebuilt (1 of 3 repositories indexed). Calls fail until indexing finishes, or wait for it when they carry a progress token.\n\nSearch code."
+	if tools[0].Description != expected {
+		t.Errorf("Unexpected search description: %q", tools[0].Description)
+	}
+	if tools[1] != stats {
+		t.Error("Expected repo_stats to be listed as registered")
+	}
+	if search.Description != "Search code." {
+		t.Error("Expected the registered tool to be left unchanged")
+	}
+
+	service.set(true, IndexProgress{})
+	if tools := list(); tools[0] != search {
+		t.Error("Expected tools to be listed as registered once ready")
+	}
+}
@@ -0,0 +1,128 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+// Score multipliers of the files a ranking profile prefers
+const (
+	docsBoost   = 5.0              // documentation files, with docs-first
+	recentBoost = 4 * symbolsBoost // files modified by the commits of the history index, with recent-first
+)
+
+// docExtensions are the extensions of documentation files
+var docExtensions = []string{"md", "markdown", "mdx", "rst", "adoc", "txt"}
+
+// rankingProfile is how the searches of a ranking profile are scored: the
+// tuning of the text query, and the files preferred or left out.
+type rankingProfile struct {
+	description  string // for the query syntax resource
+	strategy     queryStrategy
+	preferDocs   bool // documentation files score docsBoost times higher
+	excludeDocs  bool // documentation files are left out
+	preferRecent bool // recently modified files score recentBoost times higher
+}
+
+// rankingProfiles are the profiles by config.RankingProfiles name
+var rankingProfiles = map[string]rankingProfile{
+	config.RankingProfileDefault: {
+		description: "declared symbols first, then content matches",
+		strategy:    defaultQueryStrategy,
+	},
+	config.RankingProfileDefinitions: {
+		description: fmt.Sprintf("symbol matches weigh %gx more than by default; documentation files are left out", 3.0),
+		strategy:    queryStrategy{contentFuzziness: contentFuzziness, symbolsBoost: 3 * symbolsBoost, symbolPartsBoost: 3 * symbolPartsBoost},
+		excludeDocs: true,
+	},
+	config.RankingProfileDocs: {
+		description: fmt.Sprintf("documentation files (%s) score %gx higher; symbols weigh like content", strings.Join(docExtensions, ", "), docsBoost),
+		strategy:    queryStrategy{contentFuzziness: contentFuzziness, symbolsBoost: 1, symbolPartsBoost: 1},
+		preferDocs:  true,
+	},
+	config.RankingProfileRecent: {
+		description:  fmt.Sprintf("files modified by the commits of the history index score %gx higher (needs git-repos-history-commits)", recentBoost),
+		strategy:     defaultQueryStrategy,
+		preferRecent: true,
+	},
+}
+
+// rankingProfileOf returns the ranking profile of a search: the one it
+// selects, else the one of its API key, else the server's.
+func (h *SearchHandler) rankingProfileOf(ctx context.Context, requested string) (string, rankingProfile, error) {
+	name := requested
+	if name == "" {
+		name = auth.RankingProfileFromContext(ctx)
+	}
+	if name == "" {
+		name = h.service.RankingProfile()
+	}
+	if name == "" {
+		name = config.RankingProfileDefault
+	}
+	profile, ok := rankingProfiles[name]
+	if !ok {
+		return "", rankingProfile{}, fmt.Errorf("unknown profile %q (use %s)", name, strings.Join(config.RankingProfiles, ", "))
+	}
+	return name, profile, nil
+}
+
+// rankFiles adds the preferences and exclusions of profile to a file search.
+func (h *SearchHandler) rankFiles(boolQuery *query.BooleanQuery, profile rankingProfile) {
+	if profile.excludeDocs {
+		boolQuery.AddMustNot(docsQuery(1))
+	}
+	if profile.preferDocs {
+		boolQuery.AddShould(docsQuery(docsBoost))
+	}
+	if profile.preferRecent {
+		if ids := h.service.RecentFiles(); len(ids) > 0 {
+			boolQuery.AddShould(recentQuery(ids))
+		}
+	}
+}
+
+// docsQuery matches documentation files, scored by boost. A disjunction
+// does not pass its boost on and scores by the share of its clauses that
+// match, which is one extension per file, so each extension is boosted by
+// that many times boost.
+func docsQuery(boost float64) *query.DisjunctionQuery {
+	var exts []query.Query
+	for _, ext := range docExtensions {
+		extQuery := bleve.NewTermQuery(ext)
+		extQuery.SetField(domain.CodeFieldExtension)
+		extQuery.SetBoost(boost * float64(len(docExtensions)))
+		exts = append(exts, extQuery)
+	}
+	return bleve.NewDisjunctionQuery(exts...)
+}
+
+// maxRecentFiles caps the recently modified files preferred by a search, as
+// each adds a clause to it.
+const maxRecentFiles = 1000
+
+// recentQuery matches the files of ids and their declaration documents,
+// scored by recentBoost. As with docsQuery, each clause is boosted by the
+// number of clauses, as a document matches one of them.
+func recentQuery(ids []string) *query.DisjunctionQuery {
+	if len(ids) > maxRecentFiles {
+		ids = ids[:maxRecentFiles]
+	}
+	boost := recentBoost * float64(len(ids)+1)
+	files := bleve.NewDocIDQuery(ids)
+	files.SetBoost(boost)
+	clauses := []query.Query{files}
+	for _, id := range ids {
+		decls := fileDeclarationsQuery(id)
+		decls.SetBoost(boost)
+		clauses = append(clauses, decls)
+	}
+	return bleve.NewDisjunctionQuery(clauses...)
+}
@@ -0,0 +1,43 @@
+package gitrepos
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// redactedText replaces redacted content in read output
+const redactedText = "[REDACTED]"
+
+// redactContent replaces the matches of patterns in content with
+// redactedText and returns the number of replacements. For patterns with
+// capture groups only the first group is replaced, so that e.g.
+// `password:\s*(\S+)` keeps the key and masks the value.
+func redactContent(content []byte, patterns []*regexp.Regexp) ([]byte, int) {
+	count := 0
+	for _, re := range patterns {
+		matches := re.FindAllSubmatchIndex(content, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var out bytes.Buffer
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) >= 4 {
+				start, end = m[2], m[3]
+			}
+			// Skip empty matches and groups that did not participate
+			if start < 0 || start == end {
+				continue
+			}
+			out.Write(content[last:start])
+			out.WriteString(redactedText)
+			last = end
+			count++
+		}
+		out.Write(content[last:])
+		content = out.Bytes()
+	}
+	return content, count
+}
@@ -0,0 +1,223 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// refSnapshotSeparator separates the repository from the ref in the ID of a
+// ref snapshot. Repository IDs never contain it (see sanitizeForFilesystem).
+const refSnapshotSeparator = "@"
+
+// RefSnapshot records the snapshot of a tag or branch, cloned and indexed
+// separately from the default branch of its repository.
+type RefSnapshot struct {
+	Commit    string    `json:"commit"`
+	IndexedAt time.Time `json:"indexed_at"`
+	FileCount int       `json:"file_count"`
+	// IndexVersion is the IndexMappingVersion the index was built with
+	IndexVersion int `json:"index_version,omitempty"`
+}
+
+// RefSnapshotID returns the ID under which the snapshot of ref is cloned and
+// indexed, e.g. github.com_org_repo@release%2F2.0.
+func RefSnapshotID(repoID, ref string) string {
+	return repoID + refSnapshotSeparator + url.PathEscape(ref)
+}
+
+// DefaultBranchRef names the default branch among the refs of a search.
+const DefaultBranchRef = "HEAD"
+
+// parseRefs splits the ref argument of a search into its distinct refs. A
+// search of the default branch alone has none.
+func parseRefs(ref string) []string {
+	var refs []string
+	for _, r := range strings.Split(ref, ",") {
+		if r = strings.TrimSpace(r); r != "" && !slices.Contains(refs, r) {
+			refs = append(refs, r)
+		}
+	}
+	if len(refs) == 1 && refs[0] == DefaultBranchRef {
+		return nil
+	}
+	return refs
+}
+
+// docRepoID returns the ID of the index a document was found in: the
+// repository or ref snapshot ID its document ID starts with.
+func docRepoID(docID string) string {
+	repoID, _, _ := strings.Cut(docID, "/")
+	return repoID
+}
+
+// docRef returns the ref of the snapshot a document was found in, or "" for
+// the default branch.
+func docRef(docID string) string {
+	_, ref, ok := strings.Cut(docRepoID(docID), refSnapshotSeparator)
+	if !ok {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		return unescaped
+	}
+	return ref
+}
+
+// baseRepoID returns the repository ID of a ref snapshot ID, or id itself if
+// it is not a snapshot.
+func baseRepoID(id string) string {
+	repoID, _, _ := strings.Cut(id, refSnapshotSeparator)
+	return repoID
+}
+
+// syncRefSnapshots takes the configured ref snapshots of a repository that
+// are missing or were indexed with an older mapping, and removes the ones no
+// longer configured. Snapshots are taken once: refs are expected to be tags
+// or other refs that do not move. Refs missing from the repository are
+// logged and skipped.
+func (s *Service) syncRefSnapshots(ctx context.Context, repoID, url string) {
+	refs := s.currentSettings().Refs
+	state := s.manifest.GetRepoState(repoID)
+	if len(refs) == 0 && len(state.Snapshots) == 0 {
+		return
+	}
+
+	snapshots := maps.Clone(state.Snapshots)
+	if snapshots == nil {
+		snapshots = make(map[string]RefSnapshot)
+	}
+
+	for ref := range snapshots {
+		if !slices.Contains(refs, ref) {
+			slog.Info("Removing ref snapshot", "repo_id", repoID, "ref", ref)
+			s.removeRefSnapshot(RefSnapshotID(repoID, ref))
+			delete(snapshots, ref)
+		}
+	}
+
+	for _, ref := range refs {
+		id := RefSnapshotID(repoID, ref)
+		if snapshot, ok := snapshots[ref]; ok && snapshot.IndexVersion == IndexMappingVersion && s.indexer.IndexExists(id) {
+			continue
+		}
+		snapshot, err := s.takeRefSnapshot(ctx, id, url, ref)
+		if err != nil {
+			slog.Warn("Failed to take ref snapshot", "repo_id", repoID, "ref", ref, "error", err)
+			continue
+		}
+		snapshots[ref] = snapshot
+		slog.Info("Indexed ref snapshot", "repo_id", repoID, "ref", ref, "commit", snapshot.Commit, "files", snapshot.FileCount)
+	}
+
+	state = s.manifest.GetRepoState(repoID)
+	state.Snapshots = snapshots
+	if len(snapshots) == 0 {
+		state.Snapshots = nil
+	}
+	s.manifest.SetRepoState(repoID, *state)
+}
+
+// takeRefSnapshot clones ref, unless a clone is already there, and rebuilds
+// its index.
+func (s *Service) takeRefSnapshot(ctx context.Context, id, url, ref string) (RefSnapshot, error) {
+	dir := s.GetRepoDir(id)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := s.git.CloneRef(ctx, url, ref, dir); err != nil {
+			return RefSnapshot{}, fmt.Errorf("clone failed: %w", err)
+		}
+	}
+
+	commit, err := s.git.GetHeadCommit(ctx, dir)
+	if err != nil {
+		return RefSnapshot{}, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	if err := s.indexer.DeleteIndex(id); err != nil {
+		return RefSnapshot{}, fmt.Errorf("failed to delete index: %w", err)
+	}
+	count, err := s.indexer.FullIndex(id, dir)
+	if err != nil && !errors.Is(err, ErrIndexBudgetExceeded) {
+		return RefSnapshot{}, fmt.Errorf("index failed: %w", err)
+	}
+
+	return RefSnapshot{
+		Commit:       commit,
+		IndexedAt:    time.Now().UTC(),
+		FileCount:    count,
+		IndexVersion: IndexMappingVersion,
+	}, nil
+}
+
+// removeRefSnapshot deletes the index and clone of a ref snapshot.
+func (s *Service) removeRefSnapshot(id string) {
+	if err := s.indexer.DeleteIndex(id); err != nil {
+		slog.Error("Failed to delete ref snapshot index", "id", id, "error", err)
+	}
+	if err := os.RemoveAll(filepath.Join(s.currentSettings().ReposPath(), id)); err != nil {
+		slog.Error("Failed to remove ref snapshot directory", "id", id, "error", err)
+	}
+}
+
+// removeAllRefSnapshots deletes every ref snapshot of a repository found on
+// disk, for repositories that are no longer configured.
+func (s *Service) removeAllRefSnapshots(repoID string) {
+	settings := s.currentSettings()
+	pattern := repoID + refSnapshotSeparator + "*"
+	clones, _ := filepath.Glob(filepath.Join(settings.ReposPath(), pattern))
+	indexes, _ := filepath.Glob(filepath.Join(settings.IndexesPath(), pattern+IndexSuffix))
+
+	ids := make(map[string]bool)
+	for _, path := range clones {
+		ids[filepath.Base(path)] = true
+	}
+	for _, path := range indexes {
+		ids[strings.TrimSuffix(filepath.Base(path), IndexSuffix)] = true
+	}
+	for id := range ids {
+		s.removeRefSnapshot(id)
+	}
+}
+
+// Refs returns the refs that have at least one indexed snapshot.
+func (s *Service) Refs() []string {
+	var refs []string
+	for _, repoID := range ConfiguredRepoIDs(s.currentSettings()) {
+		for ref := range s.manifest.GetRepoState(repoID).Snapshots {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	slices.Sort(refs)
+	return refs
+}
+
+// RefAlias returns an alias over the snapshots of ref in all repositories
+// that have one. The caller must close it.
+func (s *Service) RefAlias(ref string) (bleve.IndexAlias, error) {
+	var ids []string
+	for _, repoID := range ConfiguredRepoIDs(s.currentSettings()) {
+		id := RefSnapshotID(repoID, ref)
+		if _, ok := s.manifest.GetRepoState(repoID).Snapshots[ref]; ok && s.indexer.IndexExists(id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		if refs := s.Refs(); len(refs) > 0 {
+			return nil, fmt.Errorf("ref %s is not indexed (indexed refs: %s)", ref, strings.Join(refs, ", "))
+		}
+		return nil, fmt.Errorf("ref %s is not indexed (no refs are configured)", ref)
+	}
+	return s.indexer.CreateAlias(ids)
+}
@@ -0,0 +1,197 @@
+package gitrepos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestRefSnapshotID(t *testing.T) {
+	tests := []struct {
+		repoID string
+		ref    string
+		want   string
+	}{
+		{"github.com_org_repo", "v1.0.0", "github.com_org_repo@v1.0.0"},
+		{"github.com_org_repo", "release/2.0", "github.com_org_repo@release%2F2.0"},
+	}
+
+	for _, tt := range tests {
+		id := RefSnapshotID(tt.repoID, tt.ref)
+		if id != tt.want {
+			t.Errorf("RefSnapshotID(%q, %q) = %q, want %q", tt.repoID, tt.ref, id, tt.want)
+		}
+		if base := baseRepoID(id); base != tt.repoID {
+			t.Errorf("baseRepoID(%q) = %q, want %q", id, base, tt.repoID)
+		}
+	}
+
+	if base := baseRepoID("github.com_org_repo"); base != "github.com_org_repo" {
+		t.Errorf("Expected a repo ID to be its own base, got %q", base)
+	}
+}
+
+// setupRefService creates a service with a v1.0 snapshot of the test
+// repository whose content differs from the default branch.
+func setupRefService(t *testing.T, baseDir string) *Service {
+	t.Helper()
+	return setupRefServiceWithShared(t, baseDir, nil)
+}
+
+// setupRefServiceWithShared is setupRefService with files of the same
+// content on the default branch and in the snapshot.
+func setupRefServiceWithShared(t *testing.T, baseDir string, shared map[string]string) *Service {
+	t.Helper()
+
+	settings := &config.GitReposSettings{
+		URLs:        []string{"git@github.com:test/repo.git"},
+		BaseDir:     baseDir,
+		SyncTimeout: 5 * time.Second,
+		MaxFileSize: 256 * 1024,
+		MaxResults:  20,
+		Refs:        []string{"v1.0"},
+	}
+
+	svc, err := NewService(settings)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+
+	mock := NewMockExecutor()
+	mock.AddResponse("git clone", []byte{}, nil)
+	mock.AddResponse("git rev-parse", []byte("abc123\n"), nil)
+	mock.AddResponse("git rev-parse", []byte("def456\n"), nil) // v1.0
+	svc.git = NewGitClientWithExecutor(mock)
+
+	// Clones are mocked, so both checkouts are written up front
+	createTestFile(t, filepath.Join(baseDir, "repos", "github.com_test_repo"), "main.go", "func currentHandler() {}")
+	createTestFile(t, filepath.Join(baseDir, "repos", "github.com_test_repo@v1.0"), "main.go", "func legacyHandler() {}")
+	for relPath, content := range shared {
+		createTestFile(t, filepath.Join(baseDir, "repos", "github.com_test_repo"), relPath, content)
+		createTestFile(t, filepath.Join(baseDir, "repos", "github.com_test_repo@v1.0"), relPath, content)
+	}
+
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	return svc
+}
+
+func TestService_RefSnapshots_SearchAndRead(t *testing.T) {
+	svc := setupRefService(t, t.TempDir())
+	ctx := context.Background()
+
+	snapshot, ok := svc.manifest.GetRepoState("github.com_test_repo").Snapshots["v1.0"]
+	if !ok || snapshot.Commit != "def456" || snapshot.FileCount != 1 {
+		t.Fatalf("Expected the v1.0 snapshot in the manifest, got %+v", snapshot)
+	}
+
+	search := NewSearchHandler(svc)
+	result, _, _ := search.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "legacyHandler", Ref: "v1.0"})
+	if text := ExtractTextContent(result); result.IsError || !strings.Contains(text, "at ref v1.0") || !strings.Contains(text, "main.go") {
+		t.Errorf("Expected a hit in the v1.0 snapshot, got: %s", text)
+	}
+
+	// The default branch does not see the snapshot; whole_word keeps the
+	// shared "handler" word of currentHandler from matching
+	result, _, _ = search.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "legacyHandler", WholeWord: true})
+	if text := ExtractTextContent(result); !strings.Contains(text, "No results") {
+		t.Errorf("Expected no hits on the default branch, got: %s", text)
+	}
+
+	result, _, _ = search.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "legacyHandler", Ref: "v2.0"})
+	if text := ExtractTextContent(result); !result.IsError || !strings.Contains(text, "indexed refs: v1.0") {
+		t.Errorf("Expected an unknown ref error, got: %s", text)
+	}
+
+	read := NewReadHandler(svc)
+	result, _, _ = read.Handle(ctx, &mcp.CallToolRequest{}, ReadArgument{Repository: "github.com/test/repo", Path: "main.go", Ref: "v1.0"})
+	if text := ExtractTextContent(result); result.IsError || !strings.Contains(text, "legacyHandler") {
+		t.Errorf("Expected the v1.0 content, got: %s", text)
+	}
+
+	result, _, _ = read.Handle(ctx, &mcp.CallToolRequest{}, ReadArgument{Repository: "github.com/test/repo", Path: "main.go", Ref: "v2.0"})
+	if text := ExtractTextContent(result); !result.IsError || !strings.Contains(text, "No snapshot of ref v2.0") {
+		t.Errorf("Expected a missing snapshot error, got: %s", text)
+	}
+
+	if !svc.IsIndexed(RefSnapshotID("github.com_test_repo", "v1.0"), "main.go") {
+		t.Error("Expected the snapshot file to be indexed")
+	}
+}
+
+func TestService_RefSnapshots_SearchSeveralRefs(t *testing.T) {
+	svc := setupRefServiceWithShared(t, t.TempDir(), map[string]string{"util.go": "func handlerName() string { return \"util\" }"})
+	search := NewSearchHandler(svc)
+
+	result, _, _ := search.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "handler", Ref: "HEAD, v1.0"})
+	text := ExtractTextContent(result)
+	if result.IsError || !strings.Contains(text, "at refs HEAD, v1.0") {
+		t.Fatalf("Expected a search of both refs, got: %s", text)
+	}
+
+	// Files that differ are listed per ref, identical ones once
+	for _, expected := range []string{"`main.go` at HEAD @ `abc123`", "`main.go` at v1.0 @ `def456`"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, text)
+		}
+	}
+	if strings.Count(text, "`util.go`") != 1 || !strings.Contains(text, "_Also present in: ") {
+		t.Errorf("Expected util.go once, with the other ref it is present in:\n%s", text)
+	}
+	if strings.Contains(text, "more results") {
+		t.Errorf("Expected folded hits not to count as more results:\n%s", text)
+	}
+
+	// A single ref is searched as before
+	result, _, _ = search.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "handler", Ref: "HEAD"})
+	if text := ExtractTextContent(result); strings.Contains(text, "at ref") || strings.Contains(text, "Also present") {
+		t.Errorf("Expected HEAD alone to search the default branch, got: %s", text)
+	}
+}
+
+func TestParseRefs(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"", nil},
+		{"HEAD", nil},
+		{"v1.0", []string{"v1.0"}},
+		{" HEAD , v1.0,,v1.0 ", []string{"HEAD", "v1.0"}},
+	}
+	for _, tt := range tests {
+		if got := parseRefs(tt.ref); !slices.Equal(got, tt.want) {
+			t.Errorf("parseRefs(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestService_RefSnapshots_Removed(t *testing.T) {
+	baseDir := t.TempDir()
+	svc := setupRefService(t, baseDir)
+	id := RefSnapshotID("github.com_test_repo", "v1.0")
+
+	settings := *svc.currentSettings()
+	settings.Refs = nil
+	svc.settings = &settings
+	svc.syncRefSnapshots(context.Background(), "github.com_test_repo", settings.URLs[0])
+
+	if state := svc.manifest.GetRepoState("github.com_test_repo"); state.Snapshots != nil {
+		t.Errorf("Expected the snapshot to be dropped from the manifest, got %+v", state.Snapshots)
+	}
+	if svc.indexer.IndexExists(id) {
+		t.Error("Expected the snapshot index to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "repos", id)); !os.IsNotExist(err) {
+		t.Errorf("Expected the snapshot clone to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// QuerySyntaxURI is the URI of the query syntax help resource.
+const QuerySyntaxURI = "relic://help/query-syntax"
+
+// QuerySyntaxHandler serves the query syntax help resource.
+type QuerySyntaxHandler struct {
+	service SearchService
+}
+
+// NewQuerySyntaxHandler creates a new query syntax resource handler.
+func NewQuerySyntaxHandler(service SearchService) *QuerySyntaxHandler {
+	return &QuerySyntaxHandler{
+		service: service,
+	}
+}
+
+// Handle returns the query syntax cheat-sheet as markdown.
+func (h *QuerySyntaxHandler) Handle(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	if req.Params == nil || req.Params.URI != QuerySyntaxURI {
+		uri := ""
+		if req.Params != nil {
+			uri = req.Params.URI
+		}
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{URI: QuerySyntaxURI, MIMEType: "text/markdown", Text: querySyntax(h.service.MaxResults(), h.service.SearchTimeout())},
+		},
+	}, nil
+}
+
+// GetResourceDefinition returns the MCP resource definition.
+func (h *QuerySyntaxHandler) GetResourceDefinition() *mcp.Resource {
+	return &mcp.Resource{
+		URI:         QuerySyntaxURI,
+		Name:        "query-syntax",
+		Title:       "Search query syntax",
+		Description: "How the search tool matches queries, its arguments and filters, with examples.",
+		MIMEType:    "text/markdown",
+	}
+}
+
+// RegisterQuerySyntaxResource registers the query syntax resource with an MCP server.
+func RegisterQuerySyntaxResource(server *mcp.Server, service SearchService) {
+	handler := NewQuerySyntaxHandler(service)
+	server.AddResource(handler.GetResourceDefinition(), handler.Handle)
+}
+
+// querySyntax builds the cheat-sheet from the search arguments and the query
+// settings used by SearchHandler.buildQuery, so it cannot drift from them.
+func querySyntax(maxResults int, timeout time.Duration) string {
+	var sb strings.Builder
+	sb.WriteString("# Search Query Syntax\n\n")
+
+	sb.WriteString("## Matching\n\n")
+	sb.WriteString(fmt.Sprintf("- The query is split into words and lowercased by the `%s` analyzer; a file matches if any word matches. Operators such as AND, OR, quotes and wildcards are not interpreted.\n", standard.Name))
+	sb.WriteString(fmt.Sprintf("- Words tolerate up to %d typo(s) (edit distance) in file content, unless `whole_word` is set.\n", contentFuzziness))
+	sb.WriteString(fmt.Sprintf("- Matches on declared symbol names (functions, types, classes) score %gx higher. Symbols are extracted for: %s, and OpenAPI/Swagger specs (paths and operationIds).\n", symbolsBoost, strings.Join(symbolLanguages(), ", ")))
+	sb.WriteString(fmt.Sprintf("- Words within symbol names match too, so `server` or `ServerConfig` finds `HTTPServerConfig` and `http_server_config` (%gx, not with `whole_word`).\n", symbolPartsBoost))
+	sb.WriteString(fmt.Sprintf("- With several words, files score higher when the words appear in order, e.g. as a qualified name like `Service.Initialize` (%gx), when a symbol is declared for every word (%gx), or when the joined words name a symbol like `ServiceInitialize` or `service_initialize` (%gx).\n", phraseBoost, allSymbolsBoost, joinedSymbolBoost))
+	sb.WriteString(fmt.Sprintf("- A `%spath.to.setting` word requires a JSON or YAML file defining that key path (case-insensitive, `*` matches any characters). Array elements share their parent's path. A pattern needs at least %d characters before its first `*`.\n", keyTermPrefix, minWildcardPrefix))
+	if timeout > 0 {
+		sb.WriteString(fmt.Sprintf("- Queries that expand to more than %d terms, or run longer than %s, fail as too broad.\n", maxQueryExpansion, timeout))
+	} else {
+		sb.WriteString(fmt.Sprintf("- Queries that expand to more than %d terms fail as too broad.\n", maxQueryExpansion))
+	}
+	sb.WriteString("- `case_sensitive` additionally requires a word of the query to appear with exactly the given letter case.\n")
+	sb.WriteString(fmt.Sprintf("- At most %d results are returned, best matches first.\n\n", maxResults))
+
+	sb.WriteString("## Arguments\n\n")
+	sb.WriteString("| Name | Type | Description |\n")
+	sb.WriteString("|------|------|-------------|\n")
+	for _, arg := range argumentDocs(reflect.TypeFor[SearchArgument]()) {
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", arg.name, arg.kind, arg.description))
+	}
+
+	sb.WriteString("\n## Filters\n\n")
+	sb.WriteString("- `repository` matches any repository whose name contains the value, e.g. `api` matches `github.com/org/api-gateway`.\n")
+	sb.WriteString("- `extension` matches the file extension exactly, with or without a leading dot: `go`, `.py`. Several extensions, as an array or comma-separated (`go,proto`), match any of them.\n")
+	sb.WriteString(fmt.Sprintf("- An extension group name matches any extension of the group. Built-in groups: `%s`; the server may configure more.\n", strings.Join(config.DefaultExtensionAliases, "`, `")))
+	sb.WriteString(fmt.Sprintf("- Generated files, detected by markers such as %s in their first %d bytes, are excluded unless `include_generated` is set.\n", generatedMarkerList(), generatedHeaderBytes))
+	sb.WriteString("- `directories` searches directories instead of files: their paths and the names of their files and subdirectories. Hits show the file and subdirectory counts and the languages present; `extension` then matches directories holding files of that kind.\n")
+	sb.WriteString("- Filters combine with AND.\n\n")
+
+	sb.WriteString("## Ranking Profiles\n\n")
+	sb.WriteString("`profile` selects how results are ranked; without it, the profile of the API key or the server applies.\n\n")
+	for _, name := range config.RankingProfiles {
+		sb.WriteString(fmt.Sprintf("- `%s`: %s\n", name, rankingProfiles[name].description))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Examples\n\n")
+	sb.WriteString("- Concept lookup: `{\"query\": \"retry backoff\"}`\n")
+	sb.WriteString("- Exact identifier: `{\"query\": \"NewServer\", \"case_sensitive\": true, \"whole_word\": true}`\n")
+	sb.WriteString(fmt.Sprintf("- Configuration key: `{\"query\": \"%sserver.timeout\"}`\n", keyTermPrefix))
+	sb.WriteString("- Scoped: `{\"query\": \"rate limit\", \"repository\": \"gateway\", \"extension\": \"go\"}`\n")
+	sb.WriteString("- Directories: `{\"query\": \"kafka\", \"directories\": true}`\n")
+	sb.WriteString("- Documentation first: `{\"query\": \"deployment\", \"profile\": \"docs-first\"}`\n")
+	sb.WriteString("- Pinned to an index generation: `{\"query\": \"auth\", \"if_generation\": 12}`\n")
+	return sb.String()
+}
+
+// argumentDoc describes one tool argument.
+type argumentDoc struct {
+	name        string
+	kind        string
+	description string
+}
+
+// argumentDocs lists the JSON arguments of a tool argument struct, including
+// those of embedded structs, in declaration order.
+func argumentDocs(t reflect.Type) []argumentDoc {
+	var docs []argumentDoc
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			docs = append(docs, argumentDocs(field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		kind := field.Type.Kind().String()
+		switch {
+		case field.Type.Kind() == reflect.Uint64 || field.Type.Kind() == reflect.Int:
+			kind = "integer"
+		case field.Type == reflect.TypeFor[ExtensionList]():
+			kind = "string or array"
+		}
+		docs = append(docs, argumentDoc{name: name, kind: kind, description: field.Tag.Get("jsonschema_description")})
+	}
+	return docs
+}
+
+// symbolLanguages returns the file extensions symbols are extracted from.
+func symbolLanguages() []string {
+	var langs []string
+	for lang := range languagePatterns {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
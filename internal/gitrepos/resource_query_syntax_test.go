@@ -0,0 +1,58 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestQuerySyntaxHandler_Handle(t *testing.T) {
+	handler := NewQuerySyntaxHandler(&mockSearchService{maxResults: 42})
+
+	result, err := handler.Handle(context.Background(), &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: QuerySyntaxURI}})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if len(result.Contents) != 1 || result.Contents[0].MIMEType != "text/markdown" {
+		t.Fatalf("Unexpected contents: %+v", result.Contents)
+	}
+
+	text := result.Contents[0].Text
+	for _, want := range []string{
+		"At most 42 results",
+		"up to 1 typo(s)",
+		"score 5x higher",
+		"| `query` | string |",
+		"| `case_sensitive` | bool |",
+		"| `if_generation` | integer |", // from the embedded ConsistencyArgument
+		"- `docs-first`: documentation files",
+		"go, java",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in query syntax, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestQuerySyntaxHandler_UnknownURI(t *testing.T) {
+	handler := NewQuerySyntaxHandler(&mockSearchService{})
+
+	_, err := handler.Handle(context.Background(), &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: "relic://help/other"}})
+	var rpcErr *jsonrpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != mcp.CodeResourceNotFound {
+		t.Errorf("Expected resource not found error, got: %v", err)
+	}
+}
+
+func TestArgumentDocs_DocumentsEverySearchArgument(t *testing.T) {
+	for _, doc := range argumentDocs(reflect.TypeFor[SearchArgument]()) {
+		if doc.description == "" {
+			t.Errorf("Search argument %q has no jsonschema_description", doc.name)
+		}
+	}
+}
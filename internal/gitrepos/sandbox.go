@@ -0,0 +1,95 @@
+package gitrepos
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// Sandbox restricts the environment and resources of the commands run by a
+// DefaultExecutor, to reduce what hostile repository contents or remotes can
+// make git do. The zero value runs commands unrestricted.
+type Sandbox struct {
+	// CleanEnv passes only sandboxEnv variables to commands, and makes git
+	// ignore the system and global config, credential helpers and hooks
+	CleanEnv bool
+	// MaxMemory limits the virtual memory of each command, in bytes (0 = none)
+	MaxMemory int64
+	// MaxCPU limits the CPU time of each command (0 = none)
+	MaxCPU time.Duration
+}
+
+// sandboxEnv are the variables passed to commands with a clean environment:
+// what git and ssh need to find the keys and known hosts of the server and
+// its proxies.
+var sandboxEnv = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR",
+	"SSH_AUTH_SOCK", "GIT_SSH_COMMAND",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// sandboxGitConfig is the git config of commands with a clean environment.
+// Credential helpers, hooks, fsmonitor and the ext:: transport can all run
+// programs; received objects are checked before they are stored.
+var sandboxGitConfig = [][2]string{
+	{"credential.helper", ""},
+	{"core.hooksPath", os.DevNull},
+	{"core.fsmonitor", "false"},
+	{"protocol.ext.allow", "never"},
+	{"submodule.recurse", "false"},
+	{"transfer.fsckObjects", "true"},
+}
+
+// NewExecutor creates the executor of the git commands of a sync, with the
+// limits and sandbox of settings.
+func NewExecutor(settings *config.GitReposSettings) *DefaultExecutor {
+	return &DefaultExecutor{
+		Timeout:   settings.GitCommandTimeout,
+		MaxOutput: settings.GitMaxOutput,
+		Sandbox: Sandbox{
+			CleanEnv:  settings.GitSandbox,
+			MaxMemory: settings.GitMaxMemory,
+			MaxCPU:    settings.GitMaxCPU,
+		},
+	}
+}
+
+// env returns the environment of sandboxed commands, or nil to inherit the
+// environment of the server.
+func (s Sandbox) env() []string {
+	if !s.CleanEnv {
+		return nil
+	}
+	var env []string
+	for _, name := range sandboxEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	env = append(env,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"GIT_TERMINAL_PROMPT=0",
+	)
+	return appendGitConfig(env, sandboxGitConfig)
+}
+
+// command returns the command to run for name and args, wrapped to apply the
+// resource limits if any.
+func (s Sandbox) command(name string, args []string) (string, []string) {
+	if s.MaxMemory <= 0 && s.MaxCPU <= 0 {
+		return name, args
+	}
+	var limits []string
+	if s.MaxMemory > 0 {
+		limits = append(limits, "ulimit -v "+strconv.FormatInt(max(s.MaxMemory/1024, 1), 10))
+	}
+	if s.MaxCPU > 0 {
+		seconds := int64((s.MaxCPU + time.Second - 1) / time.Second)
+		limits = append(limits, "ulimit -t "+strconv.FormatInt(seconds, 10))
+	}
+	return rlimitCommand(strings.Join(limits, " && "), name, args)
+}
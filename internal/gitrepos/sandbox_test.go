@@ -0,0 +1,76 @@
+package gitrepos
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestSandbox_CleanEnv(t *testing.T) {
+	t.Setenv("RELIC_TEST_SECRET", "token")
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/agent.sock")
+	executor := &DefaultExecutor{Sandbox: Sandbox{CleanEnv: true}}
+
+	output, err := executor.Run(context.Background(), "", "sh", "-c", `echo "$RELIC_TEST_SECRET|$SSH_AUTH_SOCK|$GIT_CONFIG_NOSYSTEM"`)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "|/tmp/agent.sock|1" {
+		t.Errorf("Expected only allowed variables and the git settings, got %q", got)
+	}
+
+	unrestricted := &DefaultExecutor{}
+	output, err = unrestricted.Run(context.Background(), "", "sh", "-c", `echo "$RELIC_TEST_SECRET"`)
+	if err != nil || strings.TrimSpace(string(output)) != "token" {
+		t.Errorf("Expected the environment to be inherited without a sandbox, got %q, %v", output, err)
+	}
+}
+
+func TestSandbox_GitConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	executor := &DefaultExecutor{Sandbox: Sandbox{CleanEnv: true}}
+
+	output, err := executor.Run(context.Background(), "", "git", "config", "--get", "protocol.ext.allow")
+	if err != nil || strings.TrimSpace(string(output)) != "never" {
+		t.Errorf("Expected the sandbox git config, got %q, %v", output, err)
+	}
+	if _, err := executor.Run(context.Background(), "", "git", "config", "--global", "--get", "user.name"); err == nil {
+		t.Error("Expected no global git config")
+	}
+}
+
+func TestSandbox_ResourceLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("resource limits are not supported on Windows")
+	}
+	executor := &DefaultExecutor{Sandbox: Sandbox{MaxMemory: 512 * 1024 * 1024, MaxCPU: 1500 * time.Millisecond}}
+
+	output, err := executor.Run(context.Background(), "", "sh", "-c", `echo "$(ulimit -v) $(ulimit -t) $1"`, "sh", "arg")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "524288 2 arg" {
+		t.Errorf("Expected the limits and arguments to reach the command, got %q", got)
+	}
+}
+
+func TestNewExecutor(t *testing.T) {
+	executor := NewExecutor(&config.GitReposSettings{
+		GitCommandTimeout: time.Minute,
+		GitMaxOutput:      1024,
+		GitSandbox:        true,
+		GitMaxMemory:      1 << 30,
+		GitMaxCPU:         time.Minute,
+	})
+	want := Sandbox{CleanEnv: true, MaxMemory: 1 << 30, MaxCPU: time.Minute}
+	if executor.Timeout != time.Minute || executor.MaxOutput != 1024 || executor.Sandbox != want {
+		t.Errorf("Unexpected executor: %+v", executor)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package gitrepos
+
+// rlimitCommand runs name with args through the shell, which sets the
+// resource limits of limits (ulimit commands) before replacing itself with
+// the command.
+func rlimitCommand(limits, name string, args []string) (string, []string) {
+	return "sh", append([]string{"-c", limits + ` && exec "$0" "$@"`, name}, args...)
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package gitrepos
+
+// rlimitCommand runs name unchanged; resource limits are not supported on
+// Windows, where the settings reject them.
+func rlimitCommand(_, name string, args []string) (string, []string) {
+	return name, args
+}
@@ -0,0 +1,26 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// scopeError returns the error result of a tool call made with an API key
+// that lacks scope, or nil if the call may proceed. The admin scope must be
+// granted explicitly, so administrative tools fail closed for callers without
+// scopes.
+func scopeError(ctx context.Context, tool, scope string) *mcp.CallToolResult {
+	if scope == config.ScopeAdmin && auth.HasExplicitScope(ctx, scope) || scope != config.ScopeAdmin && auth.HasScope(ctx, scope) {
+		return nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("The %s tool requires an API key with the %s scope", tool, scope)},
+		},
+		IsError: true,
+	}
+}
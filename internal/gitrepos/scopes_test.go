@@ -0,0 +1,61 @@
+package gitrepos
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+func TestScopeError(t *testing.T) {
+	if result := scopeError(context.Background(), "read", config.ScopeRead); result != nil {
+		t.Error("Expected requests without scopes to be unrestricted")
+	}
+
+	ctx := auth.WithScopes(context.Background(), []string{config.ScopeSearch})
+	if result := scopeError(ctx, "search", config.ScopeSearch); result != nil {
+		t.Error("Expected a granted scope to pass")
+	}
+	result := scopeError(ctx, "read", config.ScopeRead)
+	if result == nil || !result.IsError || !strings.Contains(ExtractTextContent(result), "read scope") {
+		t.Errorf("Expected a missing scope error, got %+v", result)
+	}
+}
+
+func TestScopeError_AdminRequiresExplicitGrant(t *testing.T) {
+	if result := scopeError(context.Background(), "reindex", config.ScopeAdmin); result == nil || !result.IsError {
+		t.Error("Expected requests without scopes to be refused the admin scope")
+	}
+	ctx := auth.WithScopes(context.Background(), []string{config.ScopeSearch, config.ScopeRead})
+	if result := scopeError(ctx, "reindex", config.ScopeAdmin); result == nil || !result.IsError {
+		t.Error("Expected a key without the admin scope to be refused")
+	}
+	ctx = auth.WithScopes(context.Background(), []string{config.ScopeAdmin})
+	if result := scopeError(ctx, "reindex", config.ScopeAdmin); result != nil {
+		t.Error("Expected a key with the admin scope to pass")
+	}
+}
+
+func TestReadHandler_RequiresReadScope(t *testing.T) {
+	handler := NewReadHandler(&mockReadService{ready: true})
+	ctx := auth.WithScopes(context.Background(), []string{config.ScopeSearch})
+
+	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, ReadArgument{
+		Repository: "github.com/test/repo",
+		Path:       "main.go",
+	})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if text := ExtractTextContent(result); !result.IsError || !strings.Contains(text, "requires an API key with the read scope") {
+		t.Errorf("Expected a scope error, got: %s", text)
+	}
+}
+
+// adminContext returns the context of a call made with an admin API key
+func adminContext() context.Context {
+	return auth.WithScopes(context.Background(), []string{config.ScopeAdmin})
+}
@@ -2,10 +2,14 @@ package gitrepos
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,26 +23,68 @@ const (
 
 	// MaxParallelSyncs is the maximum number of concurrent repository syncs
 	MaxParallelSyncs = 4
+
+	// ManifestPollInterval is how often read-only servers check the manifest
+	// for a new sync generation
+	ManifestPollInterval = 5 * time.Second
+
+	// HeadPollInterval is how often the working directory's HEAD is checked
+	// for new commits in local (--cwd) mode
+	HeadPollInterval = 2 * time.Second
+
+	// AliasDrainTimeout is how long a replaced index alias keeps serving the
+	// searches that were running on it before it is closed anyway
+	AliasDrainTimeout = 2 * queryTimeBudget
 )
 
 // Service coordinates git operations, indexing, and search.
 type Service struct {
-	settings *config.GitReposSettings
-	git      GitOperations
-	indexer  IndexOperations
-	manifest ManifestOperations
-	lock     SyncLock
-	alias    bleve.IndexAlias
-	ready    bool
-	mu       sync.RWMutex
+	settings    *config.GitReposSettings
+	configured  []string // the URLs of the settings, before the repository overlay
+	git         GitOperations
+	indexer     IndexOperations
+	manifest    ManifestOperations
+	lock        SyncLock
+	snapshots   SnapshotStore // optional, distributes indexes via object storage
+	catalog     *Catalog      // opened on first use, see getCatalog
+	catalogMu   sync.Mutex
+	catalogPath string
+	alias       *servedAlias
+	ready       bool
+	served      map[string]bool // repositories in the alias
+	pending     map[string]bool // repositories waiting for their sync, see serveSynced
+	mu          sync.RWMutex
+	syncMu      sync.Mutex       // serializes in-process syncs and reloads
+	serveSynced bool             // serve repositories as they finish syncing; guarded by syncMu
+	markRewrite bool             // mark indexes in the manifest before rewriting them; guarded by syncMu
+	rewriteMu   sync.Mutex       // serializes the manifest saves of beginRewrite
+	serveMu     sync.Mutex       // serializes alias rebuilds in serveRepo
+	limiter     *toolLimiter     // searches; replaced when reloaded limits differ
+	readLimiter *toolLimiter     // file reads; replaced when reloaded limits differ
+	progress    indexProgress    // active while the alias is closed for indexing
+	telemetry   *SearchTelemetry // nil unless search telemetry is enabled
+	throttle    *FetchThrottle   // nil unless the fetch rate is limited
+
+	// Read-only mode state
+	generation   uint64 // manifest generation of the open alias
+	pollInterval time.Duration
+	stopWatch    chan struct{} // stops the manifest or HEAD watcher
+	drainTimeout time.Duration // see AliasDrainTimeout
+
+	// Local (--cwd) mode state
+	headPollInterval time.Duration
+	watchDebounce    time.Duration
+	fileWatcher      *fileWatcher
 }
 
 // ServiceDeps holds injectable dependencies for creating a Service.
 type ServiceDeps struct {
-	Git      GitOperations
-	Indexer  IndexOperations
-	Manifest ManifestOperations
-	Lock     SyncLock
+	Git       GitOperations
+	Indexer   IndexOperations
+	Manifest  ManifestOperations
+	Lock      SyncLock
+	Snapshots SnapshotStore
+	Catalog   *Catalog
 }
 
 // NewService creates a new git repos service.
@@ -52,16 +98,12 @@ func NewService(settings *config.GitReposSettings) (*Service, error) {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
 
-	// Create repos directory
-	reposDir := filepath.Join(settings.BaseDir, "repos")
-	if err := os.MkdirAll(reposDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create repos directory: %w", err)
+	// Create repos and indexes directories, which may be on other volumes
+	if err := ensureDir(settings.ReposPath(), !settings.ReadOnly); err != nil {
+		return nil, fmt.Errorf("invalid repos directory: %w", err)
 	}
-
-	// Create indexes directory
-	indexesDir := filepath.Join(settings.BaseDir, "indexes")
-	if err := os.MkdirAll(indexesDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create indexes directory: %w", err)
+	if err := ensureDir(settings.IndexesPath(), !settings.ReadOnly); err != nil {
+		return nil, fmt.Errorf("invalid indexes directory: %w", err)
 	}
 
 	// Load or create manifest
@@ -71,60 +113,215 @@ func NewService(settings *config.GitReposSettings) (*Service, error) {
 		return nil, fmt.Errorf("failed to load manifest: %w", err)
 	}
 
+	// Repositories added or removed at runtime apply on top of the
+	// configured ones
+	configured := settings.URLs
+	if settings, err = withRepoOverlay(settings); err != nil {
+		return nil, err
+	}
+
 	// Create components
-	filter := NewFileFilter(settings.MaxFileSize)
-	indexer := NewIndexer(settings.BaseDir, filter, settings.MaxFileSize)
+	filter := newFileFilter(settings)
+	indexer := NewIndexerWithIndexesDir(settings.IndexesPath(), filter, settings.MaxFileSize)
+	indexer.SetBatchLimits(settings.IndexBatchSize, settings.IndexBatchBytes)
+	indexer.SetDeclarations(settings.IndexDeclarations)
 	lock := NewFileLock(filepath.Join(settings.BaseDir, LockFilename))
-	git := NewGitClient()
+	executor := NewExecutor(settings)
+	git := NewGitClientWithExecutor(executor)
+	git.SetURLLogging(settings.LogURLs)
+	tokens, err := settings.HTTPSCredentials()
+	if err != nil {
+		return nil, err
+	}
+	git.SetHTTPSTokens(tokens)
+
+	var snapshots SnapshotStore
+	if settings.SnapshotURL != "" {
+		snapshots, err = NewSnapshotStore(settings.SnapshotURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var telemetry *SearchTelemetry
+	if settings.SearchTelemetry {
+		telemetry, err = OpenSearchTelemetry(filepath.Join(settings.BaseDir, TelemetryFilename))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Started last, so that it is not left running when anything else fails
+	var throttle *FetchThrottle
+	if settings.FetchRateLimit > 0 {
+		throttle, err = NewFetchThrottle(settings.FetchRateLimit)
+		if err != nil {
+			_ = telemetry.Close()
+			return nil, err
+		}
+		executor.HTTPProxy = throttle.URL()
+		var unthrottled int
+		for _, url := range settings.URLs {
+			if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+				unthrottled++
+			}
+		}
+		if unthrottled > 0 {
+			slog.Warn("The fetch rate limit applies to HTTP(S) remotes only", "unthrottled_repos", unthrottled)
+		}
+	}
 
 	return &Service{
-		settings: settings,
-		git:      git,
-		indexer:  indexer,
-		manifest: manifest,
-		lock:     lock,
+		settings:     settings,
+		configured:   configured,
+		git:          git,
+		indexer:      indexer,
+		manifest:     manifest,
+		lock:         lock,
+		snapshots:    snapshots,
+		telemetry:    telemetry,
+		throttle:     throttle,
+		catalogPath:  filepath.Join(settings.BaseDir, CatalogFilename),
+		pollInterval: ManifestPollInterval,
+		drainTimeout: AliasDrainTimeout,
+
+		headPollInterval: HeadPollInterval,
+		watchDebounce:    WatchDebounce,
 	}, nil
 }
 
+// ensureDir creates dir if needed and, if writable is set, checks that files
+// can be created in it. Read-only servers may serve from read-only volumes.
+func ensureDir(dir string, writable bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if !writable {
+		return nil
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
 // NewServiceWithDeps creates a Service with injected dependencies for testing.
 func NewServiceWithDeps(settings *config.GitReposSettings, deps ServiceDeps) *Service {
 	return &Service{
-		settings: settings,
-		git:      deps.Git,
-		indexer:  deps.Indexer,
-		manifest: deps.Manifest,
-		lock:     deps.Lock,
+		settings:     settings,
+		configured:   settings.URLs,
+		git:          deps.Git,
+		indexer:      deps.Indexer,
+		manifest:     deps.Manifest,
+		lock:         deps.Lock,
+		snapshots:    deps.Snapshots,
+		catalog:      deps.Catalog,
+		pollInterval: ManifestPollInterval,
+		drainTimeout: AliasDrainTimeout,
+
+		headPollInterval: HeadPollInterval,
+		watchDebounce:    WatchDebounce,
+	}
+}
+
+// newFileFilter creates the file filter described by settings.
+func newFileFilter(settings *config.GitReposSettings) *FileFilter {
+	filter := NewFileFilter(settings.MaxFileSize)
+	filter.SetFollowSymlinks(settings.FollowSymlinks)
+	filter.SetRepoBudget(settings.MaxRepoFiles, settings.MaxRepoBytes)
+	if overrides, err := settings.FileSizeOverrides(); err == nil {
+		filter.SetSizeOverrides(overrides)
 	}
+	return filter
 }
 
 // Initialize prepares the service with leader/follower sync logic.
+// In read-only mode no sync is attempted; indexes published by an external
+// sync process are opened as they become available.
 func (s *Service) Initialize(ctx context.Context) error {
+	if s.settings.ReadOnly {
+		s.initializeReadOnly()
+		return nil
+	}
+
 	acquired, err := s.lock.TryLock()
 	if err != nil {
 		return fmt.Errorf("failed to acquire lock: %w", err)
 	}
 
 	if acquired {
-		s.initializeAsLeader(ctx)
+		if err := s.initializeAsLeader(ctx); err != nil {
+			return err
+		}
 	} else {
 		s.initializeAsFollower()
 	}
 
-	return s.openIndexes()
+	if err := s.openIndexes(); err != nil {
+		return err
+	}
+
+	if s.settings.LocalDir != "" {
+		s.startLocalWatchers()
+	}
+	return nil
 }
 
-// initializeAsLeader syncs repos, saves manifest, and unlocks.
-func (s *Service) initializeAsLeader(ctx context.Context) {
+// startLocalWatchers starts watching the local working directory for HEAD
+// changes and, if enabled, for file changes.
+func (s *Service) startLocalWatchers() {
+	s.stopWatch = make(chan struct{})
+	go s.watchHead(s.stopWatch)
+
+	if !s.settings.Watch {
+		return
+	}
+	watcher, err := newFileWatcher(s.settings.LocalDir, newFileFilter(s.settings), s.watchDebounce, s.reindexLocalFiles)
+	if err != nil {
+		// Typically the inotify watch limit; HEAD polling still works
+		slog.Warn("Failed to start file watcher, changes are indexed on commit only", "error", err)
+		return
+	}
+	s.fileWatcher = watcher
+	go watcher.run(s.stopWatch)
+	slog.Info("Watching working directory for changes", "dir", s.settings.LocalDir)
+}
+
+// initializeAsLeader syncs repos, saves manifest, and unlocks. It fails only
+// when at least SyncFailureThreshold repositories failed to sync; below that
+// the service starts degraded, without the failed repositories.
+func (s *Service) initializeAsLeader(ctx context.Context) (err error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	// No alias is open yet, so each repository can be served as soon as
+	// its sync completes
+	s.serveSynced = true
+	defer func() { s.serveSynced = false }()
+
 	slog.Info("Acquired sync leader lock, starting sync")
-	if err := s.SyncAll(ctx); err != nil {
-		slog.Error("Sync failed", "error", err)
+	if s.settings.StartupChecks && s.settings.LocalDir == "" && len(s.settings.URLs) > 0 {
+		// Failures are logged by Preflight; the sync reports them again
+		_ = s.Preflight(ctx)
+	}
+	if syncErr := s.SyncAll(ctx); syncErr != nil {
+		failed := syncFailures(syncErr)
+		slog.Error("Sync failed", "failed", failed, "error", syncErr)
+		if threshold := s.settings.SyncFailureThreshold; threshold > 0 && failed >= threshold {
+			err = fmt.Errorf("%d repository sync(s) failed, reaching the failure threshold of %d: %w", failed, threshold, syncErr)
+		}
 	}
 	if err := s.saveManifest(); err != nil {
 		slog.Error("Failed to save manifest", "error", err)
+	} else {
+		s.uploadSnapshot(ctx)
 	}
 	if err := s.lock.Unlock(); err != nil {
 		slog.Error("Failed to unlock", "error", err)
 	}
+	return err
 }
 
 // initializeAsFollower waits for the leader to finish, then opens indexes.
@@ -139,242 +336,1707 @@ func (s *Service) initializeAsFollower() {
 	}
 }
 
-// SyncAll synchronizes all configured repositories.
-func (s *Service) SyncAll(ctx context.Context) error {
-	urls := s.settings.URLs
-	if len(urls) == 0 {
-		return nil
-	}
+// initializeReadOnly opens the published indexes, if any, and starts watching
+// the manifest for new sync generations.
+func (s *Service) initializeReadOnly() {
+	slog.Info("Read-only mode, waiting for indexes published by an external sync")
+	s.checkGeneration()
 
-	// Remove stale repos from manifest
-	removed := s.manifest.RemoveStaleRepos(urls)
-	for _, repoID := range removed {
-		slog.Info("Removing stale repository", "repo_id", repoID)
-		if err := s.indexer.DeleteIndex(repoID); err != nil {
-			slog.Error("Failed to delete index for stale repo", "repo_id", repoID, "error", err)
+	s.stopWatch = make(chan struct{})
+	go s.watchGeneration(s.stopWatch)
+}
+
+// watchGeneration polls the manifest until stop is closed.
+func (s *Service) watchGeneration(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.checkGeneration()
 		}
-		// Clean up repo directory
-		repoDir := filepath.Join(s.settings.BaseDir, "repos", repoID)
-		if err := os.RemoveAll(repoDir); err != nil {
-			slog.Error("Failed to remove stale repo directory", "repo_id", repoID, "error", err)
+	}
+}
+
+// checkGeneration releases the index handles an external sync is about to
+// rewrite, and reopens the indexes once a new generation has been published.
+// The other indexes are served meanwhile.
+func (s *Service) checkGeneration() {
+	if s.snapshots != nil {
+		if err := s.downloadSnapshot(context.Background()); err != nil {
+			slog.Warn("Failed to download index snapshot", "error", err)
 		}
 	}
 
-	// Use semaphore to limit parallel syncs
-	sem := make(chan struct{}, MaxParallelSyncs)
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(urls))
+	manifest, err := LoadManifest(filepath.Join(s.currentSettings().BaseDir, ManifestFilename))
+	if err != nil {
+		slog.Warn("Failed to read manifest", "error", err)
+		return
+	}
 
-	for _, url := range urls {
-		repoID := URLToRepoID(url)
-		wg.Add(1)
-		go func(url, repoID string) {
-			defer wg.Done()
-			sem <- struct{}{}        // Acquire
-			defer func() { <-sem }() // Release
+	s.mu.RLock()
+	current := s.generation
+	open := s.ready
+	s.mu.RUnlock()
 
-			if err := s.syncRepo(ctx, repoID, url); err != nil {
-				slog.Error("Failed to sync repository", "repo_id", repoID, "error", err)
-				s.manifest.SetRepoError(repoID, err.Error())
-				errChan <- fmt.Errorf("sync %s: %w", repoID, err)
-			} else {
-				s.manifest.ClearRepoError(repoID)
+	switch {
+	case manifest.Syncing && len(manifest.Rewriting) == 0:
+		// Sync processes of earlier versions rewrite any index
+		if open {
+			slog.Info("External sync in progress, releasing indexes")
+			s.closeAlias()
+			s.mu.Lock()
+			s.generation = 0
+			s.mu.Unlock()
+		}
+	case len(manifest.Rewriting) > 0:
+		if open {
+			s.releaseRepos(manifest.Rewriting)
+		}
+	case manifest.Generation > 0 && manifest.Generation != current:
+		slog.Info("New sync generation available", "generation", manifest.Generation)
+		// Indexes rewritten since they were opened are reopened, the others
+		// keep serving. Mirror the published repository states so that
+		// stats and consistency tokens describe the indexes being opened.
+		var changed []string
+		for _, repoID := range s.manifest.GetRepoIDs() {
+			if _, ok := manifest.Repos[repoID]; !ok {
+				// Removed by the sync process, no longer served
+				changed = append(changed, repoID)
+				s.manifest.RemoveRepo(repoID)
 			}
-		}(url, repoID)
+		}
+		for repoID, state := range manifest.Repos {
+			if repoStateKey(state) != repoStateKey(*s.manifest.GetRepoState(repoID)) {
+				changed = append(changed, repoID)
+			}
+			s.manifest.SetRepoState(repoID, state)
+		}
+		s.releaseRepos(changed)
+		s.mu.Lock()
+		s.generation = manifest.Generation
+		s.mu.Unlock()
+		if err := s.openIndexes(); err != nil {
+			slog.Error("Failed to open indexes", "error", err)
+		}
 	}
+}
 
-	wg.Wait()
-	close(errChan)
+// Sync performs a single sync pass on behalf of read-only servers sharing the
+// same base directory. Each index is marked in the manifest before it is
+// rewritten, so that readers release it while they keep serving the others,
+// and a new generation is published when the pass changed anything.
+func (s *Service) Sync(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
 
-	// Collect errors
-	var errs []error
-	for err := range errChan {
-		errs = append(errs, err)
+	if err := s.lock.Lock(s.currentSettings().SyncTimeout); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
 	}
+	defer func() {
+		if err := s.lock.Unlock(); err != nil {
+			slog.Error("Failed to unlock", "error", err)
+		}
+	}()
 
-	s.manifest.UpdateLastSync()
+	s.markRewrite = true
+	syncErr := s.SyncAll(ctx)
+	s.markRewrite = false
 
-	if len(errs) > 0 {
-		return fmt.Errorf("%d repository sync(s) failed", len(errs))
+	if err := s.saveManifest(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
 	}
-	return nil
+	s.saveIndexChecksum()
+	s.uploadSnapshot(ctx)
+	return syncErr
 }
 
-// syncRepo syncs a single repository.
-func (s *Service) syncRepo(ctx context.Context, repoID, url string) error {
-	repoDir := filepath.Join(s.settings.BaseDir, "repos", repoID)
+// Reindex deletes a repository's index and rebuilds it from the current
+// working tree, regardless of the recorded commit. Useful after changing
+// filters or to recover from a damaged index. Searches are unavailable while
+// the index is rebuilt.
+func (s *Service) Reindex(ctx context.Context, repository string) error {
+	settings := s.currentSettings()
+	if settings.ReadOnly {
+		return errors.New("reindex is not available in read-only mode; run it on the sync process")
+	}
 
-	// Get current state
-	state := s.manifest.GetRepoState(repoID)
-	isNew := !s.manifest.HasRepo(repoID) || state.ClonedAt.IsZero()
+	repoID := DisplayToRepoID(repository)
+	if !slices.Contains(ConfiguredRepoIDs(settings), repoID) {
+		return fmt.Errorf("repository not configured: %s", repository)
+	}
 
-	if isNew {
-		// Clone new repository
-		slog.Info("Cloning repository", "repo_id", repoID, "url", url)
-		if err := s.git.Clone(ctx, url, repoDir); err != nil {
-			return fmt.Errorf("clone failed: %w", err)
-		}
-		state.URL = url
-		state.ClonedAt = time.Now()
-	} else {
-		// Fetch updates
-		slog.Info("Fetching repository updates", "repo_id", repoID)
-		if err := s.git.Fetch(ctx, repoDir); err != nil {
-			return fmt.Errorf("fetch failed: %w", err)
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if err := s.lock.Lock(settings.SyncTimeout); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer func() {
+		if err := s.lock.Unlock(); err != nil {
+			slog.ErrorContext(ctx, "Failed to unlock", "error", err)
 		}
+	}()
+
+	// Read-only servers sharing the base directory release the index while
+	// it is rewritten and reopen it on the new generation
+	s.manifest.MarkRewriting(repoID)
+	if err := s.saveManifest(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
 	}
 
-	// Get current HEAD commit
-	currentCommit, err := s.git.GetHeadCommit(ctx, repoDir)
+	s.progress.begin(1)
+	defer s.progress.end()
+
+	s.closeAlias()
+	reindexErr := s.reindexRepo(ctx, repoID)
+	s.progress.advance()
+
+	s.manifest.UpdateLastSync(true)
+	if err := s.saveManifest(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+	s.saveIndexChecksum()
+	s.uploadSnapshot(ctx)
+
+	if err := s.openIndexes(); err != nil {
+		return fmt.Errorf("failed to open indexes: %w", err)
+	}
+	return reindexErr
+}
+
+// reindexRepo deletes and fully rebuilds the index of a single repository.
+func (s *Service) reindexRepo(ctx context.Context, repoID string) error {
+	repoDir := s.GetRepoDir(repoID)
+
+	commit, err := s.git.GetHeadCommit(ctx, repoDir)
 	if err != nil {
-		return fmt.Errorf("failed to get HEAD commit: %w", err)
+		err = fmt.Errorf("failed to get HEAD commit: %w", err)
+		s.manifest.SetRepoError(repoID, err.Error())
+		return err
 	}
 
-	// Check if reindex is needed
-	needsReindex := isNew || state.LastIndexed == "" || currentCommit != state.LastCommit
+	slog.InfoContext(ctx, "Rebuilding index", "repo_id", repoID, "commit", commit)
+	if err := s.indexer.DeleteIndex(repoID); err != nil {
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
 
-	if needsReindex {
-		if !isNew && state.LastIndexed != "" && currentCommit != state.LastCommit {
-			// Reset to latest
-			if err := s.git.Reset(ctx, repoDir); err != nil {
-				return fmt.Errorf("reset failed: %w", err)
-			}
+	if err := s.indexRepo(ctx, repoID, repoDir, s.manifest.GetRepoState(repoID), commit, false); err != nil {
+		s.manifest.SetRepoError(repoID, err.Error())
+		return err
+	}
+	s.manifest.ClearRepoError(repoID)
+	return nil
+}
 
-			// Try incremental index if we have previous commit
-			if state.LastCommit != "" {
-				changedFiles, err := s.git.GetChangedFiles(ctx, repoDir, state.LastCommit, currentCommit)
-				if err == nil && len(changedFiles) > 0 && len(changedFiles) <= 100 {
-					slog.Info("Incremental indexing", "repo_id", repoID, "changed_files", len(changedFiles))
-					indexed, err := s.indexer.IncrementalIndex(repoID, repoDir, changedFiles)
-					if err != nil {
-						slog.Warn("Incremental index failed, falling back to full index", "error", err)
-					} else {
-						state.LastCommit = currentCommit
-						state.LastIndexed = currentCommit
-						state.LastPull = time.Now()
-						s.manifest.SetRepoState(repoID, *state)
-						slog.Info("Incremental index complete", "repo_id", repoID, "indexed", indexed)
-						return nil
-					}
-				} else if err == nil && len(changedFiles) > 100 {
-					slog.Info("Too many changed files for incremental index, falling back to full index", "repo_id", repoID, "changed_files", len(changedFiles))
-				}
-			}
-		}
+// uploadSnapshot publishes the local indexes, working trees and manifest to
+// the snapshot store, if one is configured and does not have the local
+// generation yet, then deletes superseded generations. Indexes that are open,
+// such as the ones served while syncing, are archived from a consistent copy;
+// the others are archived as they are, which the sync lock held by the caller
+// keeps from being written meanwhile.
+func (s *Service) uploadSnapshot(ctx context.Context) {
+	if s.snapshots == nil {
+		return
+	}
+
+	settings := s.currentSettings()
+	generation := s.manifest.GetGeneration()
+	if published, _, err := fetchSnapshotManifest(ctx, s.snapshots); err == nil && published.Generation == generation {
+		slog.Info("Index snapshot up to date", "generation", generation)
+		return
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(settings.BaseDir, ManifestFilename))
+	if err != nil {
+		slog.Error("Failed to read manifest for snapshot", "error", err)
+		return
+	}
+
+	copies, err := os.MkdirTemp(settings.IndexesPath(), ".upload-")
+	if err != nil {
+		slog.Error("Failed to create snapshot staging directory", "error", err)
+		return
+	}
+	defer func() { _ = os.RemoveAll(copies) }()
 
-		// Full reindex
-		slog.Info("Full indexing", "repo_id", repoID)
-		fileCount, err := s.indexer.FullIndex(repoID, repoDir)
+	indexDirs := make(map[string]string)
+	for _, repoID := range ConfiguredRepoIDs(settings) {
+		if !s.indexer.IndexExists(repoID) {
+			continue
+		}
+		indexDirs[repoID] = filepath.Join(settings.IndexesPath(), repoID+IndexSuffix)
+		copyDir := filepath.Join(copies, repoID+IndexSuffix)
+		copied, err := s.indexer.CopyOpenIndex(repoID, copyDir)
 		if err != nil {
-			return fmt.Errorf("full index failed: %w", err)
+			slog.Error("Failed to publish index snapshot", "repo_id", repoID, "error", err)
+			return
+		}
+		if copied {
+			indexDirs[repoID] = copyDir
 		}
+	}
 
-		state.LastCommit = currentCommit
-		state.LastIndexed = currentCommit
-		state.FileCount = fileCount
-		state.LastPull = time.Now()
-		s.manifest.SetRepoState(repoID, *state)
-		slog.Info("Full index complete", "repo_id", repoID, "file_count", fileCount)
-	} else {
-		slog.Info("Repository already up to date", "repo_id", repoID)
+	if err := publishSnapshot(ctx, s.snapshots, indexDirs, settings.ReposPath(), manifest); err != nil {
+		slog.Error("Failed to publish index snapshot", "error", err)
+		return
 	}
+	slog.Info("Published index snapshot", "generation", generation, "repos", len(indexDirs))
 
-	return nil
+	if err := pruneSnapshots(ctx, s.snapshots, generation, slices.Sorted(maps.Keys(indexDirs)), snapshotRetainedGenerations); err != nil {
+		slog.Warn("Failed to delete superseded index snapshots", "error", err)
+	}
 }
 
-// openIndexes opens all indexes and creates the alias.
-func (s *Service) openIndexes() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// downloadSnapshot replaces local indexes and working trees with a newer
+// published snapshot, if there is one. The local manifest is written last so
+// that the generation check opens the downloaded indexes.
+func (s *Service) downloadSnapshot(ctx context.Context) error {
+	settings := s.currentSettings()
+	manifestPath := filepath.Join(settings.BaseDir, ManifestFilename)
 
-	// Get all repo IDs that have indexes
-	var indexedRepos []string
-	for _, url := range s.settings.URLs {
-		repoID := URLToRepoID(url)
-		if s.indexer.IndexExists(repoID) {
-			indexedRepos = append(indexedRepos, repoID)
-		}
+	remote, data, err := fetchSnapshotManifest(ctx, s.snapshots)
+	if errors.Is(err, ErrSnapshotNotFound) {
+		return nil // Nothing published yet
+	}
+	if err != nil {
+		return err
 	}
 
-	if len(indexedRepos) == 0 {
-		slog.Warn("No indexes available")
-		s.ready = false
+	local, err := LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	if remote.Generation == 0 || remote.Generation == local.GetGeneration() {
 		return nil
 	}
 
-	// Create alias combining all indexes
-	alias, err := s.indexer.CreateAlias(indexedRepos)
-	if err != nil {
-		return fmt.Errorf("failed to create index alias: %w", err)
+	slog.Info("Downloading index snapshot", "generation", remote.Generation)
+	// Stage next to the targets, which may be on different volumes, so that
+	// they can be swapped in by renaming
+	stagingName := fmt.Sprintf(".snapshot-%d", remote.Generation)
+	indexStaging := filepath.Join(settings.IndexesPath(), stagingName)
+	repoStaging := filepath.Join(settings.ReposPath(), stagingName)
+	for _, staging := range []string{indexStaging, repoStaging} {
+		if err := os.RemoveAll(staging); err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(staging) }()
 	}
 
-	s.alias = alias
-	s.ready = true
-	slog.Info("Indexes ready", "count", len(indexedRepos))
-	return nil
-}
+	type stagedPath struct{ staged, target string }
+	var paths []stagedPath
+	for repoID := range remote.Repos {
+		indexPath := stagedPath{filepath.Join(indexStaging, repoID+IndexSuffix), filepath.Join(settings.IndexesPath(), repoID+IndexSuffix)}
+		err := downloadArchive(ctx, s.snapshots, snapshotArchiveKey(remote.Generation, repoID, snapshotKindIndex), indexPath.staged)
+		if errors.Is(err, ErrSnapshotNotFound) {
+			continue // Repository was not indexed by the publisher
+		}
+		if err != nil {
+			return fmt.Errorf("failed to download index for %s: %w", repoID, err)
+		}
 
-// saveManifest saves the manifest to disk.
-func (s *Service) saveManifest() error {
-	manifestPath := filepath.Join(s.settings.BaseDir, ManifestFilename)
-	return s.manifest.Save(manifestPath)
-}
+		repoPath := stagedPath{filepath.Join(repoStaging, repoID), filepath.Join(settings.ReposPath(), repoID)}
+		if err := downloadArchive(ctx, s.snapshots, snapshotArchiveKey(remote.Generation, repoID, snapshotKindRepo), repoPath.staged); err != nil {
+			return fmt.Errorf("failed to download working tree for %s: %w", repoID, err)
+		}
+		paths = append(paths, indexPath, repoPath)
+	}
 
-// IsReady returns true if indexes are ready for search.
-func (s *Service) IsReady() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.ready
-}
+	// Release index handles and force a reopen, even if the swap fails midway
+	s.closeAlias()
+	s.mu.Lock()
+	s.generation = 0
+	s.mu.Unlock()
 
-// GetIndexAlias returns the combined index for searching.
-func (s *Service) GetIndexAlias() (bleve.IndexAlias, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	for _, p := range paths {
+		if err := os.RemoveAll(p.target); err != nil {
+			return err
+		}
+		if err := os.Rename(p.staged, p.target); err != nil {
+			return err
+		}
+	}
+	// Repositories the publisher removed are not part of the snapshot
+	for _, repoID := range local.GetRepoIDs() {
+		if _, ok := remote.Repos[repoID]; ok {
+			continue
+		}
+		slog.Info("Removing repository dropped from the snapshot", "repo_id", repoID)
+		for _, dir := range []string{filepath.Join(settings.IndexesPath(), repoID+IndexSuffix), filepath.Join(settings.ReposPath(), repoID)} {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+	}
 
-	if !s.ready || s.alias == nil {
-		return nil, fmt.Errorf("indexes not ready")
+	tempPath := manifestPath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
 	}
-	return s.alias, nil
+	return os.Rename(tempPath, manifestPath)
 }
 
-// GetRepoDir returns the directory for a repository.
-func (s *Service) GetRepoDir(repoID string) string {
-	return filepath.Join(s.settings.BaseDir, "repos", repoID)
-}
+// SyncAll synchronizes all configured repositories. Repositories that fail
+// do not stop the others; their errors are returned joined, one
+// *RepoSyncError per repository.
+func (s *Service) SyncAll(ctx context.Context) error {
+	settings := s.currentSettings()
+	if settings.LocalDir != "" {
+		slog.Info("Syncing repositories", LogEventKey, EventSyncStarted, "repos", 1)
+		before := s.manifest.StateDigest()
+		err := s.syncLocalRepo(ctx, settings.LocalDir)
+		s.progress.advance()
+		s.manifest.UpdateLastSync(s.manifest.StateDigest() != before)
+		failed := 0
+		if err != nil {
+			failed = 1
+			slog.Error("Failed to sync repository", LogEventKey, EventRepoError, "repo_id", localRepoID(settings.LocalDir), "error", err)
+			err = &RepoSyncError{RepoID: localRepoID(settings.LocalDir), Err: err}
+		}
+		slog.Info("Repository sync finished", LogEventKey, EventSyncFinished, "repos", 1, "failed", failed)
+		return err
+	}
 
-// MaxResults returns the configured maximum number of search results.
-func (s *Service) MaxResults() int {
-	return s.settings.MaxResults
-}
+	urls := settings.URLs
+	if len(urls) == 0 {
+		return nil
+	}
 
-// MaxFileSize returns the configured maximum file size for reading.
-func (s *Service) MaxFileSize() int64 {
-	return s.settings.MaxFileSize
-}
+	slog.Info("Syncing repositories", LogEventKey, EventSyncStarted, "repos", len(urls))
+	before := s.manifest.StateDigest()
+	s.removeStaleRepos(urls)
+	errs := s.syncURLs(ctx, syncOrder(urls, settings.Priority))
 
-// GetSettings returns the service settings.
-func (s *Service) GetSettings() *config.GitReposSettings {
-	return s.settings
+	// The generation only advances when the pass changed a repository, so
+	// that readers and consistency tokens are left alone otherwise
+	s.manifest.UpdateLastSync(s.manifest.StateDigest() != before)
+	slog.Info("Repository sync finished", LogEventKey, EventSyncFinished, "repos", len(urls), "failed", len(errs))
+
+	return errors.Join(errs...)
 }
 
-// SetGitOperations allows injecting a custom GitOperations implementation for testing.
-func (s *Service) SetGitOperations(ops GitOperations) {
-	s.git = ops
+// Reload applies updated settings to a running service. Repositories added to
+// the URL list are cloned and indexed, removed ones are cleaned up, and the
+// index alias is reopened. Existing repositories are left as they are until
+// the next sync; the new file filter applies to any indexing from now on.
+// The repository overlay applies on top of the new URL list.
+func (s *Service) Reload(ctx context.Context, settings *config.GitReposSettings) error {
+	if settings == nil {
+		return fmt.Errorf("settings cannot be nil")
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.reload(ctx, settings)
 }
 
-// Close releases all resources.
-func (s *Service) Close() error {
+// reload applies settings as described for Reload. Must be called with
+// syncMu held.
+func (s *Service) reload(ctx context.Context, settings *config.GitReposSettings) error {
+	configured := settings.URLs
+	settings, err := withRepoOverlay(settings)
+	if err != nil {
+		return err
+	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.configured = configured
+	s.mu.Unlock()
 
-	if s.alias != nil {
-		if err := s.alias.Close(); err != nil {
-			return fmt.Errorf("failed to close alias: %w", err)
-		}
-		s.alias = nil
+	if settings.LocalDir != "" {
+		// The working directory is the only repository; apply the new filter
+		// on the next reindex.
+		s.mu.Lock()
+		s.settings = settings
+		s.mu.Unlock()
+		s.indexer.SetFilter(newFileFilter(settings))
+		s.indexer.SetBatchLimits(settings.IndexBatchSize, settings.IndexBatchBytes)
+		s.indexer.SetDeclarations(settings.IndexDeclarations)
+		return nil
 	}
 
-	s.ready = false
+	if settings.ReadOnly {
+		// Repositories are managed by the external sync process; just pick up
+		// the new repository list on the next generation check.
+		s.mu.Lock()
+		s.settings = settings
+		s.generation = 0
+		s.mu.Unlock()
+		s.checkGeneration()
+		return nil
+	}
+
+	if err := s.lock.Lock(settings.SyncTimeout); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	previous := s.currentSettings()
+	known := make(map[string]bool, len(previous.URLs))
+	for _, url := range previous.URLs {
+		known[URLToRepoID(url)] = true
+	}
+	var added []string
+	for _, url := range settings.URLs {
+		if !known[URLToRepoID(url)] {
+			added = append(added, url)
+		}
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.mu.Unlock()
+	s.indexer.SetFilter(newFileFilter(settings))
+	s.indexer.SetBatchLimits(settings.IndexBatchSize, settings.IndexBatchBytes)
+	s.indexer.SetDeclarations(settings.IndexDeclarations)
+
+	slog.Info("Reloading git repos settings", "repos", len(settings.URLs), "added", len(added))
+
+	// New repositories have no open index handles, so they can be synced
+	// while the current alias keeps serving searches, and join it as soon
+	// as they are indexed.
+	s.serveSynced = true
+	errs := s.syncURLs(ctx, syncOrder(added, settings.Priority))
+	s.serveSynced = false
+	if len(errs) > 0 {
+		slog.Error("Sync failed", "failed", len(errs), "error", errors.Join(errs...))
+	}
+
+	// Index handles must be released before stale indexes can be deleted
+	// and before the retained ones can be reopened by the new alias.
+	s.closeAlias()
+	s.removeStaleRepos(settings.URLs)
+	s.manifest.UpdateLastSync(true)
+
+	if err := s.saveManifest(); err != nil {
+		slog.Error("Failed to save manifest", "error", err)
+	}
+	if err := s.lock.Unlock(); err != nil {
+		slog.Error("Failed to unlock", "error", err)
+	}
+
+	return s.openIndexes()
+}
+
+// RepositoryUpdate is the outcome of UpdateRepositories.
+type RepositoryUpdate struct {
+	Added   []string          // display names of the added repositories
+	Removed []string          // display names of the removed repositories
+	Failed  map[string]string // sync errors of added repositories, by display name
+	// Retention is how long the clones and indexes of removed repositories
+	// are kept
+	Retention time.Duration
+}
+
+// UpdateRepositories adds the repositories at the URLs in add and removes the
+// repositories in remove, given by name or URL. The changes are recorded in
+// the repository overlay, so that they outlast restarts and reloads, and
+// applied right away: added repositories are cloned and indexed before it
+// returns, and removed ones leave searches. URLs of repositories already
+// configured are ignored.
+func (s *Service) UpdateRepositories(ctx context.Context, add, remove []string) (*RepositoryUpdate, error) {
+	settings := s.currentSettings()
+	if settings.LocalDir != "" {
+		return nil, errors.New("repositories cannot be changed when serving a local working directory")
+	}
+	if settings.ReadOnly {
+		return nil, errors.New("repositories cannot be changed in read-only mode; change them on the sync process")
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	// Checked before anything is changed, so that a bad entry fails the
+	// whole update
+	settings = s.currentSettings()
+	s.mu.RLock()
+	configured := s.configured
+	s.mu.RUnlock()
+	current := ConfiguredRepoIDs(settings)
+	var added, removed []string
+	for _, entry := range add {
+		url := config.NormalizeRepoURL(entry)
+		if url == "" {
+			continue
+		}
+		host, _, _, err := ParseRepoURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository URL %s: %w", stripURLCredentials(url), err)
+		}
+		if err := settings.CheckRepoURL(url); err != nil {
+			return nil, err
+		}
+		// Without an allow-list, added repositories are limited to the
+		// hosts the configuration already trusts
+		if len(settings.AllowedURLs) == 0 && !slices.ContainsFunc(configured, func(u string) bool {
+			h, _, _, err := ParseRepoURL(u)
+			return err == nil && strings.EqualFold(h, host)
+		}) {
+			return nil, fmt.Errorf("%w: %s is not on a configured host; set git-repos-allowed-urls to add repositories from other hosts", config.ErrURLNotAllowed, config.RepoURLName(url))
+		}
+		if repoID := URLToRepoID(url); !slices.Contains(current, repoID) && !slices.ContainsFunc(added, func(u string) bool { return URLToRepoID(u) == repoID }) {
+			added = append(added, url)
+		}
+	}
+	for _, entry := range remove {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		repoID := URLToRepoID(entry)
+		if !slices.Contains(current, repoID) {
+			return nil, fmt.Errorf("repository not configured: %s", stripURLCredentials(entry))
+		}
+		if slices.ContainsFunc(add, func(u string) bool { return URLToRepoID(u) == repoID }) {
+			return nil, fmt.Errorf("repository both added and removed: %s", RepoIDToDisplay(repoID))
+		}
+		if !slices.Contains(removed, repoID) {
+			removed = append(removed, repoID)
+		}
+	}
+
+	update := &RepositoryUpdate{Failed: make(map[string]string), Retention: settings.RemovedRetention}
+	if len(added) == 0 && len(removed) == 0 {
+		return update, nil
+	}
+
+	overlayPath := filepath.Join(settings.BaseDir, RepoOverlayFilename)
+	overlay, err := LoadRepoOverlay(overlayPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range added {
+		overlay.Add(url, configured)
+	}
+	for _, repoID := range removed {
+		overlay.Remove(repoID, configured)
+	}
+	if err := overlay.Save(overlayPath); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Updating repositories", "added", len(added), "removed", len(removed))
+
+	next := *settings
+	next.URLs = configured
+	if err := s.reload(ctx, &next); err != nil {
+		return nil, err
+	}
+
+	for _, url := range added {
+		repoID := URLToRepoID(url)
+		update.Added = append(update.Added, RepoIDToDisplay(repoID))
+		if state := s.manifest.GetRepoState(repoID); state.Error != "" {
+			update.Failed[RepoIDToDisplay(repoID)] = state.Error
+		}
+	}
+	for _, repoID := range removed {
+		update.Removed = append(update.Removed, RepoIDToDisplay(repoID))
+	}
+	return update, nil
+}
+
+// removeStaleRepos deletes the indexes and clones of repositories that are no
+// longer configured, once they have been removed for the retention period.
+// Until then they are only left out of searches, and adding them back
+// restores them without a new clone or full index.
+func (s *Service) removeStaleRepos(urls []string) {
+	retention := s.currentSettings().RemovedRetention
+	marked, removed := s.manifest.ExpireStaleRepos(urls, retention)
+	for _, repoID := range marked {
+		slog.Info("Repository removed from configuration, keeping its index", "repo_id", repoID, "delete_after", time.Now().UTC().Add(retention).Format(time.RFC3339))
+	}
+	for _, repoID := range removed {
+		slog.Info("Removing stale repository", "repo_id", repoID)
+		s.beginRewrite(repoID)
+		if err := s.indexer.DeleteIndex(repoID); err != nil {
+			slog.Error("Failed to delete index for stale repo", "repo_id", repoID, "error", err)
+		}
+		if catalog := s.getCatalog(); catalog != nil {
+			if err := catalog.DeleteRepo(repoID); err != nil {
+				slog.Error("Failed to remove stale repo from catalog", "repo_id", repoID, "error", err)
+			}
+		}
+		// Clean up repo directory; always within the base directory, never a
+		// local working directory
+		if err := os.RemoveAll(filepath.Join(s.currentSettings().ReposPath(), repoID)); err != nil {
+			slog.Error("Failed to remove stale repo directory", "repo_id", repoID, "error", err)
+		}
+		s.removeAllRefSnapshots(repoID)
+	}
+}
+
+// RepoSyncError is the error of a single repository sync. SyncAll returns
+// them joined with errors.Join; use errors.As to inspect them.
+type RepoSyncError struct {
+	RepoID string
+	Err    error
+}
+
+func (e *RepoSyncError) Error() string {
+	return fmt.Sprintf("sync %s: %v", e.RepoID, e.Err)
+}
+
+func (e *RepoSyncError) Unwrap() error {
+	return e.Err
+}
+
+// syncFailures returns the number of repository errors joined in err.
+func syncFailures(err error) int {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return len(joined.Unwrap())
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// syncOrder returns urls with the repositories listed in priority first, in
+// that order, followed by the others in their original order. Priority
+// entries are repository names or URLs; entries that match none of urls are
+// ignored.
+func syncOrder(urls, priority []string) []string {
+	if len(priority) == 0 {
+		return urls
+	}
+	rank := make(map[string]int, len(priority))
+	for i, entry := range priority {
+		if _, ok := rank[URLToRepoID(entry)]; !ok {
+			rank[URLToRepoID(entry)] = i
+		}
+	}
+	ordered := slices.Clone(urls)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		rankA, okA := rank[URLToRepoID(a)]
+		rankB, okB := rank[URLToRepoID(b)]
+		switch {
+		case okA && okB:
+			return rankA - rankB
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+	return ordered
+}
+
+// syncURLs syncs the given repositories in parallel and returns the errors of
+// the ones that failed, ordered by repository ID. Repositories start syncing
+// in the order of urls. With serveSynced set, each repository is served as
+// soon as it is done.
+func (s *Service) syncURLs(ctx context.Context, urls []string) []error {
+	if s.serveSynced {
+		s.mu.Lock()
+		s.pending = make(map[string]bool, len(urls))
+		for _, url := range urls {
+			s.pending[URLToRepoID(url)] = true
+		}
+		s.mu.Unlock()
+	}
+
+	// Use semaphore to limit parallel syncs
+	sem := make(chan struct{}, MaxParallelSyncs)
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(urls))
+
+	for _, url := range urls {
+		repoID := URLToRepoID(url)
+		wg.Add(1)
+		// Acquired before starting the goroutine, so that slots are taken
+		// in order
+		sem <- struct{}{}
+		go func(url, repoID string) {
+			defer wg.Done()
+			defer func() { <-sem }() // Release
+
+			err := s.syncRepo(ctx, repoID, url)
+			if err != nil {
+				slog.Error("Failed to sync repository", LogEventKey, EventRepoError, "repo_id", repoID, "error", err)
+				s.manifest.SetRepoError(repoID, err.Error())
+				errChan <- &RepoSyncError{RepoID: repoID, Err: err}
+			} else {
+				s.manifest.ClearRepoError(repoID)
+			}
+			// Snapshots and history have their own indexes, so the
+			// repository can be served before they are taken
+			if s.serveSynced {
+				s.serveRepo(repoID)
+			}
+			if err == nil {
+				s.syncRefSnapshots(ctx, repoID, url)
+				s.syncHistory(ctx, repoID)
+			}
+			s.progress.advance()
+		}(url, repoID)
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	// Collect errors
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.(*RepoSyncError).RepoID, b.(*RepoSyncError).RepoID)
+	})
+	return errs
+}
+
+// cloneDepth returns how many commits of history to clone and fetch for the
+// repository at url: its configured depth, deepened when the history index
+// needs more commits (one more than it indexes, so that the oldest has a
+// parent). 0 means full history.
+func (s *Service) cloneDepth(url string) int {
+	settings := s.currentSettings()
+	depth := settings.CloneDepthFor(url)
+	if history := settings.HistoryCommits; history > 0 && depth > 0 && depth <= history {
+		depth = history + 1
+	}
+	return depth
+}
+
+// branchName describes a branch in logs, where "" is the remote default.
+func branchName(branch string) string {
+	if branch == "" {
+		return "remote default"
+	}
+	return branch
+}
+
+// syncRepo syncs a single repository.
+func (s *Service) syncRepo(ctx context.Context, repoID, url string) error {
+	// Checked again here, and not only when the settings are loaded, so that
+	// a URL that reaches the service some other way is not cloned either
+	if err := s.currentSettings().CheckRepoURL(url); err != nil {
+		return err
+	}
+
+	repoDir := s.GetRepoDir(repoID)
+	branch := s.currentSettings().BranchFor(url)
+
+	// Get current state
+	state := s.manifest.GetRepoState(repoID)
+	isNew := !s.manifest.HasRepo(repoID) || state.ClonedAt.IsZero()
+
+	// A clone holds a single branch, so switching branches clones again
+	if !isNew && state.Branch != branch {
+		slog.Info("Branch changed, cloning again", "repo_id", repoID, "from", branchName(state.Branch), "to", branchName(branch))
+		if err := os.RemoveAll(repoDir); err != nil {
+			return fmt.Errorf("failed to remove previous clone: %w", err)
+		}
+		isNew = true
+	}
+
+	if isNew {
+		// Clone new repository
+		if s.currentSettings().LogURLs {
+			slog.Info("Cloning repository", "repo_id", repoID, "url", RedactURLCredentials(url))
+		} else {
+			slog.Info("Cloning repository", "repo_id", repoID)
+		}
+		if err := s.git.Clone(ctx, url, repoDir, branch, s.cloneDepth(url)); err != nil {
+			return fmt.Errorf("clone failed: %w", err)
+		}
+		state.URL = stripURLCredentials(url)
+		state.ClonedAt = time.Now().UTC()
+		state.Branch = branch
+	} else {
+		// Fetch updates
+		slog.Info("Fetching repository updates", "repo_id", repoID)
+		if err := s.git.Fetch(ctx, repoDir, branch, s.cloneDepth(url)); err != nil {
+			return fmt.Errorf("fetch failed: %w", err)
+		}
+		// Fetching does not move HEAD; check out what was fetched, whether
+		// the branch moved forward, was rebased or was force-pushed
+		if err := s.git.Reset(ctx, repoDir, branch); err != nil {
+			return fmt.Errorf("reset failed: %w", err)
+		}
+	}
+
+	// Get current HEAD commit
+	currentCommit, err := s.git.GetHeadCommit(ctx, repoDir)
+	if err != nil {
+		if isNew && branch == "" {
+			// The clone succeeded but checked nothing out. It is removed,
+			// so that the next sync clones again.
+			if rmErr := os.RemoveAll(repoDir); rmErr != nil {
+				slog.Warn("Failed to remove empty clone", "repo_id", repoID, "error", rmErr)
+			}
+			return fmt.Errorf("%w; set the branch to sync with --git-repos-branches", ErrNoDefaultBranch)
+		}
+		return fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	// Check if reindex is needed
+	needsReindex := isNew || state.LastIndexed == "" || currentCommit != state.LastCommit || state.IndexVersion != IndexMappingVersion
+	if !needsReindex {
+		slog.Info("Repository already up to date", "repo_id", repoID)
+		return nil
+	}
+
+	changed := !isNew && state.LastIndexed != "" && currentCommit != state.LastCommit
+	return s.indexRepo(ctx, repoID, repoDir, state, currentCommit, changed)
+}
+
+// syncLocalRepo indexes the working directory used in local (--cwd) mode.
+// The checkout is the developer's own, so it is never fetched or reset; it is
+// reindexed whenever HEAD moves.
+func (s *Service) syncLocalRepo(ctx context.Context, repoDir string) error {
+	repoID := localRepoID(repoDir)
+	state := s.manifest.GetRepoState(repoID)
+
+	currentCommit, err := s.git.GetHeadCommit(ctx, repoDir)
+	if err != nil {
+		err = fmt.Errorf("failed to get HEAD commit: %w", err)
+		s.manifest.SetRepoError(repoID, err.Error())
+		return err
+	}
+
+	if currentCommit == state.LastIndexed && state.IndexVersion == IndexMappingVersion && s.indexer.IndexExists(repoID) {
+		slog.Info("Repository already up to date", "repo_id", repoID)
+		return nil
+	}
+
+	state.URL = repoDir
+	incremental := state.LastIndexed != "" && s.indexer.IndexExists(repoID)
+	if err := s.indexRepo(ctx, repoID, repoDir, state, currentCommit, incremental); err != nil {
+		s.manifest.SetRepoError(repoID, err.Error())
+		return err
+	}
+	s.manifest.ClearRepoError(repoID)
+	return nil
+}
+
+// watchHead reindexes the local working directory when HEAD changes, until
+// stop is closed.
+func (s *Service) watchHead(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.headPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.checkHead(context.Background())
+		}
+	}
+}
+
+// checkHead reindexes the local working directory if HEAD moved since it was
+// last indexed. Searches are unavailable while the index is rewritten.
+func (s *Service) checkHead(ctx context.Context) {
+	settings := s.currentSettings()
+	repoID := localRepoID(settings.LocalDir)
+
+	commit, err := s.git.GetHeadCommit(ctx, settings.LocalDir)
+	if err != nil {
+		slog.Warn("Failed to read HEAD", "repo_id", repoID, "error", err)
+		return
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if commit == s.manifest.GetRepoState(repoID).LastIndexed {
+		return
+	}
+
+	slog.Info("HEAD changed, reindexing", "repo_id", repoID, "commit", commit)
+	if err := s.lock.Lock(settings.SyncTimeout); err != nil {
+		slog.Error("Failed to acquire lock", "error", err)
+		return
+	}
+
+	s.progress.begin(1)
+	defer s.progress.end()
+
+	// The index cannot be written while the alias holds it open
+	s.closeAlias()
+	if err := s.SyncAll(ctx); err != nil {
+		slog.Error("Sync failed", "error", err)
+	}
+	if err := s.saveManifest(); err != nil {
+		slog.Error("Failed to save manifest", "error", err)
+	}
+	if err := s.lock.Unlock(); err != nil {
+		slog.Error("Failed to unlock", "error", err)
+	}
+
+	if err := s.openIndexes(); err != nil {
+		slog.Error("Failed to open indexes", "error", err)
+	}
+}
+
+// reindexLocalFiles incrementally reindexes files changed in the local
+// working directory. Searches are unavailable while the index is written.
+func (s *Service) reindexLocalFiles(files []string) {
+	settings := s.currentSettings()
+	repoID := localRepoID(settings.LocalDir)
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if err := s.lock.Lock(settings.SyncTimeout); err != nil {
+		slog.Error("Failed to acquire lock", "error", err)
+		return
+	}
+
+	s.progress.begin(1)
+	defer s.progress.end()
+
+	// The index cannot be written while the alias holds it open
+	s.closeAlias()
+	indexed, err := s.indexer.IncrementalIndex(repoID, settings.LocalDir, files)
+	s.progress.advance()
+	if err != nil {
+		slog.Error("Failed to reindex changed files", "repo_id", repoID, "error", err)
+	} else {
+		slog.Info("Reindexed changed files", "repo_id", repoID, "changed_files", len(files), "indexed", indexed)
+		s.updateCatalog(repoID, s.manifest.GetRepoState(repoID).LastIndexed)
+	}
+	// Working tree edits change results just like commits do
+	s.manifest.UpdateLastSync(true)
+	if err := s.lock.Unlock(); err != nil {
+		slog.Error("Failed to unlock", "error", err)
+	}
+
+	if err := s.openIndexes(); err != nil {
+		slog.Error("Failed to open indexes", "error", err)
+	}
+}
+
+// indexRepo indexes a repository at currentCommit, incrementally from the last
+// indexed commit when possible, and records the result in the manifest.
+func (s *Service) indexRepo(ctx context.Context, repoID, repoDir string, state *RepoState, currentCommit string, incremental bool) error {
+	s.beginRewrite(repoID)
+
+	// Try incremental index if we have previous commit and the index mapping
+	// is current. Indexes cut short by the budget are rebuilt so the budget is
+	// re-evaluated against the new tree.
+	if incremental && state.LastCommit != "" && state.IndexVersion == IndexMappingVersion && state.Warning == "" {
+		changedFiles, err := s.git.GetChangedFiles(ctx, repoDir, state.LastCommit, currentCommit)
+		if err == nil && len(changedFiles) > 0 && len(changedFiles) <= 100 {
+			slog.Info("Incremental indexing", "repo_id", repoID, "changed_files", len(changedFiles))
+			indexed, err := s.indexer.IncrementalIndex(repoID, repoDir, changedFiles)
+			if err != nil {
+				slog.Warn("Incremental index failed, falling back to full index", "error", err)
+			} else {
+				state.LastCommit = currentCommit
+				state.LastIndexed = currentCommit
+				state.LastPull = time.Now().UTC()
+				state.License = DetectLicense(repoDir)
+				s.manifest.SetRepoState(repoID, *state)
+				s.updateCatalog(repoID, currentCommit)
+				slog.Info("Incremental index complete", "repo_id", repoID, "indexed", indexed)
+				return nil
+			}
+		} else if err == nil && len(changedFiles) > 100 {
+			slog.Info("Too many changed files for incremental index, falling back to full index", "repo_id", repoID, "changed_files", len(changedFiles))
+		} else if errors.Is(err, ErrCommitUnavailable) {
+			slog.Info("Indexed commit is no longer in the clone, falling back to full index", "repo_id", repoID, "commit", state.LastCommit)
+		} else if err != nil {
+			slog.Warn("Failed to list changed files, falling back to full index", "repo_id", repoID, "error", err)
+		}
+	}
+
+	// An index built with an older mapping must be recreated, not updated
+	if state.IndexVersion != IndexMappingVersion {
+		if err := s.indexer.DeleteIndex(repoID); err != nil {
+			return fmt.Errorf("failed to delete outdated index: %w", err)
+		}
+	}
+
+	// Full reindex
+	slog.Info("Full indexing", "repo_id", repoID)
+	fileCount, err := s.indexer.FullIndex(repoID, repoDir)
+	state.Warning = ""
+	if errors.Is(err, ErrIndexBudgetExceeded) {
+		slog.Warn("Repository exceeds index budget, index is incomplete", "repo_id", repoID, "error", err)
+		state.Warning = err.Error()
+	} else if err != nil {
+		return fmt.Errorf("full index failed: %w", err)
+	}
+
+	state.Verification = ""
+	if s.currentSettings().VerifyIndex {
+		state.Verification = VerificationOK
+		if err := s.indexer.VerifyIndex(repoID, fileCount); err != nil {
+			slog.Error("Index verification failed", "repo_id", repoID, "error", err)
+			state.Verification = err.Error()
+		}
+	}
+
+	state.LastCommit = currentCommit
+	state.LastIndexed = currentCommit
+	state.IndexVersion = IndexMappingVersion
+	state.FileCount = fileCount
+	state.Skipped = s.indexer.SkipStats(repoID)
+	state.LastPull = time.Now().UTC()
+	state.License = DetectLicense(repoDir)
+	s.manifest.SetRepoState(repoID, *state)
+	s.updateCatalog(repoID, currentCommit)
+	slog.Info("Full index complete", "repo_id", repoID, "file_count", fileCount)
+	return nil
+}
+
+// updateCatalog records the last indexing run of a repository in the file
+// catalog, and refreshes the directory documents of its index from it.
+// Failures are only logged; the catalog is not needed for file search.
+func (s *Service) updateCatalog(repoID, commit string) {
+	changes := s.indexer.CatalogChanges(repoID)
+	if changes == nil {
+		return
+	}
+	catalog := s.getCatalog()
+	if catalog == nil {
+		return
+	}
+	if err := catalog.Apply(repoID, commit, changes); err != nil {
+		slog.Warn("Failed to update file catalog", "repo_id", repoID, "error", err)
+		return
+	}
+
+	files, err := catalog.ListFiles(repoID, "", 0)
+	if err == nil {
+		err = s.indexer.IndexDirectories(repoID, files)
+	}
+	if err != nil {
+		slog.Warn("Failed to update directory documents", "repo_id", repoID, "error", err)
+	}
+}
+
+// getCatalog returns the file catalog, opening it on first use, or nil if it
+// is unavailable. Read-only servers open it read-only once a sync process has
+// created it.
+func (s *Service) getCatalog() *Catalog {
+	s.catalogMu.Lock()
+	defer s.catalogMu.Unlock()
+
+	if s.catalog == nil && s.catalogPath != "" {
+		catalog, err := OpenCatalog(s.catalogPath, s.currentSettings().ReadOnly)
+		if err != nil {
+			slog.Debug("File catalog unavailable", "error", err)
+			return nil
+		}
+		s.catalog = catalog
+	}
+	return s.catalog
+}
+
+// openIndexes opens all indexes and creates the alias. An alias that already
+// serves all of them, such as one built by serveRepo, is kept. Read-only
+// servers only open the repositories of the generation they adopted.
+func (s *Service) openIndexes() error {
+	s.mu.Lock()
+
+	// Get all repo IDs that have indexes
+	var indexedRepos []string
+	for _, repoID := range ConfiguredRepoIDs(s.settings) {
+		if s.settings.ReadOnly && !s.manifest.HasRepo(repoID) {
+			continue
+		}
+		if s.indexer.IndexExists(repoID) {
+			indexedRepos = append(indexedRepos, repoID)
+		}
+	}
+
+	if len(indexedRepos) == 0 {
+		slog.Warn("No indexes available")
+		s.ready = false
+		s.mu.Unlock()
+		return nil
+	}
+
+	if s.alias != nil && len(s.served) == len(indexedRepos) && !slices.ContainsFunc(indexedRepos, func(repoID string) bool { return !s.served[repoID] }) {
+		s.mu.Unlock()
+		slog.Info("Indexes ready", "count", len(indexedRepos))
+		return nil
+	}
+
+	// Create alias combining all indexes
+	alias, err := s.indexer.CreateAlias(indexedRepos)
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("failed to create index alias: %w", err)
+	}
+
+	previous, timeout := s.swapAlias(alias, indexedRepos)
+	s.mu.Unlock()
+	slog.Info("Indexes ready", "count", len(indexedRepos))
+	return closeServedAlias(previous, timeout)
+}
+
+// serveRepo adds a repository that finished syncing to the alias, so that
+// searches include it without waiting for the other repositories. The alias
+// is recreated over the served indexes, whose handles are shared, and the
+// previous one is closed once its searches are done. Repositories without an
+// index are only marked as no longer pending.
+func (s *Service) serveRepo(repoID string) {
+	s.serveMu.Lock()
+	defer s.serveMu.Unlock()
+
+	s.mu.Lock()
+	delete(s.pending, repoID)
+	if s.served[repoID] || !s.indexer.IndexExists(repoID) {
+		s.mu.Unlock()
+		return
+	}
+	var repoIDs []string
+	for _, id := range ConfiguredRepoIDs(s.settings) {
+		if id == repoID || s.served[id] {
+			repoIDs = append(repoIDs, id)
+		}
+	}
+	s.mu.Unlock()
+
+	alias, err := s.indexer.CreateAlias(repoIDs)
+	if err != nil {
+		slog.Error("Failed to serve repository", "repo_id", repoID, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	previous, timeout := s.swapAlias(alias, repoIDs)
+	s.mu.Unlock()
+	slog.Info("Repository ready", "repo_id", repoID, "served", len(repoIDs))
+	if err := closeServedAlias(previous, timeout); err != nil {
+		slog.Error("Failed to close index alias", "error", err)
+	}
+}
+
+// releaseRepos closes the index handles of the given repositories, such as
+// ones an external sync is rewriting. The alias is recreated over the other
+// served indexes, whose handles are shared, and the previous one is closed
+// once its searches are done.
+func (s *Service) releaseRepos(repoIDs []string) {
+	s.serveMu.Lock()
+	defer s.serveMu.Unlock()
+
+	s.mu.Lock()
+	var released, kept []string
+	for _, repoID := range ConfiguredRepoIDs(s.settings) {
+		switch {
+		case !s.served[repoID]:
+		case slices.Contains(repoIDs, repoID):
+			released = append(released, repoID)
+		default:
+			kept = append(kept, repoID)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(released) == 0 {
+		return
+	}
+	slog.Info("Releasing indexes rewritten by an external sync", "repo_ids", released)
+	if len(kept) == 0 {
+		s.closeAlias()
+		return
+	}
+
+	alias, err := s.indexer.CreateAlias(kept)
+	if err != nil {
+		slog.Error("Failed to create index alias", "error", err)
+		s.closeAlias()
+		return
+	}
+	s.mu.Lock()
+	previous, timeout := s.swapAlias(alias, kept)
+	s.mu.Unlock()
+	if err := closeServedAlias(previous, timeout); err != nil {
+		slog.Error("Failed to close index alias", "error", err)
+	}
+}
+
+// swapAlias makes alias over repoIDs the served one and returns the previous
+// alias, if any, with the drain timeout to close it with. The caller must hold
+// s.mu.
+func (s *Service) swapAlias(alias bleve.IndexAlias, repoIDs []string) (*servedAlias, time.Duration) {
+	previous := s.alias
+	s.alias = &servedAlias{IndexAlias: alias}
+	s.served = make(map[string]bool, len(repoIDs))
+	for _, repoID := range repoIDs {
+		s.served[repoID] = true
+	}
+	s.ready = true
+	return previous, s.aliasDrainTimeout()
+}
+
+// aliasDrainTimeout returns how long a replaced alias is kept for the
+// searches running on it: the drain timeout, or twice the search timeout when
+// that is longer. The caller must hold s.mu.
+func (s *Service) aliasDrainTimeout() time.Duration {
+	return max(s.drainTimeout, 2*s.settings.SearchTimeout)
+}
+
+// servedAlias is the alias searches are served from, with the searches
+// currently using it.
+type servedAlias struct {
+	bleve.IndexAlias
+	searches sync.WaitGroup
+}
+
+// drain waits until no search uses the alias, or until timeout, and reports
+// whether all searches finished.
+func (a *servedAlias) drain(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		a.searches.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// closeAlias marks the service not ready and closes the current alias, if
+// any. New searches are refused right away, while searches already running
+// complete on the old alias; it is closed once they are done, or after the
+// drain timeout. Indexes share handles with the alias, so closeAlias returns
+// only when the alias is closed and its indexes may be rewritten or reopened.
+func (s *Service) closeAlias() {
+	if err := s.retireAlias(); err != nil {
+		slog.Error("Failed to close index alias", "error", err)
+	}
+}
+
+// retireAlias detaches the current alias from the service and closes it after
+// draining its searches.
+func (s *Service) retireAlias() error {
+	s.mu.Lock()
+	alias := s.alias
+	s.alias = nil
+	s.served = nil
+	s.ready = false
+	timeout := s.aliasDrainTimeout()
+	s.mu.Unlock()
+
+	return closeServedAlias(alias, timeout)
+}
+
+// closeServedAlias closes a detached alias, if any, after draining its
+// searches.
+func closeServedAlias(alias *servedAlias, timeout time.Duration) error {
+	if alias == nil {
+		return nil
+	}
+	if !alias.drain(timeout) {
+		slog.Warn("Closing index alias with searches still running", "timeout", timeout)
+	}
+	return alias.Close()
+}
+
+// beginRewrite marks the index of a repository as being rewritten in the
+// saved manifest during a sync on behalf of read-only servers, so that they
+// release it. Opening the index for writing waits until they have.
+func (s *Service) beginRewrite(repoID string) {
+	if !s.markRewrite {
+		return
+	}
+	s.rewriteMu.Lock()
+	defer s.rewriteMu.Unlock()
+	s.manifest.MarkRewriting(repoID)
+	if err := s.saveManifest(); err != nil {
+		slog.Error("Failed to save manifest", "error", err)
+	}
+}
+
+// saveManifest saves the manifest to disk.
+func (s *Service) saveManifest() error {
+	manifestPath := filepath.Join(s.currentSettings().BaseDir, ManifestFilename)
+	return s.manifest.Save(manifestPath)
+}
+
+// IndexChecksum returns the checksum of the indexes recorded in the
+// manifest. Repositories indexed with an older schema version count as not
+// indexed, since the next sync rebuilds them.
+func (s *Service) IndexChecksum() *IndexChecksum {
+	settings := s.currentSettings()
+	commits := make(map[string]string)
+	for repoID, state := range s.RepoStates() {
+		commit := ""
+		if state.IndexVersion == IndexMappingVersion {
+			commit = state.LastIndexed
+		}
+		commits[RepoIDToDisplay(repoID)] = commit
+	}
+	return NewIndexChecksum(commits, settings.Refs)
+}
+
+// saveIndexChecksum writes the index checksum file to the base directory.
+// Failures are logged: the checksum only serves to skip rebuilds.
+func (s *Service) saveIndexChecksum() {
+	path := filepath.Join(s.currentSettings().BaseDir, IndexChecksumFilename)
+	if err := s.IndexChecksum().Save(path); err != nil {
+		slog.Warn("Failed to save index checksum", "error", err)
+	}
+}
+
+// IsReady returns true if indexes are ready for search. While repositories
+// are synced, it is true as soon as the first of them is served.
+func (s *Service) IsReady() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready
+}
+
+// IsRepoReady returns true if indexes are ready for search and the given
+// repository is not waiting for its sync to complete.
+func (s *Service) IsRepoReady(repoID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready && !s.pending[repoID]
+}
+
+// PendingRepos returns the repositories that are still syncing and are not
+// searched yet, sorted.
+func (s *Service) PendingRepos() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	repoIDs := slices.Collect(maps.Keys(s.pending))
+	slices.Sort(repoIDs)
+	return repoIDs
+}
+
+// AcquireIndexAlias returns the combined index for searching, and a release
+// function the caller must call when done with it. The alias is not closed
+// while acquired, even when indexing replaces it, unless its searches exceed
+// the drain timeout.
+func (s *Service) AcquireIndexAlias() (bleve.IndexAlias, func(), error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.ready || s.alias == nil {
+		return nil, nil, fmt.Errorf("indexes not ready")
+	}
+	served := s.alias
+	served.searches.Add(1)
+	var once sync.Once
+	return served.IndexAlias, func() { once.Do(served.searches.Done) }, nil
+}
+
+// GetRepoDir returns the directory for a repository.
+func (s *Service) GetRepoDir(repoID string) string {
+	settings := s.currentSettings()
+	if settings.LocalDir != "" && repoID == localRepoID(settings.LocalDir) {
+		return settings.LocalDir
+	}
+	return filepath.Join(settings.ReposPath(), repoID)
+}
+
+// ConfiguredRepoIDs returns the IDs of the repositories in the settings.
+func ConfiguredRepoIDs(settings *config.GitReposSettings) []string {
+	if settings.LocalDir != "" {
+		return []string{localRepoID(settings.LocalDir)}
+	}
+	ids := make([]string, 0, len(settings.URLs))
+	for _, url := range settings.URLs {
+		ids = append(ids, URLToRepoID(url))
+	}
+	return ids
+}
+
+// RepoStates returns the recorded state of every configured repository, by
+// repository ID. Repositories that have not been synced yet have a zero state.
+func (s *Service) RepoStates() map[string]RepoState {
+	ids := ConfiguredRepoIDs(s.currentSettings())
+	states := make(map[string]RepoState, len(ids))
+	for _, repoID := range ids {
+		states[repoID] = *s.manifest.GetRepoState(repoID)
+	}
+	return states
+}
+
+// CatalogSummary returns the cataloged file totals of a repository, or nil if
+// the catalog is unavailable.
+func (s *Service) CatalogSummary(repoID string) *CatalogSummary {
+	catalog := s.getCatalog()
+	if catalog == nil {
+		return nil
+	}
+	summary, err := catalog.Summary(repoID)
+	if err != nil {
+		slog.Warn("Failed to read file catalog", "repo_id", repoID, "error", err)
+		return nil
+	}
+	return summary
+}
+
+// CatalogFiles returns the cataloged files of a repository under prefix,
+// ordered by path, or nil if the catalog is unavailable.
+func (s *Service) CatalogFiles(repoID, prefix string) []CatalogFile {
+	catalog := s.getCatalog()
+	if catalog == nil {
+		return nil
+	}
+	files, err := catalog.ListFiles(repoID, prefix, 0)
+	if err != nil {
+		slog.Warn("Failed to read file catalog", "repo_id", repoID, "error", err)
+		return nil
+	}
+	return files
+}
+
+// Generation returns the sync generation of the indexes being served. Read-only
+// servers report the generation they opened rather than the latest published.
+func (s *Service) Generation() uint64 {
+	if s.currentSettings().ReadOnly {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.generation
+	}
+	return s.manifest.GetGeneration()
+}
+
+// IndexedCommits returns the indexed commit of every configured repository
+// that has been indexed, by display name.
+func (s *Service) IndexedCommits() map[string]string {
+	commits := make(map[string]string)
+	for repoID, state := range s.RepoStates() {
+		if state.LastIndexed != "" {
+			commits[RepoIDToDisplay(repoID)] = state.LastIndexed
+		}
+	}
+	return commits
+}
+
+// IndexedCommit returns the commit the index of repoID was built from, or
+// that of its snapshot of ref when ref is set; empty if it is not indexed.
+func (s *Service) IndexedCommit(repoID, ref string) string {
+	if !s.manifest.HasRepo(repoID) {
+		return ""
+	}
+	state := s.manifest.GetRepoState(repoID)
+	if ref != "" {
+		return state.Snapshots[ref].Commit
+	}
+	return state.LastIndexed
+}
+
+// IndexProgress reports the indexing run that is keeping the indexes closed,
+// if any.
+func (s *Service) IndexProgress() IndexProgress {
+	return s.progress.get()
+}
+
+// AcquireSearch waits for a search slot within the configured concurrency
+// limit and returns the function that releases it. It fails with
+// ErrServerBusy when the search queue is full.
+func (s *Service) AcquireSearch(ctx context.Context) (func(), error) {
+	settings := s.currentSettings()
+
+	// Searches holding a slot release it to the limiter they acquired it
+	// from, so reloaded limits apply to new searches only
+	s.mu.Lock()
+	if s.limiter == nil || !s.limiter.matches(settings.MaxConcurrentSearches, settings.SearchQueueSize) {
+		s.limiter = newToolLimiter("searches", settings.MaxConcurrentSearches, settings.SearchQueueSize)
+	}
+	limiter := s.limiter
+	s.mu.Unlock()
+
+	return limiter.acquire(ctx)
+}
+
+// AcquireRead waits for a slot of the tools that return file contents
+// within the configured concurrency limit and returns the function that
+// releases it. It fails with ErrServerBusy when the read queue is full. Reads
+// stuck on a hung file system keep their slot, so they cannot pile up.
+func (s *Service) AcquireRead(ctx context.Context) (func(), error) {
+	settings := s.currentSettings()
+
+	s.mu.Lock()
+	if s.readLimiter == nil || !s.readLimiter.matches(settings.MaxConcurrentReads, settings.ReadQueueSize) {
+		s.readLimiter = newToolLimiter("reads", settings.MaxConcurrentReads, settings.ReadQueueSize)
+	}
+	limiter := s.readLimiter
+	s.mu.Unlock()
+
+	return limiter.acquire(ctx)
+}
+
+// SearchTimeout returns how long a search may run (0 = no limit).
+func (s *Service) SearchTimeout() time.Duration {
+	return s.currentSettings().SearchTimeout
+}
+
+// ReadTimeout returns how long a call of a tool returning file contents may
+// run (0 = no limit).
+func (s *Service) ReadTimeout() time.Duration {
+	return s.currentSettings().ReadTimeout
+}
+
+// QueryExperiment returns the name of the alternate query strategy searches
+// are compared with, empty if none.
+func (s *Service) QueryExperiment() string {
+	return s.currentSettings().QueryExperiment
+}
+
+// RankingProfile returns the ranking profile of searches that select none
+// themselves or through their API key.
+func (s *Service) RankingProfile() string {
+	return s.currentSettings().RankingProfile
+}
+
+// ExtensionGroup returns the extensions the search extension filter ext
+// stands for: the members of its alias group, or ext itself. Invalid aliases
+// are ignored; they are reported by ValidateSettings.
+func (s *Service) ExtensionGroup(ext string) []string {
+	groups, _ := s.currentSettings().ExtensionGroups()
+	if exts, ok := groups[strings.ToLower(ext)]; ok {
+		return exts
+	}
+	return []string{ext}
+}
+
+// Telemetry returns the search telemetry recorder, nil unless enabled.
+func (s *Service) Telemetry() *SearchTelemetry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.telemetry
+}
+
+// MaxResults returns the configured maximum number of search results.
+func (s *Service) MaxResults() int {
+	return s.currentSettings().MaxResults
+}
+
+// HighlightTags returns the delimiters placed around matched terms in search
+// fragments. Both are empty when highlighting is disabled.
+func (s *Service) HighlightTags() (pre, post string) {
+	settings := s.currentSettings()
+	if !settings.Highlight {
+		return "", ""
+	}
+	return settings.HighlightPre, settings.HighlightPost
+}
+
+// MaxFileSize returns the configured maximum file size for reading.
+func (s *Service) MaxFileSize() int64 {
+	return s.currentSettings().MaxFileSize
+}
+
+// ExcludePatterns returns the exclusion patterns of the file filter, in the
+// order they are tried.
+func (s *Service) ExcludePatterns() []string {
+	return newFileFilter(s.currentSettings()).Patterns()
+}
+
+// MaxFileSizeFor returns the maximum size for reading the file at relPath,
+// including any per-extension override.
+func (s *Service) MaxFileSizeFor(relPath string) int64 {
+	return s.currentSettings().MaxFileSizeFor(relPath)
+}
+
+// ReadDenied reports whether the read policy forbids returning the file at
+// relPath, independently of indexing exclusions.
+func (s *Service) ReadDenied(relPath string) bool {
+	return NewFileFilterWithPatterns(s.currentSettings().ReadDenyPatterns, 0).ShouldExclude(relPath)
+}
+
+// Redact masks matches of the read redaction patterns in content and returns
+// the number of masked matches. Invalid patterns are ignored; they are
+// reported by ValidateSettings.
+func (s *Service) Redact(content []byte) ([]byte, int) {
+	redactions, _ := s.currentSettings().ReadRedactions()
+	return redactContent(content, redactions)
+}
+
+// ReadIndexedOnly reports whether reads are limited to indexed files.
+func (s *Service) ReadIndexedOnly() bool {
+	return s.currentSettings().ReadIndexedOnly
+}
+
+// IsIndexed reports whether the file at relPath in a repository, or in a ref
+// snapshot, is in the index. Lookup failures count as not indexed.
+func (s *Service) IsIndexed(repoID, relPath string) bool {
+	var alias bleve.IndexAlias
+	var release func()
+	var err error
+	if baseRepoID(repoID) != repoID {
+		alias, err = s.indexer.CreateAlias([]string{repoID})
+		release = func() { _ = alias.Close() }
+	} else {
+		alias, release, err = s.AcquireIndexAlias()
+	}
+	if err != nil {
+		return false
+	}
+	defer release()
+	req := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{repoID + "/" + filepath.ToSlash(relPath)}))
+	req.Size = 0
+	result, err := alias.Search(req)
+	return err == nil && result.Total > 0
+}
+
+// FollowSymlinks reports whether symlinks inside repositories are followed.
+func (s *Service) FollowSymlinks() bool {
+	return s.currentSettings().FollowSymlinks
+}
+
+// GetSettings returns the service settings.
+func (s *Service) GetSettings() *config.GitReposSettings {
+	return s.currentSettings()
+}
+
+// currentSettings returns the active settings, which may be replaced by Reload.
+func (s *Service) currentSettings() *config.GitReposSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+// SetGitOperations allows injecting a custom GitOperations implementation for testing.
+func (s *Service) SetGitOperations(ops GitOperations) {
+	s.git = ops
+}
+
+// Close releases all resources.
+func (s *Service) Close() error {
+	if err := s.retireAlias(); err != nil {
+		return fmt.Errorf("failed to close alias: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopWatch != nil {
+		close(s.stopWatch)
+		s.stopWatch = nil
+	}
+	if s.fileWatcher != nil {
+		_ = s.fileWatcher.Close()
+		s.fileWatcher = nil
+	}
+
+	if err := s.telemetry.Close(); err != nil {
+		slog.Error("Failed to close telemetry file", "error", err)
+	}
+	s.telemetry = nil
+
+	if s.throttle != nil {
+		if err := s.throttle.Close(); err != nil {
+			slog.Error("Failed to stop fetch throttle", "error", err)
+		}
+		s.throttle = nil
+	}
+
+	s.catalogMu.Lock()
+	defer s.catalogMu.Unlock()
+	if s.catalog != nil {
+		if err := s.catalog.Close(); err != nil {
+			return fmt.Errorf("failed to close catalog: %w", err)
+		}
+		s.catalog = nil
+	}
 	return nil
 }
@@ -2,13 +2,19 @@ package gitrepos
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/blevesearch/bleve/v2"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/sha1n/mcp-relic-server/internal/config"
 )
@@ -85,6 +91,69 @@ func TestNewService_CreatesDirStructure(t *testing.T) {
 	}
 }
 
+func TestNewService_SeparateDirs(t *testing.T) {
+	dir := t.TempDir()
+	reposDir := filepath.Join(dir, "bulk", "repos")
+	fastDir := filepath.Join(dir, "fast")
+	if err := os.MkdirAll(fastDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	indexesDir := filepath.Join(dir, "indexes-link")
+	createTestSymlink(t, fastDir, indexesDir)
+
+	settings := &config.GitReposSettings{
+		URLs:        []string{"git@github.com:test/repo.git"},
+		BaseDir:     filepath.Join(dir, "base"),
+		ReposDir:    reposDir,
+		IndexesDir:  indexesDir,
+		SyncTimeout: 5 * time.Second,
+		MaxFileSize: 256 * 1024,
+		MaxResults:  20,
+	}
+	svc, err := NewService(settings)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	defer func() { _ = svc.Close() }()
+
+	mock := NewMockExecutor()
+	mock.AddResponse("git clone", []byte{}, nil)
+	mock.AddResponse("git rev-parse", []byte("abc123\n"), nil)
+	svc.git = NewGitClientWithExecutor(mock)
+
+	repoDir := svc.GetRepoDir("github.com_test_repo")
+	if repoDir != filepath.Join(reposDir, "github.com_test_repo") {
+		t.Errorf("GetRepoDir() = %q, want it under %q", repoDir, reposDir)
+	}
+	writeTestFile(t, repoDir, "main.go", "package main")
+
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(fastDir, "github.com_test_repo"+IndexSuffix)); err != nil {
+		t.Errorf("Expected the index on the indexes volume: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settings.BaseDir, "repos")); !os.IsNotExist(err) {
+		t.Errorf("Expected no repos directory under the base directory, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settings.BaseDir, ManifestFilename)); err != nil {
+		t.Errorf("Expected the manifest in the base directory: %v", err)
+	}
+}
+
+func TestNewService_IndexesDirNotADirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewService(&config.GitReposSettings{BaseDir: dir, IndexesDir: file, MaxFileSize: 256 * 1024})
+	if err == nil || !strings.Contains(err.Error(), "invalid indexes directory") {
+		t.Errorf("Expected invalid indexes directory error, got: %v", err)
+	}
+}
+
 // ============================
 // Service method tests (using real NewService)
 // ============================
@@ -111,7 +180,7 @@ func TestService_IsReady_InitiallyFalse(t *testing.T) {
 	}
 }
 
-func TestService_GetIndexAlias_NotReady(t *testing.T) {
+func TestService_AcquireIndexAlias_NotReady(t *testing.T) {
 	dir := t.TempDir()
 	settings := &config.GitReposSettings{
 		BaseDir:     dir,
@@ -128,12 +197,80 @@ func TestService_GetIndexAlias_NotReady(t *testing.T) {
 		}
 	}()
 
-	_, err = svc.GetIndexAlias()
+	_, _, err = svc.AcquireIndexAlias()
 	if err == nil {
 		t.Error("Expected error when getting alias before ready")
 	}
 }
 
+func TestService_CloseAlias_DrainsSearches(t *testing.T) {
+	svc := setupSearchService(t, t.TempDir(), map[string]string{"main.go": "package main\n\nfunc main() {}"})
+	defer func() { _ = svc.Close() }()
+
+	alias, release, err := svc.AcquireIndexAlias()
+	if err != nil {
+		t.Fatalf("AcquireIndexAlias failed: %v", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		svc.closeAlias()
+		close(closed)
+	}()
+
+	// New searches are refused while the old alias keeps serving
+	deadline := time.Now().Add(5 * time.Second)
+	for svc.IsReady() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, _, err := svc.AcquireIndexAlias(); err == nil {
+		t.Error("Expected a replaced alias not to be acquired")
+	}
+	select {
+	case <-closed:
+		t.Fatal("Expected the alias to stay open while a search uses it")
+	case <-time.After(50 * time.Millisecond):
+	}
+	result, err := alias.Search(bleve.NewSearchRequest(bleve.NewMatchQuery("main")))
+	if err != nil || result.Total == 0 {
+		t.Errorf("Expected the in-flight search to complete on the old alias, got %v (err: %v)", result, err)
+	}
+
+	release()
+	release() // releasing twice is harmless
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the alias to be closed once released")
+	}
+	if _, err := alias.Search(bleve.NewSearchRequest(bleve.NewMatchQuery("main"))); err == nil {
+		t.Error("Expected the drained alias to be closed")
+	}
+}
+
+func TestService_CloseAlias_DrainTimeout(t *testing.T) {
+	svc := setupSearchService(t, t.TempDir(), map[string]string{"main.go": "package main"})
+	defer func() { _ = svc.Close() }()
+	svc.drainTimeout = 10 * time.Millisecond
+
+	_, release, err := svc.AcquireIndexAlias()
+	if err != nil {
+		t.Fatalf("AcquireIndexAlias failed: %v", err)
+	}
+	defer release()
+
+	closed := make(chan struct{})
+	go func() {
+		svc.closeAlias()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the alias to be closed after the drain timeout")
+	}
+}
+
 func TestService_GetRepoDir(t *testing.T) {
 	dir := t.TempDir()
 	settings := &config.GitReposSettings{
@@ -204,6 +341,43 @@ func TestService_MaxFileSize(t *testing.T) {
 	}
 }
 
+func TestService_CloneDepth(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int
+		overrides []string
+		history   int
+		want      int
+	}{
+		{"default", 1, nil, 0, 1},
+		{"full history", 0, nil, 0, 0},
+		{"override", 1, []string{"github.com/org/repo=25"}, 0, 25},
+		{"deepened for history", 1, nil, 10, 11},
+		{"deep enough for history", 30, nil, 10, 30},
+		{"full history kept with history", 0, nil, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &config.GitReposSettings{
+				BaseDir:             t.TempDir(),
+				MaxResults:          20,
+				CloneDepth:          tt.depth,
+				CloneDepthOverrides: tt.overrides,
+				HistoryCommits:      tt.history,
+			}
+			svc, err := NewService(settings)
+			if err != nil {
+				t.Fatalf("NewService failed: %v", err)
+			}
+			defer func() { _ = svc.Close() }()
+
+			if got := svc.cloneDepth("git@github.com:org/repo.git"); got != tt.want {
+				t.Errorf("cloneDepth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestService_GetSettings(t *testing.T) {
 	dir := t.TempDir()
 	settings := &config.GitReposSettings{
@@ -314,6 +488,45 @@ func TestService_Initialize_LeaderNoURLs(t *testing.T) {
 	}
 }
 
+func TestService_Initialize_SyncFailureThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		wantErr   bool
+	}{
+		{"never fails", 0, false},
+		{"below threshold", 3, false},
+		{"at threshold", 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewServiceWithDeps(
+				&config.GitReposSettings{
+					BaseDir:              t.TempDir(),
+					URLs:                 []string{"git@github.com:test/a.git", "git@github.com:test/b.git"},
+					SyncTimeout:          5 * time.Second,
+					SyncFailureThreshold: tt.threshold,
+				},
+				ServiceDeps{
+					Git:      &mockGitOps{cloneErr: fmt.Errorf("network error")},
+					Indexer:  &mockIndexOps{},
+					Manifest: newMockManifestOps(),
+					Lock:     &mockSyncLock{tryLockResult: true},
+				},
+			)
+
+			err := svc.Initialize(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Initialize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "failure threshold of 2") {
+				t.Errorf("Expected the threshold in the error, got: %v", err)
+			}
+		})
+	}
+}
+
 func TestService_Initialize_LeaderSyncSuccess(t *testing.T) {
 	dir := t.TempDir()
 	repoID := "github.com_test_repo"
@@ -363,6 +576,117 @@ func TestService_Initialize_LeaderSyncFails_StillOpensIndexes(t *testing.T) {
 	}
 }
 
+// blockingGitOps holds the clone of one URL until released.
+type blockingGitOps struct {
+	mockGitOps
+	url     string
+	release chan struct{}
+}
+
+func (m *blockingGitOps) Clone(ctx context.Context, url, destDir, branch string, depth int) error {
+	if url == m.url {
+		<-m.release
+	}
+	return m.mockGitOps.Clone(ctx, url, destDir, branch, depth)
+}
+
+// aliasRecordingIndexer records the repositories of each alias it creates.
+type aliasRecordingIndexer struct {
+	*mockIndexOps
+	mu      sync.Mutex
+	aliases [][]string
+}
+
+func (m *aliasRecordingIndexer) CreateAlias(repoIDs []string) (bleve.IndexAlias, error) {
+	m.mu.Lock()
+	m.aliases = append(m.aliases, slices.Clone(repoIDs))
+	m.mu.Unlock()
+	return m.mockIndexOps.CreateAlias(repoIDs)
+}
+
+func TestService_Initialize_ServesReposAsTheyComplete(t *testing.T) {
+	git := &blockingGitOps{mockGitOps: mockGitOps{headCommit: "abc123"}, url: "git@github.com:test/slow.git", release: make(chan struct{})}
+	indexer := &aliasRecordingIndexer{mockIndexOps: &mockIndexOps{
+		fullIndexCount: 1,
+		existsMap:      map[string]bool{"github.com_test_fast": true, "github.com_test_slow": true},
+	}}
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir:     t.TempDir(),
+			URLs:        []string{"git@github.com:test/slow.git", "git@github.com:test/fast.git"},
+			SyncTimeout: 5 * time.Second,
+		},
+		ServiceDeps{
+			Git:      git,
+			Indexer:  indexer,
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{tryLockResult: true},
+		},
+	)
+	defer func() { _ = svc.Close() }()
+
+	done := make(chan error)
+	go func() { done <- svc.Initialize(context.Background()) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !svc.IsRepoReady("github.com_test_fast") {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the first repository to be served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !svc.IsReady() {
+		t.Error("Expected the service to be ready once a repository is served")
+	}
+	if svc.IsRepoReady("github.com_test_slow") {
+		t.Error("Expected the repository still syncing not to be ready")
+	}
+	if pending := svc.PendingRepos(); !slices.Equal(pending, []string{"github.com_test_slow"}) {
+		t.Errorf("PendingRepos() = %v, want the repository still syncing", pending)
+	}
+
+	close(git.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if !svc.IsRepoReady("github.com_test_slow") || len(svc.PendingRepos()) != 0 {
+		t.Error("Expected all repositories to be ready after the sync")
+	}
+	want := [][]string{{"github.com_test_fast"}, {"github.com_test_slow", "github.com_test_fast"}}
+	if !slices.EqualFunc(indexer.aliases, want, slices.Equal[[]string]) {
+		t.Errorf("Expected an alias per served repository and none rebuilt after the sync, got %v", indexer.aliases)
+	}
+}
+
+func TestService_Sync_DoesNotServeRepos(t *testing.T) {
+	indexer := &aliasRecordingIndexer{mockIndexOps: &mockIndexOps{
+		fullIndexCount: 1,
+		existsMap:      map[string]bool{"github.com_test_repo": true},
+	}}
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir:     t.TempDir(),
+			URLs:        []string{"git@github.com:test/repo.git"},
+			SyncTimeout: 5 * time.Second,
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "abc123"},
+			Indexer:  indexer,
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	// The external sync process rewrites indexes on every pass, so it must
+	// not hold them open
+	if err := svc.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if svc.IsReady() || len(indexer.aliases) != 0 {
+		t.Errorf("Expected no alias to be opened by the sync process, got %v", indexer.aliases)
+	}
+}
+
 func TestService_Initialize_LeaderManifestSaveError(t *testing.T) {
 	manifest := newMockManifestOps()
 	manifest.saveErr = fmt.Errorf("disk full")
@@ -522,192 +846,1343 @@ func TestService_OpenIndexes_AliasError(t *testing.T) {
 
 	err := svc.Initialize(context.Background())
 	if err == nil {
-		t.Fatal("Expected error when alias creation fails")
-	}
-	if !strings.Contains(err.Error(), "failed to create index alias") {
-		t.Errorf("Expected 'failed to create index alias' in error, got: %v", err)
+		t.Fatal("Expected error when alias creation fails")
+	}
+	if !strings.Contains(err.Error(), "failed to create index alias") {
+		t.Errorf("Expected 'failed to create index alias' in error, got: %v", err)
+	}
+}
+
+// ============================
+// SyncAll tests with mocked deps
+// ============================
+
+func TestService_SyncAll_NoURLs(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{},
+			Indexer:  &mockIndexOps{},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	if err := svc.SyncAll(context.Background()); err != nil {
+		t.Errorf("SyncAll with no URLs should succeed: %v", err)
+	}
+}
+
+func TestService_SyncRepo_CloneError(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{cloneErr: fmt.Errorf("network error")},
+			Indexer:  &mockIndexOps{},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when clone fails")
+	}
+}
+
+func TestService_SyncRepo_URLNotAllowed(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir:     t.TempDir(),
+			URLs:        []string{"git@github.com:test/repo.git"},
+			AllowedURLs: []string{"git@github.com:myorg/*"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{cloneErr: errors.New("clone attempted")},
+			Indexer:  &mockIndexOps{},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if !errors.Is(err, config.ErrURLNotAllowed) || strings.Contains(err.Error(), "clone attempted") {
+		t.Fatalf("Expected ErrURLNotAllowed before cloning, got %v", err)
+	}
+}
+
+func TestService_SyncAll_JoinsRepoErrors(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/b.git", "git@github.com:test/a.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{cloneErr: fmt.Errorf("network error")},
+			Indexer:  &mockIndexOps{},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Expected joined errors, got: %v", err)
+	}
+
+	var repoIDs []string
+	for _, e := range joined.Unwrap() {
+		var repoErr *RepoSyncError
+		if !errors.As(e, &repoErr) {
+			t.Fatalf("Expected a RepoSyncError, got: %v", e)
+		}
+		repoIDs = append(repoIDs, repoErr.RepoID)
+	}
+	if want := []string{"github.com_test_a", "github.com_test_b"}; !slices.Equal(repoIDs, want) {
+		t.Errorf("Expected errors for %v, got %v", want, repoIDs)
+	}
+	if !strings.Contains(err.Error(), "network error") {
+		t.Errorf("Expected the cause in the error, got: %v", err)
+	}
+	if n := syncFailures(err); n != 2 {
+		t.Errorf("Expected 2 failures, got %d", n)
+	}
+}
+
+func TestSyncOrder(t *testing.T) {
+	urls := []string{
+		"git@github.com:org/a.git",
+		"git@github.com:org/b.git",
+		"git@github.com:org/c.git",
+		"git@github.com:org/d.git",
+	}
+	tests := []struct {
+		name     string
+		priority []string
+		want     []string
+	}{
+		{"no priority", nil, urls},
+		{"by name", []string{"github.com/org/c"}, []string{urls[2], urls[0], urls[1], urls[3]}},
+		{"in priority order", []string{"github.com/org/d", "git@github.com:org/b.git"}, []string{urls[3], urls[1], urls[0], urls[2]}},
+		{"unknown entries ignored", []string{"github.com/org/missing", "github.com/org/b"}, []string{urls[1], urls[0], urls[2], urls[3]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syncOrder(urls, tt.priority); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestService_SyncRepo_FetchError(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:      "git@github.com:test/repo.git",
+		ClonedAt: time.Now().Add(-1 * time.Hour),
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{fetchErr: fmt.Errorf("fetch failed")},
+			Indexer:  &mockIndexOps{},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when fetch fails")
+	}
+}
+
+func TestService_SyncRepo_HeadCommitError(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommitErr: fmt.Errorf("rev-parse failed")},
+			Indexer:  &mockIndexOps{},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when GetHeadCommit fails")
+	}
+}
+
+func TestService_SyncRepo_ResetError(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "commit2", resetErr: fmt.Errorf("reset failed")},
+			Indexer:  &mockIndexOps{},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when reset fails")
+	}
+}
+
+func TestService_SyncRepo_RebuildsOutdatedIndex(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:         "git@github.com:test/repo.git",
+		ClonedAt:    time.Now().Add(-1 * time.Hour),
+		LastCommit:  "commit1",
+		LastIndexed: "commit1",
+		// IndexVersion unset: built before the current mapping
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "commit1"},
+			Indexer:  &mockIndexOps{fullIndexCount: 5},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	if err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	state := manifest.repos[repoID]
+	if state.IndexVersion != IndexMappingVersion || state.FileCount != 5 {
+		t.Errorf("Expected full rebuild with current mapping, got %+v", state)
+	}
+}
+
+func TestService_SyncRepo_IndexBudgetExceeded(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git: &mockGitOps{headCommit: "commit1"},
+			Indexer: &mockIndexOps{
+				fullIndexCount: 3,
+				fullIndexErr:   fmt.Errorf("%w: stopped at 3 files (limit 3)", ErrIndexBudgetExceeded),
+				skipStats:      &SkipStats{Counts: map[string]int{SkipReasonBinary: 1}},
+			},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	if err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("Expected partial index to succeed, got: %v", err)
+	}
+
+	state := manifest.repos[repoID]
+	if state.FileCount != 3 || state.LastIndexed != "commit1" {
+		t.Errorf("Expected partial index to be recorded, got %+v", state)
+	}
+	if !strings.Contains(state.Warning, "limit 3") {
+		t.Errorf("Expected budget warning, got %q", state.Warning)
+	}
+	if state.Skipped == nil || state.Skipped.Counts[SkipReasonBinary] != 1 {
+		t.Errorf("Expected skip stats to be recorded, got %+v", state.Skipped)
+	}
+}
+
+func TestService_SyncRepo_OverBudgetRepoIsRebuiltFully(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    3,
+		Warning:      "index budget exceeded",
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "commit2", changedFiles: []string{"main.go"}},
+			Indexer:  &mockIndexOps{fullIndexCount: 2, incrIndexCount: 1},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	if err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	state := manifest.repos[repoID]
+	if state.FileCount != 2 || state.Warning != "" {
+		t.Errorf("Expected full rebuild clearing the warning, got %+v", state)
+	}
+}
+
+func TestService_SyncRepo_IndexedCommitUnavailable(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    3,
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "commit2", changedFilesErr: fmt.Errorf("%w: commit1", ErrCommitUnavailable)},
+			Indexer:  &mockIndexOps{fullIndexCount: 7, incrIndexCount: 1},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	if err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	if state := manifest.repos[repoID]; state.FileCount != 7 || state.LastIndexed != "commit2" {
+		t.Errorf("Expected a full reindex of commit2, got %+v", state)
+	}
+}
+
+func TestService_SyncRepo_BranchChanged(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    3,
+	}
+
+	dir := t.TempDir()
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir:  dir,
+			URLs:     []string{"git@github.com:test/repo.git"},
+			Branches: []string{"github.com/test/repo=trunk"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "commit2"},
+			Indexer:  &mockIndexOps{fullIndexCount: 7, incrIndexCount: 1},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+	stale := filepath.Join(svc.GetRepoDir(repoID), "stale.go")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("package stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	state := manifest.repos[repoID]
+	if state.Branch != "trunk" || state.FileCount != 7 || state.LastIndexed != "commit2" {
+		t.Errorf("Expected a full index of a new clone of trunk, got %+v", state)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected the previous clone to be removed, got: %v", err)
+	}
+}
+
+func TestService_SyncRepo_NoDefaultBranch(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommitErr: errors.New("ambiguous argument 'HEAD'")},
+			Indexer:  &mockIndexOps{},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+	// What a clone that checked nothing out leaves behind
+	repoDir := svc.GetRepoDir(repoID)
+	if err := os.MkdirAll(filepath.Join(repoDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := svc.SyncAll(context.Background())
+	if !errors.Is(err, ErrNoDefaultBranch) {
+		t.Fatalf("Expected ErrNoDefaultBranch, got: %v", err)
+	}
+	if state := manifest.repos[repoID]; !strings.Contains(state.Error, "--git-repos-branches") {
+		t.Errorf("Expected the error to be recorded with a hint, got %q", state.Error)
+	}
+	if _, err := os.Stat(repoDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the empty clone to be removed, got: %v", err)
+	}
+}
+
+func TestService_Reindex(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    3,
+		Error:        "previous failure",
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "commit1"},
+			Indexer:  &mockIndexOps{fullIndexCount: 5},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	if err := svc.Reindex(context.Background(), "github.com/test/repo"); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+
+	state := manifest.repos[repoID]
+	if state.FileCount != 5 || state.Error != "" {
+		t.Errorf("Expected a full rebuild at the same commit, got %+v", state)
+	}
+	if !slices.Equal(manifest.marked, []string{repoID}) || len(manifest.rewriting) != 0 {
+		t.Errorf("Expected the index to be marked as rewritten until the rebuild is done, got %v", manifest.marked)
+	}
+	if manifest.generation != 1 {
+		t.Errorf("Expected generation 1, got %d", manifest.generation)
+	}
+}
+
+func TestService_UpdatesCatalog(t *testing.T) {
+	catalog, _ := openTestCatalog(t)
+	repoID := "github.com_test_repo"
+	_ = catalog.Apply(repoID, "commit0", &CatalogChanges{Full: true, Files: []CatalogFile{newCatalogFile("stale.go", nil)}})
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git: &mockGitOps{headCommit: "commit1"},
+			Indexer: &mockIndexOps{fullIndexCount: 1, catalog: &CatalogChanges{
+				Full:  true,
+				Files: []CatalogFile{newCatalogFile("main.go", []byte("package main"))},
+			}},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+			Catalog:  catalog,
+		},
+	)
+
+	if err := svc.Reindex(context.Background(), "github.com/test/repo"); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+
+	if summary := svc.CatalogSummary(repoID); summary == nil || summary.Files != 1 {
+		t.Fatalf("Expected the reindexed file only, got %+v", summary)
+	}
+	if files := svc.CatalogFiles(repoID, ""); len(files) != 1 || files[0].Path != "main.go" {
+		t.Errorf("Expected main.go to be listed, got %+v", files)
+	}
+	file, err := catalog.Stat(repoID, "main.go")
+	if err != nil || file == nil || file.Commit != "commit1" {
+		t.Errorf("Expected main.go at commit1, got %+v, %v", file, err)
+	}
+}
+
+func TestService_Reindex_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   *config.GitReposSettings
+		deps       ServiceDeps
+		repository string
+		wantErr    string
+	}{
+		{
+			name:       "unknown repository",
+			settings:   &config.GitReposSettings{URLs: []string{"git@github.com:test/repo.git"}},
+			repository: "github.com/test/other",
+			wantErr:    "not configured",
+		},
+		{
+			name:       "read-only",
+			settings:   &config.GitReposSettings{URLs: []string{"git@github.com:test/repo.git"}, ReadOnly: true},
+			repository: "github.com/test/repo",
+			wantErr:    "read-only",
+		},
+		{
+			name:       "lock timeout",
+			settings:   &config.GitReposSettings{URLs: []string{"git@github.com:test/repo.git"}},
+			deps:       ServiceDeps{Lock: &mockSyncLock{lockErr: ErrLockTimeout}},
+			repository: "github.com/test/repo",
+			wantErr:    "failed to acquire lock",
+		},
+		{
+			name:     "missing checkout",
+			settings: &config.GitReposSettings{URLs: []string{"git@github.com:test/repo.git"}},
+			deps: ServiceDeps{
+				Git:     &mockGitOps{headCommitErr: fmt.Errorf("not a git repository")},
+				Indexer: &mockIndexOps{},
+			},
+			repository: "github.com/test/repo",
+			wantErr:    "failed to get HEAD commit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings.BaseDir = t.TempDir()
+			if tt.deps.Manifest == nil {
+				tt.deps.Manifest = newMockManifestOps()
+			}
+			if tt.deps.Lock == nil {
+				tt.deps.Lock = &mockSyncLock{}
+			}
+			svc := NewServiceWithDeps(tt.settings, tt.deps)
+
+			err := svc.Reindex(context.Background(), tt.repository)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_SyncRepo_FullIndexError(t *testing.T) {
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git:      &mockGitOps{headCommit: "abc123"},
+			Indexer:  &mockIndexOps{fullIndexErr: fmt.Errorf("index failed")},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when full index fails")
+	}
+}
+
+func TestService_SyncRepo_VerifyIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		verify    bool
+		verifyErr error
+		want      string
+	}{
+		{"disabled", false, nil, ""},
+		{"passed", true, nil, VerificationOK},
+		{"failed", true, fmt.Errorf("%w: 3 documents for 4 indexed files", ErrIndexVerification), "index verification failed: 3 documents for 4 indexed files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := newMockManifestOps()
+			svc := NewServiceWithDeps(
+				&config.GitReposSettings{
+					BaseDir:     t.TempDir(),
+					URLs:        []string{"git@github.com:test/repo.git"},
+					VerifyIndex: tt.verify,
+				},
+				ServiceDeps{
+					Git:      &mockGitOps{headCommit: "abc123"},
+					Indexer:  &mockIndexOps{fullIndexCount: 4, verifyErr: tt.verifyErr},
+					Manifest: manifest,
+					Lock:     &mockSyncLock{},
+				},
+			)
+
+			// A failed verification is recorded, not a sync failure
+			if err := svc.SyncAll(context.Background()); err != nil {
+				t.Fatalf("SyncAll failed: %v", err)
+			}
+			if got := manifest.repos["github.com_test_repo"].Verification; got != tt.want {
+				t.Errorf("Verification = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_SyncRepo_IncrementalFails_FallsBackToFull(t *testing.T) {
+	manifest := newMockManifestOps()
+	repoID := "github.com_test_repo"
+	manifest.repos[repoID] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+	}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			BaseDir: t.TempDir(),
+			URLs:    []string{"git@github.com:test/repo.git"},
+		},
+		ServiceDeps{
+			Git: &mockGitOps{
+				headCommit:   "commit2",
+				changedFiles: []string{"file1.go"},
+			},
+			Indexer: &mockIndexOps{
+				incrIndexErr:   fmt.Errorf("incremental failed"),
+				fullIndexCount: 5,
+			},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{},
+		},
+	)
+
+	err := svc.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll should succeed with fallback to full index: %v", err)
+	}
+
+	state := manifest.repos[repoID]
+	if state.LastCommit != "commit2" {
+		t.Errorf("Expected LastCommit = 'commit2', got %q", state.LastCommit)
+	}
+	if state.FileCount != 5 {
+		t.Errorf("Expected FileCount = 5, got %d", state.FileCount)
+	}
+}
+
+// ============================
+// Local (--cwd) mode tests with mocked deps
+// ============================
+
+func newLocalModeService(t *testing.T, git *mockGitOps, manifest *mockManifestOps) (*Service, string) {
+	t.Helper()
+	localDir := filepath.Join(t.TempDir(), "checkout")
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{BaseDir: t.TempDir(), LocalDir: localDir, SyncTimeout: time.Second},
+		ServiceDeps{
+			Git:      git,
+			Indexer:  &mockIndexOps{fullIndexCount: 3, existsMap: map[string]bool{"local_checkout": true}},
+			Manifest: manifest,
+			Lock:     &mockSyncLock{tryLockResult: true},
+		},
+	)
+	svc.headPollInterval = time.Hour // drive checks manually
+	return svc, localDir
+}
+
+func TestService_LocalMode_IndexesWorkingDirectory(t *testing.T) {
+	git := &mockGitOps{headCommit: "abc123", cloneErr: fmt.Errorf("local mode must not clone")}
+	manifest := newMockManifestOps()
+	svc, localDir := newLocalModeService(t, git, manifest)
+	defer func() { _ = svc.Close() }()
+
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	state := manifest.repos["local_checkout"]
+	if state.LastIndexed != "abc123" || state.FileCount != 3 {
+		t.Errorf("Unexpected repo state: %+v", state)
+	}
+	if got := svc.GetRepoDir("local_checkout"); got != localDir {
+		t.Errorf("GetRepoDir = %q, want the working directory %q", got, localDir)
+	}
+	if got := svc.GetRepoDir("github.com_org_repo"); got == localDir {
+		t.Error("Other repository IDs must not resolve to the working directory")
+	}
+}
+
+func TestService_LocalMode_ReindexesWhenHeadChanges(t *testing.T) {
+	git := &mockGitOps{headCommit: "abc123"}
+	manifest := newMockManifestOps()
+	svc, _ := newLocalModeService(t, git, manifest)
+	defer func() { _ = svc.Close() }()
+
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	saves := manifest.saves
+
+	// Unchanged HEAD is a no-op
+	svc.checkHead(context.Background())
+	if manifest.saves != saves {
+		t.Error("Expected no reindex when HEAD is unchanged")
+	}
+
+	git.headCommit = "def456"
+	git.changedFiles = []string{"main.go"}
+	svc.checkHead(context.Background())
+
+	if state := manifest.repos["local_checkout"]; state.LastIndexed != "def456" {
+		t.Errorf("Expected reindex at new HEAD, got %+v", state)
+	}
+	if manifest.saves == saves {
+		t.Error("Expected manifest to be saved after reindex")
+	}
+}
+
+func TestService_LocalMode_HeadError(t *testing.T) {
+	git := &mockGitOps{headCommitErr: fmt.Errorf("not a git repository")}
+	manifest := newMockManifestOps()
+	svc, _ := newLocalModeService(t, git, manifest)
+	defer func() { _ = svc.Close() }()
+
+	if err := svc.SyncAll(context.Background()); err == nil {
+		t.Fatal("Expected error when HEAD cannot be read")
+	}
+	if state := manifest.repos["local_checkout"]; state.Error == "" {
+		t.Error("Expected repo error to be recorded")
+	}
+}
+
+func TestService_LocalMode_ReindexesChangedFiles(t *testing.T) {
+	svc, _ := newLocalModeService(t, &mockGitOps{}, newMockManifestOps())
+
+	svc.reindexLocalFiles([]string{"main.go"})
+
+	if !svc.IsReady() {
+		t.Error("Expected indexes to be reopened after reindexing changed files")
+	}
+}
+
+func TestService_LocalMode_ReindexLockError(t *testing.T) {
+	svc, _ := newLocalModeService(t, &mockGitOps{}, newMockManifestOps())
+	svc.lock = &mockSyncLock{lockErr: fmt.Errorf("timeout")}
+
+	svc.reindexLocalFiles([]string{"main.go"})
+
+	if svc.IsReady() {
+		t.Error("Expected no reindex without the sync lock")
+	}
+}
+
+func TestService_LocalMode_WatchesFiles(t *testing.T) {
+	git := &mockGitOps{headCommit: "abc123"}
+	svc, localDir := newLocalModeService(t, git, newMockManifestOps())
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	svc.settings.Watch = true
+
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if svc.fileWatcher == nil {
+		t.Fatal("Expected file watcher to be started")
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if svc.fileWatcher != nil {
+		t.Error("Expected file watcher to be stopped on Close")
+	}
+}
+
+func TestService_RemoveStaleRepos_NeverTouchesLocalDir(t *testing.T) {
+	manifest := newMockManifestOps()
+	manifest.staleResult = []string{"local_checkout"}
+	svc, localDir := newLocalModeService(t, &mockGitOps{}, manifest)
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	svc.removeStaleRepos(nil)
+
+	if _, err := os.Stat(localDir); err != nil {
+		t.Errorf("Working directory must not be removed: %v", err)
+	}
+}
+
+func TestService_RemoveStaleRepos_Retention(t *testing.T) {
+	baseDir := t.TempDir()
+	svc, err := NewService(&config.GitReposSettings{BaseDir: baseDir, RemovedRetention: time.Hour})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	repoDir := filepath.Join(baseDir, "repos", "github.com_org_repo")
+	createTestFile(t, repoDir, "main.go", "package main")
+	svc.manifest.SetRepoState("github.com_org_repo", RepoState{LastCommit: "abc123"})
+
+	// Kept, hidden, within the retention period
+	svc.removeStaleRepos(nil)
+	if _, err := os.Stat(repoDir); err != nil {
+		t.Errorf("Expected the clone to be kept: %v", err)
+	}
+	if !svc.manifest.HasRepo("github.com_org_repo") {
+		t.Error("Expected the repository to stay in the manifest")
+	}
+
+	// Deleted once the retention period has passed
+	svc.settings.RemovedRetention = 0
+	svc.removeStaleRepos(nil)
+	if _, err := os.Stat(repoDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the clone to be deleted, got %v", err)
+	}
+	if svc.manifest.HasRepo("github.com_org_repo") {
+		t.Error("Expected the repository to be dropped from the manifest")
+	}
+}
+
+// ============================
+// Reload tests with mocked deps
+// ============================
+
+func TestService_Reload_NilSettings(t *testing.T) {
+	svc := NewServiceWithDeps(&config.GitReposSettings{BaseDir: t.TempDir()}, ServiceDeps{
+		Git:      &mockGitOps{},
+		Indexer:  &mockIndexOps{},
+		Manifest: newMockManifestOps(),
+		Lock:     &mockSyncLock{},
+	})
+
+	if err := svc.Reload(context.Background(), nil); err == nil {
+		t.Error("Expected error for nil settings")
+	}
+}
+
+func TestService_Reload_LockError(t *testing.T) {
+	svc := NewServiceWithDeps(&config.GitReposSettings{BaseDir: t.TempDir()}, ServiceDeps{
+		Git:      &mockGitOps{},
+		Indexer:  &mockIndexOps{},
+		Manifest: newMockManifestOps(),
+		Lock:     &mockSyncLock{lockErr: ErrLockTimeout},
+	})
+
+	err := svc.Reload(context.Background(), &config.GitReposSettings{BaseDir: t.TempDir()})
+	if err == nil {
+		t.Fatal("Expected error when lock cannot be acquired")
 	}
 }
 
-// ============================
-// SyncAll tests with mocked deps
-// ============================
+func TestService_Reload_SyncsAddedRepos(t *testing.T) {
+	dir := t.TempDir()
+	manifest := newMockManifestOps()
+	indexer := &mockIndexOps{fullIndexCount: 3}
 
-func TestService_SyncAll_NoURLs(t *testing.T) {
 	svc := NewServiceWithDeps(
 		&config.GitReposSettings{
-			BaseDir: t.TempDir(),
-			URLs:    []string{},
+			BaseDir: dir,
+			URLs:    []string{"git@github.com:test/existing.git"},
 		},
 		ServiceDeps{
-			Git:      &mockGitOps{},
-			Indexer:  &mockIndexOps{},
-			Manifest: newMockManifestOps(),
+			Git:      &mockGitOps{headCommit: "abc123"},
+			Indexer:  indexer,
+			Manifest: manifest,
 			Lock:     &mockSyncLock{},
 		},
 	)
 
-	if err := svc.SyncAll(context.Background()); err != nil {
-		t.Errorf("SyncAll with no URLs should succeed: %v", err)
+	next := &config.GitReposSettings{
+		BaseDir:     dir,
+		URLs:        []string{"git@github.com:test/existing.git", "git@github.com:test/added.git"},
+		MaxFileSize: 1024,
+		MaxResults:  7,
+	}
+	if err := svc.Reload(context.Background(), next); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if _, ok := manifest.repos["github.com_test_added"]; !ok {
+		t.Error("Expected added repo to be synced")
+	}
+	if _, ok := manifest.repos["github.com_test_existing"]; ok {
+		t.Error("Existing repo should not be resynced on reload")
+	}
+	if svc.MaxResults() != 7 {
+		t.Errorf("MaxResults() = %d, want 7", svc.MaxResults())
+	}
+	if indexer.filter == nil || indexer.filter.MaxFileSize() != 1024 {
+		t.Error("Expected indexer filter to be updated from reloaded settings")
 	}
 }
 
-func TestService_SyncRepo_CloneError(t *testing.T) {
+func TestService_Reload_RemovesStaleRepos(t *testing.T) {
+	dir := t.TempDir()
+	staleDir := filepath.Join(dir, "repos", "github.com_test_removed")
+	if err := os.MkdirAll(staleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := newMockManifestOps()
+	manifest.staleResult = []string{"github.com_test_removed"}
+
 	svc := NewServiceWithDeps(
 		&config.GitReposSettings{
-			BaseDir: t.TempDir(),
-			URLs:    []string{"git@github.com:test/repo.git"},
+			BaseDir: dir,
+			URLs:    []string{"git@github.com:test/removed.git"},
 		},
 		ServiceDeps{
-			Git:      &mockGitOps{cloneErr: fmt.Errorf("network error")},
+			Git:      &mockGitOps{},
 			Indexer:  &mockIndexOps{},
-			Manifest: newMockManifestOps(),
+			Manifest: manifest,
 			Lock:     &mockSyncLock{},
 		},
 	)
 
-	err := svc.SyncAll(context.Background())
-	if err == nil {
-		t.Fatal("Expected error when clone fails")
+	if err := svc.Reload(context.Background(), &config.GitReposSettings{BaseDir: dir}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if _, err := os.Stat(staleDir); !os.IsNotExist(err) {
+		t.Error("Expected stale repo directory to be removed")
+	}
+	if svc.IsReady() {
+		t.Error("Service should not be ready after all repos were removed")
 	}
 }
 
-func TestService_SyncRepo_FetchError(t *testing.T) {
+func TestService_UpdateRepositories(t *testing.T) {
+	dir := t.TempDir()
 	manifest := newMockManifestOps()
-	repoID := "github.com_test_repo"
-	manifest.repos[repoID] = RepoState{
-		URL:      "git@github.com:test/repo.git",
-		ClonedAt: time.Now().Add(-1 * time.Hour),
+	configured := &config.GitReposSettings{
+		BaseDir:          dir,
+		URLs:             []string{"git@github.com:test/existing.git", "git@github.com:test/dropped.git"},
+		RemovedRetention: time.Hour,
+	}
+	svc := NewServiceWithDeps(configured, ServiceDeps{
+		Git:      &mockGitOps{headCommit: "abc123"},
+		Indexer:  &mockIndexOps{fullIndexCount: 3},
+		Manifest: manifest,
+		Lock:     &mockSyncLock{},
+	})
+
+	update, err := svc.UpdateRepositories(context.Background(),
+		[]string{"git@github.com:test/added.git", "https://github.com/test/existing"},
+		[]string{"github.com/test/dropped"})
+	if err != nil {
+		t.Fatalf("UpdateRepositories failed: %v", err)
+	}
+	if !reflect.DeepEqual(update.Added, []string{"github.com/test/added"}) || !reflect.DeepEqual(update.Removed, []string{"github.com/test/dropped"}) {
+		t.Errorf("Unexpected update: %+v", update)
+	}
+	if _, ok := manifest.repos["github.com_test_added"]; !ok {
+		t.Error("Expected the added repo to be synced")
+	}
+	expected := []string{"git@github.com:test/existing.git", "git@github.com:test/added.git"}
+	if urls := svc.GetSettings().URLs; !reflect.DeepEqual(urls, expected) {
+		t.Errorf("URLs = %v, want %v", urls, expected)
+	}
+
+	// The changes are kept in the overlay and survive a reload of the
+	// configured settings
+	overlay, err := LoadRepoOverlay(filepath.Join(dir, RepoOverlayFilename))
+	if err != nil {
+		t.Fatalf("LoadRepoOverlay failed: %v", err)
+	}
+	if !reflect.DeepEqual(overlay, &RepoOverlay{Added: []string{"git@github.com:test/added.git"}, Removed: []string{"github.com_test_dropped"}}) {
+		t.Errorf("Unexpected overlay: %+v", overlay)
+	}
+	if err := svc.Reload(context.Background(), configured); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if urls := svc.GetSettings().URLs; !reflect.DeepEqual(urls, expected) {
+		t.Errorf("URLs after reload = %v, want %v", urls, expected)
+	}
+
+	// Adding a removed repository back restores it
+	if _, err := svc.UpdateRepositories(context.Background(), []string{"git@github.com:test/dropped.git"}, []string{"git@github.com:test/added.git"}); err != nil {
+		t.Fatalf("UpdateRepositories failed: %v", err)
+	}
+	if urls := svc.GetSettings().URLs; !reflect.DeepEqual(urls, configured.URLs) {
+		t.Errorf("URLs = %v, want %v", urls, configured.URLs)
+	}
+	if overlay, _ := LoadRepoOverlay(filepath.Join(dir, RepoOverlayFilename)); len(overlay.Added) != 0 || len(overlay.Removed) != 0 {
+		t.Errorf("Expected an empty overlay, got %+v", overlay)
+	}
+}
+
+func TestService_UpdateRepositories_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings config.GitReposSettings
+		add      []string
+		remove   []string
+		expected string
+	}{
+		{name: "invalid URL", add: []string{"not a url"}, expected: "invalid repository URL"},
+		{name: "denied URL", settings: config.GitReposSettings{DeniedURLs: []string{"github.com/test/**"}}, add: []string{"git@github.com:test/other.git"}, expected: "git-repos-denied-urls"},
+		{name: "unknown repository", remove: []string{"github.com/test/unknown"}, expected: "repository not configured: github.com/test/unknown"},
+		{name: "added and removed", add: []string{"git@github.com:test/repo.git"}, remove: []string{"github.com/test/repo"}, expected: "both added and removed"},
+		{name: "read-only", settings: config.GitReposSettings{ReadOnly: true}, add: []string{"git@github.com:test/other.git"}, expected: "read-only mode"},
+		{name: "unconfigured host", add: []string{"git@gitlab.example.com:test/other.git"}, expected: "not on a configured host"},
+		{name: "local directory", settings: config.GitReposSettings{LocalDir: "/src/app"}, add: []string{"git@github.com:test/other.git"}, expected: "local working directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := tt.settings
+			settings.BaseDir = t.TempDir()
+			settings.URLs = []string{"git@github.com:test/repo.git"}
+			svc := NewServiceWithDeps(&settings, ServiceDeps{
+				Git:      &mockGitOps{},
+				Indexer:  &mockIndexOps{},
+				Manifest: newMockManifestOps(),
+				Lock:     &mockSyncLock{},
+			})
+
+			_, err := svc.UpdateRepositories(context.Background(), tt.add, tt.remove)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+			}
+			if _, err := os.Stat(filepath.Join(settings.BaseDir, RepoOverlayFilename)); !os.IsNotExist(err) {
+				t.Error("Expected no overlay to be written")
+			}
+		})
 	}
+}
+
+// ============================
+// Read-only mode and external sync tests
+// ============================
 
+func TestService_Sync_MarksRewrittenIndexes(t *testing.T) {
+	manifest := newMockManifestOps()
 	svc := NewServiceWithDeps(
 		&config.GitReposSettings{
 			BaseDir: t.TempDir(),
 			URLs:    []string{"git@github.com:test/repo.git"},
 		},
 		ServiceDeps{
-			Git:      &mockGitOps{fetchErr: fmt.Errorf("fetch failed")},
+			Git:      &mockGitOps{headCommit: "abc123"},
 			Indexer:  &mockIndexOps{},
 			Manifest: manifest,
 			Lock:     &mockSyncLock{},
 		},
 	)
 
-	err := svc.SyncAll(context.Background())
-	if err == nil {
-		t.Fatal("Expected error when fetch fails")
+	if err := svc.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if !slices.Equal(manifest.marked, []string{"github.com_test_repo"}) || len(manifest.rewriting) != 0 {
+		t.Errorf("Expected the index to be marked while it was rewritten, got %v", manifest.marked)
+	}
+	if manifest.saves != 2 {
+		t.Errorf("Expected manifest to be saved twice, got %d", manifest.saves)
+	}
+	if manifest.generation != 1 {
+		t.Errorf("Expected generation 1, got %d", manifest.generation)
 	}
 }
 
-func TestService_SyncRepo_HeadCommitError(t *testing.T) {
+func TestService_Sync_UnchangedKeepsGeneration(t *testing.T) {
+	manifest := newMockManifestOps()
+	manifest.generation = 3
+	manifest.repos["github.com_test_repo"] = RepoState{
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().UTC(),
+		LastCommit:   "abc123",
+		LastIndexed:  "abc123",
+		IndexVersion: IndexMappingVersion,
+	}
 	svc := NewServiceWithDeps(
 		&config.GitReposSettings{
 			BaseDir: t.TempDir(),
 			URLs:    []string{"git@github.com:test/repo.git"},
 		},
 		ServiceDeps{
-			Git:      &mockGitOps{headCommitErr: fmt.Errorf("rev-parse failed")},
-			Indexer:  &mockIndexOps{},
-			Manifest: newMockManifestOps(),
+			Git:      &mockGitOps{headCommit: "abc123"},
+			Indexer:  &mockIndexOps{existsMap: map[string]bool{"github.com_test_repo": true}},
+			Manifest: manifest,
 			Lock:     &mockSyncLock{},
 		},
 	)
 
-	err := svc.SyncAll(context.Background())
-	if err == nil {
-		t.Fatal("Expected error when GetHeadCommit fails")
+	if err := svc.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
 	}
-}
 
-func TestService_SyncRepo_ResetError(t *testing.T) {
-	manifest := newMockManifestOps()
-	repoID := "github.com_test_repo"
-	manifest.repos[repoID] = RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "commit1",
-		LastIndexed: "commit1",
+	if len(manifest.marked) != 0 {
+		t.Errorf("Expected no index to be marked, got %v", manifest.marked)
 	}
+	if manifest.generation != 3 {
+		t.Errorf("Expected generation 3 to be kept, got %d", manifest.generation)
+	}
+}
 
+func TestService_Sync_LockError(t *testing.T) {
 	svc := NewServiceWithDeps(
-		&config.GitReposSettings{
-			BaseDir: t.TempDir(),
-			URLs:    []string{"git@github.com:test/repo.git"},
-		},
+		&config.GitReposSettings{BaseDir: t.TempDir()},
 		ServiceDeps{
-			Git:      &mockGitOps{headCommit: "commit2", resetErr: fmt.Errorf("reset failed")},
+			Git:      &mockGitOps{},
 			Indexer:  &mockIndexOps{},
-			Manifest: manifest,
-			Lock:     &mockSyncLock{},
+			Manifest: newMockManifestOps(),
+			Lock:     &mockSyncLock{lockErr: ErrLockTimeout},
 		},
 	)
 
-	err := svc.SyncAll(context.Background())
-	if err == nil {
-		t.Fatal("Expected error when reset fails")
+	if err := svc.Sync(context.Background()); err == nil {
+		t.Error("Expected error when lock cannot be acquired")
 	}
 }
 
-func TestService_SyncRepo_FullIndexError(t *testing.T) {
+func TestService_Initialize_ReadOnly_FollowsGenerations(t *testing.T) {
+	dir := t.TempDir()
+	repoID, otherID := "github.com_test_repo", "github.com_test_other"
+	manifestPath := filepath.Join(dir, ManifestFilename)
+	git := &mockGitOps{cloneErr: fmt.Errorf("read-only mode must not clone")}
+
 	svc := NewServiceWithDeps(
 		&config.GitReposSettings{
-			BaseDir: t.TempDir(),
-			URLs:    []string{"git@github.com:test/repo.git"},
+			BaseDir:  dir,
+			URLs:     []string{"git@github.com:test/repo.git", "git@github.com:test/other.git"},
+			ReadOnly: true,
 		},
 		ServiceDeps{
-			Git:      &mockGitOps{headCommit: "abc123"},
-			Indexer:  &mockIndexOps{fullIndexErr: fmt.Errorf("index failed")},
+			Git:      git,
+			Indexer:  &mockIndexOps{existsMap: map[string]bool{repoID: true, otherID: true}},
 			Manifest: newMockManifestOps(),
-			Lock:     &mockSyncLock{},
+			Lock:     &mockSyncLock{tryLockErr: fmt.Errorf("read-only mode must not lock")},
 		},
 	)
+	svc.pollInterval = time.Hour // drive checks manually
+	defer func() { _ = svc.Close() }()
 
-	err := svc.SyncAll(context.Background())
-	if err == nil {
-		t.Fatal("Expected error when full index fails")
+	if err := svc.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if svc.IsReady() {
+		t.Fatal("Service should not be ready before a generation is published")
+	}
+
+	commit, removed := "commit1", false
+	publish := func(generation uint64, syncing bool, rewriting ...string) {
+		m := NewManifest()
+		m.Generation = generation
+		m.Syncing = syncing
+		m.Rewriting = rewriting
+		if !removed {
+			m.Repos[repoID] = RepoState{LastIndexed: commit}
+		}
+		m.Repos[otherID] = RepoState{LastIndexed: "commit1"}
+		if err := m.Save(manifestPath); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		svc.checkGeneration()
+	}
+	served := func() []string {
+		svc.mu.RLock()
+		defer svc.mu.RUnlock()
+		return slices.Sorted(maps.Keys(svc.served))
+	}
+
+	publish(1, false)
+	if !svc.IsReady() || len(served()) != 2 {
+		t.Fatalf("Service should serve both indexes after generation 1 is published, got %v", served())
+	}
+
+	publish(1, true, repoID)
+	if !svc.IsReady() || !slices.Equal(served(), []string{otherID}) {
+		t.Fatalf("Service should keep serving the indexes an external sync leaves alone, got %v", served())
+	}
+
+	commit = "commit2"
+	publish(2, false)
+	if !svc.IsReady() || len(served()) != 2 {
+		t.Fatalf("Service should reopen the rewritten index for generation 2, got %v", served())
+	}
+	if state := svc.manifest.GetRepoState(repoID); state.LastIndexed != "commit2" {
+		t.Errorf("Expected the published state to be mirrored, got %+v", state)
+	}
+
+	// An external sync of an earlier version may rewrite any index
+	publish(2, true)
+	if svc.IsReady() {
+		t.Fatal("Service should release all indexes while an earlier external sync runs")
+	}
+
+	publish(3, false)
+	if !svc.IsReady() || len(served()) != 2 {
+		t.Fatalf("Service should reopen indexes for generation 3, got %v", served())
+	}
+
+	// A repository the sync process removed is no longer served
+	removed = true
+	publish(4, false)
+	if !svc.IsReady() || !slices.Equal(served(), []string{otherID}) {
+		t.Fatalf("Service should stop serving a removed repository, got %v", served())
+	}
+	if svc.manifest.HasRepo(repoID) {
+		t.Error("Expected the removed repository to be dropped from the manifest")
 	}
 }
 
-func TestService_SyncRepo_IncrementalFails_FallsBackToFull(t *testing.T) {
-	manifest := newMockManifestOps()
-	repoID := "github.com_test_repo"
-	manifest.repos[repoID] = RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "commit1",
-		LastIndexed: "commit1",
+func TestService_Snapshot_PublishAndDownload(t *testing.T) {
+	snapshotDir := t.TempDir()
+	urls := []string{"git@github.com:test/repo.git"}
+	ctx := context.Background()
+
+	// Leader: sync and publish to the snapshot store
+	leaderDir := t.TempDir()
+	leader, err := NewService(&config.GitReposSettings{
+		URLs:        urls,
+		BaseDir:     leaderDir,
+		SyncTimeout: 5 * time.Second,
+		MaxFileSize: 256 * 1024,
+		CloneDepth:  1,
+		SnapshotURL: "file://" + snapshotDir,
+	})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
 	}
+	defer func() { _ = leader.Close() }()
 
-	svc := NewServiceWithDeps(
-		&config.GitReposSettings{
-			BaseDir: t.TempDir(),
-			URLs:    []string{"git@github.com:test/repo.git"},
-		},
-		ServiceDeps{
-			Git: &mockGitOps{
-				headCommit:   "commit2",
-				changedFiles: []string{"file1.go"},
-			},
-			Indexer: &mockIndexOps{
-				incrIndexErr:   fmt.Errorf("incremental failed"),
-				fullIndexCount: 5,
-			},
-			Manifest: manifest,
-			Lock:     &mockSyncLock{},
-		},
-	)
+	mock := NewMockExecutor()
+	mock.AddResponse("git clone", []byte{}, nil)
+	mock.AddResponse("git rev-parse", []byte("abc123\n"), nil)
+	leader.git = NewGitClientWithExecutor(mock)
 
-	err := svc.SyncAll(context.Background())
+	repoDir := filepath.Join(leaderDir, "repos", "github.com_test_repo")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		t.Fatalf("Failed to create repo dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\nfunc main() {}"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := leader.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// Replica: read-only with its own empty base directory
+	replicaDir := t.TempDir()
+	replica, err := NewService(&config.GitReposSettings{
+		URLs:        urls,
+		BaseDir:     replicaDir,
+		SyncTimeout: 5 * time.Second,
+		MaxFileSize: 256 * 1024,
+		ReadOnly:    true,
+		SnapshotURL: "file://" + snapshotDir,
+	})
 	if err != nil {
-		t.Fatalf("SyncAll should succeed with fallback to full index: %v", err)
+		t.Fatalf("NewService failed: %v", err)
 	}
+	replica.pollInterval = time.Hour // drive checks manually
+	defer func() { _ = replica.Close() }()
 
-	state := manifest.repos[repoID]
-	if state.LastCommit != "commit2" {
-		t.Errorf("Expected LastCommit = 'commit2', got %q", state.LastCommit)
+	if err := replica.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
 	}
-	if state.FileCount != 5 {
-		t.Errorf("Expected FileCount = 5, got %d", state.FileCount)
+	if !replica.IsReady() {
+		t.Fatal("Replica should be ready after downloading the snapshot")
+	}
+
+	content, err := os.ReadFile(filepath.Join(replica.GetRepoDir("github.com_test_repo"), "main.go"))
+	if err != nil || !strings.Contains(string(content), "package main") {
+		t.Errorf("Expected working tree to be downloaded: %q, %v", content, err)
+	}
+
+	// Publish a new generation and let the replica pick it up
+	mock2 := NewMockExecutor()
+	mock2.AddResponse("git fetch", []byte{}, nil)
+	mock2.AddResponse("git rev-parse", []byte("def456\n"), nil)
+	mock2.AddResponse("git reset", []byte{}, nil)
+	mock2.AddResponse("git diff", []byte("main.go\n"), nil)
+	leader.git = NewGitClientWithExecutor(mock2)
+
+	if err := leader.Sync(ctx); err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+	replica.checkGeneration()
+	if !replica.IsReady() {
+		t.Fatal("Replica should be ready after the second snapshot")
+	}
+	manifest, err := LoadManifest(filepath.Join(replicaDir, ManifestFilename))
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if manifest.Generation != 2 {
+		t.Errorf("Replica generation = %d, want 2", manifest.Generation)
+	}
+
+	// A sync that changes nothing publishes nothing
+	published, err := os.Stat(filepath.Join(snapshotDir, SnapshotManifestKey))
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	mock3 := NewMockExecutor()
+	mock3.AddResponse("git fetch", []byte{}, nil)
+	mock3.AddResponse("git rev-parse", []byte("def456\n"), nil)
+	mock3.AddResponse("git reset", []byte{}, nil)
+	leader.git = NewGitClientWithExecutor(mock3)
+
+	if err := leader.Sync(ctx); err != nil {
+		t.Fatalf("Third sync failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(snapshotDir, SnapshotManifestKey)); err != nil || !info.ModTime().Equal(published.ModTime()) {
+		t.Errorf("Expected the published snapshot to be left alone, got %v, %v", info, err)
 	}
 }
 
@@ -889,10 +2364,11 @@ func TestService_IndexesReadyAfterSync(t *testing.T) {
 		t.Error("Service should be ready after successful initialization")
 	}
 
-	alias, err := svc.GetIndexAlias()
+	alias, release, err := svc.AcquireIndexAlias()
 	if err != nil {
-		t.Errorf("GetIndexAlias failed: %v", err)
+		t.Fatalf("AcquireIndexAlias failed: %v", err)
 	}
+	defer release()
 	if alias == nil {
 		t.Error("Expected non-nil alias")
 	}
@@ -986,6 +2462,7 @@ func TestService_IncrementalIndex(t *testing.T) {
 		BaseDir:     dir,
 		SyncTimeout: 5 * time.Second,
 		MaxFileSize: 256 * 1024,
+		CloneDepth:  1,
 	}
 
 	svc, err := NewService(settings)
@@ -1024,10 +2501,11 @@ func TestService_IncrementalIndex(t *testing.T) {
 
 	manifest := svc.manifest.(*Manifest)
 	manifest.SetRepoState(repoID, RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "commit1",
-		LastIndexed: "commit1",
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
 	})
 
 	_ = svc.SyncAll(ctx)
@@ -1045,6 +2523,7 @@ func TestService_IncrementalIndex_ThresholdExceeded(t *testing.T) {
 		BaseDir:     dir,
 		SyncTimeout: 5 * time.Second,
 		MaxFileSize: 256 * 1024,
+		CloneDepth:  1,
 	}
 
 	svc, err := NewService(settings)
@@ -1088,11 +2567,12 @@ func TestService_IncrementalIndex_ThresholdExceeded(t *testing.T) {
 
 	manifest := svc.manifest.(*Manifest)
 	manifest.SetRepoState(repoID, RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "commit1",
-		LastIndexed: "commit1",
-		FileCount:   1,
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    1,
 	})
 
 	_ = svc.SyncAll(ctx)
@@ -1113,6 +2593,7 @@ func TestService_IncrementalIndex_ExactBoundary(t *testing.T) {
 		BaseDir:     dir,
 		SyncTimeout: 5 * time.Second,
 		MaxFileSize: 256 * 1024,
+		CloneDepth:  1,
 	}
 
 	svc, err := NewService(settings)
@@ -1156,11 +2637,12 @@ func TestService_IncrementalIndex_ExactBoundary(t *testing.T) {
 
 	manifest := svc.manifest.(*Manifest)
 	manifest.SetRepoState(repoID, RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "commit1",
-		LastIndexed: "commit1",
-		FileCount:   1,
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    1,
 	})
 
 	_ = svc.SyncAll(ctx)
@@ -1181,6 +2663,7 @@ func TestService_IncrementalIndex_DiffError(t *testing.T) {
 		BaseDir:     dir,
 		SyncTimeout: 5 * time.Second,
 		MaxFileSize: 256 * 1024,
+		CloneDepth:  1,
 	}
 
 	svc, err := NewService(settings)
@@ -1219,11 +2702,12 @@ func TestService_IncrementalIndex_DiffError(t *testing.T) {
 
 	manifest := svc.manifest.(*Manifest)
 	manifest.SetRepoState(repoID, RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "commit1",
-		LastIndexed: "commit1",
-		FileCount:   1,
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "commit1",
+		LastIndexed:  "commit1",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    1,
 	})
 
 	_ = svc.SyncAll(ctx)
@@ -1336,10 +2820,11 @@ func TestService_FetchExistingRepo(t *testing.T) {
 
 	manifest := svc.manifest.(*Manifest)
 	manifest.SetRepoState(repoID, RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "abc123",
-		LastIndexed: "abc123",
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "abc123",
+		LastIndexed:  "abc123",
+		IndexVersion: IndexMappingVersion,
 	})
 
 	mock := NewMockExecutor()
@@ -1399,11 +2884,12 @@ func TestService_SkipReindexWhenUnchanged(t *testing.T) {
 
 	manifest := svc.manifest.(*Manifest)
 	manifest.SetRepoState(repoID, RepoState{
-		URL:         "git@github.com:test/repo.git",
-		ClonedAt:    time.Now().Add(-1 * time.Hour),
-		LastCommit:  "same_commit",
-		LastIndexed: "same_commit",
-		FileCount:   1,
+		URL:          "git@github.com:test/repo.git",
+		ClonedAt:     time.Now().Add(-1 * time.Hour),
+		LastCommit:   "same_commit",
+		LastIndexed:  "same_commit",
+		IndexVersion: IndexMappingVersion,
+		FileCount:    1,
 	})
 
 	mock := NewMockExecutor()
@@ -1507,3 +2993,21 @@ func TestService_Initialize_NoURLs(t *testing.T) {
 		t.Error("Service should not be ready with no URLs")
 	}
 }
+
+func TestService_IsIndexed(t *testing.T) {
+	svc := setupSearchService(t, t.TempDir(), map[string]string{
+		"main.go": "package main",
+		"go.sum":  "example.com/mod v1.0.0 h1:abc=",
+	})
+	defer func() { _ = svc.Close() }()
+
+	if !svc.IsIndexed("github.com_test_repo", "main.go") {
+		t.Error("Expected main.go to be indexed")
+	}
+	if svc.IsIndexed("github.com_test_repo", "go.sum") {
+		t.Error("Expected excluded go.sum not to be indexed")
+	}
+	if svc.IsIndexed("github.com_other_repo", "main.go") {
+		t.Error("Expected a file of another repository not to be indexed")
+	}
+}
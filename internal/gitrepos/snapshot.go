@@ -0,0 +1,405 @@
+package gitrepos
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SnapshotManifestKey is the object key of the published manifest, relative to the store prefix.
+// It is uploaded last, so readers never observe a generation whose archives are incomplete.
+const SnapshotManifestKey = "manifest.json"
+
+// snapshotGenerationsKey is the object key of the list of published
+// generations whose archives are kept, relative to the store prefix.
+const snapshotGenerationsKey = "generations.json"
+
+// snapshotRetainedGenerations is the number of published generations whose
+// archives are kept: the current one, and the previous one that readers may
+// still be downloading.
+const snapshotRetainedGenerations = 2
+
+// ErrSnapshotNotFound indicates the requested snapshot object does not exist.
+var ErrSnapshotNotFound = errors.New("snapshot object not found")
+
+// SnapshotStore abstracts the object storage used to distribute index snapshots.
+type SnapshotStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewSnapshotStore creates a snapshot store for the given URL.
+// Supported schemes:
+//   - file:///mnt/shared/relic - a local or network-mounted directory
+//   - s3://bucket/prefix - Amazon S3 or any S3-compatible service
+//   - gs://bucket/prefix - Google Cloud Storage via its S3-interoperable API
+//
+// S3 and GCS credentials are read from the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables (GCS
+// requires HMAC keys). AWS_REGION and AWS_ENDPOINT_URL_S3 override the region
+// and endpoint for S3-compatible services.
+func NewSnapshotStore(rawURL string) (SnapshotStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot URL: %w", err)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "file":
+		return &fileSnapshotStore{root: u.Path}, nil
+	case "s3":
+		region := envOr("AWS_REGION", envOr("AWS_DEFAULT_REGION", "us-east-1"))
+		endpoint := envOr("AWS_ENDPOINT_URL_S3", envOr("AWS_ENDPOINT_URL", "https://s3."+region+".amazonaws.com"))
+		return newS3SnapshotStore(endpoint, region, u.Host, prefix), nil
+	case "gs":
+		return newS3SnapshotStore("https://storage.googleapis.com", "auto", u.Host, prefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported snapshot URL scheme: %q", u.Scheme)
+	}
+}
+
+// envOr returns the value of the environment variable, or fallback if unset.
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Snapshot archive kinds
+const (
+	snapshotKindIndex = "index"
+	snapshotKindRepo  = "repo"
+)
+
+// snapshotArchiveKey returns the object key of a repository archive for a generation.
+func snapshotArchiveKey(generation uint64, repoID, kind string) string {
+	return path.Join(strconv.FormatUint(generation, 10), repoID+"."+kind+".tar.gz")
+}
+
+// publishSnapshot uploads the index and working tree of each repository under
+// the manifest's generation, followed by the manifest itself. indexDirs holds
+// the index directory of each repository, by repository ID; they must not be
+// written while they are archived.
+func publishSnapshot(ctx context.Context, store SnapshotStore, indexDirs map[string]string, reposDir string, manifest []byte) error {
+	var parsed Manifest
+	if err := json.Unmarshal(manifest, &parsed); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	generation := parsed.Generation
+
+	for _, repoID := range slices.Sorted(maps.Keys(indexDirs)) {
+		if err := uploadArchive(ctx, store, indexDirs[repoID], snapshotArchiveKey(generation, repoID, snapshotKindIndex)); err != nil {
+			return fmt.Errorf("failed to upload index for %s: %w", repoID, err)
+		}
+		repoDir := filepath.Join(reposDir, repoID)
+		if err := uploadArchive(ctx, store, repoDir, snapshotArchiveKey(generation, repoID, snapshotKindRepo), ".git"); err != nil {
+			return fmt.Errorf("failed to upload working tree for %s: %w", repoID, err)
+		}
+	}
+
+	if err := store.Put(ctx, SnapshotManifestKey, strings.NewReader(string(manifest)), int64(len(manifest))); err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
+
+// snapshotGeneration is a published generation and the repositories it has
+// archives of.
+type snapshotGeneration struct {
+	Generation uint64   `json:"generation"`
+	RepoIDs    []string `json:"repo_ids"`
+}
+
+// pruneSnapshots records generation as published with the archives of
+// repoIDs, and deletes the archives of the generations published before the
+// last retain ones. Generations that cannot be deleted are kept in the list,
+// so that the next publish tries again.
+func pruneSnapshots(ctx context.Context, store SnapshotStore, generation uint64, repoIDs []string, retain int) error {
+	generations, err := fetchSnapshotGenerations(ctx, store)
+	if err != nil {
+		return err
+	}
+	generations = slices.DeleteFunc(generations, func(g snapshotGeneration) bool { return g.Generation == generation })
+	generations = append(generations, snapshotGeneration{Generation: generation, RepoIDs: repoIDs})
+
+	var kept []snapshotGeneration
+	var errs []error
+	for i, g := range generations {
+		if i >= len(generations)-retain {
+			kept = append(kept, g)
+			continue
+		}
+		if err := deleteSnapshotGeneration(ctx, store, g); err != nil {
+			errs = append(errs, err)
+			kept = append(kept, g)
+		}
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	if err := store.Put(ctx, snapshotGenerationsKey, bytes.NewReader(data), int64(len(data))); err != nil {
+		errs = append(errs, fmt.Errorf("failed to upload generations: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// fetchSnapshotGenerations downloads the list of published generations,
+// oldest first. Stores published to before the list was kept have none.
+func fetchSnapshotGenerations(ctx context.Context, store SnapshotStore) ([]snapshotGeneration, error) {
+	body, err := store.Get(ctx, snapshotGenerationsKey)
+	if errors.Is(err, ErrSnapshotNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	var generations []snapshotGeneration
+	if err := json.NewDecoder(body).Decode(&generations); err != nil {
+		return nil, fmt.Errorf("failed to parse generations: %w", err)
+	}
+	return generations, nil
+}
+
+// deleteSnapshotGeneration deletes the archives of a published generation.
+func deleteSnapshotGeneration(ctx context.Context, store SnapshotStore, generation snapshotGeneration) error {
+	for _, repoID := range generation.RepoIDs {
+		for _, kind := range []string{snapshotKindIndex, snapshotKindRepo} {
+			if err := store.Delete(ctx, snapshotArchiveKey(generation.Generation, repoID, kind)); err != nil {
+				return fmt.Errorf("failed to delete generation %d: %w", generation.Generation, err)
+			}
+		}
+	}
+	return nil
+}
+
+// uploadArchive archives a directory to a temporary file and uploads it.
+func uploadArchive(ctx context.Context, store SnapshotStore, dir, key string, skipDirs ...string) error {
+	tmp, err := os.CreateTemp("", "relic-snapshot-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err := writeTarGz(tmp, dir, skipDirs...); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return store.Put(ctx, key, tmp, size)
+}
+
+// fetchSnapshotManifest downloads and parses the published manifest.
+func fetchSnapshotManifest(ctx context.Context, store SnapshotStore) (*Manifest, []byte, error) {
+	body, err := store.Get(ctx, SnapshotManifestKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, data, nil
+}
+
+// downloadArchive downloads and extracts an archive into destDir.
+func downloadArchive(ctx context.Context, store SnapshotStore, key, destDir string) error {
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = body.Close() }()
+
+	return extractTarGz(body, destDir)
+}
+
+// writeTarGz writes the contents of dir as a gzipped tar stream, omitting the
+// named top-level directories.
+func writeTarGz(w io.Writer, dir string, skipDirs ...string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() && slices.Contains(skipDirs, rel) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // Skip symlinks and special files
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractTarGz extracts a gzipped tar stream into destDir, rejecting entries
+// that would escape it.
+func extractTarGz(r io.Reader, destDir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := validatePath(header.Name); err != nil {
+			return fmt.Errorf("invalid archive entry %q: %w", header.Name, err)
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// fileSnapshotStore stores snapshots in a directory, e.g. a network mount.
+type fileSnapshotStore struct {
+	root string
+}
+
+// Put writes the object atomically.
+func (s *fileSnapshotStore) Put(_ context.Context, key string, body io.Reader, _ int64) error {
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp := target + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// Delete removes the object, and its directory once it is empty.
+func (s *fileSnapshotStore) Delete(_ context.Context, key string) error {
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if dir := filepath.Dir(target); dir != filepath.Clean(s.root) {
+		_ = os.Remove(dir) // Fails while other objects are left
+	}
+	return nil
+}
+
+// Get opens the object for reading.
+func (s *fileSnapshotStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(s.root, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrSnapshotNotFound
+	}
+	return f, err
+}
@@ -0,0 +1,96 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+// streamedHits is the number of hits of each repository sent with its
+// progress notification
+const streamedHits = 5
+
+// notifyingIndex reports the result of every search of the wrapped index.
+type notifyingIndex struct {
+	sharedIndex
+	notify func(*bleve.SearchResult)
+}
+
+func (n *notifyingIndex) SearchInContext(ctx context.Context, req *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	result, err := n.sharedIndex.SearchInContext(ctx, req)
+	if err == nil {
+		n.notify(result)
+	}
+	return result, err
+}
+
+// streamingAlias returns an alias over the indexes of alias that sends a
+// progress notification with the first hits of each repository as soon as
+// its index has been searched, so that clients see results of broad searches
+// before all repositories are done. Hits on paths the read policy of service
+// denies are left out of the notifications. The merged result is unchanged.
+// It returns alias itself when there is nothing to stream: the client sent no
+// progress token, or a single index is searched.
+func streamingAlias(ctx context.Context, req *mcp.CallToolRequest, alias bleve.IndexAlias, service SearchService) bleve.IndexAlias {
+	pooled, ok := alias.(*pooledAlias)
+	if !ok || len(pooled.indexes) < 2 || req == nil || req.Session == nil || req.Params == nil {
+		return alias
+	}
+	token := req.Params.GetProgressToken()
+	if token == nil {
+		return alias
+	}
+
+	var mu sync.Mutex
+	searched := 0
+	notify := func(result *bleve.SearchResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		searched++
+		_ = req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+			ProgressToken: token,
+			Message:       streamedMessage(result, searched, len(pooled.indexes), service),
+			Progress:      float64(searched),
+			Total:         float64(len(pooled.indexes)),
+		})
+	}
+
+	indexes := make([]bleve.Index, len(pooled.indexes))
+	for n, index := range pooled.indexes {
+		indexes[n] = &notifyingIndex{sharedIndex: index, notify: notify}
+	}
+	return bleve.NewIndexAlias(indexes...)
+}
+
+// streamedMessage describes the result of searching one of total indexes,
+// without the hits the read policy of service denies. The result is shared
+// with the alias merging it, so it is not modified.
+func streamedMessage(result *bleve.SearchResult, searched, total int, service SearchService) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Searched %d of %d repositories", searched, total))
+
+	var hits search.DocumentMatchCollection
+	for _, hit := range result.Hits {
+		if path, _ := hit.Fields[domain.CodeFieldFilePath].(string); !service.ReadDenied(path) {
+			hits = append(hits, hit)
+		}
+	}
+	if len(hits) == 0 {
+		return sb.String()
+	}
+
+	repo, _ := hits[0].Fields[domain.CodeFieldRepository].(string)
+	allowed := result.Total - uint64(len(result.Hits)-len(hits))
+	sb.WriteString(fmt.Sprintf("; %s in %s:", pluralize(int(allowed), "hit"), repo))
+	for _, hit := range hits[:min(len(hits), streamedHits)] {
+		path, _ := hit.Fields[domain.CodeFieldFilePath].(string)
+		sb.WriteString(fmt.Sprintf("\n- `%s`%s", path, hitLines(hit)))
+	}
+	return sb.String()
+}
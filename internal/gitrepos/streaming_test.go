@@ -0,0 +1,99 @@
+package gitrepos
+
+import (
+	"cmp"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSearchHandler_StreamsHitsPerRepository(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+	for repoID, files := range map[string][]string{
+		"github.com_org_api": {"auth/login.go", "auth/token.go"},
+		"github.com_org_web": {"src/auth.ts"},
+	} {
+		repoDir := filepath.Join(dir, "repos", repoID)
+		for _, file := range files {
+			createTestFile(t, repoDir, file, "// authenticate the user session")
+		}
+		if _, err := indexer.FullIndex(repoID, repoDir); err != nil {
+			t.Fatalf("FullIndex failed: %v", err)
+		}
+	}
+	alias, err := indexer.CreateAlias([]string{"github.com_org_api", "github.com_org_web"})
+	if err != nil {
+		t.Fatalf("CreateAlias failed: %v", err)
+	}
+	defer func() { _ = alias.Close() }()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server"}, nil)
+	RegisterSearchTool(server, &mockSearchService{ready: true, alias: alias, maxResults: 20, deny: []string{"auth/token.go"}})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Server connect failed: %v", err)
+	}
+	defer func() { _ = serverSession.Close() }()
+
+	notifications := make(chan *mcp.ProgressNotificationParams, 10)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			notifications <- req.Params
+		},
+	})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Client connect failed: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	// Without a progress token, nothing is streamed
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "search", Arguments: map[string]any{"query": "authenticate"}})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %v %s", err, ExtractTextContent(result))
+	}
+
+	params := &mcp.CallToolParams{Meta: mcp.Meta{}, Name: "search", Arguments: map[string]any{"query": "authenticate"}}
+	params.SetProgressToken("tok")
+	streamed, err := session.CallTool(ctx, params)
+	if err != nil || streamed.IsError {
+		t.Fatalf("CallTool failed: %v %s", err, ExtractTextContent(streamed))
+	}
+	if ExtractTextContent(streamed) != ExtractTextContent(result) {
+		t.Errorf("Expected streaming to leave the result unchanged, got:\n%s\nexpected:\n%s", ExtractTextContent(streamed), ExtractTextContent(result))
+	}
+
+	// Notifications are handled asynchronously by the client
+	var notified []*mcp.ProgressNotificationParams
+	for len(notified) < 2 {
+		select {
+		case p := <-notifications:
+			notified = append(notified, p)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected a notification per repository, got %d", len(notified))
+		}
+	}
+	slices.SortFunc(notified, func(a, b *mcp.ProgressNotificationParams) int {
+		return cmp.Compare(a.Progress, b.Progress)
+	})
+	messages := notified[0].Message + "\n" + notified[1].Message
+	for _, want := range []string{"Searched 1 of 2 repositories", "Searched 2 of 2 repositories", "1 hit in github.com/org/api:\n- `auth/login.go`", "1 hit in github.com/org/web:\n- `src/auth.ts`"} {
+		if !strings.Contains(messages, want) {
+			t.Errorf("Expected %q in notifications, got:\n%s", want, messages)
+		}
+	}
+	if strings.Contains(messages, "token.go") {
+		t.Errorf("Expected denied paths to be left out of notifications, got:\n%s", messages)
+	}
+	if notified[1].ProgressToken != "tok" || notified[1].Progress != 2 || notified[1].Total != 2 {
+		t.Errorf("Unexpected progress notification: %+v", notified[1])
+	}
+}
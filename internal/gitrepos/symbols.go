@@ -13,49 +13,47 @@ type LanguageRegex struct {
 var languagePatterns = map[string]LanguageRegex{
 	"go": {
 		Patterns: []*regexp.Regexp{
-			regexp.MustCompile(`func\s+(\w+)`),
-			regexp.MustCompile(`type\s+(\w+)\s+(struct|interface)`),
+			regexp.MustCompile(`(?m)^func\s+(?:\([^)]*\)\s*)?(\w+)`), // Function or method
+			regexp.MustCompile(`(?m)^\s*type\s+(\w+)[\s\[]`),
 			regexp.MustCompile(`const\s+(\w+)`),
 			regexp.MustCompile(`var\s+(\w+)`),
 		},
 	},
 	"py": {
-		Patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?m)^\s*def\s+(\w+)`),
-			regexp.MustCompile(`(?m)^\s*class\s+(\w+)`),
-		},
+		Patterns: pyPatterns,
 	},
 	"python": {
-		Patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?m)^\s*def\s+(\w+)`),
-			regexp.MustCompile(`(?m)^\s*class\s+(\w+)`),
-		},
+		Patterns: pyPatterns,
 	},
 	"java": {
 		Patterns: []*regexp.Regexp{
 			regexp.MustCompile(`class\s+(\w+)`),
 			regexp.MustCompile(`interface\s+(\w+)`),
 			regexp.MustCompile(`enum\s+(\w+)`),
+			regexp.MustCompile(`record\s+(\w+)\s*[(<]`),
 			regexp.MustCompile(`(?:public|protected|private|static|\s) +[\w\<\>\[\]]+\s+(\w+) *\(`), // Method
 		},
 	},
 	"js": {
 		Patterns: []*regexp.Regexp{
-			regexp.MustCompile(`function\s+(\w+)`),
+			regexp.MustCompile(`function\s*\*?\s*(\w+)`),
 			regexp.MustCompile(`class\s+(\w+)`),
 			regexp.MustCompile(`const\s+(\w+)\s*=`),
 			regexp.MustCompile(`let\s+(\w+)\s*=`),
 			regexp.MustCompile(`var\s+(\w+)\s*=`),
+			jsMethodPattern,
 		},
 	},
 	"ts": {
 		Patterns: []*regexp.Regexp{
-			regexp.MustCompile(`function\s+(\w+)`),
+			regexp.MustCompile(`function\s*\*?\s*(\w+)`),
 			regexp.MustCompile(`class\s+(\w+)`),
 			regexp.MustCompile(`interface\s+(\w+)`),
-			regexp.MustCompile(`type\s+(\w+)\s*=`),
-			regexp.MustCompile(`const\s+(\w+)\s*=`),
-			regexp.MustCompile(`let\s+(\w+)\s*=`),
+			regexp.MustCompile(`type\s+(\w+)\s*[=<]`),
+			regexp.MustCompile(`enum\s+(\w+)`),
+			regexp.MustCompile(`const\s+(\w+)\s*[=:]`),
+			regexp.MustCompile(`let\s+(\w+)\s*[=:]`),
+			jsMethodPattern,
 		},
 	},
 	"rs": {
@@ -76,6 +74,14 @@ var languagePatterns = map[string]LanguageRegex{
 			regexp.MustCompile(`#define\s+(\w+)`),
 		},
 	},
+	"proto": {
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*message\s+(\w+)`),
+			regexp.MustCompile(`(?m)^\s*service\s+(\w+)`),
+			regexp.MustCompile(`(?m)^\s*rpc\s+(\w+)`),
+			regexp.MustCompile(`(?m)^\s*enum\s+(\w+)`),
+		},
+	},
 	"cpp": {
 		Patterns: []*regexp.Regexp{
 			regexp.MustCompile(`class\s+(\w+)`),
@@ -84,27 +90,97 @@ var languagePatterns = map[string]LanguageRegex{
 			regexp.MustCompile(`(?m)^\s*\w+\s+(\w+)\s*\(.*\)\s*\{`), // Function definition (simplified)
 		},
 	},
+	"kt": {
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?:class|interface|object)\s+(\w+)`),
+			regexp.MustCompile(`fun\s+(?:<[^>\n]*>\s*)?(?:[\w.]+\.)?(\w+)\s*\(`), // Function, method or extension
+			regexp.MustCompile(`typealias\s+(\w+)`),
+		},
+	},
+	"cs": {
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?:class|interface|struct|enum|record)\s+(\w+)`),
+			regexp.MustCompile(`(?m)^\s*(?:\[[^\]\n]*\]\s*)*(?:(?:public|protected|private|internal|static|virtual|override|abstract|sealed|async|extern|unsafe|new|partial)\s+)+` +
+				`[\w<>\[\],.?]+\s+(\w+)\s*(?:<[^>\n]*>)?\s*\(`), // Method
+		},
+	},
+	"rb": {
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*def\s+(?:self\.)?(\w+[?!]?)`),
+			regexp.MustCompile(`(?m)^\s*(?:class|module)\s+(?:\w+::)*(\w+)`),
+		},
+	},
+	"php": {
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*(?:(?:abstract|final|readonly)\s+)*(?:class|interface|trait|enum)\s+(\w+)`),
+			regexp.MustCompile(`function\s+&?\s*(\w+)\s*\(`),
+		},
+	},
+	"swift": {
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?:class|struct|enum|protocol|actor|extension)\s+(\w+)`),
+			regexp.MustCompile(`func\s+(\w+)`),
+			regexp.MustCompile(`typealias\s+(\w+)`),
+		},
+	},
+}
+
+// pyPatterns match the functions, methods and classes of Python.
+var pyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*(?:async\s+)?def\s+(\w+)`),
+	regexp.MustCompile(`(?m)^\s*class\s+(\w+)`),
+}
+
+// jsMethodPattern matches the indented method definitions of JavaScript and
+// TypeScript classes and object literals, e.g. "  async load(id: string) {".
+// Control statements such as "if (x) {" match it too; symbolKeywords drops
+// them.
+var jsMethodPattern = regexp.MustCompile(`(?m)^\s+(?:(?:public|private|protected|static|async|readonly|abstract|override|get|set)\s+)*\*?\s*` +
+	`(\w+)\s*(?:<[^>\n]*>)?\s*\([^)\n]*\)\s*(?::\s*[^{=;\n]+)?\{`)
+
+// symbolKeywords are the keywords that symbol patterns can mistake for
+// names; they are never symbols.
+var symbolKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"function": true, "return": true, "with": true, "constructor": true,
+}
+
+// symbolLanguage returns the languagePatterns key of a file extension,
+// mapping commonly used extensions to it, or the normalized extension if
+// it has none.
+func symbolLanguage(ext string) string {
+	normalizedExt := strings.ToLower(strings.TrimPrefix(ext, "."))
+	switch normalizedExt {
+	case "javascript", "jsx":
+		return "js"
+	case "typescript", "tsx":
+		return "ts"
+	case "golang":
+		return "go"
+	case "rust":
+		return "rs"
+	case "h":
+		return "c"
+	case "hpp", "cc", "cxx":
+		return "cpp"
+	case "kotlin", "kts":
+		return "kt"
+	case "csharp":
+		return "cs"
+	case "ruby":
+		return "rb"
+	}
+	return normalizedExt
 }
 
 // ExtractSymbols extracts symbols from content based on file extension.
 func ExtractSymbols(ext, content string) []string {
-	normalizedExt := strings.ToLower(strings.TrimPrefix(ext, "."))
-	patterns, ok := languagePatterns[normalizedExt]
+	lang := symbolLanguage(ext)
+	patterns, ok := languagePatterns[lang]
 	if !ok {
-		// Try mapping commonly used extensions
-		switch normalizedExt {
-		case "javascript", "jsx":
-			patterns = languagePatterns["js"]
-		case "typescript", "tsx":
-			patterns = languagePatterns["ts"]
-		case "golang":
-			patterns = languagePatterns["go"]
-		case "rust":
-			patterns = languagePatterns["rs"]
-		case "h":
-			patterns = languagePatterns["c"]
-		case "hpp", "cc", "cxx":
-			patterns = languagePatterns["cpp"]
+		switch lang {
+		case "json", "yaml", "yml":
+			return extractOpenAPISymbols(lang, content)
 		default:
 			return nil
 		}
@@ -122,7 +198,7 @@ func ExtractSymbols(ext, content string) []string {
 				// match[1] should be the identifier
 				symbol := strings.TrimSpace(match[1])
 				// Basic validation to ensure it looks like an identifier
-				if symbol != "" && len(symbol) < 100 {
+				if symbol != "" && len(symbol) < 100 && !symbolKeywords[symbol] {
 					uniqueSymbols[symbol] = struct{}{}
 				}
 			}
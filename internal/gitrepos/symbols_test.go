@@ -108,6 +108,122 @@ int MyFunc() { return 0; }
 `,
 			expected: []string{"MyClass", "MyStruct", "MyEnum", "MyFunc"},
 		},
+		{
+			name: "Go methods and other types",
+			ext:  "go",
+			content: `package main
+
+// func notASymbol is in a comment
+type ID string
+type Set[T comparable] map[T]struct{}
+
+func (s *Server) Start() error { return nil }
+func (Set[T]) Len() int { return 0 }
+`,
+			expected: []string{"ID", "Set", "Start", "Len"},
+		},
+		{
+			name: "Python async functions",
+			ext:  "py",
+			content: `class Client:
+    async def fetch(self):
+        pass
+`,
+			expected: []string{"Client", "fetch"},
+		},
+		{
+			name: "TypeScript class methods",
+			ext:  "ts",
+			content: `export class UserStore {
+  constructor(private db: Db) {}
+
+  async load(id: string): Promise<User> {
+    if (id) {
+      return this.db.get(id);
+    }
+  }
+
+  private static cacheKey<T>(id: T) {
+    for (const x of []) {}
+  }
+}
+export enum Role { Admin }
+`,
+			expected: []string{"UserStore", "load", "cacheKey", "Role"},
+		},
+		{
+			name: "Java records",
+			ext:  "java",
+			content: `public record Point(int x, int y) {}
+`,
+			expected: []string{"Point"},
+		},
+		{
+			name: "Kotlin classes and functions",
+			ext:  "kt",
+			content: `data class User(val id: String)
+object Registry {
+    fun register(user: User) {}
+}
+suspend fun <T> load(): T = TODO()
+fun String.slugify(): String = lowercase()
+typealias Users = List<User>
+`,
+			expected: []string{"User", "Registry", "register", "load", "slugify", "Users"},
+		},
+		{
+			name: "C# classes and methods",
+			ext:  "cs",
+			content: `public class OrderService : IOrderService
+{
+    [HttpGet]
+    public async Task<Order> GetOrder(int id) { }
+    private static void Log<T>(T value) { }
+}
+public record OrderCreated(int Id);
+`,
+			expected: []string{"OrderService", "GetOrder", "Log", "OrderCreated"},
+		},
+		{
+			name: "Ruby classes and methods",
+			ext:  "rb",
+			content: `module Billing
+  class Invoices::Invoice < Base
+    def self.build; end
+    def paid?; end
+  end
+end
+`,
+			expected: []string{"Billing", "Invoice", "build", "paid?"},
+		},
+		{
+			name: "PHP classes and functions",
+			ext:  "php",
+			content: `<?php
+final class UserController {
+    public function show($id) {}
+}
+function &helper() {}
+`,
+			expected: []string{"UserController", "show", "helper"},
+		},
+		{
+			name: "Swift types and functions",
+			ext:  "swift",
+			content: `struct Point {}
+protocol Shape {}
+extension Point: Shape {
+    func area() -> Double { 0 }
+}
+`,
+			expected: []string{"Point", "Shape", "area"},
+		},
+		{
+			name:     "Kotlin script alias",
+			ext:      "kts",
+			content:  `fun main() {}`,
+			expected: []string{"main"},
+		},
 		{
 			name:     "Unsupported extension",
 			ext:      "txt",
@@ -153,6 +269,49 @@ int MyFunc() { return 0; }
 			content:  `#define CONSTANT 1`,
 			expected: []string{"CONSTANT"},
 		},
+		{
+			name: "Protobuf messages and services",
+			ext:  "proto",
+			content: `syntax = "proto3";
+message GetUserRequest {
+  string id = 1;
+}
+enum Status {
+  ACTIVE = 0;
+}
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+`,
+			expected: []string{"GetUserRequest", "Status", "UserService", "GetUser"},
+		},
+		{
+			name: "OpenAPI YAML spec",
+			ext:  "yaml",
+			content: `openapi: 3.0.0
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+    get:
+      operationId: getUser
+    delete:
+      operationId: deleteUser
+`,
+			expected: []string{"/users/{id}", "getUser", "deleteUser"},
+		},
+		{
+			name:     "Swagger JSON spec",
+			ext:      "json",
+			content:  `{"swagger": "2.0", "paths": {"/health": {"get": {"operationId": "healthCheck"}}}}`,
+			expected: []string{"/health", "healthCheck"},
+		},
+		{
+			name:     "YAML without spec",
+			ext:      "yml",
+			content:  "paths:\n  /users:\n    get:\n      operationId: listUsers\n",
+			expected: nil,
+		},
 		{
 			name:     "Symbol too long",
 			ext:      "go",
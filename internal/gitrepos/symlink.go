@@ -0,0 +1,46 @@
+package gitrepos
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrSymlinkNotFollowed is returned when a path goes through a symlink
+	// and symlinks are not followed.
+	ErrSymlinkNotFollowed = errors.New("symlinks are not followed")
+
+	// ErrSymlinkEscapesRepo is returned when a symlink resolves to a location
+	// outside the repository.
+	ErrSymlinkEscapesRepo = errors.New("symlink points outside the repository")
+)
+
+// resolveRepoPath resolves relPath inside repoDir and returns the real path
+// of the target. Any symlink along the way is rejected unless followSymlinks
+// is set, in which case the target must still be inside repoDir. Errors from
+// the filesystem (e.g. a missing file) are returned unchanged.
+func resolveRepoPath(repoDir, relPath string, followSymlinks bool) (string, error) {
+	root, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := filepath.EvalSymlinks(filepath.Join(repoDir, relPath))
+	if err != nil {
+		return "", err
+	}
+
+	if !followSymlinks {
+		if target != filepath.Join(root, relPath) {
+			return "", ErrSymlinkNotFollowed
+		}
+		return target, nil
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrSymlinkEscapesRepo
+	}
+	return target, nil
+}
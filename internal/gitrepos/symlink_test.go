@@ -0,0 +1,67 @@
+package gitrepos
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRepoPath(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	createTestFile(t, repoDir, "main.go", "package main")
+	createTestFile(t, repoDir, "lib/util.go", "package lib")
+	createTestFile(t, dir, "secret.txt", "password")
+	createTestSymlink(t, "main.go", filepath.Join(repoDir, "alias.go"))
+	createTestSymlink(t, "lib", filepath.Join(repoDir, "libalias"))
+	createTestSymlink(t, filepath.Join(dir, "secret.txt"), filepath.Join(repoDir, "escape.txt"))
+	createTestSymlink(t, dir, filepath.Join(repoDir, "parent"))
+
+	tests := []struct {
+		name    string
+		path    string
+		follow  bool
+		want    string
+		wantErr error
+	}{
+		{"regular file", "main.go", false, "main.go", nil},
+		{"file symlink skipped", "alias.go", false, "", ErrSymlinkNotFollowed},
+		{"file symlink followed", "alias.go", true, "main.go", nil},
+		{"directory symlink skipped", "libalias/util.go", false, "", ErrSymlinkNotFollowed},
+		{"directory symlink followed", "libalias/util.go", true, "lib/util.go", nil},
+		{"escaping file", "escape.txt", true, "", ErrSymlinkEscapesRepo},
+		{"escaping directory", "parent/secret.txt", true, "", ErrSymlinkEscapesRepo},
+	}
+
+	root, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		t.Fatalf("EvalSymlinks failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveRepoPath(repoDir, tt.path, tt.follow)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveRepoPath failed: %v", err)
+			}
+			if want := filepath.Join(root, tt.want); got != want {
+				t.Errorf("resolveRepoPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestResolveRepoPath_NotFound(t *testing.T) {
+	repoDir := t.TempDir()
+
+	if _, err := resolveRepoPath(repoDir, "missing.go", false); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
@@ -0,0 +1,393 @@
+package gitrepos
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// TelemetryFilename is the file search telemetry is appended to, in the
+	// base directory
+	TelemetryFilename = "telemetry.jsonl"
+
+	// clickWindow is how long after a search a read in the same session is
+	// attributed to it
+	clickWindow = 10 * time.Minute
+
+	// recentSearchesPerSession bounds the searches a read is matched against
+	recentSearchesPerSession = 5
+
+	// telemetryTopQueries is the number of queries listed in each ranking of
+	// a TelemetryReport
+	telemetryTopQueries = 10
+)
+
+// Telemetry event kinds
+const (
+	TelemetryEventSearch = "search"
+	TelemetryEventRead   = "read"
+)
+
+// TelemetryEvent is one line of the telemetry file. Queries are only recorded
+// as hashes.
+type TelemetryEvent struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	SearchID  string    `json:"search_id"`
+	QueryHash string    `json:"query_hash"`
+	Terms     int       `json:"terms,omitempty"`    // search: number of query terms
+	Results   uint64    `json:"results,omitempty"`  // search: total matches
+	Returned  int       `json:"returned,omitempty"` // search: hits returned
+	Rank      int       `json:"rank,omitempty"`     // read: 1-based rank of the file in the search, 0 if it was not a hit
+}
+
+// recentSearch is a search that later reads of its session are matched
+// against.
+type recentSearch struct {
+	id        string
+	queryHash string
+	at        time.Time
+	ranks     map[string]int // by hit key
+	read      map[string]bool
+}
+
+// SearchTelemetry records searches and the reads that follow them, as a
+// click-through proxy for relevance tuning. A nil *SearchTelemetry records
+// nothing.
+type SearchTelemetry struct {
+	mu       sync.Mutex
+	file     *os.File
+	sessions map[string][]*recentSearch
+	now      func() time.Time
+}
+
+// OpenSearchTelemetry opens the telemetry file at path for appending.
+func OpenSearchTelemetry(path string) (*SearchTelemetry, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open telemetry file: %w", err)
+	}
+	return &SearchTelemetry{
+		file:     file,
+		sessions: make(map[string][]*recentSearch),
+		now:      time.Now,
+	}, nil
+}
+
+// Close closes the telemetry file.
+func (t *SearchTelemetry) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Close()
+}
+
+// QueryHash returns the hash a query is recorded as. Letter case and spacing
+// do not change it.
+func QueryHash(query string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// telemetryKey identifies a file of a repository, or of one of its ref
+// snapshots, in search hits and reads.
+func telemetryKey(repoID, path string) string {
+	return repoID + "/" + strings.TrimPrefix(path, "/")
+}
+
+// RecordSearch records a search of session and the keys of its hits, in rank
+// order (see telemetryKey).
+func (t *SearchTelemetry) RecordSearch(session, query string, total uint64, hits []string) {
+	if t == nil {
+		return
+	}
+	id := newSearchID()
+	hash := QueryHash(query)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	search := &recentSearch{id: id, queryHash: hash, at: now, ranks: make(map[string]int, len(hits)), read: make(map[string]bool)}
+	for n, key := range hits {
+		if _, ok := search.ranks[key]; !ok {
+			search.ranks[key] = n + 1
+		}
+	}
+	t.pruneSessions(now)
+	searches := append(t.sessions[session], search)
+	if len(searches) > recentSearchesPerSession {
+		searches = searches[len(searches)-recentSearchesPerSession:]
+	}
+	t.sessions[session] = searches
+
+	t.write(TelemetryEvent{
+		Time:      now.UTC(),
+		Kind:      TelemetryEventSearch,
+		SearchID:  id,
+		QueryHash: hash,
+		Terms:     len(strings.Fields(query)),
+		Results:   total,
+		Returned:  len(hits),
+	})
+}
+
+// RecordRead records a read of session. It is attributed to the latest recent
+// search that returned the file, or else to the latest recent search with no
+// rank. Reads without a recent search, and repeated reads of a file, are not
+// recorded.
+func (t *SearchTelemetry) RecordRead(session, key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.pruneSessions(now)
+	searches := t.sessions[session]
+	if len(searches) == 0 {
+		return
+	}
+
+	search, rank := searches[len(searches)-1], 0
+	for n := len(searches) - 1; n >= 0; n-- {
+		if r, ok := searches[n].ranks[key]; ok {
+			search, rank = searches[n], r
+			break
+		}
+	}
+	if search.read[key] {
+		return
+	}
+	search.read[key] = true
+
+	t.write(TelemetryEvent{
+		Time:      now.UTC(),
+		Kind:      TelemetryEventRead,
+		SearchID:  search.id,
+		QueryHash: search.queryHash,
+		Rank:      rank,
+	})
+}
+
+// pruneSessions drops searches older than clickWindow. The caller holds mu.
+func (t *SearchTelemetry) pruneSessions(now time.Time) {
+	for session, searches := range t.sessions {
+		searches = slices.DeleteFunc(searches, func(s *recentSearch) bool {
+			return now.Sub(s.at) > clickWindow
+		})
+		if len(searches) == 0 {
+			delete(t.sessions, session)
+		} else {
+			t.sessions[session] = searches
+		}
+	}
+}
+
+// write appends an event to the telemetry file. The caller holds mu.
+func (t *SearchTelemetry) write(event TelemetryEvent) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if _, err := t.file.Write(append(line, '\n')); err != nil {
+		slog.Warn("Failed to write search telemetry", "error", err)
+	}
+}
+
+// sessionID returns the ID of the session of a tool call, empty for calls
+// without one (e.g. stdio, or in tests).
+func sessionID(req *mcp.CallToolRequest) string {
+	if req == nil || req.Session == nil {
+		return ""
+	}
+	return req.Session.ID()
+}
+
+func newSearchID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// QueryStats aggregates the searches of one query hash.
+type QueryStats struct {
+	QueryHash   string
+	Searches    int
+	ZeroResults int
+	Clicks      int // searches followed by a read of one of their hits
+}
+
+// TelemetryReport aggregates a telemetry file.
+type TelemetryReport struct {
+	Searches    int
+	ZeroResults int
+	// Clicked counts searches followed by a read of one of their hits
+	Clicked int
+	// ReadsOutsideHits counts reads that followed a search but were not
+	// among its hits
+	ReadsOutsideHits int
+	// MeanReciprocalRank averages 1/rank of the first hit read, over all
+	// searches with results (0 for searches without a read hit)
+	MeanReciprocalRank float64
+	// ClickRanks counts first reads by rank
+	ClickRanks map[int]int
+
+	TopQueries     []QueryStats // most frequent queries
+	TopZeroResults []QueryStats // most frequent queries without results
+}
+
+// LoadTelemetryReport aggregates the telemetry file at path. Lines that are
+// not valid events, e.g. a line cut short by a crash, are skipped.
+func LoadTelemetryReport(path string) (*TelemetryReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	type searchStats struct {
+		queryHash string
+		results   uint64
+		firstRank int
+	}
+	searches := make(map[string]*searchStats)
+	var order []string
+	report := &TelemetryReport{ClickRanks: make(map[int]int)}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event TelemetryEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		switch event.Kind {
+		case TelemetryEventSearch:
+			if _, ok := searches[event.SearchID]; !ok {
+				searches[event.SearchID] = &searchStats{queryHash: event.QueryHash, results: event.Results}
+				order = append(order, event.SearchID)
+			}
+		case TelemetryEventRead:
+			search, ok := searches[event.SearchID]
+			if !ok {
+				continue
+			}
+			if event.Rank == 0 {
+				report.ReadsOutsideHits++
+			} else if search.firstRank == 0 {
+				search.firstRank = event.Rank
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	queries := make(map[string]*QueryStats)
+	var reciprocalRanks float64
+	withResults := 0
+	for _, id := range order {
+		search := searches[id]
+		stats, ok := queries[search.queryHash]
+		if !ok {
+			stats = &QueryStats{QueryHash: search.queryHash}
+			queries[search.queryHash] = stats
+		}
+		stats.Searches++
+		report.Searches++
+		if search.results == 0 {
+			stats.ZeroResults++
+			report.ZeroResults++
+			continue
+		}
+		withResults++
+		if search.firstRank > 0 {
+			stats.Clicks++
+			report.Clicked++
+			report.ClickRanks[search.firstRank]++
+			reciprocalRanks += 1 / float64(search.firstRank)
+		}
+	}
+	if withResults > 0 {
+		report.MeanReciprocalRank = reciprocalRanks / float64(withResults)
+	}
+
+	all := make([]QueryStats, 0, len(queries))
+	for _, stats := range queries {
+		all = append(all, *stats)
+	}
+	report.TopQueries = topQueries(all, func(s QueryStats) int { return s.Searches })
+	report.TopZeroResults = topQueries(all, func(s QueryStats) int { return s.ZeroResults })
+	return report, nil
+}
+
+// topQueries returns the telemetryTopQueries queries with the highest
+// non-zero count, ties broken by hash.
+func topQueries(all []QueryStats, count func(QueryStats) int) []QueryStats {
+	top := slices.DeleteFunc(slices.Clone(all), func(s QueryStats) bool { return count(s) == 0 })
+	slices.SortFunc(top, func(a, b QueryStats) int {
+		return cmp.Or(cmp.Compare(count(b), count(a)), strings.Compare(a.QueryHash, b.QueryHash))
+	})
+	return top[:min(len(top), telemetryTopQueries)]
+}
+
+// String formats the report as markdown.
+func (r *TelemetryReport) String() string {
+	var sb strings.Builder
+	sb.WriteString("# Search Telemetry\n\n")
+	fmt.Fprintf(&sb, "- Searches: %d\n", r.Searches)
+	fmt.Fprintf(&sb, "- Without results: %d (%s)\n", r.ZeroResults, percent(r.ZeroResults, r.Searches))
+	fmt.Fprintf(&sb, "- Followed by a read of a hit: %d (%s of searches with results)\n", r.Clicked, percent(r.Clicked, r.Searches-r.ZeroResults))
+	fmt.Fprintf(&sb, "- Reads of files that were not hits: %d\n", r.ReadsOutsideHits)
+	fmt.Fprintf(&sb, "- Mean reciprocal rank: %.3f\n", r.MeanReciprocalRank)
+
+	if len(r.ClickRanks) > 0 {
+		sb.WriteString("\n## Rank of the First Hit Read\n\n")
+		ranks := make([]int, 0, len(r.ClickRanks))
+		for rank := range r.ClickRanks {
+			ranks = append(ranks, rank)
+		}
+		slices.Sort(ranks)
+		for _, rank := range ranks {
+			fmt.Fprintf(&sb, "- %d: %d\n", rank, r.ClickRanks[rank])
+		}
+	}
+
+	if len(r.TopQueries) > 0 {
+		sb.WriteString("\n## Most Frequent Queries\n\n")
+		for _, q := range r.TopQueries {
+			fmt.Fprintf(&sb, "- `%s`: %d searches, %d without results, %d read a hit\n", q.QueryHash, q.Searches, q.ZeroResults, q.Clicks)
+		}
+	}
+	if len(r.TopZeroResults) > 0 {
+		sb.WriteString("\n## Most Frequent Queries Without Results\n\n")
+		for _, q := range r.TopZeroResults {
+			fmt.Fprintf(&sb, "- `%s`: %d\n", q.QueryHash, q.ZeroResults)
+		}
+	}
+	return sb.String()
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
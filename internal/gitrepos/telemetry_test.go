@@ -0,0 +1,116 @@
+package gitrepos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestQueryHash(t *testing.T) {
+	if QueryHash("Parse  Config") != QueryHash("parse config") {
+		t.Error("Expected case and spacing not to change the hash")
+	}
+	if QueryHash("parse config") == QueryHash("parse configs") {
+		t.Error("Expected different queries to have different hashes")
+	}
+}
+
+func TestSearchTelemetry_Report(t *testing.T) {
+	path := filepath.Join(t.TempDir(), TelemetryFilename)
+	telemetry, err := OpenSearchTelemetry(path)
+	if err != nil {
+		t.Fatalf("OpenSearchTelemetry failed: %v", err)
+	}
+	now := time.Now()
+	telemetry.now = func() time.Time { return now }
+
+	// Session a reads the second hit, twice, and a file that was no hit
+	telemetry.RecordSearch("a", "parse config", 2, []string{"repo/a.go", "repo/b.go"})
+	telemetry.RecordRead("a", "repo/b.go")
+	telemetry.RecordRead("a", "repo/b.go")
+	telemetry.RecordRead("a", "repo/other.go")
+
+	// Session b searches without results, then the same query again and
+	// reads the first hit
+	telemetry.RecordSearch("b", "missing thing", 0, nil)
+	telemetry.RecordSearch("b", "Parse Config", 1, []string{"repo/a.go"})
+	telemetry.RecordRead("b", "repo/a.go")
+
+	// Reads without a recent search are not recorded
+	telemetry.RecordRead("c", "repo/a.go")
+	now = now.Add(clickWindow + time.Minute)
+	telemetry.RecordRead("a", "repo/a.go")
+
+	if err := telemetry.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	report, err := LoadTelemetryReport(path)
+	if err != nil {
+		t.Fatalf("LoadTelemetryReport failed: %v", err)
+	}
+	if report.Searches != 3 || report.ZeroResults != 1 || report.Clicked != 2 || report.ReadsOutsideHits != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.ClickRanks[1] != 1 || report.ClickRanks[2] != 1 {
+		t.Errorf("Expected one first read at each of ranks 1 and 2, got %v", report.ClickRanks)
+	}
+	if want := (1.0 + 0.5) / 2; report.MeanReciprocalRank != want {
+		t.Errorf("Expected MRR %.2f, got %.2f", want, report.MeanReciprocalRank)
+	}
+	if top := report.TopQueries[0]; top.QueryHash != QueryHash("parse config") || top.Searches != 2 || top.Clicks != 2 {
+		t.Errorf("Expected the repeated query first, got %+v", top)
+	}
+	if len(report.TopZeroResults) != 1 || report.TopZeroResults[0].QueryHash != QueryHash("missing thing") {
+		t.Errorf("Expected the query without results, got %+v", report.TopZeroResults)
+	}
+
+	text := report.String()
+	for _, want := range []string{"- Searches: 3", "33.3%", "Mean reciprocal rank: 0.750"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in report:\n%s", want, text)
+		}
+	}
+
+	// Query text is never written
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), "parse") {
+		t.Errorf("Expected no query text in the telemetry file:\n%s", content)
+	}
+}
+
+func TestService_SearchTelemetry(t *testing.T) {
+	baseDir := t.TempDir()
+	svc := setupRefService(t, baseDir)
+	telemetry, err := OpenSearchTelemetry(filepath.Join(baseDir, TelemetryFilename))
+	if err != nil {
+		t.Fatalf("OpenSearchTelemetry failed: %v", err)
+	}
+	svc.telemetry = telemetry
+
+	ctx := context.Background()
+	result, _, _ := NewSearchHandler(svc).Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "currentHandler"})
+	if result.IsError {
+		t.Fatalf("Search failed: %s", ExtractTextContent(result))
+	}
+	result, _, _ = NewReadHandler(svc).Handle(ctx, &mcp.CallToolRequest{}, ReadArgument{Repository: "github.com/test/repo", Path: "main.go"})
+	if result.IsError {
+		t.Fatalf("Read failed: %s", ExtractTextContent(result))
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	report, err := LoadTelemetryReport(filepath.Join(baseDir, TelemetryFilename))
+	if err != nil {
+		t.Fatalf("LoadTelemetryReport failed: %v", err)
+	}
+	if report.Searches != 1 || report.Clicked != 1 || report.ClickRanks[1] != 1 {
+		t.Errorf("Expected the read of the first hit to be recorded, got %+v", report)
+	}
+}
@@ -25,9 +25,10 @@ type MockCommand struct {
 
 // ExecutorCall records a command invocation.
 type ExecutorCall struct {
-	Dir  string
-	Name string
-	Args []string
+	Dir       string
+	Name      string
+	Args      []string
+	GitConfig [][2]string // config passed through the environment
 }
 
 // NewMockExecutor creates a new mock executor.
@@ -48,8 +49,8 @@ func (m *MockExecutor) AddResponse(namePrefix string, output []byte, err error)
 }
 
 // Run executes a command and returns the configured mock response.
-func (m *MockExecutor) Run(_ context.Context, dir string, name string, args ...string) ([]byte, error) {
-	call := ExecutorCall{Dir: dir, Name: name, Args: args}
+func (m *MockExecutor) Run(ctx context.Context, dir string, name string, args ...string) ([]byte, error) {
+	call := ExecutorCall{Dir: dir, Name: name, Args: args, GitConfig: gitConfigFrom(ctx)}
 	m.calls = append(m.calls, call)
 
 	// Build full command string for matching
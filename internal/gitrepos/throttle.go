@@ -0,0 +1,265 @@
+package gitrepos
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// throttleDialTimeout bounds connecting to a remote through the throttle.
+const throttleDialTimeout = 30 * time.Second
+
+// rateLimiter spreads transfers over time so that together they do not
+// exceed a number of bytes per second.
+type rateLimiter struct {
+	mu   sync.Mutex
+	rate float64   // bytes per second
+	next time.Time // when the bytes granted so far are sent at rate
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	return &rateLimiter{rate: float64(bytesPerSecond)}
+}
+
+// chunk is how many bytes to transfer between waits: a tenth of a second's
+// worth, between 1KB and 32KB.
+func (l *rateLimiter) chunk() int {
+	return min(max(int(l.rate/10), 1024), 32*1024)
+}
+
+// wait blocks until n more bytes may be transferred, or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttledReader reads from r no faster than limiter allows.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > t.limiter.chunk() {
+		p = p[:t.limiter.chunk()]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.wait(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// FetchThrottle is a local HTTP proxy that limits the bandwidth of git
+// transfers over HTTP(S), for git servers that rate-limit clients or networks
+// shared with latency-sensitive workloads. git is pointed at it with
+// http.proxy (see DefaultExecutor.HTTPProxy); the limit applies to downloads
+// and is shared by all concurrent transfers. Connections go through the
+// proxy of the environment (HTTPS_PROXY, ...), if any.
+type FetchThrottle struct {
+	limiter   *rateLimiter
+	listener  net.Listener
+	server    *http.Server
+	transport *http.Transport
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewFetchThrottle starts a throttling proxy on a loopback port.
+func NewFetchThrottle(bytesPerSecond int64) (*FetchThrottle, error) {
+	if bytesPerSecond <= 0 {
+		return nil, errors.New("fetch rate limit must be positive")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start fetch throttle: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &FetchThrottle{
+		limiter:   newRateLimiter(bytesPerSecond),
+		listener:  listener,
+		transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	t.server = &http.Server{Handler: t, ReadHeaderTimeout: throttleDialTimeout}
+	go func() {
+		if err := t.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Fetch throttle stopped", "error", err)
+		}
+	}()
+	return t, nil
+}
+
+// URL returns the proxy URL to configure git with.
+func (t *FetchThrottle) URL() string {
+	return "http://" + t.listener.Addr().String()
+}
+
+// Close stops the proxy and the transfers going through it.
+func (t *FetchThrottle) Close() error {
+	t.cancel()
+	t.transport.CloseIdleConnections()
+	return t.server.Close()
+}
+
+// ServeHTTP tunnels https:// transfers (CONNECT) and forwards http:// ones.
+func (t *FetchThrottle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		t.tunnel(w, r)
+		return
+	}
+	t.forward(w, r)
+}
+
+// forward proxies a plain HTTP request, throttling the response body.
+func (t *FetchThrottle) forward(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Scheme != "http" || r.URL.Host == "" {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+
+	resp, err := t.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, &throttledReader{ctx: r.Context(), r: resp.Body, limiter: t.limiter})
+}
+
+// tunnel connects a CONNECT request to its target and copies the traffic
+// both ways, throttling what the target sends.
+func (t *FetchThrottle) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, reader, err := t.dial(r.Context(), r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		_ = client.Close()
+		_ = upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// Bytes the client sent along with the CONNECT request first
+		_, _ = io.Copy(upstream, io.MultiReader(buffered, client))
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, &throttledReader{ctx: t.ctx, r: reader, limiter: t.limiter})
+		done <- struct{}{}
+	}()
+	// Either side closing ends the tunnel
+	<-done
+	_ = client.Close()
+	_ = upstream.Close()
+	<-done
+}
+
+// dial connects to host, through the proxy of the environment if there is
+// one for it. It returns the connection and the reader of what the target
+// sends.
+func (t *FetchThrottle) dial(ctx context.Context, host string) (net.Conn, io.Reader, error) {
+	ctx, cancel := context.WithTimeout(ctx, throttleDialTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	proxy, err := t.transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: host}})
+	if err != nil {
+		return nil, nil, err
+	}
+	if proxy == nil {
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		return conn, conn, err
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxy.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: host},
+		Host:   host,
+		Header: make(http.Header),
+	}
+	if user := proxy.User; user != nil {
+		password, _ := user.Password()
+		connect.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if err := connect.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, connect)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("proxy %s refused CONNECT: %s", proxy.Host, resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, reader, nil
+}
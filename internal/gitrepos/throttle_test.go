@@ -0,0 +1,152 @@
+package gitrepos
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := newRateLimiter(100 * 1024)
+
+	start := time.Now()
+	for range 4 {
+		if err := limiter.wait(context.Background(), 10*1024); err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+	}
+	// The first 10KB go right away, the next 30KB take 300ms at 100KB/s
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("Expected about 300ms of waits, took %v", elapsed)
+	}
+}
+
+func TestRateLimiter_WaitCanceled(t *testing.T) {
+	limiter := newRateLimiter(1024)
+	_ = limiter.wait(context.Background(), 10*1024)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx, 1024); err == nil {
+		t.Error("Expected an error once the context is canceled")
+	}
+}
+
+func TestRateLimiter_Chunk(t *testing.T) {
+	tests := map[int64]int{100: 1024, 100 * 1024: 10 * 1024, 10 * 1024 * 1024: 32 * 1024}
+	for rate, want := range tests {
+		if got := newRateLimiter(rate).chunk(); got != want {
+			t.Errorf("chunk() at %d B/s = %d, want %d", rate, got, want)
+		}
+	}
+}
+
+func TestNewFetchThrottle_InvalidRate(t *testing.T) {
+	if _, err := NewFetchThrottle(0); err == nil {
+		t.Error("Expected an error for a zero rate")
+	}
+}
+
+// fetchThroughThrottle downloads the body served by server through a
+// throttle of rate bytes per second, and returns it with the time it took.
+func fetchThroughThrottle(t *testing.T, server *httptest.Server, rate int64) ([]byte, time.Duration) {
+	t.Helper()
+	throttle, err := NewFetchThrottle(rate)
+	if err != nil {
+		t.Fatalf("NewFetchThrottle failed: %v", err)
+	}
+	defer func() { _ = throttle.Close() }()
+
+	proxy, err := url.Parse(throttle.URL())
+	if err != nil {
+		t.Fatalf("Invalid proxy URL: %v", err)
+	}
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	client := &http.Client{Transport: transport}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request through the throttle failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return body, time.Since(start)
+}
+
+func TestFetchThrottle(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 4*1024) // 64KB
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(payload)
+	})
+
+	tests := []struct {
+		name   string
+		server *httptest.Server
+	}{
+		{"http forwarded", httptest.NewServer(handler)},
+		{"https tunneled", httptest.NewTLSServer(handler)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.server.Close()
+
+			// 64KB at 256KB/s, less the first chunk that is not delayed
+			body, elapsed := fetchThroughThrottle(t, tt.server, 256*1024)
+			if !bytes.Equal(body, payload) {
+				t.Fatalf("Expected the payload through the throttle, got %d bytes", len(body))
+			}
+			if elapsed < 150*time.Millisecond {
+				t.Errorf("Expected the download to be throttled, took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestFetchThrottle_RejectsOriginRequests(t *testing.T) {
+	throttle, err := NewFetchThrottle(1024)
+	if err != nil {
+		t.Fatalf("NewFetchThrottle failed: %v", err)
+	}
+	defer func() { _ = throttle.Close() }()
+
+	resp, err := http.Get(throttle.URL() + "/")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a request that is not for a proxy, got %d", resp.StatusCode)
+	}
+}
+
+func TestDefaultExecutor_HTTPProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "git.example.com")
+	executor := &DefaultExecutor{HTTPProxy: "http://127.0.0.1:3128"}
+
+	output, err := executor.Run(context.Background(), "", "git", "config", "--get", "http.proxy")
+	if err != nil {
+		t.Fatalf("git config failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "http://127.0.0.1:3128" {
+		t.Errorf("http.proxy = %q, want the executor's proxy", got)
+	}
+
+	output, err = executor.Run(context.Background(), "", "sh", "-c", "echo \"[$NO_PROXY]\"")
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "[]" {
+		t.Errorf("Expected NO_PROXY to be cleared, got %s", got)
+	}
+}
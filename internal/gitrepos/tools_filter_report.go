@@ -0,0 +1,187 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// maxFilterReportTypes is the number of excluded file types listed by
+// filter_report.
+const maxFilterReportTypes = 15
+
+// FilterReportArgument defines filter_report parameters.
+type FilterReportArgument struct {
+	Repository string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// FilterReportHandler handles the filter_report MCP tool.
+type FilterReportHandler struct {
+	service FilterReportService
+}
+
+// NewFilterReportHandler creates a new filter_report handler.
+func NewFilterReportHandler(service FilterReportService) *FilterReportHandler {
+	return &FilterReportHandler{
+		service: service,
+	}
+}
+
+// Handle reports how the exclusion patterns filtered the last full index of
+// the requested repository.
+func (h *FilterReportHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args FilterReportArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "filter_report", config.ScopeAdmin); result != nil {
+		return result, nil, nil
+	}
+
+	if strings.TrimSpace(args.Repository) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Repository cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	state, ok := h.service.RepoStates()[DisplayToRepoID(args.Repository)]
+	if !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Repository not found: %s", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	if state.Skipped == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("No filter statistics for %s yet; they are recorded by full indexes (see the reindex tool)", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: formatFilterReport(args.Repository, state, h.service.ExcludePatterns(), formatFrom(ctx))},
+		},
+	}, nil, nil
+}
+
+// formatFilterReport renders the files excluded from the last full index of
+// a repository by pattern and file type, the patterns that excluded nothing,
+// and the files skipped for other reasons.
+func formatFilterReport(name string, state RepoState, patterns []string, format FormatOptions) string {
+	skipped := state.Skipped
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s**\n", name))
+	if state.LastIndexed != "" {
+		sb.WriteString(fmt.Sprintf("- Last full index of `%s`: %d files indexed\n", state.LastIndexed, state.FileCount))
+	}
+
+	var excludedBytes int64
+	for _, count := range skipped.ExcludedBy {
+		excludedBytes += count.Bytes
+	}
+	sb.WriteString(fmt.Sprintf("- Excluded: %d files (%s)\n\n", skipped.Counts[SkipReasonExcluded], format.size(excludedBytes, 1)))
+
+	if len(skipped.ExcludedBy) > 0 {
+		used := make([]string, 0, len(skipped.ExcludedBy))
+		for pattern := range skipped.ExcludedBy {
+			used = append(used, pattern)
+		}
+		// Most files first
+		sort.Slice(used, func(a, b int) bool {
+			ca, cb := skipped.ExcludedBy[used[a]], skipped.ExcludedBy[used[b]]
+			if ca.Files != cb.Files {
+				return ca.Files > cb.Files
+			}
+			return used[a] < used[b]
+		})
+
+		sb.WriteString("| Pattern | Files | Size |\n")
+		sb.WriteString("|---------|-------|------|\n")
+		for _, pattern := range used {
+			count := skipped.ExcludedBy[pattern]
+			sb.WriteString(fmt.Sprintf("| `%s` | %d | %s |\n", pattern, count.Files, format.size(count.Bytes, 1)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(skipped.ExcludedTypes) > 0 {
+		types := make([]string, 0, len(skipped.ExcludedTypes))
+		for ext := range skipped.ExcludedTypes {
+			types = append(types, ext)
+		}
+		sort.Slice(types, func(a, b int) bool {
+			if skipped.ExcludedTypes[types[a]] != skipped.ExcludedTypes[types[b]] {
+				return skipped.ExcludedTypes[types[a]] > skipped.ExcludedTypes[types[b]]
+			}
+			return types[a] < types[b]
+		})
+		counts := make([]string, 0, min(len(types), maxFilterReportTypes))
+		for _, ext := range types[:min(len(types), maxFilterReportTypes)] {
+			label := "." + ext
+			if ext == "" {
+				label = "no extension"
+			}
+			counts = append(counts, fmt.Sprintf("%d %s", skipped.ExcludedTypes[ext], label))
+		}
+		if len(types) > maxFilterReportTypes {
+			counts = append(counts, fmt.Sprintf("%d more types", len(types)-maxFilterReportTypes))
+		}
+		sb.WriteString(fmt.Sprintf("- Excluded file types: %s\n", strings.Join(counts, ", ")))
+	}
+
+	var unused []string
+	for _, pattern := range patterns {
+		if _, ok := skipped.ExcludedBy[pattern]; !ok {
+			unused = append(unused, "`"+pattern+"`")
+		}
+	}
+	if len(unused) > 0 {
+		sb.WriteString(fmt.Sprintf("- Patterns that excluded nothing (%d): %s\n", len(unused), strings.Join(unused, ", ")))
+	}
+
+	var others []string
+	for _, reason := range []string{SkipReasonTooLarge, SkipReasonBinary, SkipReasonSymlink, SkipReasonUnreadable} {
+		if n := skipped.Counts[reason]; n > 0 {
+			others = append(others, fmt.Sprintf("%d %s", n, strings.ReplaceAll(reason, "_", " ")))
+		}
+	}
+	if len(others) > 0 {
+		sb.WriteString(fmt.Sprintf("- Skipped for other reasons: %s\n", strings.Join(others, ", ")))
+	}
+	return sb.String()
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *FilterReportHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "filter_report",
+		Description: `Show how the file exclusion patterns filtered one repository.
+
+WHEN TO USE: Administrative operation. Use when tuning which files are
+indexed: to see which patterns exclude the most files and bytes, which
+exclude nothing, and what file types are left out.
+
+HOW IT WORKS: Reports the statistics recorded by the last full index of the
+repository: the files and bytes excluded by each pattern (attributed to the
+first pattern that matched), the most common excluded file extensions, the
+patterns that matched no file, and the files skipped for other reasons (too
+large, binary, symlink, unreadable).`,
+	}
+}
+
+// RegisterFilterReportTool registers the filter_report tool with an MCP server.
+func RegisterFilterReportTool(server *mcp.Server, service FilterReportService) {
+	handler := NewFilterReportHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
@@ -0,0 +1,78 @@
+package gitrepos
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// mockFilterReportService implements FilterReportService for handler tests.
+type mockFilterReportService struct {
+	states   map[string]RepoState
+	patterns []string
+}
+
+func (m *mockFilterReportService) RepoStates() map[string]RepoState { return m.states }
+func (m *mockFilterReportService) ExcludePatterns() []string        { return m.patterns }
+
+func TestFilterReportHandler_FormatsReport(t *testing.T) {
+	handler := NewFilterReportHandler(&mockFilterReportService{
+		states: map[string]RepoState{
+			"github.com_org_api": {
+				LastIndexed: "abc123",
+				FileCount:   42,
+				Skipped: &SkipStats{
+					Counts: map[string]int{SkipReasonExcluded: 12, SkipReasonBinary: 1},
+					ExcludedBy: map[string]ExcludedCount{
+						"node_modules/**": {Files: 10, Bytes: 4096},
+						"*.png":           {Files: 2, Bytes: 2048},
+					},
+					ExcludedTypes: map[string]int{"js": 9, "png": 2, "": 1},
+				},
+			},
+		},
+		patterns: []string{"node_modules/**", "*.png", "*.war"},
+	})
+
+	result, _, err := handler.Handle(adminContext(), &mcp.CallToolRequest{}, FilterReportArgument{Repository: "github.com/org/api"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+
+	for _, want := range []string{
+		"**github.com/org/api**",
+		"Last full index of `abc123`: 42 files indexed",
+		"Excluded: 12 files (6.0 KB)",
+		"| `node_modules/**` | 10 | 4.0 KB |\n| `*.png` | 2 | 2.0 KB |",
+		"Excluded file types: 9 .js, 2 .png, 1 no extension",
+		"Patterns that excluded nothing (1): `*.war`",
+		"Skipped for other reasons: 1 binary",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in output:\n%s", want, text)
+		}
+	}
+}
+
+func TestFilterReportHandler_Errors(t *testing.T) {
+	handler := NewFilterReportHandler(&mockFilterReportService{states: map[string]RepoState{
+		"github.com_org_new": {},
+	}})
+
+	tests := []struct {
+		repository string
+		want       string
+	}{
+		{" ", "Repository cannot be empty"},
+		{"github.com/org/missing", "Repository not found"},
+		{"github.com/org/new", "No filter statistics for github.com/org/new yet"},
+	}
+	for _, tt := range tests {
+		result, _, _ := handler.Handle(adminContext(), &mcp.CallToolRequest{}, FilterReportArgument{Repository: tt.repository})
+		if !result.IsError || !strings.Contains(ExtractTextContent(result), tt.want) {
+			t.Errorf("Expected error containing %q, got: %s", tt.want, ExtractTextContent(result))
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+const (
+	// defaultListFilesLimit is the number of files listed per page by default
+	defaultListFilesLimit = 200
+	// maxListFilesLimit caps the requested page size
+	maxListFilesLimit = 1000
+)
+
+// ListFilesArgument defines list_files parameters.
+type ListFilesArgument struct {
+	Repository string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
+	Path       string `json:"path,omitempty" jsonschema_description:"Directory to list, relative to the repository root (default: the root)"`
+	Pattern    string `json:"pattern,omitempty" jsonschema_description:"Glob the file paths below path must match, e.g. *.go, **/*_test.go or api/**"`
+	Offset     int    `json:"offset,omitempty" jsonschema_description:"Number of files to skip, to continue a listing from the offset given at its end"`
+	Limit      int    `json:"limit,omitempty" jsonschema_description:"Files per page (default: 200, max: 1000)"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// ListFilesHandler handles the list_files MCP tool.
+type ListFilesHandler struct {
+	service MapService
+}
+
+// NewListFilesHandler creates a new list_files handler.
+func NewListFilesHandler(service MapService) *ListFilesHandler {
+	return &ListFilesHandler{
+		service: service,
+	}
+}
+
+// Handle returns a page of the indexed files of a repository as a tree, from
+// the file catalog.
+func (h *ListFilesHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args ListFilesArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "list_files", config.ScopeSearch); result != nil {
+		return result, nil, nil
+	}
+
+	if strings.TrimSpace(args.Repository) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Repository cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	if args.Offset < 0 || args.Limit < 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Offset and limit cannot be negative"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	limit := args.Limit
+	if limit == 0 {
+		limit = defaultListFilesLimit
+	}
+	limit = min(limit, maxListFilesLimit)
+
+	root := strings.Trim(path.Clean("/"+strings.ReplaceAll(args.Path, "\\", "/")), "/")
+	pattern := strings.TrimPrefix(strings.ReplaceAll(strings.TrimSpace(args.Pattern), "\\", "/"), "/")
+
+	repoID := DisplayToRepoID(args.Repository)
+	if _, ok := h.service.RepoStates()[repoID]; !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Repository not found: %s", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	prefix := ""
+	if root != "" {
+		prefix = root + "/"
+	}
+	files := h.service.CatalogFiles(repoID, prefix)
+	if pattern != "" {
+		matching := files[:0:0]
+		for _, file := range files {
+			if matchPattern(pattern, strings.TrimPrefix(file.Path, prefix)) {
+				matching = append(matching, file)
+			}
+		}
+		files = matching
+	}
+
+	target := args.Repository
+	if root != "" {
+		target += "/" + root
+	}
+	if len(files) == 0 {
+		text := fmt.Sprintf("No indexed files in %s", target)
+		if pattern != "" {
+			text += fmt.Sprintf(" match `%s`", pattern)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	if args.Offset >= len(files) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Offset %d is past the last of %s in %s", args.Offset, pluralize(len(files), "file"), target)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: formatFileList(target, prefix, pattern, files, args.Offset, limit, formatFrom(ctx))},
+		},
+	}, nil, nil
+}
+
+// formatFileList renders a page of files under prefix as a nested markdown
+// list of their directories. A page starting within a directory repeats the
+// directories above its first file.
+func formatFileList(title, prefix, pattern string, files []CatalogFile, offset, limit int, format FormatOptions) string {
+	var sb strings.Builder
+	summary := pluralize(len(files), "file")
+	if pattern != "" {
+		summary += fmt.Sprintf(" matching `%s`", pattern)
+	}
+	sb.WriteString(fmt.Sprintf("**%s** (%s)\n\n", title, summary))
+
+	page := files[offset:min(offset+limit, len(files))]
+	var previous []string
+	for _, file := range page {
+		parts := strings.Split(strings.TrimPrefix(file.Path, prefix), "/")
+		dirs := parts[:len(parts)-1]
+		shared := 0
+		for shared < len(dirs) && shared < len(previous) && dirs[shared] == previous[shared] {
+			shared++
+		}
+		for level := shared; level < len(dirs); level++ {
+			sb.WriteString(fmt.Sprintf("%s- `%s/`\n", strings.Repeat("  ", level), dirs[level]))
+		}
+		sb.WriteString(fmt.Sprintf("%s- `%s` %s\n", strings.Repeat("  ", len(dirs)), parts[len(parts)-1], format.size(file.Size, 1)))
+		previous = dirs
+	}
+
+	if end := offset + len(page); end < len(files) {
+		sb.WriteString(fmt.Sprintf("\n_Files %d-%d of %d; continue with offset %d._\n", offset+1, end, len(files), end))
+	}
+	return sb.String()
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *ListFilesHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "list_files",
+		Description: `List the indexed files of a git repository as a directory tree.
+
+WHEN TO USE: Use to find the exact path of a file before reading it, or to see
+what a directory holds, instead of guessing paths.
+
+HOW IT WORKS: Provide the repository name, and optionally a path to list a
+subdirectory and a glob pattern the paths below it must match (e.g. *.go,
+**/*_test.go or api/**). Returns the matching files with their sizes, nested
+under their directories, up to limit files per page (default 200). Longer
+listings end with the offset to continue from. Files excluded from indexing
+are not listed.`,
+	}
+}
+
+// RegisterListFilesTool registers the list_files tool with an MCP server.
+func RegisterListFilesTool(server *mcp.Server, service MapService) {
+	handler := NewListFilesHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
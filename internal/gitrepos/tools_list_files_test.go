@@ -0,0 +1,126 @@
+package gitrepos
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func newListFilesTestHandler() *ListFilesHandler {
+	return NewListFilesHandler(&mockMapService{files: map[string][]CatalogFile{
+		"github.com_org_api": {
+			{Path: "README.md", Size: 2048},
+			{Path: "cmd/api/main.go", Size: 512},
+			{Path: "internal/http/routes.yaml", Size: 1024},
+			{Path: "internal/http/server.go", Size: 4096},
+			{Path: "internal/http/server_test.go", Size: 3072},
+			{Path: "internal/kafka/consumer.go", Size: 1536},
+		},
+	}})
+}
+
+func listFiles(t *testing.T, handler *ListFilesHandler, args ListFilesArgument) (string, bool) {
+	t.Helper()
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	return ExtractTextContent(result), result.IsError
+}
+
+func TestListFilesHandler_FormatsTree(t *testing.T) {
+	text, isError := listFiles(t, newListFilesTestHandler(), ListFilesArgument{Repository: "github.com/org/api"})
+	if isError {
+		t.Fatalf("Expected success, got: %s", text)
+	}
+
+	expected := "**github.com/org/api** (6 files)\n\n" +
+		"- `README.md` 2.0 KB\n" +
+		"- `cmd/`\n" +
+		"  - `api/`\n" +
+		"    - `main.go` 0.5 KB\n" +
+		"- `internal/`\n" +
+		"  - `http/`\n" +
+		"    - `routes.yaml` 1.0 KB\n" +
+		"    - `server.go` 4.0 KB\n" +
+		"    - `server_test.go` 3.0 KB\n" +
+		"  - `kafka/`\n" +
+		"    - `consumer.go` 1.5 KB\n"
+	if text != expected {
+		t.Errorf("Unexpected listing:\n%s\nexpected:\n%s", text, expected)
+	}
+}
+
+func TestListFilesHandler_PathAndPattern(t *testing.T) {
+	handler := newListFilesTestHandler()
+
+	text, _ := listFiles(t, handler, ListFilesArgument{Repository: "github.com/org/api", Path: "internal/http", Pattern: "*.go"})
+	expected := "**github.com/org/api/internal/http** (2 files matching `*.go`)\n\n" +
+		"- `server.go` 4.0 KB\n" +
+		"- `server_test.go` 3.0 KB\n"
+	if text != expected {
+		t.Errorf("Unexpected listing:\n%s\nexpected:\n%s", text, expected)
+	}
+
+	text, _ = listFiles(t, handler, ListFilesArgument{Repository: "github.com/org/api", Pattern: "**/*_test.go"})
+	if !strings.Contains(text, "(1 file matching") || !strings.Contains(text, "`server_test.go`") {
+		t.Errorf("Expected only the test file:\n%s", text)
+	}
+
+	text, isError := listFiles(t, handler, ListFilesArgument{Repository: "github.com/org/api", Pattern: "*.rs"})
+	if !isError || text != "No indexed files in github.com/org/api match `*.rs`" {
+		t.Errorf("Expected a no match error, got: %s", text)
+	}
+}
+
+func TestListFilesHandler_Pagination(t *testing.T) {
+	handler := newListFilesTestHandler()
+
+	text, _ := listFiles(t, handler, ListFilesArgument{Repository: "github.com/org/api", Limit: 2})
+	if !strings.HasSuffix(text, "_Files 1-2 of 6; continue with offset 2._\n") {
+		t.Errorf("Expected a continuation note:\n%s", text)
+	}
+
+	// A page starting within a directory repeats its parents
+	text, _ = listFiles(t, handler, ListFilesArgument{Repository: "github.com/org/api", Offset: 3, Limit: 2})
+	expected := "**github.com/org/api** (6 files)\n\n" +
+		"- `internal/`\n" +
+		"  - `http/`\n" +
+		"    - `server.go` 4.0 KB\n" +
+		"    - `server_test.go` 3.0 KB\n" +
+		"\n_Files 4-5 of 6; continue with offset 5._\n"
+	if text != expected {
+		t.Errorf("Unexpected page:\n%s\nexpected:\n%s", text, expected)
+	}
+
+	text, _ = listFiles(t, handler, ListFilesArgument{Repository: "github.com/org/api", Offset: 5})
+	if strings.Contains(text, "continue with") {
+		t.Errorf("Expected no continuation on the last page:\n%s", text)
+	}
+}
+
+func TestListFilesHandler_Errors(t *testing.T) {
+	handler := newListFilesTestHandler()
+	tests := []struct {
+		name     string
+		args     ListFilesArgument
+		expected string
+	}{
+		{name: "empty repository", args: ListFilesArgument{}, expected: "Repository cannot be empty"},
+		{name: "unknown repository", args: ListFilesArgument{Repository: "github.com/org/other"}, expected: "Repository not found: github.com/org/other"},
+		{name: "empty directory", args: ListFilesArgument{Repository: "github.com/org/api", Path: "docs"}, expected: "No indexed files in github.com/org/api/docs"},
+		{name: "negative offset", args: ListFilesArgument{Repository: "github.com/org/api", Offset: -1}, expected: "Offset and limit cannot be negative"},
+		{name: "offset past the end", args: ListFilesArgument{Repository: "github.com/org/api", Offset: 6}, expected: "Offset 6 is past the last of 6 files in github.com/org/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := listFiles(t, handler, tt.args)
+			if !isError || text != tt.expected {
+				t.Errorf("Expected error %q, got: %s", tt.expected, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+const (
+	// defaultMapDepth is the number of directory levels shown by default
+	defaultMapDepth = 2
+	// maxMapDepth caps the requested depth
+	maxMapDepth = 8
+	// maxMapDirectories caps the directories listed, so that the map stays a
+	// compact orientation payload even for very large repositories
+	maxMapDirectories = 200
+	// mapLanguages is the number of dominant languages shown per directory
+	mapLanguages = 3
+)
+
+// RepoMapArgument defines repo_map parameters.
+type RepoMapArgument struct {
+	Repository string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
+	Path       string `json:"path,omitempty" jsonschema_description:"Directory to map, relative to the repository root (default: the root)"`
+	Depth      int    `json:"depth,omitempty" jsonschema_description:"Directory levels to show below path (default: 2, max: 8)"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// RepoMapHandler handles the repo_map MCP tool.
+type RepoMapHandler struct {
+	service MapService
+}
+
+// NewRepoMapHandler creates a new repo_map handler.
+func NewRepoMapHandler(service MapService) *RepoMapHandler {
+	return &RepoMapHandler{
+		service: service,
+	}
+}
+
+// Handle returns the directory tree of a repository, annotated with file
+// counts and dominant languages, from the file catalog.
+func (h *RepoMapHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args RepoMapArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "repo_map", config.ScopeSearch); result != nil {
+		return result, nil, nil
+	}
+
+	if strings.TrimSpace(args.Repository) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Repository cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	depth := args.Depth
+	if depth <= 0 {
+		depth = defaultMapDepth
+	}
+	depth = min(depth, maxMapDepth)
+
+	root := strings.Trim(path.Clean("/"+strings.ReplaceAll(args.Path, "\\", "/")), "/")
+
+	repoID := DisplayToRepoID(args.Repository)
+	if _, ok := h.service.RepoStates()[repoID]; !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Repository not found: %s", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	prefix := ""
+	if root != "" {
+		prefix = root + "/"
+	}
+	files := h.service.CatalogFiles(repoID, prefix)
+	if len(files) == 0 {
+		target := args.Repository
+		if root != "" {
+			target += "/" + root
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("No indexed files in %s", target)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: formatRepoMap(args.Repository, repoID, root, depth, files)},
+		},
+	}, nil, nil
+}
+
+// formatRepoMap renders the directories of files under root, down to depth
+// levels, as a nested markdown list.
+func formatRepoMap(name, repoID, root string, depth int, files []CatalogFile) string {
+	languages := make(map[string]int)
+	for _, file := range files {
+		if file.Language != "" {
+			languages[file.Language]++
+		}
+	}
+
+	var sb strings.Builder
+	title := name
+	if root != "" {
+		title += "/" + root
+	}
+	sb.WriteString(fmt.Sprintf("**%s** (%s)\n\n", title, mapSummary(len(files), 0, sortedByCount(languages))))
+
+	rootDepth := 0
+	if root != "" {
+		rootDepth = strings.Count(root, "/") + 1
+	}
+
+	listed, omitted := 0, 0
+	for _, dir := range directoryDocuments(repoID, files) {
+		level := strings.Count(dir.FilePath, "/") + 1 - rootDepth
+		if level < 1 || level > depth {
+			continue
+		}
+		if listed == maxMapDirectories {
+			omitted++
+			continue
+		}
+		listed++
+		subdirs := 0
+		if level == depth {
+			subdirs = dir.SubdirCount
+		}
+		sb.WriteString(fmt.Sprintf("%s- `%s/` %s\n", strings.Repeat("  ", level-1), path.Base(dir.FilePath), mapSummary(dir.FileCount, subdirs, dir.Languages)))
+	}
+
+	if listed == 0 {
+		sb.WriteString("No subdirectories.\n")
+	}
+	if omitted > 0 {
+		sb.WriteString(fmt.Sprintf("\n_%d more directories not shown; map a subdirectory with `path` for detail._\n", omitted))
+	}
+	return sb.String()
+}
+
+// mapSummary describes the files under a directory. Subdirectories are only
+// mentioned when they are not listed themselves.
+func mapSummary(files, subdirs int, languages []string) string {
+	summary := pluralize(files, "file")
+	if subdirs > 0 {
+		summary += ", " + pluralize(subdirs, "subdirectory")
+	}
+	if len(languages) > mapLanguages {
+		languages = languages[:mapLanguages]
+	}
+	if len(languages) > 0 {
+		summary += "; " + strings.Join(languages, ", ")
+	}
+	return summary
+}
+
+// pluralize formats a count of things, e.g. "1 file" or "3 files".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *RepoMapHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "repo_map",
+		Description: `Get a compact map of the directory layout of an indexed git repository.
+
+WHEN TO USE: Use when starting work with an unfamiliar repository, together
+with get_readme, to learn where things live before searching.
+
+HOW IT WORKS: Provide the repository name, and optionally a path to map a
+subdirectory and a depth (default 2). Returns the directory tree with the
+number of indexed files and the dominant languages of each directory.
+Directories below the depth limit are counted, not listed.`,
+	}
+}
+
+// RegisterRepoMapTool registers the repo_map tool with an MCP server.
+func RegisterRepoMapTool(server *mcp.Server, service MapService) {
+	handler := NewRepoMapHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
@@ -0,0 +1,129 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// mockMapService implements MapService for handler tests.
+type mockMapService struct {
+	files map[string][]CatalogFile
+}
+
+func (m *mockMapService) RepoStates() map[string]RepoState {
+	states := make(map[string]RepoState, len(m.files))
+	for repoID := range m.files {
+		states[repoID] = RepoState{}
+	}
+	return states
+}
+
+func (m *mockMapService) CatalogFiles(repoID, prefix string) []CatalogFile {
+	var files []CatalogFile
+	for _, file := range m.files[repoID] {
+		if strings.HasPrefix(file.Path, prefix) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func TestRepoMapHandler_FormatsTree(t *testing.T) {
+	handler := NewRepoMapHandler(&mockMapService{files: map[string][]CatalogFile{
+		"github.com_org_api": {
+			{Path: "README.md", Language: "markdown"},
+			{Path: "cmd/api/main.go", Language: "go"},
+			{Path: "internal/kafka/consumer.go", Language: "go"},
+			{Path: "internal/kafka/schema/event.proto", Language: "protobuf"},
+			{Path: "internal/http/server.go", Language: "go"},
+			{Path: "internal/http/routes.yaml", Language: "yaml"},
+		},
+	}})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, RepoMapArgument{Repository: "github.com/org/api"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+
+	expected := "**github.com/org/api** (6 files; go, markdown, protobuf)\n\n" +
+		"- `cmd/` 1 file; go\n" +
+		"  - `api/` 1 file; go\n" +
+		"- `internal/` 4 files; go, protobuf, yaml\n" +
+		"  - `http/` 2 files; go, yaml\n" +
+		"  - `kafka/` 2 files, 1 subdirectory; go, protobuf\n"
+	if text != expected {
+		t.Errorf("Unexpected map:\n%s\nexpected:\n%s", text, expected)
+	}
+}
+
+func TestRepoMapHandler_PathAndDepth(t *testing.T) {
+	handler := NewRepoMapHandler(&mockMapService{files: map[string][]CatalogFile{
+		"github.com_org_api": {
+			{Path: "internal/kafka/consumer.go", Language: "go"},
+			{Path: "internal/kafka/schema/event.proto", Language: "protobuf"},
+			{Path: "internal/http/server.go", Language: "go"},
+			{Path: "internal/httpx/client.go", Language: "go"},
+		},
+	}})
+
+	result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, RepoMapArgument{Repository: "github.com/org/api", Path: "/internal/kafka/", Depth: 5})
+	text := ExtractTextContent(result)
+	expected := "**github.com/org/api/internal/kafka** (2 files; go, protobuf)\n\n" +
+		"- `schema/` 1 file; protobuf\n"
+	if text != expected {
+		t.Errorf("Unexpected map:\n%s\nexpected:\n%s", text, expected)
+	}
+
+	// A path prefix of another directory does not match it
+	result, _, _ = handler.Handle(context.Background(), &mcp.CallToolRequest{}, RepoMapArgument{Repository: "github.com/org/api", Path: "internal/http"})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "(1 file; go)") || !strings.Contains(text, "No subdirectories.") {
+		t.Errorf("Expected only internal/http, got: %s", text)
+	}
+}
+
+func TestRepoMapHandler_CapsDirectories(t *testing.T) {
+	var files []CatalogFile
+	for n := range maxMapDirectories + 5 {
+		files = append(files, CatalogFile{Path: fmt.Sprintf("pkg%03d/file.go", n), Language: "go"})
+	}
+	handler := NewRepoMapHandler(&mockMapService{files: map[string][]CatalogFile{"github.com_org_api": files}})
+
+	result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, RepoMapArgument{Repository: "github.com/org/api"})
+	text := ExtractTextContent(result)
+	if got := strings.Count(text, "\n- "); got != maxMapDirectories {
+		t.Errorf("Expected %d directories, got %d", maxMapDirectories, got)
+	}
+	if !strings.Contains(text, "5 more directories not shown") {
+		t.Errorf("Expected a note on omitted directories, got: %s", text[len(text)-200:])
+	}
+}
+
+func TestRepoMapHandler_Errors(t *testing.T) {
+	handler := NewRepoMapHandler(&mockMapService{files: map[string][]CatalogFile{
+		"github.com_org_api": {{Path: "main.go", Language: "go"}},
+	}})
+
+	tests := []struct {
+		name     string
+		args     RepoMapArgument
+		expected string
+	}{
+		{"empty repository", RepoMapArgument{}, "Repository cannot be empty"},
+		{"unknown repository", RepoMapArgument{Repository: "github.com/org/web"}, "Repository not found: github.com/org/web"},
+		{"unknown path", RepoMapArgument{Repository: "github.com/org/api", Path: "docs"}, "No indexed files in github.com/org/api/docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, tt.args)
+			if !result.IsError || !strings.Contains(ExtractTextContent(result), tt.expected) {
+				t.Errorf("Expected error %q, got: %s", tt.expected, ExtractTextContent(result))
+			}
+		})
+	}
+}
@@ -1,19 +1,37 @@
 package gitrepos
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"golang.org/x/text/unicode/norm"
 )
 
+// previewHeadShare is the percentage of a preview taken from the start of
+// the file; the rest comes from its end.
+const previewHeadShare = 75
+
 // ReadArgument defines read parameters.
 type ReadArgument struct {
 	Repository string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
 	Path       string `json:"path" jsonschema_description:"File path relative to repository root"`
+	Preview    bool   `json:"preview,omitempty" jsonschema_description:"For files over the size limit, return the beginning and end of the file instead of an error"`
+	Ref        string `json:"ref,omitempty" jsonschema_description:"Read the file from the snapshot of this tag or branch instead of the default branch; only refs configured on the server are available"`
+	StartLine  int    `json:"start_line,omitempty" jsonschema_description:"Return the file from this 1-based line on, e.g. the start of a declaration hit of search"`
+	EndLine    int    `json:"end_line,omitempty" jsonschema_description:"Return the file up to this 1-based line, inclusive"`
+
+	ConsistencyArgument
+	FormatArgument
 }
 
 // ReadHandler handles the read MCP tool.
@@ -30,150 +48,480 @@ func NewReadHandler(service ReadService) *ReadHandler {
 
 // Handle reads a file and returns formatted content.
 func (h *ReadHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args ReadArgument) (*mcp.CallToolResult, any, error) {
-	// Check if service is ready
-	if !h.service.IsReady() {
+	if result := scopeError(ctx, "read", config.ScopeRead); result != nil {
+		return result, nil, nil
+	}
+	return runRead(ctx, h.service, func(ctx context.Context) (*mcp.CallToolResult, any, error) {
+		return h.read(ctx, req, args)
+	})
+}
+
+// read reads a file within the limits applied by runRead.
+func (h *ReadHandler) read(ctx context.Context, req *mcp.CallToolRequest, args ReadArgument) (*mcp.CallToolResult, any, error) {
+	if err := validateLineRange(args); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: "Read is not available. The git repositories are still being indexed. Please try again later."},
+				&mcp.TextContent{Text: fmt.Sprintf("Invalid line range: %s", err)},
 			},
 			IsError: true,
 		}, nil, nil
 	}
+	target, result := resolveReadTarget(h.service, req, "Read", args.Repository, args.Path, args.Ref)
+	if result != nil {
+		return result, nil, nil
+	}
+	relPath, displayPath, fullPath, info, notice := target.relPath, target.displayPath, target.fullPath, target.info, target.notice
+	format := formatFrom(ctx)
+	commit := commitSuffix(h.service.IndexedCommit(DisplayToRepoID(args.Repository), args.Ref))
 
-	// Validate repository
-	if strings.TrimSpace(args.Repository) == "" {
+	// Check file size; plain text files may be previewed instead
+	maxFileSize := h.service.MaxFileSizeFor(relPath)
+	kind := detectArchive(displayPath)
+	tooLarge := info.Size() > maxFileSize
+	if tooLarge && (!args.Preview || kind != archiveNone) {
+		hint := ""
+		if kind == archiveNone {
+			hint = ". Set preview to see the beginning and end of the file"
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: "Repository cannot be empty"},
+				&mcp.TextContent{Text: fmt.Sprintf("File too large (%s). Maximum allowed size is %s%s", format.size(info.Size(), 2), format.size(maxFileSize, 2), hint)},
 			},
 			IsError: true,
 		}, nil, nil
 	}
 
-	// Validate path
-	if strings.TrimSpace(args.Path) == "" {
+	// Archives are listed rather than read
+	if kind == archiveZip || kind == archiveTar || kind == archiveTarGzip {
+		entries, truncated, err := listArchive(ctx, fullPath, kind)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Error reading archive: %s", readFailure(h.service, err))},
+				},
+				IsError: true,
+			}, nil, nil
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: "Path cannot be empty"},
+				&mcp.TextContent{Text: fmt.Sprintf("%s**%s** `%s`%s\n\n%s", notice, args.Repository, displayPath, commit, formatArchiveListing(entries, truncated, format))},
+			},
+		}, nil, nil
+	}
+
+	// Read file content, decompressing gzip files within the same size limit
+	var content []byte
+	var err error
+	if tooLarge {
+		var omitted int64
+		content, omitted, err = readPreview(ctx, fullPath, info.Size(), maxFileSize)
+		if err == nil {
+			notice += fmt.Sprintf("_Preview of a %s file: %s omitted from the middle_\n\n", format.size(info.Size(), 2), format.size(omitted, 2))
+		}
+	} else if kind == archiveGzip {
+		content, err = readGzip(ctx, fullPath, maxFileSize)
+		if errors.Is(err, ErrDecompressedTooLarge) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Decompressed file too large. Maximum allowed size is %s", format.size(maxFileSize, 2))},
+				},
+				IsError: true,
+			}, nil, nil
+		}
+		if err == nil {
+			notice += fmt.Sprintf("_Decompressed from gzip (%s to %s)_\n\n", format.size(info.Size(), 2), format.size(int64(len(content)), 2))
+		}
+	} else {
+		content, err = readFile(ctx, fullPath)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Error reading file: %s", readFailure(h.service, err))},
 			},
 			IsError: true,
 		}, nil, nil
 	}
 
-	// Validate path security
-	if err := validatePath(args.Path); err != nil {
+	// Check for binary content
+	if IsBinary(content) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Invalid path: %s", err)},
+				&mcp.TextContent{Text: "Cannot display binary file content"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// Mask sensitive values
+	content, redacted := h.service.Redact(content)
+	if redacted > 0 {
+		notice += fmt.Sprintf("_%d value(s) redacted by the server's read policy_\n\n", redacted)
+	}
+
+	// Cut the requested lines
+	if args.StartLine > 0 || args.EndLine > 0 {
+		lines, start, end, total, err := lineRange(content, args.StartLine, args.EndLine)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Invalid line range: %s", err)},
+				},
+				IsError: true,
+			}, nil, nil
+		}
+		content = lines
+		notice += fmt.Sprintf("_Lines %d-%d of %d_\n\n", start, end, total)
+	}
+
+	// Format result with language hint
+	langPath := displayPath
+	if kind == archiveGzip {
+		langPath = langPath[:len(langPath)-len(".gz")]
+	}
+	lang := extensionToLanguage(GetFileExtension(langPath))
+	var sb strings.Builder
+	sb.WriteString(notice)
+	sb.WriteString(fmt.Sprintf("**%s** `%s`%s\n\n", args.Repository, displayPath, commit))
+	sb.WriteString(fmt.Sprintf("```%s\n", lang))
+	sb.WriteString(string(content))
+	if !strings.HasSuffix(string(content), "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// validateLineRange checks the line range of a read before the file is
+// opened.
+func validateLineRange(args ReadArgument) error {
+	switch {
+	case args.StartLine < 0 || args.EndLine < 0:
+		return errors.New("lines are numbered from 1")
+	case args.EndLine > 0 && args.EndLine < args.StartLine:
+		return fmt.Errorf("end_line %d is before start_line %d", args.EndLine, args.StartLine)
+	case args.Preview && (args.StartLine > 0 || args.EndLine > 0):
+		return errors.New("a line range cannot be combined with preview")
+	}
+	return nil
+}
+
+// lineRange returns lines start to end of content, 1-based and inclusive,
+// with the range they cover and the number of lines of content. A start of
+// 0 reads from the first line and an end of 0, or past the last line, to the
+// last one.
+func lineRange(content []byte, start, end int) ([]byte, int, int, int, error) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	total := len(lines)
+	start = max(start, 1)
+	if start > total {
+		return nil, 0, 0, total, fmt.Errorf("start_line %d is past the end of the file (%d lines)", start, total)
+	}
+	if end == 0 || end > total {
+		end = total
+	}
+	return bytes.Join(lines[start-1:end], nil), start, end, total, nil
+}
+
+// readTarget is a file resolved by resolveReadTarget.
+type readTarget struct {
+	relPath     string // as found on disk, relative to the repository
+	displayPath string
+	fullPath    string
+	info        os.FileInfo
+	notice      string // markdown notice of a corrected path, if any
+}
+
+// repoNotReadyResult is the error result of a tool call on a repository
+// that is still syncing while others are already served.
+func repoNotReadyResult(repository string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("Repository %s is still being indexed. Please try again later.", repository)},
+		},
+		IsError: true,
+	}
+}
+
+// runRead runs read, the body of a tool that returns file contents, in a
+// read slot and bounded by the read timeout. Reads stop at the timeout, but
+// file system calls do not observe contexts, so read runs in its own
+// goroutine: a read stuck on a hung file system returns an error to the
+// client while the goroutine keeps its slot until the call returns. Stuck
+// reads are thus bounded by the concurrency limit rather than piling up.
+func runRead(ctx context.Context, service ReadService, read func(ctx context.Context) (*mcp.CallToolResult, any, error)) (*mcp.CallToolResult, any, error) {
+	release, err := service.AcquireRead(ctx)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Read not started: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	timeout := service.ReadTimeout()
+	readCtx, cancel := withTimeout(ctx, timeout)
+
+	type readResult struct {
+		result *mcp.CallToolResult
+		out    any
+		err    error
+	}
+	done := make(chan readResult, 1)
+	go func() {
+		defer release()
+		defer cancel()
+		result, out, err := read(readCtx)
+		done <- readResult{result, out, err}
+	}()
+
+	// Cancelled reads are left to stop on their own, like reads that return
+	// at the timeout
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case r := <-done:
+		return r.result, r.out, r.err
+	case <-expired:
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Read did not finish within %s", timeout)},
 			},
 			IsError: true,
 		}, nil, nil
 	}
+}
+
+// readFailure describes an error reading a file, naming the read timeout
+// when it expired.
+func readFailure(service ReadService, err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("the read did not finish within %s", service.ReadTimeout())
+	}
+	return err.Error()
+}
+
+// resolveReadTarget applies the checks of the tools that return file content
+// to a requested file: readiness, path validation, and the symlink, read and
+// indexed-only policies. It returns the error result of a file that cannot
+// be read; action names the tool in the not-ready message.
+func resolveReadTarget(service ReadService, req *mcp.CallToolRequest, action, repository, path, ref string) (*readTarget, *mcp.CallToolResult) {
+	// Check if service is ready
+	if !service.IsReady() {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: action + " is not available. The git repositories are still being indexed. Please try again later."},
+			},
+			IsError: true,
+		}
+	}
+
+	// Validate repository
+	if strings.TrimSpace(repository) == "" {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Repository cannot be empty"},
+			},
+			IsError: true,
+		}
+	}
+
+	// Validate path
+	if strings.TrimSpace(path) == "" {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Path cannot be empty"},
+			},
+			IsError: true,
+		}
+	}
+
+	// Validate path security
+	if err := validatePath(path); err != nil {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Invalid path: %s", err)},
+			},
+			IsError: true,
+		}
+	}
 
 	// Convert repository to repo ID
-	repoID := DisplayToRepoID(args.Repository)
-	repoDir := h.service.GetRepoDir(repoID)
+	repoID := DisplayToRepoID(repository)
+	if !service.IsRepoReady(repoID) {
+		return nil, repoNotReadyResult(repository)
+	}
+	if ref != "" {
+		repoID = RefSnapshotID(repoID, ref)
+	}
+	repoDir := service.GetRepoDir(repoID)
+	service.Telemetry().RecordRead(sessionID(req), telemetryKey(repoID, filepath.ToSlash(filepath.Clean(path))))
 
 	// Check if repo directory exists
 	if _, err := os.Stat(repoDir); os.IsNotExist(err) {
-		return &mcp.CallToolResult{
+		text := fmt.Sprintf("Repository not found: %s", repository)
+		if ref != "" {
+			text = fmt.Sprintf("No snapshot of ref %s found for repository %s", ref, repository)
+		}
+		return nil, &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Repository not found: %s", args.Repository)},
+				&mcp.TextContent{Text: text},
 			},
 			IsError: true,
-		}, nil, nil
+		}
 	}
 
 	// Build full path
-	fullPath := filepath.Join(repoDir, filepath.Clean(args.Path))
+	fullPath := filepath.Join(repoDir, filepath.Clean(path))
 
 	// Security check: ensure the path is within repo directory
 	if !strings.HasPrefix(fullPath, repoDir) {
-		return &mcp.CallToolResult{
+		return nil, &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: "Path traversal detected"},
 			},
 			IsError: true,
-		}, nil, nil
+		}
+	}
+
+	// Apply the symlink policy; other errors are reported below
+	relPath := filepath.Clean(path)
+	displayPath := path
+	resolved, err := resolveRepoPath(repoDir, relPath, service.FollowSymlinks())
+
+	// Fall back to the on-disk spelling when the path differs only in letter
+	// case or Unicode normalization (e.g. paths typed on macOS)
+	var notice string
+	if os.IsNotExist(err) {
+		if canonical, ok := findCanonicalPath(repoDir, relPath); ok {
+			relPath = canonical
+			displayPath = filepath.ToSlash(canonical)
+			fullPath = filepath.Join(repoDir, relPath)
+			notice = fmt.Sprintf("_Path `%s` resolved to `%s`_\n\n", path, displayPath)
+			resolved, err = resolveRepoPath(repoDir, relPath, service.FollowSymlinks())
+		}
+	}
+
+	if errors.Is(err, ErrSymlinkNotFollowed) || errors.Is(err, ErrSymlinkEscapesRepo) {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Cannot read %s: %s", path, err)},
+			},
+			IsError: true,
+		}
+	}
+	if err == nil {
+		fullPath = resolved
+	}
+
+	if readDenied(service, repoDir, relPath, fullPath) {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Reading %s is not allowed by the server's read policy", path)},
+			},
+			IsError: true,
+		}
+	}
+
+	// In strict mode, only serve what search can find
+	if service.ReadIndexedOnly() && !service.IsIndexed(repoID, relPath) {
+		return nil, &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Cannot read %s: the file is not indexed and this server only serves indexed files", path)},
+			},
+			IsError: true,
+		}
 	}
 
 	// Check if file exists
 	info, err := os.Stat(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &mcp.CallToolResult{
+			return nil, &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: fmt.Sprintf("File not found: %s", args.Path)},
+					&mcp.TextContent{Text: fmt.Sprintf("File not found: %s", path)},
 				},
 				IsError: true,
-			}, nil, nil
+			}
 		}
-		return &mcp.CallToolResult{
+		return nil, &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: fmt.Sprintf("Error accessing file: %s", err)},
 			},
 			IsError: true,
-		}, nil, nil
+		}
 	}
 
 	// Check if it's a directory
 	if info.IsDir() {
-		return &mcp.CallToolResult{
+		return nil, &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: "Cannot read directory, please specify a file path"},
 			},
 			IsError: true,
-		}, nil, nil
+		}
 	}
 
-	// Check file size
-	maxFileSize := h.service.MaxFileSize()
-	if info.Size() > maxFileSize {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("File too large (%.2f KB). Maximum allowed size is %.2f KB", float64(info.Size())/1024, float64(maxFileSize)/1024)},
-			},
-			IsError: true,
-		}, nil, nil
-	}
+	return &readTarget{
+		relPath:     relPath,
+		displayPath: displayPath,
+		fullPath:    fullPath,
+		info:        info,
+		notice:      notice,
+	}, nil
+}
 
-	// Read file content
-	content, err := os.ReadFile(fullPath)
+// readPreview returns the beginning and end of a file of the given size,
+// together about limit bytes, cut at line boundaries, and the number of bytes
+// left out in between.
+func readPreview(ctx context.Context, path string, size, limit int64) ([]byte, int64, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Error reading file: %s", err)},
-			},
-			IsError: true,
-		}, nil, nil
+		return nil, 0, err
 	}
+	defer func() { _ = f.Close() }()
 
-	// Check for binary content
-	if IsBinary(content) {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: "Cannot display binary file content"},
-			},
-			IsError: true,
-		}, nil, nil
+	headSize := limit * previewHeadShare / 100
+	tailSize := limit - headSize
+
+	head := make([]byte, headSize)
+	if _, err := io.ReadFull(&contextReader{ctx: ctx, r: f}, head); err != nil {
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	tail := make([]byte, tailSize)
+	if _, err := f.ReadAt(tail, size-tailSize); err != nil && err != io.EOF {
+		return nil, 0, err
 	}
 
-	// Format result with language hint
-	lang := extensionToLanguage(GetFileExtension(args.Path))
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("**%s** `%s`\n\n", args.Repository, args.Path))
-	sb.WriteString(fmt.Sprintf("```%s\n", lang))
-	sb.WriteString(string(content))
-	if !strings.HasSuffix(string(content), "\n") {
-		sb.WriteString("\n")
+	// Drop the partial lines at the cut points
+	if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	}
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
 	}
-	sb.WriteString("```\n")
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: sb.String()},
-		},
-	}, nil, nil
+	omitted := size - int64(len(head)) - int64(len(tail))
+	var preview bytes.Buffer
+	preview.Write(head)
+	preview.WriteString(fmt.Sprintf("\n... %d bytes omitted ...\n\n", omitted))
+	preview.Write(tail)
+	return preview.Bytes(), omitted, nil
 }
 
 // validatePath performs security validation on the path.
@@ -194,6 +542,62 @@ func validatePath(path string) error {
 	return nil
 }
 
+// readDenied applies the read policy to the file at relPath, resolved to
+// fullPath, under repoDir. The path is checked as given, as spelled on disk
+// and, for symlinks, as their target, so that neither a different letter case
+// on a case-insensitive file system nor a link gets around a deny pattern.
+func readDenied(service ReadService, repoDir, relPath, fullPath string) bool {
+	paths := []string{relPath}
+	if canonical, ok := findCanonicalPath(repoDir, relPath); ok {
+		paths = append(paths, canonical)
+	}
+	if root, err := filepath.EvalSymlinks(repoDir); err == nil {
+		if target, err := filepath.Rel(root, fullPath); err == nil {
+			paths = append(paths, target)
+			if canonical, ok := findCanonicalPath(root, target); ok {
+				paths = append(paths, canonical)
+			}
+		}
+	}
+	return slices.ContainsFunc(paths, service.ReadDenied)
+}
+
+// findCanonicalPath looks up relPath inside repoDir ignoring letter case and
+// Unicode normalization form, and returns the path as spelled on disk. An
+// exact match always wins; otherwise each component must match exactly one
+// directory entry.
+func findCanonicalPath(repoDir, relPath string) (string, bool) {
+	dir := repoDir
+	var parts []string
+
+	for _, name := range strings.Split(filepath.ToSlash(relPath), "/") {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", false
+		}
+
+		want := norm.NFC.String(name)
+		var matches []string
+		for _, entry := range entries {
+			if entry.Name() == name {
+				matches = []string{name}
+				break
+			}
+			if strings.EqualFold(norm.NFC.String(entry.Name()), want) {
+				matches = append(matches, entry.Name())
+			}
+		}
+		if len(matches) != 1 {
+			return "", false
+		}
+
+		parts = append(parts, matches[0])
+		dir = filepath.Join(dir, matches[0])
+	}
+
+	return filepath.Join(parts...), true
+}
+
 // extensionToLanguage maps file extension to language hint for code blocks.
 func extensionToLanguage(ext string) string {
 	langMap := map[string]string{
@@ -258,7 +662,13 @@ WHEN TO USE: Use after search to retrieve the complete file content,
 or when you know the exact repository and file path you need to read.
 
 HOW IT WORKS: Provide the repository name and file path. Returns the full
-file content with syntax highlighting hints based on file extension.`,
+file content with syntax highlighting hints based on file extension. Gzip files
+(e.g. fixture.sql.gz) are decompressed within the size limit; zip and tar
+archives return a listing of their members. Set ref to read the file from a
+tag or branch snapshot, as returned by a search with the same ref. The header
+ends with the commit the repository was indexed at (e.g. @ 3f2a9c1d8e7b).
+Set start_line and/or end_line to read only those lines, e.g. a declaration
+named by a search hit.`,
 	}
 }
 
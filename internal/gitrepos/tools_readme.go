@@ -0,0 +1,219 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// readmeDirs are the directories searched for a README, in order. These are
+// the locations GitHub renders a repository README from.
+var readmeDirs = []string{"", ".github", "docs"}
+
+// readmeNames are the preferred README file names, in order. Any other file
+// whose name starts with "readme" is used as a last resort.
+var readmeNames = []string{"readme.md", "readme.markdown", "readme.rst", "readme.adoc", "readme.txt", "readme"}
+
+// ReadmeArgument defines get_readme parameters.
+type ReadmeArgument struct {
+	Repository string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// ReadmeHandler handles the get_readme MCP tool.
+type ReadmeHandler struct {
+	service ReadService
+}
+
+// NewReadmeHandler creates a new README handler.
+func NewReadmeHandler(service ReadService) *ReadmeHandler {
+	return &ReadmeHandler{
+		service: service,
+	}
+}
+
+// Handle locates the repository README and returns its content.
+func (h *ReadmeHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args ReadmeArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "get_readme", config.ScopeRead); result != nil {
+		return result, nil, nil
+	}
+	return runRead(ctx, h.service, func(ctx context.Context) (*mcp.CallToolResult, any, error) {
+		return h.readme(ctx, req, args)
+	})
+}
+
+// readme locates and reads the README within the limits applied by runRead.
+// The README found is served under the same read policy as read.
+func (h *ReadmeHandler) readme(ctx context.Context, req *mcp.CallToolRequest, args ReadmeArgument) (*mcp.CallToolResult, any, error) {
+	// Check if service is ready
+	if !h.service.IsReady() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "README lookup is not available. The git repositories are still being indexed. Please try again later."},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// Validate repository
+	if strings.TrimSpace(args.Repository) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Repository cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	repoID := DisplayToRepoID(args.Repository)
+	if !h.service.IsRepoReady(repoID) {
+		return repoNotReadyResult(args.Repository), nil, nil
+	}
+
+	repoDir := h.service.GetRepoDir(repoID)
+	if _, err := os.Stat(repoDir); os.IsNotExist(err) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Repository not found: %s", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	relPath, _, ok := findReadme(repoDir, h.service.FollowSymlinks())
+	if !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("No README found in %s", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	target, result := resolveReadTarget(h.service, req, "README lookup", args.Repository, filepath.ToSlash(relPath), "")
+	if result != nil {
+		return result, nil, nil
+	}
+
+	// Large READMEs are truncated rather than rejected; the beginning is
+	// what matters for orientation
+	maxFileSize := h.service.MaxFileSizeFor(target.relPath)
+	truncated := target.info.Size() > maxFileSize
+	content, err := readFileHead(ctx, target.fullPath, maxFileSize)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Error reading file: %s", readFailure(h.service, err))},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	if IsBinary(content) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Cannot display binary file content"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// Mask sensitive values
+	notice := target.notice
+	content, redacted := h.service.Redact(content)
+	if redacted > 0 {
+		notice += fmt.Sprintf("_%d value(s) redacted by the server's read policy_\n\n", redacted)
+	}
+
+	displayPath := filepath.ToSlash(target.relPath)
+	var sb strings.Builder
+	sb.WriteString(notice)
+	sb.WriteString(fmt.Sprintf("**%s** `%s`\n\n", args.Repository, displayPath))
+	sb.WriteString(fmt.Sprintf("```%s\n", extensionToLanguage(GetFileExtension(displayPath))))
+	sb.WriteString(string(content))
+	if !strings.HasSuffix(string(content), "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n_Truncated to the first %d bytes; use `read` with path `%s` for specific sections._\n", maxFileSize, displayPath))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// findReadme returns the relative and resolved path of the README in
+// repoDir. Candidates are subject to the same symlink policy as read.
+func findReadme(repoDir string, followSymlinks bool) (relPath, fullPath string, ok bool) {
+	for _, dir := range readmeDirs {
+		entries, err := os.ReadDir(filepath.Join(repoDir, dir))
+		if err != nil {
+			continue
+		}
+
+		byName := make(map[string]string, len(entries))
+		var others []string
+		for _, entry := range entries {
+			lower := strings.ToLower(entry.Name())
+			if !strings.HasPrefix(lower, "readme") {
+				continue
+			}
+			if _, exists := byName[lower]; !exists {
+				byName[lower] = entry.Name()
+			}
+			others = append(others, entry.Name())
+		}
+
+		var candidates []string
+		for _, name := range readmeNames {
+			if actual, exists := byName[name]; exists {
+				candidates = append(candidates, actual)
+			}
+		}
+		candidates = append(candidates, others...)
+
+		for _, name := range candidates {
+			rel := filepath.Join(dir, name)
+			resolved, err := resolveRepoPath(repoDir, rel, followSymlinks)
+			if err != nil {
+				continue
+			}
+			if info, err := os.Stat(resolved); err != nil || info.IsDir() {
+				continue
+			}
+			return rel, resolved, true
+		}
+	}
+	return "", "", false
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *ReadmeHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "get_readme",
+		Description: `Get the README of an indexed git repository.
+
+WHEN TO USE: Use first when starting work with an unfamiliar repository to
+learn its purpose, layout, and build instructions before searching.
+
+HOW IT WORKS: Provide the repository name. Returns the README from the
+repository root, .github or docs directory, preferring Markdown.`,
+	}
+}
+
+// RegisterReadmeTool registers the get_readme tool with an MCP server.
+func RegisterReadmeTool(server *mcp.Server, service ReadService) {
+	handler := NewReadmeHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
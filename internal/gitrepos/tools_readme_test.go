@@ -0,0 +1,169 @@
+package gitrepos
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestReadmeHandler_NotReady(t *testing.T) {
+	handler := NewReadmeHandler(&mockReadService{ready: false})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result when service not ready")
+	}
+}
+
+func TestReadmeHandler_EmptyRepository(t *testing.T) {
+	handler := NewReadmeHandler(&mockReadService{ready: true})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: " "})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for empty repository")
+	}
+}
+
+func TestReadmeHandler_NonExistentRepository(t *testing.T) {
+	handler := NewReadmeHandler(&mockReadService{ready: true, repoDir: filepath.Join(t.TempDir(), "missing")})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "Repository not found") {
+		t.Errorf("Expected repository not found error, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestReadmeHandler_FindsReadme(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"root markdown", map[string]string{"README.md": "# Root", "readme.txt": "text"}, "README.md"},
+		{"preferred extension", map[string]string{"README.rst": "rst", "Readme.markdown": "# md"}, "Readme.markdown"},
+		{"no extension", map[string]string{"README": "plain"}, "README"},
+		{"other extension", map[string]string{"README.ko.md": "# ko"}, "README.ko.md"},
+		{"github directory", map[string]string{".github/README.md": "# GitHub", "docs/README.md": "# Docs"}, ".github/README.md"},
+		{"docs directory", map[string]string{"docs/readme.md": "# Docs"}, "docs/readme.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir := t.TempDir()
+			for path, content := range tt.files {
+				writeTestFile(t, repoDir, path, content)
+			}
+			handler := NewReadmeHandler(&mockReadService{ready: true, repoDir: repoDir, maxFileSize: 256 * 1024})
+
+			result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			content := ExtractTextContent(result)
+			if result.IsError {
+				t.Fatalf("Expected success, got error: %s", content)
+			}
+			if !strings.Contains(content, "`"+tt.want+"`") || !strings.Contains(content, tt.files[tt.want]) {
+				t.Errorf("Expected %s, got: %s", tt.want, content)
+			}
+		})
+	}
+}
+
+func TestReadmeHandler_NoReadme(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "main.go", "package main")
+	handler := NewReadmeHandler(&mockReadService{ready: true, repoDir: repoDir, maxFileSize: 256 * 1024})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "No README found") {
+		t.Errorf("Expected no README error, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestReadmeHandler_SkipsSymlinkedReadme(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	writeTestFile(t, dir, "secret.md", "password")
+	writeTestFile(t, repoDir, "docs/README.md", "# Docs")
+	createTestSymlink(t, filepath.Join(dir, "secret.md"), filepath.Join(repoDir, "README.md"))
+	handler := NewReadmeHandler(&mockReadService{ready: true, repoDir: repoDir, maxFileSize: 256 * 1024, symlinks: true})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	content := ExtractTextContent(result)
+	if strings.Contains(content, "password") || !strings.Contains(content, "# Docs") {
+		t.Errorf("Expected the docs README, got: %s", content)
+	}
+}
+
+func TestReadmeHandler_TruncatesLargeReadme(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "README.md", "# Title\n"+strings.Repeat("x", 100))
+	handler := NewReadmeHandler(&mockReadService{ready: true, repoDir: repoDir, maxFileSize: 16})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	content := ExtractTextContent(result)
+	if result.IsError || !strings.Contains(content, "# Title") || !strings.Contains(content, "Truncated to the first 16 bytes") {
+		t.Errorf("Expected truncated README, got: %s", content)
+	}
+}
+
+func TestReadmeHandler_AppliesReadPolicy(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "README.md", "# Title\ntoken=abc123\n")
+
+	tests := []struct {
+		name     string
+		service  *mockReadService
+		expected string
+	}{
+		{"denied", &mockReadService{deny: []string{"*.md"}}, "not allowed by the server's read policy"},
+		{"not indexed", &mockReadService{indexedOnly: true}, "the file is not indexed"},
+		{"redacted", &mockReadService{redactions: []*regexp.Regexp{regexp.MustCompile(`token=\w+`)}}, "1 value(s) redacted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.service.ready, tt.service.repoDir, tt.service.maxFileSize = true, repoDir, 256*1024
+			result, _, err := NewReadmeHandler(tt.service).Handle(context.Background(), &mcp.CallToolRequest{}, ReadmeArgument{Repository: "github.com/test/repo"})
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			content := ExtractTextContent(result)
+			if strings.Contains(content, "abc123") || !strings.Contains(content, tt.expected) {
+				t.Errorf("Expected %q without the secret, got: %s", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestReadmeHandler_GetToolDefinition(t *testing.T) {
+	tool := NewReadmeHandler(&mockReadService{}).GetToolDefinition()
+	if tool.Name != "get_readme" {
+		t.Errorf("Expected tool name 'get_readme', got '%s'", tool.Name)
+	}
+	if tool.Description == "" {
+		t.Error("Expected non-empty description")
+	}
+}
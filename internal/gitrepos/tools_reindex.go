@@ -0,0 +1,84 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// ReindexArgument defines reindex parameters.
+type ReindexArgument struct {
+	Repository string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// ReindexHandler handles the reindex MCP tool.
+type ReindexHandler struct {
+	service ReindexService
+}
+
+// NewReindexHandler creates a new reindex handler.
+func NewReindexHandler(service ReindexService) *ReindexHandler {
+	return &ReindexHandler{
+		service: service,
+	}
+}
+
+// Handle rebuilds the index of the requested repository.
+func (h *ReindexHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args ReindexArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "reindex", config.ScopeAdmin); result != nil {
+		return result, nil, nil
+	}
+
+	// Validate repository
+	if strings.TrimSpace(args.Repository) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Repository cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	if err := h.service.Reindex(ctx, args.Repository); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Reindex failed: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("Rebuilt the index of %s", args.Repository)},
+		},
+	}, nil, nil
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *ReindexHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "reindex",
+		Description: `Delete and fully rebuild the search index of one repository.
+
+WHEN TO USE: Administrative operation. Use only when search results for a
+repository are clearly stale or missing files that exist, or when asked to.
+Normal updates happen automatically on sync.
+
+HOW IT WORKS: Rebuilds the index from the repository's current checkout,
+ignoring the recorded sync state. Search is unavailable until it finishes,
+which can take minutes for large repositories.`,
+	}
+}
+
+// RegisterReindexTool registers the reindex tool with an MCP server.
+func RegisterReindexTool(server *mcp.Server, service ReindexService) {
+	handler := NewReindexHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
@@ -0,0 +1,52 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// mockReindexService implements ReindexService for handler tests.
+type mockReindexService struct {
+	reindexed []string
+	err       error
+}
+
+func (m *mockReindexService) Reindex(_ context.Context, repository string) error {
+	m.reindexed = append(m.reindexed, repository)
+	return m.err
+}
+
+func TestReindexHandler_Success(t *testing.T) {
+	svc := &mockReindexService{}
+	handler := NewReindexHandler(svc)
+
+	result, _, err := handler.Handle(adminContext(), &mcp.CallToolRequest{}, ReindexArgument{Repository: "github.com/org/repo"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if result.IsError || !strings.Contains(ExtractTextContent(result), "Rebuilt the index of github.com/org/repo") {
+		t.Errorf("Unexpected result: %s", ExtractTextContent(result))
+	}
+	if len(svc.reindexed) != 1 || svc.reindexed[0] != "github.com/org/repo" {
+		t.Errorf("Unexpected reindex calls: %v", svc.reindexed)
+	}
+}
+
+func TestReindexHandler_Errors(t *testing.T) {
+	svc := &mockReindexService{err: errors.New("repository not configured: x")}
+	handler := NewReindexHandler(svc)
+
+	result, _, _ := handler.Handle(adminContext(), &mcp.CallToolRequest{}, ReindexArgument{Repository: " "})
+	if !result.IsError || len(svc.reindexed) != 0 {
+		t.Error("Expected empty repository to be rejected without reindexing")
+	}
+
+	result, _, _ = handler.Handle(adminContext(), &mcp.CallToolRequest{}, ReindexArgument{Repository: "x"})
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "not configured") {
+		t.Errorf("Expected service error, got: %s", ExtractTextContent(result))
+	}
+}
@@ -2,21 +2,137 @@ package gitrepos
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/blevesearch/bleve/v2"
-	_ "github.com/blevesearch/bleve/v2/search/highlight/highlighter/ansi"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/plain"
+	simpleFragmenter "github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
 	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
 	"github.com/sha1n/mcp-relic-server/internal/domain"
 )
 
+// Fragments are highlighted with control-character placeholders which are
+// replaced by the configured tags when results are formatted. Bleve resolves
+// highlighters from a process-wide registry, so the tags themselves cannot
+// vary per request.
+const (
+	highlightStyle = "relic"
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// Query tuning, also documented by the query syntax resource
+const (
+	contentFuzziness = 1   // edit distance tolerated in content terms unless whole_word is set
+	symbolsBoost     = 5.0 // score multiplier for matches on declared symbol names
+	symbolPartsBoost = 2.0 // score multiplier for matches on words of symbol names, e.g. "server" in HTTPServerConfig
+
+	// Multi-word queries, e.g. "Service Initialize", also score higher when
+	// the words appear in order in the content, or as a qualified name
+	// (Service.Initialize, a single token of the standard analyzer), when a
+	// symbol is declared for every word, and when the words joined name a
+	// symbol (ServiceInitialize, service_initialize)
+	phraseBoost       = 3.0
+	allSymbolsBoost   = 2 * symbolsBoost
+	joinedSymbolBoost = symbolsBoost
+
+	// keyTermPrefix marks a query term as a YAML/JSON key path
+	keyTermPrefix = "key:"
+)
+
+// Guardrails against queries that scan or expand to large parts of an index
+const (
+	minWildcardPrefix = 2                // literal characters required before the first * of a key pattern
+	maxQueryExpansion = 4096             // terms a wildcard or fuzzy word may expand to
+	queryTimeBudget   = 10 * time.Second // default time a single search may run
+)
+
+func init() {
+	err := registry.RegisterHighlighter(highlightStyle, func(_ map[string]interface{}, cache *registry.Cache) (highlight.Highlighter, error) {
+		fragmenter, err := cache.FragmenterNamed(simpleFragmenter.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error building fragmenter: %w", err)
+		}
+		formatter := plain.NewFragmentFormatter(highlightStart, highlightEnd)
+		return simpleHighlighter.NewHighlighter(fragmenter, formatter, simpleHighlighter.DefaultSeparator), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Bleve fails searches whose terms expand beyond this limit
+	searcher.DisjunctionMaxClauseCount = maxQueryExpansion
+}
+
 // SearchArgument defines search parameters.
 type SearchArgument struct {
-	Query      string `json:"query" jsonschema_description:"Search query. Use natural language or keywords."`
-	Repository string `json:"repository,omitempty" jsonschema_description:"Filter by repository name (substring match)"`
-	Extension  string `json:"extension,omitempty" jsonschema_description:"Filter by file extension (e.g., 'go', 'py', 'java')"`
+	Query      string        `json:"query" jsonschema_description:"Search query. Use natural language or keywords. Add key:path.to.setting to find a YAML/JSON key (* matches any characters)."`
+	Repository string        `json:"repository,omitempty" jsonschema_description:"Filter by repository name (substring match)"`
+	Extension  ExtensionList `json:"extension,omitempty" jsonschema_description:"Filter by file extension (e.g., 'go', 'py', 'java') or extension group (e.g., 'js' for js, jsx, mjs, cjs, ts and tsx; 'c++' for cc, cpp, h, hpp and similar). Several may be given as an array or comma-separated, e.g. 'go,proto'"`
+
+	CaseSensitive bool `json:"case_sensitive,omitempty" jsonschema_description:"Match letter case exactly, e.g. 'UserID' does not match 'userid'"`
+	WholeWord     bool `json:"whole_word,omitempty" jsonschema_description:"Match complete words only, without fuzzy or partial matches"`
+
+	IncludeGenerated bool `json:"include_generated,omitempty" jsonschema_description:"Include generated files (e.g. 'Code generated ... DO NOT EDIT' headers), which are excluded by default"`
+
+	Ref string `json:"ref,omitempty" jsonschema_description:"Search the snapshot of this tag or branch instead of the default branch; only refs configured on the server are indexed. Several comma-separated refs are searched together, HEAD being the default branch, e.g. HEAD,v1.0.0"`
+
+	Directories bool `json:"directories,omitempty" jsonschema_description:"Search directories instead of files: matches directory paths and the names of the files and subdirectories they contain, and returns the file count and languages of each directory"`
+
+	RequireFresh bool `json:"require_fresh,omitempty" jsonschema_description:"Check the remote of the repositories matching the repository filter for newer commits before searching, and warn if the index lags behind. Takes up to a few seconds; requires repository"`
+
+	Profile string `json:"profile,omitempty" jsonschema_description:"Ranking profile: 'default', 'definitions-first' (declarations first, no documentation), 'docs-first' (documentation first) or 'recent-first' (recently modified files first). Defaults to the profile of the API key or the server"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// ExtensionList is the extension filter of a search: extensions or extension
+// groups, any of which a file may match. In JSON it is a string, which may be
+// comma-separated, or an array of strings.
+type ExtensionList []string
+
+// UnmarshalJSON accepts a single string as well as an array.
+func (l *ExtensionList) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*l = ExtensionList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("extension must be a string or an array of strings")
+	}
+	*l = list
+	return nil
+}
+
+// values returns the listed extensions with comma-separated entries split
+// and leading dots removed.
+func (l ExtensionList) values() []string {
+	var exts []string
+	for _, entry := range l {
+		for _, ext := range strings.Split(entry, ",") {
+			if ext = strings.TrimPrefix(strings.TrimSpace(ext), "."); ext != "" {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts
 }
 
 // SearchHandler handles the search MCP tool.
@@ -33,6 +149,10 @@ func NewSearchHandler(service SearchService) *SearchHandler {
 
 // Handle executes the search and returns formatted results.
 func (h *SearchHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args SearchArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "search", config.ScopeSearch); result != nil {
+		return result, nil, nil
+	}
+
 	// Check if service is ready
 	if !h.service.IsReady() {
 		return &mcp.CallToolResult{
@@ -53,8 +173,43 @@ func (h *SearchHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, ar
 		}, nil, nil
 	}
 
-	// Get index alias
-	alias, err := h.service.GetIndexAlias()
+	keys, _ := splitKeyTerms(args.Query)
+	if err := checkKeyPatterns(keys); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Query too broad: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	profileName, profile, err := h.rankingProfileOf(ctx, args.Profile)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Invalid ranking profile: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	if args.RequireFresh && (strings.TrimSpace(args.Repository) == "" || args.Ref != "") {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "require_fresh needs a repository filter and cannot be combined with ref"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	var freshness string
+	if args.RequireFresh {
+		freshness = freshnessNotice(h.service.CheckFreshness(ctx, args.Repository))
+	}
+
+	// Get index alias; ref snapshots are opened for this search only. The
+	// alias stays open until released, even when indexing replaces it.
+	refs := parseRefs(args.Ref)
+	alias, releaseAlias, err := h.acquireAlias(refs)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -64,48 +219,230 @@ func (h *SearchHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, ar
 		}, nil, nil
 	}
 
+	defer releaseAlias()
+
 	// Build query
-	searchQuery := h.buildQuery(args)
+	searchQuery := h.buildQuery(args, profile)
 
 	// Create search request
 	searchReq := bleve.NewSearchRequest(searchQuery)
 	searchReq.Size = h.service.MaxResults()
-	searchReq.Fields = []string{domain.CodeFieldRepository, domain.CodeFieldFilePath, domain.CodeFieldExtension, domain.CodeFieldContent}
-	searchReq.Highlight = bleve.NewHighlightWithStyle("ansi")
+	if len(refs) > 1 {
+		// Room for the copies of hits folded into one
+		searchReq.Size *= len(refs)
+	}
+	searchReq.Fields = []string{domain.CodeFieldRepository, domain.CodeFieldFilePath, domain.CodeFieldExtension, domain.CodeFieldContent,
+		domain.CodeFieldDeclaration, domain.CodeFieldStartLine, domain.CodeFieldEndLine}
+	if args.Directories {
+		searchReq.Fields = append(searchReq.Fields, domain.CodeFieldFileCount, domain.CodeFieldSubdirCount, domain.CodeFieldLanguages)
+	}
+	searchReq.Highlight = bleve.NewHighlightWithStyle(highlightStyle)
 	searchReq.Highlight.AddField(domain.CodeFieldContent)
 
-	// Execute search
-	results, err := alias.Search(searchReq)
+	// Wait for a search slot
+	release, err := h.service.AcquireSearch(ctx)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Search not started: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	defer release()
+
+	// Execute search within the time budget
+	timeout := h.service.SearchTimeout()
+	searchCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	results, err := streamingAlias(ctx, req, alias, h.service).SearchInContext(searchCtx, searchReq)
 	if err != nil {
+		text := fmt.Sprintf("Search failed: %s", err)
+		if ctx.Err() != nil {
+			text = "Search cancelled"
+		} else if reason := tooBroad(searchCtx, err, timeout); reason != "" {
+			text = fmt.Sprintf("Query too broad: %s. Use more specific words, a longer key prefix, or the repository and extension filters.", reason)
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Search failed: %s", err)},
+				&mcp.TextContent{Text: text},
 			},
 			IsError: true,
 		}, nil, nil
 	}
 
+	// Search returns file content, so the read policy applies to its hits
+	dropDeniedHits(results, h.service)
+	h.recordTelemetry(req, args, results)
+	if experiment := h.service.QueryExperiment(); experiment != "" && profileName == config.RankingProfileDefault {
+		h.runQueryExperiment(ctx, alias, args, results, experiment)
+	}
+
 	// Format results
-	return h.formatResults(results, args.Query), nil, nil
+	format := formatFrom(ctx)
+	pre, post := h.service.HighlightTags()
+	if format.Plain {
+		pre, post = "", ""
+	}
+	tags := strings.NewReplacer(highlightStart, pre, highlightEnd, post)
+	result := h.formatResults(results, args.Query, refs, tags, format.snippetWidth(0))
+	text := result.Content[0].(*mcp.TextContent)
+	text.Text = freshness + profileNotice(profileName, profile, h.service) + text.Text
+	if pending := h.service.PendingRepos(); len(pending) > 0 && (len(refs) == 0 || slices.Contains(refs, DefaultBranchRef)) {
+		// Early results while repositories are still syncing
+		repos := make([]string, len(pending))
+		for i, repoID := range pending {
+			repos[i] = RepoIDToDisplay(repoID)
+		}
+		text.Text = fmt.Sprintf("_Still being indexed and not searched yet: %s_\n\n", strings.Join(repos, ", ")) + text.Text
+	}
+	return result, nil, nil
 }
 
-// buildQuery constructs a Bleve query from search arguments.
-func (h *SearchHandler) buildQuery(args SearchArgument) query.Query {
-	// Content query
-	contentQuery := bleve.NewMatchQuery(args.Query)
-	contentQuery.SetField(domain.CodeFieldContent)
-	contentQuery.SetFuzziness(1)
+// acquireAlias returns the alias over the indexes of refs, or of the default
+// branch if there are none, and the function that releases it.
+func (h *SearchHandler) acquireAlias(refs []string) (bleve.IndexAlias, func(), error) {
+	if len(refs) == 0 {
+		return h.service.AcquireIndexAlias()
+	}
 
-	// Symbols query with boost
-	symbolsQuery := bleve.NewMatchQuery(args.Query)
-	symbolsQuery.SetField(domain.CodeFieldSymbols)
-	symbolsQuery.SetBoost(5.0)
+	var aliases []bleve.Index
+	var releases []func()
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	for _, ref := range refs {
+		if ref == DefaultBranchRef {
+			alias, releaseAlias, err := h.service.AcquireIndexAlias()
+			if err != nil {
+				release()
+				return nil, nil, err
+			}
+			aliases = append(aliases, alias)
+			releases = append(releases, releaseAlias)
+			continue
+		}
+		alias, err := h.service.RefAlias(ref)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		aliases = append(aliases, alias)
+		releases = append(releases, func() { _ = alias.Close() })
+	}
+	if len(aliases) == 1 {
+		return aliases[0].(bleve.IndexAlias), release, nil
+	}
+	return bleve.NewIndexAlias(aliases...), release, nil
+}
 
-	// Combined search query (Disjunction - OR)
-	searchQuery := bleve.NewDisjunctionQuery(contentQuery, symbolsQuery)
+// profileNotice names the ranking profile of a search, unless it is the
+// default.
+func profileNotice(name string, profile rankingProfile, service SearchService) string {
+	switch {
+	case name == config.RankingProfileDefault:
+		return ""
+	case profile.preferRecent && len(service.RecentFiles()) == 0:
+		return fmt.Sprintf("_Ranked with the %s profile, but no recently modified files are known: the history index is off or not built yet_\n\n", name)
+	}
+	return fmt.Sprintf("_Ranked with the %s profile_\n\n", name)
+}
+
+// freshnessNotice describes the result of the remote checks of a search with
+// require_fresh, one line per repository.
+func freshnessNotice(reports []RepoFreshness) string {
+	if len(reports) == 0 {
+		return "_No configured repository with a remote matches the repository filter; freshness not checked_\n\n"
+	}
+	var sb strings.Builder
+	for _, report := range reports {
+		repo := RepoIDToDisplay(report.RepoID)
+		switch {
+		case report.Err != nil:
+			sb.WriteString(fmt.Sprintf("_Could not check whether %s is up to date: %s_\n", repo, report.Err))
+		case report.Indexed == "":
+			sb.WriteString(fmt.Sprintf("_Warning: %s is not indexed yet; remote HEAD is %s_\n", repo, shortCommit(report.Remote)))
+		case report.Stale():
+			sb.WriteString(fmt.Sprintf("_Warning: the index of %s is stale: indexed %s, remote HEAD is %s. Results reflect the indexed commit until the next sync._\n", repo, shortCommit(report.Indexed), shortCommit(report.Remote)))
+		default:
+			sb.WriteString(fmt.Sprintf("_%s is up to date with its remote (%s)_\n", repo, shortCommit(report.Indexed)))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// tooBroad describes why a search that failed with err was too broad, or
+// returns "" if it failed for another reason.
+func tooBroad(ctx context.Context, err error, timeout time.Duration) string {
+	switch {
+	case strings.Contains(err.Error(), "TooManyClauses"): // bleve has no sentinel error for the expansion limit
+		return fmt.Sprintf("it expands to more than %d terms", maxQueryExpansion)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Sprintf("it did not finish within %s", timeout)
+	}
+	return ""
+}
+
+// recordTelemetry records a search and its hits, if telemetry is enabled.
+func (h *SearchHandler) recordTelemetry(req *mcp.CallToolRequest, args SearchArgument, results *bleve.SearchResult) {
+	telemetry := h.service.Telemetry()
+	if telemetry == nil {
+		return
+	}
+	hits := make([]string, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		path, _ := hit.Fields[domain.CodeFieldFilePath].(string)
+		hits = append(hits, telemetryKey(docRepoID(hit.ID), path))
+	}
+	telemetry.RecordSearch(sessionID(req), args.Query, results.Total, hits)
+}
+
+// buildQuery constructs a Bleve query from search arguments, scored by
+// profile.
+func (h *SearchHandler) buildQuery(args SearchArgument, profile rankingProfile) query.Query {
+	keys, text := splitKeyTerms(args.Query)
+	var searchQuery query.Query
+	if text != "" {
+		searchQuery = buildTextQuery(text, args, profile.strategy)
+	}
+
+	// Every key path must be present in the file
+	if len(keys) > 0 {
+		var must []query.Query
+		if searchQuery != nil {
+			must = append(must, searchQuery)
+		}
+		for _, key := range keys {
+			must = append(must, keyQuery(key))
+		}
+		searchQuery = bleve.NewConjunctionQuery(must...)
+	}
+
+	// Directory documents are only searched on request, and files indexed
+	// with their declarations through them
+	boolQuery := bleve.NewBooleanQuery()
+	boolQuery.AddMust(searchQuery)
+	if args.Directories {
+		boolQuery.AddMust(directoryKindQuery())
+	} else {
+		boolQuery.AddMustNot(directoryKindQuery(), declaredQuery())
+		h.rankFiles(boolQuery, profile)
+	}
+
+	// Generated files rarely answer a question better than their source
+	if !args.IncludeGenerated {
+		generatedQuery := bleve.NewBoolFieldQuery(true)
+		generatedQuery.SetField(domain.CodeFieldGenerated)
+		boolQuery.AddMustNot(generatedQuery)
+	}
+	searchQuery = boolQuery
 
 	// If no filters, return search query directly
-	if args.Repository == "" && args.Extension == "" {
+	exts := args.Extension.values()
+	if args.Repository == "" && len(exts) == 0 {
 		return searchQuery
 	}
 
@@ -119,31 +456,206 @@ func (h *SearchHandler) buildQuery(args SearchArgument) query.Query {
 		must = append(must, repoQuery)
 	}
 
-	if args.Extension != "" {
-		// Normalize extension (remove leading dot if present)
-		ext := strings.TrimPrefix(args.Extension, ".")
-		extQuery := bleve.NewTermQuery(ext)
-		extQuery.SetField(domain.CodeFieldExtension)
-		must = append(must, extQuery)
+	if len(exts) > 0 {
+		// Any of the extensions, where an alias group matches any of its
+		// members
+		var members []string
+		for _, ext := range exts {
+			for _, member := range h.service.ExtensionGroup(ext) {
+				if !slices.Contains(members, member) {
+					members = append(members, member)
+				}
+			}
+		}
+		var extQueries []query.Query
+		for _, member := range members {
+			extQuery := bleve.NewTermQuery(member)
+			extQuery.SetField(domain.CodeFieldExtension)
+			if args.Directories {
+				// Directories with files of the extension's language
+				extQuery = bleve.NewTermQuery(extensionToLanguage(member))
+				extQuery.SetField(domain.CodeFieldLanguages)
+			}
+			extQueries = append(extQueries, extQuery)
+		}
+		must = append(must, bleve.NewDisjunctionQuery(extQueries...))
 	}
 
 	return bleve.NewConjunctionQuery(must...)
 }
 
+// splitKeyTerms separates key:path terms from the rest of a query. Key paths
+// are lowercased to match the keys field.
+func splitKeyTerms(q string) (keys []string, text string) {
+	var rest []string
+	for _, term := range strings.Fields(q) {
+		if len(term) > len(keyTermPrefix) && strings.EqualFold(term[:len(keyTermPrefix)], keyTermPrefix) {
+			keys = append(keys, strings.ToLower(term[len(keyTermPrefix):]))
+			continue
+		}
+		rest = append(rest, term)
+	}
+	return keys, strings.Join(rest, " ")
+}
+
+// checkKeyPatterns rejects key patterns without a literal prefix, which
+// would be matched against every indexed key.
+func checkKeyPatterns(keys []string) error {
+	for _, key := range keys {
+		if i := strings.Index(key, "*"); i >= 0 && i < minWildcardPrefix {
+			return fmt.Errorf("key pattern %q needs at least %d characters before the first '*'", key, minWildcardPrefix)
+		}
+	}
+	return nil
+}
+
+// keyQuery matches files defining the key path, which may contain * wildcards.
+// Patterns with a single trailing * are matched as the cheaper prefix query.
+func keyQuery(key string) query.Query {
+	for strings.Contains(key, "**") {
+		key = strings.ReplaceAll(key, "**", "*")
+	}
+	switch i := strings.Index(key, "*"); {
+	case i < 0:
+		q := bleve.NewTermQuery(key)
+		q.SetField(domain.CodeFieldKeys)
+		return q
+	case i == len(key)-1:
+		q := bleve.NewPrefixQuery(key[:i])
+		q.SetField(domain.CodeFieldKeys)
+		return q
+	default:
+		q := bleve.NewWildcardQuery(key)
+		q.SetField(domain.CodeFieldKeys)
+		return q
+	}
+}
+
+// buildTextQuery matches text against file content and symbols.
+func buildTextQuery(text string, args SearchArgument, strategy queryStrategy) query.Query {
+	// Content query; whole-word matching only accepts exact tokens
+	contentQuery := bleve.NewMatchQuery(text)
+	contentQuery.SetField(domain.CodeFieldContent)
+	if !args.WholeWord {
+		contentQuery.SetFuzziness(strategy.contentFuzziness)
+	}
+
+	// Symbols query with boost
+	symbolsQuery := bleve.NewMatchQuery(text)
+	symbolsQuery.SetField(domain.CodeFieldSymbols)
+	symbolsQuery.SetBoost(strategy.symbolsBoost)
+
+	// Combined search query (Disjunction - OR)
+	disjuncts := append([]query.Query{contentQuery, symbolsQuery}, multiWordQueries(text, strategy)...)
+	if !args.WholeWord {
+		// Words of symbol names, so part of an identifier finds its declaration
+		partsQuery := bleve.NewMatchQuery(text)
+		partsQuery.SetField(domain.CodeFieldSymbolParts)
+		partsQuery.Analyzer = identifierPartsAnalyzer // the field has no path of its own to resolve it from
+		partsQuery.SetBoost(strategy.symbolPartsBoost)
+		disjuncts = append(disjuncts, partsQuery)
+	}
+	var searchQuery query.Query = bleve.NewDisjunctionQuery(disjuncts...)
+
+	if args.CaseSensitive {
+		// Require a case-exact match; the lowercased queries still provide
+		// scoring and highlight locations.
+		exactQuery := bleve.NewMatchQuery(text)
+		exactQuery.SetField(domain.CodeFieldContentExact)
+		exactQuery.Analyzer = caseSensitiveAnalyzer // the field has no path of its own to resolve it from
+		searchQuery = bleve.NewConjunctionQuery(exactQuery, searchQuery)
+	}
+	return searchQuery
+}
+
+// multiWordQueries returns the phrase and symbol sequence variants of a
+// query of several words, which may be separated by spaces or punctuation
+// (Service.Initialize). Single words have none. Symbol matches are boosted
+// relative to strategy.
+func multiWordQueries(text string, strategy queryStrategy) []query.Query {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(words) < 2 {
+		return nil
+	}
+
+	phraseQuery := bleve.NewMatchPhraseQuery(text)
+	phraseQuery.SetField(domain.CodeFieldContent)
+	phraseQuery.SetBoost(phraseBoost)
+
+	var eachWord []query.Query
+	for _, word := range words {
+		wordQuery := bleve.NewMatchQuery(word)
+		wordQuery.SetField(domain.CodeFieldSymbols)
+		eachWord = append(eachWord, wordQuery)
+	}
+	allSymbolsQuery := bleve.NewConjunctionQuery(eachWord...)
+	allSymbolsQuery.SetBoost(allSymbolsBoost / symbolsBoost * strategy.symbolsBoost)
+
+	qualifiedQuery := bleve.NewTermQuery(strings.ToLower(strings.Join(words, ".")))
+	qualifiedQuery.SetField(domain.CodeFieldContent)
+	qualifiedQuery.SetBoost(phraseBoost)
+
+	variants := []query.Query{phraseQuery, qualifiedQuery, allSymbolsQuery}
+	for _, separator := range []string{"", "_"} {
+		joinedQuery := bleve.NewTermQuery(strings.ToLower(strings.Join(words, separator)))
+		joinedQuery.SetField(domain.CodeFieldSymbols)
+		joinedQuery.SetBoost(joinedSymbolBoost / symbolsBoost * strategy.symbolsBoost)
+		variants = append(variants, joinedQuery)
+	}
+	return variants
+}
+
 // formatResults formats Bleve search results for MCP response.
-func (h *SearchHandler) formatResults(results *bleve.SearchResult, queryStr string) *mcp.CallToolResult {
+// Highlight placeholders in fragments are rewritten with tags, and fragment
+// lines are cut at width characters (0 = no limit). Hits of a search of
+// several refs name their ref, and identical hits in other refs are folded
+// into the first.
+func (h *SearchHandler) formatResults(results *bleve.SearchResult, queryStr string, refs []string, tags *strings.Replacer, width int) *mcp.CallToolResult {
+	at := ""
+	switch len(refs) {
+	case 0:
+	case 1:
+		at = fmt.Sprintf(" at ref %s", refs[0])
+	default:
+		at = fmt.Sprintf(" at refs %s", strings.Join(refs, ", "))
+	}
+
 	if results.Total == 0 {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("No results found for query: %s", queryStr)},
+				&mcp.TextContent{Text: fmt.Sprintf("No results found for query%s: %s", at, queryStr)},
 			},
 		}
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d results for '%s':\n\n", results.Total, queryStr))
+	sb.WriteString(fmt.Sprintf("Found %d results for '%s'%s:\n\n", results.Total, queryStr, at))
+
+	// Hits are cited with the commit their repository or snapshot was
+	// indexed at
+	commits := make(map[string]string)
+	commitOf := func(repo string, hit *search.DocumentMatch) string {
+		ref := docRef(hit.ID)
+		key := repo + refSnapshotSeparator + ref
+		commit, ok := commits[key]
+		if !ok {
+			commit = commitSuffix(h.service.IndexedCommit(DisplayToRepoID(repo), ref))
+			commits[key] = commit
+		}
+		if len(refs) > 1 {
+			commit = fmt.Sprintf(" at %s%s", refLabel(ref), commit)
+		}
+		return commit
+	}
 
-	for i, hit := range results.Hits {
+	hits, seen, copies := results.Hits, len(results.Hits), map[*search.DocumentMatch][]string(nil)
+	if len(refs) > 1 {
+		hits, seen, copies = foldCopies(results.Hits, h.service.MaxResults())
+	}
+
+	for i, hit := range hits {
 		// Extract fields
 		repo := ""
 		filePath := ""
@@ -158,8 +670,20 @@ func (h *SearchHandler) formatResults(results *bleve.SearchResult, queryStr stri
 			ext = val
 		}
 
-		// Write result header
-		sb.WriteString(fmt.Sprintf("**%d. %s** `%s`\n", i+1, repo, filePath))
+		// Write result header; directories are summarized
+		if files, ok := hit.Fields[domain.CodeFieldFileCount].(float64); ok {
+			subdirs, _ := hit.Fields[domain.CodeFieldSubdirCount].(float64)
+			sb.WriteString(fmt.Sprintf("**%d. %s** `%s/` (%d files, %d subdirectories", i+1, repo, filePath, int(files), int(subdirs)))
+			if languages := storedStrings(hit.Fields[domain.CodeFieldLanguages]); len(languages) > 0 {
+				sb.WriteString("; " + strings.Join(languages, ", "))
+			}
+			sb.WriteString(")" + commitOf(repo, hit) + "\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("**%d. %s** `%s`%s%s\n", i+1, repo, filePath, hitLines(hit), commitOf(repo, hit)))
+		}
+		if others := copies[hit]; len(others) > 0 {
+			sb.WriteString(fmt.Sprintf("_Also present in: %s_\n", strings.Join(others, ", ")))
+		}
 
 		// Add highlighted fragments with language-specific code fencing
 		if len(hit.Fragments) > 0 {
@@ -167,7 +691,7 @@ func (h *SearchHandler) formatResults(results *bleve.SearchResult, queryStr stri
 				lang := extensionToLanguage(ext)
 				sb.WriteString(fmt.Sprintf("```%s\n", lang))
 				for _, fragment := range fragments {
-					sb.WriteString(fragment)
+					sb.WriteString(tags.Replace(truncateFragment(redactFragment(fragment, h.service), width)))
 					sb.WriteString("\n")
 				}
 				sb.WriteString("```\n")
@@ -177,8 +701,8 @@ func (h *SearchHandler) formatResults(results *bleve.SearchResult, queryStr stri
 		sb.WriteString("\n")
 	}
 
-	if results.Total > uint64(len(results.Hits)) {
-		sb.WriteString(fmt.Sprintf("... and %d more results\n", results.Total-uint64(len(results.Hits))))
+	if results.Total > uint64(seen) {
+		sb.WriteString(fmt.Sprintf("... and %d more results\n", results.Total-uint64(seen)))
 	}
 
 	return &mcp.CallToolResult{
@@ -188,6 +712,129 @@ func (h *SearchHandler) formatResults(results *bleve.SearchResult, queryStr stri
 	}
 }
 
+// dropDeniedHits removes the hits on paths the read policy denies from
+// results, and from their total.
+func dropDeniedHits(results *bleve.SearchResult, service SearchService) {
+	kept := results.Hits[:0]
+	for _, hit := range results.Hits {
+		if path, _ := hit.Fields[domain.CodeFieldFilePath].(string); service.ReadDenied(path) {
+			results.Total--
+			continue
+		}
+		kept = append(kept, hit)
+	}
+	results.Hits = kept
+}
+
+// redactFragment masks the matches of the read redaction patterns in a
+// highlighted fragment. Highlight placeholders can split a value a pattern
+// would match, so the fragment is also redacted without them, and used
+// without highlights if that masks more.
+func redactFragment(fragment string, service SearchService) string {
+	redacted, n := service.Redact([]byte(fragment))
+	plain := strings.NewReplacer(highlightStart, "", highlightEnd, "").Replace(fragment)
+	if redactedPlain, m := service.Redact([]byte(plain)); m > n {
+		return string(redactedPlain)
+	}
+	return string(redacted)
+}
+
+// foldCopies keeps the first of the hits with the same repository, path,
+// line range and content, up to limit hits. It returns the kept hits, the
+// number of hits shown or folded, and the refs of the copies folded into
+// each kept hit.
+func foldCopies(hits search.DocumentMatchCollection, limit int) (search.DocumentMatchCollection, int, map[*search.DocumentMatch][]string) {
+	var kept search.DocumentMatchCollection
+	copies := make(map[*search.DocumentMatch][]string)
+	first := make(map[string]*search.DocumentMatch)
+	seen := 0
+	for _, hit := range hits {
+		repo, _ := hit.Fields[domain.CodeFieldRepository].(string)
+		path, _ := hit.Fields[domain.CodeFieldFilePath].(string)
+		content, _ := hit.Fields[domain.CodeFieldContent].(string)
+		key := fmt.Sprintf("%s\x00%s%s\x00%s", repo, path, hitLines(hit), blobHash([]byte(content)))
+		if original, ok := first[key]; ok {
+			copies[original] = append(copies[original], refLabel(docRef(hit.ID)))
+			seen++
+			continue
+		}
+		if len(kept) == limit {
+			continue // only its copies are looked for
+		}
+		first[key] = hit
+		kept = append(kept, hit)
+		seen++
+	}
+	return kept, seen, copies
+}
+
+// refLabel names the ref of a hit, "" being the default branch.
+func refLabel(ref string) string {
+	if ref == "" {
+		return DefaultBranchRef
+	}
+	return ref
+}
+
+// hitLines describes the line range of a declaration hit for its header,
+// e.g. " lines 12-40, `NewServer`", or returns "" for a whole file.
+func hitLines(hit *search.DocumentMatch) string {
+	start, ok := hit.Fields[domain.CodeFieldStartLine].(float64)
+	if !ok {
+		return ""
+	}
+	end, _ := hit.Fields[domain.CodeFieldEndLine].(float64)
+	lines := fmt.Sprintf(" lines %d-%d", int(start), int(end))
+	if name, _ := hit.Fields[domain.CodeFieldDeclaration].(string); name != "" {
+		lines += fmt.Sprintf(", `%s`", name)
+	}
+	return lines
+}
+
+// commitSuffix formats the commit a hit or file is cited at for its header,
+// e.g. " @ `0123456789ab`", or returns "" if the commit is not known.
+func commitSuffix(commit string) string {
+	if commit == "" {
+		return ""
+	}
+	return fmt.Sprintf(" @ `%s`", shortCommit(commit))
+}
+
+// truncateFragment cuts the lines of a highlighted fragment at width
+// characters, closing a highlight left open by the cut.
+func truncateFragment(fragment string, width int) string {
+	if width <= 0 {
+		return fragment
+	}
+	lines := strings.Split(fragment, "\n")
+	for i, line := range lines {
+		line = truncateLine(line, width)
+		if strings.Count(line, highlightStart) > strings.Count(line, highlightEnd) {
+			line += highlightEnd
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// storedStrings returns a stored text field, which Bleve returns as a string
+// for a single value and as a slice for several.
+func storedStrings(field any) []string {
+	switch v := field.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
 // GetToolDefinition returns the MCP tool definition.
 func (h *SearchHandler) GetToolDefinition() *mcp.Tool {
 	return &mcp.Tool{
@@ -198,8 +845,39 @@ WHEN TO USE: Use this to find implementation patterns, understand how features w
 across the codebase, locate configuration files, or find usage examples.
 
 HOW IT WORKS: Searches file content with optional filtering by repository or
-file extension. Returns matching files with relevant code snippets.`,
+file extension. Returns matching files with relevant code snippets. Matching is
+case-insensitive and tolerates small typos by default; set case_sensitive and/or
+whole_word for exact identifier lookups. Use key:path.to.setting to find
+JSON/YAML files defining a configuration key. Generated files are excluded
+unless include_generated is set. Set ref to search a tag or branch snapshot
+configured on the server, e.g. to see code as of a past release, or several
+comma-separated refs (HEAD for the default branch) to compare them; identical
+hits are then shown once, noting the other refs they are present in. Set
+directories to find directories instead, e.g. which directories deal with kafka.
+Set require_fresh with a repository filter to check the remote for commits
+the index lacks before searching; the results then start with a warning if it
+is stale.
+Each hit names the commit its repository was indexed at (e.g. @ 3f2a9c1d8e7b),
+to cite results as of that commit. On servers indexing declarations, hits in
+source files name the lines and declaration they matched (e.g. lines 12-40,
+` + "`NewServer`" + `); read them with start_line and end_line.
+Send a progress token to receive the first hits of each repository as progress
+notifications while the search runs.`,
+		InputSchema: searchInputSchema(),
+	}
+}
+
+// searchInputSchema infers the tool input schema from SearchArgument, with
+// extension accepting a string or an array.
+func searchInputSchema() *jsonschema.Schema {
+	schema, err := jsonschema.For[SearchArgument](nil)
+	if err != nil {
+		panic(fmt.Sprintf("invalid search input schema: %v", err)) // static definition, cannot fail
 	}
+	extension := schema.Properties["extension"]
+	extension.Type = ""
+	extension.Types = []string{"string", "array"}
+	return schema
 }
 
 // RegisterSearchTool registers the search tool with an MCP server.
@@ -0,0 +1,217 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+// SearchHistoryArgument defines search_history parameters.
+type SearchHistoryArgument struct {
+	Query      string        `json:"query" jsonschema_description:"Search query. Use natural language or keywords, e.g. the name of a removed function"`
+	Repository string        `json:"repository,omitempty" jsonschema_description:"Filter by repository name (substring match)"`
+	Extension  ExtensionList `json:"extension,omitempty" jsonschema_description:"Filter by file extension or extension group, as in search"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// SearchHistoryHandler handles the search_history MCP tool.
+type SearchHistoryHandler struct {
+	service HistoryService
+	search  *SearchHandler // builds queries as search does
+}
+
+// NewSearchHistoryHandler creates a new search_history handler.
+func NewSearchHistoryHandler(service HistoryService) *SearchHistoryHandler {
+	return &SearchHistoryHandler{
+		service: service,
+		search:  NewSearchHandler(service),
+	}
+}
+
+// Handle searches the previous versions of recently changed or deleted files.
+func (h *SearchHistoryHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args SearchHistoryArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "search_history", config.ScopeSearch); result != nil {
+		return result, nil, nil
+	}
+
+	if strings.TrimSpace(args.Query) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Query cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	keys, _ := splitKeyTerms(args.Query)
+	if err := checkKeyPatterns(keys); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Query too broad: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	alias, err := h.service.HistoryAlias()
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("History search is not available: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	defer func() { _ = alias.Close() }()
+
+	searchArgs := SearchArgument{Query: args.Query, Repository: args.Repository, Extension: args.Extension, IncludeGenerated: true}
+	searchReq := bleve.NewSearchRequest(h.search.buildQuery(searchArgs, rankingProfiles[config.RankingProfileDefault]))
+	searchReq.Size = h.service.MaxResults()
+	searchReq.Fields = []string{domain.CodeFieldRepository, domain.CodeFieldFilePath, domain.CodeFieldExtension}
+	searchReq.Highlight = bleve.NewHighlightWithStyle(highlightStyle)
+	searchReq.Highlight.AddField(domain.CodeFieldContent)
+
+	release, err := h.service.AcquireSearch(ctx)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Search not started: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	defer release()
+
+	timeout := h.service.SearchTimeout()
+	searchCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	results, err := alias.SearchInContext(searchCtx, searchReq)
+	if err != nil {
+		text := fmt.Sprintf("Search failed: %s", err)
+		if reason := tooBroad(searchCtx, err, timeout); reason != "" {
+			text = fmt.Sprintf("Query too broad: %s", reason)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	dropDeniedHistoryHits(results, h.service)
+	format := formatFrom(ctx)
+	pre, post := h.service.HighlightTags()
+	if format.Plain {
+		pre, post = "", ""
+	}
+	tags := strings.NewReplacer(highlightStart, pre, highlightEnd, post)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: h.formatResults(results, args.Query, tags, format.snippetWidth(0))},
+		},
+	}, nil, nil
+}
+
+// formatResults renders history hits with the commit that changed or deleted
+// each file, and how to recover the previous version.
+func (h *SearchHistoryHandler) formatResults(results *bleve.SearchResult, queryStr string, tags *strings.Replacer, width int) string {
+	if results.Total == 0 {
+		return fmt.Sprintf("No results found in history for query: %s", queryStr)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d results in history for '%s':\n\n", results.Total, queryStr))
+	for i, hit := range results.Hits {
+		repo, _ := hit.Fields[domain.CodeFieldRepository].(string)
+		storedPath, _ := hit.Fields[domain.CodeFieldFilePath].(string)
+		ext, _ := hit.Fields[domain.CodeFieldExtension].(string)
+
+		// Files are stored under the abbreviated SHA of their commit
+		sha, path := historyPath(storedPath)
+		repoID := DisplayToRepoID(repo)
+		change := "changed"
+		if _, err := os.Stat(filepath.Join(h.service.GetRepoDir(repoID), filepath.FromSlash(path))); os.IsNotExist(err) {
+			change = "deleted"
+		}
+		sb.WriteString(fmt.Sprintf("**%d. %s** `%s` (%s by %s", i+1, repo, path, change, sha))
+		if commit, ok := h.service.HistoryCommit(repoID, sha); ok {
+			sb.WriteString(fmt.Sprintf(" on %s: %s", commit.Time.UTC().Format("2006-01-02"), commit.Subject))
+		}
+		sb.WriteString(")\n")
+		sb.WriteString(fmt.Sprintf("Previous version: `git show %s^:%s`\n", sha, path))
+
+		if fragments, ok := hit.Fragments[domain.CodeFieldContent]; ok && len(fragments) > 0 {
+			sb.WriteString(fmt.Sprintf("```%s\n", extensionToLanguage(ext)))
+			for _, fragment := range fragments {
+				sb.WriteString(tags.Replace(truncateFragment(redactFragment(fragment, h.service), width)))
+				sb.WriteString("\n")
+			}
+			sb.WriteString("```\n")
+		}
+		sb.WriteString("\n")
+	}
+	if results.Total > uint64(len(results.Hits)) {
+		sb.WriteString(fmt.Sprintf("... and %d more results\n", results.Total-uint64(len(results.Hits))))
+	}
+	return sb.String()
+}
+
+// historyPath splits a stored history path into the abbreviated SHA of the
+// commit that changed the file and the path of the file in the repository.
+func historyPath(storedPath string) (sha, path string) {
+	sha, path, _ = strings.Cut(storedPath, "/")
+	return sha, path
+}
+
+// dropDeniedHistoryHits removes the hits on previous versions of files the
+// read policy denies from results, and from their total. Patterns are
+// matched against the repository path, without the commit prefix.
+func dropDeniedHistoryHits(results *bleve.SearchResult, service SearchService) {
+	kept := results.Hits[:0]
+	for _, hit := range results.Hits {
+		storedPath, _ := hit.Fields[domain.CodeFieldFilePath].(string)
+		if _, path := historyPath(storedPath); service.ReadDenied(path) {
+			results.Total--
+			continue
+		}
+		kept = append(kept, hit)
+	}
+	results.Hits = kept
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *SearchHistoryHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "search_history",
+		Description: `Search code that recent commits changed or deleted.
+
+WHEN TO USE: Use when code that existed recently is gone from the default
+branch, e.g. to recover a deleted function or see what a config file held
+before a change during incident response. Use search for current code.
+
+HOW IT WORKS: Searches the previous versions of the files changed or deleted
+by the last commits of each repository, as configured on the server. Each hit
+names the commit that changed or deleted the file, its date and subject, and
+the git command that shows the full previous version. Returns an error if the
+server does not index history.
+
+LIMITS: Only the number of recent commits the server is configured with is
+searchable, and only as of its last sync. The clone is not deepened on demand,
+so older changes cannot be found through this tool.`,
+	}
+}
+
+// RegisterSearchHistoryTool registers the search_history tool with an MCP server.
+func RegisterSearchHistoryTool(server *mcp.Server, service HistoryService) {
+	handler := NewSearchHistoryHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
@@ -0,0 +1,256 @@
+package gitrepos
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+const (
+	// searchInFileMaxSize is the largest file search_in_file scans. It is
+	// independent of the read size limit, since only matching lines are
+	// returned.
+	searchInFileMaxSize = 32 * 1024 * 1024
+	// searchInFileMaxMatches caps the matching lines returned
+	searchInFileMaxMatches = 200
+	// searchInFileMaxContext caps the context lines around each match
+	searchInFileMaxContext = 10
+	// searchInFileMaxLineLength cuts long lines, such as minified code, in
+	// characters
+	searchInFileMaxLineLength = 500
+)
+
+// SearchInFileArgument defines search_in_file parameters.
+type SearchInFileArgument struct {
+	Repository    string `json:"repository" jsonschema_description:"Repository name (e.g., github.com/org/repo)"`
+	Path          string `json:"path" jsonschema_description:"File path relative to repository root"`
+	Query         string `json:"query" jsonschema_description:"Text to find in each line, or a regular expression (RE2 syntax) when regex is set"`
+	Regex         bool   `json:"regex,omitempty" jsonschema_description:"Treat query as a regular expression"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty" jsonschema_description:"Match letter case exactly (default: case-insensitive)"`
+	Context       int    `json:"context,omitempty" jsonschema_description:"Lines to show before and after each match (default: 0, max: 10)"`
+	Ref           string `json:"ref,omitempty" jsonschema_description:"Search the file in the snapshot of this tag or branch instead of the default branch; only refs configured on the server are available"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// SearchInFileHandler handles the search_in_file MCP tool.
+type SearchInFileHandler struct {
+	service ReadService
+}
+
+// NewSearchInFileHandler creates a new search_in_file handler.
+func NewSearchInFileHandler(service ReadService) *SearchInFileHandler {
+	return &SearchInFileHandler{
+		service: service,
+	}
+}
+
+// Handle returns the lines of a file that match a query.
+func (h *SearchInFileHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args SearchInFileArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "search_in_file", config.ScopeRead); result != nil {
+		return result, nil, nil
+	}
+
+	if strings.TrimSpace(args.Query) == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Query cannot be empty"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	match, err := lineMatcher(args.Query, args.Regex, args.CaseSensitive)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Invalid regular expression: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return runRead(ctx, h.service, func(ctx context.Context) (*mcp.CallToolResult, any, error) {
+		return h.search(ctx, req, args, match)
+	})
+}
+
+// search returns the lines of the file that match, within the limits applied
+// by runRead.
+func (h *SearchInFileHandler) search(ctx context.Context, req *mcp.CallToolRequest, args SearchInFileArgument, match func(string) bool) (*mcp.CallToolResult, any, error) {
+	target, result := resolveReadTarget(h.service, req, "Search in file", args.Repository, args.Path, args.Ref)
+	if result != nil {
+		return result, nil, nil
+	}
+
+	if detectArchive(target.displayPath) != archiveNone {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Cannot search compressed files or archives, use read instead"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	if target.info.Size() > searchInFileMaxSize {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("File too large (%s). Maximum size for search_in_file is %s", formatFrom(ctx).size(target.info.Size(), 2), formatFrom(ctx).size(searchInFileMaxSize, 2))},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	content, err := readFile(ctx, target.fullPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Error reading file: %s", readFailure(h.service, err))},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+	if IsBinary(content) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Cannot search binary file content"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	// Redact before matching, so that matches cannot reveal masked values
+	notice := target.notice
+	content, redacted := h.service.Redact(content)
+	if redacted > 0 {
+		notice += fmt.Sprintf("_%d value(s) redacted by the server's read policy_\n\n", redacted)
+	}
+
+	lines := splitLines(content)
+	var matches []int
+	for i, line := range lines {
+		if match(line) {
+			matches = append(matches, i)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(notice)
+	sb.WriteString(fmt.Sprintf("**%s** `%s`\n\n", args.Repository, target.displayPath))
+	if len(matches) == 0 {
+		sb.WriteString(fmt.Sprintf("No lines match %q (%d lines searched)\n", args.Query, len(lines)))
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	}
+
+	format := formatFrom(ctx)
+	total := len(matches)
+	if total > searchInFileMaxMatches {
+		matches = matches[:searchInFileMaxMatches]
+		sb.WriteString(fmt.Sprintf("%d matching lines, showing the first %d\n\n", total, searchInFileMaxMatches))
+	} else {
+		sb.WriteString(fmt.Sprintf("%d matching line(s)\n\n", total))
+	}
+	sb.WriteString(fmt.Sprintf("```%s\n", extensionToLanguage(GetFileExtension(target.displayPath))))
+	sb.WriteString(formatLineMatches(lines, matches, min(max(args.Context, 0), searchInFileMaxContext), format.snippetWidth(searchInFileMaxLineLength)))
+	sb.WriteString("```\n")
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// lineMatcher returns a function that reports whether a line matches query.
+func lineMatcher(query string, regex, caseSensitive bool) (func(string) bool, error) {
+	if regex {
+		if !caseSensitive {
+			query = "(?i)" + query
+		}
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	if caseSensitive {
+		return func(line string) bool { return strings.Contains(line, query) }, nil
+	}
+	query = strings.ToLower(query)
+	return func(line string) bool { return strings.Contains(strings.ToLower(line), query) }, nil
+}
+
+// splitLines splits content into lines without their line endings.
+func splitLines(content []byte) []string {
+	content = bytes.TrimSuffix(content, []byte("\n"))
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// formatLineMatches renders the matching lines, given by index, with context
+// lines around them in grep style: "12: line" for matches, "11- line" for
+// context and "--" between groups of lines that are not adjacent. Lines are
+// cut at width characters.
+func formatLineMatches(lines []string, matches []int, context, width int) string {
+	var sb strings.Builder
+	isMatch := make(map[int]bool, len(matches))
+	for _, i := range matches {
+		isMatch[i] = true
+	}
+	next := 0 // first line not yet written
+	for _, m := range matches {
+		start := max(m-context, next)
+		end := min(m+context, len(lines)-1)
+		if next > 0 && start > next {
+			sb.WriteString("--\n")
+		}
+		for i := start; i <= end; i++ {
+			sep := "-"
+			if isMatch[i] {
+				sep = ":"
+			}
+			sb.WriteString(fmt.Sprintf("%d%s %s\n", i+1, sep, truncateLine(lines[i], width)))
+		}
+		next = max(next, end+1)
+	}
+	return sb.String()
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *SearchInFileHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "search_in_file",
+		Description: `Find the lines of one file that match a text or regular expression.
+
+WHEN TO USE: Use when you already know the repository and file and need the
+lines that mention something, e.g. where a function is called in a large
+file. It is cheaper than reading the whole file and more precise than a
+global search.
+
+HOW IT WORKS: Provide the repository, file path and query. Returns each
+matching line with its line number, optionally with context lines around it.
+Matching is a case-insensitive substring match unless regex or
+case_sensitive is set. The working tree is searched, so lines beyond the
+indexed snippets are found. Set ref to search a tag or branch snapshot.`,
+	}
+}
+
+// RegisterSearchInFileTool registers the search_in_file tool with an MCP server.
+func RegisterSearchInFileTool(server *mcp.Server, service ReadService) {
+	handler := NewSearchInFileHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
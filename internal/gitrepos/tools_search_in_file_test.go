@@ -0,0 +1,179 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const searchInFileSource = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello")
+	helper()
+}
+
+func helper() {
+	fmt.Println("helper")
+}
+`
+
+func searchInFile(t *testing.T, service *mockReadService, args SearchInFileArgument) *mcp.CallToolResult {
+	t.Helper()
+	if args.Repository == "" {
+		args.Repository = "github.com/test/repo"
+	}
+	result, _, err := NewSearchInFileHandler(service).Handle(context.Background(), &mcp.CallToolRequest{}, args)
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	return result
+}
+
+func TestSearchInFileHandler_NotReady(t *testing.T) {
+	result := searchInFile(t, &mockReadService{ready: false}, SearchInFileArgument{Path: "main.go", Query: "main"})
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "Search in file is not available") {
+		t.Errorf("Expected a not ready error, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestSearchInFileHandler_InvalidArguments(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "main.go", searchInFileSource)
+	writeTestFile(t, repoDir, "data.bin", "bin\x00ary")
+	writeTestFile(t, repoDir, "seed.sql.gz", "not really gzip")
+	service := &mockReadService{ready: true, repoDir: repoDir, maxFileSize: 256 * 1024}
+
+	tests := []struct {
+		name string
+		args SearchInFileArgument
+		want string
+	}{
+		{"empty query", SearchInFileArgument{Path: "main.go", Query: " "}, "Query cannot be empty"},
+		{"invalid regex", SearchInFileArgument{Path: "main.go", Query: "(", Regex: true}, "Invalid regular expression"},
+		{"empty path", SearchInFileArgument{Query: "main"}, "Path cannot be empty"},
+		{"traversal", SearchInFileArgument{Path: "../secret", Query: "main"}, "Invalid path"},
+		{"missing file", SearchInFileArgument{Path: "missing.go", Query: "main"}, "File not found"},
+		{"binary file", SearchInFileArgument{Path: "data.bin", Query: "bin"}, "Cannot search binary file content"},
+		{"archive", SearchInFileArgument{Path: "seed.sql.gz", Query: "insert"}, "Cannot search compressed files or archives"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := searchInFile(t, service, tt.args)
+			if !result.IsError || !strings.Contains(ExtractTextContent(result), tt.want) {
+				t.Errorf("Expected error %q, got: %s", tt.want, ExtractTextContent(result))
+			}
+		})
+	}
+}
+
+func TestSearchInFileHandler_Matches(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "main.go", searchInFileSource)
+	service := &mockReadService{ready: true, repoDir: repoDir, maxFileSize: 256 * 1024}
+
+	tests := []struct {
+		name string
+		args SearchInFileArgument
+		want string
+	}{
+		{"substring", SearchInFileArgument{Path: "main.go", Query: "helper"}, "7: \thelper()\n--\n10: func helper() {\n11: \tfmt.Println(\"helper\")\n"},
+		{"case-insensitive", SearchInFileArgument{Path: "main.go", Query: "HELLO"}, "6: \tfmt.Println(\"Hello\")\n"},
+		{"case-sensitive", SearchInFileArgument{Path: "main.go", Query: "hello", CaseSensitive: true}, "No lines match"},
+		{"regex", SearchInFileArgument{Path: "main.go", Query: `^func \w+\(`, Regex: true}, "5: func main() {\n--\n10: func helper() {\n"},
+		{"context", SearchInFileArgument{Path: "main.go", Query: "import", Context: 1}, "2- \n3: import \"fmt\"\n4- \n"},
+		{"adjacent context", SearchInFileArgument{Path: "main.go", Query: "Println", Context: 1}, "5- func main() {\n6: \tfmt.Println(\"Hello\")\n7- \thelper()\n--\n10- func helper() {\n11: \tfmt.Println(\"helper\")\n12- }\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := searchInFile(t, service, tt.args)
+			text := ExtractTextContent(result)
+			if result.IsError || !strings.Contains(text, tt.want) {
+				t.Errorf("Expected %q in result, got: %s", tt.want, text)
+			}
+			if !strings.Contains(text, "**github.com/test/repo** `main.go`") {
+				t.Errorf("Expected the repository and path in result, got: %s", text)
+			}
+		})
+	}
+}
+
+func TestSearchInFileHandler_MatchLimit(t *testing.T) {
+	repoDir := t.TempDir()
+	var sb strings.Builder
+	for i := range searchInFileMaxMatches + 50 {
+		sb.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	writeTestFile(t, repoDir, "big.txt", sb.String())
+
+	result := searchInFile(t, &mockReadService{ready: true, repoDir: repoDir, maxFileSize: 1024}, SearchInFileArgument{Path: "big.txt", Query: "line"})
+	text := ExtractTextContent(result)
+	if result.IsError {
+		t.Fatalf("Expected success for a file over the read size limit, got: %s", text)
+	}
+	if !strings.Contains(text, "250 matching lines, showing the first 200") {
+		t.Errorf("Expected a truncation notice, got: %s", text)
+	}
+	if strings.Contains(text, "line 200\n") {
+		t.Errorf("Expected matches beyond the limit to be left out, got: %s", text)
+	}
+}
+
+func TestSearchInFileHandler_RedactsBeforeMatching(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, "config.yaml", "user: admin\npassword: hunter2\n")
+	service := &mockReadService{
+		ready:       true,
+		repoDir:     repoDir,
+		maxFileSize: 256 * 1024,
+		redactions:  []*regexp.Regexp{regexp.MustCompile(`password:\s*(\S+)`)},
+	}
+
+	result := searchInFile(t, service, SearchInFileArgument{Path: "config.yaml", Query: "hunter2"})
+	if text := ExtractTextContent(result); !strings.Contains(text, "No lines match") || strings.Contains(text, "hunter2\n") {
+		t.Errorf("Expected the redacted value not to match, got: %s", text)
+	}
+
+	result = searchInFile(t, service, SearchInFileArgument{Path: "config.yaml", Query: "password"})
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "2: password: [REDACTED]") || !strings.Contains(text, "1 value(s) redacted") {
+		t.Errorf("Expected the redacted line, got: %s", text)
+	}
+}
+
+func TestSearchInFileHandler_ReadPolicy(t *testing.T) {
+	repoDir := t.TempDir()
+	writeTestFile(t, repoDir, ".env", "TOKEN=secret\n")
+
+	result := searchInFile(t, &mockReadService{ready: true, repoDir: repoDir, deny: []string{".env"}}, SearchInFileArgument{Path: ".env", Query: "TOKEN"})
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "not allowed by the server's read policy") {
+		t.Errorf("Expected the read policy to apply, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestSearchInFileHandler_GetToolDefinition(t *testing.T) {
+	tool := NewSearchInFileHandler(&mockReadService{}).GetToolDefinition()
+	if tool.Name != "search_in_file" {
+		t.Errorf("Expected tool name 'search_in_file', got %q", tool.Name)
+	}
+	if tool.Description == "" {
+		t.Error("Expected non-empty description")
+	}
+}
+
+func TestFormatLineMatches_TruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("é", searchInFileMaxLineLength+1)
+	got := formatLineMatches([]string{long}, []int{0}, 0, searchInFileMaxLineLength)
+	if !strings.HasSuffix(got, "…\n") || !strings.HasPrefix(got, "1: ") {
+		t.Errorf("Expected a truncated line, got %q", got)
+	}
+	if !strings.Contains(got, strings.Repeat("é", searchInFileMaxLineLength)+"…") || strings.ContainsRune(got, '�') {
+		t.Errorf("Expected the line to be cut at a character boundary, got %q", got)
+	}
+}
@@ -2,14 +2,21 @@ package gitrepos
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/searcher"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
 	"github.com/sha1n/mcp-relic-server/internal/config"
 )
 
@@ -86,6 +93,21 @@ func TestSearchHandler_AliasError(t *testing.T) {
 	}
 }
 
+func TestSearchHandler_ServerBusy(t *testing.T) {
+	handler := NewSearchHandler(&mockSearchService{
+		ready:      true,
+		acquireErr: fmt.Errorf("%w: 8 searches running and 16 queued, retry shortly", ErrServerBusy),
+	})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "test"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "server busy") {
+		t.Errorf("Expected server busy error, got: %s", ExtractTextContent(result))
+	}
+}
+
 func TestSearchHandler_GetToolDefinition(t *testing.T) {
 	handler := NewSearchHandler(&mockSearchService{})
 	tool := handler.GetToolDefinition()
@@ -143,6 +165,20 @@ func TestSearchHandler_SimpleSearch(t *testing.T) {
 	}
 }
 
+func TestSearchHandler_PendingReposNotice(t *testing.T) {
+	svc := setupSearchService(t, t.TempDir(), map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	defer func() { _ = svc.Close() }()
+	svc.pending = map[string]bool{"github.com_org_slow": true}
+
+	result, _, err := NewSearchHandler(svc).Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "main"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if text := ExtractTextContent(result); !strings.HasPrefix(text, "_Still being indexed and not searched yet: github.com/org/slow_") {
+		t.Errorf("Expected a notice of the repositories still syncing, got: %s", text)
+	}
+}
+
 func TestSearchHandler_SearchWithRepositoryFilter(t *testing.T) {
 	dir := t.TempDir()
 	files := map[string]string{
@@ -202,7 +238,7 @@ func TestSearchHandler_SearchWithExtensionFilter(t *testing.T) {
 	// Search for "main" with .go extension
 	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{
 		Query:     "main",
-		Extension: "go",
+		Extension: ExtensionList{"go"},
 	})
 	if err != nil {
 		t.Fatalf("Handle returned error: %v", err)
@@ -214,7 +250,7 @@ func TestSearchHandler_SearchWithExtensionFilter(t *testing.T) {
 	// Search for "main" with .py extension
 	result, _, err = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{
 		Query:     "main",
-		Extension: ".py", // With dot prefix
+		Extension: ExtensionList{".py"}, // With dot prefix
 	})
 	if err != nil {
 		t.Fatalf("Handle returned error: %v", err)
@@ -224,6 +260,230 @@ func TestSearchHandler_SearchWithExtensionFilter(t *testing.T) {
 	}
 }
 
+func TestSearchHandler_ExtensionAliases(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.js":     "function render() {}",
+		"view.tsx":   "export function render() {}",
+		"render.go":  "package ui\n\nfunc render() {}",
+		"index.html": "<div onload=\"render()\"></div>",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+	svc.settings.ExtensionAliases = []string{"web=html+css"}
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	// Built-in group
+	result, _, _ := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "render", Extension: ExtensionList{"JS"}})
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "app.js") || !strings.Contains(text, "view.tsx") || strings.Contains(text, "render.go") || strings.Contains(text, "index.html") {
+		t.Errorf("Expected the JavaScript and TypeScript files, got: %s", text)
+	}
+
+	// Configured group
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "render", Extension: ExtensionList{"web"}})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "index.html") || strings.Contains(text, "app.js") {
+		t.Errorf("Expected only the HTML file, got: %s", text)
+	}
+
+	// Plain extension
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "render", Extension: ExtensionList{"tsx"}})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "view.tsx") || strings.Contains(text, "app.js") {
+		t.Errorf("Expected only the TSX file, got: %s", text)
+	}
+}
+
+func TestSearchHandler_MultipleExtensions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.go":    "package api\n\nfunc ListUsers() {}",
+		"api.proto": "service Users {\n  rpc ListUsers(Req) returns (Resp);\n}",
+		"api.py":    "def list_users():\n    pass",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	for _, exts := range []ExtensionList{{"go,.proto"}, {"go", "proto"}} {
+		result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "ListUsers", Extension: exts})
+		text := ExtractTextContent(result)
+		if !strings.Contains(text, "api.go") || !strings.Contains(text, "api.proto") || strings.Contains(text, "api.py") {
+			t.Errorf("%q: expected the Go and proto files, got: %s", exts, text)
+		}
+	}
+}
+
+func TestSearchHandler_Directories(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"services/kafka/consumer.go": "package kafka\n\nfunc Consume() {}",
+		"services/kafka/producer.go": "package kafka\n\nfunc Produce() {}",
+		"services/kafka/topics.yaml": "topics:\n  - orders",
+		"services/http/server.go":    "package http\n\nfunc Serve() {}",
+		"docs/kafka_setup.md":        "# Setup\n\nRun the broker.",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "kafka", Directories: true})
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "`services/kafka/` (3 files, 0 subdirectories; go, yaml)") {
+		t.Errorf("Expected the kafka directory with its summary, got: %s", text)
+	}
+	if !strings.Contains(text, "`docs/`") {
+		t.Errorf("Expected the directory holding kafka_setup.md, got: %s", text)
+	}
+	if strings.Contains(text, "consumer.go`") {
+		t.Errorf("Expected directories only, got: %s", text)
+	}
+
+	// The extension filter matches the languages of a directory
+	result, _, _ = handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "kafka", Directories: true, Extension: ExtensionList{"md"}})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "`docs/`") || strings.Contains(text, "`services/kafka/`") {
+		t.Errorf("Expected only the markdown directory, got: %s", text)
+	}
+
+	// File searches never return directories
+	result, _, _ = handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "kafka"})
+	text = ExtractTextContent(result)
+	if strings.Contains(text, "`services/kafka/`") || strings.Contains(text, "subdirectories") {
+		t.Errorf("Expected no directory hits, got: %s", text)
+	}
+}
+
+// blockingIndex is an index whose searches wait until they are cancelled.
+type blockingIndex struct {
+	sharedIndex
+	started chan struct{}
+	stopped chan error
+}
+
+func (b *blockingIndex) SearchInContext(ctx context.Context, _ *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	close(b.started)
+	<-ctx.Done()
+	b.stopped <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestSearchHandler_CancelledMidSearch(t *testing.T) {
+	index, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatalf("NewMemOnly failed: %v", err)
+	}
+	defer func() { _ = index.Close() }()
+	blocking := &blockingIndex{sharedIndex: index, started: make(chan struct{}), stopped: make(chan error, 1)}
+	handler := NewSearchHandler(&mockSearchService{ready: true, alias: bleve.NewIndexAlias(blocking), maxResults: 20})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, _, _ := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "anything"})
+		results <- result
+	}()
+
+	<-blocking.started
+	cancel()
+
+	select {
+	case result := <-results:
+		if !result.IsError || ExtractTextContent(result) != "Search cancelled" {
+			t.Errorf("Expected the search to be cancelled, got: %s", ExtractTextContent(result))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the search to stop when the call was cancelled")
+	}
+	if err := <-blocking.stopped; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the index search to see the cancellation, got: %v", err)
+	}
+}
+
+func TestSearchHandler_CancelledBeforeSearch(t *testing.T) {
+	dir := t.TempDir()
+	svc := setupSearchService(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {}"})
+	defer func() { _ = svc.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, _, _ := NewSearchHandler(svc).Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "main"})
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "cancel") {
+		t.Errorf("Expected a cancelled search, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestRegisterSearchTool_ExtensionForms(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.go":    "package api\n\nfunc ListUsers() {}",
+		"api.proto": "service Users {\n  rpc ListUsers(Req) returns (Resp);\n}",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server"}, nil)
+	RegisterSearchTool(server, svc)
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Server connect failed: %v", err)
+	}
+	defer func() { _ = serverSession.Close() }()
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Client connect failed: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	for _, extension := range []any{"go,proto", []string{"go", "proto"}} {
+		result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "search", Arguments: map[string]any{"query": "ListUsers", "extension": extension}})
+		if err != nil {
+			t.Fatalf("%v: CallTool failed: %v", extension, err)
+		}
+		text := ExtractTextContent(result)
+		if result.IsError || !strings.Contains(text, "api.go") || !strings.Contains(text, "api.proto") {
+			t.Errorf("%v: expected the Go and proto files, got: %s", extension, text)
+		}
+	}
+}
+
+func TestExtensionList_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`"go"`, []string{"go"}},
+		{`".go, proto"`, []string{"go", "proto"}},
+		{`["go", "proto,py"]`, []string{"go", "proto", "py"}},
+		{`[]`, nil},
+	}
+	for _, tt := range tests {
+		var args SearchArgument
+		if err := json.Unmarshal([]byte(`{"query": "q", "extension": `+tt.input+`}`), &args); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if got := args.Extension.values(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	var args SearchArgument
+	if err := json.Unmarshal([]byte(`{"query": "q", "extension": 1}`), &args); err == nil {
+		t.Error("Expected an error for a number")
+	}
+
+	extension := searchInputSchema().Properties["extension"]
+	if !slices.Equal(extension.Types, []string{"string", "array"}) || extension.Items == nil || extension.Items.Type != "string" {
+		t.Errorf("Unexpected extension schema: %+v", extension)
+	}
+}
+
 func TestSearchHandler_SearchWithBothFilters(t *testing.T) {
 	dir := t.TempDir()
 	files := map[string]string{
@@ -242,7 +502,7 @@ func TestSearchHandler_SearchWithBothFilters(t *testing.T) {
 	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{
 		Query:      "main",
 		Repository: "github.com/test/repo",
-		Extension:  "go",
+		Extension:  ExtensionList{"go"},
 	})
 	if err != nil {
 		t.Fatalf("Handle returned error: %v", err)
@@ -344,8 +604,8 @@ func TestSearchHandler_ResultFormat(t *testing.T) {
 	if !strings.Contains(content, "github.com/test/repo") {
 		t.Errorf("Expected repository name in output, got: %s", content)
 	}
-	if !strings.Contains(content, "`main.go`") {
-		t.Errorf("Expected file path in backticks in output, got: %s", content)
+	if !strings.Contains(content, "`main.go` @ `abc123`") {
+		t.Errorf("Expected file path in backticks with the indexed commit in output, got: %s", content)
 	}
 	if !strings.Contains(content, "```go") {
 		t.Errorf("Expected language-specific code fence '```go' in output, got: %s", content)
@@ -355,6 +615,35 @@ func TestSearchHandler_ResultFormat(t *testing.T) {
 	}
 }
 
+func TestSearchHandler_AppliesReadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config/app.go":   "package config\n\nconst apiToken = \"token=s3cr3tvalue\"\n",
+		"secrets/keys.go": "package secrets\n\nconst apiToken = \"token=def456\"\n",
+	}
+	svc := setupSearchServiceWith(t, dir, files, func(settings *config.GitReposSettings) {
+		settings.ReadDenyPatterns = []string{"secrets/**"}
+		settings.ReadRedactPatterns = []string{`token=\w+`}
+	})
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	}()
+
+	result, _, err := NewSearchHandler(svc).Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "apiToken"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	content := ExtractTextContent(result)
+	if !strings.Contains(content, "`config/app.go`") || strings.Contains(content, "`secrets/keys.go`") {
+		t.Errorf("Expected only the allowed file, got: %s", content)
+	}
+	if strings.Contains(content, "s3cr3tvalue") || strings.Contains(content, "def456") {
+		t.Errorf("Expected redacted fragments, got: %s", content)
+	}
+}
+
 func TestSearchHandler_SubstringRepoFilter(t *testing.T) {
 	dir := t.TempDir()
 	files := map[string]string{
@@ -384,10 +673,395 @@ func TestSearchHandler_SubstringRepoFilter(t *testing.T) {
 	}
 }
 
+func TestSearchHandler_CaseSensitive(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"upper.go": "package a\n\nvar UserID = 1",
+		"lower.go": "package b\n\nvar userid = 2",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "UserID", CaseSensitive: true})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "upper.go") || strings.Contains(text, "lower.go") {
+		t.Errorf("Expected only the case-exact match, got: %s", text)
+	}
+
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "UserID"})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "upper.go") || !strings.Contains(text, "lower.go") {
+		t.Errorf("Expected both files without case sensitivity, got: %s", text)
+	}
+}
+
+func TestSearchHandler_WholeWord(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"exact.go": "package a\n\nfunc handle() {}",
+		"fuzzy.go": "package b\n\nfunc handles() {}",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "handle", WholeWord: true})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "exact.go") || strings.Contains(text, "fuzzy.go") {
+		t.Errorf("Expected only the whole-word match, got: %s", text)
+	}
+
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "handle"})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "fuzzy.go") {
+		t.Errorf("Expected fuzzy match without whole_word, got: %s", text)
+	}
+}
+
+func TestSearchHandler_SymbolParts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.go": "package a\n\ntype HTTPServerConfig struct{}",
+		"store.py":  "def load_user_profile():\n    pass",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	for query, want := range map[string]string{
+		"ServerConfig": "config.go",
+		"server":       "config.go",
+		"userProfile":  "store.py",
+		"user_profile": "store.py",
+	} {
+		result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: query})
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		if text := ExtractTextContent(result); !strings.Contains(text, want) {
+			t.Errorf("Expected %s for %q, got: %s", want, query, text)
+		}
+	}
+
+	result, _, _ := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "ServerConfig", WholeWord: true})
+	if text := ExtractTextContent(result); strings.Contains(text, "config.go") {
+		t.Errorf("Expected no partial identifier match with whole_word, got: %s", text)
+	}
+}
+
+func TestSearchHandler_KeySearch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml":   "server:\n  timeout: 30s\n",
+		"settings.json": `{"server": {"port": 8080}}`,
+		"notes.md":      "The server timeout is configurable.",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "key:Server.Timeout"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "config.yaml") || strings.Contains(text, "settings.json") || strings.Contains(text, "notes.md") {
+		t.Errorf("Expected only the YAML file, got: %s", text)
+	}
+
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "key:server.*"})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "config.yaml") || !strings.Contains(text, "settings.json") {
+		t.Errorf("Expected both config files for a wildcard key, got: %s", text)
+	}
+
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "key:server.port 8080"})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "settings.json") || strings.Contains(text, "config.yaml") {
+		t.Errorf("Expected key and text terms combined, got: %s", text)
+	}
+}
+
+func TestSearchHandler_QueryGuardrails(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml":   "server:\n  timeout: 30s\n",
+		"settings.json": `{"server": {"port": 8080}}`,
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+	search := func(q string) (string, bool) {
+		result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: q})
+		if err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+		return ExtractTextContent(result), result.IsError
+	}
+
+	// Key patterns need a literal prefix
+	for _, q := range []string{"key:*timeout", "key:*", "key:s*"} {
+		if text, isErr := search(q); !isErr || !strings.Contains(text, "Query too broad") {
+			t.Errorf("Expected %q to be rejected, got: %s", q, text)
+		}
+	}
+
+	// Repeated wildcards are collapsed
+	if text, isErr := search("key:server.**"); isErr || !strings.Contains(text, "config.yaml") || !strings.Contains(text, "settings.json") {
+		t.Errorf("Expected both config files, got: %s", text)
+	}
+
+	// Expansion beyond the limit
+	limit := searcher.DisjunctionMaxClauseCount
+	searcher.DisjunctionMaxClauseCount = 1
+	text, isErr := search("key:server.*")
+	searcher.DisjunctionMaxClauseCount = limit
+	if !isErr || !strings.Contains(text, "Query too broad") || !strings.Contains(text, "terms") {
+		t.Errorf("Expected an expansion error, got: %s", text)
+	}
+
+	// Time budget
+	svc.settings.SearchTimeout = time.Nanosecond
+	if text, isErr := search("server"); !isErr || !strings.Contains(text, "did not finish within") {
+		t.Errorf("Expected a time budget error, got: %s", text)
+	}
+}
+
+func TestSearchHandler_MultiWordSymbols(t *testing.T) {
+	files := map[string]string{
+		"notes.md":   "To initialize the cache, ask the service owner.",
+		"caller.go":  "package app\n\nfunc run() {\n\tService.Initialize()\n}",
+		"service.go": "package app\n\ntype Service struct{}\n\nfunc Initialize() {}",
+		"joined.go":  "package app\n\nfunc ServiceInitialize() {}",
+	}
+	svc := setupSearchService(t, t.TempDir(), files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	search := func(q string) string {
+		result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: q})
+		text := ExtractTextContent(result)
+		if result.IsError {
+			t.Fatalf("Search failed: %s", text)
+		}
+		return text
+	}
+
+	// Declarations of both words first, the qualified name before the
+	// scattered words, and the joined symbol found
+	text := search("Service Initialize")
+	service := strings.Index(text, "`service.go`")
+	caller := strings.Index(text, "`caller.go`")
+	notes := strings.Index(text, "`notes.md`")
+	if service < 0 || caller < 0 || notes < 0 || !(service < caller && caller < notes) {
+		t.Errorf("Expected service.go, caller.go, notes.md in that order:\n%s", text)
+	}
+	if !strings.Contains(text, "`joined.go`") {
+		t.Errorf("Expected the joined symbol to be found:\n%s", text)
+	}
+
+	// A qualified name also finds the declarations and the joined symbol
+	text = search("Service.Initialize")
+	for _, file := range []string{"`caller.go`", "`service.go`", "`joined.go`"} {
+		if !strings.Contains(text, file) {
+			t.Errorf("Expected %s for a qualified name:\n%s", file, text)
+		}
+	}
+}
+
+func TestSearchHandler_RankingProfiles(t *testing.T) {
+	files := map[string]string{
+		"notes.md":  "The cache is refreshed by the worker every minute.",
+		"cache.go":  "package app\n\ntype Cache struct{}\n\n// the cache\nfunc Refresh() {}",
+		"worker.go": "package app\n\nfunc run() {\n\t// refill the cache\n}",
+	}
+	svc := setupSearchService(t, t.TempDir(), files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	search := func(ctx context.Context, profile string) string {
+		result, _, _ := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "cache", Profile: profile})
+		text := ExtractTextContent(result)
+		if result.IsError {
+			t.Fatalf("Search with profile %q failed: %s", profile, text)
+		}
+		return text
+	}
+	first := func(text string) string {
+		if m := regexp.MustCompile("`([^`]+)`").FindStringSubmatch(text[strings.Index(text, "**1."):]); m != nil {
+			return m[1]
+		}
+		return ""
+	}
+
+	// By default the declaration comes first, without a profile note
+	text := search(context.Background(), "")
+	if first(text) != "cache.go" || strings.Contains(text, "Ranked with") {
+		t.Errorf("Expected cache.go first without a profile note:\n%s", text)
+	}
+
+	// docs-first puts the documentation first
+	text = search(context.Background(), config.RankingProfileDocs)
+	if first(text) != "notes.md" || !strings.Contains(text, "_Ranked with the docs-first profile_") {
+		t.Errorf("Expected notes.md first with docs-first:\n%s", text)
+	}
+
+	// definitions-first leaves the documentation out
+	text = search(context.Background(), config.RankingProfileDefinitions)
+	if first(text) != "cache.go" || strings.Contains(text, "`notes.md`") {
+		t.Errorf("Expected cache.go first and no notes.md with definitions-first:\n%s", text)
+	}
+
+	// The profile of the API key applies when the search selects none
+	text = search(auth.WithRankingProfile(context.Background(), config.RankingProfileDocs), "")
+	if first(text) != "notes.md" {
+		t.Errorf("Expected the profile of the API key to apply:\n%s", text)
+	}
+
+	// recent-first without a history index says so
+	text = search(context.Background(), config.RankingProfileRecent)
+	if !strings.Contains(text, "no recently modified files are known") {
+		t.Errorf("Expected a note about the missing history index:\n%s", text)
+	}
+
+	// recent-first puts the files of recent commits first
+	state := svc.manifest.GetRepoState("github.com_test_repo")
+	state.History = &HistorySnapshot{Commits: []HistoryCommit{{SHA: "def456", Modified: []string{"worker.go"}}}}
+	svc.manifest.SetRepoState("github.com_test_repo", *state)
+	text = search(context.Background(), config.RankingProfileRecent)
+	if first(text) != "worker.go" || !strings.Contains(text, "_Ranked with the recent-first profile_") {
+		t.Errorf("Expected worker.go first with recent-first:\n%s", text)
+	}
+
+	// An unknown profile is an error
+	result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "cache", Profile: "newest"})
+	if !result.IsError || !strings.Contains(ExtractTextContent(result), "unknown profile") {
+		t.Errorf("Expected an unknown profile error, got: %s", ExtractTextContent(result))
+	}
+}
+
+func TestSearchHandler_GeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"user.go":     "package api\n\ntype UserRecord struct{}",
+		"user_gen.go": "// Code generated by mockgen. DO NOT EDIT.\npackage api\n\ntype MockUserRecord struct{}",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "package"})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+	if !strings.Contains(text, "user.go") || strings.Contains(text, "user_gen.go") {
+		t.Errorf("Expected generated file to be excluded, got: %s", text)
+	}
+
+	result, _, _ = handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "package", IncludeGenerated: true})
+	text = ExtractTextContent(result)
+	if !strings.Contains(text, "user.go") || !strings.Contains(text, "user_gen.go") {
+		t.Errorf("Expected both files with include_generated, got: %s", text)
+	}
+}
+
+func TestSearchHandler_HighlightTags(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go": "package main\n\nfunc connect() {}",
+	}
+	svc := setupSearchService(t, dir, files)
+	defer func() { _ = svc.Close() }()
+
+	handler := NewSearchHandler(svc)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		settings config.GitReposSettings
+		want     string
+	}{
+		{"markdown", config.GitReposSettings{Highlight: true, HighlightPre: "**", HighlightPost: "**"}, "func **connect**()"},
+		{"custom", config.GitReposSettings{Highlight: true, HighlightPre: "[", HighlightPost: "]"}, "func [connect]()"},
+		{"disabled", config.GitReposSettings{Highlight: false, HighlightPre: "**", HighlightPost: "**"}, "func connect()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := *svc.GetSettings()
+			settings.Highlight = tt.settings.Highlight
+			settings.HighlightPre = tt.settings.HighlightPre
+			settings.HighlightPost = tt.settings.HighlightPost
+			svc.settings = &settings
+
+			result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, SearchArgument{Query: "connect"})
+			if err != nil {
+				t.Fatalf("Handle returned error: %v", err)
+			}
+			text := ExtractTextContent(result)
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("Expected fragment %q, got: %s", tt.want, text)
+			}
+			if strings.ContainsAny(text, highlightStart+highlightEnd+"\x1b") || strings.Contains(text, "<mark>") {
+				t.Errorf("Unexpected highlight markup in: %q", text)
+			}
+		})
+	}
+}
+
 // ============================
 // Helper to set up a service with indexed files for testing
 // ============================
 
+func TestSearchHandler_Declarations(t *testing.T) {
+	files := map[string]string{
+		"server.go": "package app\n\nfunc Start() {}\n\n// Refresh reloads the cache.\nfunc Refresh() {\n\tcache.Load()\n}\n",
+		"notes.md":  "The cache is refreshed by the worker.",
+	}
+	svc := setupSearchServiceWith(t, t.TempDir(), files, func(settings *config.GitReposSettings) {
+		settings.IndexDeclarations = true
+	})
+	defer func() { _ = svc.Close() }()
+
+	result, _, _ := NewSearchHandler(svc).Handle(context.Background(), &mcp.CallToolRequest{}, SearchArgument{Query: "cache"})
+	text := ExtractTextContent(result)
+	if result.IsError {
+		t.Fatalf("Search failed: %s", text)
+	}
+
+	// The declaration is found in place of its file, and files that are not
+	// split as a whole
+	if !strings.Contains(text, "`server.go` lines 5-8, `Refresh`") {
+		t.Errorf("Expected the Refresh declaration as a hit:\n%s", text)
+	}
+	if strings.Count(text, "`server.go`") != 1 {
+		t.Errorf("Expected server.go once:\n%s", text)
+	}
+	if !strings.Contains(text, "`notes.md`") || strings.Contains(text, "`notes.md` lines") {
+		t.Errorf("Expected notes.md as a whole file:\n%s", text)
+	}
+}
+
 func setupSearchService(t *testing.T, baseDir string, files map[string]string) *Service {
 	t.Helper()
 	return setupSearchServiceWithMaxResults(t, baseDir, files, 20)
@@ -395,14 +1069,23 @@ func setupSearchService(t *testing.T, baseDir string, files map[string]string) *
 
 func setupSearchServiceWithMaxResults(t *testing.T, baseDir string, files map[string]string, maxResults int) *Service {
 	t.Helper()
+	return setupSearchServiceWith(t, baseDir, files, func(settings *config.GitReposSettings) {
+		settings.MaxResults = maxResults
+	})
+}
+
+// setupSearchServiceWith is setupSearchService with settings changed by configure.
+func setupSearchServiceWith(t *testing.T, baseDir string, files map[string]string, configure func(*config.GitReposSettings)) *Service {
+	t.Helper()
 
 	settings := &config.GitReposSettings{
 		URLs:        []string{"git@github.com:test/repo.git"},
 		BaseDir:     baseDir,
 		SyncTimeout: 5 * time.Second,
 		MaxFileSize: 256 * 1024,
-		MaxResults:  maxResults,
+		MaxResults:  20,
 	}
+	configure(settings)
 
 	svc, err := NewService(settings)
 	if err != nil {
@@ -0,0 +1,176 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// StatsArgument defines repo_stats parameters.
+type StatsArgument struct {
+	Repository string `json:"repository,omitempty" jsonschema_description:"Filter by repository name (substring match)"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// StatsHandler handles the repo_stats MCP tool.
+type StatsHandler struct {
+	service StatsService
+}
+
+// NewStatsHandler creates a new repo_stats handler.
+func NewStatsHandler(service StatsService) *StatsHandler {
+	return &StatsHandler{
+		service: service,
+	}
+}
+
+// Handle reports the indexing state of the configured repositories.
+func (h *StatsHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args StatsArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "repo_stats", config.ScopeSearch); result != nil {
+		return result, nil, nil
+	}
+
+	states := h.service.RepoStates()
+
+	names := make([]string, 0, len(states))
+	byName := make(map[string]RepoState, len(states))
+	repoIDs := make(map[string]string, len(states))
+	for repoID, state := range states {
+		name := RepoIDToDisplay(repoID)
+		if args.Repository != "" && !strings.Contains(name, args.Repository) {
+			continue
+		}
+		names = append(names, name)
+		byName[name] = state
+		repoIDs[name] = repoID
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("No repositories match: %s", args.Repository)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	var sb strings.Builder
+	for _, name := range names {
+		formatRepoState(&sb, name, byName[name], h.service.CatalogSummary(repoIDs[name]), formatFrom(ctx), time.Now())
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// formatRepoState writes a markdown summary of a repository's state and, if
+// available, its cataloged files. Times are shown with their age at now.
+func formatRepoState(sb *strings.Builder, name string, state RepoState, catalog *CatalogSummary, format FormatOptions, now time.Time) {
+	sb.WriteString(fmt.Sprintf("**%s**\n", name))
+
+	if state.LastIndexed == "" {
+		sb.WriteString("- Not indexed yet\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("- Indexed commit: `%s` (%d files)\n", state.LastIndexed, state.FileCount))
+	}
+	if catalog != nil && catalog.Files > 0 {
+		languages := make([]string, 0, len(catalog.Languages))
+		for language := range catalog.Languages {
+			languages = append(languages, language)
+		}
+		// Most common languages first
+		sort.Slice(languages, func(a, b int) bool {
+			if catalog.Languages[languages[a]] != catalog.Languages[languages[b]] {
+				return catalog.Languages[languages[a]] > catalog.Languages[languages[b]]
+			}
+			return languages[a] < languages[b]
+		})
+
+		counts := make([]string, 0, len(languages))
+		for _, language := range languages {
+			name := language
+			if name == "" {
+				name = "other"
+			}
+			counts = append(counts, fmt.Sprintf("%d %s", catalog.Languages[language], name))
+		}
+		sb.WriteString(fmt.Sprintf("- Indexed content: %s (%s)\n", format.size(catalog.Bytes, 1), strings.Join(counts, ", ")))
+	}
+	if state.Branch != "" {
+		sb.WriteString(fmt.Sprintf("- Branch: %s\n", state.Branch))
+	}
+	if state.License != "" {
+		sb.WriteString(fmt.Sprintf("- License: %s\n", state.License))
+	}
+	if !state.LastPull.IsZero() {
+		sb.WriteString(fmt.Sprintf("- Last synced: %s\n", FormatTime(state.LastPull, now)))
+	}
+	if !state.RemovedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("- Removed from configuration: %s\n", FormatTime(state.RemovedAt, now)))
+	}
+
+	if state.Skipped != nil && state.Skipped.Total() > 0 {
+		reasons := make([]string, 0, len(state.Skipped.Counts))
+		for reason := range state.Skipped.Counts {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+
+		counts := make([]string, 0, len(reasons))
+		for _, reason := range reasons {
+			counts = append(counts, fmt.Sprintf("%d %s", state.Skipped.Counts[reason], strings.ReplaceAll(reason, "_", " ")))
+		}
+		sb.WriteString(fmt.Sprintf("- Skipped files: %d (%s)\n", state.Skipped.Total(), strings.Join(counts, ", ")))
+
+		if len(state.Skipped.Largest) > 0 {
+			sb.WriteString("- Largest skipped files:\n")
+			for _, file := range state.Skipped.Largest {
+				sb.WriteString(fmt.Sprintf("  - `%s` (%s, %s)\n", file.Path, format.size(file.Size, 1), strings.ReplaceAll(file.Reason, "_", " ")))
+			}
+		}
+	}
+
+	if state.Verification != "" {
+		sb.WriteString(fmt.Sprintf("- Index verification: %s\n", state.Verification))
+	}
+	if state.Warning != "" {
+		sb.WriteString(fmt.Sprintf("- Warning: %s\n", state.Warning))
+	}
+	if state.Error != "" {
+		sb.WriteString(fmt.Sprintf("- Error: %s\n", state.Error))
+	}
+	sb.WriteString("\n")
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *StatsHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "repo_stats",
+		Description: `Show indexing statistics for the configured git repositories.
+
+WHEN TO USE: Use when a file you expect is missing from search results, or to
+check whether a repository has been indexed and is up to date.
+
+HOW IT WORKS: Returns the indexed commit, file count, size and language
+breakdown per repository, the detected license, the number of files skipped by reason (excluded pattern, too large, binary,
+symlink, unreadable) with the largest skipped files, and any sync warnings
+or errors.`,
+	}
+}
+
+// RegisterStatsTool registers the repo_stats tool with an MCP server.
+func RegisterStatsTool(server *mcp.Server, service StatsService) {
+	handler := NewStatsHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
@@ -0,0 +1,119 @@
+package gitrepos
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// mockStatsService implements StatsService for handler tests.
+type mockStatsService struct {
+	states  map[string]RepoState
+	catalog map[string]*CatalogSummary
+}
+
+func (m *mockStatsService) RepoStates() map[string]RepoState { return m.states }
+func (m *mockStatsService) CatalogSummary(repoID string) *CatalogSummary {
+	return m.catalog[repoID]
+}
+
+func TestStatsHandler_FormatsState(t *testing.T) {
+	handler := NewStatsHandler(&mockStatsService{states: map[string]RepoState{
+		"github.com_org_api": {
+			LastIndexed: "abc123",
+			FileCount:   42,
+			Branch:      "trunk",
+			License:     "Apache-2.0",
+			LastPull:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Skipped: &SkipStats{
+				Counts:  map[string]int{SkipReasonTooLarge: 2, SkipReasonBinary: 1},
+				Largest: []SkippedFile{{Path: "dump.sql", Size: 2048, Reason: SkipReasonTooLarge}},
+			},
+			Warning: "index budget exceeded",
+		},
+		"github.com_org_web": {Error: "clone failed"},
+	}, catalog: map[string]*CatalogSummary{
+		"github.com_org_api": {Files: 42, Bytes: 4096, Languages: map[string]int{"go": 40, "markdown": 2}},
+	}})
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, StatsArgument{})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	text := ExtractTextContent(result)
+
+	for _, want := range []string{
+		"**github.com/org/api**",
+		"Indexed commit: `abc123` (42 files)",
+		"Indexed content: 4.0 KB (40 go, 2 markdown)",
+		"Branch: trunk",
+		"License: Apache-2.0",
+		"Last synced: 2026-01-02T03:04:05Z (",
+		"Skipped files: 3 (1 binary, 2 too large)",
+		"`dump.sql` (2.0 KB, too large)",
+		"Warning: index budget exceeded",
+		"**github.com/org/web**",
+		"Not indexed yet",
+		"Error: clone failed",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in output:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "org/api") > strings.Index(text, "org/web") {
+		t.Error("Expected repositories sorted by name")
+	}
+}
+
+func TestStatsHandler_RepositoryFilter(t *testing.T) {
+	handler := NewStatsHandler(&mockStatsService{states: map[string]RepoState{
+		"github.com_org_api": {},
+		"github.com_org_web": {},
+	}})
+
+	result, _, _ := handler.Handle(context.Background(), &mcp.CallToolRequest{}, StatsArgument{Repository: "web"})
+	text := ExtractTextContent(result)
+	if strings.Contains(text, "org/api") || !strings.Contains(text, "org/web") {
+		t.Errorf("Expected only the matching repository, got: %s", text)
+	}
+
+	result, _, _ = handler.Handle(context.Background(), &mcp.CallToolRequest{}, StatsArgument{Repository: "missing"})
+	if !result.IsError {
+		t.Error("Expected error when no repository matches")
+	}
+}
+
+func TestStatsHandler_GetToolDefinition(t *testing.T) {
+	tool := NewStatsHandler(&mockStatsService{}).GetToolDefinition()
+	if tool.Name != "repo_stats" {
+		t.Errorf("Expected tool name 'repo_stats', got '%s'", tool.Name)
+	}
+}
+
+func TestService_RepoStates(t *testing.T) {
+	manifest := newMockManifestOps()
+	manifest.repos["github.com_test_repo"] = RepoState{FileCount: 7}
+	manifest.repos["github.com_test_removed"] = RepoState{FileCount: 1}
+
+	svc := NewServiceWithDeps(
+		&config.GitReposSettings{
+			URLs: []string{"git@github.com:test/repo.git", "git@github.com:test/new.git"},
+		},
+		ServiceDeps{Manifest: manifest},
+	)
+
+	states := svc.RepoStates()
+	if len(states) != 2 {
+		t.Fatalf("Expected 2 configured repositories, got %v", states)
+	}
+	if states["github.com_test_repo"].FileCount != 7 {
+		t.Errorf("Unexpected state: %+v", states["github.com_test_repo"])
+	}
+	if _, ok := states["github.com_test_new"]; !ok {
+		t.Error("Expected unsynced repository to be listed")
+	}
+}
@@ -0,0 +1,110 @@
+package gitrepos
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+)
+
+// UpdateRepositoriesArgument defines update_repositories parameters.
+type UpdateRepositoriesArgument struct {
+	Add    []string `json:"add,omitempty" jsonschema_description:"URLs of repositories to add (e.g., git@github.com:org/repo.git)"`
+	Remove []string `json:"remove,omitempty" jsonschema_description:"Repositories to remove, by name (e.g., github.com/org/repo) or URL"`
+
+	ConsistencyArgument
+	FormatArgument
+}
+
+// UpdateRepositoriesHandler handles the update_repositories MCP tool.
+type UpdateRepositoriesHandler struct {
+	service RepositoryService
+}
+
+// NewUpdateRepositoriesHandler creates a new update_repositories handler.
+func NewUpdateRepositoriesHandler(service RepositoryService) *UpdateRepositoriesHandler {
+	return &UpdateRepositoriesHandler{
+		service: service,
+	}
+}
+
+// Handle adds and removes the requested repositories.
+func (h *UpdateRepositoriesHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args UpdateRepositoriesArgument) (*mcp.CallToolResult, any, error) {
+	if result := scopeError(ctx, "update_repositories", config.ScopeAdmin); result != nil {
+		return result, nil, nil
+	}
+
+	if !slices.ContainsFunc(append(slices.Clone(args.Add), args.Remove...), func(entry string) bool { return strings.TrimSpace(entry) != "" }) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Provide repositories to add or remove"},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	update, err := h.service.UpdateRepositories(ctx, args.Add, args.Remove)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Update failed: %s", err)},
+			},
+			IsError: true,
+		}, nil, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: formatRepositoryUpdate(update)},
+		},
+		IsError: len(update.Failed) > 0,
+	}, nil, nil
+}
+
+// formatRepositoryUpdate describes the outcome of an update, one line per
+// change.
+func formatRepositoryUpdate(update *RepositoryUpdate) string {
+	if len(update.Added) == 0 && len(update.Removed) == 0 {
+		return "No repositories changed; the added ones are already configured"
+	}
+
+	var sb strings.Builder
+	for _, name := range update.Added {
+		if msg, ok := update.Failed[name]; ok {
+			sb.WriteString(fmt.Sprintf("Added %s, but its sync failed: %s\n", name, msg))
+		} else {
+			sb.WriteString(fmt.Sprintf("Added and indexed %s\n", name))
+		}
+	}
+	for _, name := range update.Removed {
+		sb.WriteString(fmt.Sprintf("Removed %s; its clone and index are deleted after %s unless it is added back\n", name, update.Retention))
+	}
+	return sb.String()
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *UpdateRepositoriesHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "update_repositories",
+		Description: `Add or remove git repositories served by this server, without a restart.
+
+WHEN TO USE: Administrative operation. Use only when asked to onboard or drop
+repositories.
+
+HOW IT WORKS: Added repositories are cloned and indexed before the call
+returns, which can take minutes for large repositories. Removed repositories
+leave search right away. The changes are kept across restarts, on top of the
+configured repository list. URLs must pass the server's allowed and denied
+URL lists.`,
+	}
+}
+
+// RegisterUpdateRepositoriesTool registers the update_repositories tool with
+// an MCP server.
+func RegisterUpdateRepositoriesTool(server *mcp.Server, service RepositoryService) {
+	handler := NewUpdateRepositoriesHandler(service)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
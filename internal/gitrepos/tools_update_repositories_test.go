@@ -0,0 +1,67 @@
+package gitrepos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// mockRepositoryService implements RepositoryService for handler tests.
+type mockRepositoryService struct {
+	add, remove []string
+	update      *RepositoryUpdate
+	err         error
+}
+
+func (m *mockRepositoryService) UpdateRepositories(_ context.Context, add, remove []string) (*RepositoryUpdate, error) {
+	m.add, m.remove = add, remove
+	return m.update, m.err
+}
+
+func TestUpdateRepositoriesHandler_Success(t *testing.T) {
+	svc := &mockRepositoryService{update: &RepositoryUpdate{
+		Added:     []string{"github.com/org/a", "github.com/org/b"},
+		Removed:   []string{"github.com/org/c"},
+		Failed:    map[string]string{"github.com/org/b": "clone failed"},
+		Retention: 24 * time.Hour,
+	}}
+	handler := NewUpdateRepositoriesHandler(svc)
+
+	result, _, err := handler.Handle(adminContext(), &mcp.CallToolRequest{}, UpdateRepositoriesArgument{
+		Add:    []string{"git@github.com:org/a.git", "git@github.com:org/b.git"},
+		Remove: []string{"github.com/org/c"},
+	})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	expected := "Added and indexed github.com/org/a\n" +
+		"Added github.com/org/b, but its sync failed: clone failed\n" +
+		"Removed github.com/org/c; its clone and index are deleted after 24h0m0s unless it is added back\n"
+	if text := ExtractTextContent(result); text != expected {
+		t.Errorf("Unexpected result:\n%s\nexpected:\n%s", text, expected)
+	}
+	if !result.IsError {
+		t.Error("Expected a failed sync to mark the result as an error")
+	}
+	if len(svc.add) != 2 || len(svc.remove) != 1 {
+		t.Errorf("Unexpected service call: add %v, remove %v", svc.add, svc.remove)
+	}
+}
+
+func TestUpdateRepositoriesHandler_Errors(t *testing.T) {
+	svc := &mockRepositoryService{err: errors.New("repository not configured: x")}
+	handler := NewUpdateRepositoriesHandler(svc)
+
+	result, _, _ := handler.Handle(adminContext(), &mcp.CallToolRequest{}, UpdateRepositoriesArgument{Add: []string{" "}})
+	if !result.IsError || svc.add != nil {
+		t.Error("Expected an empty update to be rejected without calling the service")
+	}
+
+	result, _, _ = handler.Handle(adminContext(), &mcp.CallToolRequest{}, UpdateRepositoriesArgument{Remove: []string{"x"}})
+	if !result.IsError || ExtractTextContent(result) != "Update failed: repository not configured: x" {
+		t.Errorf("Expected service error, got: %s", ExtractTextContent(result))
+	}
+}
@@ -0,0 +1,102 @@
+package gitrepos
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+const (
+	// verifySampleSize is the number of documents whose stored fields are
+	// checked by VerifyIndex
+	verifySampleSize = 20
+
+	// VerificationOK is the verification status of an index that passed
+	VerificationOK = "ok"
+)
+
+// ErrIndexVerification is returned by VerifyIndex when an index does not hold
+// what was written to it.
+var ErrIndexVerification = errors.New("index verification failed")
+
+// VerifyIndex checks the index written by the last full index of a
+// repository: its document count must match the number of indexed files, and
+// an evenly spread sample of the indexed files must be stored with the same
+// path and content. This catches batches that failed without an error.
+func (i *Indexer) VerifyIndex(repoID string, fileCount int) (err error) {
+	i.runMu.Lock()
+	changes := i.catalog[repoID]
+	i.runMu.Unlock()
+	if changes == nil || !changes.Full {
+		return fmt.Errorf("%w: no full index recorded for %s", ErrIndexVerification, repoID)
+	}
+
+	index, err := i.OpenForRead(repoID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := index.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	// Directory documents of an earlier run and declarations are not files
+	files := bleve.NewBooleanQuery()
+	files.AddMust(bleve.NewMatchAllQuery())
+	files.AddMustNot(directoryKindQuery(), declarationKindQuery())
+	countReq := bleve.NewSearchRequest(files)
+	countReq.Size = 0
+	counted, err := index.Search(countReq)
+	if err != nil {
+		return err
+	}
+	if count := counted.Total; count != uint64(fileCount) {
+		return fmt.Errorf("%w: %d documents for %d indexed files", ErrIndexVerification, count, fileCount)
+	}
+
+	sample := sampleFiles(changes.Files, verifySampleSize)
+	if len(sample) == 0 {
+		return nil
+	}
+	ids := make([]string, len(sample))
+	expected := make(map[string]CatalogFile, len(sample))
+	for n, file := range sample {
+		ids[n] = repoID + "/" + file.Path
+		expected[ids[n]] = file
+	}
+
+	req := bleve.NewSearchRequest(bleve.NewDocIDQuery(ids))
+	req.Size = len(ids)
+	req.Fields = []string{domain.CodeFieldFilePath, domain.CodeFieldContent}
+	result, err := index.Search(req)
+	if err != nil {
+		return err
+	}
+	if len(result.Hits) != len(ids) {
+		return fmt.Errorf("%w: %d of %d sampled documents found", ErrIndexVerification, len(result.Hits), len(ids))
+	}
+	for _, hit := range result.Hits {
+		file := expected[hit.ID]
+		path, _ := hit.Fields[domain.CodeFieldFilePath].(string)
+		content, _ := hit.Fields[domain.CodeFieldContent].(string)
+		if path != file.Path || blobHash([]byte(content)) != file.Hash {
+			return fmt.Errorf("%w: stored fields of %s do not match the indexed file", ErrIndexVerification, file.Path)
+		}
+	}
+	return nil
+}
+
+// sampleFiles returns up to n files spread evenly over files.
+func sampleFiles(files []CatalogFile, n int) []CatalogFile {
+	if len(files) <= n {
+		return files
+	}
+	sample := make([]CatalogFile, n)
+	for k := range sample {
+		sample[k] = files[k*len(files)/n]
+	}
+	return sample
+}
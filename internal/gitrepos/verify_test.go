@@ -0,0 +1,73 @@
+package gitrepos
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/sha1n/mcp-relic-server/internal/domain"
+)
+
+func TestIndexer_VerifyIndex(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repos", "testrepo")
+	indexer := NewIndexer(dir, NewFileFilter(256*1024), 256*1024)
+
+	for n := range 30 {
+		createTestFile(t, repoDir, fmt.Sprintf("pkg/file%02d.go", n), fmt.Sprintf("package pkg // %d", n))
+	}
+	count, err := indexer.FullIndex("testrepo", repoDir)
+	if err != nil {
+		t.Fatalf("FullIndex failed: %v", err)
+	}
+
+	if err := indexer.VerifyIndex("testrepo", count); err != nil {
+		t.Errorf("Expected verification to pass: %v", err)
+	}
+	if err := indexer.VerifyIndex("testrepo", count+1); !errors.Is(err, ErrIndexVerification) {
+		t.Errorf("Expected a document count mismatch, got: %v", err)
+	}
+
+	// Overwrite a sampled document with different content
+	index, err := indexer.OpenForWrite("testrepo")
+	if err != nil {
+		t.Fatalf("OpenForWrite failed: %v", err)
+	}
+	doc := domain.CodeDocument{ID: "testrepo/pkg/file00.go", FilePath: "pkg/file00.go", Content: "tampered"}
+	if err := index.Index(doc.ID, doc); err != nil {
+		t.Fatalf("Index failed: %v", err)
+	}
+	_ = index.Close()
+
+	if err := indexer.VerifyIndex("testrepo", count); !errors.Is(err, ErrIndexVerification) {
+		t.Errorf("Expected a stored content mismatch, got: %v", err)
+	}
+}
+
+func TestIndexer_VerifyIndex_NoFullIndex(t *testing.T) {
+	indexer := NewIndexer(t.TempDir(), NewFileFilter(256*1024), 256*1024)
+
+	if err := indexer.VerifyIndex("testrepo", 0); !errors.Is(err, ErrIndexVerification) {
+		t.Errorf("Expected verification to fail without a full index, got: %v", err)
+	}
+}
+
+func TestSampleFiles(t *testing.T) {
+	files := make([]CatalogFile, 100)
+	for n := range files {
+		files[n].Path = fmt.Sprintf("file%d", n)
+	}
+
+	sample := sampleFiles(files, 4)
+	want := []string{"file0", "file25", "file50", "file75"}
+	for n, file := range sample {
+		if file.Path != want[n] {
+			t.Errorf("sample[%d] = %s, want %s", n, file.Path, want[n])
+		}
+	}
+
+	if got := sampleFiles(files[:3], 4); len(got) != 3 {
+		t.Errorf("Expected all of a small list, got %d", len(got))
+	}
+}
@@ -0,0 +1,164 @@
+package gitrepos
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchDebounce is how long the filesystem watcher waits for changes to
+// settle before reindexing, so that bursts (saves, checkouts, formatters)
+// are indexed in a single batch.
+const WatchDebounce = 500 * time.Millisecond
+
+// fileWatcher reports files changed under a working directory in debounced
+// batches of paths relative to the root. Directories excluded by the filter
+// (e.g. .git, node_modules) are not watched.
+type fileWatcher struct {
+	watcher  *fsnotify.Watcher
+	root     string
+	filter   *FileFilter
+	debounce time.Duration
+	onChange func(files []string)
+}
+
+// newFileWatcher creates a watcher for root and all non-excluded subdirectories.
+func newFileWatcher(root string, filter *FileFilter, debounce time.Duration, onChange func(files []string)) (*fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &fileWatcher{
+		watcher:  watcher,
+		root:     root,
+		filter:   filter,
+		debounce: debounce,
+		onChange: onChange,
+	}
+	if _, err := w.addRecursive(root); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// addRecursive watches dir and its subdirectories, and returns the files
+// found in them.
+func (w *fileWatcher) addRecursive(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip entries with errors
+		}
+		rel, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return nil
+		}
+
+		if !d.IsDir() {
+			if !w.filter.ShouldExclude(rel) {
+				files = append(files, rel)
+			}
+			return nil
+		}
+		if rel != "." && w.filter.ShouldExcludeDir(rel) {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+	return files, err
+}
+
+// run processes events until stop is closed. Changed files are collected and
+// passed to onChange once no further changes arrive within the debounce period.
+func (w *fileWatcher) run(stop <-chan struct{}) {
+	pending := make(map[string]struct{})
+	var timer *time.Timer
+	var flush <-chan time.Time
+
+	for {
+		select {
+		case <-stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			for _, rel := range w.changedFiles(event) {
+				pending[rel] = struct{}{}
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				timer.Reset(w.debounce)
+			}
+			flush = timer.C
+
+		case <-flush:
+			files := make([]string, 0, len(pending))
+			for rel := range pending {
+				files = append(files, rel)
+			}
+			sort.Strings(files)
+			pending = make(map[string]struct{})
+			flush = nil
+			w.onChange(files)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("File watcher error", "error", err)
+		}
+	}
+}
+
+// changedFiles returns the files affected by an event. New directories are
+// watched, and the files already in them reported, since they may have been
+// written before the watch was added.
+func (w *fileWatcher) changedFiles(event fsnotify.Event) []string {
+	if event.Op == fsnotify.Chmod {
+		return nil
+	}
+
+	rel, err := filepath.Rel(w.root, event.Name)
+	if err != nil {
+		return nil
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if w.filter.ShouldExcludeDir(rel) {
+				return nil
+			}
+			files, err := w.addRecursive(event.Name)
+			if err != nil {
+				slog.Warn("Failed to watch directory", "path", rel, "error", err)
+			}
+			return files
+		}
+	}
+
+	if w.filter.ShouldExclude(rel) {
+		return nil
+	}
+	return []string{rel}
+}
+
+// Close stops watching.
+func (w *fileWatcher) Close() error {
+	return w.watcher.Close()
+}
@@ -0,0 +1,102 @@
+package gitrepos
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// startTestWatcher starts a watcher on dir and returns a channel of reported batches.
+func startTestWatcher(t *testing.T, dir string) <-chan []string {
+	t.Helper()
+	batches := make(chan []string, 10)
+	w, err := newFileWatcher(dir, NewFileFilter(256*1024), 50*time.Millisecond, func(files []string) {
+		batches <- files
+	})
+	if err != nil {
+		t.Fatalf("newFileWatcher failed: %v", err)
+	}
+
+	stop := make(chan struct{})
+	go w.run(stop)
+	t.Cleanup(func() {
+		close(stop)
+		_ = w.Close()
+	})
+	return batches
+}
+
+func waitForBatch(t *testing.T, batches <-chan []string) []string {
+	t.Helper()
+	select {
+	case files := <-batches:
+		return files
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for file changes")
+		return nil
+	}
+}
+
+func TestFileWatcher_DebouncesChanges(t *testing.T) {
+	dir := t.TempDir()
+	batches := startTestWatcher(t, dir)
+
+	writeTestFile(t, dir, "a.go", "package a")
+	writeTestFile(t, dir, "b.go", "package b")
+	writeTestFile(t, dir, "a.go", "package a // edited")
+
+	files := waitForBatch(t, batches)
+	if !slices.Equal(files, []string{"a.go", "b.go"}) {
+		t.Errorf("Expected a single batch with both files, got %v", files)
+	}
+}
+
+func TestFileWatcher_ReportsDeletions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "old.go", "package old")
+	batches := startTestWatcher(t, dir)
+
+	if err := os.Remove(filepath.Join(dir, "old.go")); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	if files := waitForBatch(t, batches); !slices.Equal(files, []string{"old.go"}) {
+		t.Errorf("Expected deleted file to be reported, got %v", files)
+	}
+}
+
+func TestFileWatcher_WatchesNewDirectories(t *testing.T) {
+	dir := t.TempDir()
+	batches := startTestWatcher(t, dir)
+
+	writeTestFile(t, dir, filepath.Join("pkg", "util.go"), "package pkg")
+
+	// The file may be reported with the directory creation or on its own write
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case files := <-batches:
+			if slices.Contains(files, filepath.Join("pkg", "util.go")) {
+				return
+			}
+		case <-deadline:
+			t.Fatal("Expected file in new directory to be reported")
+		}
+	}
+}
+
+func TestFileWatcher_IgnoresExcludedPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, filepath.Join("node_modules", "lib", "index.js"), "x")
+	batches := startTestWatcher(t, dir)
+
+	writeTestFile(t, dir, filepath.Join("node_modules", "lib", "index.js"), "changed")
+	writeTestFile(t, dir, "logo.png", "png")
+	writeTestFile(t, dir, "main.go", "package main")
+
+	if files := waitForBatch(t, batches); !slices.Equal(files, []string{"main.go"}) {
+		t.Errorf("Expected only main.go to be reported, got %v", files)
+	}
+}
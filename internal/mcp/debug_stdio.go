@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// maxFrameString is the length above which string values of logged frames,
+// such as file contents and search results, are truncated
+const maxFrameString = 512
+
+// redactedValue replaces the values of credential fields in logged frames
+const redactedValue = "***"
+
+// credentialKeys are the (lowercase, separator-free) names of frame fields
+// whose values are never logged
+var credentialKeys = map[string]bool{
+	"authorization": true,
+	"apikey":        true,
+	"token":         true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"password":      true,
+	"secret":        true,
+	"clientsecret":  true,
+	"cookie":        true,
+}
+
+// NewFrameLogTransport returns a transport that writes every JSON-RPC frame
+// read from or written to transport to w, one line per frame, for debugging
+// protocol mismatches with clients. Frames are redacted: credential fields
+// are masked, credentials embedded in URLs are removed and long strings are
+// truncated.
+func NewFrameLogTransport(transport mcp.Transport, w io.Writer) mcp.Transport {
+	return &mcp.LoggingTransport{Transport: transport, Writer: &frameLogWriter{w: w}}
+}
+
+// frameLogWriter redacts the frame lines of mcp.LoggingTransport, which
+// writes each line ("read: <frame>", "write: <frame>" or an error) at once,
+// and prefixes them with the time.
+type frameLogWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time // nil uses time.Now
+}
+
+func (f *frameLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	if direction, frame, ok := strings.Cut(line, ": "); ok {
+		if redacted, err := redactFrame([]byte(frame)); err == nil {
+			line = direction + ": " + redacted
+		} else {
+			line = direction + ": " + gitrepos.RedactURLCredentials(frame)
+		}
+	}
+
+	now := time.Now
+	if f.now != nil {
+		now = f.now
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := fmt.Fprintf(f.w, "%s %s\n", now().UTC().Format(time.RFC3339Nano), line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// redactFrame returns the redacted JSON encoding of a frame.
+func redactFrame(frame []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(frame))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactValue(value)); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// redactValue redacts a decoded JSON value in place and returns it.
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if _, isString := field.(string); isString && isCredentialKey(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []any:
+		for n, item := range v {
+			v[n] = redactValue(item)
+		}
+	case string:
+		return truncateFrameString(gitrepos.RedactURLCredentials(v))
+	}
+	return value
+}
+
+// isCredentialKey reports whether a frame field holds a credential.
+func isCredentialKey(key string) bool {
+	key = strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+	return credentialKeys[key]
+}
+
+// truncateFrameString shortens s to maxFrameString bytes, noting its length.
+func truncateFrameString(s string) string {
+	if len(s) <= maxFrameString {
+		return s
+	}
+	cut := maxFrameString
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", s[:cut], len(s))
+}
@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// clientLoggerName identifies this server in MCP log notifications
+const clientLoggerName = "relic"
+
+// ClientLogHandler is a slog.Handler that passes every record to the next
+// handler and also forwards index activity events (records carrying the
+// gitrepos.LogEventKey attribute) to the clients connected to server, as MCP
+// log notifications. Each client still only receives messages at or above the
+// level it requested with logging/setLevel.
+type ClientLogHandler struct {
+	next   slog.Handler
+	server *mcp.Server
+	level  slog.Level
+	attrs  []slog.Attr
+}
+
+// NewClientLogHandler creates a handler forwarding events at or above level to
+// the sessions of server. level is one of the config.ClientLogLevel constants;
+// if it is config.ClientLogLevelOff, next is returned unchanged.
+func NewClientLogHandler(next slog.Handler, server *mcp.Server, level string) slog.Handler {
+	if level == config.ClientLogLevelOff {
+		return next
+	}
+	return &ClientLogHandler{
+		next:   next,
+		server: server,
+		level:  parseClientLogLevel(level),
+	}
+}
+
+// Enabled reports whether either the next handler or the clients want records
+// at level.
+func (h *ClientLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level || h.next.Enabled(ctx, level)
+}
+
+// Handle passes r to the next handler and forwards it to clients if it is an
+// index activity event.
+func (h *ClientLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	if r.Level >= h.level {
+		if data, ok := h.eventData(r); ok {
+			h.forward(ctx, r.Level, data)
+		}
+	}
+	return err
+}
+
+// WithAttrs returns a handler whose records include attrs.
+func (h *ClientLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup returns a handler that groups attributes for the next handler.
+// Forwarded events keep their attributes ungrouped.
+func (h *ClientLogHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	return &clone
+}
+
+// eventData returns the notification payload for r, and false if r is not an
+// index activity event.
+func (h *ClientLogHandler) eventData(r slog.Record) (map[string]any, bool) {
+	data := map[string]any{"message": r.Message}
+	isEvent := false
+	add := func(a slog.Attr) bool {
+		if a.Key == gitrepos.LogEventKey {
+			isEvent = true
+		}
+		data[a.Key] = a.Value.Resolve().Any()
+		if err, ok := data[a.Key].(error); ok {
+			data[a.Key] = err.Error()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+	return data, isEvent
+}
+
+// forward sends a log notification to every connected session. Sessions that
+// have not set a log level, or set a higher one, are skipped by the SDK.
+func (h *ClientLogHandler) forward(ctx context.Context, level slog.Level, data map[string]any) {
+	params := &mcp.LoggingMessageParams{
+		Logger: clientLoggerName,
+		Level:  mcpLoggingLevel(level),
+		Data:   data,
+	}
+	for session := range h.server.Sessions() {
+		_ = session.Log(ctx, params)
+	}
+}
+
+// parseClientLogLevel converts a config.ClientLogLevel constant to a slog level.
+func parseClientLogLevel(level string) slog.Level {
+	switch level {
+	case config.ClientLogLevelDebug:
+		return slog.LevelDebug
+	case config.ClientLogLevelWarn:
+		return slog.LevelWarn
+	case config.ClientLogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// mcpLoggingLevel converts a slog level to the closest MCP logging level.
+func mcpLoggingLevel(level slog.Level) mcp.LoggingLevel {
+	switch {
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warning"
+	case level >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// connectLoggingClient connects a client that requested level to server and
+// returns the channel its log notifications are delivered on.
+func connectLoggingClient(t *testing.T, server *mcp.Server, level mcp.LoggingLevel) <-chan *mcp.LoggingMessageParams {
+	t.Helper()
+	ctx := context.Background()
+	messages := make(chan *mcp.LoggingMessageParams, 10)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Server connect failed: %v", err)
+	}
+	t.Cleanup(func() { _ = serverSession.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, &mcp.ClientOptions{
+		LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
+			messages <- req.Params
+		},
+	})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Client connect failed: %v", err)
+	}
+	t.Cleanup(func() { _ = session.Close() })
+
+	if err := session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: level}); err != nil {
+		t.Fatalf("SetLoggingLevel failed: %v", err)
+	}
+	return messages
+}
+
+func nextMessage(t *testing.T, messages <-chan *mcp.LoggingMessageParams) *mcp.LoggingMessageParams {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for log notification")
+		return nil
+	}
+}
+
+func TestClientLogHandler_ForwardsEvents(t *testing.T) {
+	server := CreateServer(ServerConfig{Name: "test-server"})
+	messages := connectLoggingClient(t, server, "info")
+
+	logger := slog.New(NewClientLogHandler(slog.DiscardHandler, server, config.ClientLogLevelInfo))
+	logger.Info("Not an event", "repo_id", "a")
+	logger.Debug("Below level", gitrepos.LogEventKey, gitrepos.EventSyncStarted)
+	logger.With("repo_id", "b").Error("Failed to sync repository", gitrepos.LogEventKey, gitrepos.EventRepoError, "error", errors.New("boom"))
+
+	msg := nextMessage(t, messages)
+	if msg.Level != "error" || msg.Logger != clientLoggerName {
+		t.Errorf("Unexpected level or logger: %q %q", msg.Level, msg.Logger)
+	}
+	data, ok := msg.Data.(map[string]any)
+	if !ok {
+		t.Fatalf("Expected map data, got %T", msg.Data)
+	}
+	if data[gitrepos.LogEventKey] != gitrepos.EventRepoError || data["repo_id"] != "b" || data["error"] != "boom" {
+		t.Errorf("Unexpected data: %v", data)
+	}
+
+	select {
+	case extra := <-messages:
+		t.Errorf("Unexpected extra notification: %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClientLogHandler_RespectsClientLevel(t *testing.T) {
+	server := CreateServer(ServerConfig{Name: "test-server"})
+	messages := connectLoggingClient(t, server, "warning")
+
+	logger := slog.New(NewClientLogHandler(slog.DiscardHandler, server, config.ClientLogLevelDebug))
+	logger.Info("Syncing repositories", gitrepos.LogEventKey, gitrepos.EventSyncStarted)
+	logger.Warn("Repository sync finished", gitrepos.LogEventKey, gitrepos.EventSyncFinished)
+
+	msg := nextMessage(t, messages)
+	if msg.Level != "warning" {
+		t.Errorf("Expected only the warning to be delivered, got %q", msg.Level)
+	}
+}
+
+func TestNewClientLogHandler_Off(t *testing.T) {
+	next := slog.DiscardHandler
+	if h := NewClientLogHandler(next, nil, config.ClientLogLevelOff); h != next {
+		t.Error("Expected the next handler to be returned unchanged")
+	}
+}
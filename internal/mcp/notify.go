@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// IndexStatusURI is the URI of the index status resource, which lists the
+// indexed commit of every repository. Clients that use resource notifications
+// can subscribe to it to learn when the index changes.
+const IndexStatusURI = "relic://index/status"
+
+// indexUpdatePollInterval is how often the indexed commits are checked for
+// changes made by background syncs
+const indexUpdatePollInterval = 2 * time.Second
+
+// indexStatus is the content of the index status resource.
+type indexStatus struct {
+	Generation   uint64            `json:"generation"`
+	Repositories map[string]string `json:"repositories"` // indexed commit by repository name
+}
+
+// indexStatusResource returns the definition of the index status resource.
+func indexStatusResource() *mcp.Resource {
+	return &mcp.Resource{
+		URI:         IndexStatusURI,
+		Name:        "index-status",
+		Title:       "Index status",
+		Description: "The sync generation and the indexed commit of every repository. Changes whenever a sync updates the index.",
+		MIMEType:    "application/json",
+	}
+}
+
+// registerIndexStatusResource registers the index status resource with server.
+func registerIndexStatusResource(server *mcp.Server, service gitrepos.ConsistencyService) {
+	server.AddResource(indexStatusResource(), func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		if req.Params == nil || req.Params.URI != IndexStatusURI {
+			uri := ""
+			if req.Params != nil {
+				uri = req.Params.URI
+			}
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		data, err := json.Marshal(indexStatus{Generation: service.Generation(), Repositories: service.IndexedCommits()})
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{
+				{URI: IndexStatusURI, MIMEType: "application/json", Text: string(data)},
+			},
+		}, nil
+	})
+}
+
+// subscribeIndexStatus accepts subscriptions to the index status resource only.
+func subscribeIndexStatus(_ context.Context, req *mcp.SubscribeRequest) error {
+	if req.Params.URI != IndexStatusURI {
+		return mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	return nil
+}
+
+// unsubscribeIndexStatus ends a subscription to the index status resource.
+func unsubscribeIndexStatus(_ context.Context, _ *mcp.UnsubscribeRequest) error {
+	return nil
+}
+
+// WatchIndexUpdates tells the clients connected to server when a sync changes
+// the indexed commit of a repository, including repositories being added or
+// removed. mode is one of the config.IndexUpdateNotify constants:
+//   - resources: notifications/resources/list_changed to every client, and
+//     notifications/resources/updated to clients subscribed to IndexStatusURI
+//   - log: a log notification with the index_updated event and the changed
+//     repositories, to clients that called logging/setLevel
+//
+// It returns a function that stops watching; with config.IndexUpdateNotifyOff
+// nothing is watched.
+func WatchIndexUpdates(server *mcp.Server, service gitrepos.ConsistencyService, mode string) (stop func()) {
+	return watchIndexUpdates(server, service, mode, indexUpdatePollInterval)
+}
+
+// watchIndexUpdates implements WatchIndexUpdates, checking every interval.
+func watchIndexUpdates(server *mcp.Server, service gitrepos.ConsistencyService, mode string, interval time.Duration) func() {
+	if mode == "" || mode == config.IndexUpdateNotifyOff {
+		return func() {}
+	}
+
+	last := service.IndexedCommits()
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+			current := service.IndexedCommits()
+			changed := changedRepos(last, current)
+			if len(changed) == 0 {
+				continue
+			}
+			last = current
+			slog.Info("Index updated, notifying clients", "repos", changed, "mode", mode)
+			notifyIndexUpdate(server, service, mode, changed)
+		}
+	}()
+	return func() {
+		close(stopCh)
+		<-done
+	}
+}
+
+// notifyIndexUpdate sends the notifications of mode for the changed repositories.
+func notifyIndexUpdate(server *mcp.Server, service gitrepos.ConsistencyService, mode string, changed []string) {
+	ctx := context.Background()
+	switch mode {
+	case config.IndexUpdateNotifyResources:
+		// Re-adding the resource makes the SDK send a list-changed notification
+		registerIndexStatusResource(server, service)
+		_ = server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: IndexStatusURI})
+	case config.IndexUpdateNotifyLog:
+		params := &mcp.LoggingMessageParams{
+			Logger: clientLoggerName,
+			Level:  "info",
+			Data: map[string]any{
+				"message":            "Index updated",
+				gitrepos.LogEventKey: gitrepos.EventIndexUpdated,
+				"generation":         service.Generation(),
+				"repos":              changed,
+			},
+		}
+		for session := range server.Sessions() {
+			_ = session.Log(ctx, params)
+		}
+	}
+}
+
+// changedRepos returns the repositories whose indexed commit differs between
+// before and after, including those only present in one of them, sorted.
+func changedRepos(before, after map[string]string) []string {
+	var changed []string
+	for repo, commit := range after {
+		if before[repo] != commit {
+			changed = append(changed, repo)
+		}
+	}
+	for repo := range before {
+		if _, ok := after[repo]; !ok {
+			changed = append(changed, repo)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
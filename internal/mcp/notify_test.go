@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// fakeIndexCommits is a ConsistencyService whose indexed commits can change.
+type fakeIndexCommits struct {
+	mu      sync.Mutex
+	commits map[string]string
+}
+
+func (f *fakeIndexCommits) Generation() uint64 { return 7 }
+
+func (f *fakeIndexCommits) IndexedCommits() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return maps.Clone(f.commits)
+}
+
+func (f *fakeIndexCommits) set(repo, commit string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commits[repo] = commit
+}
+
+// connectClient connects a client with opts to server.
+func connectClient(t *testing.T, server *mcp.Server, opts *mcp.ClientOptions) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Server connect failed: %v", err)
+	}
+	t.Cleanup(func() { _ = serverSession.Close() })
+
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, opts).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Client connect failed: %v", err)
+	}
+	t.Cleanup(func() { _ = session.Close() })
+	return session
+}
+
+func waitFor(t *testing.T, ch <-chan string, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s", what)
+	}
+}
+
+func TestChangedRepos(t *testing.T) {
+	before := map[string]string{"a": "1", "b": "2", "c": "3"}
+	after := map[string]string{"a": "1", "b": "5", "d": "4"}
+	if got := changedRepos(before, after); !slices.Equal(got, []string{"b", "c", "d"}) {
+		t.Errorf("Unexpected changed repos: %v", got)
+	}
+	if got := changedRepos(before, before); len(got) != 0 {
+		t.Errorf("Expected no changes, got %v", got)
+	}
+}
+
+func TestWatchIndexUpdates_Log(t *testing.T) {
+	server := CreateServer(ServerConfig{Name: "test-server"})
+	messages := connectLoggingClient(t, server, "info")
+
+	service := &fakeIndexCommits{commits: map[string]string{"github.com/org/repo": "abc"}}
+	stop := watchIndexUpdates(server, service, config.IndexUpdateNotifyLog, 10*time.Millisecond)
+	defer stop()
+
+	service.set("github.com/org/repo", "def")
+	msg := nextMessage(t, messages)
+	data, ok := msg.Data.(map[string]any)
+	if !ok {
+		t.Fatalf("Expected map data, got %T", msg.Data)
+	}
+	if data[gitrepos.LogEventKey] != gitrepos.EventIndexUpdated || msg.Logger != clientLoggerName {
+		t.Errorf("Unexpected notification: %+v", msg)
+	}
+	if repos, _ := data["repos"].([]any); len(repos) != 1 || repos[0] != "github.com/org/repo" {
+		t.Errorf("Expected the changed repository, got %v", data["repos"])
+	}
+}
+
+func TestWatchIndexUpdates_Resources(t *testing.T) {
+	server := CreateServer(ServerConfig{
+		Name:              "test-server",
+		GitReposSvc:       &mockGitReposToolService{ready: true},
+		IndexUpdateNotify: config.IndexUpdateNotifyResources,
+	})
+	listChanged := make(chan string, 10)
+	updated := make(chan string, 10)
+	session := connectClient(t, server, &mcp.ClientOptions{
+		ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+			listChanged <- "list"
+		},
+		ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	if err := session.Subscribe(context.Background(), &mcp.SubscribeParams{URI: IndexStatusURI}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if err := session.Subscribe(context.Background(), &mcp.SubscribeParams{URI: gitrepos.QuerySyntaxURI}); err == nil {
+		t.Error("Expected subscriptions to other resources to fail")
+	}
+
+	service := &fakeIndexCommits{commits: map[string]string{}}
+	stop := watchIndexUpdates(server, service, config.IndexUpdateNotifyResources, 10*time.Millisecond)
+	defer stop()
+
+	service.set("github.com/org/repo", "abc")
+	waitFor(t, updated, "resource updated notification")
+	waitFor(t, listChanged, "resource list changed notification")
+}
+
+func TestWatchIndexUpdates_Off(t *testing.T) {
+	server := CreateServer(ServerConfig{Name: "test-server"})
+	messages := connectLoggingClient(t, server, "debug")
+
+	service := &fakeIndexCommits{commits: map[string]string{}}
+	stop := watchIndexUpdates(server, service, config.IndexUpdateNotifyOff, 10*time.Millisecond)
+	defer stop()
+
+	service.set("github.com/org/repo", "abc")
+	select {
+	case msg := <-messages:
+		t.Errorf("Unexpected notification: %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestIndexStatusResource(t *testing.T) {
+	server := CreateServer(ServerConfig{Name: "test-server", GitReposSvc: &mockGitReposToolService{ready: true}})
+	session := connectClient(t, server, nil)
+
+	result, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: IndexStatusURI})
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	var status indexStatus
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &status); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", result.Contents[0].Text, err)
+	}
+	if status.Generation != 1 {
+		t.Errorf("Expected generation 1, got %d", status.Generation)
+	}
+}
@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// JSON-RPC error codes of malformed input
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+)
+
+// batchlessProtocolVersion is the first protocol version without JSON-RPC
+// batches; the SDK ends the session of a client that sends one after
+// negotiating it or a later version
+const batchlessProtocolVersion = "2025-06-18"
+
+// ProtocolVersionMiddleware logs clients that request a protocol version the
+// server does not support. The SDK answers them with the latest version it
+// supports, which a client may then accept or reject.
+func ProtocolVersionMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			result, err := next(ctx, method, req)
+			init, ok := req.(*mcp.ServerRequest[*mcp.InitializeParams])
+			if !ok || err != nil || init.Params == nil {
+				return result, err
+			}
+			if initResult, ok := result.(*mcp.InitializeResult); ok && initResult.ProtocolVersion != init.Params.ProtocolVersion {
+				client := ""
+				if init.Params.ClientInfo != nil {
+					client = init.Params.ClientInfo.Name
+				}
+				slog.Warn("Client requested an unsupported protocol version",
+					"client", client,
+					"requested", init.Params.ProtocolVersion,
+					"negotiated", initResult.ProtocolVersion)
+			}
+			return result, err
+		}
+	}
+}
+
+// NewGuardedIOTransport returns a newline-delimited JSON transport over r and
+// w, like mcp.StdioTransport over stdin and stdout, that answers malformed
+// input with a JSON-RPC error instead of ending the session: lines that are
+// not JSON get a parse error and messages that are not JSON-RPC an invalid
+// request error. Batches sent after negotiating a protocol version without
+// them are split into single messages.
+func NewGuardedIOTransport(r io.Reader, w io.Writer) mcp.Transport {
+	out := &lockedWriter{w: w}
+	return &mcp.IOTransport{
+		Reader: &guardedReader{in: bufio.NewReader(r), out: out},
+		Writer: out,
+	}
+}
+
+// lockedWriter serializes the writes of the SDK, each a complete message,
+// with the error responses of guardedReader, and records the protocol
+// version of the initialize response.
+type lockedWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	version string // negotiated protocol version, empty until initialized
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.version == "" && bytes.Contains(p, []byte(`"protocolVersion"`)) {
+		var resp struct {
+			Result struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			} `json:"result"`
+		}
+		if json.Unmarshal(p, &resp) == nil {
+			l.version = resp.Result.ProtocolVersion
+		}
+	}
+	return l.w.Write(p)
+}
+
+// Close does not close the underlying writer, which may be stdout.
+func (l *lockedWriter) Close() error {
+	return nil
+}
+
+// protocolVersion returns the negotiated protocol version, if any.
+func (l *lockedWriter) protocolVersion() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.version
+}
+
+// guardedReader passes the valid lines of in to the SDK and answers the
+// others on out.
+type guardedReader struct {
+	in      *bufio.Reader
+	out     *lockedWriter
+	pending []byte // rest of the line being read by the SDK
+}
+
+func (g *guardedReader) Read(p []byte) (int, error) {
+	for len(g.pending) == 0 {
+		line, err := g.in.ReadBytes('\n')
+		if len(line) > 0 {
+			g.pending = g.check(line)
+		}
+		if err != nil && len(g.pending) == 0 {
+			return 0, err
+		}
+	}
+	n := copy(p, g.pending)
+	g.pending = g.pending[n:]
+	return n, nil
+}
+
+// Close is a no-op, like closing the stdin of mcp.StdioTransport.
+func (g *guardedReader) Close() error {
+	return nil
+}
+
+// check returns line if the SDK can handle it. Otherwise, it writes an error
+// response and returns nil.
+func (g *guardedReader) check(line []byte) []byte {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		line = append(line, '\n')
+	}
+
+	if trimmed[0] != '[' {
+		if resp := checkMessage(trimmed); resp != nil {
+			g.reply(resp)
+			return nil
+		}
+		return line
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		g.reply(errorResponse(nil, codeParseError, "Parse error: "+err.Error()))
+		return nil
+	}
+	if len(batch) == 0 {
+		g.reply(errorResponse(nil, codeInvalidRequest, "Invalid request: empty batch"))
+		return nil
+	}
+	var valid, responses []json.RawMessage
+	for _, raw := range batch {
+		if resp := checkMessage(raw); resp != nil {
+			responses = append(responses, resp)
+		} else {
+			valid = append(valid, raw)
+		}
+	}
+	if len(responses) > 0 {
+		data, _ := json.Marshal(responses)
+		g.reply(data)
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+
+	if version := g.out.protocolVersion(); version != "" && version >= batchlessProtocolVersion {
+		// Split the batch, which the SDK no longer accepts, into one message
+		// per line; each request is answered on its own
+		var lines []byte
+		for _, raw := range valid {
+			lines = append(append(lines, raw...), '\n')
+		}
+		return lines
+	}
+	if len(responses) == 0 {
+		return line
+	}
+	data, _ := json.Marshal(valid)
+	return append(data, '\n')
+}
+
+// reply writes a response line.
+func (g *guardedReader) reply(resp []byte) {
+	slog.Warn("Rejected malformed JSON-RPC input", "response", string(resp))
+	_, _ = g.out.Write(append(resp, '\n'))
+}
+
+// checkMessage returns the error response of a message the SDK cannot
+// decode, or nil if it can.
+func checkMessage(raw json.RawMessage) []byte {
+	if !json.Valid(raw) {
+		return errorResponse(nil, codeParseError, "Parse error: invalid JSON")
+	}
+	if _, err := jsonrpc.DecodeMessage(raw); err != nil {
+		return errorResponse(requestID(raw), codeInvalidRequest, "Invalid request: "+err.Error())
+	}
+	return nil
+}
+
+// requestID returns the ID of a request, or nil for notifications, messages
+// that are not requests and IDs that are neither strings nor numbers.
+func requestID(raw json.RawMessage) json.RawMessage {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if json.Unmarshal(raw, &msg) != nil || msg.Method == "" || len(msg.ID) == 0 {
+		return nil
+	}
+	var id any
+	if json.Unmarshal(msg.ID, &id) != nil {
+		return nil
+	}
+	switch id.(type) {
+	case string, float64:
+		return msg.ID
+	}
+	return nil
+}
+
+// errorResponse returns a JSON-RPC error response; a nil id is encoded as
+// null, for errors that cannot be attributed to a request.
+func errorResponse(id json.RawMessage, code int, message string) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	data, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]any{"code": code, "message": message},
+	})
+	return data
+}
+
+// NewSSEBatchHandler returns a handler for the SSE endpoint that splits the
+// JSON-RPC batches clients post into single messages, which the SDK's SSE
+// transport does not accept, and answers bodies that are not JSON with a
+// JSON-RPC parse error. Responses are sent over the event stream one by one.
+func NewSSEBatchHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		trimmed := bytes.TrimSpace(body)
+		if !json.Valid(trimmed) {
+			writeJSONError(w, errorResponse(nil, codeParseError, "Parse error: invalid JSON"))
+			return
+		}
+		if trimmed[0] != '[' {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil || len(batch) == 0 {
+			writeJSONError(w, errorResponse(nil, codeInvalidRequest, "Invalid request: empty batch"))
+			return
+		}
+		for _, raw := range batch {
+			single := r.Clone(r.Context())
+			single.Body = io.NopCloser(bytes.NewReader(raw))
+			single.ContentLength = int64(len(raw))
+			rec := &statusRecorder{header: http.Header{}}
+			next.ServeHTTP(rec, single)
+			if rec.status >= http.StatusBadRequest {
+				for key, values := range rec.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(rec.status)
+				_, _ = w.Write(rec.body.Bytes())
+				return
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// writeJSONError writes a JSON-RPC error response with status 400.
+func writeJSONError(w http.ResponseWriter, resp []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write(resp)
+}
+
+// statusRecorder records the response to one message of a batch.
+type statusRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (s *statusRecorder) Header() http.Header { return s.header }
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.body.Write(p)
+}
@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// guardedLines feeds input to a guarded reader, as negotiated with version,
+// and returns what the SDK reads and the responses written back.
+func guardedLines(t *testing.T, version, input string) (read, written string) {
+	t.Helper()
+	var out bytes.Buffer
+	r := &guardedReader{in: bufio.NewReader(strings.NewReader(input)), out: &lockedWriter{w: &out, version: version}}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	return string(data), out.String()
+}
+
+func TestGuardedReader(t *testing.T) {
+	batch := `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`
+
+	tests := []struct {
+		name        string
+		version     string
+		input       string
+		wantRead    string
+		wantWritten string
+	}{
+		{"valid messages", "", "{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"ping\"}\n\n  \n{\"jsonrpc\":\"2.0\",\"id\":2,\"method\":\"ping\"}",
+			"{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"ping\"}\n{\"jsonrpc\":\"2.0\",\"id\":2,\"method\":\"ping\"}\n", ""},
+		{"not JSON", "", "hello\n", "",
+			`{"error":{"code":-32700,"message":"Parse error: invalid JSON"},"id":null,"jsonrpc":"2.0"}` + "\n"},
+		{"invalid ID", "", `{"jsonrpc":"2.0","id":{"x":1},"method":"ping"}` + "\n", "",
+			`{"error":{"code":-32600,"message":"Invalid request: parse error: invalid ID type map[string]interface {}"},"id":null,"jsonrpc":"2.0"}` + "\n"},
+		{"batch", "2025-03-26", batch + "\n", batch + "\n", ""},
+		{"batch before initialization", "", batch + "\n", batch + "\n", ""},
+		{"batch split", "2025-06-18", batch + "\n",
+			`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" + `{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n", ""},
+		{"batch with an invalid message", "2025-03-26", `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"id":2,"method":"ping"}]` + "\n",
+			`[{"jsonrpc":"2.0","id":1,"method":"ping"}]` + "\n",
+			`[{"error":{"code":-32600,"message":"Invalid request: invalid message version tag \"\"; expected \"2.0\""},"id":2,"jsonrpc":"2.0"}]` + "\n"},
+		{"empty batch", "2025-03-26", "[]\n", "",
+			`{"error":{"code":-32600,"message":"Invalid request: empty batch"},"id":null,"jsonrpc":"2.0"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read, written := guardedLines(t, tt.version, tt.input)
+			if read != tt.wantRead {
+				t.Errorf("Expected the SDK to read %q, got %q", tt.wantRead, read)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("Expected the response %q, got %q", tt.wantWritten, written)
+			}
+		})
+	}
+}
+
+func TestLockedWriter_RecordsProtocolVersion(t *testing.T) {
+	w := &lockedWriter{w: io.Discard}
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","capabilities":{}}}` + "\n"))
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"\"protocolVersion\""}]}}` + "\n"))
+	if got := w.protocolVersion(); got != "2025-06-18" {
+		t.Errorf("Expected protocol version 2025-06-18, got %q", got)
+	}
+}
+
+func TestProtocolVersionMiddleware(t *testing.T) {
+	var logs lockedBuffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	server.AddReceivingMiddleware(ProtocolVersionMiddleware())
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(context.Background(), serverTransport, nil); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "1.0"}, nil).Connect(context.Background(), clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	_ = session.Close()
+
+	// The SDK client requests a version the server supports
+	if strings.Contains(logs.String(), "unsupported protocol version") {
+		t.Errorf("Unexpected warning: %s", logs.String())
+	}
+}
+
+func TestNewSSEBatchHandler(t *testing.T) {
+	var bodies []string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if strings.Contains(string(body), "reject") {
+			http.Error(w, "rejected", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	handler := NewSSEBatchHandler(next)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantBodies []string
+		wantResp   string
+	}{
+		{"single message", `{"jsonrpc":"2.0","id":1,"method":"ping"}`, http.StatusAccepted, []string{`{"jsonrpc":"2.0","id":1,"method":"ping"}`}, ""},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"ping"}, {"jsonrpc":"2.0","id":2,"method":"ping"}]`, http.StatusAccepted,
+			[]string{`{"jsonrpc":"2.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","id":2,"method":"ping"}`}, ""},
+		{"rejected batch message", `[{"jsonrpc":"2.0","id":1,"method":"reject"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`, http.StatusBadRequest,
+			[]string{`{"jsonrpc":"2.0","id":1,"method":"reject"}`}, "rejected\n"},
+		{"not JSON", `{"jsonrpc"`, http.StatusBadRequest, nil, `{"error":{"code":-32700,"message":"Parse error: invalid JSON"},"id":null,"jsonrpc":"2.0"}`},
+		{"empty batch", `[]`, http.StatusBadRequest, nil, `{"error":{"code":-32600,"message":"Invalid request: empty batch"},"id":null,"jsonrpc":"2.0"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sse?sessionid=abc", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if strings.Join(bodies, "|") != strings.Join(tt.wantBodies, "|") {
+				t.Errorf("Expected messages %q, got %q", tt.wantBodies, bodies)
+			}
+			if rec.Body.String() != tt.wantResp {
+				t.Errorf("Expected response %q, got %q", tt.wantResp, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// readinessPollInterval is how often the readiness of the indexes is checked
+// for changes
+const readinessPollInterval = time.Second
+
+// registerIndexTools registers the tools that need the indexes, which
+// gitrepos.ToolListMiddleware marks as unavailable while they are not ready.
+func registerIndexTools(server *mcp.Server, service GitReposToolService) {
+	gitrepos.RegisterSearchTool(server, service)
+	gitrepos.RegisterReadTool(server, service)
+	gitrepos.RegisterSearchInFileTool(server, service)
+	gitrepos.RegisterReadmeTool(server, service)
+}
+
+// WatchToolReadiness sends notifications/tools/list_changed to the clients
+// connected to server whenever the indexes become ready or unavailable, e.g.
+// while a reindex rebuilds them, so that clients listing tools again see
+// which tools are available. It returns a function that stops watching.
+func WatchToolReadiness(server *mcp.Server, service GitReposToolService) (stop func()) {
+	return watchToolReadiness(server, service, readinessPollInterval)
+}
+
+// watchToolReadiness implements WatchToolReadiness, checking every interval.
+func watchToolReadiness(server *mcp.Server, service GitReposToolService, interval time.Duration) func() {
+	ready := service.IsReady()
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+			if current := service.IsReady(); current != ready {
+				ready = current
+				slog.Info("Index readiness changed, notifying clients", "ready", ready)
+				// Re-adding the tools makes the SDK send a list-changed notification
+				registerIndexTools(server, service)
+			}
+		}
+	}()
+	return func() {
+		close(stopCh)
+		<-done
+	}
+}
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toggledReadiness is a GitReposToolService whose readiness can change.
+type toggledReadiness struct {
+	*mockGitReposToolService
+	mu    sync.Mutex
+	ready bool
+}
+
+func (t *toggledReadiness) IsReady() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ready
+}
+
+func (t *toggledReadiness) setReady(ready bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ready = ready
+}
+
+// toolDescriptions lists the tools of session by name.
+func toolDescriptions(t *testing.T, session *mcp.ClientSession) map[string]string {
+	t.Helper()
+	result, err := session.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	descriptions := make(map[string]string)
+	for _, tool := range result.Tools {
+		descriptions[tool.Name] = tool.Description
+	}
+	return descriptions
+}
+
+func TestWatchToolReadiness(t *testing.T) {
+	service := &toggledReadiness{mockGitReposToolService: &mockGitReposToolService{}}
+	server := CreateServer(ServerConfig{Name: "test-server", GitReposSvc: service})
+	time.Sleep(50 * time.Millisecond) // past the notifications of the initial registrations
+
+	changed := make(chan string, 10)
+	session := connectClient(t, server, &mcp.ClientOptions{
+		ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
+			changed <- "tools"
+		},
+	})
+
+	// Tools that need the indexes are marked while they are not ready
+	tools := toolDescriptions(t, session)
+	if !strings.HasPrefix(tools["search"], "UNAVAILABLE: the indexes are not ready yet.") {
+		t.Errorf("Expected search to be marked unavailable, got: %s", tools["search"])
+	}
+	if strings.HasPrefix(tools["repo_stats"], "UNAVAILABLE") {
+		t.Errorf("Expected repo_stats to stay available, got: %s", tools["repo_stats"])
+	}
+
+	stop := watchToolReadiness(server, service, 10*time.Millisecond)
+	defer stop()
+	service.setReady(true)
+	waitFor(t, changed, "the tool list change")
+
+	if tools := toolDescriptions(t, session); strings.HasPrefix(tools["search"], "UNAVAILABLE") {
+		t.Errorf("Expected search to be available once ready, got: %s", tools["search"])
+	}
+}
+
+func TestCreateServer_GitReposError(t *testing.T) {
+	server := CreateServer(ServerConfig{Name: "test-server", GitReposError: "2 repositories failed their initial sync"})
+	session := connectClient(t, server, nil)
+
+	instructions := session.InitializeResult().Instructions
+	if !strings.Contains(instructions, "Code search is unavailable on this server: 2 repositories failed their initial sync") {
+		t.Errorf("Expected the instructions to explain the missing tools, got: %q", instructions)
+	}
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "server_info"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, `"git_repos_error": "2 repositories failed their initial sync"`) {
+		t.Errorf("Expected server_info to report the error, got: %s", text)
+	}
+}
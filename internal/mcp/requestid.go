@@ -0,0 +1,219 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/auth"
+)
+
+const (
+	// MetaRequestID is the _meta key of the request ID, both in tool call
+	// params, where clients may supply one, and in tool results
+	MetaRequestID = "relic/request_id"
+
+	// RequestIDHeader is the HTTP header SSE clients may supply a request ID
+	// in, instead of the call _meta
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength bounds client-supplied request IDs
+	maxRequestIDLength = 128
+)
+
+// requestIDKey is the context key of the request ID of a tool call
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID of the tool call of ctx, or an
+// empty string outside of tool calls.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware assigns every tool call a request ID, so that a result
+// a user reports can be matched with the server logs. The ID is taken from
+// the MetaRequestID _meta key of the call if the client supplied a valid one,
+// and generated otherwise. It is passed to the handlers through the context,
+// logged with the outcome of the call, and returned in the result _meta. With
+// footer, it is also appended to the result as text.
+func RequestIDMiddleware(footer bool) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok || call.Params == nil {
+				return next(ctx, method, req)
+			}
+
+			id, _ := call.Params.Meta[MetaRequestID].(string)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			ctx = context.WithValue(ctx, requestIDKey{}, id)
+
+			start := time.Now()
+			res, err := next(ctx, method, req)
+			result, _ := res.(*mcp.CallToolResult)
+			attrs := []any{"tool", call.Params.Name, "duration", time.Since(start)}
+			if identity := auth.IdentityFromContext(ctx); identity != "" {
+				attrs = append(attrs, "identity", identity)
+			}
+			switch {
+			case err != nil:
+				slog.WarnContext(ctx, "Tool call failed", append(attrs, "error", err)...)
+			case result != nil && result.IsError:
+				slog.InfoContext(ctx, "Tool call returned an error", attrs...)
+			default:
+				slog.InfoContext(ctx, "Tool call", attrs...)
+			}
+
+			if result != nil {
+				if result.Meta == nil {
+					result.Meta = mcp.Meta{}
+				}
+				result.Meta[MetaRequestID] = id
+				if footer {
+					result.Content = append(result.Content, &mcp.TextContent{
+						Text: fmt.Sprintf("Request ID: %s", id),
+					})
+				}
+			}
+			return res, err
+		}
+	}
+}
+
+// validRequestID reports whether a client-supplied request ID can be logged
+// as is: up to maxRequestIDLength letters, digits, '.', '_', ':' and '-'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == ':', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestIDLogHandler is a slog.Handler that adds the request ID of the tool
+// call of the context to records logged with one, e.g. by slog.InfoContext.
+type RequestIDLogHandler struct {
+	next slog.Handler
+}
+
+// NewRequestIDLogHandler creates a handler tagging the records it passes to
+// next with their request ID.
+func NewRequestIDLogHandler(next slog.Handler) slog.Handler {
+	return &RequestIDLogHandler{next: next}
+}
+
+// Enabled reports whether the next handler wants records at level.
+func (h *RequestIDLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes r to the next handler, with the request ID of ctx if any.
+func (h *RequestIDLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r = r.Clone()
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler whose records include attrs.
+func (h *RequestIDLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RequestIDLogHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler that groups attributes for the next handler.
+func (h *RequestIDLogHandler) WithGroup(name string) slog.Handler {
+	return &RequestIDLogHandler{next: h.next.WithGroup(name)}
+}
+
+// NewRequestIDHandler copies the RequestIDHeader of an SSE or streamable HTTP
+// message POST into the MetaRequestID _meta key of the tool call it carries,
+// since the SDK does not pass the headers of messages to the handlers. A
+// request ID already in the _meta wins. It expects single messages, so for SSE
+// it goes inside NewSSEBatchHandler.
+func NewRequestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if r.Method != http.MethodPost || id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if tagged, ok := withRequestIDMeta(body, id); ok {
+			body = tagged
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withRequestIDMeta returns the tools/call message body with id added to its
+// params _meta, and false if body is not such a message or already has one.
+// Other fields are kept as sent.
+func withRequestIDMeta(body []byte, id string) ([]byte, bool) {
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, false
+	}
+	var method string
+	if err := json.Unmarshal(msg["method"], &method); err != nil || method != "tools/call" {
+		return nil, false
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(msg["params"], &params); err != nil || params == nil {
+		return nil, false
+	}
+	meta := map[string]any{}
+	if raw, ok := params["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil || meta == nil {
+			return nil, false
+		}
+	}
+	if _, ok := meta[MetaRequestID]; ok {
+		return nil, false
+	}
+	meta[MetaRequestID] = id
+
+	var err error
+	if params["_meta"], err = json.Marshal(meta); err != nil {
+		return nil, false
+	}
+	if msg["params"], err = json.Marshal(params); err != nil {
+		return nil, false
+	}
+	tagged, err := json.Marshal(msg)
+	if err != nil {
+		return nil, false
+	}
+	return tagged, true
+}
@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+func callWithRequestID(t *testing.T, footer bool, meta mcp.Meta) (*mcp.CallToolResult, string) {
+	t.Helper()
+	var seen string
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		seen = RequestIDFromContext(ctx)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "output"}}}, nil
+	}
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "search", Meta: meta}}
+	res, err := RequestIDMiddleware(footer)(next)(context.Background(), "tools/call", req)
+	if err != nil {
+		t.Fatalf("Middleware returned error: %v", err)
+	}
+	return res.(*mcp.CallToolResult), seen
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	result, seen := callWithRequestID(t, false, nil)
+	if len(seen) != 16 {
+		t.Fatalf("Expected a generated request ID in the context, got %q", seen)
+	}
+	if result.Meta[MetaRequestID] != seen {
+		t.Errorf("Expected the request ID in the result _meta, got %v", result.Meta)
+	}
+	if len(result.Content) != 1 {
+		t.Errorf("Expected no footer by default, got %d contents", len(result.Content))
+	}
+
+	_, other := callWithRequestID(t, false, nil)
+	if other == seen {
+		t.Errorf("Expected a new request ID per call, got %q twice", seen)
+	}
+}
+
+func TestRequestIDMiddleware_ClientID(t *testing.T) {
+	_, seen := callWithRequestID(t, false, mcp.Meta{MetaRequestID: "trace-42"})
+	if seen != "trace-42" {
+		t.Errorf("Expected the client request ID, got %q", seen)
+	}
+
+	for _, invalid := range []any{"has spaces", strings.Repeat("x", maxRequestIDLength+1), 42} {
+		_, seen := callWithRequestID(t, false, mcp.Meta{MetaRequestID: invalid})
+		if seen == invalid || len(seen) != 16 {
+			t.Errorf("Expected %v to be replaced with a generated request ID, got %q", invalid, seen)
+		}
+	}
+}
+
+func TestRequestIDMiddleware_Footer(t *testing.T) {
+	result, _ := callWithRequestID(t, true, mcp.Meta{MetaRequestID: "trace-42"})
+	if text := gitrepos.ExtractTextContent(result); !strings.HasSuffix(text, "Request ID: trace-42") {
+		t.Errorf("Expected the request ID footer, got %q", text)
+	}
+}
+
+func TestRequestIDLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRequestIDLogHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "trace-42")
+	logger.With("tool", "search").InfoContext(ctx, "Tool call")
+	logger.Info("Outside a call")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "tool=search") || !strings.Contains(lines[0], "request_id=trace-42") {
+		t.Errorf("Expected the request ID on the call's line, got %q", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("Expected no request ID outside a call, got %q", lines[1])
+	}
+}
+
+func TestNewRequestIDHandler(t *testing.T) {
+	var body []byte
+	handler := NewRequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	post := func(msg, header string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/sse?sessionid=1", strings.NewReader(msg))
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		var got map[string]any
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("Expected JSON, got %q", body)
+		}
+		return got
+	}
+	metaID := func(msg map[string]any) any {
+		params, _ := msg["params"].(map[string]any)
+		meta, _ := params["_meta"].(map[string]any)
+		return meta[MetaRequestID]
+	}
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search","arguments":{"query":"x"}}}`
+	got := post(call, "trace-42")
+	if metaID(got) != "trace-42" {
+		t.Errorf("Expected the header in the call _meta, got %v", got)
+	}
+	if params := got["params"].(map[string]any); params["name"] != "search" || params["arguments"].(map[string]any)["query"] != "x" {
+		t.Errorf("Expected the rest of the call to be kept, got %v", params)
+	}
+
+	withMeta := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search","_meta":{"relic/request_id":"from-meta"}}}`
+	if id := metaID(post(withMeta, "trace-42")); id != "from-meta" {
+		t.Errorf("Expected the _meta request ID to win, got %v", id)
+	}
+
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	if got := post(ping, "trace-42"); got["params"] != nil {
+		t.Errorf("Expected other methods to be left alone, got %v", got)
+	}
+	if id := metaID(post(call, "")); id != nil {
+		t.Errorf("Expected no request ID without the header, got %v", id)
+	}
+}
@@ -1,35 +1,125 @@
 package mcp
 
 import (
+	"fmt"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/config"
 	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
 )
 
-// GitReposToolService combines what both search and read tools need.
+// GitReposToolService combines what the search and read tools need.
 type GitReposToolService interface {
 	gitrepos.SearchService
+	gitrepos.HistoryService
 	gitrepos.ReadService
+	gitrepos.StatsService
+	gitrepos.MapService
+	gitrepos.ReindexService
+	gitrepos.RepositoryService
+	gitrepos.FilterReportService
+	gitrepos.ConsistencyService
+	gitrepos.ProgressService
 }
 
 // ServerConfig contains configuration for creating an MCP server
 type ServerConfig struct {
 	Name        string
 	Version     string
+	Build       string
 	GitReposSvc GitReposToolService // nil if initialization failed
+	// GitReposError is why GitReposSvc is nil, told to clients in the server
+	// instructions and by server_info; empty if git repos are not configured
+	GitReposError string
+	Report        func() string // self-report for the version tool; nil to omit it
+
+	// IndexUpdateNotify is how clients are told about index updates, one of
+	// the config.IndexUpdateNotify constants. With resources, clients can
+	// subscribe to IndexStatusURI.
+	IndexUpdateNotify string
+
+	// RequestIDFooter appends the request ID of each tool call to its result
+	RequestIDFooter bool
 }
 
 // CreateServer creates and configures the MCP server
 func CreateServer(cfg ServerConfig) *mcp.Server {
+	var opts *mcp.ServerOptions
+	if cfg.GitReposSvc != nil && cfg.IndexUpdateNotify == config.IndexUpdateNotifyResources {
+		opts = &mcp.ServerOptions{
+			SubscribeHandler:   subscribeIndexStatus,
+			UnsubscribeHandler: unsubscribeIndexStatus,
+		}
+	}
+	if cfg.GitReposSvc == nil && cfg.GitReposError != "" {
+		if opts == nil {
+			opts = &mcp.ServerOptions{}
+		}
+		opts.Instructions = fmt.Sprintf("Code search is unavailable on this server: %s. The search, read and other repository tools are not registered.", cfg.GitReposError)
+	}
 	s := mcp.NewServer(&mcp.Implementation{
 		Name:    cfg.Name,
 		Version: cfg.Version,
-	}, nil)
+	}, opts)
+
+	var tools []string
 
 	// Register git repos tools if service is provided
 	if cfg.GitReposSvc != nil {
-		gitrepos.RegisterSearchTool(s, cfg.GitReposSvc)
-		gitrepos.RegisterReadTool(s, cfg.GitReposSvc)
+		registerIndexTools(s, cfg.GitReposSvc)
+		gitrepos.RegisterSearchHistoryTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterStatsTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterRepoMapTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterListFilesTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterReindexTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterUpdateRepositoriesTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterFilterReportTool(s, cfg.GitReposSvc)
+		gitrepos.RegisterQuerySyntaxResource(s, cfg.GitReposSvc)
+		registerIndexStatusResource(s, cfg.GitReposSvc)
+		s.AddReceivingMiddleware(gitrepos.ConsistencyMiddleware(cfg.GitReposSvc))
+		// Added after the consistency middleware so that plain text results
+		// include its generation note
+		s.AddReceivingMiddleware(gitrepos.FormatMiddleware())
+		// Added last so that it runs first: calls are stamped with the
+		// generation they were eventually served from
+		s.AddReceivingMiddleware(gitrepos.ProgressMiddleware(cfg.GitReposSvc))
+		s.AddReceivingMiddleware(gitrepos.ToolListMiddleware(cfg.GitReposSvc))
+		tools = append(tools, "search", "read", "search_in_file", "get_readme", "search_history", "repo_stats", "repo_map", "list_files", "reindex", "update_repositories", "filter_report")
+	}
+
+	if cfg.Report != nil {
+		RegisterVersionTool(s, cfg.Report)
+		tools = append(tools, "version")
 	}
 
+	s.AddReceivingMiddleware(ProtocolVersionMiddleware())
+	// Added last so that it runs first and the other middleware logs with
+	// the request ID
+	s.AddReceivingMiddleware(RequestIDMiddleware(cfg.RequestIDFooter))
+
+	tools = append(tools, "server_info")
+	RegisterServerInfoTool(s, ServerInfo{
+		Name:               cfg.Name,
+		Version:            cfg.Version,
+		Build:              cfg.Build,
+		IndexSchemaVersion: gitrepos.IndexMappingVersion,
+		Platform:           gitrepos.CurrentPlatform(),
+		Tools:              tools,
+		GitReposError:      cfg.GitReposError,
+		Features: map[string]bool{
+			FeatureConsistencyTokens: cfg.GitReposSvc != nil,
+			FeatureCaseSensitive:     cfg.GitReposSvc != nil,
+			FeatureWholeWord:         cfg.GitReposSvc != nil,
+			FeatureGrep:              false,
+			FeatureSemanticSearch:    false,
+			FeatureIncludeGenerated:  cfg.GitReposSvc != nil,
+			FeatureRefs:              cfg.GitReposSvc != nil,
+			FeatureDirectories:       cfg.GitReposSvc != nil,
+			FeatureSearchStreaming:   cfg.GitReposSvc != nil,
+			FeatureRequireFresh:      cfg.GitReposSvc != nil,
+			FeatureRequestIDs:        true,
+		},
+	})
+
 	return s
 }
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+// Feature names reported by the server_info tool.
+const (
+	FeatureConsistencyTokens = "consistency_tokens"
+	FeatureCaseSensitive     = "case_sensitive"
+	FeatureWholeWord         = "whole_word"
+	FeatureGrep              = "grep"
+	FeatureSemanticSearch    = "semantic_search"
+	FeatureIncludeGenerated  = "include_generated"
+	FeatureRefs              = "refs"
+	FeatureDirectories       = "directories"
+	FeatureSearchStreaming   = "search_streaming"
+	FeatureRequireFresh      = "require_fresh"
+	FeatureRequestIDs        = "request_ids"
+)
+
+// ServerInfo describes the capabilities of the running server so that clients
+// can feature-detect instead of guessing from the version.
+type ServerInfo struct {
+	Name               string            `json:"name"`
+	Version            string            `json:"version"`
+	Build              string            `json:"build,omitempty"`
+	IndexSchemaVersion int               `json:"index_schema_version"`
+	Platform           gitrepos.Platform `json:"platform"`
+	Tools              []string          `json:"tools"`
+	GitReposError      string            `json:"git_repos_error,omitempty"` // why the repository tools are not registered
+	Features           map[string]bool   `json:"features"`
+}
+
+// ServerInfoArgument defines server_info parameters (none).
+type ServerInfoArgument struct{}
+
+// ServerInfoHandler handles the server_info MCP tool.
+type ServerInfoHandler struct {
+	info ServerInfo
+}
+
+// NewServerInfoHandler creates a server_info handler returning info.
+func NewServerInfoHandler(info ServerInfo) *ServerInfoHandler {
+	return &ServerInfoHandler{
+		info: info,
+	}
+}
+
+// Handle returns the server info as structured content, with a JSON copy in
+// the text content for clients that ignore structured output.
+func (h *ServerInfoHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args ServerInfoArgument) (*mcp.CallToolResult, ServerInfo, error) {
+	data, err := json.MarshalIndent(h.info, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Failed to encode server info: %v", err)},
+			},
+			IsError: true,
+		}, ServerInfo{}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(data)},
+		},
+	}, h.info, nil
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *ServerInfoHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "server_info",
+		Description: `Describe the capabilities of this server.
+
+WHEN TO USE: Use before relying on an optional tool, argument or behavior,
+especially when several server versions may be deployed.
+
+HOW IT WORKS: Returns the server name, version and build, the index schema
+version, the platform (OS, architecture, Go version, cgo, index engine), the
+registered tools and a map of supported features
+(consistency_tokens, case_sensitive, whole_word, grep, semantic_search). When
+code search failed to start, git_repos_error tells why its tools are missing.`,
+	}
+}
+
+// RegisterServerInfoTool registers the server_info tool with an MCP server.
+func RegisterServerInfoTool(server *mcp.Server, info ServerInfo) {
+	handler := NewServerInfoHandler(info)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
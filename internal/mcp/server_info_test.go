@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
+)
+
+func TestServerInfoHandler_Handle(t *testing.T) {
+	info := ServerInfo{
+		Name:               "relic-mcp",
+		Version:            "1.2.3",
+		IndexSchemaVersion: gitrepos.IndexMappingVersion,
+		Platform:           gitrepos.Platform{OS: "windows", Arch: "arm64", GoVersion: "go1.25.0", IndexEngine: "bleve (scorch)"},
+		Tools:              []string{"server_info"},
+		Features:           map[string]bool{FeatureGrep: false},
+	}
+	handler := NewServerInfoHandler(info)
+
+	result, out, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, ServerInfoArgument{})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Unexpected error result: %+v", result)
+	}
+	if out.Version != "1.2.3" {
+		t.Errorf("Expected version 1.2.3, got %q", out.Version)
+	}
+
+	var decoded ServerInfo
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &decoded); err != nil {
+		t.Fatalf("Text content is not valid JSON: %v", err)
+	}
+	if decoded.IndexSchemaVersion != gitrepos.IndexMappingVersion {
+		t.Errorf("Expected index schema version %d, got %d", gitrepos.IndexMappingVersion, decoded.IndexSchemaVersion)
+	}
+	if decoded.Platform != info.Platform {
+		t.Errorf("Expected platform %+v, got %+v", info.Platform, decoded.Platform)
+	}
+}
+
+func TestServerInfoHandler_GetToolDefinition(t *testing.T) {
+	tool := NewServerInfoHandler(ServerInfo{}).GetToolDefinition()
+	if tool.Name != "server_info" {
+		t.Errorf("Expected tool name 'server_info', got '%s'", tool.Name)
+	}
+}
+
+func TestCreateServer_ServerInfoListsRegisteredTools(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ServerConfig
+	}{
+		{
+			name: "without git repos service",
+			cfg:  ServerConfig{Name: "test-server", Version: "1.0.0"},
+		},
+		{
+			name: "with git repos service and report",
+			cfg: ServerConfig{
+				Name:        "test-server",
+				Version:     "1.0.0",
+				Build:       "abc",
+				GitReposSvc: &mockGitReposToolService{ready: true, maxResults: 20},
+				Report:      func() string { return "report" },
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := CreateServer(tt.cfg)
+			serverTransport, clientTransport := mcp.NewInMemoryTransports()
+			serverSession, err := server.Connect(ctx, serverTransport, nil)
+			if err != nil {
+				t.Fatalf("Server connect failed: %v", err)
+			}
+			defer func() { _ = serverSession.Close() }()
+
+			client := mcp.NewClient(&mcp.Implementation{Name: "test-client"}, nil)
+			session, err := client.Connect(ctx, clientTransport, nil)
+			if err != nil {
+				t.Fatalf("Client connect failed: %v", err)
+			}
+			defer func() { _ = session.Close() }()
+
+			listed, err := session.ListTools(ctx, nil)
+			if err != nil {
+				t.Fatalf("ListTools failed: %v", err)
+			}
+			var registered []string
+			for _, tool := range listed.Tools {
+				registered = append(registered, tool.Name)
+			}
+
+			result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "server_info"})
+			if err != nil {
+				t.Fatalf("CallTool failed: %v", err)
+			}
+			var info ServerInfo
+			if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &info); err != nil {
+				t.Fatalf("Invalid server_info output: %v", err)
+			}
+
+			slices.Sort(registered)
+			reported := slices.Clone(info.Tools)
+			slices.Sort(reported)
+			if !slices.Equal(registered, reported) {
+				t.Errorf("Reported tools %v do not match registered tools %v", reported, registered)
+			}
+			if info.Features[FeatureConsistencyTokens] != (tt.cfg.GitReposSvc != nil) {
+				t.Errorf("Unexpected consistency_tokens feature: %v", info.Features)
+			}
+			if info.Build != tt.cfg.Build {
+				t.Errorf("Expected build %q, got %q", tt.cfg.Build, info.Build)
+			}
+
+			if tt.cfg.GitReposSvc != nil {
+				resources, err := session.ListResources(ctx, nil)
+				if err != nil {
+					t.Fatalf("ListResources failed: %v", err)
+				}
+				var uris []string
+				for _, resource := range resources.Resources {
+					uris = append(uris, resource.URI)
+				}
+				slices.Sort(uris)
+				if !slices.Equal(uris, []string{gitrepos.QuerySyntaxURI, IndexStatusURI}) {
+					t.Errorf("Expected the index status and query syntax resources, got %v", uris)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package mcp
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/sha1n/mcp-relic-server/internal/gitrepos"
 )
 
 // mockGitReposToolService implements GitReposToolService for testing.
@@ -17,13 +20,67 @@ type mockGitReposToolService struct {
 	maxFileSize int64
 }
 
-func (m *mockGitReposToolService) IsReady() bool { return m.ready }
-func (m *mockGitReposToolService) GetIndexAlias() (bleve.IndexAlias, error) {
+func (m *mockGitReposToolService) IsReady() bool             { return m.ready }
+func (m *mockGitReposToolService) IsRepoReady(_ string) bool { return m.ready }
+func (m *mockGitReposToolService) PendingRepos() []string    { return nil }
+func (m *mockGitReposToolService) HistoryAlias() (bleve.IndexAlias, error) {
+	return nil, fmt.Errorf("history is not indexed")
+}
+func (m *mockGitReposToolService) HistoryCommit(_, _ string) (gitrepos.HistoryCommit, bool) {
+	return gitrepos.HistoryCommit{}, false
+}
+func (m *mockGitReposToolService) CheckFreshness(_ context.Context, _ string) []gitrepos.RepoFreshness {
+	return nil
+}
+func (m *mockGitReposToolService) AcquireIndexAlias() (bleve.IndexAlias, func(), error) {
+	return m.alias, func() {}, m.aliasErr
+}
+func (m *mockGitReposToolService) RefAlias(_ string) (bleve.IndexAlias, error) {
 	return m.alias, m.aliasErr
 }
-func (m *mockGitReposToolService) MaxResults() int            { return m.maxResults }
-func (m *mockGitReposToolService) GetRepoDir(_ string) string { return m.repoDir }
-func (m *mockGitReposToolService) MaxFileSize() int64         { return m.maxFileSize }
+func (m *mockGitReposToolService) MaxResults() int                  { return m.maxResults }
+func (m *mockGitReposToolService) HighlightTags() (string, string)  { return "**", "**" }
+func (m *mockGitReposToolService) GetRepoDir(_ string) string       { return m.repoDir }
+func (m *mockGitReposToolService) MaxFileSize() int64               { return m.maxFileSize }
+func (m *mockGitReposToolService) MaxFileSizeFor(_ string) int64    { return m.maxFileSize }
+func (m *mockGitReposToolService) FollowSymlinks() bool             { return false }
+func (m *mockGitReposToolService) ReadDenied(_ string) bool         { return false }
+func (m *mockGitReposToolService) Redact(c []byte) ([]byte, int)    { return c, 0 }
+func (m *mockGitReposToolService) ReadIndexedOnly() bool            { return false }
+func (m *mockGitReposToolService) IsIndexed(_, _ string) bool       { return true }
+func (m *mockGitReposToolService) IndexedCommit(_, _ string) string { return "" }
+func (m *mockGitReposToolService) Telemetry() *gitrepos.SearchTelemetry {
+	return nil
+}
+func (m *mockGitReposToolService) QueryExperiment() string { return "" }
+func (m *mockGitReposToolService) RankingProfile() string  { return "" }
+func (m *mockGitReposToolService) RecentFiles() []string   { return nil }
+func (m *mockGitReposToolService) ExtensionGroup(ext string) []string {
+	return []string{ext}
+}
+func (m *mockGitReposToolService) RepoStates() map[string]gitrepos.RepoState {
+	return nil
+}
+func (m *mockGitReposToolService) Reindex(_ context.Context, _ string) error { return nil }
+func (m *mockGitReposToolService) UpdateRepositories(_ context.Context, _, _ []string) (*gitrepos.RepositoryUpdate, error) {
+	return &gitrepos.RepositoryUpdate{}, nil
+}
+func (m *mockGitReposToolService) ExcludePatterns() []string                        { return nil }
+func (m *mockGitReposToolService) CatalogSummary(_ string) *gitrepos.CatalogSummary { return nil }
+func (m *mockGitReposToolService) CatalogFiles(_, _ string) []gitrepos.CatalogFile  { return nil }
+func (m *mockGitReposToolService) AcquireSearch(_ context.Context) (func(), error) {
+	return func() {}, nil
+}
+func (m *mockGitReposToolService) AcquireRead(_ context.Context) (func(), error) {
+	return func() {}, nil
+}
+func (m *mockGitReposToolService) SearchTimeout() time.Duration      { return 0 }
+func (m *mockGitReposToolService) ReadTimeout() time.Duration        { return 0 }
+func (m *mockGitReposToolService) Generation() uint64                { return 1 }
+func (m *mockGitReposToolService) IndexedCommits() map[string]string { return nil }
+func (m *mockGitReposToolService) IndexProgress() gitrepos.IndexProgress {
+	return gitrepos.IndexProgress{}
+}
 
 func TestCreateServer(t *testing.T) {
 	cfg := ServerConfig{
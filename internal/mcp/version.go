@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// VersionArgument defines version parameters (none).
+type VersionArgument struct{}
+
+// VersionHandler handles the version MCP tool.
+type VersionHandler struct {
+	report func() string
+}
+
+// NewVersionHandler creates a version handler returning the output of report.
+func NewVersionHandler(report func() string) *VersionHandler {
+	return &VersionHandler{
+		report: report,
+	}
+}
+
+// Handle returns the server self-report.
+func (h *VersionHandler) Handle(ctx context.Context, req *mcp.CallToolRequest, args VersionArgument) (*mcp.CallToolResult, any, error) {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: h.report()},
+		},
+	}, nil, nil
+}
+
+// GetToolDefinition returns the MCP tool definition.
+func (h *VersionHandler) GetToolDefinition() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "version",
+		Description: `Show the server version and environment.
+
+WHEN TO USE: Use when reporting a problem with this server, or to check which
+version is running.
+
+HOW IT WORKS: Returns the version and build, transport, auth type, number of
+configured repositories, base directory, index disk usage and git version.`,
+	}
+}
+
+// RegisterVersionTool registers the version tool with an MCP server.
+func RegisterVersionTool(server *mcp.Server, report func() string) {
+	handler := NewVersionHandler(report)
+	mcp.AddTool(server, handler.GetToolDefinition(), handler.Handle)
+}
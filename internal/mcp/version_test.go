@@ -0,0 +1,27 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestVersionHandler_Handle(t *testing.T) {
+	handler := NewVersionHandler(func() string { return "- Version: 1.2.3" })
+
+	result, _, err := handler.Handle(context.Background(), &mcp.CallToolRequest{}, VersionArgument{})
+	if err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+	if result.IsError || len(result.Content) != 1 || result.Content[0].(*mcp.TextContent).Text != "- Version: 1.2.3" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestVersionHandler_GetToolDefinition(t *testing.T) {
+	tool := NewVersionHandler(nil).GetToolDefinition()
+	if tool.Name != "version" {
+		t.Errorf("Expected tool name 'version', got '%s'", tool.Name)
+	}
+}
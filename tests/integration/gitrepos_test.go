@@ -207,10 +207,11 @@ func TestIndex_FullIndexCreateSearchableIndex(t *testing.T) {
 	defer closeService(t, svc)
 
 	// Search should find content
-	alias, err := svc.GetIndexAlias()
+	alias, release, err := svc.AcquireIndexAlias()
 	if err != nil {
-		t.Fatalf("GetIndexAlias failed: %v", err)
+		t.Fatalf("AcquireIndexAlias failed: %v", err)
 	}
+	defer release()
 
 	// Perform a simple search
 	searchReq := bleve.NewSearchRequest(bleve.NewMatchQuery("hello"))
@@ -270,10 +271,11 @@ func TestIndex_MultipleReposCreateCombinedAlias(t *testing.T) {
 	}
 
 	// Search should find content from both repos
-	alias, err := svc.GetIndexAlias()
+	alias, release, err := svc.AcquireIndexAlias()
 	if err != nil {
-		t.Fatalf("GetIndexAlias failed: %v", err)
+		t.Fatalf("AcquireIndexAlias failed: %v", err)
 	}
+	defer release()
 
 	searchReq := bleve.NewSearchRequest(bleve.NewMatchQuery("Main"))
 	searchReq.Size = 20
@@ -378,7 +380,7 @@ func TestSearchTool_SearchWithExtensionFilter(t *testing.T) {
 	// Search with .go extension
 	result, _, err := handler.Handle(ctx, &mcp.CallToolRequest{}, gitrepos.SearchArgument{
 		Query:     "main",
-		Extension: "go",
+		Extension: gitrepos.ExtensionList{"go"},
 	})
 	if err != nil {
 		t.Fatalf("Handle returned error: %v", err)
@@ -391,7 +393,7 @@ func TestSearchTool_SearchWithExtensionFilter(t *testing.T) {
 	// Search with .py extension (with dot prefix)
 	result, _, err = handler.Handle(ctx, &mcp.CallToolRequest{}, gitrepos.SearchArgument{
 		Query:     "main",
-		Extension: ".py",
+		Extension: gitrepos.ExtensionList{".py"},
 	})
 	if err != nil {
 		t.Fatalf("Handle returned error: %v", err)
@@ -543,6 +545,52 @@ func TestReadTool_ReadFileReturnsContent(t *testing.T) {
 	}
 }
 
+func TestReadTool_PlainFormatOverMCP(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+
+	svc := setupTestService(t, dir, files)
+	defer closeService(t, svc)
+
+	server := mcputil.CreateServer(mcputil.ServerConfig{
+		Name:        "test-server",
+		Version:     "1.0.0",
+		GitReposSvc: svc,
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ctx := context.Background()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("Failed to connect server: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "1.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect client: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "read",
+		Arguments: map[string]any{
+			"repository": "github.com/test/repo",
+			"path":       "main.go",
+			"format":     map[string]any{"plain": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	content := gitrepos.ExtractTextContent(result)
+	if result.IsError || !strings.Contains(content, "github.com/test/repo main.go @ abc123\n\npackage main") {
+		t.Errorf("Expected plain file content, got: %s", content)
+	}
+	if strings.Contains(content, "```") || strings.Contains(content, "**") {
+		t.Errorf("Expected no markdown, got: %s", content)
+	}
+}
+
 func TestReadTool_ReadWithInvalidRepoReturnsError(t *testing.T) {
 	dir := t.TempDir()
 	files := map[string]string{
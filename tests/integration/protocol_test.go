@@ -0,0 +1,271 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sha1n/mcp-relic-server/internal/app"
+	"github.com/sha1n/mcp-relic-server/internal/config"
+	mcputil "github.com/sha1n/mcp-relic-server/internal/mcp"
+)
+
+// latestProtocolVersion is the version the SDK answers unsupported versions with
+const latestProtocolVersion = "2025-06-18"
+
+// ========================================
+// Protocol Tests (stdio)
+// ========================================
+
+func TestProtocol_VersionNegotiation(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{"2024-11-05", "2024-11-05"},
+		{"2025-03-26", "2025-03-26"},
+		{"2025-06-18", "2025-06-18"},
+		{"2099-01-01", latestProtocolVersion},
+		{"not-a-version", latestProtocolVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			session := startRawSession(t)
+			if got := session.initialize(t, tt.requested); got != tt.want {
+				t.Errorf("Expected protocol version %s, got %s", tt.want, got)
+			}
+			session.send(t, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+			if resp := session.receive(t); !strings.Contains(resp, `"server_info"`) {
+				t.Errorf("Expected the tool list, got %s", resp)
+			}
+		})
+	}
+}
+
+func TestProtocol_MalformedInputKeepsSession(t *testing.T) {
+	session := startRawSession(t)
+	session.initialize(t, latestProtocolVersion)
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"not JSON", `{"jsonrpc":"2.0",`, `{"error":{"code":-32700,"message":"Parse error: invalid JSON"},"id":null,"jsonrpc":"2.0"}`},
+		{"no version tag", `{"id":7,"method":"tools/list"}`, `{"error":{"code":-32600,"message":"Invalid request: invalid message version tag \"\"; expected \"2.0\""},"id":7,"jsonrpc":"2.0"}`},
+		{"empty batch", `[]`, `{"error":{"code":-32600,"message":"Invalid request: empty batch"},"id":null,"jsonrpc":"2.0"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session.send(t, tt.line)
+			resp := session.receive(t)
+			if resp != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, resp)
+			}
+		})
+	}
+
+	session.send(t, `{"jsonrpc":"2.0","id":8,"method":"tools/list"}`)
+	if resp := session.receive(t); !strings.Contains(resp, `"id":8`) || !strings.Contains(resp, `"server_info"`) {
+		t.Errorf("Expected the session to keep working, got %s", resp)
+	}
+}
+
+func TestProtocol_Batches(t *testing.T) {
+	batch := `[{"jsonrpc":"2.0","id":"a","method":"tools/list"},{"jsonrpc":"2.0","id":"b","method":"ping"}]`
+
+	t.Run("answered as a batch before 2025-06-18", func(t *testing.T) {
+		session := startRawSession(t)
+		session.initialize(t, "2025-03-26")
+		session.send(t, batch)
+
+		var responses []map[string]any
+		resp := session.receive(t)
+		if err := json.Unmarshal([]byte(resp), &responses); err != nil || len(responses) != 2 {
+			t.Fatalf("Expected an array of 2 responses, got %s", resp)
+		}
+	})
+
+	t.Run("split from 2025-06-18", func(t *testing.T) {
+		session := startRawSession(t)
+		session.initialize(t, "2025-06-18")
+		session.send(t, batch)
+
+		ids := map[string]bool{}
+		for range 2 {
+			var resp struct {
+				ID     string          `json:"id"`
+				Result json.RawMessage `json:"result"`
+			}
+			line := session.receive(t)
+			if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Result == nil {
+				t.Fatalf("Expected a single result, got %s", line)
+			}
+			ids[resp.ID] = true
+		}
+		if !ids["a"] || !ids["b"] {
+			t.Errorf("Expected responses to a and b, got %v", ids)
+		}
+	})
+}
+
+// ========================================
+// Protocol Tests (SSE)
+// ========================================
+
+func TestProtocol_SSEBatches(t *testing.T) {
+	server := mcputil.CreateServer(mcputil.ServerConfig{Name: "test-server", Version: "1.0.0"})
+	srv, err := app.NewSSEServer(server, &config.Settings{Auth: config.AuthSettings{Type: config.AuthTypeNone}})
+	if err != nil {
+		t.Fatalf("NewSSEServer failed: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the event stream: %v", err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+	events := readSSEEvents(stream.Body)
+	endpoint := ts.URL + receiveLine(t, events)
+
+	post := func(body string) *http.Response {
+		resp, err := http.Post(endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	if resp := post(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected initialize to be accepted, got %d", resp.StatusCode)
+	}
+	if msg := receiveLine(t, events); !strings.Contains(msg, `"protocolVersion":"2024-11-05"`) {
+		t.Fatalf("Unexpected initialize response: %s", msg)
+	}
+
+	resp := post(`[{"jsonrpc":"2.0","method":"notifications/initialized","params":{}},{"jsonrpc":"2.0","id":2,"method":"tools/list"}]`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected the batch to be accepted, got %d", resp.StatusCode)
+	}
+	if msg := receiveLine(t, events); !strings.Contains(msg, `"id":2`) || !strings.Contains(msg, `"server_info"`) {
+		t.Errorf("Expected the tool list, got %s", msg)
+	}
+
+	if resp := post(`{"jsonrpc":`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected malformed JSON to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+// ========================================
+// Helper Functions
+// ========================================
+
+// rawSession exchanges raw JSON-RPC lines with a server over the guarded
+// stdio transport.
+type rawSession struct {
+	in    *io.PipeWriter
+	lines <-chan string
+}
+
+func startRawSession(t *testing.T) *rawSession {
+	t.Helper()
+	server := mcputil.CreateServer(mcputil.ServerConfig{Name: "test-server", Version: "1.0.0"})
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = server.Run(ctx, mcputil.NewGuardedIOTransport(inReader, outWriter))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		_ = inWriter.Close()
+		_ = outReader.Close()
+		<-done
+	})
+
+	lines := make(chan string, 16)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(outReader)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return &rawSession{in: inWriter, lines: lines}
+}
+
+func (s *rawSession) send(t *testing.T, line string) {
+	t.Helper()
+	if _, err := fmt.Fprintln(s.in, line); err != nil {
+		t.Fatalf("Failed to send %s: %v", line, err)
+	}
+}
+
+func (s *rawSession) receive(t *testing.T) string {
+	t.Helper()
+	return receiveLine(t, s.lines)
+}
+
+// initialize completes the handshake and returns the negotiated version.
+func (s *rawSession) initialize(t *testing.T, version string) string {
+	t.Helper()
+	s.send(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":%q,"capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`, version))
+	var resp struct {
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		} `json:"result"`
+	}
+	line := s.receive(t)
+	if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Result.ProtocolVersion == "" {
+		t.Fatalf("Unexpected initialize response: %s", line)
+	}
+	s.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`)
+	return resp.Result.ProtocolVersion
+}
+
+func receiveLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line, ok := <-lines:
+		if !ok {
+			t.Fatal("Connection closed")
+		}
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a message")
+	}
+	return ""
+}
+
+// readSSEEvents returns the data of the events of an SSE stream.
+func readSSEEvents(r io.Reader) <-chan string {
+	events := make(chan string, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+	}()
+	return events
+}